@@ -0,0 +1,145 @@
+package action
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+)
+
+// BlackoutLabel container label with freeze windows of service owner, when container is skipped by chaos:
+// windows, separated by ';', of optional days and optional time range (end minute inclusive), e.g.
+// 'Sat,Sun 00:00-23:59', 'Mon-Fri 18:00-08:00' (overnight) or 'Fri; 12:00-13:00'
+const BlackoutLabel = "com.gaiaadm.pumba.blackout"
+
+// Now returns current time, freeze windows are evaluated at; virtual time in simulation
+var Now = time.Now
+
+// weekdays day names by time.Weekday
+var weekdays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// minutesPerDay number of minutes in a day; time of day is counted in minutes since midnight
+const minutesPerDay = 24 * 60
+
+// blackoutWindow freeze window: days (by time.Weekday) and time range in minutes since midnight, end inclusive;
+// time range with start after end spans midnight and starts on one of days
+type blackoutWindow struct {
+	days       [7]bool
+	start, end int
+}
+
+// contains reports whether time is in freeze window
+func (w blackoutWindow) contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	day := int(t.Weekday())
+	if w.start <= w.end {
+		return w.days[day] && m >= w.start && m <= w.end
+	}
+	return (w.days[day] && m >= w.start) || (w.days[(day+6)%7] && m <= w.end)
+}
+
+// parseBlackout parses freeze windows of BlackoutLabel
+func parseBlackout(value string) ([]blackoutWindow, error) {
+	var windows []blackoutWindow
+	for _, item := range strings.Split(value, ";") {
+		fields := strings.Fields(item)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("Invalid freeze window '%s': must be '[days] [HH:MM-HH:MM]'", strings.TrimSpace(item))
+		}
+		w := blackoutWindow{days: [7]bool{true, true, true, true, true, true, true}, start: 0, end: minutesPerDay - 1}
+		days, times := fields[0], ""
+		if len(fields) == 2 {
+			times = fields[1]
+		} else if strings.Contains(days, ":") {
+			days, times = "", days
+		}
+		var err error
+		if days != "" {
+			if w.days, err = parseDays(days); err != nil {
+				return nil, err
+			}
+		}
+		if times != "" {
+			if w.start, w.end, err = parseTimeRange(times); err != nil {
+				return nil, err
+			}
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+// parseDays parses comma-separated day names and day ranges, e.g. 'Sat,Sun' or 'Mon-Fri'
+func parseDays(value string) ([7]bool, error) {
+	var days [7]bool
+	for _, item := range strings.Split(value, ",") {
+		bounds := strings.SplitN(item, "-", 2)
+		first, err := parseDay(bounds[0])
+		if err != nil {
+			return days, err
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, err = parseDay(bounds[1]); err != nil {
+				return days, err
+			}
+		}
+		// day range may wrap around the week, e.g. 'Fri-Mon'
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// parseDay parses day name, full or 3-letter abbreviation, case-insensitive
+func parseDay(value string) (int, error) {
+	name := strings.ToLower(value)
+	for d, day := range weekdays {
+		if name == day || name == day[:3] {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("Invalid day '%s': must be day name, e.g. 'Mon' or 'Saturday'", value)
+}
+
+// parseTimeRange parses time range 'HH:MM-HH:MM' into minutes since midnight
+func parseTimeRange(value string) (int, int, error) {
+	bounds := strings.SplitN(value, "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, fmt.Errorf("Invalid time range '%s': must be 'HH:MM-HH:MM'", value)
+	}
+	var minutes [2]int
+	for i, bound := range bounds {
+		t, err := time.Parse("15:04", bound)
+		if err != nil {
+			return 0, 0, fmt.Errorf("Invalid time range '%s': must be 'HH:MM-HH:MM'", value)
+		}
+		minutes[i] = t.Hour()*60 + t.Minute()
+	}
+	return minutes[0], minutes[1], nil
+}
+
+// blackout returns skip reason of container in freeze window of its BlackoutLabel at specified time, or with invalid
+// label; empty - container is not in freeze window
+func blackout(c container.Container, t time.Time) string {
+	value, ok := c.Label(BlackoutLabel)
+	if !ok {
+		return ""
+	}
+	windows, err := parseBlackout(value)
+	if err != nil {
+		// opt-out label is honored even if it is invalid
+		return fmt.Sprintf("invalid '%s' label: %s", BlackoutLabel, err)
+	}
+	for _, w := range windows {
+		if w.contains(t) {
+			return fmt.Sprintf("freeze window '%s' ('%s' label)", strings.TrimSpace(value), BlackoutLabel)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,82 @@
+package action
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// makeBlackoutContainer returns container with blackout label
+// day returns time on day of the first week of 2017 (Sunday, January 1) at hour and minute
+func day(weekday time.Weekday, hour, minute int) time.Time {
+	return time.Date(2017, 1, 1+int(weekday), hour, minute, 30, 0, time.Local)
+}
+
+func TestParseBlackout(t *testing.T) {
+	for _, tt := range []struct {
+		windows string
+		in      []time.Time
+		out     []time.Time
+	}{
+		{"Sat,Sun 00:00-23:59", []time.Time{day(time.Saturday, 0, 0), day(time.Sunday, 23, 59)}, []time.Time{day(time.Friday, 23, 59), day(time.Monday, 0, 0)}},
+		{"Mon-Fri 18:00-08:00", []time.Time{day(time.Monday, 18, 0), day(time.Tuesday, 8, 0), day(time.Saturday, 7, 0)}, []time.Time{day(time.Monday, 7, 0), day(time.Wednesday, 8, 1), day(time.Saturday, 18, 0)}},
+		{"fri-mon", []time.Time{day(time.Sunday, 12, 0), day(time.Friday, 0, 0)}, []time.Time{day(time.Tuesday, 12, 0)}},
+		{"Friday; 12:00-13:00", []time.Time{day(time.Friday, 9, 0), day(time.Monday, 12, 30)}, []time.Time{day(time.Monday, 13, 1)}},
+	} {
+		windows, err := parseBlackout(tt.windows)
+		if !assert.NoError(t, err, tt.windows) {
+			continue
+		}
+		contains := func(t time.Time) bool {
+			for _, w := range windows {
+				if w.contains(t) {
+					return true
+				}
+			}
+			return false
+		}
+		for _, in := range tt.in {
+			assert.True(t, contains(in), "%s: %s", tt.windows, in)
+		}
+		for _, out := range tt.out {
+			assert.False(t, contains(out), "%s: %s", tt.windows, out)
+		}
+	}
+	for windows, expected := range map[string]string{
+		"":                         "Invalid freeze window '': must be '[days] [HH:MM-HH:MM]'",
+		"Sat Sun 00:00-23:59":      "Invalid freeze window 'Sat Sun 00:00-23:59': must be '[days] [HH:MM-HH:MM]'",
+		"Sat,Holiday":              "Invalid day 'Holiday': must be day name, e.g. 'Mon' or 'Saturday'",
+		"Sat 10:00":                "Invalid time range '10:00': must be 'HH:MM-HH:MM'",
+		"Sat 10:00-25:00":          "Invalid time range '10:00-25:00': must be 'HH:MM-HH:MM'",
+		"Sat 00:00-23:59; Sun 9am": "Invalid time range '9am': must be 'HH:MM-HH:MM'",
+	} {
+		_, err := parseBlackout(windows)
+		assert.EqualError(t, err, expected, windows)
+	}
+}
+
+func TestListContainers_Blackout(t *testing.T) {
+	frozen := container.NewMockContainer("", "frozen", map[string]string{BlackoutLabel: "Sat,Sun 00:00-23:59"})
+	open := container.NewMockContainer("", "open", map[string]string{BlackoutLabel: "Mon-Fri 18:00-08:00"})
+	invalid := container.NewMockContainer("", "invalid", map[string]string{BlackoutLabel: "weekends"})
+	plain := *container.NewContainer(&dockerclient.ContainerInfo{Name: "plain", Config: &dockerclient.ContainerConfig{}}, nil)
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{frozen, open, invalid, plain}, nil)
+	Now = func() time.Time { return day(time.Saturday, 12, 0) }
+	defer func() { Now = time.Now }()
+	skips := map[string]string{}
+	defer recordSkips(skips)()
+
+	cs, err := Pumba{}.listContainers(client, nil, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []container.Container{open, plain}, cs)
+	assert.Equal(t, map[string]string{
+		"frozen":  "freeze window 'Sat,Sun 00:00-23:59' ('com.gaiaadm.pumba.blackout' label)",
+		"invalid": "invalid 'com.gaiaadm.pumba.blackout' label: Invalid day 'weekends': must be day name, e.g. 'Mon' or 'Saturday'",
+	}, skips)
+}
@@ -0,0 +1,114 @@
+package action
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/gaia-adm/pumba/container"
+)
+
+// MaxBlast - maximum number of containers affected by single chaos action; 0 - no limit
+var MaxBlast = 0
+
+// Kubernetes labels, added by kubelet to pod containers
+const (
+	k8sNamespaceLabel = "io.kubernetes.pod.namespace"
+	k8sContainerLabel = "io.kubernetes.container.name"
+)
+
+// BlastRadius estimated impact of chaos action on matching containers
+type BlastRadius struct {
+	// Matched number of containers matching chaos command targets
+	Matched int
+	// Affected number of containers, that chaos action will affect (single one in random mode)
+	Affected int
+	// Images unique images of matched containers
+	Images []string
+	// Services unique services (docker-compose services, Kubernetes containers or container names)
+	Services []string
+	// Projects unique docker-compose projects
+	Projects []string
+	// Namespaces unique Kubernetes namespaces
+	Namespaces []string
+}
+
+// String returns blast radius description
+func (b BlastRadius) String() string {
+	s := fmt.Sprintf("%d of %d matching containers", b.Affected, b.Matched)
+	for _, part := range []struct {
+		name   string
+		values []string
+	}{
+		{"images", b.Images},
+		{"services", b.Services},
+		{"compose projects", b.Projects},
+		{"k8s namespaces", b.Namespaces},
+	} {
+		if len(part.values) > 0 {
+			s += fmt.Sprintf("; %s: %s", part.name, strings.Join(part.values, ", "))
+		}
+	}
+	return s
+}
+
+// EstimateBlastRadius estimates blast radius of chaos action on containers; in random mode single container is affected
+func EstimateBlastRadius(containers []container.Container, random bool) BlastRadius {
+	b := BlastRadius{Matched: len(containers), Affected: len(containers)}
+	if random && b.Affected > 1 {
+		b.Affected = 1
+	}
+	images, services, projects, namespaces := map[string]bool{}, map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, c := range containers {
+		if image := c.ImageName(); image != "" {
+			images[image] = true
+		}
+		services[service(c)] = true
+		if project := c.ComposeProject(); project != "" {
+			projects[project] = true
+		}
+		if namespace, ok := c.Label(k8sNamespaceLabel); ok && namespace != "" {
+			namespaces[namespace] = true
+		}
+	}
+	b.Images, b.Services, b.Projects, b.Namespaces = sortedKeys(images), sortedKeys(services), sortedKeys(projects), sortedKeys(namespaces)
+	return b
+}
+
+// checkBlastRadius logs blast radius of chaos action and fails when it exceeds MaxBlast
+func checkBlastRadius(containers []container.Container, random bool) error {
+	b := EstimateBlastRadius(containers, random)
+	log.WithFields(log.Fields{
+		"matched":    b.Matched,
+		"affected":   b.Affected,
+		"images":     strings.Join(b.Images, ","),
+		"services":   strings.Join(b.Services, ","),
+		"projects":   strings.Join(b.Projects, ","),
+		"namespaces": strings.Join(b.Namespaces, ","),
+	}).Infof("Blast radius: %s", b)
+	if MaxBlast > 0 && b.Affected > MaxBlast {
+		return &ErrBlastRadius{Affected: b.Affected, Max: MaxBlast}
+	}
+	return nil
+}
+
+// service returns service name of container: docker-compose service, Kubernetes container or container name
+func service(c container.Container) string {
+	if s := c.ComposeService(); s != "" {
+		return s
+	}
+	if s, ok := c.Label(k8sContainerLabel); ok && s != "" {
+		return s
+	}
+	return strings.TrimPrefix(c.Name(), "/")
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
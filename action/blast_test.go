@@ -0,0 +1,56 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func blastContainers() []container.Container {
+	return []container.Container{
+		container.NewMockContainer("shop_api_1_id", "/shop_api_1", map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "api"}, container.MockImage("shop/api:1.2")),
+		container.NewMockContainer("shop_api_2_id", "/shop_api_2", map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "api"}, container.MockImage("shop/api:1.2")),
+		container.NewMockContainer("k8s_web_pod_id", "/k8s_web_pod", map[string]string{"io.kubernetes.pod.namespace": "prod", "io.kubernetes.container.name": "web"}, container.MockImage("nginx")),
+		container.NewMockContainer("redis_id", "/redis", nil, container.MockImage("redis:3")),
+	}
+}
+
+func TestEstimateBlastRadius(t *testing.T) {
+	b := EstimateBlastRadius(blastContainers(), false)
+	assert.Equal(t, 4, b.Matched)
+	assert.Equal(t, 4, b.Affected)
+	assert.Equal(t, []string{"nginx:latest", "redis:3", "shop/api:1.2"}, b.Images)
+	assert.Equal(t, []string{"api", "redis", "web"}, b.Services)
+	assert.Equal(t, []string{"shop"}, b.Projects)
+	assert.Equal(t, []string{"prod"}, b.Namespaces)
+	assert.Equal(t, "4 of 4 matching containers; images: nginx:latest, redis:3, shop/api:1.2; services: api, redis, web; compose projects: shop; k8s namespaces: prod", b.String())
+}
+
+func TestEstimateBlastRadius_RandomMode(t *testing.T) {
+	b := EstimateBlastRadius(blastContainers(), true)
+	assert.Equal(t, 4, b.Matched)
+	assert.Equal(t, 1, b.Affected)
+	assert.Equal(t, "0 of 0 matching containers", EstimateBlastRadius(nil, true).String())
+}
+
+func TestCheckBlastRadius(t *testing.T) {
+	MaxBlast = 3
+	defer func() { MaxBlast = 0 }()
+	err := checkBlastRadius(blastContainers(), false)
+	assert.EqualError(t, err, "Blast radius of 4 containers exceeds maximum of 3 (--max-blast); chaos action is skipped")
+	assert.Equal(t, &ErrBlastRadius{Affected: 4, Max: 3}, err)
+	assert.NoError(t, checkBlastRadius(blastContainers()[:3], false))
+}
+
+func TestKillContainers_MaxBlast(t *testing.T) {
+	MaxBlast = 1
+	defer func() { MaxBlast = 0 }()
+	names, cs := makeContainersN(2)
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	err := Pumba{}.KillContainers(client, names, "", CommandKill{Signal: "SIGKILL"})
+	assert.EqualError(t, err, "Blast radius of 2 containers exceeds maximum of 1 (--max-blast); chaos action is skipped")
+	client.AssertNotCalled(t, "KillContainer")
+}
@@ -4,18 +4,22 @@ import (
 	"errors"
 	"math/rand"
 	"net"
+	"os"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/netem"
 )
 
 var (
 	// RandomMode - select random container from matching list
 	RandomMode = false
-	// DryMode - do not 'kill' the container only log event
+	// DryMode - do not 'kill' the container only log event; container client is wrapped with dry run client
 	DryMode = false
 )
 
@@ -24,31 +28,224 @@ const (
 	DeafultWaitTime = 10
 	// DefaultKillSignal default kill signal
 	DefaultKillSignal = "SIGKILL"
+	// shortIDLength length of short container ID, like in 'docker ps'
+	shortIDLength = 12
 )
 
 // CommandKill arguments for kill command
 type CommandKill struct {
-	Signal string
+	Signal  string
+	Cascade bool
 }
 
 // CommandPause arguments for pause command
 type CommandPause struct {
 	Duration time.Duration
+	// ExecHooks commands to exec in container before pause and after unpause
+	ExecHooks ExecHooks
+}
+
+// CommandNetem arguments of 'netem' command, common to netem sub-commands: network interface, traffic filters,
+// duration, hooks and tools
+type CommandNetem struct {
+	NetInterface string
+	// Network Docker network name; if set, interface connected to this network is used instead of NetInterface
+	Network string
+	// Targets destination IPs (host ranges) and CIDR ranges; traffic to them is impaired
+	Targets []*net.IPNet
+	// TargetHosts destination host names, resolved by Pumba on each run and re-resolved during netem duration;
+	// traffic to resolved IPs is impaired
+	TargetHosts []string
+	// TargetAlias host name (Docker network alias), resolved in container on each run; traffic to resolved IPs is impaired
+	TargetAlias string
+	// TargetPeer impair traffic to other matching containers (peers); in random mode a random pair of victim and
+	// peer is selected on each run
+	TargetPeer bool
+	// Protocol IP protocol filter: tcp, udp or icmp; netem impacts only on traffic of this protocol
+	Protocol string
+	// Port destination port filter; 0 - any port
+	Port int
+	// FwMark select traffic with iptables packet mark instead of u32 matches (per-connection mode)
+	FwMark bool
+	// UID socket owner filter, FwMark mode only
+	UID string
+	// DstPercent percent of destinations (by destination IP hash) to impair; 0 - all destinations
+	DstPercent int
+	// Exclude destination IPs (host ranges) and CIDR ranges, whose traffic bypasses netem
+	Exclude  []*net.IPNet
+	Duration time.Duration
+	// ReapplyOnRestart re-apply netem if container is restarted during netem duration
+	ReapplyOnRestart bool
+	// Probe host or HTTP(S) URL; if set, latency to probe is measured in container before netem and under netem
+	// to verify, that impairment took effect
+	Probe string
+	// ExecHooks commands to exec in container before netem starts and after it ends
+	ExecHooks ExecHooks
+	// InjectTools host directory with helper tools (tc, iptables), copied into container for netem duration
+	InjectTools string
 }
 
 // CommandNetemDelay arguments for 'netem delay' sub-command
 type CommandNetemDelay struct {
+	CommandNetem
+	Amount      int
+	Variation   int
+	Correlation int
+	// Distribution delay distribution: uniform (default), normal, pareto or paretonormal
+	Distribution string
+	// Reorder percent of packets sent immediately (reordered); 0 - delay jitter is applied in order
+	Reorder int
+	// Limit netem queue limit, in packets; 0 - auto
+	Limit int
+}
+
+// CommandNetemCorrupt arguments for 'netem corrupt' sub-command
+type CommandNetemCorrupt struct {
+	CommandNetem
+	// Percent percent of packets with random single-bit corruption
+	Percent int
+	// Correlation corruption correlation, in percents; 0 - not set
+	Correlation int
+}
+
+// CommandNetemThrottle arguments for 'netem throttle' sub-command
+type CommandNetemThrottle struct {
+	CommandNetem
+	// Rate, Burst tbf rate and bucket size, with tc units, e.g. '1mbit' and '32kbit'
+	Rate, Burst string
+	// Latency maximal time packet waits in tbf queue, in milliseconds
+	Latency int
+}
+
+// CommandNetemLoss arguments for 'netem loss' sub-command
+type CommandNetemLoss struct {
+	CommandNetem
+	// Model packet loss model: random (default), state (4-state Markov) or gemodel (Gilbert-Elliott)
+	Model string
+	// Percent percent of lost packets, random model
+	Percent int
+	// Correlation loss correlation, in percents, random model; 0 - not set
+	Correlation int
+	// P13, P31, P32, P23, P14 transition probabilities of state model, in percents; trailing zero values are not set
+	P13, P31, P32, P23, P14 int
+	// PG, PB, OneH, OneK Gilbert-Elliott model: transition probability to bad state and back to good state, loss
+	// probability in bad state (1-h) and in good state (1-k), in percents; trailing zero values are not set
+	PG, PB, OneH, OneK int
+}
+
+// CommandNetemCombine arguments for 'netem combine' sub-command: several impairments in single netem qdisc;
+// zero impairment is not applied
+type CommandNetemCombine struct {
+	CommandNetem
+	// Delay, Variation delay and its variation, in milliseconds; DelayCorrelation in percents
+	Delay, Variation, DelayCorrelation int
+	// Distribution delay distribution: uniform (default), normal, pareto or paretonormal
+	Distribution string
+	// Loss, LossCorrelation percent of lost packets and loss correlation
+	Loss, LossCorrelation int
+	// Corrupt, CorruptCorrelation percent of corrupted packets and corruption correlation
+	Corrupt, CorruptCorrelation int
+}
+
+// CommandNetemStop arguments for 'netem stop' sub-command: network interface or Docker network to remove netem from
+type CommandNetemStop struct {
 	NetInterface string
-	IP           net.IP
+	Network      string
+}
+
+// CommandHTTP arguments for http command
+type CommandHTTP struct {
+	Port         int
+	ProxyPort    int
+	Image        string
 	Duration     time.Duration
-	Amount       int
-	Variation    int
-	Correlation  int
+	Delay        int
+	ErrorRate    int
+	ErrorCode    int
+	TruncateRate int
+}
+
+// CommandPorts arguments for ports command
+type CommandPorts struct {
+	Duration time.Duration
+	Loss     int
+	// ExecHooks commands to exec in container before and after disruption
+	ExecHooks ExecHooks
+}
+
+// CommandCPU arguments for cpu command
+type CommandCPU struct {
+	Duration time.Duration
+	// Workers number of CPU busy-loop processes; 0 - one per CPU
+	Workers int
+	// InjectTools host directory with helper tools (e.g. static busybox), copied into container for CPU burn
+	InjectTools string
+}
+
+// CommandOOM arguments for oom command
+type CommandOOM struct {
+	// Timeout memory balloon is stopped, if OOM killer does not fire within timeout
+	Timeout time.Duration
+	// InjectTools host directory with helper tools (e.g. static busybox), copied into container for memory balloon
+	InjectTools string
+}
+
+// CommandFD arguments for fd command
+type CommandFD struct {
+	Duration time.Duration
+	// Free number of descriptors, that processes can still open
+	Free int
+}
+
+// CommandPoisonImage arguments for poison-image command
+type CommandPoisonImage struct {
+	Duration time.Duration
+	// Image bogus image, tagged over image of target containers
+	Image string
+}
+
+// CommandHealth arguments for health command
+type CommandHealth struct {
+	Duration time.Duration
+	// Probe healthcheck probe to disrupt: probe binary or port
+	Probe health.Probe
+	// InjectTools host directory with helper tools (e.g. iptables), copied into container for disruption duration
+	InjectTools string
+}
+
+// CommandMemory arguments for memory command
+type CommandMemory struct {
+	Duration time.Duration
+	// Percent memory limit (memory.high) of container, in percents of its current memory usage
+	Percent int
+}
+
+// CommandEnv arguments for env command
+type CommandEnv struct {
+	// Set environment variables to override ('NAME=value')
+	Set []string
+	// Unset names of environment variables to remove
+	Unset []string
+	// WaitTime seconds to wait for stop of original container before killing it
+	WaitTime int
+	Duration time.Duration
+}
+
+// CommandSecrets arguments for secrets command
+type CommandSecrets struct {
+	// Secrets names of secrets to remove from Swarm service
+	Secrets []string
+	// Configs names of configs to remove from Swarm service
+	Configs  []string
+	Duration time.Duration
 }
 
 // CommandStop arguments for stop command
 type CommandStop struct {
 	WaitTime int
+	// Restart start containers again after Duration (blackout window)
+	Restart  bool
+	Duration time.Duration
 }
 
 // CommandRemove arguments for remove command
@@ -64,31 +261,98 @@ type Chaos interface {
 	KillContainers(container.Client, []string, string, interface{}) error
 	RemoveContainers(container.Client, []string, string, interface{}) error
 	NetemDelayContainers(container.Client, []string, string, interface{}) error
+	NetemCorruptContainers(container.Client, []string, string, interface{}) error
+	NetemLossContainers(container.Client, []string, string, interface{}) error
+	NetemCombineContainers(container.Client, []string, string, interface{}) error
+	NetemThrottleContainers(container.Client, []string, string, interface{}) error
+	NetemStopContainers(container.Client, []string, string, interface{}) error
 	PauseContainers(container.Client, []string, string, interface{}) error
+	HTTPContainers(container.Client, []string, string, interface{}) error
+	PortsContainers(container.Client, []string, string, interface{}) error
+	CPUContainers(container.Client, []string, string, interface{}) error
+	OOMContainers(container.Client, []string, string, interface{}) error
+	FDContainers(container.Client, []string, string, interface{}) error
+	PoisonImageContainers(container.Client, []string, string, interface{}) error
+	HealthContainers(container.Client, []string, string, interface{}) error
+	MemoryContainers(container.Client, []string, string, interface{}) error
+	EnvContainers(container.Client, []string, string, interface{}) error
+	SecretsContainers(container.Client, []string, string, interface{}) error
 }
 
 // Pumba makes Chaos
-type Pumba struct{}
+type Pumba struct {
+	// Random select random container from matching list (in addition to RandomMode)
+	Random bool
+	// GroupBy rotate chaos across groups of containers sharing the label value (GroupByLabel, if not set)
+	GroupBy string
+	// rotator selected group state, shared by copies of Pumba; required for GroupBy (see NewPumba)
+	rotator *groupRotator
+}
+
+// NewPumba returns chaos actions with new group rotation state (--group-by); every chaos command should have own
+// chaos actions, so concurrent commands do not advance rotation of each other
+func NewPumba() Pumba {
+	return Pumba{rotator: &groupRotator{}}
+}
+
+// WithNewRotation returns copy of chaos actions with new group rotation state
+func (p Pumba) WithNewRotation() Pumba {
+	p.rotator = &groupRotator{}
+	return p
+}
+
+// random returns true, when random container is selected from matching list
+func (p Pumba) random() bool {
+	return p.Random || RandomMode
+}
+
+// groupBy returns label, containers are grouped by; empty - containers are not grouped
+func (p Pumba) groupBy() string {
+	if p.GroupBy != "" {
+		return p.GroupBy
+	}
+	return GroupByLabel
+}
+
+// excluded returns reason, why container is excluded from chaos: Pumba container, container with skip label or
+// in freeze window of blackout label; empty - container is not excluded
+func excluded(c container.Container) string {
+	switch {
+	case c.IsPumba():
+		return "Pumba container"
+	case c.IsPumbaSkip():
+		return "'com.gaiaadm.pumba.skip' label"
+	}
+	return blackout(c, Now())
+}
 
 // all containers beside Pumba and PumbaSkip
 func allContainersFilter(c container.Container) bool {
-	if c.IsPumba() || c.IsPumbaSkip() {
-		return false
-	}
-	return true
+	return excluded(c) == ""
 }
 
 func containerFilter(names []string) container.Filter {
-	if len(names) == 0 {
-		return allContainersFilter
+	match := nameFilter(names)
+	return func(c container.Container) bool {
+		return excluded(c) == "" && match(c)
 	}
+}
 
+func regexContainerFilter(pattern string) container.Filter {
+	match := regexFilter(pattern)
 	return func(c container.Container) bool {
-		if c.IsPumba() || c.IsPumbaSkip() {
-			return false
+		return excluded(c) == "" && match(c)
+	}
+}
+
+// nameFilter matches containers by names or IDs (all containers, if names are empty); exclusions are not applied
+func nameFilter(names []string) container.Filter {
+	return func(c container.Container) bool {
+		if len(names) == 0 {
+			return true
 		}
 		for _, name := range names {
-			if (name == c.Name()) || (name == c.Name()[1:]) {
+			if (name == c.Name()) || (name == c.Name()[1:]) || matchID(name, c.ID()) {
 				return true
 			}
 		}
@@ -96,11 +360,14 @@ func containerFilter(names []string) container.Filter {
 	}
 }
 
-func regexContainerFilter(pattern string) container.Filter {
+// matchID checks if value is container ID: full or short (at least 12 characters) ID
+func matchID(value, id string) bool {
+	return value == id || (len(value) >= shortIDLength && strings.HasPrefix(id, value))
+}
+
+// regexFilter matches containers by RE2 pattern; exclusions are not applied
+func regexFilter(pattern string) container.Filter {
 	return func(c container.Container) bool {
-		if c.IsPumba() || c.IsPumbaSkip() {
-			return false
-		}
 		matched, err := regexp.MatchString(pattern, c.Name())
 		if err != nil {
 			return false
@@ -116,17 +383,49 @@ func regexContainerFilter(pattern string) container.Filter {
 	}
 }
 
-func listContainers(client container.Client, names []string, pattern string) ([]container.Container, error) {
-	var err error
-	var containers []container.Container
+// FindContainers lists running containers matching names or RE2 pattern (Pumba and skipped containers excluded)
+func FindContainers(client container.Client, names []string, pattern string) ([]container.Container, error) {
+	if pattern != "" {
+		return client.ListContainers(regexContainerFilter(pattern))
+	}
+	return client.ListContainers(containerFilter(names))
+}
+
+// listContainers lists chaos action targets: running containers matching names or RE2 pattern, rotated by group
+// and limited by blast radius; matching containers, that are excluded or not selected, are reported as skipped
+func (p Pumba) listContainers(client container.Client, names []string, pattern string) ([]container.Container, error) {
+	match := nameFilter(names)
 	if pattern != "" {
-		if containers, err = client.ListContainers(regexContainerFilter(pattern)); err != nil {
-			return nil, err
+		match = regexFilter(pattern)
+	}
+	matched, err := client.ListContainers(match)
+	if err != nil {
+		return nil, err
+	}
+	var containers []container.Container
+	for _, c := range matched {
+		if reason := excluded(c); reason != "" {
+			Skip(c, "", reason)
+			continue
 		}
-	} else {
-		if containers, err = client.ListContainers(containerFilter(names)); err != nil {
-			return nil, err
+		if reason := footprint(c); reason != "" {
+			Skip(c, "", reason)
+			continue
 		}
+		containers = append(containers, c)
+	}
+	if p.groupBy() != "" {
+		if p.rotator == nil {
+			// without rotation state every run would select the first group
+			return nil, errors.New("Group rotation (--group-by) requires chaos actions created with NewPumba")
+		}
+		containers = p.rotator.next(p.groupBy(), containers)
+	}
+	if err = checkBlastRadius(containers, p.random()); err != nil {
+		for _, c := range containers {
+			Skip(c, "", "blast radius exceeds --max-blast")
+		}
+		return nil, err
 	}
 	return containers, nil
 }
@@ -136,26 +435,52 @@ func randomContainer(containers []container.Container) *container.Container {
 		r := rand.New(rand.NewSource(time.Now().UnixNano()))
 		i := r.Intn(len(containers))
 		log.Debug(i, "  ", containers[i])
+		for j, c := range containers {
+			if j != i {
+				Skip(c, "", "not selected in random mode")
+			}
+		}
 		return &containers[i]
 	}
 	return nil
 }
 
-func stopContainers(client container.Client, containers []container.Container, waitTime int) error {
+func (p Pumba) stopContainers(client container.Client, containers []container.Container, cmd CommandStop) error {
+	waitTime := cmd.WaitTime
 	if waitTime == 0 {
 		waitTime = DeafultWaitTime
 	}
-	if RandomMode {
+	if cmd.Restart {
+		for _, c := range p.selectVictims(containers) {
+			wait, err := overrideInt(c, OverrideStopTime, waitTime, 1, maxStopTime)
+			if err != nil {
+				return err
+			}
+			if err = client.BlackoutContainer(c, wait, cmd.Duration); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if p.random() {
 		container := randomContainer(containers)
 		if container != nil {
-			err := client.StopContainer(*container, waitTime, DryMode)
+			wait, err := overrideInt(*container, OverrideStopTime, waitTime, 1, maxStopTime)
+			if err != nil {
+				return err
+			}
+			err = client.StopContainer(*container, wait)
 			if err != nil {
 				return err
 			}
 		}
 	} else {
 		for _, container := range containers {
-			err := client.StopContainer(container, waitTime, DryMode)
+			wait, err := overrideInt(container, OverrideStopTime, waitTime, 1, maxStopTime)
+			if err != nil {
+				return err
+			}
+			err = client.StopContainer(container, wait)
 			if err != nil {
 				return err
 			}
@@ -164,22 +489,81 @@ func stopContainers(client container.Client, containers []container.Container, w
 	return nil
 }
 
-func killContainers(client container.Client, containers []container.Container, signal string) error {
+func (p Pumba) selectVictims(containers []container.Container) []container.Container {
+	if p.random() {
+		if c := randomContainer(containers); c != nil {
+			return []container.Container{*c}
+		}
+		return nil
+	}
+	return containers
+}
+
+// noteRestartPolicy logs expected outcome of kill/rm/oom chaos, based on container restart policy
+func noteRestartPolicy(c container.Container, action string) {
+	switch {
+	case c.Recovery(action) == "auto":
+		log.Warnf("Container %s has '%s' restart policy: expected auto-recovery", c.Name(), c.RestartPolicy())
+	case c.AutoRestarts():
+		log.Infof("Container %s is removed: '%s' restart policy does not restart removed container; expected permanent loss", c.Name(), c.RestartPolicy())
+	default:
+		log.Infof("Container %s has no restart policy: expected permanent loss", c.Name())
+	}
+}
+
+// killContainersCascade kill target containers and then all containers of dependent docker-compose services
+func (p Pumba) killContainersCascade(client container.Client, containers []container.Container, signal string) error {
 	if signal == "" {
 		signal = DefaultKillSignal
 	}
-	if RandomMode {
+	victims := p.selectVictims(containers)
+	for _, c := range victims {
+		if err := killContainer(client, c, signal); err != nil {
+			return err
+		}
+	}
+	all, err := client.ListContainers(allContainersFilter)
+	if err != nil {
+		return err
+	}
+	for _, c := range composeDependents(all, victims) {
+		log.Infof("Cascading kill to dependent service %s: container %s", c.ComposeService(), c.Name())
+		if err := killContainer(client, c, signal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// killContainer kills container with signal, unless the signal is overridden by container label
+func killContainer(client container.Client, c container.Container, signal string) error {
+	signal, err := overrideSignal(c, OverrideKillSignal, signal)
+	if err != nil {
+		return err
+	}
+	if err = client.KillContainer(c, signal); err != nil {
+		return err
+	}
+	noteRestartPolicy(c, "kill")
+	return nil
+}
+
+func (p Pumba) killContainers(client container.Client, containers []container.Container, signal string) error {
+	if signal == "" {
+		signal = DefaultKillSignal
+	}
+	if p.random() {
 		container := randomContainer(containers)
 		if container != nil {
 			log.Debug("Container", container)
-			err := client.KillContainer(*container, signal, DryMode)
+			err := killContainer(client, *container, signal)
 			if err != nil {
 				return err
 			}
 		}
 	} else {
 		for _, container := range containers {
-			err := client.KillContainer(container, signal, DryMode)
+			err := killContainer(client, container, signal)
 			if err != nil {
 				return err
 			}
@@ -188,58 +572,199 @@ func killContainers(client container.Client, containers []container.Container, s
 	return nil
 }
 
-func removeContainers(client container.Client, containers []container.Container, force bool, links bool, volumes bool) error {
-	if RandomMode {
+func (p Pumba) removeContainers(client container.Client, containers []container.Container, force bool, links bool, volumes bool) error {
+	if p.random() {
 		container := randomContainer(containers)
 		if container != nil {
-			err := client.RemoveContainer(*container, force, links, volumes, DryMode)
+			err := client.RemoveContainer(*container, force, links, volumes)
 			if err != nil {
 				return err
 			}
+			noteRestartPolicy(*container, "rm")
 		}
 	} else {
 		for _, container := range containers {
-			err := client.RemoveContainer(container, force, links, volumes, DryMode)
+			err := client.RemoveContainer(container, force, links, volumes)
 			if err != nil {
 				return err
 			}
+			noteRestartPolicy(container, "rm")
 		}
 	}
 	return nil
 }
 
-func pauseContainers(client container.Client, containers []container.Container, duration time.Duration) error {
-	if RandomMode {
-		container := randomContainer(containers)
-		if container != nil {
-			err := client.PauseContainer(*container, duration, DryMode)
-			if err != nil {
-				return err
-			}
+func (p Pumba) pauseContainers(client container.Client, containers []container.Container, duration time.Duration, hooks ExecHooks) error {
+	for _, c := range p.selectVictims(containers) {
+		c := c
+		d, err := overrideDuration(c, OverridePauseDuration, duration)
+		if err != nil {
+			return err
 		}
-	} else {
-		for _, container := range containers {
-			err := client.PauseContainer(container, duration, DryMode)
-			if err != nil {
-				return err
+		err = withExecHooks(client, c, hooks, func() error {
+			return client.PauseContainer(c, d)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p Pumba) portsContainers(client container.Client, containers []container.Container, loss int, duration time.Duration, hooks ExecHooks) error {
+	for _, c := range p.selectVictims(containers) {
+		c := c
+		err := withExecHooks(client, c, hooks, func() error {
+			return client.DropPortsContainer(c, loss, duration)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p Pumba) cpuContainers(client container.Client, containers []container.Container, cmd CommandCPU) error {
+	for _, c := range p.selectVictims(containers) {
+		c := c
+		err := withInjectedTools(client, c, cmd.InjectTools, func() error {
+			return client.BurnCPUContainer(c, cmd.Workers, cmd.Duration)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p Pumba) oomContainers(client container.Client, containers []container.Container, cmd CommandOOM) error {
+	for _, c := range p.selectVictims(containers) {
+		c := c
+		err := withInjectedTools(client, c, cmd.InjectTools, func() error {
+			return client.OOMContainer(c, cmd.Timeout)
+		})
+		if err != nil {
+			return err
+		}
+		noteRestartPolicy(c, "oom")
+	}
+	return nil
+}
+
+func (p Pumba) fdContainers(client container.Client, containers []container.Container, cmd CommandFD) error {
+	// running Pumba binary is injected into target containers as helper
+	dir, err := container.HelperTools()
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	for _, c := range p.selectVictims(containers) {
+		c := c
+		err := withInjectedTools(client, c, dir, func() error {
+			return client.ExhaustFDsContainer(c, cmd.Free, cmd.Duration)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// poisonImageContainers poisons image of each victim; image tag, shared by several victims (replicas), is poisoned once
+func (p Pumba) poisonImageContainers(client container.Client, containers []container.Container, cmd CommandPoisonImage) error {
+	poisoned := map[string]bool{}
+	for _, c := range p.selectVictims(containers) {
+		if poisoned[c.ImageName()] {
+			Skip(c, "poison-image", "image "+c.ImageName()+" is already poisoned")
+			continue
+		}
+		poisoned[c.ImageName()] = true
+		if err := client.PoisonImageContainer(c, cmd.Image, cmd.Duration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// healthContainers disrupts healthcheck probe of each victim
+func (p Pumba) healthContainers(client container.Client, containers []container.Container, cmd CommandHealth) error {
+	for _, c := range p.selectVictims(containers) {
+		c := c
+		err := withInjectedTools(client, c, cmd.InjectTools, func() error {
+			return client.HealthcheckContainer(c, cmd.Probe, cmd.Duration)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// memoryContainers puts each victim under memory pressure
+func (p Pumba) memoryContainers(client container.Client, containers []container.Container, cmd CommandMemory) error {
+	for _, c := range p.selectVictims(containers) {
+		if err := client.MemoryPressureContainer(c, cmd.Percent, cmd.Duration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envContainers re-creates each victim with corrupted environment
+func (p Pumba) envContainers(client container.Client, containers []container.Container, cmd CommandEnv) error {
+	for _, c := range p.selectVictims(containers) {
+		if err := client.CorruptEnvContainer(c, cmd.Set, cmd.Unset, cmd.WaitTime, cmd.Duration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// secretsContainers removes secrets and configs from Swarm service of each victim; service is updated once, for
+// the first of its victim tasks
+func (p Pumba) secretsContainers(client container.Client, containers []container.Container, cmd CommandSecrets) error {
+	updated := map[string]bool{}
+	for _, c := range p.selectVictims(containers) {
+		if service := c.SwarmService(); service != "" {
+			if updated[service] {
+				Skip(c, "secrets", "Swarm service "+service+" is already updated")
+				continue
 			}
+			updated[service] = true
+		}
+		if err := client.RemoveSecretsContainer(c, cmd.Secrets, cmd.Configs, cmd.Duration); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func netemContainers(client container.Client, containers []container.Container, netInterface string, netemCmd string, ip net.IP, duration time.Duration) error {
-	if RandomMode {
+// netemImpairment returns netem command (e.g. 'delay 100ms') for container; impairment parameters may be overridden
+// by container labels
+type netemImpairment func(c container.Container) (string, error)
+
+// fixedImpairment returns the same netem command for all containers
+func fixedImpairment(netemCmd string) netemImpairment {
+	return func(container.Container) (string, error) {
+		return netemCmd, nil
+	}
+}
+
+func (p Pumba) netemContainers(client container.Client, containers []container.Container, impairment netemImpairment, cmd CommandNetem) error {
+	if cmd.TargetPeer {
+		return netemPeerPairs(client, p.selectPeerPairs(containers), impairment, cmd)
+	}
+	if p.random() {
 		container := randomContainer(containers)
 		if container != nil {
-			err := client.NetemContainer(*container, netInterface, netemCmd, ip, duration, DryMode)
+			err := netemContainer(client, *container, impairment, cmd, nil)
 			if err != nil {
 				return err
 			}
 		}
 	} else {
 		for _, container := range containers {
-			err := client.NetemContainer(container, netInterface, netemCmd, ip, duration, DryMode)
+			err := netemContainer(client, container, impairment, cmd, nil)
 			if err != nil {
 				return err
 			}
@@ -248,6 +773,73 @@ func netemContainers(client container.Client, containers []container.Container,
 	return nil
 }
 
+// netemPeerPairs impairs traffic of each victim to its peers
+func netemPeerPairs(client container.Client, pairs []peerPair, impairment netemImpairment, cmd CommandNetem) error {
+	for _, p := range pairs {
+		ips, err := peerIPs(p, cmd.Network)
+		if err != nil {
+			return err
+		}
+		if err = netemContainer(client, p.victim, impairment, cmd, ips); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func netemContainer(client container.Client, c container.Container, impairment netemImpairment, cmd CommandNetem, peerIPs []net.IP) error {
+	netemCmd, err := impairment(c)
+	if err != nil {
+		return err
+	}
+	if cmd.Duration, err = overrideDuration(c, OverrideNetemDuration, cmd.Duration); err != nil {
+		return err
+	}
+	// interface and alias are resolved with injected tools too
+	return withInjectedTools(client, c, cmd.InjectTools, func() error {
+		return applyNetem(client, c, netemCmd, cmd, peerIPs)
+	})
+}
+
+func applyNetem(client container.Client, c container.Container, netemCmd string, cmd CommandNetem, peerIPs []net.IP) error {
+	var err error
+	netInterface := cmd.NetInterface
+	if cmd.Network != "" {
+		if netInterface, err = client.NetworkInterface(c, cmd.Network); err != nil {
+			return err
+		}
+	}
+	filter := netem.Filter{Hosts: cmd.TargetHosts, Protocol: cmd.Protocol, Port: cmd.Port, FwMark: cmd.FwMark, UID: cmd.UID, DstPercent: cmd.DstPercent, Exclude: cmd.Exclude}
+	for _, target := range cmd.Targets {
+		if ones, bits := target.Mask.Size(); ones == bits {
+			filter.IPs = append(filter.IPs, target.IP)
+		} else {
+			filter.Nets = append(filter.Nets, target)
+		}
+	}
+	filter.IPs = append(filter.IPs, peerIPs...)
+	// alias IPs may change, when aliased containers are re-created: resolve on each run
+	if cmd.TargetAlias != "" {
+		var aliasIPs []net.IP
+		if aliasIPs, err = client.ResolveAlias(c, cmd.TargetAlias); err != nil {
+			return err
+		}
+		filter.IPs = append(filter.IPs, aliasIPs...)
+	}
+	return withExecHooks(client, c, cmd.ExecHooks, func() error {
+		return client.NetemContainer(c, netInterface, netemCmd, filter, cmd.Duration, cmd.ReapplyOnRestart, cmd.Probe)
+	})
+}
+
+func (p Pumba) sidecarContainers(client container.Client, containers []container.Container, image string, cmd []string, duration time.Duration) error {
+	for _, c := range p.selectVictims(containers) {
+		if err := client.SidecarContainer(c, image, cmd, duration); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 //---------------------------------------------------------------------------------------------------
 
 // StopContainers stop containers matching pattern
@@ -256,14 +848,14 @@ func (p Pumba) StopContainers(client container.Client, names []string, pattern s
 	// get command details
 	command, ok := cmd.(CommandStop)
 	if !ok {
-		return errors.New("Unexpected cmd type; should be CommandStop")
+		return &ErrUnexpectedCommand{Expected: "CommandStop"}
 	}
 	var err error
 	var containers []container.Container
-	if containers, err = listContainers(client, names, pattern); err != nil {
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
 		return err
 	}
-	return stopContainers(client, containers, command.WaitTime)
+	return p.stopContainers(client, containers, command)
 }
 
 // KillContainers - kill containers either by RE2 pattern (if specified) or by names
@@ -272,14 +864,17 @@ func (p Pumba) KillContainers(client container.Client, names []string, pattern s
 	// get command details
 	command, ok := cmd.(CommandKill)
 	if !ok {
-		return errors.New("Unexpected cmd type; should be CommandKill")
+		return &ErrUnexpectedCommand{Expected: "CommandKill"}
 	}
 	var err error
 	var containers []container.Container
-	if containers, err = listContainers(client, names, pattern); err != nil {
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
 		return err
 	}
-	return killContainers(client, containers, command.Signal)
+	if command.Cascade {
+		return p.killContainersCascade(client, containers, command.Signal)
+	}
+	return p.killContainers(client, containers, command.Signal)
 }
 
 // RemoveContainers - remove container either by RE2 pattern (if specified) or by names
@@ -288,14 +883,14 @@ func (p Pumba) RemoveContainers(client container.Client, names []string, pattern
 	// get command details
 	command, ok := cmd.(CommandRemove)
 	if !ok {
-		return errors.New("Unexpected cmd type; should be CommandRemove")
+		return &ErrUnexpectedCommand{Expected: "CommandRemove"}
 	}
 	var err error
 	var containers []container.Container
-	if containers, err = listContainers(client, names, pattern); err != nil {
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
 		return err
 	}
-	return removeContainers(client, containers, command.Force, command.Links, command.Volumes)
+	return p.removeContainers(client, containers, command.Force, command.Links, command.Volumes)
 }
 
 // NetemDelayContainers delay network traffic with optional variation and correlation
@@ -304,22 +899,157 @@ func (p Pumba) NetemDelayContainers(client container.Client, names []string, pat
 	// get command details
 	command, ok := cmd.(CommandNetemDelay)
 	if !ok {
-		return errors.New("Unexpected cmd type; should be CommandNetemDelay")
+		return &ErrUnexpectedCommand{Expected: "CommandNetemDelay"}
+	}
+	var err error
+	var containers []container.Container
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
+		return err
+	}
+	// delay and jitter may be overridden by container labels
+	impairment := func(c container.Container) (string, error) {
+		amount, err := overrideMilliseconds(c, OverrideNetemDelay, command.Amount)
+		if err != nil {
+			return "", err
+		}
+		variation, err := overrideMilliseconds(c, OverrideNetemJitter, command.Variation)
+		if err != nil {
+			return "", err
+		}
+		args := netem.Delay(amount, variation, command.Correlation)
+		args = append(args, netem.Distribution(command.Distribution)...)
+		args = append(args, netem.Reorder(command.Reorder)...)
+		args = append(args, netem.Limit(command.Limit, amount, variation)...)
+		return strings.Join(args, " "), nil
+	}
+	return p.netemContainers(client, containers, impairment, command.CommandNetem)
+}
+
+// NetemCorruptContainers corrupt network traffic: flip random single bit in specified percent of packets
+func (p Pumba) NetemCorruptContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("netem corrupt for containers")
+	// get command details
+	command, ok := cmd.(CommandNetemCorrupt)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandNetemCorrupt"}
 	}
 	var err error
 	var containers []container.Container
-	if containers, err = listContainers(client, names, pattern); err != nil {
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
 		return err
 	}
-	netemCmd := "delay " + strconv.Itoa(command.Amount) + "ms"
-	if command.Variation > 0 {
-		netemCmd += " " + strconv.Itoa(command.Variation) + "ms"
+	netemCmd := strings.Join(netem.Corrupt(command.Percent, command.Correlation), " ")
+	return p.netemContainers(client, containers, fixedImpairment(netemCmd), command.CommandNetem)
+}
+
+// NetemThrottleContainers throttle bandwidth of network traffic with token bucket filter (tbf)
+func (p Pumba) NetemThrottleContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("netem throttle for containers")
+	// get command details
+	command, ok := cmd.(CommandNetemThrottle)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandNetemThrottle"}
 	}
-	if command.Correlation > 0 {
-		netemCmd += " " + strconv.Itoa(command.Correlation) + "%"
+	var err error
+	var containers []container.Container
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
+		return err
 	}
+	netemCmd := strings.Join(netem.Throttle(command.Rate, command.Burst, command.Latency), " ")
+	return p.netemContainers(client, containers, fixedImpairment(netemCmd), command.CommandNetem)
+}
 
-	return netemContainers(client, containers, command.NetInterface, netemCmd, command.IP, command.Duration)
+// NetemLossContainers drop network packets: independent random loss or bursty loss of state or Gilbert-Elliott model
+func (p Pumba) NetemLossContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("netem loss for containers")
+	// get command details
+	command, ok := cmd.(CommandNetemLoss)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandNetemLoss"}
+	}
+	var err error
+	var containers []container.Container
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
+		return err
+	}
+	switch command.Model {
+	case netem.LossModelState:
+		netemCmd := strings.Join(netem.LossState(command.P13, command.P31, command.P32, command.P23, command.P14), " ")
+		return p.netemContainers(client, containers, fixedImpairment(netemCmd), command.CommandNetem)
+	case netem.LossModelGE:
+		netemCmd := strings.Join(netem.LossGEModel(command.PG, command.PB, command.OneH, command.OneK), " ")
+		return p.netemContainers(client, containers, fixedImpairment(netemCmd), command.CommandNetem)
+	}
+	// random loss percent may be overridden by container label
+	impairment := func(c container.Container) (string, error) {
+		percent, err := overrideInt(c, OverrideNetemLoss, command.Percent, 0, 100)
+		if err != nil {
+			return "", err
+		}
+		return strings.Join(netem.Loss(percent, command.Correlation), " "), nil
+	}
+	return p.netemContainers(client, containers, impairment, command.CommandNetem)
+}
+
+// NetemCombineContainers apply several network impairments (delay, loss, corrupt) in single netem qdisc
+func (p Pumba) NetemCombineContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("netem combine for containers")
+	// get command details
+	command, ok := cmd.(CommandNetemCombine)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandNetemCombine"}
+	}
+	var err error
+	var containers []container.Container
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
+		return err
+	}
+	var impairment []string
+	if command.Delay > 0 || command.Variation > 0 {
+		impairment = append(impairment, netem.Delay(command.Delay, command.Variation, command.DelayCorrelation)...)
+		impairment = append(impairment, netem.Distribution(command.Distribution)...)
+	}
+	if command.Loss > 0 {
+		impairment = append(impairment, netem.Loss(command.Loss, command.LossCorrelation)...)
+	}
+	if command.Corrupt > 0 {
+		impairment = append(impairment, netem.Corrupt(command.Corrupt, command.CorruptCorrelation)...)
+	}
+	impairment = append(impairment, netem.Limit(0, command.Delay, command.Variation)...)
+	return p.netemContainers(client, containers, fixedImpairment(strings.Join(impairment, " ")), command.CommandNetem)
+}
+
+// NetemStopContainers remove netem, added by Pumba, from containers, cancelling network emulation in progress; all
+// matching containers are cleaned up, regardless of random mode, skip and blackout labels and footprint filters
+func (p Pumba) NetemStopContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("netem stop for containers")
+	// get command details
+	command, ok := cmd.(CommandNetemStop)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandNetemStop"}
+	}
+	match := nameFilter(names)
+	if pattern != "" {
+		match = regexFilter(pattern)
+	}
+	containers, err := client.ListContainers(func(c container.Container) bool {
+		return !c.IsPumba() && match(c)
+	})
+	if err != nil {
+		return err
+	}
+	for _, c := range containers {
+		netInterface := command.NetInterface
+		if command.Network != "" {
+			if netInterface, err = client.NetworkInterface(c, command.Network); err != nil {
+				return err
+			}
+		}
+		if err = client.ClearNetemContainer(c, netInterface); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // PauseContainers pause container,if its name within `names`, for specified interval
@@ -328,12 +1058,186 @@ func (p Pumba) PauseContainers(client container.Client, names []string, pattern
 	// get command details
 	command, ok := cmd.(CommandPause)
 	if !ok {
-		return errors.New("Unexpected cmd type; should be CommandPause")
+		return &ErrUnexpectedCommand{Expected: "CommandPause"}
+	}
+	var err error
+	var containers []container.Container
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
+		return err
+	}
+	return p.pauseContainers(client, containers, command.Duration, command.ExecHooks)
+}
+
+// HTTPContainers inject HTTP faults (latency, errors, truncated responses) into incoming traffic
+// of the specified container port, using a proxy sidecar container
+func (p Pumba) HTTPContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("HTTP chaos for containers")
+	// get command details
+	command, ok := cmd.(CommandHTTP)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandHTTP"}
+	}
+	var err error
+	var containers []container.Container
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
+		return err
+	}
+	proxyCmd := []string{"/usr/bin/pumba", "proxy",
+		"--port", strconv.Itoa(command.Port),
+		"--proxy-port", strconv.Itoa(command.ProxyPort),
+		"--delay", strconv.Itoa(command.Delay),
+		"--error-rate", strconv.Itoa(command.ErrorRate),
+		"--error-code", strconv.Itoa(command.ErrorCode),
+		"--truncate-rate", strconv.Itoa(command.TruncateRate),
+	}
+	return p.sidecarContainers(client, containers, command.Image, proxyCmd, command.Duration)
+}
+
+// PortsContainers drop packets sent to published ports of containers (host DNAT path)
+func (p Pumba) PortsContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("Drop packets to published ports of containers")
+	// get command details
+	command, ok := cmd.(CommandPorts)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandPorts"}
+	}
+	var err error
+	var containers []container.Container
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
+		return err
+	}
+	return p.portsContainers(client, containers, command.Loss, command.Duration, command.ExecHooks)
+}
+
+// CPUContainers burn CPU of containers with busy-loop processes, exec-ed inside container for specified interval
+func (p Pumba) CPUContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("Burn CPU of containers")
+	// get command details
+	command, ok := cmd.(CommandCPU)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandCPU"}
+	}
+	var err error
+	var containers []container.Container
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
+		return err
+	}
+	return p.cpuContainers(client, containers, command)
+}
+
+// OOMContainers trigger kernel OOM killer inside containers with memory balloon, exec-ed in container memory cgroup
+func (p Pumba) OOMContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("Trigger OOM killer in containers")
+	// get command details
+	command, ok := cmd.(CommandOOM)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandOOM"}
+	}
+	var err error
+	var containers []container.Container
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
+		return err
+	}
+	return p.oomContainers(client, containers, command)
+}
+
+// FDContainers exhaust file descriptors of container processes for specified interval with injected Pumba helper
+func (p Pumba) FDContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("Exhaust file descriptors of containers")
+	// get command details
+	command, ok := cmd.(CommandFD)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandFD"}
+	}
+	var err error
+	var containers []container.Container
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
+		return err
+	}
+	return p.fdContainers(client, containers, command)
+}
+
+// PoisonImageContainers tag bogus image over image tag of containers in local image cache for specified interval
+func (p Pumba) PoisonImageContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("Poison images of containers")
+	// get command details
+	command, ok := cmd.(CommandPoisonImage)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandPoisonImage"}
+	}
+	var err error
+	var containers []container.Container
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
+		return err
+	}
+	return p.poisonImageContainers(client, containers, command)
+}
+
+// HealthContainers disrupt only healthcheck probe of containers for specified interval: containers look healthy
+// (or unhealthy) to orchestrator, while serving real traffic as before
+func (p Pumba) HealthContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("Disrupt healthchecks of containers")
+	// get command details
+	command, ok := cmd.(CommandHealth)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandHealth"}
+	}
+	var err error
+	var containers []container.Container
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
+		return err
+	}
+	return p.healthContainers(client, containers, command)
+}
+
+// MemoryContainers put containers under memory pressure for specified interval: memory limit of containers
+// (memory.high of cgroup v2) is lowered below current memory usage, so containers are throttled and their memory is
+// reclaimed
+func (p Pumba) MemoryContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("Put containers under memory pressure")
+	// get command details
+	command, ok := cmd.(CommandMemory)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandMemory"}
+	}
+	var err error
+	var containers []container.Container
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
+		return err
+	}
+	return p.memoryContainers(client, containers, command)
+}
+
+// EnvContainers re-create containers with environment variables overridden or removed for specified interval, to
+// test resilience to misconfiguration; original containers are restored afterwards
+func (p Pumba) EnvContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("Re-create containers with corrupted environment")
+	// get command details
+	command, ok := cmd.(CommandEnv)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandEnv"}
+	}
+	var err error
+	var containers []container.Container
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
+		return err
+	}
+	return p.envContainers(client, containers, command)
+}
+
+// SecretsContainers remove secrets and configs from Swarm services of containers (tasks) for specified interval and
+// restore them afterwards: service tasks are re-deployed without mounted secrets and configs
+func (p Pumba) SecretsContainers(client container.Client, names []string, pattern string, cmd interface{}) error {
+	log.Info("Remove secrets and configs from Swarm services of containers")
+	// get command details
+	command, ok := cmd.(CommandSecrets)
+	if !ok {
+		return &ErrUnexpectedCommand{Expected: "CommandSecrets"}
 	}
 	var err error
 	var containers []container.Container
-	if containers, err = listContainers(client, names, pattern); err != nil {
+	if containers, err = p.listContainers(client, names, pattern); err != nil {
 		return err
 	}
-	return pauseContainers(client, containers, command.Duration)
+	return p.secretsContainers(client, containers, command)
 }
@@ -1,12 +1,16 @@
 package action
 
 import (
+	"errors"
 	"net"
+	"runtime"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/netem"
 	"github.com/samalba/dockerclient"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -117,6 +121,22 @@ func TestNamesFilter(t *testing.T) {
 	assert.False(t, cf(c3))
 }
 
+func TestNamesFilter_ID(t *testing.T) {
+	c := *container.NewContainer(
+		&dockerclient.ContainerInfo{
+			Id:     "3f2a9c1b7e4d5a6b7c8d9e0f",
+			Name:   "/api_1",
+			Config: &dockerclient.ContainerConfig{},
+		},
+		nil,
+	)
+	assert.True(t, nameFilter([]string{"3f2a9c1b7e4d5a6b7c8d9e0f"})(c))
+	assert.True(t, nameFilter([]string{"3f2a9c1b7e4d"})(c))
+	// too short to be container ID
+	assert.False(t, nameFilter([]string{"3f2a"})(c))
+	assert.False(t, nameFilter([]string{"4f2a9c1b7e4d"})(c))
+}
+
 func TestAllNamesFilter(t *testing.T) {
 	c1 := *container.NewContainer(
 		&dockerclient.ContainerInfo{
@@ -202,9 +222,7 @@ func TestStopByNameRandom(t *testing.T) {
 	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
 	client.On("StopContainer", mock.AnythingOfType("container.Container"), 10).Return(nil)
 	// do action
-	RandomMode = true
-	err := Pumba{}.StopContainers(client, names, "", cmd)
-	RandomMode = false
+	err := Pumba{Random: true}.StopContainers(client, names, "", cmd)
 	// asserts
 	assert.NoError(t, err)
 	client.AssertExpectations(t)
@@ -234,14 +252,29 @@ func TestStopByPatternRandom(t *testing.T) {
 	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
 	client.On("StopContainer", mock.AnythingOfType("container.Container"), 10).Return(nil)
 	// do action
-	RandomMode = true
-	err := Pumba{}.StopContainers(client, []string{}, "^c", cmd)
-	RandomMode = false
+	err := Pumba{Random: true}.StopContainers(client, []string{}, "^c", cmd)
 	// asserts
 	assert.NoError(t, err)
 	client.AssertExpectations(t)
 }
 
+func TestStopRestart(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(3)
+	cmd := CommandStop{WaitTime: 5, Restart: true, Duration: 2 * time.Millisecond}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("BlackoutContainer", c, 5, 2*time.Millisecond).Return(nil)
+	}
+	// do action
+	err := Pumba{}.StopContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "StopContainer", cs[0], 5)
+}
+
 func TestKillByName(t *testing.T) {
 	// prepare test data and mock
 	names, cs := makeContainersN(10)
@@ -258,6 +291,25 @@ func TestKillByName(t *testing.T) {
 	client.AssertExpectations(t)
 }
 
+func TestKillCascade(t *testing.T) {
+	// prepare test data and mock
+	db := container.NewMockContainer("shop_db_1-id", "shop_db_1", map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "db"})
+	api := container.NewMockContainer("shop_api_1-id", "shop_api_1", map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "api", "com.docker.compose.depends_on": "db:service_started:false"})
+	web := container.NewMockContainer("shop_web_1-id", "shop_web_1", map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "web", "com.docker.compose.depends_on": "api:service_started:false"})
+	cmd := CommandKill{Signal: "SIGTEST", Cascade: true}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{db}, nil).Once()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{db, api, web}, nil).Once()
+	client.On("KillContainer", db, "SIGTEST").Return(nil)
+	client.On("KillContainer", api, "SIGTEST").Return(nil)
+	client.On("KillContainer", web, "SIGTEST").Return(nil)
+	// do action
+	err := Pumba{}.KillContainers(client, []string{"shop_db_1"}, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
 func TestKillByNameRandom(t *testing.T) {
 	// prepare test data and mocks
 	names, cs := makeContainersN(10)
@@ -266,9 +318,7 @@ func TestKillByNameRandom(t *testing.T) {
 	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
 	client.On("KillContainer", mock.AnythingOfType("container.Container"), "SIGTEST").Return(nil)
 	// do action
-	RandomMode = true
-	err := Pumba{}.KillContainers(client, names, "", cmd)
-	RandomMode = false
+	err := Pumba{Random: true}.KillContainers(client, names, "", cmd)
 	// asserts
 	assert.NoError(t, err)
 	client.AssertExpectations(t)
@@ -298,9 +348,7 @@ func TestKillByPatternRandom(t *testing.T) {
 	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
 	client.On("KillContainer", mock.AnythingOfType("container.Container"), "SIGTEST").Return(nil)
 	// do action
-	RandomMode = true
-	err := Pumba{}.KillContainers(client, []string{}, "^c", cmd)
-	RandomMode = false
+	err := Pumba{Random: true}.KillContainers(client, []string{}, "^c", cmd)
 	// asserts
 	assert.NoError(t, err)
 	client.AssertExpectations(t)
@@ -327,9 +375,7 @@ func TestRemoveByNameRandom(t *testing.T) {
 	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
 	client.On("RemoveContainer", mock.AnythingOfType("container.Container"), false, true, true).Return(nil)
 	// do action
-	RandomMode = true
-	err := Pumba{}.RemoveContainers(client, names, "", cmd)
-	RandomMode = false
+	err := Pumba{Random: true}.RemoveContainers(client, names, "", cmd)
 	// asserts
 	assert.NoError(t, err)
 	client.AssertExpectations(t)
@@ -359,9 +405,7 @@ func TestRemoveByPatternRandom(t *testing.T) {
 	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
 	client.On("RemoveContainer", mock.AnythingOfType("container.Container"), false, true, true).Return(nil)
 	// do action
-	RandomMode = true
-	err := Pumba{}.RemoveContainers(client, []string{}, "^c", cmd)
-	RandomMode = false
+	err := Pumba{Random: true}.RemoveContainers(client, []string{}, "^c", cmd)
 	// asserts
 	assert.NoError(t, err)
 	client.AssertExpectations(t)
@@ -383,6 +427,212 @@ func TestPauseByName(t *testing.T) {
 	client.AssertExpectations(t)
 }
 
+func TestPortsByName(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(10)
+	cmd := CommandPorts{Duration: 2 * time.Millisecond, Loss: 50}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("DropPortsContainer", c, 50, 2*time.Millisecond).Return(nil)
+	}
+	// do action
+	err := Pumba{}.PortsContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestCPUByName(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(10)
+	cmd := CommandCPU{Duration: 2 * time.Millisecond, Workers: 2}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("BurnCPUContainer", c, 2, 2*time.Millisecond).Return(nil)
+	}
+	// do action
+	err := Pumba{}.CPUContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestCPUByNameError(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(2)
+	cmd := CommandCPU{Duration: 2 * time.Millisecond}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("BurnCPUContainer", cs[0], 0, 2*time.Millisecond).Return(errors.New("exec: sh not found"))
+	// do action
+	err := Pumba{}.CPUContainers(client, names, "", cmd)
+	// asserts
+	assert.EqualError(t, err, "exec: sh not found")
+	client.AssertNotCalled(t, "BurnCPUContainer", cs[1], 0, 2*time.Millisecond)
+}
+
+func TestOOMByName(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(2)
+	cmd := CommandOOM{Timeout: time.Second}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("OOMContainer", cs[0], time.Second).Return(nil)
+	client.On("OOMContainer", cs[1], time.Second).Return(errors.New("no memory limit"))
+	// do action
+	err := Pumba{}.OOMContainers(client, names, "", cmd)
+	// asserts
+	assert.EqualError(t, err, "no memory limit")
+	client.AssertExpectations(t)
+}
+
+func TestOOMBadCommand(t *testing.T) {
+	err := Pumba{}.OOMContainers(container.NewMockSamalbaClient(), []string{"c1"}, "", CommandCPU{})
+	assert.EqualError(t, err, "Unexpected cmd type; should be CommandOOM")
+}
+
+func TestPoisonImageByName(t *testing.T) {
+	// prepare test data and mocks: api_1 and api_2 are replicas of the same image
+	var cs []container.Container
+	for _, c := range [][]string{{"api_1", "myorg/api:1.2"}, {"api_2", "myorg/api:1.2"}, {"db_1", "postgres:9.5"}} {
+		cs = append(cs, *container.NewContainer(&dockerclient.ContainerInfo{Name: c[0], Config: &dockerclient.ContainerConfig{Image: c[1]}}, nil))
+	}
+	cmd := CommandPoisonImage{Duration: time.Minute, Image: "busybox:latest"}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("PoisonImageContainer", cs[0], "busybox:latest", time.Minute).Return(nil)
+	client.On("PoisonImageContainer", cs[2], "busybox:latest", time.Minute).Return(nil)
+	// do action
+	err := Pumba{}.PoisonImageContainers(client, []string{"api_1", "api_2", "db_1"}, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+	client.AssertNumberOfCalls(t, "PoisonImageContainer", 2)
+}
+
+func TestPoisonImageBadCommand(t *testing.T) {
+	err := Pumba{}.PoisonImageContainers(nil, []string{"c1"}, "", CommandFD{})
+	assert.EqualError(t, err, "Unexpected cmd type; should be CommandPoisonImage")
+	assert.Equal(t, &ErrUnexpectedCommand{Expected: "CommandPoisonImage"}, err)
+}
+
+func TestHealthByName(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(2)
+	probe := health.Probe{Binary: "/usr/bin/curl"}
+	cmd := CommandHealth{Duration: time.Minute, Probe: probe}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("HealthcheckContainer", c, probe, time.Minute).Return(nil)
+	}
+	// do action
+	err := Pumba{}.HealthContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestHealthBadCommand(t *testing.T) {
+	err := Pumba{}.HealthContainers(nil, []string{"c1"}, "", CommandFD{})
+	assert.EqualError(t, err, "Unexpected cmd type; should be CommandHealth")
+}
+
+func TestMemoryByName(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(2)
+	cmd := CommandMemory{Duration: time.Minute, Percent: 80}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("MemoryPressureContainer", c, 80, time.Minute).Return(nil)
+	}
+	// do action
+	err := Pumba{}.MemoryContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestMemoryBadCommand(t *testing.T) {
+	err := Pumba{}.MemoryContainers(nil, []string{"c1"}, "", CommandHealth{})
+	assert.EqualError(t, err, "Unexpected cmd type; should be CommandMemory")
+}
+
+func TestEnvByName(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(2)
+	cmd := CommandEnv{Set: []string{"DB_HOST=nowhere"}, Unset: []string{"DB_PASSWORD"}, WaitTime: 10, Duration: time.Minute}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("CorruptEnvContainer", c, cmd.Set, cmd.Unset, 10, time.Minute).Return(nil)
+	}
+	// do action
+	err := Pumba{}.EnvContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestEnvBadCommand(t *testing.T) {
+	err := Pumba{}.EnvContainers(nil, []string{"c1"}, "", CommandMemory{})
+	assert.EqualError(t, err, "Unexpected cmd type; should be CommandEnv")
+}
+
+func TestSecretsByPattern(t *testing.T) {
+	// prepare test data and mocks: two tasks of 'web' service and one task of 'db' service
+	var cs []container.Container
+	for i, service := range []string{"web", "web", "db"} {
+		cs = append(cs, *container.NewContainer(
+			&dockerclient.ContainerInfo{
+				Name:   "c" + strconv.Itoa(i),
+				Config: &dockerclient.ContainerConfig{Labels: map[string]string{"com.docker.swarm.service.id": service}},
+			},
+			nil,
+		))
+	}
+	cmd := CommandSecrets{Secrets: []string{"db-password"}, Duration: time.Minute}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("RemoveSecretsContainer", cs[0], cmd.Secrets, []string(nil), time.Minute).Return(nil)
+	client.On("RemoveSecretsContainer", cs[2], cmd.Secrets, []string(nil), time.Minute).Return(nil)
+	// do action
+	err := Pumba{}.SecretsContainers(client, []string{}, "^c", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "RemoveSecretsContainer", cs[1], cmd.Secrets, []string(nil), time.Minute)
+}
+
+func TestSecretsBadCommand(t *testing.T) {
+	err := Pumba{}.SecretsContainers(nil, []string{"c1"}, "", CommandEnv{})
+	assert.EqualError(t, err, "Unexpected cmd type; should be CommandSecrets")
+}
+
+func TestFDByName(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Pumba helper requires Linux")
+	}
+	// prepare test data and mocks
+	names, cs := makeContainersN(2)
+	cmd := CommandFD{Duration: time.Second, Free: 1}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("InjectTools", c, mock.AnythingOfType("string")).Return(nil)
+		client.On("ExhaustFDsContainer", c, 1, time.Second).Return(nil)
+		client.On("RemoveTools", c).Return(nil)
+	}
+	// do action
+	err := Pumba{}.FDContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
 func TestPauseByPattern(t *testing.T) {
 	// prepare test data and mocks
 	_, cs := makeContainersN(10)
@@ -407,29 +657,293 @@ func TestPauseByNameRandom(t *testing.T) {
 	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
 	client.On("PauseContainer", mock.AnythingOfType("container.Container"), 2*time.Millisecond).Return(nil)
 	// do action
-	RandomMode = true
-	err := Pumba{}.PauseContainers(client, names, "", cmd)
-	RandomMode = false
+	err := Pumba{Random: true}.PauseContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestPauseExecHooks(t *testing.T) {
+	// prepare test data and mocks
+	_, cs := makeContainersN(2)
+	cmd := CommandPause{Duration: 2 * time.Millisecond, ExecHooks: ExecHooks{Before: "sync", After: "nginx -s reload"}}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("ExecContainer", c, []string{"sh", "-c", "sync"}, ExecHookTimeout).Return(0, nil).Once()
+		client.On("PauseContainer", c, 2*time.Millisecond).Return(nil)
+		// after hook failure does not fail action
+		client.On("ExecContainer", c, []string{"sh", "-c", "nginx -s reload"}, ExecHookTimeout).Return(1, nil).Once()
+	}
+	// do action
+	err := Pumba{}.PauseContainers(client, []string{}, "^c", cmd)
 	// asserts
 	assert.NoError(t, err)
 	client.AssertExpectations(t)
 }
 
+func TestNetemExecHooksAfterFailure(t *testing.T) {
+	// prepare test data and mocks
+	_, cs := makeContainersN(1)
+	cmd := CommandNetemDelay{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Duration:     1 * time.Second,
+			ExecHooks:    ExecHooks{After: "nginx -s reload"},
+		},
+		Amount: 120,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("NetemContainer", cs[0], "eth0", "delay 120ms", netem.Filter{}, 1*time.Second).Return(errors.New("tc failed"))
+	client.On("ExecContainer", cs[0], []string{"sh", "-c", "nginx -s reload"}, ExecHookTimeout).Return(0, nil)
+	// do action
+	err := Pumba{}.NetemDelayContainers(client, []string{}, "^c", cmd)
+	// asserts
+	assert.EqualError(t, err, "tc failed")
+	client.AssertExpectations(t)
+}
+
+func TestPauseExecHooksDryRun(t *testing.T) {
+	// prepare test data and mocks
+	_, cs := makeContainersN(1)
+	cmd := CommandPause{Duration: 2 * time.Millisecond, ExecHooks: ExecHooks{Before: "sync"}}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("PauseContainer", cs[0], 2*time.Millisecond).Return(nil)
+	client.Dry = true
+	// do action
+	err := Pumba{}.PauseContainers(client, []string{}, "^c", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertNotCalled(t, "ExecContainer", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestNetemDealyByName(t *testing.T) {
 	// prepare test data and mocks
 	names, cs := makeContainersN(10)
 	cmd := CommandNetemDelay{
-		NetInterface: "eth1",
-		IP:           nil,
-		Duration:     1 * time.Second,
-		Amount:       120,
-		Variation:    25,
-		Correlation:  15,
+		CommandNetem: CommandNetem{
+			NetInterface: "eth1",
+			Targets:      nil,
+			Duration:     1 * time.Second,
+		},
+		Amount:      120,
+		Variation:   25,
+		Correlation: 15,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("NetemContainer", c, "eth1", "delay 120ms 25ms 15%", netem.Filter{}, 1*time.Second).Return(nil)
+	}
+	// do action
+	err := Pumba{}.NetemDelayContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemCorruptByName(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(2)
+	cmd := CommandNetemCorrupt{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Protocol:     "udp",
+			Duration:     1 * time.Second,
+		},
+		Percent:     5,
+		Correlation: 25,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("NetemContainer", c, "eth0", "corrupt 5% 25%", netem.Filter{Protocol: "udp"}, 1*time.Second).Return(nil)
+	}
+	// do action
+	err := Pumba{}.NetemCorruptContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemCorruptUnexpectedCommand(t *testing.T) {
+	err := Pumba{}.NetemCorruptContainers(container.NewMockSamalbaClient(), []string{"c1"}, "", CommandNetemDelay{})
+	assert.EqualError(t, err, "Unexpected cmd type; should be CommandNetemCorrupt")
+}
+
+func TestNetemLossGEModelByName(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(2)
+	cmd := CommandNetemLoss{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Duration:     1 * time.Second,
+		},
+		Model: netem.LossModelGE,
+		PG:    1,
+		PB:    25,
+		OneH:  80,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("NetemContainer", c, "eth0", "loss gemodel 1% 25% 80%", netem.Filter{}, 1*time.Second).Return(nil)
+	}
+	// do action
+	err := Pumba{}.NetemLossContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemCombineByName(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(2)
+	cmd := CommandNetemCombine{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Duration:     1 * time.Second,
+		},
+		Delay:        100,
+		Variation:    10,
+		Distribution: "normal",
+		Loss:         5,
+		Corrupt:      1,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("NetemContainer", c, "eth0", "delay 100ms 10ms distribution normal loss 5% corrupt 1%", netem.Filter{}, 1*time.Second).Return(nil)
+	}
+	// do action
+	err := Pumba{}.NetemCombineContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemCombineNoDelay(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(1)
+	cmd := CommandNetemCombine{
+		CommandNetem:    CommandNetem{NetInterface: "eth0", Duration: 1 * time.Second},
+		Loss:            5,
+		LossCorrelation: 25,
+		Corrupt:         1,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("NetemContainer", cs[0], "eth0", "loss 5% 25% corrupt 1%", netem.Filter{}, 1*time.Second).Return(nil)
+	// do action
+	err := Pumba{}.NetemCombineContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemThrottleByName(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(2)
+	cmd := CommandNetemThrottle{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Duration:     1 * time.Second,
+		},
+		Rate:    "1mbit",
+		Burst:   "32kbit",
+		Latency: 400,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("NetemContainer", c, "eth0", "tbf rate 1mbit burst 32kbit latency 400ms", netem.Filter{}, 1*time.Second).Return(nil)
+	}
+	// do action
+	err := Pumba{}.NetemThrottleContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemStopByName(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(2)
+	// netem is removed from containers in freeze window too, but not from Pumba containers
+	frozen := *container.NewContainer(&dockerclient.ContainerInfo{
+		Name:   "c0",
+		Config: &dockerclient.ContainerConfig{Labels: map[string]string{BlackoutLabel: "Mon-Sun"}},
+	}, nil)
+	pumba := *container.NewContainer(&dockerclient.ContainerInfo{
+		Name:   "c1",
+		Config: &dockerclient.ContainerConfig{Labels: map[string]string{"com.gaiaadm.pumba": "true"}},
+	}, nil)
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.MatchedBy(func(f container.Filter) bool {
+		return f(frozen) && !f(pumba)
+	})).Return(cs, nil)
+	for _, c := range cs {
+		client.On("ClearNetemContainer", c, "eth0").Return(nil)
+	}
+	// do action
+	err := Pumba{}.NetemStopContainers(client, names, "", CommandNetemStop{NetInterface: "eth0"})
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemStopByNetwork(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(1)
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("NetworkInterface", cs[0], "backend").Return("eth1", nil)
+	client.On("ClearNetemContainer", cs[0], "eth1").Return(errors.New("failed"))
+	// do action
+	err := Pumba{}.NetemStopContainers(client, names, "", CommandNetemStop{NetInterface: "eth0", Network: "backend"})
+	// asserts
+	assert.EqualError(t, err, "failed")
+	client.AssertExpectations(t)
+}
+
+func TestNetemLossRandomByName(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(1)
+	cmd := CommandNetemLoss{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Duration:     1 * time.Second,
+		},
+		Percent:     10,
+		Correlation: 25,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("NetemContainer", cs[0], "eth0", "loss 10% 25%", netem.Filter{}, 1*time.Second).Return(nil)
+	// do action
+	err := Pumba{}.NetemLossContainers(client, names, "", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemDealyByNameNetwork(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(3)
+	cmd := CommandNetemDelay{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Network:      "macvlan0",
+			Duration:     1 * time.Second,
+		},
+		Amount: 120,
 	}
 	client := container.NewMockSamalbaClient()
 	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
 	for _, c := range cs {
-		client.On("NetemContainer", c, "eth1", "delay 120ms 25ms 15%", net.ParseIP(""), 1*time.Second).Return(nil)
+		client.On("NetworkInterface", c, "macvlan0").Return("eth2", nil)
+		client.On("NetemContainer", c, "eth2", "delay 120ms", netem.Filter{}, 1*time.Second).Return(nil)
 	}
 	// do action
 	err := Pumba{}.NetemDelayContainers(client, names, "", cmd)
@@ -438,24 +952,45 @@ func TestNetemDealyByName(t *testing.T) {
 	client.AssertExpectations(t)
 }
 
+func TestNetemDealyByNameNetworkError(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(3)
+	cmd := CommandNetemDelay{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Network:      "overlay0",
+			Duration:     1 * time.Second,
+		},
+		Amount: 120,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("NetworkInterface", cs[0], "overlay0").Return("", errors.New("not connected"))
+	// do action
+	err := Pumba{}.NetemDelayContainers(client, names, "", cmd)
+	// asserts
+	assert.EqualError(t, err, "not connected")
+	client.AssertNotCalled(t, "NetemContainer", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestNetemDealyByNameRandom(t *testing.T) {
 	// prepare test data and mocks
 	names, cs := makeContainersN(10)
 	cmd := CommandNetemDelay{
-		NetInterface: "eth1",
-		IP:           nil,
-		Duration:     1 * time.Second,
-		Amount:       120,
-		Variation:    25,
-		Correlation:  15,
+		CommandNetem: CommandNetem{
+			NetInterface: "eth1",
+			Targets:      nil,
+			Duration:     1 * time.Second,
+		},
+		Amount:      120,
+		Variation:   25,
+		Correlation: 15,
 	}
 	client := container.NewMockSamalbaClient()
 	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
-	client.On("NetemContainer", mock.AnythingOfType("container.Container"), "eth1", "delay 120ms 25ms 15%", net.ParseIP(""), 1*time.Second).Return(nil)
+	client.On("NetemContainer", mock.AnythingOfType("container.Container"), "eth1", "delay 120ms 25ms 15%", netem.Filter{}, 1*time.Second).Return(nil)
 	// do action
-	RandomMode = true
-	err := Pumba{}.NetemDelayContainers(client, names, "", cmd)
-	RandomMode = false
+	err := Pumba{Random: true}.NetemDelayContainers(client, names, "", cmd)
 	// asserts
 	assert.NoError(t, err)
 	client.AssertExpectations(t)
@@ -465,17 +1000,19 @@ func TestNetemDealyByPattern(t *testing.T) {
 	// prepare test data and mocks
 	_, cs := makeContainersN(10)
 	cmd := CommandNetemDelay{
-		NetInterface: "eth1",
-		IP:           nil,
-		Duration:     1 * time.Second,
-		Amount:       120,
-		Variation:    25,
-		Correlation:  15,
+		CommandNetem: CommandNetem{
+			NetInterface: "eth1",
+			Targets:      nil,
+			Duration:     1 * time.Second,
+		},
+		Amount:      120,
+		Variation:   25,
+		Correlation: 15,
 	}
 	client := container.NewMockSamalbaClient()
 	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
 	for _, c := range cs {
-		client.On("NetemContainer", c, "eth1", "delay 120ms 25ms 15%", net.ParseIP(""), 1*time.Second).Return(nil)
+		client.On("NetemContainer", c, "eth1", "delay 120ms 25ms 15%", netem.Filter{}, 1*time.Second).Return(nil)
 	}
 	// do action
 	err := Pumba{}.NetemDelayContainers(client, []string{}, "^c", cmd)
@@ -487,19 +1024,22 @@ func TestNetemDealyByPattern(t *testing.T) {
 func TestNetemDealyByPatternIPFilter(t *testing.T) {
 	// prepare test data and mocks
 	_, cs := makeContainersN(10)
-	ip := net.ParseIP("10.10.0.1")
+	ip := net.ParseIP("10.10.0.1").To4()
+	_, subnet, _ := net.ParseCIDR("10.20.0.0/16")
 	cmd := CommandNetemDelay{
-		NetInterface: "eth1",
-		IP:           ip,
-		Duration:     1 * time.Second,
-		Amount:       120,
-		Variation:    25,
-		Correlation:  15,
+		CommandNetem: CommandNetem{
+			NetInterface: "eth1",
+			Targets:      []*net.IPNet{{IP: ip, Mask: net.CIDRMask(32, 32)}, subnet},
+			Duration:     1 * time.Second,
+		},
+		Amount:      120,
+		Variation:   25,
+		Correlation: 15,
 	}
 	client := container.NewMockSamalbaClient()
 	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
 	for _, c := range cs {
-		client.On("NetemContainer", c, "eth1", "delay 120ms 25ms 15%", ip, 1*time.Second).Return(nil)
+		client.On("NetemContainer", c, "eth1", "delay 120ms 25ms 15%", netem.Filter{IPs: []net.IP{ip}, Nets: []*net.IPNet{subnet}}, 1*time.Second).Return(nil)
 	}
 	// do action
 	err := Pumba{}.NetemDelayContainers(client, []string{}, "^c", cmd)
@@ -508,29 +1048,244 @@ func TestNetemDealyByPatternIPFilter(t *testing.T) {
 	client.AssertExpectations(t)
 }
 
+func TestNetemDelayTargetHosts(t *testing.T) {
+	// prepare test data and mocks
+	_, cs := makeContainersN(2)
+	ip := net.ParseIP("10.10.0.1").To4()
+	cmd := CommandNetemDelay{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Targets:      []*net.IPNet{{IP: ip, Mask: net.CIDRMask(32, 32)}},
+			TargetHosts:  []string{"db.service.local"},
+			Duration:     time.Second,
+		},
+		Amount: 100,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		// host names are resolved by container client during netem
+		client.On("NetemContainer", c, "eth0", "delay 100ms", netem.Filter{IPs: []net.IP{ip}, Hosts: []string{"db.service.local"}}, time.Second).Return(nil)
+	}
+	// do action
+	err := Pumba{}.NetemDelayContainers(client, []string{}, "^c", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemDelayExclude(t *testing.T) {
+	// prepare test data and mocks
+	_, cs := makeContainersN(2)
+	dns := &net.IPNet{IP: net.ParseIP("127.0.0.11").To4(), Mask: net.CIDRMask(32, 32)}
+	cmd := CommandNetemDelay{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Protocol:     "udp",
+			Exclude:      []*net.IPNet{dns},
+			Duration:     time.Second,
+		},
+		Amount: 100,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("NetemContainer", c, "eth0", "delay 100ms", netem.Filter{Protocol: "udp", Exclude: []*net.IPNet{dns}}, time.Second).Return(nil)
+	}
+	// do action
+	err := Pumba{}.NetemDelayContainers(client, []string{}, "^c", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemDealyByPatternProtocol(t *testing.T) {
+	// prepare test data and mocks
+	_, cs := makeContainersN(3)
+	cmd := CommandNetemDelay{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Protocol:     "udp",
+			Duration:     1 * time.Second,
+		},
+		Amount: 120,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("NetemContainer", c, "eth0", "delay 120ms", netem.Filter{Protocol: "udp"}, 1*time.Second).Return(nil)
+	}
+	// do action
+	err := Pumba{}.NetemDelayContainers(client, []string{}, "^c", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemDealyByPatternFwMark(t *testing.T) {
+	// prepare test data and mocks
+	_, cs := makeContainersN(3)
+	cmd := CommandNetemDelay{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Port:         5432,
+			FwMark:       true,
+			UID:          "1000",
+			Duration:     1 * time.Second,
+		},
+		Amount: 120,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("NetemContainer", c, "eth0", "delay 120ms", netem.Filter{Port: 5432, FwMark: true, UID: "1000"}, 1*time.Second).Return(nil)
+	}
+	// do action
+	err := Pumba{}.NetemDelayContainers(client, []string{}, "^c", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemDealyByPatternDstPercent(t *testing.T) {
+	// prepare test data and mocks
+	_, cs := makeContainersN(2)
+	cmd := CommandNetemDelay{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			DstPercent:   50,
+			Duration:     1 * time.Second,
+		},
+		Amount: 120,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("NetemContainer", c, "eth0", "delay 120ms", netem.Filter{DstPercent: 50}, 1*time.Second).Return(nil)
+	}
+	// do action
+	err := Pumba{}.NetemDelayContainers(client, []string{}, "^c", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemDealyJitterOnlyOptions(t *testing.T) {
+	// prepare test data and mocks
+	_, cs := makeContainersN(1)
+	cmd := CommandNetemDelay{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Duration:     1 * time.Second,
+		},
+		Variation:    1500,
+		Distribution: "pareto",
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("NetemContainer", cs[0], "eth0", "delay 0ms 1500ms distribution pareto limit 15000", netem.Filter{}, 1*time.Second).Return(nil)
+	// do action
+	err := Pumba{}.NetemDelayContainers(client, []string{}, "^c", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemDealyByPatternTargetAlias(t *testing.T) {
+	// prepare test data and mocks
+	_, cs := makeContainersN(3)
+	ip := net.ParseIP("10.10.0.1").To4()
+	aliasIPs := []net.IP{net.ParseIP("10.0.9.5"), net.ParseIP("10.0.9.6")}
+	cmd := CommandNetemDelay{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Targets:      []*net.IPNet{{IP: ip, Mask: net.CIDRMask(32, 32)}},
+			TargetAlias:  "db",
+			Duration:     1 * time.Second,
+		},
+		Amount: 120,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("ResolveAlias", c, "db").Return(aliasIPs, nil)
+		client.On("NetemContainer", c, "eth0", "delay 120ms", netem.Filter{IPs: append([]net.IP{ip}, aliasIPs...)}, 1*time.Second).Return(nil)
+	}
+	// do action
+	err := Pumba{}.NetemDelayContainers(client, []string{}, "^c", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemDealyByPatternTargetAliasError(t *testing.T) {
+	// prepare test data and mocks
+	_, cs := makeContainersN(3)
+	cmd := CommandNetemDelay{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			TargetAlias:  "db",
+			Duration:     1 * time.Second,
+		},
+		Amount: 120,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("ResolveAlias", cs[0], "db").Return([]net.IP(nil), errors.New("Failed to resolve 'db'"))
+	// do action
+	err := Pumba{}.NetemDelayContainers(client, []string{}, "^c", cmd)
+	// asserts
+	assert.EqualError(t, err, "Failed to resolve 'db'")
+	client.AssertNotCalled(t, "NetemContainer", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestNetemDealyByPatternRandom(t *testing.T) {
 	// prepare test data and mocks
 	_, cs := makeContainersN(10)
 	cmd := CommandNetemDelay{
-		NetInterface: "eth1",
-		IP:           nil,
-		Duration:     1 * time.Second,
-		Amount:       120,
-		Variation:    25,
-		Correlation:  15,
+		CommandNetem: CommandNetem{
+			NetInterface: "eth1",
+			Targets:      nil,
+			Duration:     1 * time.Second,
+		},
+		Amount:      120,
+		Variation:   25,
+		Correlation: 15,
 	}
 	client := container.NewMockSamalbaClient()
 	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
-	client.On("NetemContainer", mock.AnythingOfType("container.Container"), "eth1", "delay 120ms 25ms 15%", net.ParseIP(""), 1*time.Second).Return(nil)
+	client.On("NetemContainer", mock.AnythingOfType("container.Container"), "eth1", "delay 120ms 25ms 15%", netem.Filter{}, 1*time.Second).Return(nil)
 	// do action
-	RandomMode = true
-	err := Pumba{}.NetemDelayContainers(client, []string{}, "^c", cmd)
-	RandomMode = false
+	err := Pumba{Random: true}.NetemDelayContainers(client, []string{}, "^c", cmd)
+	// asserts
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestHTTPByName(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(3)
+	cmd := CommandHTTP{Port: 80, ProxyPort: 15001, Image: "gaiaadm/pumba", Duration: time.Second,
+		Delay: 100, ErrorRate: 10, ErrorCode: 503, TruncateRate: 5}
+	proxyCmd := []string{"/usr/bin/pumba", "proxy", "--port", "80", "--proxy-port", "15001", "--delay", "100",
+		"--error-rate", "10", "--error-code", "503", "--truncate-rate", "5"}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	for _, c := range cs {
+		client.On("SidecarContainer", c, "gaiaadm/pumba", proxyCmd, time.Second).Return(nil)
+	}
+	// do action
+	err := Pumba{}.HTTPContainers(client, names, "", cmd)
 	// asserts
 	assert.NoError(t, err)
 	client.AssertExpectations(t)
 }
 
+func TestHTTPBadCommand(t *testing.T) {
+	err := Pumba{}.HTTPContainers(nil, []string{}, "", CommandPause{})
+	assert.EqualError(t, err, "Unexpected cmd type; should be CommandHTTP")
+}
+
 func TestSelectRandomContainer(t *testing.T) {
 	_, cs := makeContainersN(30)
 	c1 := randomContainer(cs)
@@ -539,3 +1294,34 @@ func TestSelectRandomContainer(t *testing.T) {
 	assert.NotNil(t, c2)
 	assert.NotEqual(t, c1.Name(), c2.Name())
 }
+
+func TestCPUByNameInjectTools(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(1)
+	cmd := CommandCPU{Duration: 2 * time.Millisecond, InjectTools: "/opt/tools"}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("InjectTools", cs[0], "/opt/tools").Return(nil)
+	client.On("BurnCPUContainer", cs[0], 0, 2*time.Millisecond).Return(nil)
+	client.On("RemoveTools", cs[0]).Return(errors.New("rm: not found"))
+	// do action
+	err := Pumba{}.CPUContainers(client, names, "", cmd)
+	// asserts: tools removal failure does not fail chaos action
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestNetemInjectToolsError(t *testing.T) {
+	// prepare test data and mocks
+	names, cs := makeContainersN(1)
+	cmd := CommandNetemDelay{CommandNetem: CommandNetem{NetInterface: "eth0", Duration: 2 * time.Millisecond, InjectTools: "/opt/tools"}, Amount: 10}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("InjectTools", cs[0], "/opt/tools").Return(errors.New("read-only file system"))
+	// do action
+	err := Pumba{}.NetemDelayContainers(client, names, "", cmd)
+	// asserts
+	assert.EqualError(t, err, "read-only file system")
+	client.AssertNotCalled(t, "NetemContainer", cs[0], "eth0", mock.Anything, mock.Anything, mock.Anything)
+	client.AssertNotCalled(t, "RemoveTools", cs[0])
+}
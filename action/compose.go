@@ -0,0 +1,79 @@
+package action
+
+import (
+	"github.com/gaia-adm/pumba/container"
+)
+
+// composeDependents returns containers, that depend (directly or through a chain of
+// dependencies) on docker-compose services of the victim containers. Dependencies
+// are resolved within the same compose project, using 'depends_on' label and links.
+func composeDependents(all []container.Container, victims []container.Container) []container.Container {
+	type serviceKey struct {
+		project string
+		service string
+	}
+	// map container name to its service, to resolve links
+	nameToService := map[string]serviceKey{}
+	for _, c := range all {
+		if c.ComposeService() != "" {
+			nameToService[c.Name()] = serviceKey{c.ComposeProject(), c.ComposeService()}
+		}
+	}
+	// build reverse dependency graph: service -> dependent services
+	dependents := map[serviceKey][]serviceKey{}
+	for _, c := range all {
+		if c.ComposeService() == "" {
+			continue
+		}
+		key := serviceKey{c.ComposeProject(), c.ComposeService()}
+		for _, dep := range c.ComposeDependsOn() {
+			depKey := serviceKey{key.project, dep}
+			dependents[depKey] = append(dependents[depKey], key)
+		}
+		for _, link := range c.Links() {
+			if depKey, ok := nameToService[link]; ok && depKey.project == key.project {
+				dependents[depKey] = append(dependents[depKey], key)
+			}
+		}
+	}
+	// walk the graph, starting from victim services
+	visited := map[serviceKey]bool{}
+	queue := []serviceKey{}
+	for _, v := range victims {
+		if v.ComposeService() == "" {
+			continue
+		}
+		key := serviceKey{v.ComposeProject(), v.ComposeService()}
+		if !visited[key] {
+			visited[key] = true
+			queue = append(queue, key)
+		}
+	}
+	affected := map[serviceKey]bool{}
+	for len(queue) > 0 {
+		key := queue[0]
+		queue = queue[1:]
+		for _, d := range dependents[key] {
+			if !visited[d] {
+				visited[d] = true
+				affected[d] = true
+				queue = append(queue, d)
+			}
+		}
+	}
+	// collect dependent containers, skipping victims
+	isVictim := map[string]bool{}
+	for _, v := range victims {
+		isVictim[v.ID()] = true
+	}
+	var result []container.Container
+	for _, c := range all {
+		if isVictim[c.ID()] || c.ComposeService() == "" {
+			continue
+		}
+		if affected[serviceKey{c.ComposeProject(), c.ComposeService()}] {
+			result = append(result, c)
+		}
+	}
+	return result
+}
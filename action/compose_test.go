@@ -0,0 +1,41 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComposeDependents_Chain(t *testing.T) {
+	db := container.NewMockContainer("shop_db_1-id", "shop_db_1", map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "db"})
+	api := container.NewMockContainer("shop_api_1-id", "shop_api_1", map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "api", "com.docker.compose.depends_on": "db:service_healthy:false"})
+	web := container.NewMockContainer("shop_web_1-id", "shop_web_1", map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "web", "com.docker.compose.depends_on": "api:service_started:false"})
+	other := container.NewMockContainer("blog_api_1-id", "blog_api_1", map[string]string{"com.docker.compose.project": "blog", "com.docker.compose.service": "api", "com.docker.compose.depends_on": "db:service_started:false"})
+	all := []container.Container{db, api, web, other}
+
+	deps := composeDependents(all, []container.Container{db})
+	assert.Equal(t, []container.Container{api, web}, deps)
+
+	deps = composeDependents(all, []container.Container{web})
+	assert.Empty(t, deps)
+}
+
+func TestComposeDependents_Links(t *testing.T) {
+	db := container.NewMockContainer("/shop_db_1-id", "/shop_db_1", map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "db"})
+	api := container.NewMockContainer("/shop_api_1-id", "/shop_api_1", map[string]string{"com.docker.compose.project": "shop", "com.docker.compose.service": "api"}, container.MockHostConfig(dockerclient.HostConfig{Links: []string{"/shop_db_1:/shop_api_1/db"}}))
+	all := []container.Container{db, api}
+
+	deps := composeDependents(all, []container.Container{db})
+	assert.Equal(t, []container.Container{api}, deps)
+}
+
+func TestComposeDependents_Cycle(t *testing.T) {
+	a := container.NewMockContainer("p_a_1-id", "p_a_1", map[string]string{"com.docker.compose.project": "p", "com.docker.compose.service": "a", "com.docker.compose.depends_on": "b"})
+	b := container.NewMockContainer("p_b_1-id", "p_b_1", map[string]string{"com.docker.compose.project": "p", "com.docker.compose.service": "b", "com.docker.compose.depends_on": "a"})
+	all := []container.Container{a, b}
+
+	deps := composeDependents(all, []container.Container{a})
+	assert.Equal(t, []container.Container{b}, deps)
+}
@@ -0,0 +1,23 @@
+package action
+
+import "fmt"
+
+// ErrUnexpectedCommand chaos action got arguments of another chaos command
+type ErrUnexpectedCommand struct {
+	// Expected type name of expected command arguments, like 'CommandKill'
+	Expected string
+}
+
+func (e *ErrUnexpectedCommand) Error() string {
+	return fmt.Sprintf("Unexpected cmd type; should be %s", e.Expected)
+}
+
+// ErrBlastRadius chaos action is skipped, because it affects more containers than allowed by --max-blast
+type ErrBlastRadius struct {
+	Affected int
+	Max      int
+}
+
+func (e *ErrBlastRadius) Error() string {
+	return fmt.Sprintf("Blast radius of %d containers exceeds maximum of %d (--max-blast); chaos action is skipped", e.Affected, e.Max)
+}
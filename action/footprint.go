@@ -0,0 +1,68 @@
+package action
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gaia-adm/pumba/container"
+)
+
+// Footprint filters of target containers by resource limits from container HostConfig; 0 - no filter
+var (
+	// MinMemory - minimal memory limit of target containers, in bytes (--min-memory)
+	MinMemory int64
+	// MaxMemory - maximal memory limit of target containers, in bytes (--max-memory)
+	MaxMemory int64
+	// MinCPUs - minimal CPU limit of target containers, in CPUs (--min-cpus)
+	MinCPUs float64
+	// MaxCPUs - maximal CPU limit of target containers, in CPUs (--max-cpus)
+	MaxCPUs float64
+)
+
+// memoryUnits binary units of memory size, largest first
+var memoryUnits = []struct {
+	name string
+	size int64
+}{{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}}
+
+// formatMemory formats memory size in bytes with the largest binary unit, e.g. '1.5GiB'
+func formatMemory(size int64) string {
+	for _, unit := range memoryUnits {
+		if size >= unit.size {
+			return strconv.FormatFloat(float64(size)/float64(unit.size), 'f', -1, 64) + unit.name
+		}
+	}
+	return strconv.FormatInt(size, 10) + "B"
+}
+
+// footprint returns reason, why container does not match footprint filters; container without limit does not match
+// any filter of this resource, since its footprint is unknown; empty - container matches footprint filters
+func footprint(c container.Container) string {
+	if MinMemory > 0 || MaxMemory > 0 {
+		limit := c.MemoryLimit()
+		switch {
+		case limit <= 0 && MinMemory > 0:
+			return "no memory limit (--min-memory)"
+		case limit <= 0:
+			return "no memory limit (--max-memory)"
+		case MinMemory > 0 && limit < MinMemory:
+			return fmt.Sprintf("memory limit %s is below --min-memory %s", formatMemory(limit), formatMemory(MinMemory))
+		case MaxMemory > 0 && limit > MaxMemory:
+			return fmt.Sprintf("memory limit %s is above --max-memory %s", formatMemory(limit), formatMemory(MaxMemory))
+		}
+	}
+	if MinCPUs > 0 || MaxCPUs > 0 {
+		limit := c.CPULimit()
+		switch {
+		case limit <= 0 && MinCPUs > 0:
+			return "no CPU limit (--min-cpus)"
+		case limit <= 0:
+			return "no CPU limit (--max-cpus)"
+		case MinCPUs > 0 && limit < MinCPUs:
+			return fmt.Sprintf("CPU limit %g is below --min-cpus %g", limit, MinCPUs)
+		case MaxCPUs > 0 && limit > MaxCPUs:
+			return fmt.Sprintf("CPU limit %g is above --max-cpus %g", limit, MaxCPUs)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// makeSizedContainer returns container with memory limit (in MiB) and CPU quota (in CPUs)
+func TestFormatMemory(t *testing.T) {
+	assert.Equal(t, "512MiB", formatMemory(512<<20))
+	assert.Equal(t, "1.5GiB", formatMemory(3<<29))
+	assert.Equal(t, "64KiB", formatMemory(64<<10))
+	assert.Equal(t, "100B", formatMemory(100))
+}
+
+func TestListContainers_Footprint(t *testing.T) {
+	large := container.NewMockContainer("", "large", nil, container.MockHostConfig(dockerclient.HostConfig{Memory: 2048 << 20, CpuQuota: 200000}))
+	sidecar := container.NewMockContainer("", "sidecar", nil, container.MockHostConfig(dockerclient.HostConfig{Memory: 64 << 20, CpuQuota: 25000}))
+	unlimited := container.NewMockContainer("", "unlimited", nil, container.MockHostConfig(dockerclient.HostConfig{}))
+	cpuOnly := container.NewMockContainer("", "cpu-only", nil, container.MockHostConfig(dockerclient.HostConfig{CpuQuota: 400000}))
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{large, sidecar, unlimited, cpuOnly}, nil)
+	defer func() { MinMemory, MaxMemory, MinCPUs, MaxCPUs = 0, 0, 0, 0 }()
+
+	for _, tt := range []struct {
+		minMemory, maxMemory int64
+		minCPUs, maxCPUs     float64
+		expected             []container.Container
+		skips                map[string]string
+	}{
+		{0, 0, 0, 0, []container.Container{large, sidecar, unlimited, cpuOnly}, map[string]string{}},
+		{512 << 20, 0, 0, 0, []container.Container{large}, map[string]string{
+			"sidecar":   "memory limit 64MiB is below --min-memory 512MiB",
+			"unlimited": "no memory limit (--min-memory)",
+			"cpu-only":  "no memory limit (--min-memory)",
+		}},
+		{0, 0, 0, 0.5, []container.Container{sidecar}, map[string]string{
+			"large":     "CPU limit 2 is above --max-cpus 0.5",
+			"unlimited": "no CPU limit (--max-cpus)",
+			"cpu-only":  "CPU limit 4 is above --max-cpus 0.5",
+		}},
+		{0, 1 << 30, 1, 0, nil, map[string]string{
+			"large":     "memory limit 2GiB is above --max-memory 1GiB",
+			"sidecar":   "CPU limit 0.25 is below --min-cpus 1",
+			"unlimited": "no memory limit (--max-memory)",
+			"cpu-only":  "no memory limit (--max-memory)",
+		}},
+	} {
+		MinMemory, MaxMemory, MinCPUs, MaxCPUs = tt.minMemory, tt.maxMemory, tt.minCPUs, tt.maxCPUs
+		skips := map[string]string{}
+		restore := recordSkips(skips)
+
+		cs, err := Pumba{}.listContainers(client, nil, "")
+		restore()
+
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, cs)
+		assert.Equal(t, tt.skips, skips)
+	}
+}
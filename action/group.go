@@ -0,0 +1,67 @@
+package action
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/gaia-adm/pumba/container"
+)
+
+// GroupByLabel - rotate chaos across groups of containers sharing the same label value
+var GroupByLabel = ""
+
+// groupRotator remembers the last selected group, so every chaos tick moves
+// to the next group (round-robin over sorted label values); every chaos command has own rotator (see NewPumba),
+// so concurrent commands select the same group on the same tick
+type groupRotator struct {
+	sync.Mutex
+	last    string
+	started bool
+}
+
+// next splits containers into groups by label value and returns containers of the
+// group following the previously selected one; containers without label are ignored
+func (r *groupRotator) next(label string, containers []container.Container) []container.Container {
+	groups := map[string][]container.Container{}
+	for _, c := range containers {
+		val, ok := c.Label(label)
+		if !ok {
+			Skip(c, "", fmt.Sprintf("no '%s' label (--group-by)", label))
+			continue
+		}
+		groups[val] = append(groups[val], c)
+	}
+	if len(groups) == 0 {
+		log.Warningf("No containers with '%s' label found", label)
+		return nil
+	}
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	r.Lock()
+	defer r.Unlock()
+	i := 0
+	if r.started {
+		// first group after last selected one; wrap around at the end
+		i = sort.Search(len(keys), func(i int) bool { return keys[i] > r.last })
+		if i == len(keys) {
+			i = 0
+		}
+	}
+	r.last = keys[i]
+	r.started = true
+	for _, k := range keys {
+		if k != keys[i] {
+			for _, c := range groups[k] {
+				Skip(c, "", fmt.Sprintf("group %s=%s is not selected (--group-by)", label, k))
+			}
+		}
+	}
+	log.Infof("Selected group %s=%s (%d containers)", label, keys[i], len(groups[keys[i]]))
+	return groups[keys[i]]
+}
@@ -0,0 +1,100 @@
+package action
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGroupRotator_RoundRobin(t *testing.T) {
+	cs := []container.Container{
+		container.NewMockContainer("", "c1", map[string]string{"zone": "b"}),
+		container.NewMockContainer("", "c2", map[string]string{"zone": "a"}),
+		container.NewMockContainer("", "c3", map[string]string{"zone": "b"}),
+		container.NewMockContainer("", "c4", nil),
+		container.NewMockContainer("", "c5", map[string]string{"zone": "c"}),
+	}
+	r := &groupRotator{}
+	// first tick: group 'a'
+	g := r.next("zone", cs)
+	assert.Len(t, g, 1)
+	assert.Equal(t, "c2", g[0].Name())
+	// second tick: group 'b'
+	g = r.next("zone", cs)
+	assert.Len(t, g, 2)
+	assert.Equal(t, "c1", g[0].Name())
+	assert.Equal(t, "c3", g[1].Name())
+	// third tick: group 'c'
+	g = r.next("zone", cs)
+	assert.Len(t, g, 1)
+	assert.Equal(t, "c5", g[0].Name())
+	// wrap around
+	g = r.next("zone", cs)
+	assert.Len(t, g, 1)
+	assert.Equal(t, "c2", g[0].Name())
+}
+
+func TestGroupRotator_GroupDisappeared(t *testing.T) {
+	r := &groupRotator{last: "b", started: true}
+	cs := []container.Container{
+		container.NewMockContainer("", "c1", map[string]string{"zone": "a"}),
+		container.NewMockContainer("", "c2", map[string]string{"zone": "c"}),
+	}
+	g := r.next("zone", cs)
+	assert.Len(t, g, 1)
+	assert.Equal(t, "c2", g[0].Name())
+}
+
+func TestGroupRotator_NoLabels(t *testing.T) {
+	r := &groupRotator{}
+	cs := []container.Container{container.NewMockContainer("", "c1", nil)}
+	assert.Empty(t, r.next("zone", cs))
+}
+
+func TestPumba_GroupByWithoutRotator(t *testing.T) {
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{
+		container.NewMockContainer("", "c1", map[string]string{"zone": "a"}),
+	}, nil)
+	_, err := Pumba{GroupBy: "zone"}.listContainers(client, nil, "")
+	assert.EqualError(t, err, "Group rotation (--group-by) requires chaos actions created with NewPumba")
+}
+
+func TestPumba_ConcurrentCommandsSelectSameGroup(t *testing.T) {
+	cs := []container.Container{
+		container.NewMockContainer("", "c1", map[string]string{"zone": "a"}),
+		container.NewMockContainer("", "c2", map[string]string{"zone": "b"}),
+		container.NewMockContainer("", "c3", map[string]string{"zone": "c"}),
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	// chaos commands of 'multi' run concurrently on every tick, with own chaos actions
+	commands := []Pumba{NewPumba(), NewPumba()}
+	for i := range commands {
+		commands[i].GroupBy = "zone"
+	}
+
+	for tick, zone := range []string{"a", "b", "c", "a"} {
+		selected := make([][]container.Container, len(commands))
+		var wg sync.WaitGroup
+		for i, p := range commands {
+			wg.Add(1)
+			go func(i int, p Pumba) {
+				defer wg.Done()
+				var err error
+				selected[i], err = p.listContainers(client, nil, "")
+				assert.NoError(t, err)
+			}(i, p)
+		}
+		wg.Wait()
+		for _, s := range selected {
+			if assert.Len(t, s, 1, "tick %d", tick) {
+				zoneLabel, _ := s[0].Label("zone")
+				assert.Equal(t, zone, zoneLabel, "tick %d", tick)
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package action
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/gaia-adm/pumba/container"
+)
+
+// ExecHookTimeout timeout of command executed in target container by exec hook
+const ExecHookTimeout = time.Minute
+
+// ExecHooks shell commands to exec inside target container before and after disruption,
+// e.g. 'nginx -s reload' after netem ends; empty - no hook
+type ExecHooks struct {
+	Before string
+	After  string
+}
+
+// withExecHooks runs disruption between exec hooks; after hook runs even if disruption fails,
+// hook failures are logged and do not fail chaos action
+func withExecHooks(client container.Client, c container.Container, hooks ExecHooks, disrupt func() error) error {
+	execHook(client, c, "before", hooks.Before)
+	err := disrupt()
+	execHook(client, c, "after", hooks.After)
+	return err
+}
+
+func execHook(client container.Client, c container.Container, stage string, cmd string) {
+	if cmd == "" {
+		return
+	}
+	if client.DryRun() {
+		log.WithField("dry_run", true).Infof("Exec %s hook '%s' in container %s", stage, cmd, c.Name())
+		return
+	}
+	log.Debugf("Exec %s hook '%s' in container %s", stage, cmd, c.Name())
+	code, err := client.ExecContainer(c, []string{"sh", "-c", cmd}, ExecHookTimeout)
+	if err != nil {
+		log.Warnf("Failed to exec %s hook '%s' in container %s: %s", stage, cmd, c.Name(), err)
+	} else if code != 0 {
+		log.Warnf("Exec %s hook '%s' in container %s exited with code %d", stage, cmd, c.Name(), code)
+	}
+}
@@ -0,0 +1,136 @@
+package action
+
+import (
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockChaos mock for Chaos interface
+type MockChaos struct {
+	mock.Mock
+}
+
+// NewMockChaos creates new Chaos mock
+func NewMockChaos() *MockChaos {
+	return &MockChaos{}
+}
+
+// StopContainers mock
+func (m *MockChaos) StopContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// KillContainers mock
+func (m *MockChaos) KillContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// RemoveContainers mock
+func (m *MockChaos) RemoveContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// PauseContainers mock
+func (m *MockChaos) PauseContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// NetemDelayContainers mock
+func (m *MockChaos) NetemDelayContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// NetemCorruptContainers mock
+func (m *MockChaos) NetemCorruptContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// HealthContainers mock
+func (m *MockChaos) HealthContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// MemoryContainers mock
+func (m *MockChaos) MemoryContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// EnvContainers mock
+func (m *MockChaos) EnvContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// SecretsContainers mock
+func (m *MockChaos) SecretsContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// NetemThrottleContainers mock
+func (m *MockChaos) NetemThrottleContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// NetemStopContainers mock
+func (m *MockChaos) NetemStopContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// NetemCombineContainers mock
+func (m *MockChaos) NetemCombineContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// NetemLossContainers mock
+func (m *MockChaos) NetemLossContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// HTTPContainers mock
+func (m *MockChaos) HTTPContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// PortsContainers mock
+func (m *MockChaos) PortsContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// CPUContainers mock
+func (m *MockChaos) CPUContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// OOMContainers mock
+func (m *MockChaos) OOMContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// FDContainers mock
+func (m *MockChaos) FDContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+// PoisonImageContainers mock
+func (m *MockChaos) PoisonImageContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
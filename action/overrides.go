@@ -0,0 +1,105 @@
+package action
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/validate"
+)
+
+// OverrideLabelPrefix prefix of target container labels, overriding chaos parameters for this container:
+// '<prefix><command>.<parameter>', e.g. 'com.gaiaadm.pumba.netem.delay=50ms' or 'com.gaiaadm.pumba.kill.signal=SIGINT'
+const OverrideLabelPrefix = "com.gaiaadm.pumba."
+
+// Overridable chaos parameters (label name suffixes)
+const (
+	// OverrideKillSignal kill signal of 'kill' command
+	OverrideKillSignal = "kill.signal"
+	// OverrideStopTime seconds to wait before killing container, of 'stop' command
+	OverrideStopTime = "stop.time"
+	// OverridePauseDuration pause duration of 'pause' command
+	OverridePauseDuration = "pause.duration"
+	// OverrideNetemDuration netem duration of all 'netem' sub-commands
+	OverrideNetemDuration = "netem.duration"
+	// OverrideNetemDelay delay time of 'netem delay' command, e.g. '50ms'
+	OverrideNetemDelay = "netem.delay"
+	// OverrideNetemJitter delay variation (jitter) of 'netem delay' command, e.g. '10ms'
+	OverrideNetemJitter = "netem.jitter"
+	// OverrideNetemLoss packet loss percent of 'netem loss' command (random loss model)
+	OverrideNetemLoss = "netem.loss"
+)
+
+// maxStopTime upper bound of stop wait time, overridden by container label, in seconds
+const maxStopTime = 3600
+
+// override returns value of container label, overriding chaos parameter, and a flag indicating whether or not
+// the label is set
+func override(c container.Container, param string) (string, bool) {
+	value, ok := c.Label(OverrideLabelPrefix + param)
+	if !ok {
+		return "", false
+	}
+	value = strings.TrimSpace(value)
+	log.Debugf("Container %s overrides %s with '%s' label: %s", c.Name(), param, OverrideLabelPrefix+param, value)
+	return value, true
+}
+
+// overrideError returns error of invalid override label of container
+func overrideError(c container.Container, param string, err error) error {
+	return fmt.Errorf("Invalid label '%s' of container %s: %s", OverrideLabelPrefix+param, c.Name(), err)
+}
+
+// overrideSignal returns signal, overridden by container label, if set
+func overrideSignal(c container.Container, param string, signal string) (string, error) {
+	value, ok := override(c, param)
+	if !ok {
+		return signal, nil
+	}
+	if err := validate.Signal(value); err != nil {
+		return "", overrideError(c, param, err)
+	}
+	return value, nil
+}
+
+// overrideDuration returns duration, overridden by container label (with optional unit suffix), if set
+func overrideDuration(c container.Container, param string, duration time.Duration) (time.Duration, error) {
+	value, ok := override(c, param)
+	if !ok {
+		return duration, nil
+	}
+	d, err := validate.Duration(value)
+	if err != nil {
+		return 0, overrideError(c, param, err)
+	}
+	return d, nil
+}
+
+// overrideMilliseconds returns time in milliseconds, overridden by container label (duration with optional unit
+// suffix, e.g. '50ms'), if set
+func overrideMilliseconds(c container.Container, param string, ms int) (int, error) {
+	d, err := overrideDuration(c, param, time.Duration(ms)*time.Millisecond)
+	if err != nil {
+		return 0, err
+	}
+	return int(d / time.Millisecond), nil
+}
+
+// overrideInt returns integer value in range [min, max], overridden by container label, if set
+func overrideInt(c container.Container, param string, value, min, max int) (int, error) {
+	label, ok := override(c, param)
+	if !ok {
+		return value, nil
+	}
+	v, err := strconv.Atoi(label)
+	if err != nil {
+		return 0, overrideError(c, param, fmt.Errorf("'%s' is not a number", label))
+	}
+	if err = validate.Range(param, v, min, max); err != nil {
+		return 0, overrideError(c, param, err)
+	}
+	return v, nil
+}
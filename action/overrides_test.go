@@ -0,0 +1,87 @@
+package action
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// makeOverrideContainer returns container with labels, overriding chaos parameters
+func TestKillContainers_Override(t *testing.T) {
+	c1 := container.NewMockContainer("", "c1", map[string]string{OverrideLabelPrefix + OverrideKillSignal: "SIGINT"})
+	c2 := container.NewMockContainer("", "c2", nil)
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{c1, c2}, nil)
+	client.On("KillContainer", c1, "SIGINT").Return(nil)
+	client.On("KillContainer", c2, "SIGTERM").Return(nil)
+
+	err := Pumba{}.KillContainers(client, []string{"c1", "c2"}, "", CommandKill{Signal: "SIGTERM"})
+
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestStopAndPauseContainers_Override(t *testing.T) {
+	c1 := container.NewMockContainer("", "c1", map[string]string{OverrideLabelPrefix + OverrideStopTime: "30", OverrideLabelPrefix + OverridePauseDuration: "5s"})
+	c2 := container.NewMockContainer("", "c2", nil)
+	client := container.NewMockSamalbaClient()
+	client.On("StopContainer", c1, 30).Return(nil)
+	client.On("StopContainer", c2, DeafultWaitTime).Return(nil)
+	client.On("PauseContainer", c1, 5*time.Second).Return(nil)
+	client.On("PauseContainer", c2, time.Minute).Return(nil)
+
+	assert.NoError(t, Pumba{}.stopContainers(client, []container.Container{c1, c2}, CommandStop{}))
+	assert.NoError(t, Pumba{}.pauseContainers(client, []container.Container{c1, c2}, time.Minute, ExecHooks{}))
+	client.AssertExpectations(t)
+}
+
+func TestNetemContainers_Override(t *testing.T) {
+	c1 := container.NewMockContainer("", "c1", map[string]string{OverrideLabelPrefix + OverrideNetemDelay: "50ms", OverrideLabelPrefix + OverrideNetemJitter: "5ms", OverrideLabelPrefix + OverrideNetemDuration: "30s"})
+	c2 := container.NewMockContainer("", "c2", map[string]string{OverrideLabelPrefix + OverrideNetemLoss: "5"})
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{c1, c2}, nil)
+	client.On("NetemContainer", c1, "eth0", "delay 50ms 5ms", netem.Filter{}, 30*time.Second).Return(nil)
+	client.On("NetemContainer", c2, "eth0", "delay 100ms", netem.Filter{}, time.Minute).Return(nil)
+	client.On("NetemContainer", c1, "eth0", "loss 20%", netem.Filter{}, 30*time.Second).Return(nil)
+	client.On("NetemContainer", c2, "eth0", "loss 5%", netem.Filter{}, time.Minute).Return(nil)
+	cmd := CommandNetem{NetInterface: "eth0", Duration: time.Minute}
+
+	err := Pumba{}.NetemDelayContainers(client, nil, "^c", CommandNetemDelay{CommandNetem: cmd, Amount: 100})
+	assert.NoError(t, err)
+	err = Pumba{}.NetemLossContainers(client, nil, "^c", CommandNetemLoss{CommandNetem: cmd, Model: netem.LossModelRandom, Percent: 20})
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+}
+
+func TestOverride_Invalid(t *testing.T) {
+	client := container.NewMockSamalbaClient()
+	for _, tt := range []struct {
+		param, value string
+		run          func(c container.Container) error
+		expected     string
+	}{
+		{OverrideKillSignal, "SIGFOO", func(c container.Container) error {
+			return Pumba{}.killContainers(client, []container.Container{c}, "SIGTERM")
+		}, "Invalid label 'com.gaiaadm.pumba.kill.signal' of container c1: Unexpected signal: SIGFOO"},
+		{OverrideStopTime, "forever", func(c container.Container) error {
+			return Pumba{}.stopContainers(client, []container.Container{c}, CommandStop{})
+		}, "Invalid label 'com.gaiaadm.pumba.stop.time' of container c1: 'forever' is not a number"},
+		{OverrideNetemLoss, "150", func(c container.Container) error {
+			client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{c}, nil).Once()
+			return Pumba{}.NetemLossContainers(client, nil, "^c", CommandNetemLoss{Model: netem.LossModelRandom, Percent: 20})
+		}, "Invalid label 'com.gaiaadm.pumba.netem.loss' of container c1: Invalid netem.loss 150: must be between 0 and 100"},
+		{OverridePauseDuration, "-1m", func(c container.Container) error {
+			return Pumba{}.pauseContainers(client, []container.Container{c}, time.Minute, ExecHooks{})
+		}, "Invalid label 'com.gaiaadm.pumba.pause.duration' of container c1: Invalid duration '-1m': must not be negative"},
+	} {
+		err := tt.run(container.NewMockContainer("", "c1", map[string]string{OverrideLabelPrefix + tt.param: tt.value}))
+		assert.EqualError(t, err, tt.expected, tt.param)
+	}
+	// invalid labels fail chaos action before it runs
+	client.AssertNotCalled(t, "KillContainer", mock.Anything, mock.Anything)
+	client.AssertNotCalled(t, "NetemContainer", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,74 @@
+package action
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/gaia-adm/pumba/container"
+)
+
+// peerPair victim of netem chaos with target peers (--target-peer): matching containers, traffic to which is impaired
+type peerPair struct {
+	victim container.Container
+	peers  []container.Container
+}
+
+// selectPeerPairs pairs victims of netem chaos with their peers; in random mode a single random pair of distinct
+// containers is selected on each run, so victim and filter target are selected consistently within a tick;
+// otherwise each container is a victim, paired with all other matching containers
+func (p Pumba) selectPeerPairs(containers []container.Container) []peerPair {
+	if len(containers) < 2 {
+		log.Warning("Target peer filter requires at least 2 matching containers")
+		for _, c := range containers {
+			Skip(c, "", "no peer container (--target-peer)")
+		}
+		return nil
+	}
+	if p.random() {
+		r := rand.New(rand.NewSource(time.Now().UnixNano()))
+		i := r.Intn(len(containers))
+		// peer is selected from the rest of containers
+		j := r.Intn(len(containers) - 1)
+		if j >= i {
+			j++
+		}
+		for k, c := range containers {
+			switch k {
+			case i:
+			case j:
+				Skip(c, "", "selected as random peer of "+containers[i].Name())
+			default:
+				Skip(c, "", "not selected in random mode")
+			}
+		}
+		return []peerPair{{victim: containers[i], peers: []container.Container{containers[j]}}}
+	}
+	pairs := make([]peerPair, 0, len(containers))
+	for i, c := range containers {
+		peers := make([]container.Container, 0, len(containers)-1)
+		peers = append(peers, containers[:i]...)
+		peers = append(peers, containers[i+1:]...)
+		pairs = append(pairs, peerPair{victim: c, peers: peers})
+	}
+	return pairs
+}
+
+// peerIPs returns IPs of peers of victim: IPs on Docker network, if set, or IPs on all networks; fails if no peer
+// IP is known, since empty IP filter would impair all victim traffic
+func peerIPs(p peerPair, network string) ([]net.IP, error) {
+	var ips []net.IP
+	for _, peer := range p.peers {
+		if network == "" {
+			ips = append(ips, peer.IPs()...)
+		} else if ip, ok := peer.NetworkIP(network); ok {
+			ips = append(ips, ip)
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("No IP address of peer containers of %s found", p.victim.Name())
+	}
+	return ips, nil
+}
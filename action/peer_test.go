@@ -0,0 +1,67 @@
+package action
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// peers containers on 'backend' network
+var peers = []container.Container{
+	container.NewMockContainer("", "c0", nil, container.MockNetwork("backend", "10.0.0.1")),
+	container.NewMockContainer("", "c1", nil, container.MockNetwork("backend", "10.0.0.2")),
+	container.NewMockContainer("", "c2", nil, container.MockNetwork("backend", "10.0.0.3")),
+}
+
+func TestSelectPeerPairs(t *testing.T) {
+	cs := peers
+	pairs := Pumba{}.selectPeerPairs(cs)
+	if assert.Len(t, pairs, 3) {
+		assert.Equal(t, cs[1], pairs[1].victim)
+		assert.Equal(t, []container.Container{cs[0], cs[2]}, pairs[1].peers)
+	}
+	assert.Empty(t, Pumba{}.selectPeerPairs(cs[:1]))
+}
+
+func TestSelectPeerPairs_Random(t *testing.T) {
+	cs := peers
+	for i := 0; i < 20; i++ {
+		pairs := Pumba{Random: true}.selectPeerPairs(cs)
+		if assert.Len(t, pairs, 1) && assert.Len(t, pairs[0].peers, 1) {
+			assert.NotEqual(t, pairs[0].victim.Name(), pairs[0].peers[0].Name())
+		}
+	}
+}
+
+func TestPeerIPs_NoIP(t *testing.T) {
+	cs := peers[:2]
+	_, err := peerIPs(peerPair{victim: cs[0], peers: cs[1:]}, "frontend")
+	assert.EqualError(t, err, "No IP address of peer containers of c0 found")
+}
+
+func TestNetemDelayTargetPeerRandom(t *testing.T) {
+	names, cs := []string{"c0", "c1"}, peers[:2]
+	cmd := CommandNetemDelay{
+		CommandNetem: CommandNetem{
+			NetInterface: "eth0",
+			Network:      "backend",
+			TargetPeer:   true,
+			Duration:     1 * time.Second,
+		},
+		Amount: 100,
+	}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	client.On("NetworkInterface", mock.AnythingOfType("container.Container"), "backend").Return("eth1", nil)
+	// victim traffic is impaired toward the other container of pair
+	client.On("NetemContainer", cs[0], "eth1", "delay 100ms", netem.Filter{IPs: []net.IP{net.ParseIP("10.0.0.2")}}, 1*time.Second).Return(nil)
+	client.On("NetemContainer", cs[1], "eth1", "delay 100ms", netem.Filter{IPs: []net.IP{net.ParseIP("10.0.0.1")}}, 1*time.Second).Return(nil)
+	err := Pumba{Random: true}.NetemDelayContainers(client, names, "", cmd)
+	assert.NoError(t, err)
+	client.AssertNumberOfCalls(t, "NetemContainer", 1)
+}
@@ -0,0 +1,23 @@
+package action
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/gaia-adm/pumba/container"
+)
+
+// SkipHandler called for each container, that matches chaos command targets, but is skipped by chaos action
+// (exclusion label, group rotation, random mode, blast radius or lock), with skip reason; nil - skips are only logged
+var SkipHandler func(c container.Container, action string, reason string)
+
+// Skip logs and reports container skipped by chaos action; action is empty, when container is skipped
+// on target selection
+func Skip(c container.Container, action string, reason string) {
+	log.WithFields(log.Fields{
+		"container": c.Name(),
+		"action":    action,
+		"reason":    reason,
+	}).Debugf("Container %s is skipped: %s", c.Name(), reason)
+	if SkipHandler != nil {
+		SkipHandler(c, action, reason)
+	}
+}
@@ -0,0 +1,79 @@
+package action
+
+import (
+	"testing"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// recordSkips sets SkipHandler, that records skip reason per container name; returns function restoring handler
+func recordSkips(skips map[string]string) func() {
+	SkipHandler = func(c container.Container, action string, reason string) { skips[c.Name()] = reason }
+	return func() { SkipHandler = nil }
+}
+
+func TestListContainers_Skipped(t *testing.T) {
+	pumba := *container.NewContainer(&dockerclient.ContainerInfo{
+		Name:   "pumba",
+		Config: &dockerclient.ContainerConfig{Labels: map[string]string{"com.gaiaadm.pumba": "true"}},
+	}, nil)
+	monitor := *container.NewContainer(&dockerclient.ContainerInfo{
+		Name:   "monitor",
+		Config: &dockerclient.ContainerConfig{Labels: map[string]string{"com.gaiaadm.pumba.skip": "true"}},
+	}, nil)
+	c1 := container.NewMockContainer("", "c1", map[string]string{"zone": "a"})
+	c2 := container.NewMockContainer("", "c2", map[string]string{"zone": "b"})
+	c3 := container.NewMockContainer("", "c3", nil)
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{pumba, monitor, c1, c2, c3}, nil)
+	skips := map[string]string{}
+	defer recordSkips(skips)()
+
+	p := NewPumba()
+	p.GroupBy = "zone"
+	cs, err := p.listContainers(client, nil, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []container.Container{c1}, cs)
+	assert.Equal(t, map[string]string{
+		"pumba":   "Pumba container",
+		"monitor": "'com.gaiaadm.pumba.skip' label",
+		"c2":      "group zone=b is not selected (--group-by)",
+		"c3":      "no 'zone' label (--group-by)",
+	}, skips)
+}
+
+func TestListContainers_SkippedByBlastRadius(t *testing.T) {
+	_, cs := makeContainersN(3)
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return(cs, nil)
+	MaxBlast = 2
+	defer func() { MaxBlast = 0 }()
+	skips := map[string]string{}
+	defer recordSkips(skips)()
+
+	_, err := Pumba{}.listContainers(client, nil, "")
+
+	assert.Error(t, err)
+	assert.Len(t, skips, 3)
+	for _, reason := range skips {
+		assert.Equal(t, "blast radius exceeds --max-blast", reason)
+	}
+}
+
+func TestRandomContainer_Skipped(t *testing.T) {
+	_, cs := makeContainersN(3)
+	skips := map[string]string{}
+	defer recordSkips(skips)()
+
+	c := randomContainer(cs)
+
+	assert.Len(t, skips, 2)
+	assert.NotContains(t, skips, c.Name())
+	for _, reason := range skips {
+		assert.Equal(t, "not selected in random mode", reason)
+	}
+}
@@ -0,0 +1,23 @@
+package action
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/gaia-adm/pumba/container"
+)
+
+// withInjectedTools runs chaos action on container with helper tools, copied from host directory into container,
+// and removes them afterwards; empty dir - no tools injected. Tools removal failure is logged and does not
+// fail chaos action.
+func withInjectedTools(client container.Client, c container.Container, dir string, action func() error) error {
+	if dir == "" {
+		return action()
+	}
+	if err := client.InjectTools(c, dir); err != nil {
+		return err
+	}
+	err := action()
+	if rmErr := client.RemoveTools(c); rmErr != nil {
+		log.Warnf("Failed to remove injected tools from container %s: %s", c.Name(), rmErr)
+	}
+	return err
+}
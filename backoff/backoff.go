@@ -0,0 +1,132 @@
+// Package backoff denylists targets, that repeatedly fail chaos actions (e.g. container without tc), with
+// exponential back-off: denylisted targets are skipped instead of failing on every run, and retried when back-off
+// expires (circuit breaker)
+package backoff
+
+import (
+	"sync"
+	"time"
+)
+
+// Observer is notified, when target is denylisted after failed chaos action
+type Observer func(action string, id string, backoff time.Duration)
+
+// key denylist entry key: chaos action of container; target may fail one action (netem) and not another (kill)
+type key struct {
+	action string
+	id     string
+}
+
+// entry failures of chaos action on target
+type entry struct {
+	// failures consecutive failures
+	failures int
+	// trips times target was denylisted since last success
+	trips int
+	until time.Time
+}
+
+// Denylist tracks consecutive failures of chaos actions per target
+type Denylist struct {
+	mu sync.Mutex
+	// after number of consecutive failures, that denylists target
+	after   int
+	base    time.Duration
+	max     time.Duration
+	entries map[key]*entry
+	// observer optional listener of denylisted targets
+	observer Observer
+	now      func() time.Time
+}
+
+// New creates denylist: target is denylisted after consecutive failures for base back-off, doubled on each
+// failure after back-off expires, up to max
+func New(after int, base, max time.Duration) *Denylist {
+	return &Denylist{after: after, base: base, max: max, entries: map[key]*entry{}, now: time.Now}
+}
+
+// Observe sets listener of denylisted targets
+func (d *Denylist) Observe(observer Observer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.observer = observer
+}
+
+// Denied checks if target is denylisted for action; returns remaining back-off and number of failures
+func (d *Denylist) Denied(action, id string) (time.Duration, int, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, ok := d.entries[key{action: action, id: id}]
+	if !ok {
+		return 0, 0, false
+	}
+	if left := e.until.Sub(d.now()); left > 0 {
+		return left, e.failures, true
+	}
+	return 0, e.failures, false
+}
+
+// Failure records failed chaos action on target; returns back-off, if target is denylisted; after back-off
+// expires, single failure denylists target again with doubled back-off (half-open circuit)
+func (d *Denylist) Failure(action, id string) (time.Duration, bool) {
+	d.mu.Lock()
+	d.prune()
+	k := key{action: action, id: id}
+	e, ok := d.entries[k]
+	if !ok {
+		e = &entry{}
+		d.entries[k] = e
+	}
+	e.failures++
+	if e.failures < d.after && e.trips == 0 {
+		d.mu.Unlock()
+		return 0, false
+	}
+	backoff := d.base
+	for i := 0; i < e.trips && backoff < d.max; i++ {
+		backoff *= 2
+	}
+	if backoff > d.max {
+		backoff = d.max
+	}
+	e.trips++
+	e.until = d.now().Add(backoff)
+	observer := d.observer
+	d.mu.Unlock()
+	if observer != nil {
+		observer(action, id, backoff)
+	}
+	return backoff, true
+}
+
+// Success records successful chaos action on target and removes it from denylist
+func (d *Denylist) Success(action, id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.entries, key{action: action, id: id})
+}
+
+// Len returns number of currently denylisted targets (container and action pairs)
+func (d *Denylist) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	n := 0
+	for _, e := range d.entries {
+		if e.until.After(now) {
+			n++
+		}
+	}
+	return n
+}
+
+// prune removes entries of targets, that were not retried for max back-off after back-off expired (e.g. removed
+// containers); must be called holding lock
+func (d *Denylist) prune() {
+	stale := d.now().Add(-d.max)
+	for k, e := range d.entries {
+		if e.trips > 0 && e.until.Before(stale) {
+			delete(d.entries, k)
+		}
+	}
+}
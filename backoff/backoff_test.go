@@ -0,0 +1,70 @@
+package backoff
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testDenylist() (*Denylist, *time.Time) {
+	now := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	d := New(3, time.Minute, 5*time.Minute)
+	d.now = func() time.Time { return now }
+	return d, &now
+}
+
+func TestDenylist_Threshold(t *testing.T) {
+	d, _ := testDenylist()
+	for i := 0; i < 2; i++ {
+		_, ok := d.Failure("netem", "abc")
+		assert.False(t, ok)
+	}
+	_, _, denied := d.Denied("netem", "abc")
+	assert.False(t, denied)
+	backoff, ok := d.Failure("netem", "abc")
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, backoff)
+	left, failures, denied := d.Denied("netem", "abc")
+	assert.True(t, denied)
+	assert.Equal(t, time.Minute, left)
+	assert.Equal(t, 3, failures)
+	// other actions of the same container are not denied
+	_, _, denied = d.Denied("kill", "abc")
+	assert.False(t, denied)
+	assert.Equal(t, 1, d.Len())
+}
+
+func TestDenylist_ExponentialBackoff(t *testing.T) {
+	d, now := testDenylist()
+	var observed []time.Duration
+	d.Observe(func(action string, id string, backoff time.Duration) { observed = append(observed, backoff) })
+	for i := 0; i < 3; i++ {
+		d.Failure("netem", "abc")
+	}
+	// after back-off expires, single failure denylists target again with doubled back-off, up to max
+	for i := 0; i < 3; i++ {
+		*now = now.Add(observed[len(observed)-1])
+		_, _, denied := d.Denied("netem", "abc")
+		assert.False(t, denied)
+		d.Failure("netem", "abc")
+	}
+	assert.Equal(t, []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute}, observed)
+	// success closes circuit
+	*now = now.Add(5 * time.Minute)
+	d.Success("netem", "abc")
+	_, ok := d.Failure("netem", "abc")
+	assert.False(t, ok)
+}
+
+func TestDenylist_Prune(t *testing.T) {
+	d, now := testDenylist()
+	for i := 0; i < 3; i++ {
+		d.Failure("netem", "abc")
+	}
+	// target is not retried (e.g. removed container) for max back-off after back-off expired
+	*now = now.Add(7 * time.Minute)
+	assert.Equal(t, 0, d.Len())
+	d.Failure("kill", "def")
+	assert.Equal(t, 1, len(d.entries))
+}
@@ -0,0 +1,127 @@
+package backoff
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gaia-adm/pumba/action"
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/netem"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// backoffClient skips containers, denylisted after repeated failures of chaos action; dry runs are not tracked
+type backoffClient struct {
+	container.Client
+	denylist *Denylist
+}
+
+// NewClient wraps container client with denylist of repeatedly failing targets
+func NewClient(client container.Client, denylist *Denylist) container.Client {
+	return backoffClient{Client: client, denylist: denylist}
+}
+
+// guarded runs action, unless container is denylisted for it; records action result (failures of removed
+// containers are not counted)
+func (client backoffClient) guarded(c container.Container, name string, fn func() error) error {
+	if client.DryRun() {
+		return fn()
+	}
+	if left, failures, ok := client.denylist.Denied(name, c.ID()); ok {
+		reason := fmt.Sprintf("denylisted after %d failures, retry in %s", failures, left)
+		log.WithFields(log.Fields{"action": name, "backoff": left.String()}).Infof("Container %s is %s; skipping %s", c.Name(), reason, name)
+		action.Skip(c, name, reason)
+		return nil
+	}
+	err := fn()
+	if err == nil {
+		client.denylist.Success(name, c.ID())
+		return nil
+	}
+	// container is gone (removed during chaos action): it can not fail again
+	if container.IsNoSuchContainer(err) {
+		return err
+	}
+	if backoff, ok := client.denylist.Failure(name, c.ID()); ok {
+		log.WithFields(log.Fields{"action": name, "backoff": backoff.String()}).Warnf("Container %s repeatedly fails %s; skipping it for %s", c.Name(), name, backoff)
+	}
+	return err
+}
+
+func (client backoffClient) StopContainer(c container.Container, timeout int) error {
+	return client.guarded(c, "stop", func() error { return client.Client.StopContainer(c, timeout) })
+}
+
+func (client backoffClient) KillContainer(c container.Container, signal string) error {
+	return client.guarded(c, "kill", func() error { return client.Client.KillContainer(c, signal) })
+}
+
+func (client backoffClient) RemoveContainer(c container.Container, force bool, links bool, volumes bool) error {
+	return client.guarded(c, "rm", func() error { return client.Client.RemoveContainer(c, force, links, volumes) })
+}
+
+func (client backoffClient) NetemContainer(c container.Container, netInterface string, netemCmd string, filter netem.Filter, duration time.Duration, reapply bool, probe string) error {
+	return client.guarded(c, "netem", func() error {
+		return client.Client.NetemContainer(c, netInterface, netemCmd, filter, duration, reapply, probe)
+	})
+}
+
+func (client backoffClient) PauseContainer(c container.Container, duration time.Duration) error {
+	return client.guarded(c, "pause", func() error { return client.Client.PauseContainer(c, duration) })
+}
+
+func (client backoffClient) SidecarContainer(c container.Container, image string, cmd []string, duration time.Duration) error {
+	return client.guarded(c, "sidecar", func() error { return client.Client.SidecarContainer(c, image, cmd, duration) })
+}
+
+func (client backoffClient) DropPortsContainer(c container.Container, loss int, duration time.Duration) error {
+	return client.guarded(c, "ports", func() error { return client.Client.DropPortsContainer(c, loss, duration) })
+}
+
+func (client backoffClient) BlackoutContainer(c container.Container, timeout int, duration time.Duration) error {
+	return client.guarded(c, "stop", func() error { return client.Client.BlackoutContainer(c, timeout, duration) })
+}
+
+func (client backoffClient) BurnCPUContainer(c container.Container, workers int, duration time.Duration) error {
+	return client.guarded(c, "cpu", func() error { return client.Client.BurnCPUContainer(c, workers, duration) })
+}
+
+func (client backoffClient) OOMContainer(c container.Container, timeout time.Duration) error {
+	return client.guarded(c, "oom", func() error { return client.Client.OOMContainer(c, timeout) })
+}
+
+func (client backoffClient) ExhaustFDsContainer(c container.Container, free int, duration time.Duration) error {
+	return client.guarded(c, "fd", func() error { return client.Client.ExhaustFDsContainer(c, free, duration) })
+}
+
+func (client backoffClient) HealthcheckContainer(c container.Container, probe health.Probe, duration time.Duration) error {
+	return client.guarded(c, "health", func() error {
+		return client.Client.HealthcheckContainer(c, probe, duration)
+	})
+}
+
+func (client backoffClient) MemoryPressureContainer(c container.Container, percent int, duration time.Duration) error {
+	return client.guarded(c, "memory", func() error {
+		return client.Client.MemoryPressureContainer(c, percent, duration)
+	})
+}
+
+func (client backoffClient) CorruptEnvContainer(c container.Container, set []string, unset []string, timeout int, duration time.Duration) error {
+	return client.guarded(c, "env", func() error {
+		return client.Client.CorruptEnvContainer(c, set, unset, timeout, duration)
+	})
+}
+
+func (client backoffClient) RemoveSecretsContainer(c container.Container, secrets []string, configs []string, duration time.Duration) error {
+	return client.guarded(c, "secrets", func() error {
+		return client.Client.RemoveSecretsContainer(c, secrets, configs, duration)
+	})
+}
+
+func (client backoffClient) PoisonImageContainer(c container.Container, image string, duration time.Duration) error {
+	return client.guarded(c, "poison-image", func() error {
+		return client.Client.PoisonImageContainer(c, image, duration)
+	})
+}
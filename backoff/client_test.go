@@ -0,0 +1,55 @@
+package backoff
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/action"
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_Denylisted(t *testing.T) {
+	c := *container.NewContainer(&dockerclient.ContainerInfo{Id: "abc", Name: "/c1"}, nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("NetemContainer", c, "eth0", "delay 100ms", netem.Filter{}, time.Minute).Return(errors.New("tc: not found"))
+	inner.On("KillContainer", c, "SIGKILL").Return(nil)
+	var skipped []string
+	action.SkipHandler = func(c container.Container, name string, reason string) { skipped = append(skipped, name, reason) }
+	defer func() { action.SkipHandler = nil }()
+	denylist, _ := testDenylist()
+	client := NewClient(inner, denylist)
+
+	for i := 0; i < 3; i++ {
+		assert.EqualError(t, client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{}, time.Minute, false, ""), "tc: not found")
+	}
+	// denylisted container is skipped without error
+	assert.NoError(t, client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{}, time.Minute, false, ""))
+	assert.Equal(t, []string{"netem", "denylisted after 3 failures, retry in 1m0s"}, skipped)
+	// dry runs and other actions are not denied
+	inner.Dry = true
+	assert.EqualError(t, client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{}, time.Minute, false, ""), "tc: not found")
+	inner.Dry = false
+	assert.NoError(t, client.KillContainer(c, "SIGKILL"))
+	inner.AssertNumberOfCalls(t, "NetemContainer", 4)
+	inner.AssertNumberOfCalls(t, "KillContainer", 1)
+}
+
+func TestClient_NoSuchContainer(t *testing.T) {
+	c := *container.NewContainer(&dockerclient.ContainerInfo{Id: "abc", Name: "/c1"}, nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("PauseContainer", c, time.Minute).Return(errors.New("Error response from daemon: No such container: abc"))
+	denylist, _ := testDenylist()
+	client := NewClient(inner, denylist)
+
+	// failures of removed container are not counted
+	for i := 0; i < 4; i++ {
+		assert.Error(t, client.PauseContainer(c, time.Minute))
+	}
+	_, failures, denied := denylist.Denied("pause", "abc")
+	assert.False(t, denied)
+	assert.Equal(t, 0, failures)
+}
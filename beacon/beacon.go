@@ -0,0 +1,49 @@
+package beacon
+
+import (
+	"strings"
+
+	"github.com/gaia-adm/pumba/container"
+)
+
+// beacon container labels
+const (
+	// PhaseLabel chaos action phase: 'start' or 'end'
+	PhaseLabel = "com.gaiaadm.pumba.chaos"
+	// ActionLabel chaos action: kill, stop, rm, pause, netem, ports, cpu or sidecar
+	ActionLabel = "com.gaiaadm.pumba.chaos.action"
+	// TargetIDLabel and TargetNameLabel target container of chaos action
+	TargetIDLabel   = "com.gaiaadm.pumba.chaos.target-id"
+	TargetNameLabel = "com.gaiaadm.pumba.chaos.target-name"
+	// ResultLabel chaos action result ('success' or 'failure'), set on 'end' phase
+	ResultLabel = "com.gaiaadm.pumba.chaos.result"
+	// ContextLabelPrefix prefix of --context pairs labels
+	ContextLabelPrefix = "com.gaiaadm.pumba.chaos.context."
+)
+
+// chaos action phases
+const (
+	Start = "start"
+	End   = "end"
+)
+
+// Labels returns beacon container labels for chaos action phase on target container; actionErr is reported
+// on 'end' phase only
+func Labels(phase string, action string, c container.Container, context map[string]string, actionErr error) map[string]string {
+	labels := map[string]string{
+		PhaseLabel:      phase,
+		ActionLabel:     action,
+		TargetIDLabel:   c.ID(),
+		TargetNameLabel: strings.TrimPrefix(c.Name(), "/"),
+	}
+	if phase == End {
+		labels[ResultLabel] = "success"
+		if actionErr != nil {
+			labels[ResultLabel] = "failure"
+		}
+	}
+	for k, v := range context {
+		labels[ContextLabelPrefix+k] = v
+	}
+	return labels
+}
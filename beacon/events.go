@@ -0,0 +1,40 @@
+package beacon
+
+import (
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/events"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Emitter creates labeled beacon containers at start and end of chaos actions, so external monitoring,
+// watching Docker events, can correlate chaos windows; dry runs do not create beacons
+type Emitter struct {
+	client  container.Client
+	context map[string]string
+}
+
+// NewEmitter creates beacon emitter; context key=value pairs are added to beacon labels
+func NewEmitter(client container.Client, context map[string]string) *Emitter {
+	return &Emitter{client: client, context: context}
+}
+
+// Handle creates start beacon on start of chaos action and end beacon on its result; beacon failures are logged
+// and do not fail action
+func (e *Emitter) Handle(ev events.Event) {
+	if ev.DryRun {
+		return
+	}
+	var labels map[string]string
+	switch ev.Type {
+	case events.Started:
+		labels = Labels(Start, ev.Action, ev.Container, e.context, nil)
+	case events.Succeeded, events.Failed:
+		labels = Labels(End, ev.Action, ev.Container, e.context, ev.Err)
+	default:
+		return
+	}
+	if err := e.client.BeaconContainer(ev.Container, labels); err != nil {
+		log.Warn(err)
+	}
+}
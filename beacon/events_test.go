@@ -0,0 +1,59 @@
+package beacon
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmitter_Beacons(t *testing.T) {
+	c := container.NewMockContainer("abc", "/api_1", nil)
+	inner := container.NewMockSamalbaClient()
+	context := map[string]string{"build": "42"}
+	start := map[string]string{
+		PhaseLabel:                   "start",
+		ActionLabel:                  "pause",
+		TargetIDLabel:                "abc",
+		TargetNameLabel:              "api_1",
+		ContextLabelPrefix + "build": "42",
+	}
+	end := map[string]string{
+		PhaseLabel:                   "end",
+		ActionLabel:                  "pause",
+		TargetIDLabel:                "abc",
+		TargetNameLabel:              "api_1",
+		ResultLabel:                  "failure",
+		ContextLabelPrefix + "build": "42",
+	}
+	inner.On("BeaconContainer", c, start).Return(nil).Once()
+	inner.On("BeaconContainer", c, end).Return(nil).Once()
+	e := NewEmitter(inner, context)
+	e.Handle(events.Event{Type: events.Started, Action: "pause", Container: c})
+	e.Handle(events.Event{Type: events.Failed, Action: "pause", Container: c, Err: errors.New("pause failed")})
+	// no beacon on clean up
+	e.Handle(events.Event{Type: events.CleanedUp, Action: "pause", Container: c})
+	inner.AssertExpectations(t)
+}
+
+func TestEmitter_BeaconError(t *testing.T) {
+	c := container.NewMockContainer("abc", "/api_1", nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("BeaconContainer", c, Labels(Start, "kill", c, nil, nil)).Return(errors.New("no such image"))
+	inner.On("BeaconContainer", c, Labels(End, "kill", c, nil, nil)).Return(nil)
+	e := NewEmitter(inner, nil)
+	e.Handle(events.Event{Type: events.Started, Action: "kill", Container: c})
+	e.Handle(events.Event{Type: events.Succeeded, Action: "kill", Container: c})
+	assert.Equal(t, "success", Labels(End, "kill", c, nil, nil)[ResultLabel])
+	inner.AssertExpectations(t)
+}
+
+func TestEmitter_DryRun(t *testing.T) {
+	c := container.NewMockContainer("abc", "/api_1", nil)
+	inner := container.NewMockSamalbaClient()
+	e := NewEmitter(inner, nil)
+	e.Handle(events.Event{Type: events.Started, Action: "kill", Container: c, DryRun: true})
+	inner.AssertNotCalled(t, "BeaconContainer", c, Labels(Start, "kill", c, nil, nil))
+}
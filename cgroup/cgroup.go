@@ -0,0 +1,119 @@
+// Package cgroup reads and changes cgroup controls of container processes on Docker host; both cgroup v1 and v2
+// (unified hierarchy) are supported
+package cgroup
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cgroup versions: v1 - separate hierarchy per controller, v2 - unified hierarchy
+const (
+	V1 = 1
+	V2 = 2
+)
+
+// Group cgroup of process for controller: cgroup directory and version of its hierarchy; hybrid hosts have both
+// v1 and v2 hierarchies, controller is bound to only one of them
+type Group struct {
+	Dir     string
+	Version int
+}
+
+// MemoryControls returns names of memory controls of cgroup: current memory usage, in bytes, and memory limit, in bytes or 'max' (-1 or large
+// number in v1); v2 limit is memory.high - processes are throttled and put under heavy reclaim above it, but OOM
+// killer is not invoked; v1 has no throttle limit, so memory.limit_in_bytes (hard limit) is used
+func (g Group) MemoryControls() (usage, limit string) {
+	if g.Version == V1 {
+		return "memory.usage_in_bytes", "memory.limit_in_bytes"
+	}
+	return "memory.current", "memory.high"
+}
+
+// FS cgroup filesystem of Docker host: mount points of procfs and cgroup hierarchies (v1 controllers are mounted in
+// subdirectories, e.g. 'memory', v2 unified hierarchy - in mount point itself)
+type FS struct {
+	Proc   string
+	Cgroup string
+}
+
+// Host cgroup v2 filesystem of local host; Pumba must run on Docker host (or in container with host PID namespace
+// and writable host cgroup mount)
+var Host = FS{Proc: "/proc", Cgroup: "/sys/fs/cgroup"}
+
+// Controller returns cgroup of process for controller (e.g. 'memory'), parsed from /proc/<pid>/cgroup: v1 line
+// '4:memory:/docker/<id>' or v2 line '0::/system.slice/docker-<id>.scope'; v2 controller must be enabled in the
+// cgroup (listed in cgroup.controllers)
+func (fs FS) Controller(pid int, name string) (Group, error) {
+	f, err := os.Open(filepath.Join(fs.Proc, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return Group{}, err
+	}
+	defer f.Close()
+	unified := ""
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			unified = fields[2]
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			if controller == name {
+				return Group{Dir: filepath.Join(fs.Cgroup, name, filepath.Clean("/"+fields[2])), Version: V1}, nil
+			}
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return Group{}, err
+	}
+	if unified == "" {
+		return Group{}, fmt.Errorf("Controller %s is not available for process %d: cgroup hierarchy is not mounted", name, pid)
+	}
+	g := Group{Dir: filepath.Join(fs.Cgroup, filepath.Clean("/"+unified)), Version: V2}
+	controllers, err := fs.Read(g.Dir, "cgroup.controllers")
+	if err != nil {
+		return Group{}, err
+	}
+	for _, controller := range strings.Fields(controllers) {
+		if controller == name {
+			return g, nil
+		}
+	}
+	return Group{}, fmt.Errorf("Controller %s is not enabled in cgroup v2 of process %d", name, pid)
+}
+
+// Read returns value of cgroup control file, e.g. 'max' of memory.high
+func (fs FS) Read(dir, control string) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, control))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// Write sets value of cgroup control file
+func (fs FS) Write(dir, control, value string) error {
+	return ioutil.WriteFile(filepath.Join(dir, control), []byte(value), 0644)
+}
+
+// Bytes returns value of cgroup control file in bytes, e.g. memory.current
+func (fs FS) Bytes(dir, control string) (int64, error) {
+	value, err := fs.Read(dir, control)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid value '%s' of %s", value, control)
+	}
+	return n, nil
+}
@@ -0,0 +1,85 @@
+package cgroup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testFS creates cgroup filesystem in temporary directory with files (path relative to root: content), e.g.
+// 'proc/42/cgroup' and cgroup controls
+func testFS(t *testing.T, files map[string]string) (FS, func()) {
+	root, err := ioutil.TempDir("", "pumba-cgroup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range files {
+		path = filepath.Join(root, path)
+		if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return FS{Proc: filepath.Join(root, "proc"), Cgroup: filepath.Join(root, "cgroup")}, func() { os.RemoveAll(root) }
+}
+
+func TestFS_V2(t *testing.T) {
+	fs, cleanup := testFS(t, map[string]string{
+		"proc/42/cgroup": "0::/system.slice/docker-abc.scope\n",
+		"cgroup/system.slice/docker-abc.scope/cgroup.controllers": "cpuset cpu io memory pids\n",
+		"cgroup/system.slice/docker-abc.scope/memory.current":     "104857600\n",
+		"cgroup/system.slice/docker-abc.scope/memory.high":        "max\n",
+	})
+	defer cleanup()
+	g, err := fs.Controller(42, "memory")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, Group{Dir: filepath.Join(fs.Cgroup, "system.slice", "docker-abc.scope"), Version: V2}, g)
+	usage, limit := g.MemoryControls()
+	current, err := fs.Bytes(g.Dir, usage)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(104857600), current)
+	high, err := fs.Read(g.Dir, limit)
+	assert.NoError(t, err)
+	assert.Equal(t, "max", high)
+	assert.NoError(t, fs.Write(g.Dir, limit, "52428800"))
+	high, _ = fs.Read(g.Dir, limit)
+	assert.Equal(t, "52428800", high)
+	_, err = fs.Bytes(g.Dir, "memory.missing")
+	assert.Error(t, err)
+
+	_, err = fs.Controller(42, "hugetlb")
+	assert.EqualError(t, err, "Controller hugetlb is not enabled in cgroup v2 of process 42")
+}
+
+func TestFS_V1(t *testing.T) {
+	fs, cleanup := testFS(t, map[string]string{
+		"proc/42/cgroup": "12:memory:/docker/abc\n11:cpu,cpuacct:/docker/abc\n0::/system.slice/docker.service\n",
+	})
+	defer cleanup()
+	g, err := fs.Controller(42, "memory")
+	assert.NoError(t, err)
+	assert.Equal(t, Group{Dir: filepath.Join(fs.Cgroup, "memory", "docker", "abc"), Version: V1}, g)
+	usage, limit := g.MemoryControls()
+	assert.Equal(t, "memory.usage_in_bytes", usage)
+	assert.Equal(t, "memory.limit_in_bytes", limit)
+	// controller of joint hierarchy
+	g, err = fs.Controller(42, "cpu")
+	assert.NoError(t, err)
+	assert.Equal(t, Group{Dir: filepath.Join(fs.Cgroup, "cpu", "docker", "abc"), Version: V1}, g)
+}
+
+func TestFS_NoController(t *testing.T) {
+	fs, cleanup := testFS(t, map[string]string{
+		"proc/42/cgroup": "11:cpu,cpuacct:/docker/abc\n",
+	})
+	defer cleanup()
+	_, err := fs.Controller(42, "memory")
+	assert.EqualError(t, err, "Controller memory is not available for process 42: cgroup hierarchy is not mounted")
+}
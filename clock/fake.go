@@ -0,0 +1,121 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake clock: time moves only when Advance is called
+type Fake struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []*waiter
+}
+
+type waiter struct {
+	until  time.Time
+	period time.Duration
+	ch     chan time.Time
+}
+
+// NewFake creates fake clock, set to specified time
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// Now returns fake clock time
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Sleep blocks till fake clock is advanced by d
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// After returns channel, that receives fake time after clock is advanced by d
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.add(d, 0).ch
+}
+
+// NewTicker returns ticker, that ticks each time fake clock is advanced by d
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	return &fakeTicker{f: f, w: f.add(d, d)}
+}
+
+func (f *Fake) add(d time.Duration, period time.Duration) *waiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := &waiter{until: f.now.Add(d), period: period, ch: make(chan time.Time, 1)}
+	if d <= 0 && period == 0 {
+		w.ch <- f.now
+		return w
+	}
+	f.waiters = append(f.waiters, w)
+	f.cond.Broadcast()
+	return w
+}
+
+func (f *Fake) remove(w *waiter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, fw := range f.waiters {
+		if fw == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// Advance moves fake clock forward and fires expired timers and tickers
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = f.now.Add(d)
+	waiters := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.until.After(f.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		// like real ticker, drop ticks for slow receivers
+		select {
+		case w.ch <- f.now:
+		default:
+		}
+		if w.period > 0 {
+			for !w.until.After(f.now) {
+				w.until = w.until.Add(w.period)
+			}
+			waiters = append(waiters, w)
+		}
+	}
+	f.waiters = waiters
+}
+
+// BlockUntil blocks till at least n timers and tickers are waiting for the fake clock
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
+
+type fakeTicker struct {
+	f *Fake
+	w *waiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.w.ch
+}
+
+func (t *fakeTicker) Stop() {
+	t.f.remove(t.w)
+}
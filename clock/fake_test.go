@@ -0,0 +1,54 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2016, 8, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFake_After(t *testing.T) {
+	f := NewFake(start)
+	ch := f.After(10 * time.Second)
+	f.Advance(5 * time.Second)
+	select {
+	case <-ch:
+		t.Fatal("timer fired too early")
+	default:
+	}
+	f.Advance(5 * time.Second)
+	assert.Equal(t, start.Add(10*time.Second), <-ch)
+	assert.Equal(t, start.Add(10*time.Second), f.Now())
+}
+
+func TestFake_Sleep(t *testing.T) {
+	f := NewFake(start)
+	done := make(chan bool)
+	go func() {
+		f.Sleep(time.Minute)
+		done <- true
+	}()
+	f.BlockUntil(1)
+	f.Advance(time.Minute)
+	assert.True(t, <-done)
+}
+
+func TestFake_Ticker(t *testing.T) {
+	f := NewFake(start)
+	ticker := f.NewTicker(time.Second)
+	f.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), <-ticker.C())
+	// slow receiver: ticks are dropped
+	f.Advance(time.Second)
+	f.Advance(time.Second)
+	assert.Equal(t, start.Add(2*time.Second), <-ticker.C())
+	ticker.Stop()
+	f.Advance(time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatal("stopped ticker fired")
+	default:
+	}
+}
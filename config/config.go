@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gaia-adm/pumba/logging"
+	"github.com/gaia-adm/pumba/validate"
+	"gopkg.in/yaml.v2"
+)
+
+// Profile named chaos experiment: schedule and chaos commands with targets
+type Profile struct {
+	Description string `yaml:"description"`
+	// Interval recurrent interval for chaos commands
+	Interval string `yaml:"interval"`
+	// Random randomly select single matching container
+	Random bool `yaml:"random"`
+	// GroupBy rotate chaos across groups of containers sharing the label value
+	GroupBy string `yaml:"group-by"`
+	// Commands chaos commands, like 'kill --signal SIGTERM re2:^api'; executed concurrently
+	Commands []string `yaml:"commands"`
+}
+
+// Settings effective settings of profile run: profile settings, overridden by command line options
+type Settings struct {
+	// Interval recurrent interval for chaos commands; 0 - not set
+	Interval time.Duration
+	// Random randomly select single matching container
+	Random bool
+	// GroupBy rotate chaos across groups of containers sharing the label value
+	GroupBy string
+	// Commands chaos commands of profile
+	Commands []string
+}
+
+// Config Pumba configuration file
+type Config struct {
+	Profiles map[string]Profile `yaml:"profiles"`
+	// Logging structured logging configuration: caller, module log levels and sampling
+	Logging logging.Config `yaml:"logging"`
+}
+
+// Load reads configuration from file
+func Load(filename string) (*Config, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Parse parses and validates configuration
+func Parse(data []byte) (*Config, error) {
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("Failed to parse config: %s", err)
+	}
+	for name, p := range cfg.Profiles {
+		if len(p.Commands) == 0 {
+			return nil, fmt.Errorf("Profile '%s' has no commands", name)
+		}
+		if p.Interval != "" {
+			if _, err := validate.Duration(p.Interval); err != nil {
+				return nil, fmt.Errorf("Invalid interval of profile '%s': %s", name, err)
+			}
+		}
+	}
+	if err := cfg.Logging.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Profile returns profile by name
+func (cfg *Config) Profile(name string) (Profile, error) {
+	p, ok := cfg.Profiles[name]
+	if !ok {
+		return p, fmt.Errorf("Unknown profile: '%s'; available profiles: %s", name, strings.Join(cfg.ProfileNames(), ", "))
+	}
+	return p, nil
+}
+
+// Resolve returns effective settings of profile run: command line interval and group-by label (if not empty)
+// override profile settings; random mode is enabled by either of them
+func (p Profile) Resolve(interval string, random bool, groupBy string) (Settings, error) {
+	settings := Settings{Random: p.Random || random, GroupBy: p.GroupBy, Commands: p.Commands}
+	if groupBy != "" {
+		settings.GroupBy = groupBy
+	}
+	if interval == "" {
+		interval = p.Interval
+	}
+	if interval != "" {
+		var err error
+		if settings.Interval, err = validate.Duration(interval); err != nil {
+			return settings, fmt.Errorf("Invalid interval: %s", err)
+		}
+	}
+	return settings, nil
+}
+
+// ProfileNames returns sorted profile names
+func (cfg *Config) ProfileNames() []string {
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
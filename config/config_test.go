@@ -0,0 +1,95 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig = `
+profiles:
+  nightly-soak:
+    description: kill API and slow down DB every hour
+    interval: 1h
+    random: true
+    commands:
+      - kill --signal SIGTERM re2:^api
+      - netem --duration 5m delay --amount 500 re2:^db
+  smoke:
+    interval: 30s
+    commands:
+      - pause --duration 10s re2:^web
+`
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(testConfig))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"nightly-soak", "smoke"}, cfg.ProfileNames())
+	p, err := cfg.Profile("nightly-soak")
+	assert.NoError(t, err)
+	assert.Equal(t, "1h", p.Interval)
+	assert.True(t, p.Random)
+	assert.Equal(t, []string{"kill --signal SIGTERM re2:^api", "netem --duration 5m delay --amount 500 re2:^db"}, p.Commands)
+}
+
+func TestProfile_Unknown(t *testing.T) {
+	cfg, err := Parse([]byte(testConfig))
+	assert.NoError(t, err)
+	_, err = cfg.Profile("weekly")
+	assert.EqualError(t, err, "Unknown profile: 'weekly'; available profiles: nightly-soak, smoke")
+}
+
+func TestProfile_Resolve(t *testing.T) {
+	p := Profile{Interval: "1h", GroupBy: "zone", Commands: []string{"kill c1"}}
+	s, err := p.Resolve("", false, "")
+	assert.NoError(t, err)
+	assert.Equal(t, Settings{Interval: time.Hour, GroupBy: "zone", Commands: []string{"kill c1"}}, s)
+	// command line options override profile settings
+	s, err = p.Resolve("30s", true, "rack")
+	assert.NoError(t, err)
+	assert.Equal(t, Settings{Interval: 30 * time.Second, Random: true, GroupBy: "rack", Commands: []string{"kill c1"}}, s)
+	// profile random mode is not disabled by command line
+	s, err = Profile{Random: true}.Resolve("", false, "")
+	assert.NoError(t, err)
+	assert.Equal(t, Settings{Random: true}, s)
+	_, err = p.Resolve("soon", false, "")
+	assert.Error(t, err)
+	_, err = p.Resolve("-30s", false, "")
+	assert.Error(t, err)
+}
+
+func TestParse_NoCommands(t *testing.T) {
+	_, err := Parse([]byte("profiles:\n  empty:\n    interval: 1m\n"))
+	assert.EqualError(t, err, "Profile 'empty' has no commands")
+}
+
+func TestParse_BadInterval(t *testing.T) {
+	_, err := Parse([]byte("profiles:\n  bad:\n    interval: soon\n    commands: [kill c1 c2]\n"))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid interval of profile 'bad'")
+}
+
+func TestParse_NegativeInterval(t *testing.T) {
+	_, err := Parse([]byte("profiles:\n  bad:\n    interval: -1m\n    commands: [kill c1 c2]\n"))
+	assert.EqualError(t, err, "Invalid interval of profile 'bad': Invalid duration '-1m': must not be negative")
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse([]byte("profiles: [a, b"))
+	assert.Error(t, err)
+}
+
+func TestParse_Logging(t *testing.T) {
+	cfg, err := Parse([]byte("logging:\n  caller: true\n  levels:\n    container: debug\n    scheduler: warning\n  sampling:\n    tick: 5s\n    first: 10\n    thereafter: 100\n"))
+	assert.NoError(t, err)
+	assert.True(t, cfg.Logging.Caller)
+	assert.Equal(t, map[string]string{"container": "debug", "scheduler": "warning"}, cfg.Logging.Levels)
+	assert.Equal(t, &logging.Sampling{Tick: "5s", First: 10, Thereafter: 100}, cfg.Logging.Sampling)
+}
+
+func TestParse_BadLogging(t *testing.T) {
+	_, err := Parse([]byte("logging:\n  levels:\n    container: loud\n"))
+	assert.EqualError(t, err, "Invalid log level 'loud' of module 'container'")
+}
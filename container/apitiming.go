@@ -0,0 +1,230 @@
+package container
+
+import (
+	"io"
+	"time"
+
+	"golang.org/x/net/context"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/samalba/dockerclient"
+
+	engineapi "github.com/docker/engine-api/client"
+	enginetypes "github.com/docker/engine-api/types"
+	enginecontainer "github.com/docker/engine-api/types/container"
+	enginenetwork "github.com/docker/engine-api/types/network"
+)
+
+// APIObserver receives duration of Docker API call (e.g. 'exec_start') made by Pumba action (e.g. 'netem');
+// containers are listed by 'list' action
+type APIObserver func(action, call string, d time.Duration, err error)
+
+// ObserveAPI reports Docker API calls of client, created with NewClient (optionally wrapped with NewDryRunClient), to
+// observer; other clients are returned as is
+func ObserveAPI(client Client, observer APIObserver) Client {
+	switch c := client.(type) {
+	case dockerClient:
+		c.observer = observer
+		return c
+	case dryRunClient:
+		c.wrapped = ObserveAPI(c.wrapped, observer)
+		return c
+	}
+	return client
+}
+
+// timed returns copy of client, which reports Docker API calls to observer on behalf of action;
+// nested calls keep action of outer call
+func (client dockerClient) timed(action string) dockerClient {
+	if client.observer == nil || client.action != "" {
+		return client
+	}
+	client.action = action
+	t := apiTimer{action: action, observer: client.observer}
+	if client.api != nil {
+		client.api = timedAPI{Client: client.api, timer: t}
+	}
+	if client.apiClient != nil {
+		client.apiClient = timedEngineAPI{ContainerAPIClient: client.apiClient, ImageAPIClient: client.apiClient, timer: t}
+	}
+	return client
+}
+
+// apiTimer measures Docker API calls of action
+type apiTimer struct {
+	action   string
+	observer APIObserver
+}
+
+func (t apiTimer) observe(call string, start time.Time, err error) {
+	d := time.Since(start)
+	log.WithFields(log.Fields{"action": t.action, "call": call, "duration": d}).Debug("Docker API call")
+	t.observer(t.action, call, d, err)
+}
+
+// timedAPI measures samalba/dockerclient calls; other calls (e.g. event monitoring) are not measured
+type timedAPI struct {
+	dockerclient.Client
+	timer apiTimer
+}
+
+func (api timedAPI) ListContainers(all, size bool, filters string) ([]dockerclient.Container, error) {
+	start := time.Now()
+	cs, err := api.Client.ListContainers(all, size, filters)
+	api.timer.observe("list", start, err)
+	return cs, err
+}
+
+func (api timedAPI) InspectContainer(id string) (*dockerclient.ContainerInfo, error) {
+	start := time.Now()
+	info, err := api.Client.InspectContainer(id)
+	api.timer.observe("inspect", start, err)
+	return info, err
+}
+
+func (api timedAPI) InspectImage(id string) (*dockerclient.ImageInfo, error) {
+	start := time.Now()
+	info, err := api.Client.InspectImage(id)
+	api.timer.observe("inspect_image", start, err)
+	return info, err
+}
+
+func (api timedAPI) CreateContainer(config *dockerclient.ContainerConfig, name string, auth *dockerclient.AuthConfig) (string, error) {
+	start := time.Now()
+	id, err := api.Client.CreateContainer(config, name, auth)
+	api.timer.observe("create", start, err)
+	return id, err
+}
+
+func (api timedAPI) StartContainer(id string, config *dockerclient.HostConfig) error {
+	start := time.Now()
+	err := api.Client.StartContainer(id, config)
+	api.timer.observe("start", start, err)
+	return err
+}
+
+func (api timedAPI) StopContainer(id string, timeout int) error {
+	start := time.Now()
+	err := api.Client.StopContainer(id, timeout)
+	api.timer.observe("stop", start, err)
+	return err
+}
+
+func (api timedAPI) KillContainer(id, signal string) error {
+	start := time.Now()
+	err := api.Client.KillContainer(id, signal)
+	api.timer.observe("kill", start, err)
+	return err
+}
+
+func (api timedAPI) PauseContainer(name string) error {
+	start := time.Now()
+	err := api.Client.PauseContainer(name)
+	api.timer.observe("pause", start, err)
+	return err
+}
+
+func (api timedAPI) UnpauseContainer(name string) error {
+	start := time.Now()
+	err := api.Client.UnpauseContainer(name)
+	api.timer.observe("unpause", start, err)
+	return err
+}
+
+func (api timedAPI) RenameContainer(oldName string, newName string) error {
+	start := time.Now()
+	err := api.Client.RenameContainer(oldName, newName)
+	api.timer.observe("rename", start, err)
+	return err
+}
+
+func (api timedAPI) RemoveImage(name string, force bool) ([]*dockerclient.ImageDelete, error) {
+	start := time.Now()
+	deleted, err := api.Client.RemoveImage(name, force)
+	api.timer.observe("remove_image", start, err)
+	return deleted, err
+}
+
+func (api timedAPI) ListImages(all bool) ([]*dockerclient.Image, error) {
+	start := time.Now()
+	images, err := api.Client.ListImages(all)
+	api.timer.observe("list_images", start, err)
+	return images, err
+}
+
+// timedEngineAPI measures docker/engine-api container calls; image calls are not measured
+type timedEngineAPI struct {
+	engineapi.ContainerAPIClient
+	engineapi.ImageAPIClient
+	timer apiTimer
+}
+
+func (api timedEngineAPI) ContainerCreate(ctx context.Context, config *enginecontainer.Config, hostConfig *enginecontainer.HostConfig, networkingConfig *enginenetwork.NetworkingConfig, name string) (enginetypes.ContainerCreateResponse, error) {
+	start := time.Now()
+	resp, err := api.ContainerAPIClient.ContainerCreate(ctx, config, hostConfig, networkingConfig, name)
+	api.timer.observe("create", start, err)
+	return resp, err
+}
+
+func (api timedEngineAPI) ContainerStart(ctx context.Context, container string, options enginetypes.ContainerStartOptions) error {
+	start := time.Now()
+	err := api.ContainerAPIClient.ContainerStart(ctx, container, options)
+	api.timer.observe("start", start, err)
+	return err
+}
+
+func (api timedEngineAPI) ContainerStop(ctx context.Context, container string, timeout *time.Duration) error {
+	start := time.Now()
+	err := api.ContainerAPIClient.ContainerStop(ctx, container, timeout)
+	api.timer.observe("stop", start, err)
+	return err
+}
+
+func (api timedEngineAPI) ContainerRemove(ctx context.Context, container string, options enginetypes.ContainerRemoveOptions) error {
+	start := time.Now()
+	err := api.ContainerAPIClient.ContainerRemove(ctx, container, options)
+	api.timer.observe("remove", start, err)
+	return err
+}
+
+func (api timedEngineAPI) ContainerCommit(ctx context.Context, container string, options enginetypes.ContainerCommitOptions) (enginetypes.ContainerCommitResponse, error) {
+	start := time.Now()
+	resp, err := api.ContainerAPIClient.ContainerCommit(ctx, container, options)
+	api.timer.observe("commit", start, err)
+	return resp, err
+}
+
+func (api timedEngineAPI) ContainerExecCreate(ctx context.Context, container string, config enginetypes.ExecConfig) (enginetypes.ContainerExecCreateResponse, error) {
+	start := time.Now()
+	resp, err := api.ContainerAPIClient.ContainerExecCreate(ctx, container, config)
+	api.timer.observe("exec_create", start, err)
+	return resp, err
+}
+
+func (api timedEngineAPI) ContainerExecStart(ctx context.Context, execID string, config enginetypes.ExecStartCheck) error {
+	start := time.Now()
+	err := api.ContainerAPIClient.ContainerExecStart(ctx, execID, config)
+	api.timer.observe("exec_start", start, err)
+	return err
+}
+
+func (api timedEngineAPI) ContainerExecAttach(ctx context.Context, execID string, config enginetypes.ExecConfig) (enginetypes.HijackedResponse, error) {
+	start := time.Now()
+	resp, err := api.ContainerAPIClient.ContainerExecAttach(ctx, execID, config)
+	api.timer.observe("exec_attach", start, err)
+	return resp, err
+}
+
+func (api timedEngineAPI) ContainerExecInspect(ctx context.Context, execID string) (enginetypes.ContainerExecInspect, error) {
+	start := time.Now()
+	resp, err := api.ContainerAPIClient.ContainerExecInspect(ctx, execID)
+	api.timer.observe("exec_inspect", start, err)
+	return resp, err
+}
+
+func (api timedEngineAPI) CopyToContainer(ctx context.Context, container, path string, content io.Reader, options enginetypes.CopyToContainerOptions) error {
+	start := time.Now()
+	err := api.ContainerAPIClient.CopyToContainer(ctx, container, path, content, options)
+	api.timer.observe("copy", start, err)
+	return err
+}
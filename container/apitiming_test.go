@@ -0,0 +1,97 @@
+package container
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/netem"
+
+	"github.com/docker/engine-api/types"
+	"github.com/samalba/dockerclient"
+	"github.com/samalba/dockerclient/mockclient"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+// recordedCall Docker API call reported to observer
+type recordedCall struct {
+	action string
+	call   string
+	err    error
+}
+
+func recordCalls(calls *[]recordedCall) APIObserver {
+	return func(action, call string, d time.Duration, err error) {
+		*calls = append(*calls, recordedCall{action: action, call: call, err: err})
+	}
+}
+
+func TestObserveAPI_ListContainers(t *testing.T) {
+	ci := &dockerclient.ContainerInfo{Image: "abc123", Config: &dockerclient.ContainerConfig{Image: "img"}}
+	api := mockclient.NewMockClient()
+	api.On("ListContainers", false, false, "").Return([]dockerclient.Container{{Id: "foo", Names: []string{"bar"}}}, nil)
+	api.On("InspectContainer", "foo").Return(ci, nil)
+	api.On("InspectImage", "abc123").Return(&dockerclient.ImageInfo{}, nil)
+
+	var calls []recordedCall
+	client := ObserveAPI(dockerClient{api: api}, recordCalls(&calls))
+	_, err := client.ListContainers(allContainers)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []recordedCall{
+		{action: "list", call: "list"},
+		{action: "list", call: "inspect"},
+		{action: "list", call: "inspect_image"},
+	}, calls)
+	api.AssertExpectations(t)
+}
+
+func TestObserveAPI_Error(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123", Name: "foo"}}
+	killErr := errors.New("oops")
+	api := mockclient.NewMockClient()
+	api.On("KillContainer", "abc123", "SIGKILL").Return(killErr)
+
+	var calls []recordedCall
+	client := ObserveAPI(dockerClient{api: api}, recordCalls(&calls))
+	err := client.KillContainer(c, "SIGKILL")
+
+	assert.Equal(t, killErr, Cause(err))
+	assert.Equal(t, []recordedCall{{action: "kill", call: "kill", err: killErr}}, calls)
+}
+
+func TestObserveAPI_NestedCalls(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	config := types.ExecConfig{Cmd: []string{"tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "1000ms"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.ContainerExecCreateResponse{"testID"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "testID", types.ExecStartCheck{}).Return(nil)
+	stopConfig := types.ExecConfig{Cmd: []string{"tc", "qdisc", "del", "dev", "eth0", "root", "netem"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", stopConfig).Return(types.ContainerExecCreateResponse{"testID"}, nil)
+	// qdiscs are checked before netem is applied and verified after it is applied and removed
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc noqueue 0: root refcnt 2\r\n")
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc netem 8001: root refcnt 2 limit 1000 delay 1.0s\r\n")
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc noqueue 0: root refcnt 2\r\n")
+
+	var calls []recordedCall
+	client := ObserveAPI(dockerClient{apiClient: engineClient}, recordCalls(&calls))
+	err := client.NetemContainer(c, "eth0", "delay 1000ms", netem.Filter{}, 1*time.Millisecond, false, "")
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, calls)
+	observed := map[string]bool{}
+	for _, call := range calls {
+		assert.Equal(t, "netem", call.action)
+		observed[call.call] = true
+	}
+	assert.True(t, observed["exec_create"])
+	assert.True(t, observed["exec_start"])
+	engineClient.AssertExpectations(t)
+}
+
+func TestObserveAPI_OtherClient(t *testing.T) {
+	client := NewMockSamalbaClient()
+	assert.Equal(t, client, ObserveAPI(client, recordCalls(&[]recordedCall{})))
+}
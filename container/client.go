@@ -2,50 +2,118 @@ package container
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"golang.org/x/net/context"
 
+	"github.com/gaia-adm/pumba/cgroup"
+	"github.com/gaia-adm/pumba/clock"
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/iptables"
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/gaia-adm/pumba/stress"
+	"github.com/gaia-adm/pumba/teardown"
+
 	log "github.com/Sirupsen/logrus"
 	"github.com/samalba/dockerclient"
 
 	engineapi "github.com/docker/engine-api/client"
 	enginetypes "github.com/docker/engine-api/types"
+	enginecontainer "github.com/docker/engine-api/types/container"
 )
 
 const (
 	defaultStopSignal = "SIGTERM"
 	defaultKillSignal = "SIGKILL"
-	dryRunPrefix      = "DRY: "
+	sidecarNetCap     = "NET_ADMIN"
+	sidecarStopTime   = 10 * time.Second
+	execPollInterval  = 100 * time.Millisecond
+	// extra time for CPU burn exec to finish after its duration
+	burnCPUGracePeriod = 10 * time.Second
+	// extra time for OOM balloon exec to finish after its timeout
+	oomGracePeriod = 10 * time.Second
+	// extra time for file descriptor exhaustion helper to restore limits after its duration
+	fdGracePeriod = 10 * time.Second
+	// netem removal verification
+	netemStopRetries    = 2
+	netemStopRetryDelay = 1 * time.Second
 )
 
+// AllInterfaces netem network interface name, that selects all network interfaces of container, that are up,
+// except loopback
+const AllInterfaces = "all"
+
 // A Filter is a prototype for a function that can be used to filter the
 // results from a call to the ListContainers() method on the Client.
 type Filter func(Container) bool
 
+// Image Docker image, e.g. container snapshot
+type Image struct {
+	ID      string
+	Tags    []string
+	Created time.Time
+	Labels  map[string]string
+}
+
 // Client interface
 type Client interface {
 	ListContainers(Filter) ([]Container, error)
-	StopContainer(Container, int, bool) error
-	KillContainer(Container, string, bool) error
+	StopContainer(Container, int) error
+	KillContainer(Container, string) error
 	StartContainer(Container) error
 	RenameContainer(Container, string) error
-	RemoveImage(Container, bool, bool) error
-	RemoveContainer(Container, bool, bool, bool, bool) error
-	NetemContainer(Container, string, string, net.IP, time.Duration, bool) error
-	PauseContainer(Container, time.Duration, bool) error
+	RemoveImage(Container, bool) error
+	RemoveContainer(Container, bool, bool, bool) error
+	NetemContainer(Container, string, string, netem.Filter, time.Duration, bool, string) error
+	PauseContainer(Container, time.Duration) error
+	SidecarContainer(Container, string, []string, time.Duration) error
+	ExecContainer(Container, []string, time.Duration) (int, error)
+	NetworkInterface(Container, string) (string, error)
+	NetworkInterfaces(Container) ([]string, error)
+	NetemLeftovers(Container, string) ([]string, error)
+	ResolveAlias(Container, string) ([]net.IP, error)
+	DropPortsContainer(Container, int, time.Duration) error
+	UnpauseContainer(Container) error
+	StopNetemContainer(Container, string, netem.Filter) error
+	ClearNetemContainer(Container, string) error
+	RestorePortsContainer(Container, int) error
+	InjectTools(Container, string) error
+	RemoveTools(Container) error
+	BurnCPUContainer(Container, int, time.Duration) error
+	OOMContainer(Container, time.Duration) error
+	ExhaustFDsContainer(Container, int, time.Duration) error
+	PoisonImageContainer(Container, string, time.Duration) error
+	HealthcheckContainer(Container, health.Probe, time.Duration) error
+	MemoryPressureContainer(Container, int, time.Duration) error
+	CorruptEnvContainer(Container, []string, []string, int, time.Duration) error
+	RemoveSecretsContainer(Container, []string, []string, time.Duration) error
+	BlackoutContainer(Container, int, time.Duration) error
+	BeaconContainer(Container, map[string]string) error
+	CommitContainer(Container, string, map[string]string) (string, error)
+	ListImages(string) ([]Image, error)
+	DeleteImage(string, bool) error
+	ExitWatch(Container, time.Duration) (<-chan time.Time, error)
+	DryRun() bool
 }
 
 // NewClient returns a new Client instance which can be used to interact with
-// the Docker API.
-func NewClient(dockerHost string, tlsConfig *tls.Config) Client {
+// the Docker API; proxy (if not nil) is used for TCP Docker hosts.
+func NewClient(dockerHost string, tlsConfig *tls.Config, proxy func(*http.Request) (*url.URL, error)) Client {
 	docker, err := dockerclient.NewDockerClient(dockerHost, tlsConfig)
 	if err != nil {
 		log.Fatalf("Error instantiating Docker client: %s", err)
 	}
+	setProxy(docker.HTTPClient, dockerHost, proxy)
 
 	// Use HTTP Client used by dockerclient to create engine-api client
 	apiClient, err := engineapi.NewClient(dockerHost, "", docker.HTTPClient, nil)
@@ -53,25 +121,75 @@ func NewClient(dockerHost string, tlsConfig *tls.Config) Client {
 		log.Fatalf("Error instantiating Docker engine-api: %s", err)
 	}
 
-	return dockerClient{api: docker, apiClient: apiClient}
+	services := httpServiceAPI{client: docker.HTTPClient, url: docker.URL}
+	return dockerClient{api: docker, apiClient: apiClient, services: services, clock: clock.New(), tools: newToolRegistry(), events: newEventHub(docker)}
+}
+
+// engineAPI docker/engine-api container and image calls, used by client
+type engineAPI interface {
+	engineapi.ContainerAPIClient
+	engineapi.ImageAPIClient
 }
 
 type dockerClient struct {
 	api dockerclient.Client
 	// NOTE: use official docker/engine-api instead of samalba/dockerclient; lazy refactoring
-	apiClient engineapi.ContainerAPIClient
+	apiClient engineAPI
+	// Swarm service API, called with raw JSON
+	services serviceAPI
+	// clock used for chaos durations and timeouts; fake clock in tests
+	clock clock.Clock
+	// nsenter executor, used when container exec is not available
+	nsenter nsenterFunc
+	// host command executor
+	hostExec hostExecFunc
+	// host name resolver of netem target hosts; net.LookupIP if not set
+	lookup lookupFunc
+	// cgroup filesystem of Docker host; temporary directory in tests
+	cgroupFS *cgroup.FS
+	// tools injected into containers
+	tools *toolRegistry
+	// shared Docker events stream
+	events *eventHub
+	// user and working directory of exec commands
+	execOpts ExecOptions
+	// name prefix of listed containers; empty - all containers
+	namePrefix string
+	// observer of Docker API calls and action, on behalf of which calls are made
+	observer APIObserver
+	action   string
+}
+
+// getClock returns client clock; real clock if not set
+func (client dockerClient) getClock() clock.Clock {
+	if client.clock == nil {
+		return clock.New()
+	}
+	return client.clock
+}
+
+// getEvents returns shared Docker events stream; stream of single watcher, if not set
+func (client dockerClient) getEvents() *eventHub {
+	if client.events == nil {
+		return newEventHub(client.api)
+	}
+	return client.events
 }
 
 func (client dockerClient) ListContainers(fn Filter) ([]Container, error) {
+	client = client.timed("list")
 	cs := []Container{}
 
 	log.Debug("Retrieving running containers")
 
-	runningContainers, err := client.api.ListContainers(false, false, "")
+	runningContainers, err := client.api.ListContainers(false, false, nameFilters(client.namePrefix))
 	if err != nil {
 		return nil, err
 	}
 	for _, runningContainer := range runningContainers {
+		if !hasNamePrefix(runningContainer.Names, client.namePrefix) {
+			continue
+		}
 		containerInfo, err := client.api.InspectContainer(runningContainer.Id)
 		if err != nil {
 			return nil, err
@@ -92,55 +210,89 @@ func (client dockerClient) ListContainers(fn Filter) ([]Container, error) {
 	return cs, nil
 }
 
-func (client dockerClient) KillContainer(c Container, signal string, dryrun bool) error {
-	prefix := ""
-	if dryrun {
-		prefix = dryRunPrefix
+// DryRun reports, whether client only logs chaos actions; Docker client runs them
+func (client dockerClient) DryRun() bool {
+	return false
+}
+
+func (client dockerClient) KillContainer(c Container, signal string) (err error) {
+	client = client.timed("kill")
+	defer wrapError("kill", c, &err)
+	log.Infof("Killing %s (%s) with signal %s", c.Name(), c.ID(), signal)
+	return client.api.KillContainer(c.ID(), signal)
+}
+
+// ExitWatch watches Docker events for container exit ('die' event); returned channel receives exit time,
+// or is closed without value, if container does not exit within timeout
+func (client dockerClient) ExitWatch(c Container, timeout time.Duration) (<-chan time.Time, error) {
+	hub := client.getEvents()
+	w, err := hub.watch(c.ID(), "die")
+	if err != nil {
+		return nil, err
 	}
-	log.Infof("%sKilling %s (%s) with signal %s", prefix, c.Name(), c.ID(), signal)
-	if !dryrun {
-		if err := client.api.KillContainer(c.ID(), signal); err != nil {
-			return err
+	exited := make(chan time.Time, 1)
+	go func() {
+		defer hub.unwatch(w)
+		defer close(exited)
+		select {
+		case <-client.getClock().After(timeout):
+		case e, ok := <-w.events:
+			if ok && e.Error == nil {
+				exited <- client.getClock().Now()
+			}
 		}
-	}
-	return nil
+	}()
+	return exited, nil
 }
 
-func (client dockerClient) StopContainer(c Container, timeout int, dryrun bool) error {
+func (client dockerClient) StopContainer(c Container, timeout int) (err error) {
+	client = client.timed("stop")
+	defer wrapError("stop", c, &err)
 	signal := c.StopSignal()
 	if signal == "" {
 		signal = defaultStopSignal
 	}
-	prefix := ""
-	if dryrun {
-		prefix = dryRunPrefix
+	log.Infof("Stopping %s (%s) with %s", c.Name(), c.ID(), signal)
+	if err := client.api.KillContainer(c.ID(), signal); err != nil {
+		return err
 	}
-	log.Infof("%sStopping %s (%s) with %s", prefix, c.Name(), c.ID(), signal)
-	if !dryrun {
-		if err := client.api.KillContainer(c.ID(), signal); err != nil {
-			return err
-		}
 
-		// Wait for container to exit, but proceed anyway after the timeout elapses
-		if err := client.waitForStop(c, timeout); err != nil {
-			log.Debugf("Error waiting for container %s (%s) to stop: ''%s'", c.Name(), c.ID(), err.Error())
-		}
+	// Wait for container to exit, but proceed anyway after the timeout elapses
+	if err := client.waitForStop(c, timeout); err != nil {
+		log.Debugf("Error waiting for container %s (%s) to stop: ''%s'", c.Name(), c.ID(), err.Error())
+	}
 
-		log.Debugf("Killing container %s with %s", c.ID(), defaultKillSignal)
-		if err := client.api.KillContainer(c.ID(), defaultKillSignal); err != nil {
-			return err
-		}
+	log.Debugf("Killing container %s with %s", c.ID(), defaultKillSignal)
+	if err := client.api.KillContainer(c.ID(), defaultKillSignal); err != nil {
+		return err
+	}
 
-		// Wait for container to be removed. In this case an error is a good thing
-		if err := client.waitForStop(c, timeout); err == nil {
-			return fmt.Errorf("Container %s (%s) could not be stopped", c.Name(), c.ID())
-		}
+	// Wait for container to be removed. In this case an error is a good thing
+	if err := client.waitForStop(c, timeout); err == nil {
+		return fmt.Errorf("Container %s (%s) could not be stopped", c.Name(), c.ID())
 	}
 
 	return nil
 }
 
-func (client dockerClient) StartContainer(c Container) error {
+// BlackoutContainer stops container (waiting timeout seconds before killing it), keeps it down for specified
+// duration and starts the same container again
+func (client dockerClient) BlackoutContainer(c Container, timeout int, duration time.Duration) (err error) {
+	client = client.timed("stop")
+	defer wrapError("stop", c, &err)
+	log.Infof("Stopping %s (%s) for %s", c.Name(), c.ID(), duration)
+	if err := client.api.StopContainer(c.ID(), timeout); err != nil {
+		return err
+	}
+	log.Debugf("Container %s stopped for %s", c.ID(), duration)
+	client.getClock().Sleep(duration)
+	log.Infof("Starting %s (%s) after %s", c.Name(), c.ID(), duration)
+	return client.api.StartContainer(c.ID(), nil)
+}
+
+func (client dockerClient) StartContainer(c Container) (err error) {
+	client = client.timed("start")
+	defer wrapError("start", c, &err)
 	config := c.runtimeConfig()
 	hostConfig := c.hostConfig()
 	name := c.Name()
@@ -158,181 +310,837 @@ func (client dockerClient) StartContainer(c Container) error {
 }
 
 func (client dockerClient) RenameContainer(c Container, newName string) error {
+	client = client.timed("rename")
 	log.Debugf("Renaming container %s (%s) to %s", c.Name(), c.ID(), newName)
 	return client.api.RenameContainer(c.ID(), newName)
 }
 
-func (client dockerClient) RemoveImage(c Container, force bool, dryrun bool) error {
+func (client dockerClient) RemoveImage(c Container, force bool) error {
+	client = client.timed("rmi")
 	imageID := c.ImageID()
-	prefix := ""
-	if dryrun {
-		prefix = dryRunPrefix
+	log.Infof("Removing image %s", imageID)
+	_, err := client.api.RemoveImage(imageID, force)
+	return err
+}
+
+func (client dockerClient) RemoveContainer(c Container, force bool, links bool, volumes bool) (err error) {
+	client = client.timed("rm")
+	defer wrapError("rm", c, &err)
+	log.Infof("Removing container %s", c.ID())
+	removeOpts := enginetypes.ContainerRemoveOptions{
+		RemoveVolumes: links,
+		RemoveLinks:   volumes,
+		Force:         force,
 	}
-	log.Infof("%sRemoving image %s", prefix, imageID)
-	if !dryrun {
-		_, err := client.api.RemoveImage(imageID, force)
+	return client.apiClient.ContainerRemove(context.Background(), c.ID(), removeOpts)
+}
+
+func (client dockerClient) NetemContainer(c Container, netInterface string, netemCmd string, filter netem.Filter, duration time.Duration, reapply bool, probe string) (err error) {
+	client = client.timed("netem")
+	defer wrapError("netem", c, &err)
+	if filter.IsEmpty() {
+		log.Infof("Running netem command '%s' on container %s for %s", netemCmd, c.ID(), duration)
+	} else {
+		log.Infof("Running netem command '%s' on container %s with filter %s for %s", netemCmd, c.ID(), filter, duration)
+	}
+	impairment := netem.Impairment(netemCmd)
+	ifaces, err := client.netemInterfaces(c, netInterface)
+	if err != nil {
+		return err
+	}
+	// target host IPs are resolved by Pumba and added to static target IPs
+	static := filter.IPs
+	if len(filter.Hosts) > 0 {
+		if filter, err = resolveHosts(client.getLookup(), filter, static); err != nil {
+			return err
+		}
+	}
+	var baseline time.Duration
+	if probe != "" {
+		var err error
+		if baseline, err = client.probeLatency(c, probe); err != nil {
+			return fmt.Errorf("Failed to measure baseline latency to %s on container %s: %s", probe, c.ID(), err)
+		}
+	}
+	if err = client.applyNetem(c, ifaces, impairment, filter); err != nil {
+		return err
+	}
+	if !filter.IsEmpty() {
+		for _, iface := range ifaces {
+			if err := client.verifyNetemFilters(c, iface, filter); err != nil {
+				client.unwindNetem(c, ifaces, filter)
+				return err
+			}
+		}
+	}
+	if probe != "" {
+		client.reportLatency(c, probe, baseline)
+	}
+	// sleep (current goroutine) for specified duration and then stop netem; optionally re-apply netem if
+	// container is restarted meanwhile, and update filters, if target hosts are resolved to other IPs
+	if reapply || len(filter.Hosts) > 0 {
+		if filter, err = client.netemWatch(c, ifaces, impairment, filter, static, duration, reapply); err != nil {
+			return err
+		}
+	} else {
+		client.getClock().Sleep(duration)
+	}
+	return client.stopNetem(c, ifaces, filter)
+}
+
+// StopNetemContainer removes netem (and filters), added by NetemContainer, from container network interface
+func (client dockerClient) StopNetemContainer(c Container, netInterface string, filter netem.Filter) (err error) {
+	client = client.timed("netem")
+	defer wrapError("netem", c, &err)
+	ifaces, err := client.netemInterfaces(c, netInterface)
+	if err != nil {
+		return err
+	}
+	return client.stopNetem(c, ifaces, filter)
+}
+
+// ClearNetemContainer removes netem, added by Pumba (by this or another run), from network interface of container, e.g.
+// to cancel netem in progress: root netem or prio qdisc is removed with its child qdiscs and filters; interfaces
+// without netem are skipped, and root qdisc, not added by Pumba, is left as is
+func (client dockerClient) ClearNetemContainer(c Container, netInterface string) (err error) {
+	client = client.timed("netem")
+	defer wrapError("netem", c, &err)
+	ifaces, err := client.netemInterfaces(c, netInterface)
+	if err != nil {
 		return err
 	}
+	for _, iface := range ifaces {
+		out, err := client.execOutput(c, netem.ShowCommand(iface))
+		if err != nil {
+			return err
+		}
+		if root := netem.ForeignRoot(out); root != "" {
+			log.Warnf("Container %s has root qdisc '%s' on '%s', not added by Pumba: skipping", c.ID(), root, iface)
+			continue
+		}
+		leftovers := netem.Leftovers(out)
+		if len(leftovers) == 0 {
+			log.Infof("No netem on container %s on '%s'", c.ID(), iface)
+			continue
+		}
+		log.Infof("Removing netem from container %s on '%s': %s", c.ID(), iface, strings.Join(leftovers, "; "))
+		if err = client.execOnContainer(c, netem.ClearCommand(iface), true); err != nil {
+			return err
+		}
+		// exec gives no exit code: check that netem is removed
+		if out, err = client.execOutput(c, netem.ShowCommand(iface)); err != nil {
+			return err
+		}
+		if leftovers = netem.Leftovers(out); len(leftovers) > 0 {
+			return fmt.Errorf("Failed to remove netem from container %s on '%s': %s", c.ID(), iface, strings.Join(leftovers, "; "))
+		}
+	}
 	return nil
 }
 
-func (client dockerClient) RemoveContainer(c Container, force bool, links bool, volumes bool, dryrun bool) error {
-	prefix := ""
-	if dryrun {
-		prefix = dryRunPrefix
+// netemInterfaces returns network interfaces of container to apply netem on: specified interface or all
+// interfaces of container (AllInterfaces)
+func (client dockerClient) netemInterfaces(c Container, netInterface string) ([]string, error) {
+	if netInterface != AllInterfaces {
+		return []string{netInterface}, nil
+	}
+	ifaces, err := client.NetworkInterfaces(c)
+	if err != nil {
+		return nil, err
 	}
-	log.Infof("%sRemoving container %s", prefix, c.ID())
-	if !dryrun {
-		removeOpts := enginetypes.ContainerRemoveOptions{
-			RemoveVolumes: links,
-			RemoveLinks:   volumes,
-			Force:         force,
+	log.Debugf("Applying netem on interfaces %s of container %s", strings.Join(ifaces, ", "), c.ID())
+	return ifaces, nil
+}
+
+// applyNetem applies netem on network interfaces; partially applied netem (qdiscs, filters, mark rules) is rolled
+// back on failure, on all interfaces
+func (client dockerClient) applyNetem(c Container, ifaces []string, impairment []string, filter netem.Filter) error {
+	for i, iface := range ifaces {
+		if err := client.checkQdiscs(c, iface); err != nil {
+			client.unwindNetem(c, ifaces[:i], filter)
+			return err
+		}
+		if _, err := netem.Pipeline(iface, impairment, filter).Apply(client.execStep(c)); err != nil {
+			client.unwindNetem(c, ifaces[:i], filter)
+			return err
+		}
+		if err := client.verifyNetemApplied(c, iface, filter); err != nil {
+			client.unwindNetem(c, ifaces[:i+1], filter)
+			return err
 		}
-		return client.apiClient.ContainerRemove(context.Background(), c.ID(), removeOpts)
 	}
 	return nil
 }
 
-func (client dockerClient) NetemContainer(c Container, netInterface string, netemCmd string, targetIP net.IP, duration time.Duration, dryrun bool) error {
-	prefix := ""
-	if dryrun {
-		prefix = dryRunPrefix
+// checkQdiscs checks (with 'tc qdisc show') root qdisc of network interface before netem is applied: netem, left by
+// previous run, is replaced by netem root qdisc; root qdisc, that is not added by Pumba, can not be replaced safely
+func (client dockerClient) checkQdiscs(c Container, netInterface string) error {
+	out, err := client.execOutput(c, netem.ShowCommand(netInterface))
+	if err != nil {
+		log.Warnf("Failed to check qdiscs of container %s on '%s': %s", c.ID(), netInterface, err)
+		return nil
 	}
-	var err error
-	if targetIP == nil {
-		log.Infof("%sRunning netem command '%s' on container %s for %s", prefix, netemCmd, c.ID(), duration)
-		err = client.startNetemContainer(c, netInterface, netemCmd, dryrun)
-	} else {
-		log.Infof("%sRunning netem command '%s' on container %s with filter %s for %s", prefix, netemCmd, c.ID(), targetIP.String(), duration)
-		err = client.startNetemContainerIPFilter(c, netInterface, netemCmd, targetIP.String(), dryrun)
+	if root := netem.ForeignRoot(out); root != "" {
+		return fmt.Errorf("Container %s has root qdisc '%s' on '%s', not added by Pumba: remove it to apply netem", c.ID(), root, netInterface)
+	}
+	if leftovers := netem.Leftovers(out); len(leftovers) > 0 {
+		log.Warnf("Replacing netem qdisc on container %s on '%s', left by previous run: %s", c.ID(), netInterface, strings.Join(leftovers, "; "))
 	}
+	return nil
+}
+
+// verifyNetemApplied checks with 'tc qdisc show', that netem qdisc was added: exec gives no exit code, so failed
+// 'tc' command (e.g. no 'tc' in container or no NET_ADMIN capability) is detected by its result only
+func (client dockerClient) verifyNetemApplied(c Container, netInterface string, filter netem.Filter) error {
+	out, err := client.execOutput(c, netem.ShowCommand(netInterface))
 	if err != nil {
-		return err
+		return fmt.Errorf("Failed to verify netem on container %s on '%s': %s", c.ID(), netInterface, err)
+	}
+	if !netem.Applied(out, filter) {
+		return fmt.Errorf("Netem qdisc is not applied on container %s on '%s' (is 'tc' available and NET_ADMIN capability granted?): %s", c.ID(), netInterface, strings.TrimSpace(out))
 	}
-	// sleep (current goroutine) for specified duration and then stop netem
-	time.Sleep(duration)
-	log.Infof("%sStopping netem on container %s", prefix, c.ID())
-	return client.stopNetemContainer(c, netInterface, dryrun)
+	log.Debugf("Netem applied on container %s on '%s'", c.ID(), netInterface)
+	return nil
 }
 
-func (client dockerClient) PauseContainer(c Container, duration time.Duration, dryrun bool) error {
-	prefix := ""
-	if dryrun {
-		prefix = dryRunPrefix
+// unwindNetem removes netem from network interfaces; netem is removed from all interfaces, even if some removal
+// fails; returns the first error
+func (client dockerClient) unwindNetem(c Container, ifaces []string, filter netem.Filter) error {
+	var first error
+	for _, iface := range ifaces {
+		if err := netem.Pipeline(iface, nil, filter).Stack().Unwind(client.execStep(c)); err != nil && first == nil {
+			first = err
+		}
 	}
-	log.Infof("%sPausing container %s for %s", prefix, c.ID(), duration)
-	if !dryrun {
-		if err := client.api.PauseContainer(c.ID()); err != nil {
-			return err
+	return first
+}
+
+// stopNetem removes netem from network interfaces and verifies, that netem qdisc was removed; returns the first error
+func (client dockerClient) stopNetem(c Container, ifaces []string, filter netem.Filter) error {
+	log.Infof("Stopping netem on container %s", c.ID())
+	var first error
+	for _, iface := range ifaces {
+		err := netem.Pipeline(iface, nil, filter).Stack().Unwind(client.execStep(c))
+		if err == nil {
+			err = client.verifyNetemStopped(c, iface, filter)
+		}
+		if err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// netemWatch waits for netem duration and returns filter of applied netem: with reapply, it watches Docker events
+// for container (re)start, since container restart removes netem qdisc, and applies netem again for the remaining
+// duration; with target hosts, it re-resolves them periodically and re-applies netem with filter for new IPs
+func (client dockerClient) netemWatch(c Container, ifaces []string, impairment []string, filter netem.Filter, static []net.IP, duration time.Duration, reapply bool) (netem.Filter, error) {
+	end := client.getClock().Now().Add(duration)
+	deadline := client.getClock().After(duration)
+	var events <-chan dockerclient.EventOrError
+	if reapply {
+		hub := client.getEvents()
+		if w, err := hub.watch(c.ID(), "start"); err != nil {
+			log.Warnf("Failed to watch restarts of container %s: %s", c.ID(), err)
+		} else {
+			defer hub.unwatch(w)
+			events = w.events
+		}
+	}
+	// nil channel blocks forever
+	var refresh <-chan time.Time
+	if len(filter.Hosts) > 0 {
+		ticker := client.getClock().NewTicker(hostRefreshInterval)
+		defer ticker.Stop()
+		refresh = ticker.C()
+	}
+	for {
+		select {
+		case <-deadline:
+			return filter, nil
+		case <-refresh:
+			resolved, err := resolveHosts(client.getLookup(), filter, static)
+			if err != nil {
+				log.Warnf("Failed to re-resolve netem target hosts of container %s: %s; keeping filter %s", c.ID(), err, filter)
+				continue
+			}
+			if sameIPs(resolved.IPs, filter.IPs) {
+				continue
+			}
+			log.Infof("Netem target hosts of container %s are resolved to other IPs; re-applying netem with filter %s for remaining %s", c.ID(), resolved, end.Sub(client.getClock().Now()))
+			if err = client.unwindNetem(c, ifaces, filter); err != nil {
+				return filter, err
+			}
+			if err = client.applyNetem(c, ifaces, impairment, resolved); err != nil {
+				return filter, err
+			}
+			filter = resolved
+		case e, ok := <-events:
+			if !ok || e.Error != nil {
+				if ok {
+					log.Warnf("Failed to watch restarts of container %s: %s", c.ID(), e.Error)
+				}
+				// stop watching
+				events = nil
+				continue
+			}
+			if e.ID != c.ID() || e.Status != "start" {
+				continue
+			}
+			log.Warnf("Container %s was restarted; re-applying netem for remaining %s", c.ID(), end.Sub(client.getClock().Now()))
+			if err := client.applyNetem(c, ifaces, impairment, filter); err != nil {
+				return filter, err
+			}
+		}
+	}
+}
+
+// verifyNetemFilters checks with 'tc filter show', that filters for all protocol families (IPv4, IPv6) of target
+// addresses were applied, and reports covered families
+func (client dockerClient) verifyNetemFilters(c Container, netInterface string, filter netem.Filter) error {
+	out, err := client.execOutput(c, netem.FilterShowCommand(netInterface))
+	if err != nil {
+		return fmt.Errorf("Failed to verify netem filters on container %s on '%s': %s", c.ID(), netInterface, err)
+	}
+	applied := map[string]bool{}
+	for _, family := range netem.FilterFamilies(out) {
+		applied[family] = true
+	}
+	var covered []string
+	for _, family := range netem.Families(filter) {
+		if !applied[family] {
+			return fmt.Errorf("Netem filter for %s traffic is missing on container %s on '%s'", familyName(family), c.ID(), netInterface)
+		}
+		covered = append(covered, familyName(family))
+	}
+	log.Infof("Netem filters cover %s traffic on container %s on '%s'", strings.Join(covered, " and "), c.ID(), netInterface)
+	return nil
+}
+
+// probeLatency measures latency to probe target (host or HTTP(S) URL) from container
+func (client dockerClient) probeLatency(c Container, probe string) (time.Duration, error) {
+	out, err := client.execOutput(c, netem.ProbeCommand(probe))
+	if err != nil {
+		return 0, err
+	}
+	return netem.ParseProbe(probe, out)
+}
+
+// reportLatency measures latency to probe target under netem and reports it with baseline latency, measured before
+// netem was applied; unreachable target or latency increase proves, that impairment took effect
+func (client dockerClient) reportLatency(c Container, probe string, baseline time.Duration) {
+	fields := log.Fields{"container": c.ID(), "probe": probe, "baseline": baseline.String()}
+	impaired, err := client.probeLatency(c, probe)
+	if err != nil {
+		log.WithFields(fields).Infof("Netem verification: %s is unreachable from container %s under impairment (baseline latency %s): %s", probe, c.ID(), baseline, err)
+		return
+	}
+	fields["impaired"] = impaired.String()
+	if impaired <= baseline {
+		log.WithFields(fields).Warnf("Netem verification: latency to %s on container %s did not increase (baseline %s, impaired %s); probe traffic may not match netem filter", probe, c.ID(), baseline, impaired)
+		return
+	}
+	log.WithFields(fields).Infof("Netem verification: latency to %s on container %s increased by %s (baseline %s, impaired %s)", probe, c.ID(), impaired-baseline, baseline, impaired)
+}
+
+func familyName(family string) string {
+	if family == "ipv6" {
+		return "IPv6"
+	}
+	return "IPv4"
+}
+
+// verifyNetemStopped checks (with 'tc qdisc show') that netem qdisc was removed; retries removal otherwise
+func (client dockerClient) verifyNetemStopped(c Container, netInterface string, filter netem.Filter) error {
+	for attempt := 1; ; attempt++ {
+		out, err := client.execOutput(c, netem.ShowCommand(netInterface))
+		if err != nil {
+			return fmt.Errorf("Failed to verify netem removal on container %s on '%s': %s", c.ID(), netInterface, err)
+		}
+		if !netem.Lingering(out, filter) {
+			log.Debugf("Netem removed from container %s on '%s'", c.ID(), netInterface)
+			return nil
+		}
+		if attempt > netemStopRetries {
+			return fmt.Errorf("Netem qdisc is still present on container %s on '%s': %s", c.ID(), netInterface, strings.TrimSpace(out))
 		}
-		log.Debugf("Container %s paused for %s", c.ID(), duration)
-		// pause the current goroutine for specified duration
-		time.Sleep(duration)
-		if err := client.api.UnpauseContainer(c.ID()); err != nil {
+		log.Warnf("Netem qdisc is still present on container %s on '%s'; retry removal (%d/%d)", c.ID(), netInterface, attempt, netemStopRetries)
+		if err = netem.Pipeline(netInterface, nil, filter).Stack().Unwind(client.execStep(c)); err != nil {
 			return err
 		}
-		log.Debugf("Container upaused %s after %s", c.ID(), duration)
+		client.getClock().Sleep(netemStopRetryDelay)
 	}
+}
+
+func (client dockerClient) PauseContainer(c Container, duration time.Duration) (err error) {
+	client = client.timed("pause")
+	defer wrapError("pause", c, &err)
+	log.Infof("Pausing container %s for %s", c.ID(), duration)
+	if err := client.api.PauseContainer(c.ID()); err != nil {
+		return err
+	}
+	log.Debugf("Container %s paused for %s", c.ID(), duration)
+	// pause the current goroutine for specified duration
+	client.getClock().Sleep(duration)
+	if err := client.api.UnpauseContainer(c.ID()); err != nil {
+		return err
+	}
+	log.Debugf("Container upaused %s after %s", c.ID(), duration)
 	return nil
 }
 
-func (client dockerClient) startNetemContainer(c Container, netInterface string, netemCmd string, dryrun bool) error {
-	prefix := ""
-	if dryrun {
-		prefix = dryRunPrefix
+// UnpauseContainer unpauses container; used to end pause started by another Pumba instance
+func (client dockerClient) UnpauseContainer(c Container) (err error) {
+	client = client.timed("pause")
+	defer wrapError("pause", c, &err)
+	log.Infof("Unpausing container %s", c.ID())
+	return client.api.UnpauseContainer(c.ID())
+}
+
+// DropPortsContainer drops loss percent of packets sent to container published ports through host DNAT path
+// for specified duration; uses iptables on Docker host, so other traffic of container is not affected
+func (client dockerClient) DropPortsContainer(c Container, loss int, duration time.Duration) (err error) {
+	client = client.timed("ports")
+	defer wrapError("ports", c, &err)
+	ports, err := publishedPorts(c)
+	if err != nil {
+		return err
+	}
+	if len(ports) == 0 {
+		return fmt.Errorf("Container %s has no published ports", c.ID())
+	}
+	log.Infof("Dropping %d%% of packets to published ports of container %s for %s", loss, c.ID(), duration)
+	// rules added before failure are removed
+	if _, err = iptables.Pipeline(ports, loss).Apply(client.hostStep()); err != nil {
+		return err
+	}
+	// sleep (current goroutine) for specified duration and then restore published ports
+	client.getClock().Sleep(duration)
+	return client.RestorePortsContainer(c, loss)
+}
+
+// RestorePortsContainer removes host iptables rules, added by DropPortsContainer with the same loss percent
+func (client dockerClient) RestorePortsContainer(c Container, loss int) (err error) {
+	client = client.timed("ports")
+	defer wrapError("ports", c, &err)
+	ports, err := publishedPorts(c)
+	if err != nil {
+		return err
+	}
+	log.Infof("Restoring published ports of container %s", c.ID())
+	return iptables.Pipeline(ports, loss).Stack().Unwind(client.hostStep())
+}
+
+// SidecarContainer runs a helper container, sharing network namespace with the target container,
+// for specified duration and removes it afterwards; sidecar gets NET_ADMIN capability; missing sidecar image is pulled
+func (client dockerClient) SidecarContainer(c Container, image string, cmd []string, duration time.Duration) (err error) {
+	client = client.timed("sidecar")
+	defer wrapError("sidecar", c, &err)
+	log.Infof("Running sidecar '%s' for container %s for %s", image, c.ID(), duration)
+	config := enginecontainer.Config{
+		Image:      image,
+		Entrypoint: cmd[:1],
+		Cmd:        cmd[1:],
+		User:       "root",
+		Labels:     map[string]string{pumbaLabel: "true"},
+	}
+	hostConfig := enginecontainer.HostConfig{
+		NetworkMode: enginecontainer.NetworkMode("container:" + c.ID()),
+		CapAdd:      []string{sidecarNetCap},
+	}
+	ctx := context.Background()
+	if err = client.pullImage(ctx, image); err != nil {
+		return err
+	}
+	sidecar, err := client.apiClient.ContainerCreate(ctx, &config, &hostConfig, nil, "")
+	if err != nil {
+		return err
+	}
+	log.Debugf("Starting sidecar %s for container %s", sidecar.ID, c.ID())
+	if err = client.apiClient.ContainerStart(ctx, sidecar.ID, enginetypes.ContainerStartOptions{}); err != nil {
+		// custom sidecar image may not have expected entrypoint
+		err = fmt.Errorf("Failed to start sidecar '%s' (image should contain '%s'): %s", image, cmd[0], err)
+	} else {
+		// sleep (current goroutine) for specified duration and then stop sidecar
+		client.getClock().Sleep(duration)
+		log.Infof("Stopping sidecar %s for container %s", sidecar.ID, c.ID())
+		timeout := sidecarStopTime
+		if err = client.apiClient.ContainerStop(ctx, sidecar.ID, &timeout); err != nil {
+			log.Warningf("Failed to stop sidecar %s gracefully: %s", sidecar.ID, err)
+		}
+	}
+	removeOpts := enginetypes.ContainerRemoveOptions{Force: true}
+	if rmErr := client.apiClient.ContainerRemove(ctx, sidecar.ID, removeOpts); rmErr != nil && err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// pullImage pulls image, if it is not present on Docker host; Docker reports pull failure in progress stream
+func (client dockerClient) pullImage(ctx context.Context, image string) error {
+	if _, _, err := client.apiClient.ImageInspectWithRaw(ctx, image, false); err == nil {
+		return nil
+	}
+	log.Infof("Pulling image '%s'", image)
+	resp, err := client.apiClient.ImagePull(ctx, image, enginetypes.ImagePullOptions{})
+	if err != nil {
+		return fmt.Errorf("Failed to pull image '%s': %s", image, err)
+	}
+	defer resp.Close()
+	decoder := json.NewDecoder(resp)
+	for {
+		var msg struct {
+			Error string `json:"error"`
+		}
+		if err = decoder.Decode(&msg); err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("Failed to pull image '%s': %s", image, err)
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("Failed to pull image '%s': %s", image, msg.Error)
+		}
+	}
+}
+
+// BeaconContainer creates and removes (without starting) no-op container from image of target container, with
+// specified labels; Docker emits 'create' and 'destroy' events with these labels, so tools watching Docker events
+// can observe chaos actions
+func (client dockerClient) BeaconContainer(c Container, labels map[string]string) error {
+	client = client.timed("beacon")
+	config := enginecontainer.Config{
+		Image:      c.containerInfo.Image,
+		Entrypoint: []string{"true"},
+		Labels:     map[string]string{pumbaLabel: "true"},
+	}
+	for k, v := range labels {
+		config.Labels[k] = v
+	}
+	ctx := context.Background()
+	beacon, err := client.apiClient.ContainerCreate(ctx, &config, &enginecontainer.HostConfig{}, nil, "")
+	if err != nil {
+		return fmt.Errorf("Failed to create beacon container for container %s: %s", c.ID(), err)
+	}
+	log.Debugf("Created beacon container %s for container %s", beacon.ID, c.ID())
+	return client.apiClient.ContainerRemove(ctx, beacon.ID, enginetypes.ContainerRemoveOptions{Force: true})
+}
+
+// OOMContainer triggers kernel OOM killer in container memory cgroup: container main process is made preferred
+// OOM victim and memory balloon is exec-ed inside container till OOM killer fires or timeout; container must have
+// memory limit, otherwise balloon would exhaust Docker host memory
+func (client dockerClient) OOMContainer(c Container, timeout time.Duration) (err error) {
+	client = client.timed("oom")
+	defer wrapError("oom", c, &err)
+	limit, err := memoryLimit(c)
+	if err != nil {
+		return err
+	}
+	log.Infof("Triggering OOM killer in container %s with memory limit %d bytes, timeout %s", c.ID(), limit, timeout)
+	code, err := client.ExecContainer(c, stress.OOMCommand(timeout), timeout+oomGracePeriod)
+	// exec may fail, when main process is killed and container exits
+	if client.oomKilled(c) {
+		log.Infof("Main process of container %s was killed by OOM killer", c.ID())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	switch code {
+	case stress.OOMKilledCode:
+		log.Warnf("OOM killer fired in container %s, but killed memory balloon instead of main process", c.ID())
+		return nil
+	case stress.OOMTimeoutCode:
+		return fmt.Errorf("OOM killer was not triggered in container %s within %s", c.ID(), timeout)
+	}
+	return &ErrExecFailed{Command: "Memory balloon", ContainerID: c.ID(), ExitCode: code}
+}
+
+// memoryLimit returns memory limit of container; memory balloon of container without limit would exhaust Docker host
+// memory
+func memoryLimit(c Container) (int64, error) {
+	limit := c.MemoryLimit()
+	if limit <= 0 {
+		return 0, fmt.Errorf("Container %s has no memory limit: memory balloon would exhaust Docker host memory", c.ID())
+	}
+	return limit, nil
+}
+
+// oomKilled checks Docker OOM-killed flag of container; flag is reset, when container is restarted
+func (client dockerClient) oomKilled(c Container) bool {
+	info, err := client.api.InspectContainer(c.ID())
+	if err != nil {
+		log.Debugf("Failed to inspect container %s: %s", c.ID(), err)
+		return false
+	}
+	return info.State != nil && info.State.OOMKilled
+}
+
+// ExhaustFDsContainer exhausts file descriptors of container processes for specified duration with Pumba helper
+// ('pumba fd-helper'), injected into container as HelperTool: soft open files limit of each process is lowered to
+// number of its open descriptors plus free, so new files and sockets fail with 'too many open files'; helper
+// restores the limits after duration, even if Pumba exits earlier
+func (client dockerClient) ExhaustFDsContainer(c Container, free int, duration time.Duration) (err error) {
+	client = client.timed("fd")
+	defer wrapError("fd", c, &err)
+	log.Infof("Exhausting file descriptors of container %s processes (%d free) for %s", c.ID(), free, duration)
+	if !client.tools.get(c.ID())[HelperTool] {
+		return fmt.Errorf("Pumba helper is not injected into container %s", c.ID())
 	}
-	log.Infof("%sStart netem for container %s on '%s' with command '%s'", prefix, c.ID(), netInterface, netemCmd)
-	if !dryrun {
-		// use dockerclient ExecStart to run Traffic Control:
-		// 'tc qdisc add dev eth0 root netem delay 100ms'
-		// http://www.linuxfoundation.org/collaborate/workgroups/networking/netem
-		netemCommand := "tc qdisc add dev " + netInterface + " root netem " + strings.ToLower(netemCmd)
-		// stop disruption command
-		// netemStopCommand := "tc qdisc del dev eth0 root netem"
-		log.Debugf("netem command '%s'", netemCommand)
-		return client.execOnContainer(c, netemCommand, true)
+	cmd := []string{HelperTool, "fd-helper", "--duration", duration.String(), "--free", strconv.Itoa(free)}
+	code, err := client.ExecContainer(c, cmd, duration+fdGracePeriod)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return &ErrExecFailed{Command: "File descriptor exhaustion", ContainerID: c.ID(), ExitCode: code}
 	}
+	log.Debugf("File descriptor exhaustion of container %s completed after %s", c.ID(), duration)
 	return nil
 }
 
-func (client dockerClient) stopNetemContainer(c Container, netInterface string, dryrun bool) error {
-	prefix := ""
-	if dryrun {
-		prefix = dryRunPrefix
+// CommitContainer commits container (paused, for consistent filesystem) to image with specified reference
+// ('repository:tag') and labels; returns image ID
+func (client dockerClient) CommitContainer(c Container, reference string, labels map[string]string) (string, error) {
+	client = client.timed("commit")
+	options := enginetypes.ContainerCommitOptions{
+		Reference: reference,
+		Comment:   "Pumba snapshot of container " + c.Name(),
+		Pause:     true,
+		Config:    &enginecontainer.Config{Labels: labels},
+	}
+	resp, err := client.apiClient.ContainerCommit(context.Background(), c.ID(), options)
+	if err != nil {
+		return "", fmt.Errorf("Failed to commit container %s: %s", c.ID(), err)
+	}
+	log.Debugf("Committed container %s to image %s (%s)", c.ID(), reference, resp.ID)
+	return resp.ID, nil
+}
+
+// ListImages returns images with specified label (any value)
+func (client dockerClient) ListImages(label string) ([]Image, error) {
+	client = client.timed("images")
+	images, err := client.api.ListImages(false)
+	if err != nil {
+		return nil, err
+	}
+	var result []Image
+	for _, img := range images {
+		if _, ok := img.Labels[label]; !ok {
+			continue
+		}
+		result = append(result, Image{ID: img.Id, Tags: img.RepoTags, Created: time.Unix(img.Created, 0), Labels: img.Labels})
+	}
+	return result, nil
+}
+
+// DeleteImage removes image by ID or reference
+func (client dockerClient) DeleteImage(image string, force bool) error {
+	client = client.timed("rmi")
+	log.Debugf("Removing image %s", image)
+	_, err := client.api.RemoveImage(image, force)
+	return err
+}
+
+// BurnCPUContainer runs CPU busy-loop workers inside container for specified duration; workers: number of
+// busy-loop processes, 0 - one per CPU; only POSIX shell is required in container image
+func (client dockerClient) BurnCPUContainer(c Container, workers int, duration time.Duration) (err error) {
+	client = client.timed("cpu")
+	defer wrapError("cpu", c, &err)
+	log.Infof("Burning CPU of container %s with %s for %s", c.ID(), burnWorkers(workers), duration)
+	code, err := client.ExecContainer(c, stress.CPUCommand(workers, duration), duration+burnCPUGracePeriod)
+	if err != nil {
+		return err
 	}
-	log.Infof("%sStop netem for container %s on '%s'", prefix, c.ID(), netInterface)
-	if !dryrun {
-		// stop netem command
-		// http://www.linuxfoundation.org/collaborate/workgroups/networking/netem
-		netemCommand := "tc qdisc del dev " + netInterface + " root netem"
-		log.Debugf("netem command '%s'", netemCommand)
-		return client.execOnContainer(c, netemCommand, true)
+	if code != 0 {
+		return &ErrExecFailed{Command: "CPU burn", ContainerID: c.ID(), ExitCode: code}
 	}
+	log.Debugf("CPU burn of container %s completed after %s", c.ID(), duration)
 	return nil
 }
 
-func (client dockerClient) startNetemContainerIPFilter(c Container, netInterface string, netemCmd string,
-	targetIP string, dryrun bool) error {
-	prefix := ""
-	if dryrun {
-		prefix = dryRunPrefix
-	}
-	log.Infof("%sStart netem for container %s on '%s' with command '%s', filter by IP '%s'",
-		prefix, c.ID(), netInterface, netemCmd, targetIP)
-	if !dryrun {
-		// use dockerclient ExecStart to run Traffic Control
-		// to filter network, needs to create a priority scheduling, add a low priority
-		// queue, apply netem command on that queue only, then route IP traffic to the low priority queue
-		// See more: http://www.linuxfoundation.org/collaborate/workgroups/networking/netem
-
-		//  Create a priority-based queue.
-		// 'tc qdisc add dev <netInterface> root handle 1: prio'
-		// See more: http://stuff.onse.fi/man?program=tc
-		handleCommand := "tc qdisc add dev " + netInterface + " root handle 1: prio"
-		log.Debugf("handleCommand %s", handleCommand)
-		err := client.execOnContainer(c, handleCommand, true)
+func burnWorkers(workers int) string {
+	if workers <= 0 {
+		return "worker per CPU"
+	}
+	return fmt.Sprintf("%d worker(s)", workers)
+}
+
+func (client dockerClient) ExecContainer(c Container, cmd []string, timeout time.Duration) (int, error) {
+	client = client.timed("exec")
+	log.Debugf("Executing %s in container %s", cmd, c.Name())
+	exec, err := client.apiClient.ContainerExecCreate(context.Background(), c.ID(), client.execConfig(c, cmd))
+	if err != nil {
+		return -1, err
+	}
+	if err = client.apiClient.ContainerExecStart(context.Background(), exec.ID, enginetypes.ExecStartCheck{}); err != nil {
+		return -1, err
+	}
+	// wait for exec process to complete and get its exit code
+	deadline := client.getClock().After(timeout)
+	for {
+		inspect, err := client.apiClient.ContainerExecInspect(context.Background(), exec.ID)
 		if err != nil {
-			return err
+			return -1, err
+		}
+		if !inspect.Running {
+			return inspect.ExitCode, nil
+		}
+		select {
+		case <-deadline:
+			return -1, fmt.Errorf("Exec %s in container %s timed out after %s", cmd, c.Name(), timeout)
+		case <-client.getClock().After(execPollInterval):
 		}
+	}
+}
+
+// NetworkInterface resolves name of container network interface connected to Docker network;
+// needed for macvlan/overlay and other custom network drivers, where interface is not always ethN
+func (client dockerClient) NetworkInterface(c Container, network string) (string, error) {
+	client = client.timed("netem")
+	ip, ok := c.NetworkIP(network)
+	if !ok {
+		return "", fmt.Errorf("Container %s is not connected to network '%s'", c.ID(), network)
+	}
+	out, err := client.execOutput(c, netem.AddrCommand())
+	if err != nil {
+		return "", err
+	}
+	netInterface := netem.InterfaceByIP(out, ip)
+	if netInterface == "" {
+		return "", fmt.Errorf("Failed to find network interface with address %s in container %s", ip, c.ID())
+	}
+	log.Debugf("Network '%s' is connected to interface '%s' in container %s", network, netInterface, c.ID())
+	return netInterface, nil
+}
+
+// NetworkInterfaces lists network interfaces of container, that are up, except loopback (with 'ip -o link show')
+func (client dockerClient) NetworkInterfaces(c Container) ([]string, error) {
+	client = client.timed("netem")
+	out, err := client.execOutput(c, netem.LinkCommand())
+	if err != nil {
+		return nil, err
+	}
+	ifaces := netem.Interfaces(out)
+	if len(ifaces) == 0 {
+		return nil, fmt.Errorf("Failed to find network interfaces in container %s", c.ID())
+	}
+	return ifaces, nil
+}
+
+// NetemLeftovers lists qdiscs, added by netem, on network interface of container (with 'tc qdisc show'); empty -
+// interface is clean
+func (client dockerClient) NetemLeftovers(c Container, netInterface string) ([]string, error) {
+	client = client.timed("netem")
+	out, err := client.execOutput(c, netem.ShowCommand(netInterface))
+	if err != nil {
+		return nil, err
+	}
+	return netem.Leftovers(out), nil
+}
 
-		//  Delay everything in band 3
-		// 'tc qdisc add dev <netInterface> parent 1:3 netem <netemCmd>'
-		// See more: http://stuff.onse.fi/man?program=tc
-		netemCommand := "tc qdisc add dev " + netInterface + " parent 1:3 netem " + strings.ToLower(netemCmd)
-		log.Debugf("netemCommand %s", netemCommand)
-		err = client.execOnContainer(c, netemCommand, true)
+// ResolveAlias resolves host name (e.g. Docker network alias) with container DNS resolver, both IPv4 (A)
+// and IPv6 (AAAA) addresses; resolved addresses may change when aliased containers are re-created
+func (client dockerClient) ResolveAlias(c Container, alias string) ([]net.IP, error) {
+	client = client.timed("netem")
+	// 'getent ahosts' returns addresses of all families; fallback to 'getent hosts', if not supported
+	var ips []net.IP
+	for _, cmd := range [][]string{{"getent", "ahosts", alias}, {"getent", "hosts", alias}} {
+		out, err := client.execOutput(c, cmd)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		if ips = parseHosts(out); len(ips) > 0 {
+			break
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("Failed to resolve '%s' in container %s", alias, c.ID())
+	}
+	log.Debugf("Resolved '%s' to %v in container %s", alias, ips, c.ID())
+	return ips, nil
+}
+
+// parseHosts parses 'getent hosts' ('10.0.9.5      db') or 'getent ahosts' ('10.0.9.5      STREAM db') output;
+// returns unique addresses
+func parseHosts(output string) []net.IP {
+	var ips []net.IP
+	seen := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if ip := net.ParseIP(fields[0]); ip != nil && !seen[ip.String()] {
+			seen[ip.String()] = true
+			ips = append(ips, ip)
 		}
+	}
+	return ips
+}
 
-		// # say traffic to $PORT is band 3
-		// 'tc filter add dev <netInterface> protocol ip parent 1:0 prio 3 u32 match ip dst <targetIP> flowid 1:3'
-		// See more: http://stuff.onse.fi/man?program=tc-u32
-		filterCommand := "tc filter add dev " + netInterface + " protocol ip parent 1:0 prio 3 " +
-			"u32 match ip dport " + strings.ToLower(targetIP) + " flowid 1:3"
-		log.Debugf("filterCommand %s", filterCommand)
-		return client.execOnContainer(c, filterCommand, true)
+// execCommands runs commands (tc, iptables) one by one inside container, with privileged exec
+func (client dockerClient) execCommands(c Container, cmds [][]string) error {
+	for _, cmd := range cmds {
+		log.Debugf("Exec command '%s' on container %s", strings.Join(cmd, " "), c.ID())
+		if err := client.execOnContainer(c, cmd, true); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-func (client dockerClient) execOnContainer(c Container, execCmd string, privileged bool) error {
-	config := enginetypes.ExecConfig{
-		Privileged: privileged,
-		Cmd:        strings.Split(execCmd, " "),
+// execStep returns executor of teardown pipeline steps inside container
+func (client dockerClient) execStep(c Container) teardown.Exec {
+	return func(cmd []string) error {
+		return client.execCommands(c, [][]string{cmd})
 	}
+}
 
+// execOutput runs command inside container (with TTY) and returns its output
+func (client dockerClient) execOutput(c Container, cmd []string) (string, error) {
+	config := client.execConfig(c, cmd)
+	config.AttachStdout = true
+	config.AttachStderr = true
+	config.Tty = true
 	exec, err := client.apiClient.ContainerExecCreate(context.Background(), c.ID(), config)
+	if isExecUnsupported(err) {
+		out, err := client.nsenterOnContainer(c, cmd, err)
+		return string(out), err
+	}
 	if err != nil {
-		return err
+		return "", err
 	}
+	resp, err := client.apiClient.ContainerExecAttach(context.Background(), exec.ID, config)
+	if isExecUnsupported(err) {
+		out, err := client.nsenterOnContainer(c, cmd, err)
+		return string(out), err
+	}
+	if err != nil {
+		return "", err
+	}
+	defer resp.Close()
+	out, err := ioutil.ReadAll(resp.Reader)
+	return string(out), err
+}
+
+func (client dockerClient) execOnContainer(c Container, execCmd []string, privileged bool) error {
+	config := client.execConfig(c, execCmd)
+	config.Privileged = privileged
 
-	log.Debugf("Starting Exec %s (%s)", execCmd, exec.ID)
-	return client.apiClient.ContainerExecStart(context.Background(), exec.ID, enginetypes.ExecStartCheck{})
+	exec, err := client.apiClient.ContainerExecCreate(context.Background(), c.ID(), config)
+	if err == nil {
+		log.Debugf("Starting Exec %s (%s)", execCmd, exec.ID)
+		err = client.apiClient.ContainerExecStart(context.Background(), exec.ID, enginetypes.ExecStartCheck{})
+	}
+	if isExecUnsupported(err) {
+		_, err = client.nsenterOnContainer(c, execCmd, err)
+	}
+	return err
 }
 
 func (client dockerClient) waitForStop(c Container, waitTime int) error {
-	timeout := time.After(time.Duration(waitTime) * time.Second)
+	timeout := client.getClock().After(time.Duration(waitTime) * time.Second)
 
 	for {
 		select {
@@ -346,6 +1154,92 @@ func (client dockerClient) waitForStop(c Container, waitTime int) error {
 			}
 		}
 
-		time.Sleep(1 * time.Second)
+		client.getClock().Sleep(1 * time.Second)
+	}
+}
+
+// publishedPorts returns container ports published on Docker host
+func publishedPorts(c Container) ([]iptables.Port, error) {
+	settings := c.containerInfo.NetworkSettings
+	containerIP := net.ParseIP(settings.IPAddress)
+	if containerIP == nil {
+		// not on default bridge network: use first user defined network
+		networks := make([]string, 0, len(settings.Networks))
+		for name := range settings.Networks {
+			networks = append(networks, name)
+		}
+		sort.Strings(networks)
+		for _, name := range networks {
+			if containerIP, _ = c.NetworkIP(name); containerIP != nil {
+				break
+			}
+		}
+	}
+	// exposed ports, like "80/tcp"
+	exposed := make([]string, 0, len(settings.Ports))
+	for port := range settings.Ports {
+		exposed = append(exposed, port)
+	}
+	sort.Strings(exposed)
+	var ports []iptables.Port
+	for _, exposedPort := range exposed {
+		parts := strings.SplitN(exposedPort, "/", 2)
+		containerPort, err := strconv.Atoi(parts[0])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid port '%s' of container %s", exposedPort, c.ID())
+		}
+		proto := "tcp"
+		if len(parts) > 1 {
+			proto = parts[1]
+		}
+		for _, binding := range settings.Ports[exposedPort] {
+			if binding.HostPort == "" {
+				continue
+			}
+			hostPort, err := strconv.Atoi(binding.HostPort)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid host port '%s' of container %s", binding.HostPort, c.ID())
+			}
+			if containerIP == nil {
+				return nil, fmt.Errorf("Failed to get IP address of container %s", c.ID())
+			}
+			ports = append(ports, iptables.Port{
+				Proto:         proto,
+				HostIP:        net.ParseIP(binding.HostIp),
+				HostPort:      hostPort,
+				ContainerIP:   containerIP,
+				ContainerPort: containerPort,
+			})
+		}
+	}
+	return ports, nil
+}
+
+// hostExecFunc runs command on Docker host and returns its combined output
+type hostExecFunc func(cmd []string) ([]byte, error)
+
+// hostCommands runs commands (iptables) one by one on Docker host
+func (client dockerClient) hostCommands(cmds [][]string) error {
+	for _, cmd := range cmds {
+		log.Debugf("Run command '%s' on host", strings.Join(cmd, " "))
+		if out, err := client.getHostExec()(cmd); err != nil {
+			return fmt.Errorf("Command '%s' failed: %s: %s", strings.Join(cmd, " "), err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// hostStep returns executor of teardown pipeline steps on Docker host
+func (client dockerClient) hostStep() teardown.Exec {
+	return func(cmd []string) error {
+		return client.hostCommands([][]string{cmd})
+	}
+}
+
+// getHostExec returns client host command executor; local host if not set
+func (client dockerClient) getHostExec() hostExecFunc {
+	if client.hostExec == nil {
+		return runOnHost
 	}
+	return client.hostExec
 }
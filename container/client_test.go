@@ -1,15 +1,24 @@
 package container
 
 import (
+	"bufio"
 	"errors"
+	"io/ioutil"
 	"net"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/docker/engine-api/types"
+	enginecontainer "github.com/docker/engine-api/types/container"
+	"github.com/docker/engine-api/types/network"
 	"github.com/samalba/dockerclient/mockclient"
 	"golang.org/x/net/context"
 
+	"github.com/gaia-adm/pumba/clock"
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/gaia-adm/pumba/stress"
+
 	"github.com/samalba/dockerclient"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -115,7 +124,7 @@ func TestStopContainer_DefaultSuccess(t *testing.T) {
 	api.On("InspectContainer", "abc123").Return(&dockerclient.ContainerInfo{}, errors.New("Not Found"))
 
 	client := dockerClient{api: api}
-	err := client.StopContainer(c, 1, false)
+	err := client.StopContainer(c, 1)
 
 	assert.NoError(t, err)
 	api.AssertExpectations(t)
@@ -143,7 +152,7 @@ func TestStopContainer_DryRun(t *testing.T) {
 	api.On("InspectContainer", "abc123").Return(&dockerclient.ContainerInfo{}, errors.New("Not Found"))
 
 	client := dockerClient{api: api}
-	err := client.StopContainer(c, 1, true)
+	err := NewDryRunClient(client).StopContainer(c, 1)
 
 	assert.NoError(t, err)
 	api.AssertNotCalled(t, "KillContainer", "abc123", "SIGTERM")
@@ -165,7 +174,7 @@ func TestKillContainer_DefaultSuccess(t *testing.T) {
 	api.On("KillContainer", "abc123", "SIGTERM").Return(nil)
 
 	client := dockerClient{api: api}
-	err := client.KillContainer(c, "SIGTERM", false)
+	err := client.KillContainer(c, "SIGTERM")
 
 	assert.NoError(t, err)
 	api.AssertExpectations(t)
@@ -184,7 +193,7 @@ func TestKillContainer_DryRun(t *testing.T) {
 	api.On("KillContainer", "abc123", "SIGTERM").Return(nil)
 
 	client := dockerClient{api: api}
-	err := client.KillContainer(c, "SIGTERM", true)
+	err := NewDryRunClient(client).KillContainer(c, "SIGTERM")
 
 	assert.NoError(t, err)
 	api.AssertNotCalled(t, "KillContainer", "abc123", "SIGTERM")
@@ -213,7 +222,7 @@ func TestStopContainer_CustomSignalSuccess(t *testing.T) {
 	api.On("InspectContainer", "abc123").Return(&dockerclient.ContainerInfo{}, errors.New("Not Found"))
 
 	client := dockerClient{api: api}
-	err := client.StopContainer(c, 1, false)
+	err := client.StopContainer(c, 1)
 
 	assert.NoError(t, err)
 	api.AssertExpectations(t)
@@ -232,7 +241,7 @@ func TestStopContainer_KillContainerError(t *testing.T) {
 	api.On("KillContainer", "abc123", "SIGTERM").Return(errors.New("oops"))
 
 	client := dockerClient{api: api}
-	err := client.StopContainer(c, 1, false)
+	err := client.StopContainer(c, 1)
 
 	assert.Error(t, err)
 	assert.EqualError(t, err, "oops")
@@ -254,7 +263,7 @@ func TestStopContainer_2ndKillContainerError(t *testing.T) {
 	api.On("KillContainer", "abc123", "SIGKILL").Return(errors.New("whoops"))
 
 	client := dockerClient{api: api}
-	err := client.StopContainer(c, 1, false)
+	err := client.StopContainer(c, 1)
 
 	assert.Error(t, err)
 	assert.EqualError(t, err, "whoops")
@@ -380,7 +389,7 @@ func TestRemoveImage_Success(t *testing.T) {
 	api.On("RemoveImage", "abc123", false).Return([]*dockerclient.ImageDelete{}, nil)
 
 	client := dockerClient{api: api}
-	err := client.RemoveImage(c, false, false)
+	err := client.RemoveImage(c, false)
 
 	assert.NoError(t, err)
 	api.AssertExpectations(t)
@@ -397,7 +406,7 @@ func TestRemoveImage_DryRun(t *testing.T) {
 	api.On("RemoveImage", "abc123", false).Return([]*dockerclient.ImageDelete{}, nil)
 
 	client := dockerClient{api: api}
-	err := client.RemoveImage(c, false, true)
+	err := NewDryRunClient(client).RemoveImage(c, false)
 
 	assert.NoError(t, err)
 	api.AssertNotCalled(t, "RemoveImage", "abc123", false)
@@ -416,7 +425,7 @@ func TestRemoveContainer_Success(t *testing.T) {
 	engineClient.On("ContainerRemove", ctx, "abc123", removeOpts).Return(nil)
 
 	client := dockerClient{apiClient: engineClient}
-	err := client.RemoveContainer(c, true, true, true, false)
+	err := client.RemoveContainer(c, true, true, true)
 
 	assert.NoError(t, err)
 	engineClient.AssertExpectations(t)
@@ -435,7 +444,7 @@ func TestRemoveContainer_DryRun(t *testing.T) {
 	engineClient.On("ContainerRemove", ctx, "abc123", removeOpts).Return(nil)
 
 	client := dockerClient{apiClient: engineClient}
-	err := client.RemoveContainer(c, true, true, true, true)
+	err := NewDryRunClient(client).RemoveContainer(c, true, true, true)
 
 	assert.NoError(t, err)
 	engineClient.AssertNotCalled(t, "ContainerRemove", ctx, "abc123", removeOpts)
@@ -452,7 +461,7 @@ func TestRemoveImage_Error(t *testing.T) {
 	api.On("RemoveImage", "abc123", false).Return([]*dockerclient.ImageDelete{}, errors.New("oops"))
 
 	client := dockerClient{api: api}
-	err := client.RemoveImage(c, false, false)
+	err := client.RemoveImage(c, false)
 
 	assert.Error(t, err)
 	assert.EqualError(t, err, "oops")
@@ -474,7 +483,7 @@ func TestPauseContainer_Success(t *testing.T) {
 
 	client := dockerClient{api: api}
 	start := time.Now()
-	err := client.PauseContainer(c, d, false)
+	err := client.PauseContainer(c, d)
 	duration := time.Since(start)
 
 	assert.True(t, duration >= d)
@@ -494,7 +503,7 @@ func TestPauseContainer_DryRun(t *testing.T) {
 	api := mockclient.NewMockClient()
 
 	client := dockerClient{api: api}
-	err := client.PauseContainer(c, d, true)
+	err := NewDryRunClient(client).PauseContainer(c, d)
 
 	assert.NoError(t, err)
 	api.AssertNotCalled(t, "PauseContainer", "abc123")
@@ -514,7 +523,7 @@ func TestPauseContainer_PauseError(t *testing.T) {
 	api.On("PauseContainer", "abc123").Return(errors.New("pause"))
 
 	client := dockerClient{api: api}
-	err := client.PauseContainer(c, d, false)
+	err := client.PauseContainer(c, d)
 
 	assert.Error(t, err)
 	assert.EqualError(t, err, "pause")
@@ -535,13 +544,43 @@ func TestPauseContainer_UnpauseError(t *testing.T) {
 	api.On("UnpauseContainer", "abc123").Return(errors.New("unpause"))
 
 	client := dockerClient{api: api}
-	err := client.PauseContainer(c, d, false)
+	err := client.PauseContainer(c, d)
 
 	assert.Error(t, err)
 	assert.EqualError(t, err, "unpause")
 	api.AssertExpectations(t)
 }
 
+// expect 'tc qdisc show' exec with specified output
+func expectTcShow(engineClient *MockEngine, id string, netInterface string, output string) {
+	ctx := context.Background()
+	config := types.ExecConfig{Cmd: []string{"tc", "qdisc", "show", "dev", netInterface}, AttachStdout: true, AttachStderr: true, Tty: true}
+	conn, _ := net.Pipe()
+	resp := types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(strings.NewReader(output))}
+	engineClient.On("ContainerExecCreate", ctx, id, config).Return(types.ContainerExecCreateResponse{ID: "showID"}, nil).Once()
+	engineClient.On("ContainerExecAttach", ctx, "showID", config).Return(resp, nil).Once()
+}
+
+// 'tc qdisc show' output of applied netem: root netem qdisc or, with filter, netem qdisc on band of root prio qdisc
+const (
+	netemRootShow = "qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms\r\n"
+	netemPrioShow = "qdisc prio 1: root refcnt 2 bands 3 priomap  1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1\r\nqdisc netem 8002: parent 1:3 limit 1000 delay 100.0ms\r\n"
+)
+
+// expectQdiscCheck expects check of clean network interface, before netem is applied
+func expectQdiscCheck(engineClient *MockEngine, id string, netInterface string) {
+	expectTcShow(engineClient, id, netInterface, "qdisc noqueue 0: root refcnt 2\r\n")
+}
+
+func expectTcFilterShow(engineClient *MockEngine, id string, netInterface string, output string) {
+	ctx := context.Background()
+	config := types.ExecConfig{Cmd: []string{"tc", "filter", "show", "dev", netInterface}, AttachStdout: true, AttachStderr: true, Tty: true}
+	conn, _ := net.Pipe()
+	resp := types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(strings.NewReader(output))}
+	engineClient.On("ContainerExecCreate", ctx, id, config).Return(types.ContainerExecCreateResponse{ID: "filterShowID"}, nil).Once()
+	engineClient.On("ContainerExecAttach", ctx, "filterShowID", config).Return(resp, nil).Once()
+}
+
 func TestNetemContainer_Success(t *testing.T) {
 	c := Container{
 		containerInfo: &dockerclient.ContainerInfo{
@@ -551,15 +590,19 @@ func TestNetemContainer_Success(t *testing.T) {
 
 	ctx := context.Background()
 	engineClient := NewMockEngine()
-	config := types.ExecConfig{Cmd: []string{"tc", "qdisc", "add", "dev", "eth0", "root", "netem", "delay", "1000ms"}, Privileged: true}
+	config := types.ExecConfig{Cmd: []string{"tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "1000ms"}, Privileged: true}
 	engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.ContainerExecCreateResponse{"testID"}, nil)
 	engineClient.On("ContainerExecStart", ctx, "testID", types.ExecStartCheck{}).Return(nil)
 	stopConfig := types.ExecConfig{Cmd: []string{"tc", "qdisc", "del", "dev", "eth0", "root", "netem"}, Privileged: true}
 	engineClient.On("ContainerExecCreate", ctx, "abc123", stopConfig).Return(types.ContainerExecCreateResponse{"testID"}, nil)
 	engineClient.On("ContainerExecStart", ctx, "testID", types.ExecStartCheck{}).Return(nil)
 
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", netemRootShow)
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc noqueue 0: root refcnt 2\r\n")
+
 	client := dockerClient{apiClient: engineClient}
-	err := client.NetemContainer(c, "eth0", "delay 1000ms", nil, 1*time.Millisecond, false)
+	err := client.NetemContainer(c, "eth0", "delay 1000ms", netem.Filter{}, 1*time.Millisecond, false, "")
 
 	assert.NoError(t, err)
 	engineClient.AssertExpectations(t)
@@ -574,13 +617,72 @@ func TestNetemContainer_DryRun(t *testing.T) {
 
 	engineClient := NewMockEngine()
 	client := dockerClient{apiClient: engineClient}
-	err := client.NetemContainer(c, "eth0", "delay 1000ms", nil, 1*time.Millisecond, true)
+	err := NewDryRunClient(client).NetemContainer(c, "eth0", "delay 1000ms", netem.Filter{}, 1*time.Millisecond, false, "")
 
 	assert.NoError(t, err)
 	engineClient.AssertNotCalled(t, "ContainerExecCreate", mock.Anything)
 	engineClient.AssertNotCalled(t, "ContainerExecStart", "abc123", mock.Anything)
 }
 
+func expectProbe(engineClient *MockEngine, id string, probe string, execID string, output string) {
+	ctx := context.Background()
+	config := types.ExecConfig{Cmd: netem.ProbeCommand(probe), AttachStdout: true, AttachStderr: true, Tty: true}
+	conn, _ := net.Pipe()
+	resp := types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(strings.NewReader(output))}
+	engineClient.On("ContainerExecCreate", ctx, id, config).Return(types.ContainerExecCreateResponse{ID: execID}, nil).Once()
+	engineClient.On("ContainerExecAttach", ctx, execID, config).Return(resp, nil).Once()
+}
+
+func TestNetemContainer_Probe(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	engineClient := NewMockEngine()
+	var cmds [][]string
+	engineClient.On("ContainerExecCreate", context.Background(), "abc123", mock.MatchedBy(func(config types.ExecConfig) bool {
+		if config.Privileged {
+			cmds = append(cmds, config.Cmd)
+			return true
+		}
+		return false
+	})).Return(types.ContainerExecCreateResponse{ID: "cmdID"}, nil)
+	engineClient.On("ContainerExecStart", context.Background(), "cmdID", types.ExecStartCheck{}).Return(nil)
+	// baseline is measured before netem is applied, impaired latency - after
+	expectProbe(engineClient, "abc123", "db", "baselineID", "round-trip min/avg/max = 0.051/0.062/0.071 ms\r\n")
+	expectProbe(engineClient, "abc123", "db", "impairedID", "round-trip min/avg/max = 100.051/100.062/100.071 ms\r\n")
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", netemRootShow)
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc noqueue 0: root refcnt 2\r\n")
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{}, 1*time.Millisecond, false, "db")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(cmds))
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetemContainer_ProbeBaselineFails(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	engineClient := NewMockEngine()
+	expectProbe(engineClient, "abc123", "db", "baselineID", "ping: bad address 'db'\r\n")
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{}, 1*time.Millisecond, false, "db")
+
+	// netem is not applied, when impairment can not be verified
+	assert.EqualError(t, err, "Failed to measure baseline latency to db on container abc123: No response from db")
+	engineClient.AssertExpectations(t)
+}
+
 func TestNetemContainerIPFilter_Success(t *testing.T) {
 	c := Container{
 		containerInfo: &dockerclient.ContainerInfo{
@@ -591,7 +693,7 @@ func TestNetemContainerIPFilter_Success(t *testing.T) {
 	ctx := context.Background()
 	engineClient := NewMockEngine()
 
-	config1 := types.ExecConfig{Cmd: []string{"tc", "qdisc", "add", "dev", "eth0", "root", "handle", "1:", "prio"}, Privileged: true}
+	config1 := types.ExecConfig{Cmd: []string{"tc", "qdisc", "replace", "dev", "eth0", "root", "handle", "1:", "prio"}, Privileged: true}
 	engineClient.On("ContainerExecCreate", ctx, "abc123", config1).Return(types.ContainerExecCreateResponse{"cmd1"}, nil)
 	engineClient.On("ContainerExecStart", ctx, "cmd1", types.ExecStartCheck{}).Return(nil)
 
@@ -600,17 +702,1282 @@ func TestNetemContainerIPFilter_Success(t *testing.T) {
 	engineClient.On("ContainerExecStart", ctx, "cmd2", types.ExecStartCheck{}).Return(nil)
 
 	config3 := types.ExecConfig{Cmd: []string{"tc", "filter", "add", "dev", "eth0", "protocol", "ip",
-		"parent", "1:0", "prio", "3", "u32", "match", "ip", "dport", "10.10.0.1", "flowid", "1:3"}, Privileged: true}
+		"parent", "1:0", "prio", "3", "u32", "match", "ip", "dst", "10.10.0.1/32", "flowid", "1:3"}, Privileged: true}
 	engineClient.On("ContainerExecCreate", ctx, "abc123", config3).Return(types.ContainerExecCreateResponse{"cmd3"}, nil)
 	engineClient.On("ContainerExecStart", ctx, "cmd3", types.ExecStartCheck{}).Return(nil)
 
-	stopConfig := types.ExecConfig{Cmd: []string{"tc", "qdisc", "del", "dev", "eth0", "root", "netem"}, Privileged: true}
+	stopConfig := types.ExecConfig{Cmd: []string{"tc", "qdisc", "del", "dev", "eth0", "root", "handle", "1:", "prio"}, Privileged: true}
 	engineClient.On("ContainerExecCreate", ctx, "abc123", stopConfig).Return(types.ContainerExecCreateResponse{"testID"}, nil)
 	engineClient.On("ContainerExecStart", ctx, "testID", types.ExecStartCheck{}).Return(nil)
 
+	expectTcFilterShow(engineClient, "abc123", "eth0", "filter parent 1: protocol ip pref 3 u32 chain 0 fh 800::800 order 2048 key ht 800 bkt 0 *flowid 1:3 not_in_hw \r\n")
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", netemPrioShow)
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc noqueue 0: root refcnt 2\r\n")
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.NetemContainer(c, "eth0", "delay 1000ms", netem.Filter{IPs: []net.IP{net.ParseIP("10.10.0.1")}}, 1*time.Millisecond, false, "")
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetemContainerIPFilter_DualStack(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	engineClient := NewMockEngine()
+	var cmds [][]string
+	engineClient.On("ContainerExecCreate", context.Background(), "abc123", mock.MatchedBy(func(config types.ExecConfig) bool {
+		if config.Privileged {
+			cmds = append(cmds, config.Cmd)
+			return true
+		}
+		return false
+	})).Return(types.ContainerExecCreateResponse{ID: "cmdID"}, nil)
+	engineClient.On("ContainerExecStart", context.Background(), "cmdID", types.ExecStartCheck{}).Return(nil)
+	expectTcFilterShow(engineClient, "abc123", "eth0", "filter parent 1: protocol ip pref 3 u32 chain 0 fh 800::800 order 2048 key ht 800 bkt 0 *flowid 1:3 not_in_hw \r\n"+
+		"filter parent 1: protocol ipv6 pref 4 u32 chain 0 fh 801::800 order 2048 key ht 801 bkt 0 *flowid 1:3 not_in_hw \r\n")
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", netemPrioShow)
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc noqueue 0: root refcnt 2\r\n")
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{IPs: []net.IP{net.ParseIP("10.10.0.1"), net.ParseIP("fd00::1")}}, 1*time.Millisecond, false, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tc", "filter", "add", "dev", "eth0", "protocol", "ip", "parent", "1:0", "prio", "3", "u32", "match", "ip", "dst", "10.10.0.1/32", "flowid", "1:3"}, cmds[2])
+	assert.Equal(t, []string{"tc", "filter", "add", "dev", "eth0", "protocol", "ipv6", "parent", "1:0", "prio", "4", "u32", "match", "ip6", "dst", "fd00::1/128", "flowid", "1:3"}, cmds[3])
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetemContainerIPFilter_MissingFamily(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	engineClient := NewMockEngine()
+	var cmds [][]string
+	engineClient.On("ContainerExecCreate", context.Background(), "abc123", mock.MatchedBy(func(config types.ExecConfig) bool {
+		if config.Privileged {
+			cmds = append(cmds, config.Cmd)
+			return true
+		}
+		return false
+	})).Return(types.ContainerExecCreateResponse{ID: "cmdID"}, nil)
+	engineClient.On("ContainerExecStart", context.Background(), "cmdID", types.ExecStartCheck{}).Return(nil)
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", netemPrioShow)
+	expectTcFilterShow(engineClient, "abc123", "eth0", "filter parent 1: protocol ip pref 3 u32 chain 0 fh 800::800 order 2048 key ht 800 bkt 0 *flowid 1:3 not_in_hw \r\n")
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{IPs: []net.IP{net.ParseIP("10.10.0.1"), net.ParseIP("fd00::1")}}, 1*time.Millisecond, false, "")
+
+	assert.EqualError(t, err, "Netem filter for IPv6 traffic is missing on container abc123 on 'eth0'")
+	// netem is removed
+	assert.Equal(t, []string{"tc", "qdisc", "del", "dev", "eth0", "root", "handle", "1:", "prio"}, cmds[len(cmds)-1])
+	engineClient.AssertExpectations(t)
+}
+
+func TestSidecarContainer_Success(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	config := &enginecontainer.Config{
+		Image:      "gaiaadm/pumba",
+		Entrypoint: []string{"/usr/bin/pumba"},
+		Cmd:        []string{"proxy", "--port", "80"},
+		User:       "root",
+		Labels:     map[string]string{"com.gaiaadm.pumba": "true"},
+	}
+	hostConfig := &enginecontainer.HostConfig{NetworkMode: "container:abc123", CapAdd: []string{"NET_ADMIN"}}
+	engineClient.On("ImageInspectWithRaw", ctx, "gaiaadm/pumba", false).Return(types.ImageInspect{ID: "img1"}, []byte{}, nil)
+	engineClient.On("ContainerCreate", ctx, config, hostConfig, (*network.NetworkingConfig)(nil), "").Return(types.ContainerCreateResponse{ID: "side1"}, nil)
+	engineClient.On("ContainerStart", ctx, "side1", types.ContainerStartOptions{}).Return(nil)
+	engineClient.On("ContainerStop", ctx, "side1", mock.Anything).Return(nil)
+	engineClient.On("ContainerRemove", ctx, "side1", types.ContainerRemoveOptions{Force: true}).Return(nil)
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.SidecarContainer(c, "gaiaadm/pumba", []string{"/usr/bin/pumba", "proxy", "--port", "80"}, 1*time.Millisecond)
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestBeaconContainer(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id:    "abc123",
+			Image: "sha256:f00d",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	config := &enginecontainer.Config{
+		Image:      "sha256:f00d",
+		Entrypoint: []string{"true"},
+		Labels:     map[string]string{"com.gaiaadm.pumba": "true", "com.gaiaadm.pumba.chaos": "start"},
+	}
+	engineClient.On("ContainerCreate", ctx, config, &enginecontainer.HostConfig{}, (*network.NetworkingConfig)(nil), "").Return(types.ContainerCreateResponse{ID: "beacon1"}, nil)
+	engineClient.On("ContainerRemove", ctx, "beacon1", types.ContainerRemoveOptions{Force: true}).Return(nil)
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.BeaconContainer(c, map[string]string{"com.gaiaadm.pumba.chaos": "start"})
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+	engineClient.AssertNotCalled(t, "ContainerStart", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestBeaconContainer_CreateError(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerCreate", ctx, mock.Anything, mock.Anything, mock.Anything, "").Return(types.ContainerCreateResponse{}, errors.New("no such image"))
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.BeaconContainer(c, nil)
+
+	assert.EqualError(t, err, "Failed to create beacon container for container abc123: no such image")
+	engineClient.AssertNotCalled(t, "ContainerRemove", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCommitContainer(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id:   "abc123",
+			Name: "/web",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	options := types.ContainerCommitOptions{
+		Reference: "pumba-snapshot/web:1",
+		Comment:   "Pumba snapshot of container /web",
+		Pause:     true,
+		Config:    &enginecontainer.Config{Labels: map[string]string{"com.gaiaadm.pumba.snapshot": "true"}},
+	}
+	engineClient.On("ContainerCommit", ctx, "abc123", options).Return(types.ContainerCommitResponse{ID: "sha256:123"}, nil)
+
+	client := dockerClient{apiClient: engineClient}
+	id, err := client.CommitContainer(c, "pumba-snapshot/web:1", map[string]string{"com.gaiaadm.pumba.snapshot": "true"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256:123", id)
+	engineClient.AssertExpectations(t)
+}
+
+func TestCommitContainer_Error(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerCommit", mock.Anything, "abc123", mock.Anything).Return(types.ContainerCommitResponse{}, errors.New("no space left on device"))
+
+	client := dockerClient{apiClient: engineClient}
+	_, err := client.CommitContainer(c, "pumba-snapshot/c:1", nil)
+
+	assert.EqualError(t, err, "Failed to commit container abc123: no space left on device")
+}
+
+func TestListImages(t *testing.T) {
+	api := mockclient.NewMockClient()
+	api.On("ListImages", false).Return([]*dockerclient.Image{
+		{Id: "img1", Created: 1470045600, RepoTags: []string{"pumba-snapshot/web:1"}, Labels: map[string]string{"com.gaiaadm.pumba.snapshot": "true"}},
+		{Id: "img2", RepoTags: []string{"alpine:latest"}},
+	}, nil)
+
+	client := dockerClient{api: api}
+	images, err := client.ListImages("com.gaiaadm.pumba.snapshot")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Image{{ID: "img1", Tags: []string{"pumba-snapshot/web:1"}, Created: time.Unix(1470045600, 0), Labels: map[string]string{"com.gaiaadm.pumba.snapshot": "true"}}}, images)
+	api.AssertExpectations(t)
+}
+
+func TestDeleteImage(t *testing.T) {
+	api := mockclient.NewMockClient()
+	api.On("RemoveImage", "img1", false).Return([]*dockerclient.ImageDelete{}, nil)
+
+	client := dockerClient{api: api}
+	err := client.DeleteImage("img1", false)
+
+	assert.NoError(t, err)
+	api.AssertExpectations(t)
+}
+
+func TestSidecarContainer_StartError(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	engineClient.On("ImageInspectWithRaw", ctx, "gaiaadm/pumba", false).Return(types.ImageInspect{ID: "img1"}, []byte{}, nil)
+	engineClient.On("ContainerCreate", ctx, mock.Anything, mock.Anything, mock.Anything, "").Return(types.ContainerCreateResponse{ID: "side1"}, nil)
+	engineClient.On("ContainerStart", ctx, "side1", types.ContainerStartOptions{}).Return(errors.New("exec: \"/usr/bin/pumba\": no such file or directory"))
+	engineClient.On("ContainerRemove", ctx, "side1", types.ContainerRemoveOptions{Force: true}).Return(nil)
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.SidecarContainer(c, "gaiaadm/pumba", []string{"/usr/bin/pumba", "proxy"}, 1*time.Millisecond)
+
+	assert.EqualError(t, err, "Failed to start sidecar 'gaiaadm/pumba' (image should contain '/usr/bin/pumba'): exec: \"/usr/bin/pumba\": no such file or directory")
+	engineClient.AssertExpectations(t)
+}
+
+func TestSidecarContainer_PullImage(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	progress := ioutil.NopCloser(strings.NewReader(`{"status":"Pulling from gaiaadm/pumba"}` + "\n" + `{"status":"Downloaded newer image for gaiaadm/pumba:latest"}` + "\n"))
+	engineClient.On("ImageInspectWithRaw", ctx, "gaiaadm/pumba", false).Return(types.ImageInspect{}, []byte{}, errors.New("No such image: gaiaadm/pumba"))
+	engineClient.On("ImagePull", ctx, "gaiaadm/pumba", types.ImagePullOptions{}).Return(progress, nil)
+	engineClient.On("ContainerCreate", ctx, mock.Anything, mock.Anything, mock.Anything, "").Return(types.ContainerCreateResponse{ID: "side1"}, nil)
+	engineClient.On("ContainerStart", ctx, "side1", types.ContainerStartOptions{}).Return(nil)
+	engineClient.On("ContainerStop", ctx, "side1", mock.Anything).Return(nil)
+	engineClient.On("ContainerRemove", ctx, "side1", types.ContainerRemoveOptions{Force: true}).Return(nil)
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.SidecarContainer(c, "gaiaadm/pumba", []string{"/usr/bin/pumba", "proxy"}, 1*time.Millisecond)
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestSidecarContainer_PullError(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	// Docker reports pull failure in progress stream, with successful response
+	progress := ioutil.NopCloser(strings.NewReader(`{"error":"repository acme/proxy not found"}` + "\n"))
+	engineClient.On("ImageInspectWithRaw", ctx, "acme/proxy", false).Return(types.ImageInspect{}, []byte{}, errors.New("No such image: acme/proxy"))
+	engineClient.On("ImagePull", ctx, "acme/proxy", types.ImagePullOptions{}).Return(progress, nil)
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.SidecarContainer(c, "acme/proxy", []string{"/usr/bin/pumba", "proxy"}, 1*time.Millisecond)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Failed to pull image 'acme/proxy': repository acme/proxy not found")
+	engineClient.AssertExpectations(t)
+	engineClient.AssertNotCalled(t, "ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestSidecarContainer_DryRun(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	engineClient := NewMockEngine()
+	client := dockerClient{apiClient: engineClient}
+	err := NewDryRunClient(client).SidecarContainer(c, "gaiaadm/pumba", []string{"/usr/bin/pumba", "proxy"}, 1*time.Millisecond)
+
+	assert.NoError(t, err)
+	engineClient.AssertNotCalled(t, "ContainerCreate", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExecContainer_ExitCode(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Name: "db2",
+			Id:   "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	config := types.ExecConfig{Cmd: []string{"sh", "-c", "test -f /tmp/promoted"}}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.ContainerExecCreateResponse{ID: "e1"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "e1", types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", ctx, "e1").Return(types.ContainerExecInspect{ExecID: "e1", Running: true}, nil).Once()
+	engineClient.On("ContainerExecInspect", ctx, "e1").Return(types.ContainerExecInspect{ExecID: "e1", ExitCode: 1}, nil).Once()
+
 	client := dockerClient{apiClient: engineClient}
-	err := client.NetemContainer(c, "eth0", "delay 1000ms", net.ParseIP("10.10.0.1"), 1*time.Millisecond, false)
+	code, err := client.ExecContainer(c, []string{"sh", "-c", "test -f /tmp/promoted"}, time.Second)
 
 	assert.NoError(t, err)
+	assert.Equal(t, 1, code)
+	engineClient.AssertExpectations(t)
+}
+
+func TestExecContainer_Timeout(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Name: "db2",
+			Id:   "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerExecCreate", ctx, "abc123", mock.Anything).Return(types.ContainerExecCreateResponse{ID: "e1"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "e1", types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", ctx, "e1").Return(types.ContainerExecInspect{ExecID: "e1", Running: true}, nil)
+
+	client := dockerClient{apiClient: engineClient}
+	_, err := client.ExecContainer(c, []string{"sleep", "100"}, 1*time.Millisecond)
+
+	assert.EqualError(t, err, "Exec [sleep 100] in container db2 timed out after 1ms")
 	engineClient.AssertExpectations(t)
 }
+
+func TestBurnCPUContainer(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Name: "api1",
+			Id:   "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	config := types.ExecConfig{Cmd: stress.CPUCommand(2, 10*time.Millisecond)}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.ContainerExecCreateResponse{ID: "e1"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "e1", types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", ctx, "e1").Return(types.ContainerExecInspect{ExecID: "e1"}, nil)
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.BurnCPUContainer(c, 2, 10*time.Millisecond)
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestBurnCPUContainer_ExitCode(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Name: "api1",
+			Id:   "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerExecCreate", ctx, "abc123", mock.Anything).Return(types.ContainerExecCreateResponse{ID: "e1"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "e1", types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", ctx, "e1").Return(types.ContainerExecInspect{ExecID: "e1", ExitCode: 127}, nil)
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.BurnCPUContainer(c, 0, 10*time.Millisecond)
+
+	assert.EqualError(t, err, "CPU burn in container abc123 failed with exit code 127")
+	code, ok := ExitCode(err)
+	assert.True(t, ok)
+	assert.Equal(t, 127, code)
+	assert.Equal(t, "cpu", err.(*Error).Action)
+}
+
+func TestBurnCPUContainer_DryRun(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	engineClient := NewMockEngine()
+	client := dockerClient{apiClient: engineClient}
+	err := NewDryRunClient(client).BurnCPUContainer(c, 0, 10*time.Millisecond)
+
+	assert.NoError(t, err)
+	engineClient.AssertNotCalled(t, "ContainerExecCreate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func oomTestContainer(memory int64) Container {
+	return Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Name:       "api1",
+			Id:         "abc123",
+			HostConfig: &dockerclient.HostConfig{Memory: memory},
+		},
+	}
+}
+
+func TestOOMContainer_MainProcessKilled(t *testing.T) {
+	c := oomTestContainer(64 << 20)
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	config := types.ExecConfig{Cmd: stress.OOMCommand(time.Second)}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.ContainerExecCreateResponse{ID: "e1"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "e1", types.ExecStartCheck{}).Return(nil)
+	// exec is gone with exited container
+	engineClient.On("ContainerExecInspect", ctx, "e1").Return(types.ContainerExecInspect{}, errors.New("no such exec instance"))
+	api := mockclient.NewMockClient()
+	api.On("InspectContainer", "abc123").Return(&dockerclient.ContainerInfo{State: &dockerclient.State{OOMKilled: true}}, nil)
+
+	client := dockerClient{api: api, apiClient: engineClient}
+	err := client.OOMContainer(c, time.Second)
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+	api.AssertExpectations(t)
+}
+
+func TestOOMContainer_ExitCodes(t *testing.T) {
+	for code, expected := range map[int]string{
+		137: "",
+		143: "OOM killer was not triggered in container abc123 within 1s",
+		127: "Memory balloon in container abc123 failed with exit code 127",
+	} {
+		ctx := context.Background()
+		engineClient := NewMockEngine()
+		engineClient.On("ContainerExecCreate", ctx, "abc123", mock.Anything).Return(types.ContainerExecCreateResponse{ID: "e1"}, nil)
+		engineClient.On("ContainerExecStart", ctx, "e1", types.ExecStartCheck{}).Return(nil)
+		engineClient.On("ContainerExecInspect", ctx, "e1").Return(types.ContainerExecInspect{ExecID: "e1", ExitCode: code}, nil)
+		api := mockclient.NewMockClient()
+		api.On("InspectContainer", "abc123").Return(&dockerclient.ContainerInfo{State: &dockerclient.State{Running: true}}, nil)
+
+		client := dockerClient{api: api, apiClient: engineClient}
+		err := client.OOMContainer(oomTestContainer(64<<20), time.Second)
+
+		if expected == "" {
+			assert.NoError(t, err)
+		} else {
+			assert.EqualError(t, err, expected)
+		}
+	}
+}
+
+func TestOOMContainer_NoMemoryLimit(t *testing.T) {
+	engineClient := NewMockEngine()
+	client := dockerClient{apiClient: engineClient}
+	err := NewDryRunClient(client).OOMContainer(oomTestContainer(0), time.Second)
+
+	assert.EqualError(t, err, "Container abc123 has no memory limit: memory balloon would exhaust Docker host memory")
+	engineClient.AssertNotCalled(t, "ContainerExecCreate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPauseContainer_FakeClock(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	api := mockclient.NewMockClient()
+	api.On("PauseContainer", "abc123").Return(nil)
+	api.On("UnpauseContainer", "abc123").Return(nil)
+
+	fake := clock.NewFake(time.Now())
+	client := dockerClient{api: api, clock: fake}
+	done := make(chan error)
+	go func() { done <- client.PauseContainer(c, 1*time.Hour) }()
+
+	fake.BlockUntil(1)
+	api.AssertNotCalled(t, "UnpauseContainer", "abc123")
+	fake.Advance(1 * time.Hour)
+
+	assert.NoError(t, <-done)
+	api.AssertExpectations(t)
+}
+
+func TestBlackoutContainer_FakeClock(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id:   "abc123",
+			Name: "/api_1",
+		},
+	}
+
+	api := mockclient.NewMockClient()
+	api.On("StopContainer", "abc123", 10).Return(nil)
+	api.On("StartContainer", "abc123", mock.AnythingOfType("*dockerclient.HostConfig")).Return(nil)
+
+	fake := clock.NewFake(time.Now())
+	client := dockerClient{api: api, clock: fake}
+	done := make(chan error)
+	go func() { done <- client.BlackoutContainer(c, 10, 5*time.Minute) }()
+
+	fake.BlockUntil(1)
+	api.AssertNotCalled(t, "StartContainer", "abc123", mock.Anything)
+	fake.Advance(5 * time.Minute)
+
+	assert.NoError(t, <-done)
+	api.AssertExpectations(t)
+}
+
+func TestBlackoutContainer_StopError(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	api := mockclient.NewMockClient()
+	api.On("StopContainer", "abc123", 10).Return(errors.New("stop"))
+
+	client := dockerClient{api: api}
+	err := client.BlackoutContainer(c, 10, time.Millisecond)
+
+	assert.EqualError(t, err, "stop")
+	api.AssertNotCalled(t, "StartContainer", "abc123", mock.Anything)
+}
+
+func TestBlackoutContainer_DryRun(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	api := mockclient.NewMockClient()
+	client := dockerClient{api: api}
+	err := NewDryRunClient(client).BlackoutContainer(c, 10, time.Hour)
+
+	assert.NoError(t, err)
+	api.AssertNotCalled(t, "StopContainer", "abc123", 10)
+}
+
+func TestNetemContainer_FakeClock(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	config := types.ExecConfig{Cmd: []string{"tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "1000ms"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.ContainerExecCreateResponse{ID: "testID"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "testID", types.ExecStartCheck{}).Return(nil)
+	stopConfig := types.ExecConfig{Cmd: []string{"tc", "qdisc", "del", "dev", "eth0", "root", "netem"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", stopConfig).Return(types.ContainerExecCreateResponse{ID: "stopID"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "stopID", types.ExecStartCheck{}).Return(nil)
+
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", netemRootShow)
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc noqueue 0: root refcnt 2\r\n")
+
+	fake := clock.NewFake(time.Now())
+	client := dockerClient{apiClient: engineClient, clock: fake}
+	done := make(chan error)
+	go func() {
+		done <- client.NetemContainer(c, "eth0", "delay 1000ms", netem.Filter{}, 10*time.Minute, false, "")
+	}()
+
+	fake.BlockUntil(1)
+	engineClient.AssertNotCalled(t, "ContainerExecCreate", ctx, "abc123", stopConfig)
+	fake.Advance(10 * time.Minute)
+
+	assert.NoError(t, <-done)
+	engineClient.AssertExpectations(t)
+}
+
+func TestWaitForStop_FakeClockTimeout(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	ci := &dockerclient.ContainerInfo{
+		State: &dockerclient.State{
+			Running: true,
+		},
+	}
+	api := mockclient.NewMockClient()
+	api.On("InspectContainer", "abc123").Return(ci, nil)
+
+	fake := clock.NewFake(time.Now())
+	client := dockerClient{api: api, clock: fake}
+	done := make(chan error)
+	go func() { done <- client.waitForStop(c, 10) }()
+
+	// wait for timeout timer and poll sleep
+	fake.BlockUntil(2)
+	fake.Advance(10 * time.Second)
+
+	assert.NoError(t, <-done)
+	api.AssertExpectations(t)
+}
+
+func TestNetemContainer_LingeringQdisc(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerExecCreate", ctx, "abc123", mock.MatchedBy(func(config types.ExecConfig) bool { return config.Privileged })).Return(types.ContainerExecCreateResponse{ID: "tcID"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "tcID", types.ExecStartCheck{}).Return(nil)
+	lingering := "qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms\r\n"
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", netemRootShow)
+	expectTcShow(engineClient, "abc123", "eth0", lingering)
+	expectTcShow(engineClient, "abc123", "eth0", lingering)
+	expectTcShow(engineClient, "abc123", "eth0", lingering)
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{}, 1*time.Millisecond, false, "")
+
+	assert.EqualError(t, err, "Netem qdisc is still present on container abc123 on 'eth0': qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms")
+	// add + del + 2 retries of del
+	engineClient.AssertNumberOfCalls(t, "ContainerExecStart", 4)
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetemContainer_ReplaceLeftover(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	var cmds [][]string
+	engineClient.On("ContainerExecCreate", ctx, "abc123", mock.MatchedBy(func(config types.ExecConfig) bool {
+		if config.Privileged {
+			cmds = append(cmds, config.Cmd)
+			return true
+		}
+		return false
+	})).Return(types.ContainerExecCreateResponse{ID: "tcID"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "tcID", types.ExecStartCheck{}).Return(nil)
+	// netem of previous run is left on interface
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc netem 8001: root refcnt 2 limit 1000 delay 500.0ms\r\n")
+	expectTcShow(engineClient, "abc123", "eth0", netemRootShow)
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc noqueue 0: root refcnt 2\r\n")
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{}, time.Millisecond, false, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "100ms"},
+		{"tc", "qdisc", "del", "dev", "eth0", "root", "netem"},
+	}, cmds)
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetemContainer_ForeignRootQdisc(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	engineClient := NewMockEngine()
+	expectIPLink(engineClient, "abc123", ipLinkOutput)
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", netemRootShow)
+	expectTcShow(engineClient, "abc123", "eth1", "qdisc htb 1: root refcnt 2 r2q 10 default 0x10 direct_packets_stat 0\r\n")
+	ctx := context.Background()
+	addConfig := types.ExecConfig{Cmd: []string{"tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "100ms"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", addConfig).Return(types.ContainerExecCreateResponse{ID: "tcID"}, nil).Once()
+	// netem is removed from eth0, when root qdisc of eth1 can not be replaced
+	delConfig := types.ExecConfig{Cmd: []string{"tc", "qdisc", "del", "dev", "eth0", "root", "netem"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", delConfig).Return(types.ContainerExecCreateResponse{ID: "tcID"}, nil).Once()
+	engineClient.On("ContainerExecStart", ctx, "tcID", types.ExecStartCheck{}).Return(nil).Twice()
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.NetemContainer(c, AllInterfaces, "delay 100ms", netem.Filter{}, time.Millisecond, false, "")
+
+	assert.EqualError(t, err, "Container abc123 has root qdisc 'qdisc htb 1: root refcnt 2 r2q 10 default 0x10 direct_packets_stat 0' on 'eth1', not added by Pumba: remove it to apply netem")
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetemContainer_NotApplied(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	var cmds [][]string
+	engineClient.On("ContainerExecCreate", ctx, "abc123", mock.MatchedBy(func(config types.ExecConfig) bool {
+		if config.Privileged {
+			cmds = append(cmds, config.Cmd)
+			return true
+		}
+		return false
+	})).Return(types.ContainerExecCreateResponse{ID: "tcID"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "tcID", types.ExecStartCheck{}).Return(nil)
+	// 'tc' fails without NET_ADMIN capability, and exec does not report it
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc noqueue 0: root refcnt 2\r\n")
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{}, time.Minute, false, "")
+
+	assert.EqualError(t, err, "Netem qdisc is not applied on container abc123 on 'eth0' (is 'tc' available and NET_ADMIN capability granted?): qdisc noqueue 0: root refcnt 2")
+	// partially applied netem is rolled back, and netem duration is not waited for
+	assert.Equal(t, [][]string{
+		{"tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "100ms"},
+		{"tc", "qdisc", "del", "dev", "eth0", "root", "netem"},
+	}, cmds)
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetemContainer_VerifyFails(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	var cmds [][]string
+	engineClient.On("ContainerExecCreate", ctx, "abc123", mock.MatchedBy(func(config types.ExecConfig) bool {
+		if config.Privileged {
+			cmds = append(cmds, config.Cmd)
+			return true
+		}
+		return false
+	})).Return(types.ContainerExecCreateResponse{ID: "tcID"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "tcID", types.ExecStartCheck{}).Return(nil)
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	showConfig := types.ExecConfig{Cmd: []string{"tc", "qdisc", "show", "dev", "eth0"}, AttachStdout: true, AttachStderr: true, Tty: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", showConfig).Return(types.ContainerExecCreateResponse{}, errors.New("exec failed")).Once()
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{}, time.Minute, false, "")
+
+	// netem, that can not be verified, fails chaos action and is rolled back
+	assert.EqualError(t, err, "Failed to verify netem on container abc123 on 'eth0': exec failed")
+	assert.Equal(t, [][]string{
+		{"tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "100ms"},
+		{"tc", "qdisc", "del", "dev", "eth0", "root", "netem"},
+	}, cmds)
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetemContainer_RetryRemoval(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerExecCreate", ctx, "abc123", mock.MatchedBy(func(config types.ExecConfig) bool { return config.Privileged })).Return(types.ContainerExecCreateResponse{ID: "tcID"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "tcID", types.ExecStartCheck{}).Return(nil)
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", netemRootShow)
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms\r\n")
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc noqueue 0: root refcnt 2\r\n")
+
+	client := dockerClient{apiClient: engineClient, clock: clock.NewFake(time.Now())}
+	done := make(chan error)
+	go func() { done <- client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{}, 0, false, "") }()
+	// retry delay
+	client.clock.(*clock.Fake).BlockUntil(1)
+	client.clock.(*clock.Fake).Advance(netemStopRetryDelay)
+
+	assert.NoError(t, <-done)
+	engineClient.AssertNumberOfCalls(t, "ContainerExecStart", 3)
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetworkInterface_Success(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+	c.containerInfo.NetworkSettings.Networks = map[string]*dockerclient.EndpointSettings{
+		"macvlan0": {IPAddress: "10.0.9.3"},
+	}
+
+	ctx := context.Background()
+	config := types.ExecConfig{Cmd: []string{"ip", "-o", "addr", "show"}, AttachStdout: true, AttachStderr: true, Tty: true}
+	conn, _ := net.Pipe()
+	output := "1: lo    inet 127.0.0.1/8 scope host lo\\       valid_lft forever preferred_lft forever\r\n" +
+		"12: eth1@if13    inet 10.0.9.3/24 scope global eth1\\       valid_lft forever preferred_lft forever\r\n"
+	resp := types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(strings.NewReader(output))}
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.ContainerExecCreateResponse{ID: "addrID"}, nil)
+	engineClient.On("ContainerExecAttach", ctx, "addrID", config).Return(resp, nil)
+
+	client := dockerClient{apiClient: engineClient}
+	netInterface, err := client.NetworkInterface(c, "macvlan0")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "eth1", netInterface)
+	engineClient.AssertExpectations(t)
+}
+
+func TestResolveAlias_Success(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	config := types.ExecConfig{Cmd: []string{"getent", "ahosts", "db"}, AttachStdout: true, AttachStderr: true, Tty: true}
+	conn, _ := net.Pipe()
+	output := "10.0.9.5        STREAM db\r\n10.0.9.5        DGRAM  \r\n10.0.9.5        RAW    \r\n" +
+		"fd00::5         STREAM \r\nfd00::5         DGRAM  \r\nfd00::5         RAW    \r\n"
+	resp := types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(strings.NewReader(output))}
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.ContainerExecCreateResponse{ID: "getentID"}, nil)
+	engineClient.On("ContainerExecAttach", ctx, "getentID", config).Return(resp, nil)
+
+	client := dockerClient{apiClient: engineClient}
+	ips, err := client.ResolveAlias(c, "db")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []net.IP{net.ParseIP("10.0.9.5"), net.ParseIP("fd00::5")}, ips)
+	engineClient.AssertExpectations(t)
+}
+
+func TestResolveAlias_NotFound(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	for _, db := range []string{"ahosts", "hosts"} {
+		config := types.ExecConfig{Cmd: []string{"getent", db, "db"}, AttachStdout: true, AttachStderr: true, Tty: true}
+		conn, _ := net.Pipe()
+		resp := types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(strings.NewReader(""))}
+		engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.ContainerExecCreateResponse{ID: db + "ID"}, nil)
+		engineClient.On("ContainerExecAttach", ctx, db+"ID", config).Return(resp, nil)
+	}
+
+	client := dockerClient{apiClient: engineClient}
+	_, err := client.ResolveAlias(c, "db")
+
+	assert.EqualError(t, err, "Failed to resolve 'db' in container abc123")
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetworkInterface_NotConnected(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	engineClient := NewMockEngine()
+	client := dockerClient{apiClient: engineClient}
+	_, err := client.NetworkInterface(c, "overlay0")
+
+	assert.EqualError(t, err, "Container abc123 is not connected to network 'overlay0'")
+	engineClient.AssertExpectations(t)
+}
+
+// expect 'ip -o link show' exec with specified output
+func expectIPLink(engineClient *MockEngine, id string, output string) {
+	ctx := context.Background()
+	config := types.ExecConfig{Cmd: []string{"ip", "-o", "link", "show"}, AttachStdout: true, AttachStderr: true, Tty: true}
+	conn, _ := net.Pipe()
+	resp := types.HijackedResponse{Conn: conn, Reader: bufio.NewReader(strings.NewReader(output))}
+	engineClient.On("ContainerExecCreate", ctx, id, config).Return(types.ContainerExecCreateResponse{ID: "linkID"}, nil).Once()
+	engineClient.On("ContainerExecAttach", ctx, "linkID", config).Return(resp, nil).Once()
+}
+
+const ipLinkOutput = "1: lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN mode DEFAULT group default qlen 1000\\    link/loopback 00:00:00:00:00:00 brd 00:00:00:00:00:00\r\n" +
+	"12: eth0@if13: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc noqueue state UP mode DEFAULT group default \\    link/ether 02:42:ac:11:00:02 brd ff:ff:ff:ff:ff:ff link-netnsid 0\r\n" +
+	"14: eth1@if15: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc noqueue state UP mode DEFAULT group default \\    link/ether 02:42:0a:00:09:03 brd ff:ff:ff:ff:ff:ff link-netnsid 0\r\n"
+
+func TestNetworkInterfaces(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	engineClient := NewMockEngine()
+	expectIPLink(engineClient, "abc123", ipLinkOutput)
+	expectIPLink(engineClient, "abc123", "1: lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN\r\n")
+
+	client := dockerClient{apiClient: engineClient}
+	ifaces, err := client.NetworkInterfaces(c)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"eth0", "eth1"}, ifaces)
+	_, err = client.NetworkInterfaces(c)
+	assert.EqualError(t, err, "Failed to find network interfaces in container abc123")
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetemLeftovers(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	engineClient := NewMockEngine()
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms\r\n")
+	expectTcShow(engineClient, "abc123", "eth1", "qdisc noqueue 0: root refcnt 2\r\n")
+
+	client := dockerClient{apiClient: engineClient}
+	qdiscs, err := client.NetemLeftovers(c, "eth0")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms"}, qdiscs)
+	qdiscs, err = client.NetemLeftovers(c, "eth1")
+	assert.NoError(t, err)
+	assert.Empty(t, qdiscs)
+	engineClient.AssertExpectations(t)
+}
+
+func TestClearNetemContainer(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	expectIPLink(engineClient, "abc123", ipLinkOutput)
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc prio 1: root refcnt 2 bands 3 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1\r\nqdisc netem 10: parent 1:3 limit 1000 delay 100.0ms\r\n")
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc noqueue 0: root refcnt 2\r\n")
+	// interface without netem is skipped
+	expectTcShow(engineClient, "abc123", "eth1", "qdisc noqueue 0: root refcnt 2\r\n")
+	delConfig := types.ExecConfig{Cmd: []string{"tc", "qdisc", "del", "dev", "eth0", "root"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", delConfig).Return(types.ContainerExecCreateResponse{ID: "tcID"}, nil).Once()
+	engineClient.On("ContainerExecStart", ctx, "tcID", types.ExecStartCheck{}).Return(nil).Once()
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.ClearNetemContainer(c, AllInterfaces)
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestClearNetemContainer_ForeignRootQdisc(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	engineClient := NewMockEngine()
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc htb 1: root refcnt 2 r2q 10 default 0x10 direct_packets_stat 0\r\n")
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.ClearNetemContainer(c, "eth0")
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+	engineClient.AssertNotCalled(t, "ContainerExecStart", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestClearNetemContainer_Lingering(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	// netem is not removed, e.g. tc fails without NET_ADMIN capability
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms\r\n")
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms\r\n")
+	delConfig := types.ExecConfig{Cmd: []string{"tc", "qdisc", "del", "dev", "eth0", "root"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", delConfig).Return(types.ContainerExecCreateResponse{ID: "tcID"}, nil).Once()
+	engineClient.On("ContainerExecStart", ctx, "tcID", types.ExecStartCheck{}).Return(nil).Once()
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.ClearNetemContainer(c, "eth0")
+
+	assert.EqualError(t, err, "Failed to remove netem from container abc123 on 'eth0': qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms")
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetemContainer_AllInterfaces(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	expectIPLink(engineClient, "abc123", ipLinkOutput)
+	for _, iface := range []string{"eth0", "eth1"} {
+		for _, cmd := range [][]string{
+			{"tc", "qdisc", "replace", "dev", iface, "root", "netem", "delay", "100ms"},
+			{"tc", "qdisc", "del", "dev", iface, "root", "netem"},
+		} {
+			config := types.ExecConfig{Cmd: cmd, Privileged: true}
+			engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.ContainerExecCreateResponse{ID: "tcID"}, nil).Once()
+		}
+		expectQdiscCheck(engineClient, "abc123", iface)
+		expectTcShow(engineClient, "abc123", iface, netemRootShow)
+		expectTcShow(engineClient, "abc123", iface, "qdisc noqueue 0: root refcnt 2\r\n")
+	}
+	engineClient.On("ContainerExecStart", ctx, "tcID", types.ExecStartCheck{}).Return(nil).Times(4)
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.NetemContainer(c, AllInterfaces, "delay 100ms", netem.Filter{}, time.Millisecond, false, "")
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetemContainer_AllInterfacesRollback(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	expectIPLink(engineClient, "abc123", ipLinkOutput)
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", netemRootShow)
+	expectQdiscCheck(engineClient, "abc123", "eth1")
+	addConfig := types.ExecConfig{Cmd: []string{"tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "100ms"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", addConfig).Return(types.ContainerExecCreateResponse{ID: "eth0ID"}, nil).Once()
+	failConfig := types.ExecConfig{Cmd: []string{"tc", "qdisc", "replace", "dev", "eth1", "root", "netem", "delay", "100ms"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", failConfig).Return(types.ContainerExecCreateResponse{ID: "eth1ID"}, nil).Once()
+	engineClient.On("ContainerExecStart", ctx, "eth1ID", types.ExecStartCheck{}).Return(errors.New("tc failed")).Once()
+	// netem is removed from eth0, when it fails on eth1
+	delConfig := types.ExecConfig{Cmd: []string{"tc", "qdisc", "del", "dev", "eth0", "root", "netem"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", delConfig).Return(types.ContainerExecCreateResponse{ID: "eth0ID"}, nil).Once()
+	engineClient.On("ContainerExecStart", ctx, "eth0ID", types.ExecStartCheck{}).Return(nil).Twice()
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.NetemContainer(c, AllInterfaces, "delay 100ms", netem.Filter{}, time.Millisecond, false, "")
+
+	assert.Error(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestNetemContainer_ReapplyOnRestart(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	config := types.ExecConfig{Cmd: []string{"tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "1000ms"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.ContainerExecCreateResponse{ID: "testID"}, nil).Twice()
+	reapplied := make(chan struct{})
+	engineClient.On("ContainerExecStart", ctx, "testID", types.ExecStartCheck{}).Return(nil).Once()
+	engineClient.On("ContainerExecStart", ctx, "testID", types.ExecStartCheck{}).Return(nil).Once().Run(func(mock.Arguments) { close(reapplied) })
+	stopConfig := types.ExecConfig{Cmd: []string{"tc", "qdisc", "del", "dev", "eth0", "root", "netem"}, Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", stopConfig).Return(types.ContainerExecCreateResponse{ID: "stopID"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "stopID", types.ExecStartCheck{}).Return(nil)
+	// qdiscs are checked before netem is applied and re-applied
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", netemRootShow)
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", netemRootShow)
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc noqueue 0: root refcnt 2\r\n")
+
+	events := make(chan dockerclient.EventOrError)
+	api := mockclient.NewMockClient()
+	api.On("MonitorEvents", mock.AnythingOfType("*dockerclient.MonitorEventsOptions"), mock.Anything).Return((<-chan dockerclient.EventOrError)(events), nil)
+
+	fake := clock.NewFake(time.Now())
+	client := dockerClient{api: api, apiClient: engineClient, clock: fake}
+	done := make(chan error)
+	go func() {
+		done <- client.NetemContainer(c, "eth0", "delay 1000ms", netem.Filter{}, 10*time.Minute, true, "")
+	}()
+
+	fake.BlockUntil(1)
+	// events of other containers are not delivered to netem watch
+	events <- dockerclient.EventOrError{Event: dockerclient.Event{ID: "def456", Status: "start"}}
+	events <- dockerclient.EventOrError{Event: dockerclient.Event{ID: "abc123", Status: "start"}}
+	<-reapplied
+	fake.Advance(10 * time.Minute)
+
+	assert.NoError(t, <-done)
+	engineClient.AssertExpectations(t)
+	api.AssertExpectations(t)
+}
+
+func TestDropPortsContainer_Success(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+	c.containerInfo.NetworkSettings.IPAddress = "172.17.0.2"
+	c.containerInfo.NetworkSettings.Ports = map[string][]dockerclient.PortBinding{
+		"80/tcp":   {{HostIp: "0.0.0.0", HostPort: "8080"}},
+		"9000/tcp": nil,
+	}
+
+	var cmds []string
+	hostExec := func(cmd []string) ([]byte, error) {
+		cmds = append(cmds, strings.Join(cmd, " "))
+		return nil, nil
+	}
+
+	client := dockerClient{hostExec: hostExec}
+	err := client.DropPortsContainer(c, 100, 1*time.Millisecond)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"iptables -I FORWARD -p tcp -d 172.17.0.2 --dport 80 -m conntrack --ctstate DNAT --ctorigdstport 8080 -m comment --comment pumba -j DROP",
+		"iptables -D FORWARD -p tcp -d 172.17.0.2 --dport 80 -m conntrack --ctstate DNAT --ctorigdstport 8080 -m comment --comment pumba -j DROP",
+	}, cmds)
+}
+
+func TestDropPortsContainer_UserNetwork(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+	c.containerInfo.NetworkSettings.Networks = map[string]*dockerclient.EndpointSettings{
+		"front": {IPAddress: "10.0.9.3"},
+	}
+	c.containerInfo.NetworkSettings.Ports = map[string][]dockerclient.PortBinding{
+		"53/udp": {{HostIp: "10.0.0.1", HostPort: "5353"}},
+	}
+
+	ports, err := publishedPorts(c)
+
+	assert.NoError(t, err)
+	assert.Len(t, ports, 1)
+	assert.Equal(t, "udp", ports[0].Proto)
+	assert.Equal(t, "10.0.9.3", ports[0].ContainerIP.String())
+	assert.Equal(t, "10.0.0.1", ports[0].HostIP.String())
+	assert.Equal(t, 5353, ports[0].HostPort)
+	assert.Equal(t, 53, ports[0].ContainerPort)
+}
+
+func TestDropPortsContainer_NoPorts(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+	c.containerInfo.NetworkSettings.IPAddress = "172.17.0.2"
+
+	client := dockerClient{}
+	err := client.DropPortsContainer(c, 100, 1*time.Millisecond)
+
+	assert.EqualError(t, err, "Container abc123 has no published ports")
+}
+
+func TestDropPortsContainer_HostError(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+	c.containerInfo.NetworkSettings.IPAddress = "172.17.0.2"
+	c.containerInfo.NetworkSettings.Ports = map[string][]dockerclient.PortBinding{
+		"80/tcp": {{HostPort: "8080"}},
+	}
+
+	var ops []string
+	hostExec := func(cmd []string) ([]byte, error) {
+		ops = append(ops, cmd[1])
+		return []byte("iptables: Permission denied\n"), errors.New("exit status 1")
+	}
+
+	client := dockerClient{hostExec: hostExec}
+	err := client.DropPortsContainer(c, 100, 1*time.Millisecond)
+
+	assert.EqualError(t, err, "Command 'iptables -I FORWARD -p tcp -d 172.17.0.2 --dport 80 -m conntrack --ctstate DNAT --ctorigdstport 8080 -m comment --comment pumba -j DROP' failed: exit status 1: iptables: Permission denied")
+	// failed rule was not added: nothing to clean up
+	assert.Equal(t, []string{"-I"}, ops)
+}
+
+func TestDropPortsContainer_Rollback(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+	c.containerInfo.NetworkSettings.IPAddress = "172.17.0.2"
+	c.containerInfo.NetworkSettings.Ports = map[string][]dockerclient.PortBinding{
+		"80/tcp": {{HostPort: "8080"}, {HostPort: "8081"}},
+	}
+
+	var ops []string
+	hostExec := func(cmd []string) ([]byte, error) {
+		ops = append(ops, cmd[1])
+		if len(ops) == 2 {
+			return nil, errors.New("exit status 1")
+		}
+		return nil, nil
+	}
+
+	client := dockerClient{hostExec: hostExec}
+	err := client.DropPortsContainer(c, 100, 1*time.Millisecond)
+
+	assert.Error(t, err)
+	// rule added before failure is removed
+	assert.Equal(t, []string{"-I", "-I", "-D"}, ops)
+}
+
+func TestExitWatch_Die(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	events := make(chan dockerclient.EventOrError, 2)
+	events <- dockerclient.EventOrError{Event: dockerclient.Event{ID: "abc123", Status: "kill"}}
+	events <- dockerclient.EventOrError{Event: dockerclient.Event{ID: "abc123", Status: "die"}}
+	api := mockclient.NewMockClient()
+	api.On("MonitorEvents", mock.AnythingOfType("*dockerclient.MonitorEventsOptions"), mock.Anything).Return((<-chan dockerclient.EventOrError)(events), nil)
+
+	now := time.Now()
+	client := dockerClient{api: api, clock: clock.NewFake(now)}
+	exited, err := client.ExitWatch(c, time.Minute)
+
+	assert.NoError(t, err)
+	exitTime, ok := <-exited
+	assert.True(t, ok)
+	assert.Equal(t, now, exitTime)
+	api.AssertExpectations(t)
+}
+
+func TestExitWatch_Timeout(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	events := make(chan dockerclient.EventOrError)
+	api := mockclient.NewMockClient()
+	api.On("MonitorEvents", mock.AnythingOfType("*dockerclient.MonitorEventsOptions"), mock.Anything).Return((<-chan dockerclient.EventOrError)(events), nil)
+
+	fake := clock.NewFake(time.Now())
+	client := dockerClient{api: api, clock: fake}
+	exited, err := client.ExitWatch(c, time.Minute)
+	assert.NoError(t, err)
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+
+	_, ok := <-exited
+	assert.False(t, ok)
+}
+
+func TestUnpauseContainer(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	api := mockclient.NewMockClient()
+	api.On("UnpauseContainer", "abc123").Return(nil)
+
+	client := dockerClient{api: api}
+	err := client.UnpauseContainer(c)
+
+	assert.NoError(t, err)
+	api.AssertExpectations(t)
+}
+
+func TestRestorePortsContainer(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+	c.containerInfo.NetworkSettings.IPAddress = "172.17.0.2"
+	c.containerInfo.NetworkSettings.Ports = map[string][]dockerclient.PortBinding{
+		"80/tcp": {{HostIp: "0.0.0.0", HostPort: "8080"}},
+	}
+
+	var cmds []string
+	hostExec := func(cmd []string) ([]byte, error) {
+		cmds = append(cmds, strings.Join(cmd, " "))
+		return nil, nil
+	}
+
+	client := dockerClient{hostExec: hostExec}
+	err := client.RestorePortsContainer(c, 50)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"iptables -D FORWARD -p tcp -d 172.17.0.2 --dport 80 -m conntrack --ctstate DNAT --ctorigdstport 8080 -m statistic --mode random --probability 0.50 -m comment --comment pumba -j DROP",
+	}, cmds)
+}
@@ -2,6 +2,9 @@ package container
 
 import (
 	"fmt"
+	"net"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/samalba/dockerclient"
@@ -11,6 +14,14 @@ const (
 	pumbaLabel     = "com.gaiaadm.pumba"
 	pumbaSkipLabel = "com.gaiaadm.pumba.skip"
 	signalLabel    = "com.gaiaadm.pumba.stop-signal"
+	// docker-compose labels
+	composeProjectLabel   = "com.docker.compose.project"
+	composeServiceLabel   = "com.docker.compose.service"
+	composeDependsOnLabel = "com.docker.compose.depends_on"
+	// Swarm task label
+	swarmServiceIDLabel = "com.docker.swarm.service.id"
+	// defaultCPUPeriod CFS scheduler period, when container CPU quota is set without period (in microseconds)
+	defaultCPUPeriod = 100000
 )
 
 // NewContainer returns a new Container instance instantiated with the
@@ -45,8 +56,11 @@ func (c Container) ImageID() string {
 
 // ImageName returns the name of the Docker image that was used to start the
 // container. If the original image was specified without a particular tag, the
-// "latest" tag is assumed.
+// "latest" tag is assumed. Returns empty string, if container configuration is unknown.
 func (c Container) ImageName() string {
+	if c.containerInfo.Config == nil {
+		return ""
+	}
 	imageName := c.containerInfo.Config.Image
 	if !strings.Contains(imageName, ":") {
 		imageName = fmt.Sprintf("%s:latest", imageName)
@@ -75,7 +89,7 @@ func (c Container) Links() []string {
 // identified by the presence of the "com.gaiaadm.pumba" label in
 // the container metadata.
 func (c Container) IsPumba() bool {
-	val, ok := c.containerInfo.Config.Labels[pumbaLabel]
+	val, ok := c.Label(pumbaLabel)
 	return ok && val == "true"
 }
 
@@ -84,10 +98,183 @@ func (c Container) IsPumba() bool {
 // identified by the presence of the "com.gaiaadm.pumba.skip" label in
 // the container metadata. Use it to skip monitoring and helper containers.
 func (c Container) IsPumbaSkip() bool {
-	val, ok := c.containerInfo.Config.Labels[pumbaSkipLabel]
+	val, ok := c.Label(pumbaSkipLabel)
 	return ok && val == "true"
 }
 
+// Label returns the value of the specified label from the container metadata
+// and a boolean flag indicating whether or not the label is present.
+func (c Container) Label(name string) (string, bool) {
+	if c.containerInfo.Config == nil {
+		return "", false
+	}
+	val, ok := c.containerInfo.Config.Labels[name]
+	return val, ok
+}
+
+// NetworkIP returns container IP address on the specified Docker network
+// (IPv4, or global IPv6 address for IPv6-only networks) and a boolean flag
+// indicating whether or not the container is connected to this network.
+func (c Container) NetworkIP(network string) (net.IP, bool) {
+	endpoint, ok := c.containerInfo.NetworkSettings.Networks[network]
+	if !ok || endpoint == nil {
+		return nil, false
+	}
+	if ip := net.ParseIP(endpoint.IPAddress); ip != nil {
+		return ip, true
+	}
+	if ip := net.ParseIP(endpoint.GlobalIPv6Address); ip != nil {
+		return ip, true
+	}
+	return nil, false
+}
+
+// IPs returns container IP addresses on all Docker networks it is connected to
+// (see NetworkIP), ordered by network name, without duplicates.
+func (c Container) IPs() []net.IP {
+	var ips []net.IP
+	seen := map[string]bool{}
+	add := func(ip net.IP) {
+		if ip != nil && !seen[ip.String()] {
+			seen[ip.String()] = true
+			ips = append(ips, ip)
+		}
+	}
+	add(net.ParseIP(c.containerInfo.NetworkSettings.IPAddress))
+	networks := make([]string, 0, len(c.containerInfo.NetworkSettings.Networks))
+	for network := range c.containerInfo.NetworkSettings.Networks {
+		networks = append(networks, network)
+	}
+	sort.Strings(networks)
+	for _, network := range networks {
+		ip, _ := c.NetworkIP(network)
+		add(ip)
+	}
+	return ips
+}
+
+// ComposeProject returns the docker-compose project name the container belongs to,
+// or the empty string "" if the container was not created by docker-compose.
+func (c Container) ComposeProject() string {
+	val, _ := c.Label(composeProjectLabel)
+	return val
+}
+
+// ComposeService returns the docker-compose service name of the container,
+// or the empty string "" if the container was not created by docker-compose.
+func (c Container) ComposeService() string {
+	val, _ := c.Label(composeServiceLabel)
+	return val
+}
+
+// SwarmService returns ID of the Swarm service the container is a task of,
+// or the empty string "" if the container is not a Swarm task.
+func (c Container) SwarmService() string {
+	val, _ := c.Label(swarmServiceIDLabel)
+	return val
+}
+
+// ComposeDependsOn returns names of docker-compose services this container
+// depends on. The "com.docker.compose.depends_on" label has the following format:
+// "service[:condition[:restart]],..."
+func (c Container) ComposeDependsOn() []string {
+	var services []string
+	val, ok := c.Label(composeDependsOnLabel)
+	if !ok || val == "" {
+		return services
+	}
+	for _, dep := range strings.Split(val, ",") {
+		if name := strings.TrimSpace(strings.Split(dep, ":")[0]); name != "" {
+			services = append(services, name)
+		}
+	}
+	return services
+}
+
+// RestartPolicy returns the container restart policy name: "always",
+// "unless-stopped", "on-failure" or "no" (default).
+func (c Container) RestartPolicy() string {
+	if c.containerInfo.HostConfig == nil || c.containerInfo.HostConfig.RestartPolicy.Name == "" {
+		return "no"
+	}
+	return c.containerInfo.HostConfig.RestartPolicy.Name
+}
+
+// AutoRestarts returns true if the container restart policy makes Docker
+// restart the container once it exits.
+func (c Container) AutoRestarts() bool {
+	switch c.RestartPolicy() {
+	case "always", "unless-stopped", "on-failure":
+		return true
+	}
+	return false
+}
+
+// Recovery returns expected outcome of chaos action on the container: "auto", if Docker restarts the same container
+// (same ID), or "none". Removed container is never restarted, whatever its restart policy is; a container,
+// re-created with the same name (e.g. by docker-compose or Swarm), has another ID and is not its recovery.
+func (c Container) Recovery(action string) string {
+	if action != "rm" && c.AutoRestarts() {
+		return "auto"
+	}
+	return "none"
+}
+
+// IsPaused returns true if the container is paused.
+func (c Container) IsPaused() bool {
+	return c.containerInfo.State != nil && c.containerInfo.State.Paused
+}
+
+// MemoryLimit returns the container memory limit in bytes; 0 - no limit.
+func (c Container) MemoryLimit() int64 {
+	if c.containerInfo.HostConfig == nil {
+		return 0
+	}
+	return c.containerInfo.HostConfig.Memory
+}
+
+// CPULimit returns the container CPU limit in CPUs: CPU quota per period
+// ('--cpu-quota'), or number of CPUs the container can run on ('--cpuset-cpus');
+// 0 - no limit.
+func (c Container) CPULimit() float64 {
+	hostConfig := c.containerInfo.HostConfig
+	if hostConfig == nil {
+		return 0
+	}
+	if hostConfig.CpuQuota > 0 {
+		period := hostConfig.CpuPeriod
+		if period <= 0 {
+			period = defaultCPUPeriod
+		}
+		return float64(hostConfig.CpuQuota) / float64(period)
+	}
+	return float64(cpusetSize(hostConfig.CpusetCpus))
+}
+
+// cpusetSize returns number of CPUs in cpuset list, e.g. '0-3,6'; 0 - empty or
+// invalid list.
+func cpusetSize(cpuset string) int {
+	size := 0
+	for _, item := range strings.Split(cpuset, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		bounds := strings.SplitN(item, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return 0
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, err = strconv.Atoi(bounds[1]); err != nil || last < first {
+				return 0
+			}
+		}
+		size += last - first + 1
+	}
+	return size
+}
+
 // StopSignal returns the custom stop signal (if any) that is encoded in the
 // container's metadata. If the container has not specified a custom stop
 // signal, the empty string "" is returned.
@@ -1,6 +1,7 @@
 package container
 
 import (
+	"net"
 	"testing"
 
 	"github.com/samalba/dockerclient"
@@ -144,3 +145,130 @@ func TestStopSignal_NoLabel(t *testing.T) {
 
 	assert.Equal(t, "", c.StopSignal())
 }
+
+func TestLabel_Present(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Config: &dockerclient.ContainerConfig{
+				Labels: map[string]string{"zone": "us-east-1a"},
+			},
+		},
+	}
+
+	val, ok := c.Label("zone")
+	assert.True(t, ok)
+	assert.Equal(t, "us-east-1a", val)
+}
+
+func TestLabel_NoConfig(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{},
+	}
+
+	_, ok := c.Label("zone")
+	assert.False(t, ok)
+}
+
+func TestComposeLabels(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Config: &dockerclient.ContainerConfig{
+				Labels: map[string]string{
+					"com.docker.compose.project":    "shop",
+					"com.docker.compose.service":    "api",
+					"com.docker.compose.depends_on": "db:service_healthy:false, cache:service_started:false",
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, "shop", c.ComposeProject())
+	assert.Equal(t, "api", c.ComposeService())
+	assert.Equal(t, []string{"db", "cache"}, c.ComposeDependsOn())
+}
+
+func TestRestartPolicy(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			HostConfig: &dockerclient.HostConfig{
+				RestartPolicy: dockerclient.RestartPolicy{Name: "unless-stopped"},
+			},
+		},
+	}
+
+	assert.Equal(t, "unless-stopped", c.RestartPolicy())
+	assert.True(t, c.AutoRestarts())
+	assert.Equal(t, "auto", c.Recovery("kill"))
+	// removed container is not restarted
+	assert.Equal(t, "none", c.Recovery("rm"))
+}
+
+func TestIsPaused(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			State: &dockerclient.State{Running: true, Paused: true},
+		},
+	}
+
+	assert.True(t, c.IsPaused())
+	assert.False(t, Container{containerInfo: &dockerclient.ContainerInfo{}}.IsPaused())
+}
+
+func TestRestartPolicy_Default(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{},
+	}
+
+	assert.Equal(t, "no", c.RestartPolicy())
+	assert.False(t, c.AutoRestarts())
+	assert.Equal(t, "none", c.Recovery("kill"))
+}
+
+func TestResourceLimits(t *testing.T) {
+	for _, tt := range []struct {
+		hostConfig *dockerclient.HostConfig
+		memory     int64
+		cpus       float64
+	}{
+		{nil, 0, 0},
+		{&dockerclient.HostConfig{}, 0, 0},
+		{&dockerclient.HostConfig{Memory: 512 << 20, CpuQuota: 150000}, 512 << 20, 1.5},
+		{&dockerclient.HostConfig{CpuQuota: 25000, CpuPeriod: 50000, CpusetCpus: "0-3"}, 0, 0.5},
+		{&dockerclient.HostConfig{CpusetCpus: "0-3,6"}, 0, 5},
+		{&dockerclient.HostConfig{CpusetCpus: "3-1"}, 0, 0},
+	} {
+		c := Container{
+			containerInfo: &dockerclient.ContainerInfo{HostConfig: tt.hostConfig},
+		}
+
+		assert.Equal(t, tt.memory, c.MemoryLimit())
+		assert.Equal(t, tt.cpus, c.CPULimit())
+	}
+}
+
+func TestComposeLabels_NoLabels(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Config: &dockerclient.ContainerConfig{},
+		},
+	}
+
+	assert.Equal(t, "", c.ComposeProject())
+	assert.Equal(t, "", c.ComposeService())
+	assert.Empty(t, c.ComposeDependsOn())
+}
+
+func TestIPs(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{},
+	}
+	c.containerInfo.NetworkSettings.IPAddress = "172.17.0.2"
+	c.containerInfo.NetworkSettings.Networks = map[string]*dockerclient.EndpointSettings{
+		"bridge":  {IPAddress: "172.17.0.2"},
+		"overlay": {IPAddress: "10.0.0.5"},
+		"v6only":  {GlobalIPv6Address: "2001:db8::5"},
+		"none":    {},
+	}
+
+	assert.Equal(t, []net.IP{net.ParseIP("172.17.0.2"), net.ParseIP("10.0.0.5"), net.ParseIP("2001:db8::5")}, c.IPs())
+}
@@ -0,0 +1,197 @@
+package container
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultHost default Docker daemon socket
+	DefaultHost = "unix:///var/run/docker.sock"
+	// DefaultContext name of Docker CLI context, that uses DOCKER_HOST and TLS options
+	DefaultContext = "default"
+	// default TCP ports of Docker daemon, like Docker CLI
+	defaultHTTPPort = "2375"
+	defaultTLSPort  = "2376"
+	defaultTCPHost  = "127.0.0.1"
+)
+
+// ParseHost normalizes Docker host like Docker CLI does: empty host is default socket, address without
+// scheme is TCP address and missing TCP host and port are replaced with 127.0.0.1 and 2375 (2376 with TLS)
+func ParseHost(host string, tls bool) (string, error) {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return DefaultHost, nil
+	}
+	parts := strings.SplitN(host, "://", 2)
+	if len(parts) == 1 {
+		parts = []string{"tcp", parts[0]}
+	}
+	switch parts[0] {
+	case "unix":
+		if parts[1] == "" {
+			return DefaultHost, nil
+		}
+		return host, nil
+	case "tcp":
+		addr, path := parts[1], ""
+		if i := strings.Index(addr, "/"); i >= 0 {
+			addr, path = addr[:i], addr[i:]
+		}
+		port := defaultHTTPPort
+		if tls {
+			port = defaultTLSPort
+		}
+		h, p, err := net.SplitHostPort(addr)
+		if err != nil {
+			// no port
+			h, p = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]"), port
+		}
+		if h == "" {
+			h = defaultTCPHost
+		}
+		if p == "" {
+			p = port
+		}
+		return "tcp://" + net.JoinHostPort(h, p) + path, nil
+	}
+	return "", fmt.Errorf("Unsupported Docker host '%s': must be 'unix://' or 'tcp://' address", host)
+}
+
+// ConfigDir returns Docker CLI configuration directory: $DOCKER_CONFIG or ~/.docker
+func ConfigDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home := os.Getenv("HOME")
+	if home == "" {
+		home = os.Getenv("USERPROFILE")
+	}
+	return filepath.Join(home, ".docker")
+}
+
+// DockerContext Docker endpoint of Docker CLI context
+type DockerContext struct {
+	Name          string
+	Host          string
+	SkipTLSVerify bool
+	// TLSDir directory with TLS files of context (ca.pem, cert.pem and key.pem); empty if context has no TLS files
+	TLSDir string
+}
+
+// CurrentContext returns name of current Docker CLI context, from 'currentContext' of config.json;
+// default context, if not set
+func CurrentContext(configDir string) (string, error) {
+	data, err := ioutil.ReadFile(filepath.Join(configDir, "config.json"))
+	if os.IsNotExist(err) {
+		return DefaultContext, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	var config struct {
+		CurrentContext string `json:"currentContext"`
+	}
+	if err = json.Unmarshal(data, &config); err != nil {
+		return "", fmt.Errorf("Failed to parse Docker CLI config: %s", err)
+	}
+	if config.CurrentContext == "" {
+		return DefaultContext, nil
+	}
+	return config.CurrentContext, nil
+}
+
+// contextID returns directory name of Docker CLI context in context store: SHA-256 digest of context name
+func contextID(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadContext reads Docker endpoint of Docker CLI context from context store of configuration directory
+func LoadContext(configDir, name string) (*DockerContext, error) {
+	id := contextID(name)
+	data, err := ioutil.ReadFile(filepath.Join(configDir, "contexts", "meta", id, "meta.json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("Docker context '%s' not found", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var meta struct {
+		Name      string `json:"Name"`
+		Endpoints map[string]struct {
+			Host          string `json:"Host"`
+			SkipTLSVerify bool   `json:"SkipTLSVerify"`
+		} `json:"Endpoints"`
+	}
+	if err = json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("Failed to parse Docker context '%s': %s", name, err)
+	}
+	endpoint, ok := meta.Endpoints["docker"]
+	if !ok {
+		return nil, fmt.Errorf("Docker context '%s' has no Docker endpoint", name)
+	}
+	ctx := &DockerContext{Name: name, Host: endpoint.Host, SkipTLSVerify: endpoint.SkipTLSVerify}
+	tlsDir := filepath.Join(configDir, "contexts", "tls", id, "docker")
+	if info, err := os.Stat(tlsDir); err == nil && info.IsDir() {
+		ctx.TLSDir = tlsDir
+	}
+	return ctx, nil
+}
+
+// TLSConfig returns TLS configuration of Docker context, like Docker CLI: context without TLS files uses TLS
+// only when remote is not verified (SkipTLSVerify); nil - no TLS
+func (ctx *DockerContext) TLSConfig() (*tls.Config, error) {
+	if ctx.TLSDir == "" {
+		if ctx.SkipTLSVerify {
+			return &tls.Config{InsecureSkipVerify: true}, nil
+		}
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: ctx.SkipTLSVerify}
+	caCert, err := ctx.readTLSFile("ca.pem")
+	if err != nil {
+		return nil, err
+	}
+	if caCert != nil {
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("Invalid TLS CA certificate of Docker context '%s'", ctx.Name)
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+	cert, err := ctx.readTLSFile("cert.pem")
+	if err != nil {
+		return nil, err
+	}
+	key, err := ctx.readTLSFile("key.pem")
+	if err != nil {
+		return nil, err
+	}
+	if cert != nil && key != nil {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid TLS client certificate of Docker context '%s': %s", ctx.Name, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+	return tlsConfig, nil
+}
+
+// readTLSFile reads TLS file of Docker context; nil, if file does not exist
+func (ctx *DockerContext) readTLSFile(name string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(ctx.TLSDir, name))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
@@ -0,0 +1,100 @@
+package container
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHost(t *testing.T) {
+	tests := []struct {
+		host     string
+		tls      bool
+		expected string
+	}{
+		{"", false, DefaultHost},
+		{"unix://", false, DefaultHost},
+		{"unix:///tmp/docker.sock", false, "unix:///tmp/docker.sock"},
+		{"tcp://10.0.0.1", false, "tcp://10.0.0.1:2375"},
+		{"tcp://10.0.0.1", true, "tcp://10.0.0.1:2376"},
+		{"tcp://10.0.0.1:4243", true, "tcp://10.0.0.1:4243"},
+		{"tcp://:2376", false, "tcp://127.0.0.1:2376"},
+		{"tcp://", false, "tcp://127.0.0.1:2375"},
+		{"docker.local", false, "tcp://docker.local:2375"},
+		{"tcp://docker.local:/path", true, "tcp://docker.local:2376/path"},
+		{"tcp://[::1]", false, "tcp://[::1]:2375"},
+	}
+	for _, test := range tests {
+		host, err := ParseHost(test.host, test.tls)
+		assert.NoError(t, err, test.host)
+		assert.Equal(t, test.expected, host, test.host)
+	}
+	_, err := ParseHost("ssh://user@host", false)
+	assert.EqualError(t, err, "Unsupported Docker host 'ssh://user@host': must be 'unix://' or 'tcp://' address")
+}
+
+// writeContext writes Docker CLI context metadata into context store of configuration directory
+func writeContext(t *testing.T, configDir, name, meta string) string {
+	id := contextID(name)
+	dir := filepath.Join(configDir, "contexts", "meta", id)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "meta.json"), []byte(meta), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestCurrentContext(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pumba-docker")
+	defer os.RemoveAll(dir)
+	name, err := CurrentContext(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultContext, name)
+	ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"auths":{},"currentContext":"remote"}`), 0600)
+	name, err = CurrentContext(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, "remote", name)
+}
+
+func TestLoadContext(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pumba-docker")
+	defer os.RemoveAll(dir)
+	writeContext(t, dir, "remote", `{"Name":"remote","Metadata":{},"Endpoints":{"docker":{"Host":"tcp://remote:2376","SkipTLSVerify":true}}}`)
+	ctx, err := LoadContext(dir, "remote")
+	assert.NoError(t, err)
+	assert.Equal(t, &DockerContext{Name: "remote", Host: "tcp://remote:2376", SkipTLSVerify: true}, ctx)
+	// no TLS files, remote is not verified
+	tls, err := ctx.TLSConfig()
+	assert.NoError(t, err)
+	if assert.NotNil(t, tls) {
+		assert.True(t, tls.InsecureSkipVerify)
+	}
+	_, err = LoadContext(dir, "missing")
+	assert.EqualError(t, err, "Docker context 'missing' not found")
+	writeContext(t, dir, "k8s", `{"Name":"k8s","Endpoints":{"kubernetes":{}}}`)
+	_, err = LoadContext(dir, "k8s")
+	assert.EqualError(t, err, "Docker context 'k8s' has no Docker endpoint")
+}
+
+func TestLoadContext_TLS(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pumba-docker")
+	defer os.RemoveAll(dir)
+	id := writeContext(t, dir, "secure", `{"Name":"secure","Endpoints":{"docker":{"Host":"tcp://secure"}}}`)
+	tlsDir := filepath.Join(dir, "contexts", "tls", id, "docker")
+	os.MkdirAll(tlsDir, 0700)
+	ioutil.WriteFile(filepath.Join(tlsDir, "ca.pem"), []byte("not a certificate"), 0600)
+	ctx, err := LoadContext(dir, "secure")
+	assert.NoError(t, err)
+	assert.Equal(t, tlsDir, ctx.TLSDir)
+	_, err = ctx.TLSConfig()
+	assert.EqualError(t, err, "Invalid TLS CA certificate of Docker context 'secure'")
+	// context without TLS files and verified remote does not use TLS
+	tls, err := (&DockerContext{Name: "plain"}).TLSConfig()
+	assert.NoError(t, err)
+	assert.Nil(t, tls)
+}
@@ -0,0 +1,324 @@
+package container
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/iptables"
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/gaia-adm/pumba/teardown"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// dryRunClient logs chaos actions with 'dry_run' field instead of running them, so dry run output can be filtered;
+// action parameters are validated as in real run; listing and inspecting containers is delegated to wrapped client.
+// Wrapped client is not embedded: every Client method is implemented explicitly, so new method does not compile
+// until its dry run is defined
+type dryRunClient struct {
+	wrapped Client
+}
+
+// NewDryRunClient wraps container client for dry runs (--dry); dry run client is returned as is
+func NewDryRunClient(client Client) Client {
+	if client.DryRun() {
+		return client
+	}
+	return dryRunClient{wrapped: client}
+}
+
+// dryLog returns logger of dry run
+func dryLog() *log.Entry {
+	return log.WithField("dry_run", true)
+}
+
+// dryExecStep returns executor of teardown pipeline steps inside container, that only logs commands
+func dryExecStep(c Container) teardown.Exec {
+	return func(cmd []string) error {
+		dryLog().Debugf("Exec command '%s' on container %s", strings.Join(cmd, " "), c.ID())
+		return nil
+	}
+}
+
+// dryHostStep returns executor of teardown pipeline steps on Docker host, that only logs commands
+func dryHostStep() teardown.Exec {
+	return func(cmd []string) error {
+		dryLog().Debugf("Run command '%s' on host", strings.Join(cmd, " "))
+		return nil
+	}
+}
+
+// DryRun reports, whether client only logs chaos actions
+func (client dryRunClient) DryRun() bool {
+	return true
+}
+
+// ListContainers lists containers with wrapped client
+func (client dryRunClient) ListContainers(fn Filter) ([]Container, error) {
+	return client.wrapped.ListContainers(fn)
+}
+
+// NetworkInterface resolves network interface of container with wrapped client
+func (client dryRunClient) NetworkInterface(c Container, network string) (string, error) {
+	return client.wrapped.NetworkInterface(c, network)
+}
+
+// NetworkInterfaces lists network interfaces of container with wrapped client
+func (client dryRunClient) NetworkInterfaces(c Container) ([]string, error) {
+	return client.wrapped.NetworkInterfaces(c)
+}
+
+// NetemLeftovers lists netem qdiscs of container with wrapped client
+func (client dryRunClient) NetemLeftovers(c Container, netInterface string) ([]string, error) {
+	return client.wrapped.NetemLeftovers(c, netInterface)
+}
+
+// ResolveAlias resolves host name with container DNS resolver of wrapped client
+func (client dryRunClient) ResolveAlias(c Container, alias string) ([]net.IP, error) {
+	return client.wrapped.ResolveAlias(c, alias)
+}
+
+// ListImages lists images with wrapped client
+func (client dryRunClient) ListImages(label string) ([]Image, error) {
+	return client.wrapped.ListImages(label)
+}
+
+// ExitWatch watches container exit with wrapped client
+func (client dryRunClient) ExitWatch(c Container, timeout time.Duration) (<-chan time.Time, error) {
+	return client.wrapped.ExitWatch(c, timeout)
+}
+
+func (client dryRunClient) StartContainer(c Container) error {
+	dryLog().Infof("Starting %s (%s)", c.Name(), c.ID())
+	return nil
+}
+
+func (client dryRunClient) RenameContainer(c Container, newName string) error {
+	dryLog().Infof("Renaming %s (%s) to %s", c.Name(), c.ID(), newName)
+	return nil
+}
+
+// ExecContainer logs command and reports successful exit code
+func (client dryRunClient) ExecContainer(c Container, cmd []string, timeout time.Duration) (int, error) {
+	dryLog().Infof("Exec command '%s' on container %s", strings.Join(cmd, " "), c.ID())
+	return 0, nil
+}
+
+func (client dryRunClient) BeaconContainer(c Container, labels map[string]string) error {
+	dryLog().Infof("Creating beacon container for container %s with labels %v", c.ID(), labels)
+	return nil
+}
+
+// CommitContainer logs commit and returns empty image ID
+func (client dryRunClient) CommitContainer(c Container, reference string, labels map[string]string) (string, error) {
+	dryLog().Infof("Committing container %s as %s", c.ID(), reference)
+	return "", nil
+}
+
+func (client dryRunClient) DeleteImage(image string, force bool) error {
+	dryLog().Infof("Removing image %s", image)
+	return nil
+}
+
+func (client dryRunClient) KillContainer(c Container, signal string) error {
+	dryLog().Infof("Killing %s (%s) with signal %s", c.Name(), c.ID(), signal)
+	return nil
+}
+
+func (client dryRunClient) StopContainer(c Container, timeout int) error {
+	signal := c.StopSignal()
+	if signal == "" {
+		signal = defaultStopSignal
+	}
+	dryLog().Infof("Stopping %s (%s) with %s", c.Name(), c.ID(), signal)
+	return nil
+}
+
+func (client dryRunClient) BlackoutContainer(c Container, timeout int, duration time.Duration) error {
+	dryLog().Infof("Stopping %s (%s) for %s", c.Name(), c.ID(), duration)
+	return nil
+}
+
+func (client dryRunClient) RemoveImage(c Container, force bool) error {
+	dryLog().Infof("Removing image %s", c.ImageID())
+	return nil
+}
+
+func (client dryRunClient) RemoveContainer(c Container, force bool, links bool, volumes bool) error {
+	dryLog().Infof("Removing container %s", c.ID())
+	return nil
+}
+
+func (client dryRunClient) NetemContainer(c Container, netInterface string, netemCmd string, filter netem.Filter, duration time.Duration, reapply bool, probe string) error {
+	if len(filter.Hosts) > 0 {
+		var err error
+		if filter, err = resolveHosts(net.LookupIP, filter, filter.IPs); err != nil {
+			return err
+		}
+	}
+	if filter.IsEmpty() {
+		dryLog().Infof("Running netem command '%s' on container %s for %s", netemCmd, c.ID(), duration)
+	} else {
+		dryLog().Infof("Running netem command '%s' on container %s with filter %s for %s", netemCmd, c.ID(), filter, duration)
+	}
+	impairment := strings.Fields(strings.ToLower(netemCmd))
+	ifaces, err := client.netemInterfaces(c, netInterface)
+	if err != nil {
+		return err
+	}
+	for _, iface := range ifaces {
+		if _, err = netem.Pipeline(iface, impairment, filter).Apply(dryExecStep(c)); err != nil {
+			return err
+		}
+	}
+	return client.StopNetemContainer(c, netInterface, filter)
+}
+
+func (client dryRunClient) StopNetemContainer(c Container, netInterface string, filter netem.Filter) error {
+	dryLog().Infof("Stopping netem on container %s", c.ID())
+	ifaces, err := client.netemInterfaces(c, netInterface)
+	if err != nil {
+		return err
+	}
+	for _, iface := range ifaces {
+		if err = netem.Pipeline(iface, nil, filter).Stack().Unwind(dryExecStep(c)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (client dryRunClient) ClearNetemContainer(c Container, netInterface string) error {
+	ifaces, err := client.netemInterfaces(c, netInterface)
+	if err != nil {
+		return err
+	}
+	for _, iface := range ifaces {
+		dryLog().Infof("Removing netem from container %s on '%s'", c.ID(), iface)
+		dryExecStep(c)(netem.ClearCommand(iface))
+	}
+	return nil
+}
+
+// netemInterfaces returns network interfaces of container to apply netem on; all interfaces (AllInterfaces) are
+// listed by wrapped client
+func (client dryRunClient) netemInterfaces(c Container, netInterface string) ([]string, error) {
+	if netInterface != AllInterfaces {
+		return []string{netInterface}, nil
+	}
+	return client.wrapped.NetworkInterfaces(c)
+}
+
+func (client dryRunClient) PauseContainer(c Container, duration time.Duration) error {
+	dryLog().Infof("Pausing container %s for %s", c.ID(), duration)
+	return nil
+}
+
+func (client dryRunClient) UnpauseContainer(c Container) error {
+	dryLog().Infof("Unpausing container %s", c.ID())
+	return nil
+}
+
+func (client dryRunClient) DropPortsContainer(c Container, loss int, duration time.Duration) error {
+	ports, err := publishedPorts(c)
+	if err != nil {
+		return err
+	}
+	if len(ports) == 0 {
+		return fmt.Errorf("Container %s has no published ports", c.ID())
+	}
+	dryLog().Infof("Dropping %d%% of packets to published ports of container %s for %s", loss, c.ID(), duration)
+	if _, err = iptables.Pipeline(ports, loss).Apply(dryHostStep()); err != nil {
+		return err
+	}
+	return client.RestorePortsContainer(c, loss)
+}
+
+func (client dryRunClient) RestorePortsContainer(c Container, loss int) error {
+	ports, err := publishedPorts(c)
+	if err != nil {
+		return err
+	}
+	dryLog().Infof("Restoring published ports of container %s", c.ID())
+	return iptables.Pipeline(ports, loss).Stack().Unwind(dryHostStep())
+}
+
+func (client dryRunClient) SidecarContainer(c Container, image string, cmd []string, duration time.Duration) error {
+	dryLog().Infof("Running sidecar '%s' for container %s for %s", image, c.ID(), duration)
+	return nil
+}
+
+func (client dryRunClient) OOMContainer(c Container, timeout time.Duration) error {
+	limit, err := memoryLimit(c)
+	if err != nil {
+		return err
+	}
+	dryLog().Infof("Triggering OOM killer in container %s with memory limit %d bytes, timeout %s", c.ID(), limit, timeout)
+	return nil
+}
+
+func (client dryRunClient) ExhaustFDsContainer(c Container, free int, duration time.Duration) error {
+	dryLog().Infof("Exhausting file descriptors of container %s processes (%d free) for %s", c.ID(), free, duration)
+	return nil
+}
+
+func (client dryRunClient) BurnCPUContainer(c Container, workers int, duration time.Duration) error {
+	dryLog().Infof("Burning CPU of container %s with %s for %s", c.ID(), burnWorkers(workers), duration)
+	return nil
+}
+
+func (client dryRunClient) PoisonImageContainer(c Container, image string, duration time.Duration) error {
+	reference := c.ImageName()
+	if _, _, err := imageReference(reference); err != nil {
+		return fmt.Errorf("Can not poison image of container %s: %s", c.ID(), err)
+	}
+	dryLog().Infof("Poisoning image %s of container %s with %s for %s", reference, c.ID(), image, duration)
+	return nil
+}
+
+func (client dryRunClient) HealthcheckContainer(c Container, probe health.Probe, duration time.Duration) error {
+	dryLog().Infof("Disrupting healthcheck of container %s with %s for %s", c.ID(), probe, duration)
+	if _, err := health.Pipeline(probe).Apply(dryExecStep(c)); err != nil {
+		return err
+	}
+	return health.Pipeline(probe).Stack().Unwind(dryExecStep(c))
+}
+
+func (client dryRunClient) MemoryPressureContainer(c Container, percent int, duration time.Duration) error {
+	if _, err := containerPid(c); err != nil {
+		return err
+	}
+	dryLog().Infof("Setting memory.high of container %s to %d%% of its memory usage for %s", c.ID(), percent, duration)
+	return nil
+}
+
+func (client dryRunClient) CorruptEnvContainer(c Container, set []string, unset []string, timeout int, duration time.Duration) error {
+	dryLog().Infof("Re-creating %s (%s) with corrupted environment (set: %s; unset: %s) for %s", c.Name(), c.ID(), strings.Join(set, " "), strings.Join(unset, " "), duration)
+	return nil
+}
+
+func (client dryRunClient) RemoveSecretsContainer(c Container, secrets []string, configs []string, duration time.Duration) error {
+	service := c.SwarmService()
+	if service == "" {
+		return fmt.Errorf("Container %s is not a task of Swarm service", c.Name())
+	}
+	dryLog().Infof("Removing secrets %v and configs %v from Swarm service %s of container %s for %s", secrets, configs, service, c.Name(), duration)
+	return nil
+}
+
+func (client dryRunClient) InjectTools(c Container, dir string) error {
+	_, tools, err := toolsArchive(dir)
+	if err != nil {
+		return err
+	}
+	dryLog().Infof("Injecting tools (%s) from '%s' into %s of container %s", strings.Join(sortedToolNames(tools), ", "), dir, toolsDir, c.ID())
+	return nil
+}
+
+func (client dryRunClient) RemoveTools(c Container) error {
+	dryLog().Infof("Removing injected tools from container %s", c.ID())
+	return nil
+}
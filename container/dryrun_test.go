@@ -0,0 +1,127 @@
+package container
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewDryRunClient(t *testing.T) {
+	client := dockerClient{}
+	assert.False(t, client.DryRun())
+	dry := NewDryRunClient(client)
+	assert.True(t, dry.DryRun())
+	// dry run client is not wrapped again
+	assert.Equal(t, dry, NewDryRunClient(dry))
+}
+
+func TestDryRunClient_DropPorts(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+	c.containerInfo.NetworkSettings.IPAddress = "172.17.0.2"
+	var cmds [][]string
+	hostExec := func(cmd []string) ([]byte, error) {
+		cmds = append(cmds, cmd)
+		return nil, nil
+	}
+	client := NewDryRunClient(dockerClient{hostExec: hostExec})
+
+	// parameters are validated as in real run
+	err := client.DropPortsContainer(c, 100, time.Hour)
+	assert.EqualError(t, err, "Container abc123 has no published ports")
+
+	c.containerInfo.NetworkSettings.Ports = map[string][]dockerclient.PortBinding{
+		"80/tcp": {{HostIp: "0.0.0.0", HostPort: "8080"}},
+	}
+	err = client.DropPortsContainer(c, 100, time.Hour)
+	assert.NoError(t, err)
+	assert.Empty(t, cmds)
+}
+
+func TestDryRunClient_ObserveAPI(t *testing.T) {
+	var calls []recordedCall
+	client := ObserveAPI(NewDryRunClient(dockerClient{}), recordCalls(&calls))
+	if dry, ok := client.(dryRunClient); assert.True(t, ok) {
+		assert.NotNil(t, dry.wrapped.(dockerClient).observer)
+	}
+}
+
+func TestDryRunClient_NetemAllInterfaces(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	mock := NewMockSamalbaClient()
+	mock.On("NetworkInterfaces", c).Return([]string{"eth0", "eth1"}, nil).Twice()
+	client := NewDryRunClient(mock)
+
+	// interfaces are listed by wrapped client, netem is only logged
+	err := client.NetemContainer(c, AllInterfaces, "delay 100ms", netem.Filter{}, time.Hour, false, "")
+
+	assert.NoError(t, err)
+	mock.AssertExpectations(t)
+	mock.AssertNotCalled(t, "NetemContainer", c, AllInterfaces, "delay 100ms", netem.Filter{}, time.Hour)
+}
+
+func TestDryRunClient_NoPassThrough(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id:         "abc123",
+			Name:       "/c",
+			Config:     &dockerclient.ContainerConfig{Image: "app:1", Labels: map[string]string{}},
+			HostConfig: &dockerclient.HostConfig{},
+			State:      &dockerclient.State{Pid: 42},
+		},
+		imageInfo: &dockerclient.ImageInfo{Id: "sha256:app"},
+	}
+	// only listing and inspecting methods are delegated to wrapped client
+	delegated := map[string]bool{
+		"ListContainers":    true,
+		"NetworkInterface":  true,
+		"NetworkInterfaces": true,
+		"NetemLeftovers":    true,
+		"ResolveAlias":      true,
+		"ListImages":        true,
+		"ExitWatch":         true,
+	}
+	wrapped := NewMockSamalbaClient()
+	wrapped.On("ListContainers", mock.Anything).Return([]Container{}, nil)
+	wrapped.On("NetworkInterface", c, "").Return("eth0", nil)
+	wrapped.On("NetworkInterfaces", c).Return([]string{"eth0"}, nil)
+	wrapped.On("NetemLeftovers", c, "").Return([]string{}, nil)
+	wrapped.On("ResolveAlias", c, "").Return([]net.IP{}, nil)
+	wrapped.On("ListImages", "").Return([]Image{}, nil)
+	wrapped.On("ExitWatch", c, time.Duration(0)).Return((<-chan time.Time)(nil), nil)
+	client := reflect.ValueOf(NewDryRunClient(wrapped))
+
+	iface := reflect.TypeOf((*Client)(nil)).Elem()
+	for i := 0; i < iface.NumMethod(); i++ {
+		method := iface.Method(i)
+		args := make([]reflect.Value, method.Type.NumIn())
+		for j := range args {
+			if method.Type.In(j) == reflect.TypeOf(c) {
+				args[j] = reflect.ValueOf(c)
+			} else {
+				args[j] = reflect.Zero(method.Type.In(j))
+			}
+		}
+		// unexpected call of wrapped mock panics
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("%s is passed through to wrapped client in dry run: %v", method.Name, r)
+				}
+			}()
+			client.MethodByName(method.Name).Call(args)
+		}()
+	}
+	for _, call := range wrapped.Calls {
+		assert.True(t, delegated[call.Method], "%s is passed through to wrapped client in dry run", call.Method)
+	}
+}
@@ -0,0 +1,98 @@
+package container
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// envOriginalSuffix name suffix of original container, kept stopped while its copy runs with corrupted environment
+const envOriginalSuffix = "_pumba_env"
+
+// envName returns name of environment variable ('NAME=value' or 'NAME')
+func envName(v string) string {
+	if i := strings.Index(v, "="); i >= 0 {
+		return v[:i]
+	}
+	return v
+}
+
+// corruptEnv returns runtime environment of container with variables overridden (set, 'NAME=value') and removed
+// (unset, 'NAME'); variables, defined in image, can not be removed from container environment and are set empty
+func corruptEnv(env, imageEnv, set, unset []string) []string {
+	drop := map[string]bool{}
+	for _, v := range set {
+		drop[envName(v)] = true
+	}
+	for _, name := range unset {
+		drop[name] = true
+	}
+	corrupted := []string{}
+	for _, v := range env {
+		if !drop[envName(v)] {
+			corrupted = append(corrupted, v)
+		}
+	}
+	corrupted = append(corrupted, set...)
+	for _, name := range unset {
+		for _, v := range imageEnv {
+			if envName(v) == name {
+				corrupted = append(corrupted, name+"=")
+				break
+			}
+		}
+	}
+	return corrupted
+}
+
+// CorruptEnvContainer re-creates container with environment variables overridden (set, 'NAME=value') and removed
+// (unset, 'NAME') for specified duration: original container is stopped (waiting timeout seconds before killing
+// it) and renamed, its copy with corrupted environment runs under original name; afterwards the copy is removed
+// with its anonymous volumes and original container is renamed back and started again
+func (client dockerClient) CorruptEnvContainer(c Container, set []string, unset []string, timeout int, duration time.Duration) (err error) {
+	client = client.timed("env")
+	defer wrapError("env", c, &err)
+	name := strings.TrimPrefix(c.Name(), "/")
+	if c.containerInfo.Config == nil {
+		return fmt.Errorf("Unknown config of container %s", c.ID())
+	}
+	// without image config, copy gets full container config, including variables defined in image
+	config := *c.containerInfo.Config
+	var imageEnv []string
+	if c.imageInfo != nil && c.imageInfo.Config != nil {
+		config = *c.runtimeConfig()
+		imageEnv = c.imageInfo.Config.Env
+	}
+	config.Env = corruptEnv(config.Env, imageEnv, set, unset)
+	hostConfig := c.hostConfig()
+	log.Infof("Re-creating %s (%s) with corrupted environment for %s", name, c.ID(), duration)
+	if err = client.api.StopContainer(c.ID(), timeout); err != nil {
+		return err
+	}
+	original := name + envOriginalSuffix
+	if err = client.api.RenameContainer(c.ID(), original); err != nil {
+		return err
+	}
+	copyID, err := client.api.CreateContainer(&config, name, nil)
+	if err == nil {
+		log.Debugf("Starting container %s (%s) with corrupted environment", name, copyID)
+		if err = client.api.StartContainer(copyID, hostConfig); err == nil {
+			log.Debugf("Container %s runs with corrupted environment for %s", name, duration)
+			client.getClock().Sleep(duration)
+		}
+		if rmErr := client.api.RemoveContainer(copyID, true, true); rmErr != nil && err == nil {
+			err = fmt.Errorf("Failed to remove container %s (%s) with corrupted environment: %s", name, copyID, rmErr)
+		}
+	}
+	// restore original container even if its copy failed
+	log.Infof("Restoring original container %s (%s)", name, c.ID())
+	if renameErr := client.api.RenameContainer(c.ID(), name); renameErr != nil {
+		return fmt.Errorf("Failed to restore name of container %s (%s): %s", original, c.ID(), renameErr)
+	}
+	if startErr := client.api.StartContainer(c.ID(), nil); startErr != nil {
+		return fmt.Errorf("Failed to start original container %s (%s): %s", name, c.ID(), startErr)
+	}
+	return err
+}
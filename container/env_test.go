@@ -0,0 +1,95 @@
+package container
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/clock"
+	"github.com/samalba/dockerclient"
+	"github.com/samalba/dockerclient/mockclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCorruptEnv(t *testing.T) {
+	env := []string{"PATH=/bin", "DB_HOST=db", "DB_PASSWORD=secret", "MODE=prod"}
+	imageEnv := []string{"PATH=/bin", "MODE=dev"}
+	corrupted := corruptEnv(env, imageEnv, []string{"DB_HOST=nowhere", "DEBUG=1"}, []string{"DB_PASSWORD", "MODE"})
+	assert.Equal(t, []string{"PATH=/bin", "DB_HOST=nowhere", "DEBUG=1", "MODE="}, corrupted)
+}
+
+func envTestContainer() Container {
+	return Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id:         "abc123",
+			Name:       "/db",
+			Config:     &dockerclient.ContainerConfig{Image: "postgres", Env: []string{"PGDATA=/data", "PGPASSWORD=secret"}},
+			HostConfig: &dockerclient.HostConfig{},
+		},
+		imageInfo: &dockerclient.ImageInfo{
+			Config: &dockerclient.ContainerConfig{Env: []string{"PGDATA=/data"}},
+		},
+	}
+}
+
+func TestCorruptEnvContainer(t *testing.T) {
+	c := envTestContainer()
+	api := mockclient.NewMockClient()
+	api.On("StopContainer", "abc123", 10).Return(nil)
+	api.On("RenameContainer", "abc123", "db_pumba_env").Return(nil)
+	api.On("CreateContainer", mock.MatchedBy(func(config *dockerclient.ContainerConfig) bool {
+		return assert.ObjectsAreEqual([]string{"PGPASSWORD=wrong"}, config.Env)
+	}), "db", (*dockerclient.AuthConfig)(nil)).Return("def789", nil)
+	api.On("StartContainer", "def789", mock.AnythingOfType("*dockerclient.HostConfig")).Return(nil)
+	api.On("RemoveContainer", "def789", true, true).Return(nil)
+	api.On("RenameContainer", "abc123", "db").Return(nil)
+	api.On("StartContainer", "abc123", (*dockerclient.HostConfig)(nil)).Return(nil)
+	fake := clock.NewFake(time.Now())
+	client := dockerClient{api: api, clock: fake}
+
+	done := make(chan error)
+	go func() { done <- client.CorruptEnvContainer(c, []string{"PGPASSWORD=wrong"}, nil, 10, time.Minute) }()
+	fake.BlockUntil(1)
+	api.AssertNotCalled(t, "RemoveContainer", "def789", true, true)
+	fake.Advance(time.Minute)
+	assert.NoError(t, <-done)
+	api.AssertExpectations(t)
+}
+
+func TestCorruptEnvContainer_NoImageInfo(t *testing.T) {
+	c := envTestContainer()
+	c.imageInfo = nil
+	api := mockclient.NewMockClient()
+	api.On("StopContainer", "abc123", 10).Return(nil)
+	api.On("RenameContainer", "abc123", "db_pumba_env").Return(nil)
+	// container config env is used as is
+	api.On("CreateContainer", mock.MatchedBy(func(config *dockerclient.ContainerConfig) bool {
+		return assert.ObjectsAreEqual([]string{"PGDATA=/data", "PGPASSWORD=wrong"}, config.Env)
+	}), "db", (*dockerclient.AuthConfig)(nil)).Return("def789", nil)
+	api.On("StartContainer", "def789", mock.AnythingOfType("*dockerclient.HostConfig")).Return(nil)
+	api.On("RemoveContainer", "def789", true, true).Return(nil)
+	api.On("RenameContainer", "abc123", "db").Return(nil)
+	api.On("StartContainer", "abc123", (*dockerclient.HostConfig)(nil)).Return(nil)
+	client := dockerClient{api: api, clock: clock.NewFake(time.Now())}
+
+	err := client.CorruptEnvContainer(c, []string{"PGPASSWORD=wrong"}, nil, 10, 0)
+	assert.NoError(t, err)
+	api.AssertExpectations(t)
+}
+
+func TestCorruptEnvContainer_CreateError(t *testing.T) {
+	c := envTestContainer()
+	api := mockclient.NewMockClient()
+	api.On("StopContainer", "abc123", 10).Return(nil)
+	api.On("RenameContainer", "abc123", "db_pumba_env").Return(nil)
+	api.On("CreateContainer", mock.Anything, "db", mock.Anything).Return("", errors.New("no such image"))
+	// original container is restored
+	api.On("RenameContainer", "abc123", "db").Return(nil)
+	api.On("StartContainer", "abc123", (*dockerclient.HostConfig)(nil)).Return(nil)
+	client := dockerClient{api: api}
+
+	err := client.CorruptEnvContainer(c, nil, []string{"PGDATA"}, 10, time.Minute)
+	assert.EqualError(t, err, "no such image")
+	api.AssertExpectations(t)
+}
@@ -0,0 +1,114 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/samalba/dockerclient"
+)
+
+var (
+	// ErrNoSuchContainer target container does not exist (e.g. removed during chaos action)
+	ErrNoSuchContainer = errors.New("No such container")
+	// ErrPermissionDenied Docker daemon or container denied chaos action (e.g. tc without NET_ADMIN capability)
+	ErrPermissionDenied = errors.New("Permission denied")
+)
+
+// ErrExecFailed command, exec-ed in container by chaos action, exited with non-zero code
+type ErrExecFailed struct {
+	// Command description of exec-ed command, like 'CPU burn'
+	Command     string
+	ContainerID string
+	ExitCode    int
+}
+
+func (e *ErrExecFailed) Error() string {
+	return fmt.Sprintf("%s in container %s failed with exit code %d", e.Command, e.ContainerID, e.ExitCode)
+}
+
+// Error error of chaos action on container: cause with action and container ID; Kind classifies cause
+type Error struct {
+	// Action chaos action: kill, netem, pause, ...
+	Action      string
+	ContainerID string
+	// Kind ErrNoSuchContainer, ErrPermissionDenied, *ErrExecFailed or nil (other errors)
+	Kind error
+	// Err cause
+	Err error
+}
+
+// Error returns message of cause, which describes failed action
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// wrapError wraps error of chaos action on container into Error; used with defer in client methods, so all returned
+// errors are wrapped; nil and already wrapped errors are not changed
+func wrapError(action string, c Container, err *error) {
+	if *err == nil {
+		return
+	}
+	if _, ok := (*err).(*Error); ok {
+		return
+	}
+	*err = &Error{Action: action, ContainerID: c.ID(), Kind: classify(*err), Err: *err}
+}
+
+// classify returns kind of Docker API or exec error
+func classify(err error) error {
+	if e, ok := err.(*ErrExecFailed); ok {
+		return e
+	}
+	if err == dockerclient.ErrNotFound {
+		return ErrNoSuchContainer
+	}
+	if e, ok := err.(dockerclient.Error); ok && e.StatusCode == 403 {
+		return ErrPermissionDenied
+	}
+	msg := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(msg, "no such container"):
+		return ErrNoSuchContainer
+	case strings.Contains(msg, "permission denied"), strings.Contains(msg, "operation not permitted"):
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+// Cause returns cause of chaos action error; other errors are returned as is
+func Cause(err error) error {
+	if e, ok := err.(*Error); ok {
+		return e.Err
+	}
+	return err
+}
+
+// Kind returns kind of chaos action error: ErrNoSuchContainer, ErrPermissionDenied, *ErrExecFailed or nil
+func Kind(err error) error {
+	if e, ok := err.(*Error); ok {
+		return e.Kind
+	}
+	if err != nil {
+		return classify(err)
+	}
+	return nil
+}
+
+// IsNoSuchContainer checks if chaos action failed, because container does not exist
+func IsNoSuchContainer(err error) bool {
+	return Kind(err) == ErrNoSuchContainer
+}
+
+// IsPermissionDenied checks if chaos action was denied by Docker daemon or container
+func IsPermissionDenied(err error) bool {
+	return Kind(err) == ErrPermissionDenied
+}
+
+// ExitCode returns exit code of command, exec-ed in container by failed chaos action
+func ExitCode(err error) (int, bool) {
+	if e, ok := Kind(err).(*ErrExecFailed); ok {
+		return e.ExitCode, true
+	}
+	return 0, false
+}
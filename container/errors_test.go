@@ -0,0 +1,58 @@
+package container
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/samalba/dockerclient"
+	"github.com/samalba/dockerclient/mockclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapError(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	var err error
+	wrapError("kill", c, &err)
+	assert.NoError(t, err)
+
+	err = errors.New("Error response from daemon: No such container: abc123")
+	wrapError("kill", c, &err)
+	assert.EqualError(t, err, "Error response from daemon: No such container: abc123")
+	assert.Equal(t, "kill", err.(*Error).Action)
+	assert.Equal(t, "abc123", err.(*Error).ContainerID)
+	assert.True(t, IsNoSuchContainer(err))
+	assert.False(t, IsPermissionDenied(err))
+
+	// wrapped errors keep action of inner call
+	wrapError("netem", c, &err)
+	assert.Equal(t, "kill", err.(*Error).Action)
+	assert.EqualError(t, Cause(err), "Error response from daemon: No such container: abc123")
+}
+
+func TestKind(t *testing.T) {
+	assert.Nil(t, Kind(nil))
+	assert.Nil(t, Kind(errors.New("tc: not found")))
+	assert.Equal(t, ErrNoSuchContainer, Kind(dockerclient.ErrNotFound))
+	assert.Equal(t, ErrPermissionDenied, Kind(dockerclient.Error{StatusCode: 403, Status: "403 Forbidden"}))
+	assert.Equal(t, ErrPermissionDenied, Kind(errors.New("RTNETLINK answers: Operation not permitted")))
+	assert.Equal(t, ErrPermissionDenied, Kind(errors.New("iptables: Permission denied (you must be root)")))
+
+	_, ok := ExitCode(errors.New("tc: not found"))
+	assert.False(t, ok)
+	code, ok := ExitCode(&ErrExecFailed{Command: "CPU burn", ContainerID: "abc123", ExitCode: 137})
+	assert.True(t, ok)
+	assert.Equal(t, 137, code)
+}
+
+func TestKillContainer_NoSuchContainer(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123", Name: "/api_1"}}
+	api := mockclient.NewMockClient()
+	api.On("KillContainer", "abc123", "SIGKILL").Return(dockerclient.ErrNotFound)
+
+	client := dockerClient{api: api}
+	err := client.KillContainer(c, "SIGKILL")
+
+	assert.True(t, IsNoSuchContainer(err))
+	assert.Equal(t, dockerclient.ErrNotFound, Cause(err))
+	assert.Equal(t, &Error{Action: "kill", ContainerID: "abc123", Kind: ErrNoSuchContainer, Err: dockerclient.ErrNotFound}, err)
+}
@@ -0,0 +1,118 @@
+package container
+
+import (
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/samalba/dockerclient"
+)
+
+// watchedEvents container events, watched by chaos actions: exit of killed/stopped container and restart of
+// container with netem; Docker events stream is not filtered (dockerclient filters single event only), so other
+// events are skipped by dispatch
+var watchedEvents = map[string]bool{"die": true, "start": true}
+
+// eventWatcher receives Docker events of single container with status; channel is closed, when events stream fails
+type eventWatcher struct {
+	id     string
+	status string
+	events chan dockerclient.EventOrError
+}
+
+// eventHub shares single Docker events stream between watchers of container events; stream is opened with the first
+// watcher and closed with the last one; shared by copies of dockerClient
+type eventHub struct {
+	api      dockerclient.Client
+	mu       sync.Mutex
+	watchers map[*eventWatcher]bool
+	// stop closes events stream; nil - stream is not open
+	stop chan struct{}
+}
+
+func newEventHub(api dockerclient.Client) *eventHub {
+	return &eventHub{api: api, watchers: map[*eventWatcher]bool{}}
+}
+
+// watch subscribes to events of container with status, opening events stream, if needed
+func (h *eventHub) watch(id, status string) (*eventWatcher, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stop == nil {
+		stop := make(chan struct{})
+		events, err := h.api.MonitorEvents(&dockerclient.MonitorEventsOptions{}, stop)
+		if err != nil {
+			return nil, err
+		}
+		h.stop = stop
+		go h.dispatch(events, stop)
+	}
+	w := &eventWatcher{id: id, status: status, events: make(chan dockerclient.EventOrError, 8)}
+	h.watchers[w] = true
+	return w, nil
+}
+
+// unwatch unsubscribes watcher; events stream is closed, when there are no watchers left
+func (h *eventHub) unwatch(w *eventWatcher) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.watchers[w] {
+		return
+	}
+	delete(h.watchers, w)
+	if len(h.watchers) == 0 && h.stop != nil {
+		close(h.stop)
+		h.stop = nil
+	}
+}
+
+// dispatch delivers events of stream to watchers; when stream fails or is closed, watchers are notified and removed
+func (h *eventHub) dispatch(events <-chan dockerclient.EventOrError, stop chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case e, ok := <-events:
+			if !ok || e.Error != nil {
+				h.fail(e, ok, stop)
+				return
+			}
+			if !watchedEvents[e.Status] {
+				continue
+			}
+			h.mu.Lock()
+			for w := range h.watchers {
+				if w.id != e.ID || w.status != e.Status {
+					continue
+				}
+				select {
+				case w.events <- e:
+				default:
+					log.Warnf("Dropped Docker '%s' event of container %s: watcher is busy", e.Status, e.ID)
+				}
+			}
+			h.mu.Unlock()
+		}
+	}
+}
+
+// fail notifies watchers of failed events stream; next watcher opens new stream
+func (h *eventHub) fail(e dockerclient.EventOrError, ok bool, stop chan struct{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.stop != stop {
+		// stream is already closed by the last watcher
+		return
+	}
+	for w := range h.watchers {
+		if ok {
+			select {
+			case w.events <- e:
+			default:
+			}
+		}
+		close(w.events)
+		delete(h.watchers, w)
+	}
+	close(h.stop)
+	h.stop = nil
+}
@@ -0,0 +1,65 @@
+package container
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/samalba/dockerclient"
+	"github.com/samalba/dockerclient/mockclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEventHub_SharedStream(t *testing.T) {
+	events := make(chan dockerclient.EventOrError)
+	var stop <-chan struct{}
+	api := mockclient.NewMockClient()
+	api.On("MonitorEvents", &dockerclient.MonitorEventsOptions{}, mock.Anything).
+		Return((<-chan dockerclient.EventOrError)(events), nil).Once().
+		Run(func(args mock.Arguments) { stop = args.Get(1).(<-chan struct{}) })
+	hub := newEventHub(api)
+
+	die, err := hub.watch("abc123", "die")
+	assert.NoError(t, err)
+	start, err := hub.watch("def456", "start")
+	assert.NoError(t, err)
+	events <- dockerclient.EventOrError{Event: dockerclient.Event{ID: "abc123", Status: "kill"}}
+	events <- dockerclient.EventOrError{Event: dockerclient.Event{ID: "abc123", Status: "start"}}
+	events <- dockerclient.EventOrError{Event: dockerclient.Event{ID: "def456", Status: "start"}}
+	events <- dockerclient.EventOrError{Event: dockerclient.Event{ID: "abc123", Status: "die"}}
+
+	assert.Equal(t, "def456", (<-start.events).ID)
+	assert.Equal(t, "abc123", (<-die.events).ID)
+	// stream is closed with the last watcher
+	hub.unwatch(die)
+	select {
+	case <-stop:
+		t.Fatal("events stream is closed with watchers left")
+	default:
+	}
+	hub.unwatch(start)
+	<-stop
+	api.AssertExpectations(t)
+}
+
+func TestEventHub_StreamError(t *testing.T) {
+	events := make(chan dockerclient.EventOrError)
+	api := mockclient.NewMockClient()
+	api.On("MonitorEvents", mock.AnythingOfType("*dockerclient.MonitorEventsOptions"), mock.Anything).Return((<-chan dockerclient.EventOrError)(events), nil).Twice()
+	hub := newEventHub(api)
+
+	w, err := hub.watch("abc123", "die")
+	assert.NoError(t, err)
+	events <- dockerclient.EventOrError{Error: errors.New("connection reset")}
+
+	e, ok := <-w.events
+	assert.True(t, ok)
+	assert.EqualError(t, e.Error, "connection reset")
+	_, ok = <-w.events
+	assert.False(t, ok)
+	hub.unwatch(w)
+	// next watcher opens new stream
+	_, err = hub.watch("abc123", "die")
+	assert.NoError(t, err)
+	api.AssertExpectations(t)
+}
@@ -0,0 +1,36 @@
+package container
+
+import (
+	enginetypes "github.com/docker/engine-api/types"
+)
+
+// workdirScript changes to working directory ($0) and replaces shell with command; exec API has no working
+// directory option, so command is wrapped with shell
+const workdirScript = `cd "$0" && exec "$@"`
+
+// ExecOptions user and working directory of commands (tc, iptables, stress, probes), executed in containers;
+// empty values keep container defaults
+type ExecOptions struct {
+	// User user name or UID, optionally with group: 'user[:group]'
+	User string
+	// WorkingDir absolute path of working directory; requires 'sh' in container
+	WorkingDir string
+}
+
+// WithExecOptions sets exec options of client, created with NewClient; other clients are returned as is
+func WithExecOptions(client Client, opts ExecOptions) Client {
+	if c, ok := client.(dockerClient); ok {
+		c.execOpts = opts
+		return c
+	}
+	return client
+}
+
+// execConfig returns exec configuration of command in container: injected tools, user and working directory
+func (client dockerClient) execConfig(c Container, cmd []string) enginetypes.ExecConfig {
+	config := enginetypes.ExecConfig{User: client.execOpts.User, Cmd: client.toolCommand(c, cmd)}
+	if dir := client.execOpts.WorkingDir; dir != "" {
+		config.Cmd = client.toolCommand(c, append([]string{"sh", "-c", workdirScript, dir}, config.Cmd...))
+	}
+	return config
+}
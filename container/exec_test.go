@@ -0,0 +1,46 @@
+package container
+
+import (
+	"testing"
+
+	"github.com/docker/engine-api/types"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+)
+
+func TestWithExecOptions(t *testing.T) {
+	client := WithExecOptions(dockerClient{}, ExecOptions{User: "root"})
+	assert.Equal(t, ExecOptions{User: "root"}, client.(dockerClient).execOpts)
+	// decorated clients are not changed
+	mock := &MockClient{}
+	assert.Equal(t, mock, WithExecOptions(mock, ExecOptions{User: "root"}))
+}
+
+func TestExecConfig(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	client := dockerClient{tools: newToolRegistry()}
+	assert.Equal(t, types.ExecConfig{Cmd: []string{"tc", "qdisc", "show"}}, client.execConfig(c, []string{"tc", "qdisc", "show"}))
+
+	client.execOpts = ExecOptions{User: "0:0", WorkingDir: "/var/lib/app"}
+	assert.Equal(t, types.ExecConfig{User: "0:0", Cmd: []string{"sh", "-c", workdirScript, "/var/lib/app", "tc", "qdisc", "show"}},
+		client.execConfig(c, []string{"tc", "qdisc", "show"}))
+
+	// injected tools are used both for shell wrapper and command
+	client.tools.set("abc123", map[string]bool{"sh": true, "tc": true})
+	assert.Equal(t, []string{"/tmp/.pumba-tools/sh", "-c", workdirScript, "/var/lib/app", "/tmp/.pumba-tools/tc", "qdisc", "show"},
+		client.execConfig(c, []string{"tc", "qdisc", "show"}).Cmd)
+}
+
+func TestExecOnContainer_User(t *testing.T) {
+	c := Container{containerInfo: &dockerclient.ContainerInfo{Id: "abc123"}}
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	config := types.ExecConfig{User: "root", Privileged: true, Cmd: []string{"tc", "qdisc", "show"}}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.ContainerExecCreateResponse{ID: "e1"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "e1", types.ExecStartCheck{}).Return(nil)
+
+	client := dockerClient{apiClient: engineClient, execOpts: ExecOptions{User: "root"}}
+	assert.NoError(t, client.execOnContainer(c, []string{"tc", "qdisc", "show"}, true))
+	engineClient.AssertExpectations(t)
+}
@@ -0,0 +1,27 @@
+package container
+
+import (
+	"time"
+
+	"github.com/gaia-adm/pumba/health"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// HealthcheckContainer disrupts only healthcheck probe of container for specified duration: probe binary is replaced
+// with stub, that passes (false-healthy container) or fails, or probe traffic to loopback port is rejected; container
+// itself is not affected, so alerting based on real traffic can be tested
+func (client dockerClient) HealthcheckContainer(c Container, probe health.Probe, duration time.Duration) (err error) {
+	client = client.timed("health")
+	defer wrapError("health", c, &err)
+	log.Infof("Disrupting healthcheck of container %s with %s for %s", c.ID(), probe, duration)
+	// original probe binary is restored, if stub is not created
+	stack, err := health.Pipeline(probe).Apply(client.execStep(c))
+	if err != nil {
+		return err
+	}
+	// sleep (current goroutine) for specified duration and then restore probe
+	client.getClock().Sleep(duration)
+	log.Infof("Restoring healthcheck of container %s", c.ID())
+	return stack.Unwind(client.execStep(c))
+}
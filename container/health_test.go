@@ -0,0 +1,53 @@
+package container
+
+import (
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/docker/engine-api/types"
+	"github.com/gaia-adm/pumba/health"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHealthcheckContainer_Port(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	cmds := health.Pipeline(health.Probe{Port: 8080})
+	applyConfig := types.ExecConfig{Cmd: cmds.ApplyCommands()[0], Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", applyConfig).Return(types.ContainerExecCreateResponse{ID: "e1"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "e1", types.ExecStartCheck{}).Return(nil)
+	undoConfig := types.ExecConfig{Cmd: cmds.TeardownCommands()[0], Privileged: true}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", undoConfig).Return(types.ContainerExecCreateResponse{ID: "e2"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "e2", types.ExecStartCheck{}).Return(nil)
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.HealthcheckContainer(c, health.Probe{Port: 8080}, time.Millisecond)
+
+	assert.NoError(t, err)
+	engineClient.AssertExpectations(t)
+}
+
+func TestHealthcheckContainer_DryRun(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	engineClient := NewMockEngine()
+	client := dockerClient{apiClient: engineClient}
+	err := NewDryRunClient(client).HealthcheckContainer(c, health.Probe{Binary: "/usr/bin/curl"}, time.Hour)
+
+	assert.NoError(t, err)
+	engineClient.AssertNotCalled(t, "ContainerExecCreate", mock.Anything, mock.Anything, mock.Anything)
+}
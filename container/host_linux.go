@@ -0,0 +1,16 @@
+package container
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// runOnHost runs command on Docker host; requires Pumba to run on Docker host
+// (or in container with host network namespace) with enough privileges
+func runOnHost(cmd []string) ([]byte, error) {
+	path, err := exec.LookPath(cmd[0])
+	if err != nil {
+		return nil, fmt.Errorf("%s is not installed: %s", cmd[0], err)
+	}
+	return exec.Command(path, cmd[1:]...).CombinedOutput()
+}
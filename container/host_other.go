@@ -0,0 +1,13 @@
+// +build !linux
+
+package container
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// runOnHost is not supported: host iptables rules are available only on Linux Docker host
+func runOnHost(cmd []string) ([]byte, error) {
+	return nil, fmt.Errorf("%s is not supported on %s/%s", cmd[0], runtime.GOOS, runtime.GOARCH)
+}
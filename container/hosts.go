@@ -0,0 +1,73 @@
+package container
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gaia-adm/pumba/netem"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// hostRefreshInterval interval of re-resolving netem target hosts during netem duration
+const hostRefreshInterval = 30 * time.Second
+
+// lookupFunc resolves host name to IP addresses
+type lookupFunc func(host string) ([]net.IP, error)
+
+// getLookup returns host name resolver; Pumba host resolver if not set
+func (client dockerClient) getLookup() lookupFunc {
+	if client.lookup == nil {
+		return net.LookupIP
+	}
+	return client.lookup
+}
+
+// resolveHosts returns netem filter with IPs of target hosts, resolved by Pumba host resolver, added to static target
+// IPs; container IPs change, when containers are re-created, so hosts are resolved on each run and during netem
+func resolveHosts(lookup lookupFunc, filter netem.Filter, static []net.IP) (netem.Filter, error) {
+	ips := append([]net.IP{}, static...)
+	seen := map[string]bool{}
+	for _, ip := range static {
+		seen[ip.String()] = true
+	}
+	for _, host := range filter.Hosts {
+		resolved, err := lookup(host)
+		if err != nil {
+			return filter, fmt.Errorf("Failed to resolve netem target host '%s': %s", host, err)
+		}
+		if len(resolved) == 0 {
+			return filter, fmt.Errorf("Failed to resolve netem target host '%s': no addresses", host)
+		}
+		log.Debugf("Resolved netem target host '%s' to %v", host, resolved)
+		for _, ip := range resolved {
+			if ip4 := ip.To4(); ip4 != nil {
+				ip = ip4
+			}
+			if !seen[ip.String()] {
+				seen[ip.String()] = true
+				ips = append(ips, ip)
+			}
+		}
+	}
+	filter.IPs = ips
+	return filter, nil
+}
+
+// sameIPs checks, that lists contain the same IP addresses, regardless of order
+func sameIPs(a, b []net.IP) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := map[string]bool{}
+	for _, ip := range a {
+		set[ip.String()] = true
+	}
+	for _, ip := range b {
+		if !set[ip.String()] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,111 @@
+package container
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/types"
+	"github.com/gaia-adm/pumba/clock"
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/net/context"
+)
+
+func TestResolveHosts(t *testing.T) {
+	lookup := func(host string) ([]net.IP, error) {
+		switch host {
+		case "db.service.local":
+			return []net.IP{net.ParseIP("10.0.0.5"), net.ParseIP("fd00::5")}, nil
+		case "cache.service.local":
+			return []net.IP{net.ParseIP("10.0.0.1")}, nil
+		case "empty.local":
+			return nil, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	static := []net.IP{net.ParseIP("10.0.0.1").To4()}
+	filter, err := resolveHosts(lookup, netem.Filter{Hosts: []string{"db.service.local", "cache.service.local"}, Port: 5432}, static)
+	assert.NoError(t, err)
+	// IPv4 addresses are 4-byte, duplicates of static IPs are dropped
+	assert.Equal(t, []net.IP{net.ParseIP("10.0.0.1").To4(), net.ParseIP("10.0.0.5").To4(), net.ParseIP("fd00::5")}, filter.IPs)
+	assert.Equal(t, 5432, filter.Port)
+	assert.Equal(t, "dst 10.0.0.1,10.0.0.5,fd00::5 host db.service.local,cache.service.local dport 5432", filter.String())
+
+	_, err = resolveHosts(lookup, netem.Filter{Hosts: []string{"db.internal"}}, nil)
+	assert.EqualError(t, err, "Failed to resolve netem target host 'db.internal': no such host")
+	_, err = resolveHosts(lookup, netem.Filter{Hosts: []string{"empty.local"}}, nil)
+	assert.EqualError(t, err, "Failed to resolve netem target host 'empty.local': no addresses")
+}
+
+func TestSameIPs(t *testing.T) {
+	a := []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}
+	assert.True(t, sameIPs(a, []net.IP{net.ParseIP("10.0.0.2"), net.ParseIP("10.0.0.1")}))
+	assert.False(t, sameIPs(a, []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.3")}))
+	assert.False(t, sameIPs(a, a[:1]))
+}
+
+func TestNetemContainer_RefreshHosts(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+	engineClient := NewMockEngine()
+	var cmds [][]string
+	engineClient.On("ContainerExecCreate", context.Background(), "abc123", mock.MatchedBy(func(config types.ExecConfig) bool {
+		if config.Privileged {
+			cmds = append(cmds, config.Cmd)
+			return true
+		}
+		return false
+	})).Return(types.ContainerExecCreateResponse{ID: "cmdID"}, nil)
+	engineClient.On("ContainerExecStart", context.Background(), "cmdID", types.ExecStartCheck{}).Return(nil)
+	expectTcFilterShow(engineClient, "abc123", "eth0", "filter parent 1: protocol ip pref 3 u32 fh 800::800 order 2048 key ht 800 bkt 0 flowid 1:3\r\n")
+	// qdiscs are checked before netem is applied and re-applied for new IP
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", netemPrioShow)
+	expectQdiscCheck(engineClient, "abc123", "eth0")
+	expectTcShow(engineClient, "abc123", "eth0", netemPrioShow)
+	expectTcShow(engineClient, "abc123", "eth0", "qdisc noqueue 0: root refcnt 2\r\n")
+
+	// db is re-created with new IP after the first refresh
+	resolved := []string{"10.0.0.5", "10.0.0.5", "10.0.0.6"}
+	looked := make(chan int, len(resolved))
+	lookups := 0
+	lookup := func(host string) ([]net.IP, error) {
+		ip := net.ParseIP(resolved[len(resolved)-1])
+		if lookups < len(resolved) {
+			ip = net.ParseIP(resolved[lookups])
+		}
+		lookups++
+		looked <- lookups
+		return []net.IP{ip}, nil
+	}
+	fake := clock.NewFake(time.Now())
+	client := dockerClient{apiClient: engineClient, clock: fake, lookup: lookup}
+	done := make(chan error)
+	go func() {
+		done <- client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{Hosts: []string{"db"}}, 2*time.Minute, false, "")
+	}()
+	<-looked
+	// deadline and refresh ticker
+	fake.BlockUntil(2)
+	fake.Advance(hostRefreshInterval)
+	<-looked
+	fake.Advance(hostRefreshInterval)
+	<-looked
+	fake.Advance(time.Minute)
+	assert.NoError(t, <-done)
+	filter := func(ip string) []string {
+		return []string{"tc", "filter", "add", "dev", "eth0", "protocol", "ip", "parent", "1:0", "prio", "3", "u32", "match", "ip", "dst", ip + "/32", "flowid", "1:3"}
+	}
+	delRoot := []string{"tc", "qdisc", "del", "dev", "eth0", "root", "handle", "1:", "prio"}
+	assert.Equal(t, filter("10.0.0.5"), cmds[2])
+	// netem is re-applied with filter for new IP, and the new filter is removed at the end
+	assert.Equal(t, [][]string{delRoot, cmds[0], cmds[1], filter("10.0.0.6"), delRoot}, cmds[3:])
+	engineClient.AssertExpectations(t)
+}
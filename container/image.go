@@ -0,0 +1,63 @@
+package container
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// imageReference splits image reference ('repository:tag') of container image into repository and tag; images,
+// referenced by digest or ID, can not be re-tagged
+func imageReference(image string) (string, string, error) {
+	if image == "" || strings.Contains(image, "@") || strings.HasPrefix(image, "sha256:") {
+		return "", "", fmt.Errorf("Image '%s' is not referenced by tag", image)
+	}
+	repo, tag := image, "latest"
+	// colon after last slash separates tag; colon before it separates registry port
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		repo, tag = image[:i], image[i+1:]
+	}
+	return repo, tag, nil
+}
+
+// PoisonImageContainer tags bogus image over image tag of container in local image cache for specified duration,
+// and restores the tag afterwards: containers, created from the tag on this node without pull, run bogus image;
+// bogus image is pulled, if it is not available locally. The tag is restored to image it points to before poisoning,
+// which is not the image of container, if the tag was moved after container creation
+func (client dockerClient) PoisonImageContainer(c Container, image string, duration time.Duration) (err error) {
+	client = client.timed("poison-image")
+	defer wrapError("poison-image", c, &err)
+	reference := c.ImageName()
+	repo, tag, err := imageReference(reference)
+	if err != nil {
+		return fmt.Errorf("Can not poison image of container %s: %s", c.ID(), err)
+	}
+	log.Infof("Poisoning image %s of container %s with %s for %s", reference, c.ID(), image, duration)
+	current, err := client.api.InspectImage(reference)
+	if err != nil {
+		return fmt.Errorf("Failed to inspect image %s: %s", reference, err)
+	}
+	original := current.Id
+	bogus, err := client.api.InspectImage(image)
+	if err != nil {
+		log.Debugf("Pulling image %s", image)
+		if err = client.api.PullImage(image, nil); err != nil {
+			return fmt.Errorf("Failed to pull image %s: %s", image, err)
+		}
+		if bogus, err = client.api.InspectImage(image); err != nil {
+			return err
+		}
+	}
+	if err = client.api.TagImage(bogus.Id, repo, tag, true); err != nil {
+		return fmt.Errorf("Failed to tag image %s as %s: %s", image, reference, err)
+	}
+	log.Debugf("Image %s poisoned with %s for %s", reference, image, duration)
+	client.getClock().Sleep(duration)
+	if err = client.api.TagImage(original, repo, tag, true); err != nil {
+		return fmt.Errorf("Failed to restore image %s (%s): %s", reference, original, err)
+	}
+	log.Debugf("Image %s restored after %s", reference, duration)
+	return nil
+}
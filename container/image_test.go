@@ -0,0 +1,124 @@
+package container
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/samalba/dockerclient"
+	"github.com/samalba/dockerclient/mockclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageReference(t *testing.T) {
+	for _, ref := range [][]string{
+		{"myorg/api:1.2", "myorg/api", "1.2"},
+		{"api", "api", "latest"},
+		{"registry:5000/api", "registry:5000/api", "latest"},
+		{"registry:5000/myorg/api:1.2", "registry:5000/myorg/api", "1.2"},
+	} {
+		repo, tag, err := imageReference(ref[0])
+		assert.NoError(t, err)
+		assert.Equal(t, ref[1], repo)
+		assert.Equal(t, ref[2], tag)
+	}
+	_, _, err := imageReference("api@sha256:4f2a9c")
+	assert.EqualError(t, err, "Image 'api@sha256:4f2a9c' is not referenced by tag")
+	_, _, err = imageReference("sha256:4f2a9c")
+	assert.Error(t, err)
+}
+
+func imageContainer(image string) Container {
+	return Container{
+		containerInfo: &dockerclient.ContainerInfo{Id: "abc123", Config: &dockerclient.ContainerConfig{Image: image}},
+		imageInfo:     &dockerclient.ImageInfo{Id: "sha256:api"},
+	}
+}
+
+func TestPoisonImageContainer(t *testing.T) {
+	api := mockclient.NewMockClient()
+	api.On("InspectImage", "myorg/api:1.2").Return(&dockerclient.ImageInfo{Id: "sha256:api"}, nil)
+	api.On("InspectImage", "busybox:latest").Return(&dockerclient.ImageInfo{Id: "sha256:busybox"}, nil)
+	api.On("TagImage", "sha256:busybox", "myorg/api", "1.2", true).Return(nil).Once()
+	api.On("TagImage", "sha256:api", "myorg/api", "1.2", true).Return(nil).Once()
+
+	client := dockerClient{api: api}
+	start := time.Now()
+	err := client.PoisonImageContainer(imageContainer("myorg/api:1.2"), "busybox:latest", 10*time.Millisecond)
+
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 10*time.Millisecond)
+	api.AssertExpectations(t)
+}
+
+func TestPoisonImageContainer_Pull(t *testing.T) {
+	api := mockclient.NewMockClient()
+	api.On("InspectImage", "api:latest").Return(&dockerclient.ImageInfo{Id: "sha256:api"}, nil)
+	api.On("InspectImage", "busybox:latest").Return(&dockerclient.ImageInfo{}, errors.New("not found")).Once()
+	api.On("PullImage", "busybox:latest", (*dockerclient.AuthConfig)(nil)).Return(nil)
+	api.On("InspectImage", "busybox:latest").Return(&dockerclient.ImageInfo{Id: "sha256:busybox"}, nil).Once()
+	api.On("TagImage", "sha256:busybox", "api", "latest", true).Return(nil).Once()
+	api.On("TagImage", "sha256:api", "api", "latest", true).Return(nil).Once()
+
+	client := dockerClient{api: api}
+	err := client.PoisonImageContainer(imageContainer("api"), "busybox:latest", time.Millisecond)
+
+	assert.NoError(t, err)
+	api.AssertExpectations(t)
+}
+
+func TestPoisonImageContainer_RestoreError(t *testing.T) {
+	api := mockclient.NewMockClient()
+	api.On("InspectImage", "myorg/api:1.2").Return(&dockerclient.ImageInfo{Id: "sha256:api"}, nil)
+	api.On("InspectImage", "busybox:latest").Return(&dockerclient.ImageInfo{Id: "sha256:busybox"}, nil)
+	api.On("TagImage", "sha256:busybox", "myorg/api", "1.2", true).Return(nil).Once()
+	api.On("TagImage", "sha256:api", "myorg/api", "1.2", true).Return(errors.New("conflict")).Once()
+
+	client := dockerClient{api: api}
+	err := client.PoisonImageContainer(imageContainer("myorg/api:1.2"), "busybox:latest", time.Millisecond)
+
+	assert.EqualError(t, err, "Failed to restore image myorg/api:1.2 (sha256:api): conflict")
+	api.AssertExpectations(t)
+}
+
+func TestPoisonImageContainer_TagMoved(t *testing.T) {
+	api := mockclient.NewMockClient()
+	// tag was moved to new image after container creation
+	api.On("InspectImage", "myorg/api:1.2").Return(&dockerclient.ImageInfo{Id: "sha256:api-rebuilt"}, nil)
+	api.On("InspectImage", "busybox:latest").Return(&dockerclient.ImageInfo{Id: "sha256:busybox"}, nil)
+	api.On("TagImage", "sha256:busybox", "myorg/api", "1.2", true).Return(nil).Once()
+	api.On("TagImage", "sha256:api-rebuilt", "myorg/api", "1.2", true).Return(nil).Once()
+
+	client := dockerClient{api: api}
+	err := client.PoisonImageContainer(imageContainer("myorg/api:1.2"), "busybox:latest", time.Millisecond)
+
+	assert.NoError(t, err)
+	api.AssertExpectations(t)
+	api.AssertNotCalled(t, "TagImage", "sha256:api", "myorg/api", "1.2", true)
+}
+
+func TestPoisonImageContainer_TagMissing(t *testing.T) {
+	api := mockclient.NewMockClient()
+	api.On("InspectImage", "myorg/api:1.2").Return(&dockerclient.ImageInfo{}, errors.New("no such image"))
+
+	client := dockerClient{api: api}
+	err := client.PoisonImageContainer(imageContainer("myorg/api:1.2"), "busybox:latest", time.Millisecond)
+
+	assert.EqualError(t, err, "Failed to inspect image myorg/api:1.2: no such image")
+	api.AssertNotCalled(t, "TagImage", "sha256:busybox", "myorg/api", "1.2", true)
+}
+
+func TestPoisonImageContainer_Digest(t *testing.T) {
+	api := mockclient.NewMockClient()
+	client := dockerClient{api: api}
+	err := client.PoisonImageContainer(imageContainer("api@sha256:4f2a9c"), "busybox:latest", time.Millisecond)
+	assert.EqualError(t, err, "Can not poison image of container abc123: Image 'api@sha256:4f2a9c' is not referenced by tag")
+}
+
+func TestPoisonImageContainer_DryRun(t *testing.T) {
+	api := mockclient.NewMockClient()
+	client := dockerClient{api: api}
+	err := NewDryRunClient(client).PoisonImageContainer(imageContainer("myorg/api:1.2"), "busybox:latest", time.Hour)
+	assert.NoError(t, err)
+	api.AssertNotCalled(t, "TagImage", "sha256:busybox", "myorg/api", "1.2", true)
+}
@@ -0,0 +1,67 @@
+package container
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gaia-adm/pumba/cgroup"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// getCgroupFS returns client cgroup filesystem; cgroup filesystem of local host if not set
+func (client dockerClient) getCgroupFS() cgroup.FS {
+	if client.cgroupFS == nil {
+		return cgroup.Host
+	}
+	return *client.cgroupFS
+}
+
+// containerPid returns PID of container main process
+func containerPid(c Container) (int, error) {
+	if c.containerInfo == nil || c.containerInfo.State == nil || c.containerInfo.State.Pid == 0 {
+		return 0, fmt.Errorf("Unknown PID of container %s: container is not running", c.ID())
+	}
+	return c.containerInfo.State.Pid, nil
+}
+
+// MemoryPressureContainer puts container under memory pressure for specified duration: memory limit of container
+// cgroup is set to percent of its current memory usage, so kernel throttles container processes and reclaims their
+// memory (page cache, swap); original limit is restored after duration. On cgroup v2 memory.high is lowered and OOM
+// killer is not invoked; cgroup v1 has no throttle limit and hard memory.limit_in_bytes would invoke OOM killer, so
+// containers in cgroup v1 fail with error. Pumba must run on Docker host with writable cgroup filesystem
+func (client dockerClient) MemoryPressureContainer(c Container, percent int, duration time.Duration) (err error) {
+	client = client.timed("memory")
+	defer wrapError("memory", c, &err)
+	pid, err := containerPid(c)
+	if err != nil {
+		return err
+	}
+	fs := client.getCgroupFS()
+	group, err := fs.Controller(pid, "memory")
+	if err != nil {
+		return err
+	}
+	if group.Version == cgroup.V1 {
+		return fmt.Errorf("Container %s is in cgroup v1 without memory.high: memory pressure requires cgroup v2 (use 'oom' command for OOM kill)", c.ID())
+	}
+	usage, limit := group.MemoryControls()
+	current, err := fs.Bytes(group.Dir, usage)
+	if err != nil {
+		return err
+	}
+	original, err := fs.Read(group.Dir, limit)
+	if err != nil {
+		return err
+	}
+	lowered := current * int64(percent) / 100
+	log.Infof("Setting %s of container %s to %d bytes (%d%% of %d bytes used) for %s", limit, c.ID(), lowered, percent, current, duration)
+	if err = fs.Write(group.Dir, limit, strconv.FormatInt(lowered, 10)); err != nil {
+		return err
+	}
+	// sleep (current goroutine) for specified duration and then restore memory limit
+	client.getClock().Sleep(duration)
+	log.Infof("Restoring %s of container %s to %s", limit, c.ID(), original)
+	return fs.Write(group.Dir, limit, original)
+}
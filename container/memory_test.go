@@ -0,0 +1,107 @@
+package container
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/cgroup"
+	"github.com/gaia-adm/pumba/clock"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryPressureContainer(t *testing.T) {
+	for _, test := range []struct {
+		cgroups  string
+		dir      string
+		files    map[string]string
+		limit    string
+		original string
+	}{
+		{
+			cgroups:  "0::/system.slice/docker-abc123.scope\n",
+			dir:      "system.slice/docker-abc123.scope",
+			files:    map[string]string{"cgroup.controllers": "cpu memory pids\n", "memory.current": "1000000\n", "memory.high": "max\n"},
+			limit:    "memory.high",
+			original: "max",
+		},
+	} {
+		root, err := ioutil.TempDir("", "pumba-memory")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer os.RemoveAll(root)
+		fs := cgroup.FS{Proc: filepath.Join(root, "proc"), Cgroup: filepath.Join(root, "cgroup")}
+		dir := filepath.Join(fs.Cgroup, test.dir)
+		os.MkdirAll(filepath.Join(fs.Proc, "4242"), 0755)
+		os.MkdirAll(dir, 0755)
+		ioutil.WriteFile(filepath.Join(fs.Proc, "4242", "cgroup"), []byte(test.cgroups), 0644)
+		for name, content := range test.files {
+			ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		}
+
+		c := Container{
+			containerInfo: &dockerclient.ContainerInfo{
+				Id:    "abc123",
+				State: &dockerclient.State{Pid: 4242},
+			},
+		}
+		fake := clock.NewFake(time.Now())
+		client := dockerClient{cgroupFS: &fs, clock: fake}
+		done := make(chan error)
+		go func() { done <- client.MemoryPressureContainer(c, 80, time.Minute) }()
+
+		// memory limit is lowered for duration and then restored
+		fake.BlockUntil(1)
+		limit, _ := fs.Read(dir, test.limit)
+		assert.Equal(t, "800000", limit, test.limit)
+		fake.Advance(time.Minute)
+
+		assert.NoError(t, <-done)
+		limit, _ = fs.Read(dir, test.limit)
+		assert.Equal(t, test.original, limit, test.limit)
+	}
+}
+
+func TestMemoryPressureContainer_CgroupV1(t *testing.T) {
+	root, err := ioutil.TempDir("", "pumba-memory")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(root)
+	fs := cgroup.FS{Proc: filepath.Join(root, "proc"), Cgroup: filepath.Join(root, "cgroup")}
+	dir := filepath.Join(fs.Cgroup, "memory/docker/abc123")
+	os.MkdirAll(filepath.Join(fs.Proc, "4242"), 0755)
+	os.MkdirAll(dir, 0755)
+	ioutil.WriteFile(filepath.Join(fs.Proc, "4242", "cgroup"), []byte("4:memory:/docker/abc123\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "memory.usage_in_bytes"), []byte("1000000\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "memory.limit_in_bytes"), []byte("9223372036854771712\n"), 0644)
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id:    "abc123",
+			State: &dockerclient.State{Pid: 4242},
+		},
+	}
+
+	err = dockerClient{cgroupFS: &fs}.MemoryPressureContainer(c, 80, time.Minute)
+
+	// hard limit is not lowered: OOM killer would fire
+	assert.EqualError(t, err, "Container abc123 is in cgroup v1 without memory.high: memory pressure requires cgroup v2 (use 'oom' command for OOM kill)")
+	limit, _ := fs.Read(dir, "memory.limit_in_bytes")
+	assert.Equal(t, "9223372036854771712", limit)
+}
+
+func TestMemoryPressureContainer_NoPid(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+	err := dockerClient{}.MemoryPressureContainer(c, 80, time.Minute)
+	assert.EqualError(t, err, "Unknown PID of container abc123: container is not running")
+	err = NewDryRunClient(dockerClient{}).MemoryPressureContainer(c, 80, time.Minute)
+	assert.EqualError(t, err, "Unknown PID of container abc123: container is not running")
+}
@@ -4,12 +4,49 @@ import (
 	"net"
 	"time"
 
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/samalba/dockerclient"
 	"github.com/stretchr/testify/mock"
 )
 
+// MockContainerOption sets container info of mock container
+type MockContainerOption func(info *dockerclient.ContainerInfo)
+
+// MockImage sets image of mock container
+func MockImage(image string) MockContainerOption {
+	return func(info *dockerclient.ContainerInfo) { info.Config.Image = image }
+}
+
+// MockHostConfig sets host config (links, resources) of mock container
+func MockHostConfig(hostConfig dockerclient.HostConfig) MockContainerOption {
+	return func(info *dockerclient.ContainerInfo) { info.HostConfig = &hostConfig }
+}
+
+// MockNetwork connects mock container to network with IP address
+func MockNetwork(network, ip string) MockContainerOption {
+	return func(info *dockerclient.ContainerInfo) {
+		if info.NetworkSettings.Networks == nil {
+			info.NetworkSettings.Networks = map[string]*dockerclient.EndpointSettings{}
+		}
+		info.NetworkSettings.Networks[network] = &dockerclient.EndpointSettings{IPAddress: ip}
+	}
+}
+
+// NewMockContainer creates container with ID, name, labels and options for tests
+func NewMockContainer(id, name string, labels map[string]string, options ...MockContainerOption) Container {
+	info := &dockerclient.ContainerInfo{Id: id, Name: name, Config: &dockerclient.ContainerConfig{Labels: labels}}
+	for _, option := range options {
+		option(info)
+	}
+	return *NewContainer(info, nil)
+}
+
 // MockClient mock struct
 type MockClient struct {
 	mock.Mock
+	// Dry mock dry run client
+	Dry bool
 }
 
 // NewMockSamalbaClient creates a new mock client
@@ -24,7 +61,7 @@ func (m *MockClient) ListContainers(cf Filter) ([]Container, error) {
 }
 
 // StopContainer mock
-func (m *MockClient) StopContainer(c Container, timeout int, dryrun bool) error {
+func (m *MockClient) StopContainer(c Container, timeout int) error {
 	args := m.Called(c, timeout)
 	return args.Error(0)
 }
@@ -42,31 +79,198 @@ func (m *MockClient) RenameContainer(c Container, name string) error {
 }
 
 // RemoveImage mock
-func (m *MockClient) RemoveImage(c Container, b bool, dryrun bool) error {
+func (m *MockClient) RemoveImage(c Container, b bool) error {
 	args := m.Called(c, b)
 	return args.Error(0)
 }
 
 // KillContainer mock
-func (m *MockClient) KillContainer(c Container, s string, dryrun bool) error {
+func (m *MockClient) KillContainer(c Container, s string) error {
 	args := m.Called(c, s)
 	return args.Error(0)
 }
 
 // RemoveContainer mock
-func (m *MockClient) RemoveContainer(c Container, f bool, l bool, v bool, dryrun bool) error {
+func (m *MockClient) RemoveContainer(c Container, f bool, l bool, v bool) error {
 	args := m.Called(c, f, l, v)
 	return args.Error(0)
 }
 
 // PauseContainer mock
-func (m *MockClient) PauseContainer(c Container, d time.Duration, dryrun bool) error {
+func (m *MockClient) PauseContainer(c Container, d time.Duration) error {
 	args := m.Called(c, d)
 	return args.Error(0)
 }
 
 // NetemContainer mock
-func (m *MockClient) NetemContainer(c Container, n string, s string, ip net.IP, d time.Duration, dryrun bool) error {
-	args := m.Called(c, n, s, ip, d)
+func (m *MockClient) NetemContainer(c Container, n string, s string, f netem.Filter, d time.Duration, reapply bool, probe string) error {
+	args := m.Called(c, n, s, f, d)
+	return args.Error(0)
+}
+
+// SidecarContainer mock
+func (m *MockClient) SidecarContainer(c Container, image string, cmd []string, d time.Duration) error {
+	args := m.Called(c, image, cmd, d)
+	return args.Error(0)
+}
+
+// ExecContainer mock
+func (m *MockClient) ExecContainer(c Container, cmd []string, timeout time.Duration) (int, error) {
+	args := m.Called(c, cmd, timeout)
+	return args.Int(0), args.Error(1)
+}
+
+// NetworkInterface mock
+func (m *MockClient) NetworkInterface(c Container, network string) (string, error) {
+	args := m.Called(c, network)
+	return args.String(0), args.Error(1)
+}
+
+// NetworkInterfaces mock
+func (m *MockClient) NetworkInterfaces(c Container) ([]string, error) {
+	args := m.Called(c)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// NetemLeftovers mock
+func (m *MockClient) NetemLeftovers(c Container, n string) ([]string, error) {
+	args := m.Called(c, n)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+// ResolveAlias mock
+func (m *MockClient) ResolveAlias(c Container, alias string) ([]net.IP, error) {
+	args := m.Called(c, alias)
+	return args.Get(0).([]net.IP), args.Error(1)
+}
+
+// DropPortsContainer mock
+func (m *MockClient) DropPortsContainer(c Container, loss int, d time.Duration) error {
+	args := m.Called(c, loss, d)
+	return args.Error(0)
+}
+
+// ExitWatch mock
+func (m *MockClient) ExitWatch(c Container, timeout time.Duration) (<-chan time.Time, error) {
+	args := m.Called(c, timeout)
+	return args.Get(0).(<-chan time.Time), args.Error(1)
+}
+
+// UnpauseContainer mock
+func (m *MockClient) UnpauseContainer(c Container) error {
+	args := m.Called(c)
+	return args.Error(0)
+}
+
+// ClearNetemContainer mock
+func (m *MockClient) ClearNetemContainer(c Container, n string) error {
+	args := m.Called(c, n)
+	return args.Error(0)
+}
+
+// StopNetemContainer mock
+func (m *MockClient) StopNetemContainer(c Container, n string, f netem.Filter) error {
+	args := m.Called(c, n, f)
+	return args.Error(0)
+}
+
+// RestorePortsContainer mock
+func (m *MockClient) RestorePortsContainer(c Container, loss int) error {
+	args := m.Called(c, loss)
+	return args.Error(0)
+}
+
+// BurnCPUContainer mock
+func (m *MockClient) BurnCPUContainer(c Container, workers int, d time.Duration) error {
+	args := m.Called(c, workers, d)
 	return args.Error(0)
 }
+
+// BlackoutContainer mock
+func (m *MockClient) BlackoutContainer(c Container, timeout int, d time.Duration) error {
+	args := m.Called(c, timeout, d)
+	return args.Error(0)
+}
+
+// BeaconContainer mock
+func (m *MockClient) BeaconContainer(c Container, labels map[string]string) error {
+	args := m.Called(c, labels)
+	return args.Error(0)
+}
+
+// OOMContainer mock
+func (m *MockClient) OOMContainer(c Container, timeout time.Duration) error {
+	args := m.Called(c, timeout)
+	return args.Error(0)
+}
+
+// ExhaustFDsContainer mock
+func (m *MockClient) ExhaustFDsContainer(c Container, free int, d time.Duration) error {
+	args := m.Called(c, free, d)
+	return args.Error(0)
+}
+
+// HealthcheckContainer mock
+func (m *MockClient) HealthcheckContainer(c Container, probe health.Probe, d time.Duration) error {
+	args := m.Called(c, probe, d)
+	return args.Error(0)
+}
+
+// MemoryPressureContainer mock
+func (m *MockClient) MemoryPressureContainer(c Container, percent int, d time.Duration) error {
+	args := m.Called(c, percent, d)
+	return args.Error(0)
+}
+
+// CorruptEnvContainer mock
+func (m *MockClient) CorruptEnvContainer(c Container, set []string, unset []string, timeout int, d time.Duration) error {
+	args := m.Called(c, set, unset, timeout, d)
+	return args.Error(0)
+}
+
+// RemoveSecretsContainer mock
+func (m *MockClient) RemoveSecretsContainer(c Container, secrets []string, configs []string, d time.Duration) error {
+	args := m.Called(c, secrets, configs, d)
+	return args.Error(0)
+}
+
+// PoisonImageContainer mock
+func (m *MockClient) PoisonImageContainer(c Container, image string, d time.Duration) error {
+	args := m.Called(c, image, d)
+	return args.Error(0)
+}
+
+// CommitContainer mock
+func (m *MockClient) CommitContainer(c Container, reference string, labels map[string]string) (string, error) {
+	args := m.Called(c, reference, labels)
+	return args.String(0), args.Error(1)
+}
+
+// ListImages mock
+func (m *MockClient) ListImages(label string) ([]Image, error) {
+	args := m.Called(label)
+	return args.Get(0).([]Image), args.Error(1)
+}
+
+// DeleteImage mock
+func (m *MockClient) DeleteImage(image string, force bool) error {
+	args := m.Called(image, force)
+	return args.Error(0)
+}
+
+// InjectTools mock
+func (m *MockClient) InjectTools(c Container, dir string) error {
+	args := m.Called(c, dir)
+	return args.Error(0)
+}
+
+// RemoveTools mock
+func (m *MockClient) RemoveTools(c Container) error {
+	args := m.Called(c)
+	return args.Error(0)
+}
+
+// DryRun mock
+func (m *MockClient) DryRun() bool {
+	return m.Dry
+}
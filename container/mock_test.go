@@ -3,6 +3,9 @@ package container
 import (
 	"reflect"
 	"testing"
+
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestMockInterface(t *testing.T) {
@@ -13,3 +16,20 @@ func TestMockInterface(t *testing.T) {
 		t.Fatalf("Mock does not implement the Client interface")
 	}
 }
+
+func TestNewMockContainer(t *testing.T) {
+	c := NewMockContainer("abc123", "/api_1", map[string]string{"zone": "a"},
+		MockImage("api:1.2"),
+		MockHostConfig(dockerclient.HostConfig{Links: []string{"/db_1:/api_1/db"}}),
+		MockNetwork("backend", "10.0.0.1"))
+
+	assert.Equal(t, "abc123", c.ID())
+	assert.Equal(t, "/api_1", c.Name())
+	zone, _ := c.Label("zone")
+	assert.Equal(t, "a", zone)
+	assert.Equal(t, "api:1.2", c.ImageName())
+	assert.Equal(t, []string{"/db_1:/api_1/db"}, c.containerInfo.HostConfig.Links)
+	ip, ok := c.NetworkIP("backend")
+	assert.True(t, ok)
+	assert.Equal(t, "10.0.0.1", ip.String())
+}
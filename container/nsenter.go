@@ -0,0 +1,65 @@
+package container
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// nsenterFunc runs command in network namespace of process with specified PID and returns its combined output
+type nsenterFunc func(pid int, cmd []string) ([]byte, error)
+
+// errors returned by Docker daemon when exec cannot be used: older API without exec support,
+// exec disabled by daemon or authorization plugin rejecting (privileged) exec; patterns are anchored to the start
+// of daemon error message, so errors of command itself (like 'Operation not supported') do not match
+var execUnsupportedErrors = []*regexp.Regexp{
+	regexp.MustCompile(`^(404 )?page not found$`),
+	regexp.MustCompile(`^exec (is disabled|is not supported|not supported)\b`),
+	regexp.MustCompile(`^authorization denied by plugin\b`),
+	regexp.MustCompile(`^privileged mode is (disabled|incompatible with user namespaces)\b`),
+}
+
+// daemonErrorPrefix prefix of Docker daemon error responses
+const daemonErrorPrefix = "error response from daemon: "
+
+// isExecUnsupported checks if error means that container exec is not available
+func isExecUnsupported(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(err.Error())), daemonErrorPrefix)
+	for _, re := range execUnsupportedErrors {
+		if re.MatchString(msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// getNsenter returns client nsenter executor; host nsenter if not set
+func (client dockerClient) getNsenter() nsenterFunc {
+	if client.nsenter == nil {
+		return runNsenter
+	}
+	return client.nsenter
+}
+
+// nsenterOnContainer runs command in network namespace of container main process
+func (client dockerClient) nsenterOnContainer(c Container, cmd []string, execErr error) ([]byte, error) {
+	pid := 0
+	if c.containerInfo != nil && c.containerInfo.State != nil {
+		pid = c.containerInfo.State.Pid
+	}
+	if pid == 0 {
+		return nil, fmt.Errorf("Failed to exec in container %s: %s; unknown container PID for nsenter fallback", c.ID(), execErr)
+	}
+	log.Warnf("Exec is not available for container %s (%s); falling back to nsenter into host network namespace of PID %d", c.ID(), execErr, pid)
+	log.Debugf("Running '%s' with nsenter (PID %d)", strings.Join(cmd, " "), pid)
+	out, err := client.getNsenter()(pid, cmd)
+	if err != nil {
+		return out, fmt.Errorf("nsenter '%s' failed for container %s: %s: %s", strings.Join(cmd, " "), c.ID(), err, strings.TrimSpace(string(out)))
+	}
+	return out, nil
+}
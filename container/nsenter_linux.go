@@ -0,0 +1,18 @@
+package container
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// runNsenter enters network namespace of host process and runs command there;
+// requires Pumba to run in host PID namespace with enough privileges
+func runNsenter(pid int, cmd []string) ([]byte, error) {
+	path, err := exec.LookPath("nsenter")
+	if err != nil {
+		return nil, fmt.Errorf("nsenter is not installed: %s", err)
+	}
+	args := append([]string{"-t", strconv.Itoa(pid), "-n"}, cmd...)
+	return exec.Command(path, args...).CombinedOutput()
+}
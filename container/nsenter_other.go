@@ -0,0 +1,13 @@
+// +build !linux
+
+package container
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// runNsenter is not supported: network namespaces are available only on Linux Docker host
+func runNsenter(pid int, cmd []string) ([]byte, error) {
+	return nil, fmt.Errorf("nsenter is not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+}
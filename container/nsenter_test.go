@@ -0,0 +1,110 @@
+package container
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/types"
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/net/context"
+)
+
+func TestIsExecUnsupported(t *testing.T) {
+	assert.False(t, isExecUnsupported(nil))
+	assert.False(t, isExecUnsupported(errors.New("No such container: abc123")))
+	assert.True(t, isExecUnsupported(errors.New("Error response from daemon: page not found")))
+	assert.True(t, isExecUnsupported(errors.New("authorization denied by plugin authz: privileged exec")))
+	assert.True(t, isExecUnsupported(errors.New("Privileged mode is disabled")))
+	assert.True(t, isExecUnsupported(errors.New("404 page not found")))
+	assert.True(t, isExecUnsupported(errors.New("Exec is not supported by the lxc driver")))
+	// errors of command or container, that only mention the same words
+	assert.False(t, isExecUnsupported(errors.New("RTNETLINK answers: Operation not supported")))
+	assert.False(t, isExecUnsupported(errors.New("Error response from daemon: Container abc123 is not running: privileged exec requested")))
+	assert.False(t, isExecUnsupported(errors.New("Error: image page not found in registry")))
+}
+
+func TestNetemContainer_NsenterFallback(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id:    "abc123",
+			State: &dockerclient.State{Pid: 4242},
+		},
+	}
+
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerExecCreate", context.Background(), "abc123", mock.Anything).Return(types.ContainerExecCreateResponse{}, errors.New("authorization denied by plugin"))
+
+	var pids []int
+	var cmds [][]string
+	// qdiscs of container network namespace
+	qdiscs := "qdisc noqueue 0: root refcnt 2\n"
+	nsenter := func(pid int, cmd []string) ([]byte, error) {
+		pids = append(pids, pid)
+		cmds = append(cmds, cmd)
+		switch cmd[2] {
+		case "replace":
+			qdiscs = "qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms\n"
+		case "del":
+			qdiscs = "qdisc noqueue 0: root refcnt 2\n"
+		}
+		return []byte(qdiscs), nil
+	}
+
+	client := dockerClient{apiClient: engineClient, nsenter: nsenter}
+	err := client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{}, 1*time.Millisecond, false, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{4242, 4242, 4242, 4242, 4242}, pids)
+	assert.Equal(t, [][]string{
+		{"tc", "qdisc", "show", "dev", "eth0"},
+		{"tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "100ms"},
+		{"tc", "qdisc", "show", "dev", "eth0"},
+		{"tc", "qdisc", "del", "dev", "eth0", "root", "netem"},
+		{"tc", "qdisc", "show", "dev", "eth0"},
+	}, cmds)
+	engineClient.AssertNotCalled(t, "ContainerExecStart", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestNetemContainer_NsenterFallbackNoPid(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerExecCreate", context.Background(), "abc123", mock.Anything).Return(types.ContainerExecCreateResponse{}, errors.New("page not found"))
+
+	client := dockerClient{apiClient: engineClient}
+	err := client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{}, 1*time.Millisecond, false, "")
+
+	assert.EqualError(t, err, "Failed to exec in container abc123: page not found; unknown container PID for nsenter fallback")
+}
+
+func TestNetemContainer_ExecError(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id:    "abc123",
+			State: &dockerclient.State{Pid: 4242},
+		},
+	}
+
+	engineClient := NewMockEngine()
+	engineClient.On("ContainerExecCreate", context.Background(), "abc123", mock.Anything).Return(types.ContainerExecCreateResponse{}, errors.New("No such container: abc123"))
+
+	called := false
+	nsenter := func(pid int, cmd []string) ([]byte, error) {
+		called = true
+		return nil, nil
+	}
+
+	client := dockerClient{apiClient: engineClient, nsenter: nsenter}
+	err := client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{}, 1*time.Millisecond, false, "")
+
+	assert.EqualError(t, err, "No such container: abc123")
+	assert.False(t, called)
+}
@@ -0,0 +1,41 @@
+package container
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// WithNamePrefix limits containers, listed by client created with NewClient, to containers with name prefix; other
+// clients are returned as is
+func WithNamePrefix(client Client, prefix string) Client {
+	if c, ok := client.(dockerClient); ok {
+		c.namePrefix = prefix
+		return c
+	}
+	return client
+}
+
+// nameFilters returns Docker API filters of containers with name prefix: Docker matches 'name' filter as regular
+// expression against container names (with leading slash), so prefix is quoted and anchored; empty - no filters
+func nameFilters(prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+	filters, _ := json.Marshal(map[string][]string{"name": {"^/" + regexp.QuoteMeta(prefix)}})
+	return url.QueryEscape(string(filters))
+}
+
+// hasNamePrefix checks if any container name starts with prefix; older Docker daemons ignore name filter
+func hasNamePrefix(names []string, prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	for _, name := range names {
+		if strings.HasPrefix(strings.TrimPrefix(name, "/"), prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package container
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/samalba/dockerclient"
+	"github.com/samalba/dockerclient/mockclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithNamePrefix(t *testing.T) {
+	client := WithNamePrefix(dockerClient{}, "svc_")
+	assert.Equal(t, "svc_", client.(dockerClient).namePrefix)
+	// decorated clients are not changed
+	mock := &MockClient{}
+	assert.Equal(t, mock, WithNamePrefix(mock, "svc_"))
+}
+
+func TestNameFilters(t *testing.T) {
+	assert.Equal(t, "", nameFilters(""))
+	assert.Equal(t, "%7B%22name%22%3A%5B%22%5E%2Fsvc_%22%5D%7D", nameFilters("svc_"))
+	// prefix is not a regular expression
+	filters, err := url.QueryUnescape(nameFilters("app.v1"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":["^/app\\.v1"]}`, filters)
+}
+
+func TestHasNamePrefix(t *testing.T) {
+	assert.True(t, hasNamePrefix([]string{"/api_1"}, ""))
+	assert.True(t, hasNamePrefix([]string{"/svc_api_1"}, "svc_"))
+	assert.True(t, hasNamePrefix([]string{"/web/svc_db", "/svc_db"}, "svc_"))
+	assert.False(t, hasNamePrefix([]string{"/web/svc_db", "/db"}, "svc_"))
+	assert.False(t, hasNamePrefix([]string{"/api_svc_1"}, "svc_"))
+}
+
+func TestListContainers_NamePrefix(t *testing.T) {
+	ci := &dockerclient.ContainerInfo{Image: "abc123", Config: &dockerclient.ContainerConfig{Image: "img"}}
+	ii := &dockerclient.ImageInfo{}
+	api := mockclient.NewMockClient()
+	// daemon, that ignores name filter, returns all containers
+	api.On("ListContainers", false, false, nameFilters("svc_")).Return([]dockerclient.Container{
+		{Id: "foo", Names: []string{"/svc_foo"}},
+		{Id: "bar", Names: []string{"/bar"}},
+	}, nil)
+	api.On("InspectContainer", "foo").Return(ci, nil)
+	api.On("InspectImage", "abc123").Return(ii, nil)
+
+	client := dockerClient{api: api, namePrefix: "svc_"}
+	cs, err := client.ListContainers(allContainers)
+
+	assert.NoError(t, err)
+	assert.Len(t, cs, 1)
+	api.AssertExpectations(t)
+	api.AssertNotCalled(t, "InspectContainer", "bar")
+}
@@ -0,0 +1,84 @@
+package container
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// ProxyFunc returns proxy selection function for HTTP transports, created by Pumba:
+// empty proxyURL - proxy from HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables;
+// otherwise proxyURL overrides HTTP_PROXY and HTTPS_PROXY, but hosts listed in NO_PROXY are still reached directly
+func ProxyFunc(proxyURL string) (func(*http.Request) (*url.URL, error), error) {
+	if proxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("Invalid proxy URL '%s'", proxyURL)
+	}
+	return func(req *http.Request) (*url.URL, error) {
+		if !useProxy(req.URL.Host, getEnvAny("NO_PROXY", "no_proxy")) {
+			return nil, nil
+		}
+		return u, nil
+	}, nil
+}
+
+// useProxy reports whether requests to host (host or host:port) should use proxy, according to NO_PROXY list
+func useProxy(host, noProxy string) bool {
+	if host == "" {
+		return true
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if host == "localhost" {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return false
+	}
+	for _, p := range strings.Split(noProxy, ",") {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" {
+			continue
+		}
+		if p == "*" {
+			return false
+		}
+		if h, _, err := net.SplitHostPort(p); err == nil {
+			p = h
+		}
+		// '.example.com' and 'example.com' match example.com and all its sub-domains
+		p = strings.TrimPrefix(p, ".")
+		if host == p || strings.HasSuffix(host, "."+p) {
+			return false
+		}
+	}
+	return true
+}
+
+// getEnvAny returns value of first non-empty environment variable
+func getEnvAny(names ...string) string {
+	for _, n := range names {
+		if v := os.Getenv(n); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// setProxy sets proxy of Docker client HTTP transport; Unix socket and named pipe connections do not use proxy
+func setProxy(client *http.Client, dockerHost string, proxy func(*http.Request) (*url.URL, error)) {
+	if proxy == nil || client == nil || strings.HasPrefix(dockerHost, "unix://") || strings.HasPrefix(dockerHost, "npipe://") {
+		return
+	}
+	if t, ok := client.Transport.(*http.Transport); ok {
+		t.Proxy = proxy
+	}
+}
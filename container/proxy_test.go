@@ -0,0 +1,53 @@
+package container
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUseProxy(t *testing.T) {
+	noProxy := "internal.corp, .svc.local,10.0.0.5:2376"
+	assert.True(t, useProxy("hooks.slack.com", noProxy))
+	assert.True(t, useProxy("hooks.slack.com:443", noProxy))
+	assert.False(t, useProxy("internal.corp", noProxy))
+	assert.False(t, useProxy("docker.internal.corp:2376", noProxy))
+	assert.True(t, useProxy("notinternal.corp", noProxy))
+	assert.False(t, useProxy("db.svc.local", noProxy))
+	assert.False(t, useProxy("10.0.0.5:2376", noProxy))
+	assert.False(t, useProxy("localhost:2375", noProxy))
+	assert.False(t, useProxy("127.0.0.1:2375", ""))
+	assert.False(t, useProxy("hooks.slack.com", "*"))
+}
+
+func TestProxyFunc_Override(t *testing.T) {
+	defer os.Setenv("NO_PROXY", os.Getenv("NO_PROXY"))
+	os.Setenv("NO_PROXY", "docker.internal.corp")
+	proxy, err := ProxyFunc("http://proxy.corp:3128")
+	assert.NoError(t, err)
+	req, _ := http.NewRequest("POST", "https://hooks.slack.com/services/T0/B0/X", nil)
+	u, err := proxy(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://proxy.corp:3128", u.String())
+	req, _ = http.NewRequest("GET", "https://docker.internal.corp:2376/containers/json", nil)
+	u, err = proxy(req)
+	assert.NoError(t, err)
+	assert.Nil(t, u)
+}
+
+func TestProxyFunc_Invalid(t *testing.T) {
+	_, err := ProxyFunc("proxy.corp")
+	assert.EqualError(t, err, "Invalid proxy URL 'proxy.corp'")
+}
+
+func TestSetProxy(t *testing.T) {
+	proxy, _ := ProxyFunc("http://proxy.corp:3128")
+	tcp := &http.Client{Transport: &http.Transport{}}
+	setProxy(tcp, "tcp://docker.corp:2376", proxy)
+	assert.NotNil(t, tcp.Transport.(*http.Transport).Proxy)
+	unix := &http.Client{Transport: &http.Transport{}}
+	setProxy(unix, "unix:///var/run/docker.sock", proxy)
+	assert.Nil(t, unix.Transport.(*http.Transport).Proxy)
+}
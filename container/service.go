@@ -0,0 +1,205 @@
+package container
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// serviceAPI Docker Swarm service API; service spec is handled as raw JSON, since engine-api service spec has no
+// secrets and configs, and typed update would drop them and other unknown fields
+type serviceAPI interface {
+	// InspectService returns service version and spec
+	InspectService(id string) (uint64, map[string]interface{}, error)
+	// UpdateService updates service spec of specified version
+	UpdateService(id string, version uint64, spec map[string]interface{}) error
+}
+
+// httpServiceAPI calls Docker service API with HTTP client of Docker client
+type httpServiceAPI struct {
+	client *http.Client
+	url    *url.URL
+}
+
+func (api httpServiceAPI) do(method, path string, body interface{}, result interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, strings.TrimSuffix(api.url.String(), "/")+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := api.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	payload, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		var msg struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(payload, &msg) == nil && msg.Message != "" {
+			return fmt.Errorf("Docker API error %d: %s", resp.StatusCode, msg.Message)
+		}
+		return fmt.Errorf("Docker API error %d: %s", resp.StatusCode, strings.TrimSpace(string(payload)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(payload, result)
+}
+
+func (api httpServiceAPI) InspectService(id string) (uint64, map[string]interface{}, error) {
+	var service struct {
+		Version struct{ Index uint64 }
+		Spec    map[string]interface{}
+	}
+	if err := api.do("GET", "/services/"+url.QueryEscape(id), nil, &service); err != nil {
+		return 0, nil, err
+	}
+	return service.Version.Index, service.Spec, nil
+}
+
+func (api httpServiceAPI) UpdateService(id string, version uint64, spec map[string]interface{}) error {
+	path := "/services/" + url.QueryEscape(id) + "/update?version=" + strconv.FormatUint(version, 10)
+	return api.do("POST", path, spec, nil)
+}
+
+// serviceReferences kinds of service references, removed by RemoveSecretsContainer: key of reference list in
+// container spec and key of referenced name
+var serviceReferences = []struct {
+	kind, list, name string
+}{
+	{"secret", "Secrets", "SecretName"},
+	{"config", "Configs", "ConfigName"},
+}
+
+// containerSpec returns container spec of service spec ('TaskTemplate.ContainerSpec')
+func containerSpec(spec map[string]interface{}) (map[string]interface{}, error) {
+	template, _ := spec["TaskTemplate"].(map[string]interface{})
+	containerSpec, _ := template["ContainerSpec"].(map[string]interface{})
+	if containerSpec == nil {
+		return nil, fmt.Errorf("Service %v has no container spec", spec["Name"])
+	}
+	return containerSpec, nil
+}
+
+// referenceName returns referenced secret or config name of service reference
+func referenceName(ref interface{}, key string) string {
+	m, _ := ref.(map[string]interface{})
+	name, _ := m[key].(string)
+	return name
+}
+
+// removeReferences removes secrets and configs, referenced by name, from container spec of service; returns
+// removed references by list key ('Secrets', 'Configs'); fails, if service does not reference some of names
+func removeReferences(spec map[string]interface{}, names map[string][]string) (map[string][]interface{}, error) {
+	cs, err := containerSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	removed := map[string][]interface{}{}
+	for _, r := range serviceReferences {
+		refs, _ := cs[r.list].([]interface{})
+		for _, name := range names[r.kind] {
+			var kept []interface{}
+			found := false
+			for _, ref := range refs {
+				if referenceName(ref, r.name) == name {
+					removed[r.list] = append(removed[r.list], ref)
+					found = true
+				} else {
+					kept = append(kept, ref)
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("Service %v has no %s '%s'", spec["Name"], r.kind, name)
+			}
+			refs = kept
+		}
+		if len(removed[r.list]) > 0 {
+			cs[r.list] = refs
+		}
+	}
+	return removed, nil
+}
+
+// restoreReferences adds removed secrets and configs back to container spec of service, unless service references
+// them again already (e.g. re-deployed during chaos)
+func restoreReferences(spec map[string]interface{}, removed map[string][]interface{}) error {
+	cs, err := containerSpec(spec)
+	if err != nil {
+		return err
+	}
+	for _, r := range serviceReferences {
+		refs, _ := cs[r.list].([]interface{})
+	next:
+		for _, ref := range removed[r.list] {
+			for _, existing := range refs {
+				if referenceName(existing, r.name) == referenceName(ref, r.name) {
+					continue next
+				}
+			}
+			refs = append(refs, ref)
+		}
+		if len(refs) > 0 {
+			cs[r.list] = refs
+		}
+	}
+	return nil
+}
+
+// RemoveSecretsContainer removes secrets and configs from spec of Swarm service of container (task) for specified
+// duration and restores them afterwards; service update re-deploys service tasks without removed secrets and
+// configs mounted
+func (client dockerClient) RemoveSecretsContainer(c Container, secrets []string, configs []string, duration time.Duration) (err error) {
+	client = client.timed("secrets")
+	defer wrapError("secrets", c, &err)
+	service := c.SwarmService()
+	if service == "" {
+		return fmt.Errorf("Container %s is not a task of Swarm service", c.Name())
+	}
+	version, spec, err := client.services.InspectService(service)
+	if err != nil {
+		return err
+	}
+	removed, err := removeReferences(spec, map[string][]string{"secret": secrets, "config": configs})
+	if err != nil {
+		return err
+	}
+	log.Infof("Removing secrets %v and configs %v from Swarm service %s of container %s for %s", secrets, configs, service, c.Name(), duration)
+	if err = client.services.UpdateService(service, version, spec); err != nil {
+		return err
+	}
+	log.Debugf("Secrets and configs of Swarm service %s removed for %s", service, duration)
+	client.getClock().Sleep(duration)
+	// service version is changed by update (and possibly by others): restore on the latest spec
+	if version, spec, err = client.services.InspectService(service); err != nil {
+		return fmt.Errorf("Failed to restore secrets and configs of Swarm service %s: %s", service, err)
+	}
+	if err = restoreReferences(spec, removed); err != nil {
+		return err
+	}
+	log.Infof("Restoring secrets and configs of Swarm service %s", service)
+	if err = client.services.UpdateService(service, version, spec); err != nil {
+		return fmt.Errorf("Failed to restore secrets and configs of Swarm service %s: %s", service, err)
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package container
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/clock"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+const testServiceSpec = `{
+	"Name": "web",
+	"Labels": {"tier": "front"},
+	"TaskTemplate": {
+		"ContainerSpec": {
+			"Image": "nginx",
+			"Secrets": [
+				{"SecretID": "s1", "SecretName": "db-password", "File": {"Name": "db-password"}},
+				{"SecretID": "s2", "SecretName": "api-key", "File": {"Name": "api-key"}}
+			],
+			"Configs": [{"ConfigID": "c1", "ConfigName": "nginx-conf"}]
+		}
+	}
+}`
+
+func testSpec() map[string]interface{} {
+	var spec map[string]interface{}
+	json.Unmarshal([]byte(testServiceSpec), &spec)
+	return spec
+}
+
+// referenceNames returns names of secrets or configs in container spec of service
+func referenceNames(spec map[string]interface{}, list, key string) []string {
+	cs, _ := containerSpec(spec)
+	refs, _ := cs[list].([]interface{})
+	names := []string{}
+	for _, ref := range refs {
+		names = append(names, referenceName(ref, key))
+	}
+	return names
+}
+
+func TestRemoveAndRestoreReferences(t *testing.T) {
+	spec := testSpec()
+	removed, err := removeReferences(spec, map[string][]string{"secret": {"db-password"}, "config": {"nginx-conf"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"api-key"}, referenceNames(spec, "Secrets", "SecretName"))
+	assert.Equal(t, []string{}, referenceNames(spec, "Configs", "ConfigName"))
+	// unknown fields are kept
+	assert.Equal(t, map[string]interface{}{"tier": "front"}, spec["Labels"])
+
+	assert.NoError(t, restoreReferences(spec, removed))
+	assert.Equal(t, []string{"api-key", "db-password"}, referenceNames(spec, "Secrets", "SecretName"))
+	assert.Equal(t, []string{"nginx-conf"}, referenceNames(spec, "Configs", "ConfigName"))
+	// restored twice: no duplicates
+	assert.NoError(t, restoreReferences(spec, removed))
+	assert.Equal(t, []string{"api-key", "db-password"}, referenceNames(spec, "Secrets", "SecretName"))
+
+	_, err = removeReferences(testSpec(), map[string][]string{"secret": {"tls-key"}})
+	assert.EqualError(t, err, "Service web has no secret 'tls-key'")
+	_, err = removeReferences(map[string]interface{}{"Name": "web"}, nil)
+	assert.EqualError(t, err, "Service web has no container spec")
+}
+
+// fakeServiceAPI Swarm service API, that keeps single service spec in memory
+type fakeServiceAPI struct {
+	version uint64
+	spec    string
+	updates []string
+	err     error
+}
+
+func (api *fakeServiceAPI) InspectService(id string) (uint64, map[string]interface{}, error) {
+	var spec map[string]interface{}
+	json.Unmarshal([]byte(api.spec), &spec)
+	return api.version, spec, nil
+}
+
+func (api *fakeServiceAPI) UpdateService(id string, version uint64, spec map[string]interface{}) error {
+	if api.err != nil {
+		return api.err
+	}
+	data, _ := json.Marshal(spec)
+	api.updates = append(api.updates, id)
+	api.spec = string(data)
+	api.version++
+	return nil
+}
+
+func swarmTask(service string) Container {
+	return Container{containerInfo: &dockerclient.ContainerInfo{
+		Id:     "abc123",
+		Name:   "/web.1.xyz",
+		Config: &dockerclient.ContainerConfig{Labels: map[string]string{swarmServiceIDLabel: service}},
+	}}
+}
+
+func TestRemoveSecretsContainer(t *testing.T) {
+	api := &fakeServiceAPI{version: 10, spec: testServiceSpec}
+	fake := clock.NewFake(time.Now())
+	client := dockerClient{services: api, clock: fake}
+
+	done := make(chan error)
+	go func() {
+		done <- client.RemoveSecretsContainer(swarmTask("svc1"), []string{"db-password"}, nil, time.Minute)
+	}()
+	fake.BlockUntil(1)
+	var spec map[string]interface{}
+	json.Unmarshal([]byte(api.spec), &spec)
+	assert.Equal(t, []string{"api-key"}, referenceNames(spec, "Secrets", "SecretName"))
+	fake.Advance(time.Minute)
+	assert.NoError(t, <-done)
+	json.Unmarshal([]byte(api.spec), &spec)
+	assert.Equal(t, []string{"api-key", "db-password"}, referenceNames(spec, "Secrets", "SecretName"))
+	assert.Equal(t, []string{"svc1", "svc1"}, api.updates)
+	assert.Equal(t, uint64(12), api.version)
+}
+
+func TestRemoveSecretsContainer_Errors(t *testing.T) {
+	client := dockerClient{services: &fakeServiceAPI{spec: testServiceSpec, err: errors.New("update out of sequence")}}
+	err := client.RemoveSecretsContainer(swarmTask(""), []string{"db-password"}, nil, time.Minute)
+	assert.EqualError(t, err, "Container /web.1.xyz is not a task of Swarm service")
+	err = client.RemoveSecretsContainer(swarmTask("svc1"), nil, []string{"app-conf"}, time.Minute)
+	assert.EqualError(t, err, "Service web has no config 'app-conf'")
+	err = client.RemoveSecretsContainer(swarmTask("svc1"), []string{"db-password"}, nil, time.Minute)
+	assert.EqualError(t, err, "update out of sequence")
+}
+
+func TestHTTPServiceAPI(t *testing.T) {
+	var updated string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/services/svc1":
+			w.Write([]byte(`{"ID": "svc1", "Version": {"Index": 42}, "Spec": ` + testServiceSpec + `}`))
+		case r.Method == "POST" && r.URL.Path == "/services/svc1/update" && r.URL.Query().Get("version") == "42":
+			body, _ := ioutil.ReadAll(r.Body)
+			updated = string(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "service svc2 not found"}`))
+		}
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+	api := httpServiceAPI{client: http.DefaultClient, url: u}
+
+	version, spec, err := api.InspectService("svc1")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), version)
+	assert.Equal(t, "web", spec["Name"])
+	assert.NoError(t, api.UpdateService("svc1", 42, spec))
+	assert.Contains(t, updated, `"SecretName":"db-password"`)
+	_, _, err = api.InspectService("svc2")
+	assert.EqualError(t, err, "Docker API error 404: service svc2 not found")
+}
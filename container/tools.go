@@ -0,0 +1,192 @@
+package container
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	enginetypes "github.com/docker/engine-api/types"
+	"golang.org/x/net/context"
+)
+
+const (
+	// container directory for injected tools; '/tmp' exists in most images and is writable by root
+	toolsParentDir = "/tmp"
+	toolsDirName   = ".pumba-tools"
+	// timeout of injected tools removal
+	removeToolsTimeout = time.Minute
+)
+
+// HelperTool name of Pumba binary, injected into container as helper (e.g. 'pumba fd-helper')
+const HelperTool = "pumba"
+
+// toolsDir container directory with tools injected by Pumba
+var toolsDir = path.Join(toolsParentDir, toolsDirName)
+
+// toolRegistry keeps names of tools injected into containers; shared by copies of dockerClient
+type toolRegistry struct {
+	sync.Mutex
+	tools map[string]map[string]bool
+}
+
+func newToolRegistry() *toolRegistry {
+	return &toolRegistry{tools: make(map[string]map[string]bool)}
+}
+
+func (r *toolRegistry) set(id string, tools map[string]bool) {
+	if r == nil {
+		return
+	}
+	r.Lock()
+	defer r.Unlock()
+	if tools == nil {
+		delete(r.tools, id)
+	} else {
+		r.tools[id] = tools
+	}
+}
+
+func (r *toolRegistry) get(id string) map[string]bool {
+	if r == nil {
+		return nil
+	}
+	r.Lock()
+	defer r.Unlock()
+	return r.tools[id]
+}
+
+// InjectTools copies helper binaries (e.g. static tc, iptables or busybox) from host directory into container
+// with CopyToContainer API; till RemoveTools, commands exec-ed in container run injected tools, if available
+func (client dockerClient) InjectTools(c Container, dir string) (err error) {
+	client = client.timed("tools")
+	defer wrapError("tools", c, &err)
+	archive, tools, err := toolsArchive(dir)
+	if err != nil {
+		return err
+	}
+	log.Infof("Injecting tools (%s) from '%s' into %s of container %s", strings.Join(sortedToolNames(tools), ", "), dir, toolsDir, c.ID())
+	if err = client.apiClient.CopyToContainer(context.Background(), c.ID(), toolsParentDir, archive, enginetypes.CopyToContainerOptions{}); err != nil {
+		return fmt.Errorf("Failed to inject tools into container %s: %s", c.ID(), err)
+	}
+	client.tools.set(c.ID(), tools)
+	return nil
+}
+
+// RemoveTools removes tools, injected by InjectTools, from container
+func (client dockerClient) RemoveTools(c Container) (err error) {
+	client = client.timed("tools")
+	defer wrapError("tools", c, &err)
+	log.Infof("Removing injected tools from container %s", c.ID())
+	code, err := client.ExecContainer(c, []string{"rm", "-rf", toolsDir}, removeToolsTimeout)
+	client.tools.set(c.ID(), nil)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return &ErrExecFailed{Command: "Removal of " + toolsDir, ContainerID: c.ID(), ExitCode: code}
+	}
+	return nil
+}
+
+// HelperTools creates temporary tools directory with link to running Pumba binary, which is injected into
+// containers as HelperTool; Pumba must be static Linux binary of container architecture (like release binaries).
+// Caller removes the directory.
+func HelperTools() (string, error) {
+	if runtime.GOOS != "linux" {
+		return "", fmt.Errorf("Pumba helper can not be injected into container from %s/%s: Linux binary is required", runtime.GOOS, runtime.GOARCH)
+	}
+	exe, err := os.Readlink("/proc/self/exe")
+	if err != nil {
+		return "", fmt.Errorf("Failed to find Pumba binary: %s", err)
+	}
+	dir, err := ioutil.TempDir("", "pumba-helper")
+	if err != nil {
+		return "", err
+	}
+	if err = os.Symlink(exe, filepath.Join(dir, HelperTool)); err != nil {
+		os.RemoveAll(dir)
+		return "", err
+	}
+	return dir, nil
+}
+
+// toolCommand replaces command with tool injected into container, if any; PATH of shell scripts ('sh -c')
+// is prefixed with injected tools directory
+func (client dockerClient) toolCommand(c Container, cmd []string) []string {
+	tools := client.tools.get(c.ID())
+	if len(tools) == 0 || len(cmd) == 0 {
+		return cmd
+	}
+	out := append([]string{}, cmd...)
+	if tools[out[0]] {
+		out[0] = path.Join(toolsDir, out[0])
+	}
+	if len(out) == 3 && path.Base(out[0]) == "sh" && out[1] == "-c" {
+		out[2] = fmt.Sprintf("PATH=%s:$PATH; %s", toolsDir, out[2])
+	}
+	return out
+}
+
+// toolsArchive returns tar archive with regular files (symlinks are followed) of host directory,
+// placed in tools directory, and names of archived tools
+func toolsArchive(dir string) (*bytes.Buffer, map[string]bool, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to read tools directory: %s", err)
+	}
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	if err = tw.WriteHeader(&tar.Header{Name: toolsDirName + "/", Mode: 0755, Typeflag: tar.TypeDir}); err != nil {
+		return nil, nil, err
+	}
+	tools := make(map[string]bool)
+	for _, f := range files {
+		name := filepath.Join(dir, f.Name())
+		// follow symlinks, e.g. busybox applets
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		data, err := ioutil.ReadFile(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		hdr := &tar.Header{Name: toolsDirName + "/" + f.Name(), Mode: 0755, Size: int64(len(data)), Typeflag: tar.TypeReg}
+		if err = tw.WriteHeader(hdr); err != nil {
+			return nil, nil, err
+		}
+		if _, err = tw.Write(data); err != nil {
+			return nil, nil, err
+		}
+		tools[f.Name()] = true
+	}
+	if len(tools) == 0 {
+		return nil, nil, fmt.Errorf("No tools found in directory '%s'", dir)
+	}
+	if err = tw.Close(); err != nil {
+		return nil, nil, err
+	}
+	return &buf, tools, nil
+}
+
+func sortedToolNames(tools map[string]bool) []string {
+	names := make([]string, 0, len(tools))
+	for name := range tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
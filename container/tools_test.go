@@ -0,0 +1,170 @@
+package container
+
+import (
+	"archive/tar"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/docker/engine-api/types"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/net/context"
+)
+
+func makeToolsDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "pumba-tools")
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "tc"), []byte("tc binary"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "busybox"), []byte("busybox binary"), 0755))
+	assert.NoError(t, os.Symlink(filepath.Join(dir, "busybox"), filepath.Join(dir, "sh")))
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "lib"), 0755))
+	return dir
+}
+
+func TestToolsArchive(t *testing.T) {
+	dir := makeToolsDir(t)
+	defer os.RemoveAll(dir)
+
+	archive, tools, err := toolsArchive(dir)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"tc": true, "busybox": true, "sh": true}, tools)
+	tr := tar.NewReader(archive)
+	content := map[string]string{}
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		data, _ := ioutil.ReadAll(tr)
+		content[hdr.Name] = string(data)
+	}
+	assert.Equal(t, map[string]string{
+		".pumba-tools/":        "",
+		".pumba-tools/busybox": "busybox binary",
+		".pumba-tools/sh":      "busybox binary",
+		".pumba-tools/tc":      "tc binary",
+	}, content)
+}
+
+func TestToolsArchive_Empty(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "pumba-tools")
+	defer os.RemoveAll(dir)
+
+	_, _, err := toolsArchive(dir)
+
+	assert.EqualError(t, err, "No tools found in directory '"+dir+"'")
+}
+
+func TestInjectTools(t *testing.T) {
+	dir := makeToolsDir(t)
+	defer os.RemoveAll(dir)
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Name: "api1",
+			Id:   "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	engineClient.On("CopyToContainer", ctx, "abc123", "/tmp", mock.Anything, types.CopyToContainerOptions{}).Return(nil)
+	rm := types.ExecConfig{Cmd: []string{"rm", "-rf", "/tmp/.pumba-tools"}}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", rm).Return(types.ContainerExecCreateResponse{ID: "e1"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "e1", types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", ctx, "e1").Return(types.ContainerExecInspect{ExecID: "e1"}, nil)
+
+	client := dockerClient{apiClient: engineClient, tools: newToolRegistry()}
+	err := client.InjectTools(c, dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/tmp/.pumba-tools/tc", "qdisc", "show"}, client.toolCommand(c, []string{"tc", "qdisc", "show"}))
+	assert.Equal(t, []string{"ip", "addr"}, client.toolCommand(c, []string{"ip", "addr"}))
+	assert.Equal(t, []string{"/tmp/.pumba-tools/sh", "-c", "PATH=/tmp/.pumba-tools:$PATH; sleep 1"}, client.toolCommand(c, []string{"sh", "-c", "sleep 1"}))
+
+	err = client.RemoveTools(c)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tc", "qdisc", "show"}, client.toolCommand(c, []string{"tc", "qdisc", "show"}))
+	engineClient.AssertExpectations(t)
+}
+
+func TestInjectTools_CopyError(t *testing.T) {
+	dir := makeToolsDir(t)
+	defer os.RemoveAll(dir)
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	engineClient := NewMockEngine()
+	engineClient.On("CopyToContainer", context.Background(), "abc123", "/tmp", mock.Anything, types.CopyToContainerOptions{}).Return(errors.New("read-only file system"))
+
+	client := dockerClient{apiClient: engineClient, tools: newToolRegistry()}
+	err := client.InjectTools(c, dir)
+
+	assert.EqualError(t, err, "Failed to inject tools into container abc123: read-only file system")
+	assert.Equal(t, []string{"tc"}, client.toolCommand(c, []string{"tc"}))
+}
+
+func TestInjectTools_DryRun(t *testing.T) {
+	dir := makeToolsDir(t)
+	defer os.RemoveAll(dir)
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Id: "abc123",
+		},
+	}
+
+	engineClient := NewMockEngine()
+	client := dockerClient{apiClient: engineClient, tools: newToolRegistry()}
+
+	assert.NoError(t, NewDryRunClient(client).InjectTools(c, dir))
+	assert.NoError(t, NewDryRunClient(client).RemoveTools(c))
+	engineClient.AssertNotCalled(t, "CopyToContainer", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	engineClient.AssertNotCalled(t, "ContainerExecCreate", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestHelperTools(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("Pumba helper requires Linux")
+	}
+	dir, err := HelperTools()
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, tools, err := toolsArchive(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{HelperTool: true}, tools)
+}
+
+func TestExhaustFDsContainer(t *testing.T) {
+	c := Container{
+		containerInfo: &dockerclient.ContainerInfo{
+			Name: "api1",
+			Id:   "abc123",
+		},
+	}
+
+	ctx := context.Background()
+	engineClient := NewMockEngine()
+	config := types.ExecConfig{Cmd: []string{"/tmp/.pumba-tools/pumba", "fd-helper", "--duration", "1s", "--free", "2"}}
+	engineClient.On("ContainerExecCreate", ctx, "abc123", config).Return(types.ContainerExecCreateResponse{ID: "e1"}, nil)
+	engineClient.On("ContainerExecStart", ctx, "e1", types.ExecStartCheck{}).Return(nil)
+	engineClient.On("ContainerExecInspect", ctx, "e1").Return(types.ContainerExecInspect{ExecID: "e1", ExitCode: 1}, nil).Once()
+
+	client := dockerClient{apiClient: engineClient, tools: newToolRegistry()}
+	// helper is not injected
+	err := client.ExhaustFDsContainer(c, 2, time.Second)
+	assert.EqualError(t, err, "Pumba helper is not injected into container abc123")
+
+	client.tools.set("abc123", map[string]bool{HelperTool: true})
+	err = client.ExhaustFDsContainer(c, 2, time.Second)
+	assert.EqualError(t, err, "File descriptor exhaustion in container abc123 failed with exit code 1")
+	engineClient.AssertExpectations(t)
+}
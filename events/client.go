@@ -0,0 +1,137 @@
+package events
+
+import (
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/netem"
+)
+
+// eventsClient publishes events of chaos actions: started, succeeded or failed and, for disruptions with
+// duration, cleaned up after successful action; dry runs are published with DryRun flag
+type eventsClient struct {
+	container.Client
+	bus *Bus
+}
+
+// NewClient wraps container client with chaos events publishing
+func NewClient(client container.Client, bus *Bus) container.Client {
+	return eventsClient{Client: client, bus: bus}
+}
+
+// around publishes start and result of action; disruption with duration is removed by action, when it succeeds
+func (client eventsClient) around(c container.Container, action string, duration time.Duration, fn func() error) error {
+	dryrun := client.DryRun()
+	client.bus.Publish(Event{Type: Started, Action: action, Container: c, DryRun: dryrun})
+	err := fn()
+	if err != nil {
+		client.bus.Publish(Event{Type: Failed, Action: action, Container: c, DryRun: dryrun, Err: err})
+		return err
+	}
+	client.bus.Publish(Event{Type: Succeeded, Action: action, Container: c, DryRun: dryrun})
+	if duration > 0 {
+		client.bus.Publish(Event{Type: CleanedUp, Action: action, Container: c, DryRun: dryrun})
+	}
+	return nil
+}
+
+// cleanup publishes removal of disruption by restore call
+func (client eventsClient) cleanup(c container.Container, action string, err error) error {
+	if err == nil {
+		client.bus.Publish(Event{Type: CleanedUp, Action: action, Container: c, DryRun: client.DryRun()})
+	}
+	return err
+}
+
+func (client eventsClient) StopContainer(c container.Container, timeout int) error {
+	return client.around(c, "stop", 0, func() error { return client.Client.StopContainer(c, timeout) })
+}
+
+func (client eventsClient) KillContainer(c container.Container, signal string) error {
+	return client.around(c, "kill", 0, func() error { return client.Client.KillContainer(c, signal) })
+}
+
+func (client eventsClient) RemoveContainer(c container.Container, force bool, links bool, volumes bool) error {
+	return client.around(c, "rm", 0, func() error { return client.Client.RemoveContainer(c, force, links, volumes) })
+}
+
+func (client eventsClient) NetemContainer(c container.Container, netInterface string, netemCmd string, filter netem.Filter, duration time.Duration, reapply bool, probe string) error {
+	return client.around(c, "netem", duration, func() error {
+		return client.Client.NetemContainer(c, netInterface, netemCmd, filter, duration, reapply, probe)
+	})
+}
+
+func (client eventsClient) PauseContainer(c container.Container, duration time.Duration) error {
+	return client.around(c, "pause", duration, func() error { return client.Client.PauseContainer(c, duration) })
+}
+
+func (client eventsClient) SidecarContainer(c container.Container, image string, cmd []string, duration time.Duration) error {
+	return client.around(c, "sidecar", duration, func() error { return client.Client.SidecarContainer(c, image, cmd, duration) })
+}
+
+func (client eventsClient) DropPortsContainer(c container.Container, loss int, duration time.Duration) error {
+	return client.around(c, "ports", duration, func() error { return client.Client.DropPortsContainer(c, loss, duration) })
+}
+
+func (client eventsClient) BlackoutContainer(c container.Container, timeout int, duration time.Duration) error {
+	return client.around(c, "stop", duration, func() error { return client.Client.BlackoutContainer(c, timeout, duration) })
+}
+
+func (client eventsClient) BurnCPUContainer(c container.Container, workers int, duration time.Duration) error {
+	return client.around(c, "cpu", duration, func() error { return client.Client.BurnCPUContainer(c, workers, duration) })
+}
+
+func (client eventsClient) OOMContainer(c container.Container, timeout time.Duration) error {
+	return client.around(c, "oom", 0, func() error { return client.Client.OOMContainer(c, timeout) })
+}
+
+func (client eventsClient) ExhaustFDsContainer(c container.Container, free int, duration time.Duration) error {
+	return client.around(c, "fd", duration, func() error { return client.Client.ExhaustFDsContainer(c, free, duration) })
+}
+
+func (client eventsClient) HealthcheckContainer(c container.Container, probe health.Probe, duration time.Duration) error {
+	return client.around(c, "health", duration, func() error {
+		return client.Client.HealthcheckContainer(c, probe, duration)
+	})
+}
+
+func (client eventsClient) MemoryPressureContainer(c container.Container, percent int, duration time.Duration) error {
+	return client.around(c, "memory", duration, func() error {
+		return client.Client.MemoryPressureContainer(c, percent, duration)
+	})
+}
+
+func (client eventsClient) CorruptEnvContainer(c container.Container, set []string, unset []string, timeout int, duration time.Duration) error {
+	return client.around(c, "env", duration, func() error {
+		return client.Client.CorruptEnvContainer(c, set, unset, timeout, duration)
+	})
+}
+
+func (client eventsClient) RemoveSecretsContainer(c container.Container, secrets []string, configs []string, duration time.Duration) error {
+	return client.around(c, "secrets", duration, func() error {
+		return client.Client.RemoveSecretsContainer(c, secrets, configs, duration)
+	})
+}
+
+func (client eventsClient) PoisonImageContainer(c container.Container, image string, duration time.Duration) error {
+	return client.around(c, "poison-image", duration, func() error {
+		return client.Client.PoisonImageContainer(c, image, duration)
+	})
+}
+
+func (client eventsClient) UnpauseContainer(c container.Container) error {
+	return client.cleanup(c, "pause", client.Client.UnpauseContainer(c))
+}
+
+func (client eventsClient) StopNetemContainer(c container.Container, netInterface string, filter netem.Filter) error {
+	return client.cleanup(c, "netem", client.Client.StopNetemContainer(c, netInterface, filter))
+}
+
+func (client eventsClient) ClearNetemContainer(c container.Container, netInterface string) error {
+	return client.cleanup(c, "netem", client.Client.ClearNetemContainer(c, netInterface))
+}
+
+func (client eventsClient) RestorePortsContainer(c container.Container, loss int) error {
+	return client.cleanup(c, "ports", client.Client.RestorePortsContainer(c, loss))
+}
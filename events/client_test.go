@@ -0,0 +1,50 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func eventTypes(published []Event) []Type {
+	var types []Type
+	for _, e := range published {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestClient_Kill(t *testing.T) {
+	c := container.NewMockContainer("abc", "/api_1", nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("KillContainer", c, "SIGKILL").Return(nil)
+	bus := NewBus()
+	published := recorder(bus)
+	client := NewClient(inner, bus)
+	inner.Dry = true
+	assert.NoError(t, client.KillContainer(c, "SIGKILL"))
+	assert.Equal(t, []Type{Started, Succeeded}, eventTypes(*published))
+	assert.Equal(t, "kill", (*published)[1].Action)
+	assert.True(t, (*published)[1].DryRun)
+	inner.AssertExpectations(t)
+}
+
+func TestClient_PauseCleanUp(t *testing.T) {
+	c := container.NewMockContainer("abc", "/api_1", nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("PauseContainer", c, time.Second).Return(nil).Once()
+	inner.On("PauseContainer", c, 2*time.Second).Return(errors.New("pause failed")).Once()
+	inner.On("UnpauseContainer", c).Return(nil)
+	bus := NewBus()
+	published := recorder(bus)
+	client := NewClient(inner, bus)
+	assert.NoError(t, client.PauseContainer(c, time.Second))
+	assert.EqualError(t, client.PauseContainer(c, 2*time.Second), "pause failed")
+	assert.NoError(t, client.UnpauseContainer(c))
+	assert.Equal(t, []Type{Started, Succeeded, CleanedUp, Started, Failed, CleanedUp}, eventTypes(*published))
+	assert.Equal(t, "pause failed", (*published)[4].Error)
+	inner.AssertExpectations(t)
+}
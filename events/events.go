@@ -0,0 +1,108 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+)
+
+// Type type of chaos event
+type Type string
+
+// chaos event types
+const (
+	// Scheduled chaos command run is started by scheduler
+	Scheduled Type = "scheduled"
+	// Started chaos action on container is started
+	Started Type = "started"
+	// Succeeded chaos action on container succeeded
+	Succeeded Type = "succeeded"
+	// Failed chaos action on container failed
+	Failed Type = "failed"
+	// CleanedUp disruption of container (pause, netem, ports, ...) is removed
+	CleanedUp Type = "cleaned-up"
+	// Skipped container, matching chaos command targets, is skipped; Reason describes why
+	Skipped Type = "skipped"
+	// WarmupComplete warm-up delay after startup is over; chaos command starts
+	WarmupComplete Type = "warmup-complete"
+)
+
+// Event chaos event; Scheduled and WarmupComplete events have Command only, other events describe chaos action on Container
+type Event struct {
+	Type Type      `json:"type"`
+	Time time.Time `json:"time"`
+	// Command chaos command line, like 'kill --signal SIGTERM re2:^api'
+	Command string `json:"command,omitempty"`
+	// Action chaos action: kill, stop, rm, pause, netem, ports, cpu or sidecar
+	Action        string `json:"action,omitempty"`
+	ContainerID   string `json:"container_id,omitempty"`
+	ContainerName string `json:"container_name,omitempty"`
+	DryRun        bool   `json:"dry_run,omitempty"`
+	// Error error message of failed action
+	Error string `json:"error,omitempty"`
+	// Reason why container is skipped
+	Reason string `json:"reason,omitempty"`
+	// Container target container of chaos action
+	Container container.Container `json:"-"`
+	// Err error of failed action
+	Err error `json:"-"`
+}
+
+// Handler handles chaos event
+type Handler func(Event)
+
+type subscription struct {
+	handler Handler
+}
+
+// Bus publishes chaos events to subscribers; handlers are called synchronously, in order of subscription,
+// so slow handlers delay chaos actions
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions []*subscription
+	now           func() time.Time
+}
+
+// NewBus creates event bus without subscribers
+func NewBus() *Bus {
+	return &Bus{now: time.Now}
+}
+
+// Subscribe adds event handler; returns function, that removes the handler
+func (b *Bus) Subscribe(handler Handler) func() {
+	s := &subscription{handler: handler}
+	b.mu.Lock()
+	b.subscriptions = append(b.subscriptions, s)
+	b.mu.Unlock()
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, x := range b.subscriptions {
+			if x == s {
+				b.subscriptions = append(b.subscriptions[:i], b.subscriptions[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Publish sends event to all subscribers; event time, container ID and name and error message are filled in
+func (b *Bus) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = b.now()
+	}
+	if e.Type != Scheduled && e.ContainerID == "" {
+		e.ContainerID = e.Container.ID()
+		e.ContainerName = e.Container.Name()
+	}
+	if e.Err != nil && e.Error == "" {
+		e.Error = e.Err.Error()
+	}
+	b.mu.RLock()
+	subscriptions := append([]*subscription{}, b.subscriptions...)
+	b.mu.RUnlock()
+	for _, s := range subscriptions {
+		s.handler(e)
+	}
+}
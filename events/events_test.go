@@ -0,0 +1,40 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder subscribes to bus and records published events
+func recorder(bus *Bus) *[]Event {
+	var published []Event
+	bus.Subscribe(func(e Event) { published = append(published, e) })
+	return &published
+}
+
+func TestBus_Publish(t *testing.T) {
+	now := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	bus := NewBus()
+	bus.now = func() time.Time { return now }
+	published := recorder(bus)
+	var order []string
+	bus.Subscribe(func(Event) { order = append(order, "first") })
+	unsubscribe := bus.Subscribe(func(Event) { order = append(order, "second") })
+	c := container.NewMockContainer("abc", "/api_1", nil)
+	bus.Publish(Event{Type: Failed, Action: "kill", Container: c, Err: errors.New("oops")})
+	unsubscribe()
+	bus.Publish(Event{Type: Scheduled, Command: "kill re2:^api"})
+	if assert.Len(t, *published, 2) {
+		e := (*published)[0]
+		assert.Equal(t, now, e.Time)
+		assert.Equal(t, "abc", e.ContainerID)
+		assert.Equal(t, "/api_1", e.ContainerName)
+		assert.Equal(t, "oops", e.Error)
+		assert.Equal(t, Event{Type: Scheduled, Time: now, Command: "kill re2:^api"}, (*published)[1])
+	}
+	assert.Equal(t, []string{"first", "second", "first"}, order)
+}
@@ -0,0 +1,113 @@
+// Package fdlimit exhausts file descriptors of container processes: soft open files limit (RLIMIT_NOFILE) of
+// each process is lowered to the number of its open descriptors, so new open(2), socket(2) and accept(2) calls
+// fail with EMFILE ('too many open files'); original limits are restored afterwards. Open files limit is per
+// process, so descriptors of application can not be consumed by another process; lowering the limit has the
+// same effect for application. Package runs inside target container, as injected Pumba helper.
+package fdlimit
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Limit original open files limit of process
+type Limit struct {
+	PID  int
+	Open int
+	Soft uint64
+	Hard uint64
+}
+
+// rlimit resource limit (struct rlimit64)
+type rlimit struct {
+	Cur uint64
+	Max uint64
+}
+
+// procDir proc filesystem of container PID namespace
+var procDir = "/proc"
+
+// prlimit gets (old) and sets (limit) open files limit of process; nil limit - get only
+var prlimit = sysPrlimit
+
+// Exhaust lowers soft open files limit of all processes, except process skip (helper), to number of open
+// descriptors plus free; returns original limits of changed processes. Processes, that can not be changed
+// (e.g. owned by other user or exited), are skipped.
+func Exhaust(free int, skip int) ([]Limit, error) {
+	entries, err := ioutil.ReadDir(procDir)
+	if err != nil {
+		return nil, err
+	}
+	var limits []Limit
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() || pid == skip {
+			continue
+		}
+		fds, err := ioutil.ReadDir(filepath.Join(procDir, e.Name(), "fd"))
+		if err != nil {
+			log.Debugf("Skipping process %d: %s", pid, err)
+			continue
+		}
+		var old rlimit
+		if err = prlimit(pid, nil, &old); err != nil {
+			log.Warnf("Failed to get open files limit of process %d: %s", pid, err)
+			continue
+		}
+		soft := uint64(len(fds) + free)
+		if soft >= old.Cur {
+			log.Infof("Process %d has %d open files of %d allowed: limit is not changed", pid, len(fds), old.Cur)
+			continue
+		}
+		if err = prlimit(pid, &rlimit{Cur: soft, Max: old.Max}, nil); err != nil {
+			log.Warnf("Failed to lower open files limit of process %d: %s", pid, err)
+			continue
+		}
+		log.Infof("Lowered open files limit of process %d from %d to %d (%d open)", pid, old.Cur, soft, len(fds))
+		limits = append(limits, Limit{PID: pid, Open: len(fds), Soft: old.Cur, Hard: old.Max})
+	}
+	if len(limits) == 0 {
+		return nil, errors.New("No process open files limit was lowered")
+	}
+	return limits, nil
+}
+
+// Restore restores original open files limits; exited processes are ignored; returns the first error
+func Restore(limits []Limit) error {
+	var first error
+	for _, l := range limits {
+		err := prlimit(l.PID, &rlimit{Cur: l.Soft, Max: l.Hard}, nil)
+		if err == syscall.ESRCH {
+			continue
+		}
+		if err != nil {
+			log.Warnf("Failed to restore open files limit of process %d: %s", l.PID, err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// Run exhausts file descriptors of all processes, except current one, for duration or till stop signal, and
+// restores open files limits
+func Run(duration time.Duration, free int, stop <-chan os.Signal) error {
+	limits, err := Exhaust(free, os.Getpid())
+	if err != nil {
+		return err
+	}
+	select {
+	case <-time.After(duration):
+	case <-stop:
+	}
+	log.Infof("Restoring open files limit of %d processes", len(limits))
+	return Restore(limits)
+}
@@ -0,0 +1,16 @@
+package fdlimit
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// sysPrlimit calls prlimit64(2) for RLIMIT_NOFILE of process
+func sysPrlimit(pid int, limit *rlimit, old *rlimit) error {
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(syscall.RLIMIT_NOFILE),
+		uintptr(unsafe.Pointer(limit)), uintptr(unsafe.Pointer(old)), 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
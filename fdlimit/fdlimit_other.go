@@ -0,0 +1,13 @@
+// +build !linux
+
+package fdlimit
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// sysPrlimit is not supported: helper runs inside Linux container
+func sysPrlimit(pid int, limit *rlimit, old *rlimit) error {
+	return fmt.Errorf("prlimit is not supported on %s/%s", runtime.GOOS, runtime.GOARCH)
+}
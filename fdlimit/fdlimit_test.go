@@ -0,0 +1,81 @@
+package fdlimit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProc creates proc directory with processes and their open descriptors
+func fakeProc(t *testing.T, open map[int]int) string {
+	dir, err := ioutil.TempDir("", "pumba-proc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for pid, n := range open {
+		fd := filepath.Join(dir, strconv.Itoa(pid), "fd")
+		os.MkdirAll(fd, 0700)
+		for i := 0; i < n; i++ {
+			ioutil.WriteFile(filepath.Join(fd, strconv.Itoa(i)), nil, 0600)
+		}
+	}
+	os.MkdirAll(filepath.Join(dir, "self"), 0700)
+	ioutil.WriteFile(filepath.Join(dir, "uptime"), nil, 0600)
+	return dir
+}
+
+// fakeLimits replaces prlimit with in-memory limits; missing process - ESRCH
+func fakeLimits(limits map[int]rlimit) func() {
+	orig := prlimit
+	prlimit = func(pid int, limit *rlimit, old *rlimit) error {
+		cur, ok := limits[pid]
+		if !ok {
+			return syscall.ESRCH
+		}
+		if old != nil {
+			*old = cur
+		}
+		if limit != nil {
+			limits[pid] = *limit
+		}
+		return nil
+	}
+	return func() { prlimit = orig }
+}
+
+func TestExhaustAndRestore(t *testing.T) {
+	procDir = fakeProc(t, map[int]int{1: 5, 42: 3, 77: 2, 100: 1})
+	defer func() { os.RemoveAll(procDir); procDir = "/proc" }()
+	limits := map[int]rlimit{
+		1:  {Cur: 1024, Max: 4096},
+		42: {Cur: 4, Max: 4096},
+		// 77 exited
+		100: {Cur: 1024, Max: 4096},
+	}
+	defer fakeLimits(limits)()
+
+	changed, err := Exhaust(1, 100)
+	assert.NoError(t, err)
+	// process 42 already has fewer free descriptors; process 100 is helper
+	assert.Equal(t, []Limit{{PID: 1, Open: 5, Soft: 1024, Hard: 4096}}, changed)
+	assert.Equal(t, rlimit{Cur: 6, Max: 4096}, limits[1])
+	assert.Equal(t, rlimit{Cur: 4, Max: 4096}, limits[42])
+	assert.Equal(t, rlimit{Cur: 1024, Max: 4096}, limits[100])
+
+	assert.NoError(t, Restore(append(changed, Limit{PID: 77, Soft: 1024, Hard: 4096})))
+	assert.Equal(t, rlimit{Cur: 1024, Max: 4096}, limits[1])
+}
+
+func TestExhaust_NothingLowered(t *testing.T) {
+	procDir = fakeProc(t, map[int]int{1: 5})
+	defer func() { os.RemoveAll(procDir); procDir = "/proc" }()
+	defer fakeLimits(map[int]rlimit{1: {Cur: 5, Max: 5}})()
+
+	_, err := Exhaust(0, 0)
+	assert.EqualError(t, err, "No process open files limit was lowered")
+}
@@ -0,0 +1,74 @@
+// Package health disrupts container healthcheck probe only: probe binary is replaced with a stub, that always
+// passes (false-healthy container) or always fails, or probe traffic to loopback port is rejected
+package health
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gaia-adm/pumba/teardown"
+)
+
+const (
+	// backupSuffix suffix of original probe binary, moved aside for disruption duration
+	backupSuffix = ".pumba"
+	// ruleComment comment of iptables rules, helps to find rules left by Pumba
+	ruleComment = "pumba"
+)
+
+// stubScript shell script, that writes stub probe binary to path ($0) with specified exit code
+const stubScript = `printf '#!/bin/sh\nexit %d\n' > "$0" && chmod 755 "$0"`
+
+// Probe healthcheck probe of container: probe binary (e.g. /usr/bin/curl) or TCP port, probed on loopback interface
+type Probe struct {
+	// Binary absolute path of probe binary in container, replaced with stub
+	Binary string
+	// Fail stub binary fails (broken healthcheck); otherwise it passes (false-healthy container)
+	Fail bool
+	// Port TCP port of probe; probe traffic to loopback interface is rejected, traffic of other hosts is not affected
+	Port int
+}
+
+// String returns probe disruption description, e.g. 'passing stub of /usr/bin/curl' or 'rejected port 8080'
+func (p Probe) String() string {
+	if p.Binary == "" {
+		return fmt.Sprintf("rejected loopback port %d", p.Port)
+	}
+	if p.Fail {
+		return "failing stub of " + p.Binary
+	}
+	return "passing stub of " + p.Binary
+}
+
+// Pipeline returns named setup steps of probe disruption inside container: original probe binary is moved aside and
+// replaced with stub (stub is overwritten, when original binary is moved back), or loopback port reject rule is added
+func Pipeline(p Probe) teardown.Pipeline {
+	if p.Binary == "" {
+		return teardown.Pipeline{Name: "healthcheck probe port reject rule", Steps: []teardown.Step{{
+			Name:  fmt.Sprintf("reject rule of loopback port %d", p.Port),
+			Apply: rule("-I", p.Port),
+			Undo:  rule("-D", p.Port),
+		}}}
+	}
+	code := 0
+	if p.Fail {
+		code = 1
+	}
+	return teardown.Pipeline{Name: "healthcheck probe binary stub", Steps: []teardown.Step{
+		{
+			Name:  "backup of " + p.Binary,
+			Apply: []string{"mv", "-f", p.Binary, p.Binary + backupSuffix},
+			Undo:  []string{"mv", "-f", p.Binary + backupSuffix, p.Binary},
+		},
+		{
+			Name:  "stub of " + p.Binary,
+			Apply: []string{"sh", "-c", fmt.Sprintf(stubScript, code), p.Binary},
+		},
+	}}
+}
+
+// 'iptables -I INPUT -i lo -p tcp --dport 8080 -m comment --comment pumba -j REJECT --reject-with tcp-reset'
+func rule(op string, port int) []string {
+	return []string{"iptables", op, "INPUT", "-i", "lo", "-p", "tcp", "--dport", strconv.Itoa(port),
+		"-m", "comment", "--comment", ruleComment, "-j", "REJECT", "--reject-with", "tcp-reset"}
+}
@@ -0,0 +1,77 @@
+package health
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func join(cmds [][]string) []string {
+	lines := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		lines[i] = strings.Join(cmd, " ")
+	}
+	return lines
+}
+
+func TestPipeline_Port(t *testing.T) {
+	p := Pipeline(Probe{Port: 8080})
+	assert.Equal(t, []string{
+		"iptables -I INPUT -i lo -p tcp --dport 8080 -m comment --comment pumba -j REJECT --reject-with tcp-reset",
+	}, join(p.ApplyCommands()))
+	assert.Equal(t, []string{
+		"iptables -D INPUT -i lo -p tcp --dport 8080 -m comment --comment pumba -j REJECT --reject-with tcp-reset",
+	}, join(p.TeardownCommands()))
+}
+
+func TestPipeline_Binary(t *testing.T) {
+	p := Pipeline(Probe{Binary: "/usr/bin/curl", Fail: true})
+	cmds := p.ApplyCommands()
+	if assert.Len(t, cmds, 2) {
+		assert.Equal(t, []string{"mv", "-f", "/usr/bin/curl", "/usr/bin/curl.pumba"}, cmds[0])
+		assert.Equal(t, "/usr/bin/curl", cmds[1][3])
+		assert.Contains(t, cmds[1][2], "exit 1")
+	}
+	// stub is overwritten, when original binary is moved back
+	assert.Equal(t, []string{"mv -f /usr/bin/curl.pumba /usr/bin/curl"}, join(p.TeardownCommands()))
+}
+
+func TestPipeline_BinaryRun(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	dir, err := ioutil.TempDir("", "pumba-health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	probe := filepath.Join(dir, "probe")
+	if err = ioutil.WriteFile(probe, []byte("#!/bin/sh\nexit 3\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	run := func(cmd []string) error {
+		return exec.Command(cmd[0], cmd[1:]...).Run()
+	}
+	stack, err := Pipeline(Probe{Binary: probe}).Apply(run)
+	if !assert.NoError(t, err) {
+		return
+	}
+	// false-healthy: stub passes
+	assert.NoError(t, exec.Command(probe).Run())
+	assert.NoError(t, stack.Unwind(run))
+	// original probe is restored
+	assert.Error(t, exec.Command(probe).Run())
+	_, err = os.Stat(probe + backupSuffix)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestProbe_String(t *testing.T) {
+	assert.Equal(t, "passing stub of /usr/bin/curl", Probe{Binary: "/usr/bin/curl"}.String())
+	assert.Equal(t, "failing stub of /usr/bin/curl", Probe{Binary: "/usr/bin/curl", Fail: true}.String())
+	assert.Equal(t, "rejected loopback port 8080", Probe{Port: 8080}.String())
+}
@@ -0,0 +1,132 @@
+package history
+
+import (
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/netem"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// recordingClient records outcomes of chaos actions in experiment history; dry runs are not recorded
+type recordingClient struct {
+	container.Client
+	store *Store
+	now   func() time.Time
+}
+
+// NewClient wraps container client with experiment history recording
+func NewClient(client container.Client, store *Store) container.Client {
+	return recordingClient{Client: client, store: store, now: time.Now}
+}
+
+// record runs chaos action and stores its outcome; history failures are logged and do not fail action
+func (client recordingClient) record(c container.Container, action string, fn func() error) error {
+	if client.DryRun() {
+		return fn()
+	}
+	start := client.now()
+	err := fn()
+	a := Action{Time: start, Duration: client.now().Sub(start), Action: action, ContainerID: c.ID(), ContainerName: c.Name()}
+	if err != nil {
+		a.Error = err.Error()
+	}
+	if herr := client.store.AddAction(a); herr != nil {
+		log.Warnf("Failed to record %s action on container %s in history: %s", action, c.ID(), herr)
+	}
+	return err
+}
+
+// RecordRun returns task, that runs chaos command and stores the run with context (--context) in experiment history
+func RecordRun(store *Store, command string, context map[string]string, task func() error) func() error {
+	return func() error {
+		r := Run{Command: command, Started: time.Now(), Context: context}
+		err := task()
+		r.Finished = time.Now()
+		if err != nil {
+			r.Error = err.Error()
+		}
+		if herr := store.AddRun(r); herr != nil {
+			log.Warnf("Failed to record run of '%s' in history: %s", command, herr)
+		}
+		return err
+	}
+}
+
+func (client recordingClient) StopContainer(c container.Container, timeout int) error {
+	return client.record(c, "stop", func() error { return client.Client.StopContainer(c, timeout) })
+}
+
+func (client recordingClient) KillContainer(c container.Container, signal string) error {
+	return client.record(c, "kill", func() error { return client.Client.KillContainer(c, signal) })
+}
+
+func (client recordingClient) RemoveContainer(c container.Container, force bool, links bool, volumes bool) error {
+	return client.record(c, "rm", func() error { return client.Client.RemoveContainer(c, force, links, volumes) })
+}
+
+func (client recordingClient) NetemContainer(c container.Container, netInterface string, netemCmd string, filter netem.Filter, duration time.Duration, reapply bool, probe string) error {
+	return client.record(c, "netem", func() error {
+		return client.Client.NetemContainer(c, netInterface, netemCmd, filter, duration, reapply, probe)
+	})
+}
+
+func (client recordingClient) PauseContainer(c container.Container, duration time.Duration) error {
+	return client.record(c, "pause", func() error { return client.Client.PauseContainer(c, duration) })
+}
+
+func (client recordingClient) SidecarContainer(c container.Container, image string, cmd []string, duration time.Duration) error {
+	return client.record(c, "sidecar", func() error { return client.Client.SidecarContainer(c, image, cmd, duration) })
+}
+
+func (client recordingClient) DropPortsContainer(c container.Container, loss int, duration time.Duration) error {
+	return client.record(c, "ports", func() error { return client.Client.DropPortsContainer(c, loss, duration) })
+}
+
+func (client recordingClient) BlackoutContainer(c container.Container, timeout int, duration time.Duration) error {
+	return client.record(c, "stop", func() error { return client.Client.BlackoutContainer(c, timeout, duration) })
+}
+
+func (client recordingClient) BurnCPUContainer(c container.Container, workers int, duration time.Duration) error {
+	return client.record(c, "cpu", func() error { return client.Client.BurnCPUContainer(c, workers, duration) })
+}
+
+func (client recordingClient) OOMContainer(c container.Container, timeout time.Duration) error {
+	return client.record(c, "oom", func() error { return client.Client.OOMContainer(c, timeout) })
+}
+
+func (client recordingClient) ExhaustFDsContainer(c container.Container, free int, duration time.Duration) error {
+	return client.record(c, "fd", func() error { return client.Client.ExhaustFDsContainer(c, free, duration) })
+}
+
+func (client recordingClient) HealthcheckContainer(c container.Container, probe health.Probe, duration time.Duration) error {
+	return client.record(c, "health", func() error {
+		return client.Client.HealthcheckContainer(c, probe, duration)
+	})
+}
+
+func (client recordingClient) MemoryPressureContainer(c container.Container, percent int, duration time.Duration) error {
+	return client.record(c, "memory", func() error {
+		return client.Client.MemoryPressureContainer(c, percent, duration)
+	})
+}
+
+func (client recordingClient) CorruptEnvContainer(c container.Container, set []string, unset []string, timeout int, duration time.Duration) error {
+	return client.record(c, "env", func() error {
+		return client.Client.CorruptEnvContainer(c, set, unset, timeout, duration)
+	})
+}
+
+func (client recordingClient) RemoveSecretsContainer(c container.Container, secrets []string, configs []string, duration time.Duration) error {
+	return client.record(c, "secrets", func() error {
+		return client.Client.RemoveSecretsContainer(c, secrets, configs, duration)
+	})
+}
+
+func (client recordingClient) PoisonImageContainer(c container.Container, image string, duration time.Duration) error {
+	return client.record(c, "poison-image", func() error {
+		return client.Client.PoisonImageContainer(c, image, duration)
+	})
+}
@@ -0,0 +1,53 @@
+package history
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_RecordsActions(t *testing.T) {
+	s, cleanup := openTemp(t)
+	defer cleanup()
+	c := *container.NewContainer(&dockerclient.ContainerInfo{Id: "abc123", Name: "/api_1"}, nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("KillContainer", c, "SIGKILL").Return(nil)
+	inner.On("PauseContainer", c, time.Minute).Return(errors.New("pause failed"))
+	client := NewClient(inner, s)
+
+	assert.NoError(t, client.KillContainer(c, "SIGKILL"))
+	assert.EqualError(t, client.PauseContainer(c, time.Minute), "pause failed")
+	// dry run is not recorded
+	inner.Dry = true
+	assert.NoError(t, client.KillContainer(c, "SIGKILL"))
+
+	actions, err := s.Actions(Filter{})
+	assert.NoError(t, err)
+	if assert.Len(t, actions, 2) {
+		assert.Equal(t, "kill", actions[0].Action)
+		assert.Equal(t, "/api_1", actions[0].ContainerName)
+		assert.Equal(t, "", actions[0].Error)
+		assert.Equal(t, "pause", actions[1].Action)
+		assert.Equal(t, "pause failed", actions[1].Error)
+	}
+}
+
+func TestRecordRun(t *testing.T) {
+	s, cleanup := openTemp(t)
+	defer cleanup()
+	task := RecordRun(s, "kill re2:^api", map[string]string{"build": "1.2.3"}, func() error { return errors.New("no containers") })
+
+	assert.EqualError(t, task(), "no containers")
+
+	runs, err := s.Runs(Filter{})
+	assert.NoError(t, err)
+	if assert.Len(t, runs, 1) {
+		assert.Equal(t, "kill re2:^api", runs[0].Command)
+		assert.Equal(t, "no containers", runs[0].Error)
+		assert.Equal(t, map[string]string{"build": "1.2.3"}, runs[0].Context)
+	}
+}
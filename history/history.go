@@ -0,0 +1,312 @@
+package history
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultFile default SQLite database file of experiment history
+const DefaultFile = "pumba-history.db"
+
+// sqliteDriver database/sql driver name; registered only in cgo builds (see sqlite.go)
+const sqliteDriver = "sqlite3"
+
+// errNoSQLite returned when Pumba is built without SQLite driver
+var errNoSQLite = errors.New("Experiment history is not supported: Pumba is built without SQLite (cgo) support")
+
+const schema = `
+CREATE TABLE IF NOT EXISTS runs (
+	id       INTEGER PRIMARY KEY AUTOINCREMENT,
+	command  TEXT NOT NULL,
+	started  INTEGER NOT NULL,
+	finished INTEGER NOT NULL,
+	error    TEXT NOT NULL DEFAULT '',
+	context  TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS actions (
+	id             INTEGER PRIMARY KEY AUTOINCREMENT,
+	time           INTEGER NOT NULL,
+	duration       INTEGER NOT NULL,
+	action         TEXT NOT NULL,
+	container_id   TEXT NOT NULL,
+	container_name TEXT NOT NULL,
+	error          TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS actions_time ON actions (time);
+CREATE TABLE IF NOT EXISTS probes (
+	id       INTEGER PRIMARY KEY AUTOINCREMENT,
+	time     INTEGER NOT NULL,
+	duration INTEGER NOT NULL,
+	name     TEXT NOT NULL,
+	error    TEXT NOT NULL DEFAULT ''
+);
+`
+
+// column column, added to table of history database created by older Pumba version
+type column struct {
+	table, name, definition string
+}
+
+// addedColumns columns, added to history database schema
+var addedColumns = []column{
+	{"runs", "context", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// Run experiment run: single execution of chaos command
+type Run struct {
+	Command  string    `json:"command"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Error    string    `json:"error,omitempty"`
+	// Context metadata of system under test (--context), e.g. build and commit
+	Context map[string]string `json:"context,omitempty"`
+}
+
+// Action outcome of chaos action on container
+type Action struct {
+	Time          time.Time     `json:"time"`
+	Duration      time.Duration `json:"duration"`
+	Action        string        `json:"action"`
+	ContainerID   string        `json:"container_id"`
+	ContainerName string        `json:"container_name"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// Probe outcome of scenario probe step: checks, whether system under test is healthy during or after chaos
+type Probe struct {
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+	Name     string        `json:"name"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Filter history query filter; zero fields match all
+type Filter struct {
+	Since     time.Time
+	Until     time.Time
+	Container string
+	Action    string
+}
+
+// Store experiment history, persisted to SQLite database
+type Store struct {
+	db *sql.DB
+	// SQLite allows single writer
+	mu sync.Mutex
+}
+
+// Open opens (creates) history database file
+func Open(path string) (*Store, error) {
+	if !driverRegistered() {
+		return nil, errNoSQLite
+	}
+	db, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Failed to create history database '%s': %s", path, err)
+	}
+	if err = addColumns(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("Failed to upgrade history database '%s': %s", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// addColumns adds missing columns to tables of history database, created by older Pumba version
+func addColumns(db *sql.DB) error {
+	for _, col := range addedColumns {
+		exists, err := hasColumn(db, col.table, col.name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if _, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", col.table, col.name, col.definition)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// hasColumn checks if table has column
+func hasColumn(db *sql.DB, table, name string) (bool, error) {
+	rows, err := db.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid, notNull, pk int
+		var colName, colType string
+		var dflt sql.NullString
+		if err = rows.Scan(&cid, &colName, &colType, &notNull, &dflt, &pk); err != nil {
+			return false, err
+		}
+		if colName == name {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// Close closes history database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// AddRun stores experiment run
+func (s *Store) AddRun(r Run) error {
+	var context string
+	if len(r.Context) > 0 {
+		data, err := json.Marshal(r.Context)
+		if err != nil {
+			return err
+		}
+		context = string(data)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.db.Exec("INSERT INTO runs (command, started, finished, error, context) VALUES (?, ?, ?, ?, ?)",
+		r.Command, r.Started.UnixNano(), r.Finished.UnixNano(), r.Error, context)
+	return err
+}
+
+// AddAction stores chaos action outcome
+func (s *Store) AddAction(a Action) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.db.Exec("INSERT INTO actions (time, duration, action, container_id, container_name, error) VALUES (?, ?, ?, ?, ?, ?)",
+		a.Time.UnixNano(), int64(a.Duration), a.Action, a.ContainerID, a.ContainerName, a.Error)
+	return err
+}
+
+// AddProbe stores scenario probe outcome
+func (s *Store) AddProbe(p Probe) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := s.db.Exec("INSERT INTO probes (time, duration, name, error) VALUES (?, ?, ?, ?)",
+		p.Time.UnixNano(), int64(p.Duration), p.Name, p.Error)
+	return err
+}
+
+// Runs returns experiment runs started in filter time range, ordered by start time
+func (s *Store) Runs(f Filter) ([]Run, error) {
+	where, args := timeRange("started", f)
+	rows, err := s.db.Query("SELECT command, started, finished, error, context FROM runs"+where+" ORDER BY started, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	runs := []Run{}
+	for rows.Next() {
+		var r Run
+		var started, finished int64
+		var context string
+		if err = rows.Scan(&r.Command, &started, &finished, &r.Error, &context); err != nil {
+			return nil, err
+		}
+		if context != "" {
+			if err = json.Unmarshal([]byte(context), &r.Context); err != nil {
+				return nil, fmt.Errorf("Invalid context of run '%s': %s", r.Command, err)
+			}
+		}
+		r.Started, r.Finished = time.Unix(0, started), time.Unix(0, finished)
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// Actions returns chaos actions matching filter, ordered by time; container filter matches container name or ID prefix
+func (s *Store) Actions(f Filter) ([]Action, error) {
+	where, args := timeRange("time", f)
+	var conds []string
+	if where != "" {
+		conds = append(conds, strings.TrimPrefix(where, " WHERE "))
+	}
+	if f.Container != "" {
+		conds = append(conds, "(container_name = ? OR container_name = ? OR container_id LIKE ?)")
+		args = append(args, f.Container, "/"+f.Container, f.Container+"%")
+	}
+	if f.Action != "" {
+		conds = append(conds, "action = ?")
+		args = append(args, f.Action)
+	}
+	query := "SELECT time, duration, action, container_id, container_name, error FROM actions"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	rows, err := s.db.Query(query+" ORDER BY time, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	actions := []Action{}
+	for rows.Next() {
+		var a Action
+		var t, d int64
+		if err = rows.Scan(&t, &d, &a.Action, &a.ContainerID, &a.ContainerName, &a.Error); err != nil {
+			return nil, err
+		}
+		a.Time, a.Duration = time.Unix(0, t), time.Duration(d)
+		actions = append(actions, a)
+	}
+	return actions, rows.Err()
+}
+
+// Probes returns scenario probe outcomes in filter time range, ordered by time; container and action filters are
+// not applied
+func (s *Store) Probes(f Filter) ([]Probe, error) {
+	where, args := timeRange("time", f)
+	rows, err := s.db.Query("SELECT time, duration, name, error FROM probes"+where+" ORDER BY time, id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	probes := []Probe{}
+	for rows.Next() {
+		var p Probe
+		var t, d int64
+		if err = rows.Scan(&t, &d, &p.Name, &p.Error); err != nil {
+			return nil, err
+		}
+		p.Time, p.Duration = time.Unix(0, t), time.Duration(d)
+		probes = append(probes, p)
+	}
+	return probes, rows.Err()
+}
+
+// timeRange returns WHERE clause and arguments for filter time range
+func timeRange(column string, f Filter) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	if !f.Since.IsZero() {
+		conds = append(conds, column+" >= ?")
+		args = append(args, f.Since.UnixNano())
+	}
+	if !f.Until.IsZero() {
+		conds = append(conds, column+" < ?")
+		args = append(args, f.Until.UnixNano())
+	}
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// driverRegistered checks that SQLite database/sql driver is registered
+func driverRegistered() bool {
+	for _, d := range sql.Drivers() {
+		if d == sqliteDriver {
+			return true
+		}
+	}
+	return false
+}
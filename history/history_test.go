@@ -0,0 +1,126 @@
+package history
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func openTemp(t *testing.T) (*Store, func()) {
+	if !driverRegistered() {
+		t.Skip("SQLite driver requires cgo")
+	}
+	dir, err := ioutil.TempDir("", "pumba")
+	assert.NoError(t, err)
+	s, err := Open(filepath.Join(dir, DefaultFile))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return s, func() {
+		s.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestStore_Actions(t *testing.T) {
+	s, cleanup := openTemp(t)
+	defer cleanup()
+	t0 := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, s.AddAction(Action{Time: t0, Duration: time.Second, Action: "kill", ContainerID: "abc123", ContainerName: "/api_1"}))
+	assert.NoError(t, s.AddAction(Action{Time: t0.Add(time.Hour), Action: "netem", ContainerID: "def456", ContainerName: "/db_1", Error: "tc not found"}))
+	assert.NoError(t, s.AddAction(Action{Time: t0.Add(2 * time.Hour), Action: "kill", ContainerID: "def456", ContainerName: "/db_1"}))
+
+	all, err := s.Actions(Filter{})
+	assert.NoError(t, err)
+	if assert.Len(t, all, 3) {
+		assert.True(t, t0.Equal(all[0].Time))
+		assert.Equal(t, time.Second, all[0].Duration)
+		assert.Equal(t, "tc not found", all[1].Error)
+	}
+
+	byTime, err := s.Actions(Filter{Since: t0.Add(time.Minute), Until: t0.Add(2 * time.Hour)})
+	assert.NoError(t, err)
+	if assert.Len(t, byTime, 1) {
+		assert.Equal(t, "netem", byTime[0].Action)
+	}
+
+	byContainer, err := s.Actions(Filter{Container: "db_1", Action: "kill"})
+	assert.NoError(t, err)
+	if assert.Len(t, byContainer, 1) {
+		assert.True(t, t0.Add(2*time.Hour).Equal(byContainer[0].Time))
+	}
+
+	byID, err := s.Actions(Filter{Container: "abc"})
+	assert.NoError(t, err)
+	assert.Len(t, byID, 1)
+}
+
+func TestStore_Runs(t *testing.T) {
+	s, cleanup := openTemp(t)
+	defer cleanup()
+	t0 := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, s.AddRun(Run{Command: "kill re2:^api", Started: t0, Finished: t0.Add(time.Second)}))
+	assert.NoError(t, s.AddRun(Run{Command: "kill re2:^api", Started: t0.Add(time.Hour), Finished: t0.Add(time.Hour), Error: "no containers"}))
+
+	runs, err := s.Runs(Filter{Since: t0.Add(time.Minute)})
+
+	assert.NoError(t, err)
+	if assert.Len(t, runs, 1) {
+		assert.Equal(t, "no containers", runs[0].Error)
+	}
+}
+
+func TestOpen_AddsColumns(t *testing.T) {
+	s, cleanup := openTemp(t)
+	defer cleanup()
+	// history database of older Pumba version: runs without context
+	_, err := s.db.Exec("DROP TABLE runs; CREATE TABLE runs (id INTEGER PRIMARY KEY AUTOINCREMENT, command TEXT NOT NULL, started INTEGER NOT NULL, finished INTEGER NOT NULL, error TEXT NOT NULL DEFAULT '')")
+	assert.NoError(t, err)
+	_, err = s.db.Exec("INSERT INTO runs (command, started, finished) VALUES ('kill c1', 0, 0)")
+	assert.NoError(t, err)
+
+	assert.NoError(t, addColumns(s.db))
+
+	t0 := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, s.AddRun(Run{Command: "kill c2", Started: t0, Finished: t0, Context: map[string]string{"commit": "abc"}}))
+	runs, err := s.Runs(Filter{})
+	assert.NoError(t, err)
+	if assert.Len(t, runs, 2) {
+		assert.Nil(t, runs[0].Context)
+		assert.Equal(t, map[string]string{"commit": "abc"}, runs[1].Context)
+	}
+}
+
+func TestStore_Probes(t *testing.T) {
+	s, cleanup := openTemp(t)
+	defer cleanup()
+	t0 := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, s.AddProbe(Probe{Time: t0, Duration: 3 * time.Second, Name: "api-healthy"}))
+	assert.NoError(t, s.AddProbe(Probe{Time: t0.Add(time.Hour), Duration: 30 * time.Second, Name: "replica-promoted", Error: "timeout"}))
+
+	probes, err := s.Probes(Filter{Until: t0.Add(time.Minute)})
+
+	assert.NoError(t, err)
+	if assert.Len(t, probes, 1) {
+		assert.Equal(t, "api-healthy", probes[0].Name)
+		assert.Equal(t, 3*time.Second, probes[0].Duration)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	var out bytes.Buffer
+	err := WriteTable(&out, []Action{
+		{Time: time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC), Duration: time.Second, Action: "kill", ContainerID: "abc123def456789", ContainerName: "/api_1"},
+		{Time: time.Date(2016, 8, 1, 11, 0, 0, 0, time.UTC), Duration: time.Minute, Action: "netem", ContainerID: "def456", ContainerName: "/db_1", Error: "tc not found"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `TIME                  ACTION  CONTAINER  ID            DURATION  RESULT
+2016-08-01T10:00:00Z  kill    /api_1     abc123def456  1s        success
+2016-08-01T11:00:00Z  netem   /db_1      def456        1m0s      failure: tc not found
+`, out.String())
+}
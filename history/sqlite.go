@@ -0,0 +1,6 @@
+// +build cgo
+
+package history
+
+// SQLite driver requires cgo; release binaries, built with CGO_ENABLED=0, have no experiment history
+import _ "github.com/mattn/go-sqlite3"
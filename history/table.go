@@ -0,0 +1,26 @@
+package history
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteTable writes chaos actions as text table
+func WriteTable(out io.Writer, actions []Action) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tACTION\tCONTAINER\tID\tDURATION\tRESULT")
+	for _, a := range actions {
+		result := "success"
+		if a.Error != "" {
+			result = "failure: " + a.Error
+		}
+		id := a.ContainerID
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", a.Time.Format(time.RFC3339), a.Action, a.ContainerName, id, a.Duration, result)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,25 @@
+package hook
+
+import (
+	"github.com/gaia-adm/pumba/events"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Handle runs pre hook on start of chaos action and post hook on its result; hook failures are logged
+// and do not fail action; dry runs do not run hooks
+func (e *Executor) Handle(ev events.Event) {
+	if ev.DryRun {
+		return
+	}
+	var err error
+	switch ev.Type {
+	case events.Started:
+		err = e.Before(ev.Action, ev.Container)
+	case events.Succeeded, events.Failed:
+		err = e.After(ev.Action, ev.Container, ev.Err)
+	}
+	if err != nil {
+		log.Warn(err)
+	}
+}
@@ -0,0 +1,40 @@
+package hook
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutor_Handle(t *testing.T) {
+	c := container.NewMockContainer("abc", "/api_1", apiService)
+	e, calls := recordingExecutor("pre.sh", "post.sh", nil, nil)
+	e.Handle(events.Event{Type: events.Started, Action: "pause", Container: c})
+	e.Handle(events.Event{Type: events.Failed, Action: "pause", Container: c, Err: errors.New("pause failed")})
+	// no hooks on clean up
+	e.Handle(events.Event{Type: events.CleanedUp, Action: "pause", Container: c})
+	if assert.Len(t, *calls, 2) {
+		assert.Equal(t, "pre.sh", (*calls)[0].cmd)
+		assert.Equal(t, "post.sh", (*calls)[1].cmd)
+		assert.Contains(t, (*calls)[1].env, "PUMBA_ERROR=pause failed")
+	}
+}
+
+func TestExecutor_HandleHookError(t *testing.T) {
+	c := container.NewMockContainer("abc", "/api_1", apiService)
+	e, calls := recordingExecutor("pre.sh", "post.sh", nil, errors.New("exit status 2"))
+	e.Handle(events.Event{Type: events.Started, Action: "kill", Container: c})
+	e.Handle(events.Event{Type: events.Succeeded, Action: "kill", Container: c})
+	assert.Len(t, *calls, 2)
+}
+
+func TestExecutor_HandleDryRun(t *testing.T) {
+	c := container.NewMockContainer("abc", "/api_1", apiService)
+	e, calls := recordingExecutor("pre.sh", "post.sh", nil, nil)
+	e.Handle(events.Event{Type: events.Started, Action: "kill", Container: c, DryRun: true})
+	e.Handle(events.Event{Type: events.Succeeded, Action: "kill", Container: c, DryRun: true})
+	assert.Empty(t, *calls)
+}
@@ -0,0 +1,123 @@
+package hook
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// DefaultTimeout hook command timeout
+const DefaultTimeout = time.Minute
+
+// hook stages
+const (
+	Pre  = "pre"
+	Post = "post"
+)
+
+// runFunc runs shell command with extra environment variables and returns its combined output
+type runFunc func(cmd string, env []string, timeout time.Duration) ([]byte, error)
+
+// Executor runs shell hooks on Pumba host before and after chaos action;
+// hook environment describes action and target container:
+// PUMBA_HOOK (pre/post), PUMBA_ACTION, PUMBA_CONTAINER_ID, PUMBA_CONTAINER_NAME, PUMBA_SERVICE,
+// PUMBA_CONTEXT_<KEY> (--context pairs) and, for post hook, PUMBA_RESULT (success/failure) and PUMBA_ERROR
+type Executor struct {
+	// Pre command to run before action; empty - no hook
+	Pre string
+	// Post command to run after action; empty - no hook
+	Post string
+	// Context key=value pairs passed to hooks
+	Context map[string]string
+	// Timeout kills hook command, if it runs longer
+	Timeout time.Duration
+	run     runFunc
+}
+
+// NewExecutor creates hook executor
+func NewExecutor(pre, post string, context map[string]string) *Executor {
+	return &Executor{Pre: pre, Post: post, Context: context, Timeout: DefaultTimeout, run: runShell}
+}
+
+// Before runs pre hook for action on container
+func (e *Executor) Before(action string, c container.Container) error {
+	return e.exec(Pre, e.Pre, e.env(Pre, action, c))
+}
+
+// After runs post hook for action on container with action result
+func (e *Executor) After(action string, c container.Container, actionErr error) error {
+	env := e.env(Post, action, c)
+	if actionErr == nil {
+		env = append(env, "PUMBA_RESULT=success")
+	} else {
+		env = append(env, "PUMBA_RESULT=failure", "PUMBA_ERROR="+actionErr.Error())
+	}
+	return e.exec(Post, e.Post, env)
+}
+
+func (e *Executor) exec(stage string, cmd string, env []string) error {
+	if cmd == "" {
+		return nil
+	}
+	log.Debugf("Running %s hook '%s'", stage, cmd)
+	out, err := e.run(cmd, env, e.Timeout)
+	if len(out) > 0 {
+		log.Debugf("%s hook output: %s", stage, strings.TrimSpace(string(out)))
+	}
+	if err != nil {
+		return fmt.Errorf("%s hook '%s' failed: %s", stage, cmd, err)
+	}
+	return nil
+}
+
+var invalidEnvChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+func (e *Executor) env(stage string, action string, c container.Container) []string {
+	env := []string{
+		"PUMBA_HOOK=" + stage,
+		"PUMBA_ACTION=" + action,
+		"PUMBA_CONTAINER_ID=" + c.ID(),
+		"PUMBA_CONTAINER_NAME=" + strings.TrimPrefix(c.Name(), "/"),
+		"PUMBA_SERVICE=" + c.ComposeService(),
+	}
+	keys := make([]string, 0, len(e.Context))
+	for k := range e.Context {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		env = append(env, "PUMBA_CONTEXT_"+invalidEnvChars.ReplaceAllString(strings.ToUpper(k), "_")+"="+e.Context[k])
+	}
+	return env
+}
+
+// runShell runs command with 'sh -c' and kills it after timeout
+func runShell(cmd string, env []string, timeout time.Duration) ([]byte, error) {
+	sh := exec.Command("sh", "-c", cmd)
+	sh.Env = append(os.Environ(), env...)
+	var out bytes.Buffer
+	sh.Stdout = &out
+	sh.Stderr = &out
+	if err := sh.Start(); err != nil {
+		return nil, err
+	}
+	done := make(chan error, 1)
+	go func() { done <- sh.Wait() }()
+	select {
+	case err := <-done:
+		return out.Bytes(), err
+	case <-time.After(timeout):
+		// do not wait for hook children, holding output open
+		sh.Process.Kill()
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+}
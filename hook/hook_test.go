@@ -0,0 +1,78 @@
+package hook
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/assert"
+)
+
+// apiService labels of Docker compose 'api' service container
+var apiService = map[string]string{"com.docker.compose.service": "api"}
+
+type call struct {
+	cmd string
+	env []string
+}
+
+func recordingExecutor(pre, post string, context map[string]string, err error) (*Executor, *[]call) {
+	var calls []call
+	e := NewExecutor(pre, post, context)
+	e.run = func(cmd string, env []string, timeout time.Duration) ([]byte, error) {
+		calls = append(calls, call{cmd, env})
+		return nil, err
+	}
+	return e, &calls
+}
+
+func TestExecutor_Before(t *testing.T) {
+	e, calls := recordingExecutor("snapshot.sh", "", map[string]string{"build": "1.2.3", "run-id": "42"}, nil)
+	err := e.Before("kill", container.NewMockContainer("abc", "/api_1", apiService))
+	assert.NoError(t, err)
+	assert.Equal(t, []call{{"snapshot.sh", []string{
+		"PUMBA_HOOK=pre",
+		"PUMBA_ACTION=kill",
+		"PUMBA_CONTAINER_ID=abc",
+		"PUMBA_CONTAINER_NAME=api_1",
+		"PUMBA_SERVICE=api",
+		"PUMBA_CONTEXT_BUILD=1.2.3",
+		"PUMBA_CONTEXT_RUN_ID=42",
+	}}}, *calls)
+}
+
+func TestExecutor_After(t *testing.T) {
+	e, calls := recordingExecutor("", "notify.sh", nil, nil)
+	c := container.NewMockContainer("abc", "/api_1", apiService)
+	assert.NoError(t, e.After("stop", c, nil))
+	assert.NoError(t, e.After("stop", c, errors.New("stop failed")))
+	assert.Len(t, *calls, 2)
+	assert.Contains(t, (*calls)[0].env, "PUMBA_RESULT=success")
+	assert.Contains(t, (*calls)[1].env, "PUMBA_RESULT=failure")
+	assert.Contains(t, (*calls)[1].env, "PUMBA_ERROR=stop failed")
+}
+
+func TestExecutor_NoHook(t *testing.T) {
+	e, calls := recordingExecutor("", "", nil, nil)
+	assert.NoError(t, e.Before("kill", container.NewMockContainer("abc", "/api_1", apiService)))
+	assert.Empty(t, *calls)
+}
+
+func TestExecutor_Error(t *testing.T) {
+	e, _ := recordingExecutor("snapshot.sh", "", nil, errors.New("exit status 1"))
+	err := e.Before("kill", container.NewMockContainer("abc", "/api_1", apiService))
+	assert.EqualError(t, err, "pre hook 'snapshot.sh' failed: exit status 1")
+}
+
+func TestRunShell(t *testing.T) {
+	out, err := runShell("echo $PUMBA_ACTION", []string{"PUMBA_ACTION=kill"}, time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "kill", strings.TrimSpace(string(out)))
+}
+
+func TestRunShell_Timeout(t *testing.T) {
+	_, err := runShell("sleep 5", nil, 10*time.Millisecond)
+	assert.EqualError(t, err, "timed out after 10ms")
+}
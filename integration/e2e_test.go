@@ -0,0 +1,176 @@
+// +build integration
+
+package integration
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	engineapi "github.com/docker/engine-api/client"
+	"github.com/docker/engine-api/types"
+	enginecontainer "github.com/docker/engine-api/types/container"
+	"github.com/gaia-adm/pumba/action"
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/suite"
+)
+
+// End-to-end tests: run against real Docker daemon (DOCKER_HOST or local socket) with 'make e2e'
+// Test image should contain 'ping' and 'tc' (iproute2) tools; override with E2E_IMAGE
+const (
+	defaultE2EImage = "alpine:3.4"
+	e2ePrefix       = "pumba-e2e-"
+)
+
+var e2eImage = defaultE2EImage
+
+type e2eTestSuite struct {
+	suite.Suite
+	ctx    context.Context
+	docker *engineapi.Client
+	client container.Client
+	chaos  action.Chaos
+}
+
+func (s *e2eTestSuite) SetupSuite() {
+	if image := os.Getenv("E2E_IMAGE"); image != "" {
+		e2eImage = image
+	}
+	s.ctx = context.Background()
+	docker, err := engineapi.NewEnvClient()
+	s.Require().NoError(err)
+	s.docker = docker
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = "unix:///var/run/docker.sock"
+	}
+	s.client = container.NewClient(host, nil, nil)
+	s.chaos = action.Pumba{}
+	// pull test image
+	resp, err := s.docker.ImagePull(s.ctx, e2eImage, types.ImagePullOptions{})
+	s.Require().NoError(err)
+	ioutil.ReadAll(resp)
+	resp.Close()
+}
+
+// startContainer starts disposable container; 'tc' is installed on alpine, if missing
+func (s *e2eTestSuite) startContainer(name string) string {
+	config := enginecontainer.Config{
+		Image: e2eImage,
+		Cmd:   []string{"sh", "-c", "which tc || apk add --no-cache iproute2; sleep 1000"},
+	}
+	hostConfig := enginecontainer.HostConfig{}
+	// remove leftovers of previous runs
+	s.docker.ContainerRemove(s.ctx, e2ePrefix+name, types.ContainerRemoveOptions{Force: true})
+	resp, err := s.docker.ContainerCreate(s.ctx, &config, &hostConfig, nil, e2ePrefix+name)
+	s.Require().NoError(err)
+	s.Require().NoError(s.docker.ContainerStart(s.ctx, resp.ID, types.ContainerStartOptions{}))
+	// wait for tools
+	s.Require().Equal("0", s.execOutput(resp.ID, "sh", "-c", "for i in $(seq 30); do which tc >/dev/null && break; sleep 1; done; which tc >/dev/null; echo -n $?"))
+	return resp.ID
+}
+
+func (s *e2eTestSuite) removeContainer(id string) {
+	s.docker.ContainerRemove(s.ctx, id, types.ContainerRemoveOptions{Force: true})
+}
+
+func (s *e2eTestSuite) inspect(id string) types.ContainerJSON {
+	info, err := s.docker.ContainerInspect(s.ctx, id)
+	s.Require().NoError(err)
+	return info
+}
+
+// execOutput runs command in container (with TTY) and returns its output
+func (s *e2eTestSuite) execOutput(id string, cmd ...string) string {
+	config := types.ExecConfig{Cmd: cmd, Tty: true, AttachStdout: true, AttachStderr: true}
+	exec, err := s.docker.ContainerExecCreate(s.ctx, id, config)
+	s.Require().NoError(err)
+	resp, err := s.docker.ContainerExecAttach(s.ctx, exec.ID, config)
+	s.Require().NoError(err)
+	defer resp.Close()
+	out, err := ioutil.ReadAll(resp.Reader)
+	s.Require().NoError(err)
+	return string(out)
+}
+
+var pingAvgRe = regexp.MustCompile(`= [0-9.]+/([0-9.]+)/`)
+
+// pingAvg measures average round-trip time from container to IP address
+func (s *e2eTestSuite) pingAvg(id string, ip string) time.Duration {
+	out := s.execOutput(id, "ping", "-c", "5", "-i", "0.2", ip)
+	m := pingAvgRe.FindStringSubmatch(out)
+	s.Require().Len(m, 2, "unexpected ping output: %s", out)
+	avg, err := strconv.ParseFloat(m[1], 64)
+	s.Require().NoError(err)
+	return time.Duration(avg * float64(time.Millisecond))
+}
+
+func (s *e2eTestSuite) ipAddress(id string) string {
+	for _, endpoint := range s.inspect(id).NetworkSettings.Networks {
+		if endpoint.IPAddress != "" {
+			return endpoint.IPAddress
+		}
+	}
+	s.FailNow(fmt.Sprintf("container %s has no IP address", id))
+	return ""
+}
+
+func (s *e2eTestSuite) TestKill() {
+	id := s.startContainer("kill")
+	defer s.removeContainer(id)
+
+	err := s.chaos.KillContainers(s.client, []string{e2ePrefix + "kill"}, "", action.CommandKill{Signal: "SIGKILL"})
+
+	s.NoError(err)
+	state := s.inspect(id).State
+	s.False(state.Running)
+	s.Equal(137, state.ExitCode)
+}
+
+func (s *e2eTestSuite) TestPause() {
+	id := s.startContainer("pause")
+	defer s.removeContainer(id)
+
+	done := make(chan error)
+	go func() {
+		done <- s.chaos.PauseContainers(s.client, []string{e2ePrefix + "pause"}, "", action.CommandPause{Duration: 3 * time.Second})
+	}()
+	time.Sleep(1 * time.Second)
+	s.True(s.inspect(id).State.Paused)
+
+	s.NoError(<-done)
+	s.False(s.inspect(id).State.Paused)
+	s.True(s.inspect(id).State.Running)
+}
+
+func (s *e2eTestSuite) TestNetemDelay() {
+	id := s.startContainer("netem")
+	defer s.removeContainer(id)
+	peer := s.startContainer("netem-peer")
+	defer s.removeContainer(peer)
+	peerIP := s.ipAddress(peer)
+	before := s.pingAvg(id, peerIP)
+
+	done := make(chan error)
+	go func() {
+		cmd := action.CommandNetemDelay{CommandNetem: action.CommandNetem{NetInterface: "eth0", Duration: 5 * time.Second}, Amount: 200}
+		done <- s.chaos.NetemDelayContainers(s.client, []string{e2ePrefix + "netem"}, "", cmd)
+	}()
+	time.Sleep(1 * time.Second)
+	during := s.pingAvg(id, peerIP)
+
+	s.NoError(<-done)
+	after := s.pingAvg(id, peerIP)
+	s.True(during-before >= 180*time.Millisecond, "expected added latency ~200ms, before: %s, during: %s", before, during)
+	s.True(after-before < 50*time.Millisecond, "expected latency restored, before: %s, after: %s", before, after)
+}
+
+func TestE2ETestSuite(t *testing.T) {
+	suite.Run(t, new(e2eTestSuite))
+}
@@ -0,0 +1,71 @@
+package iptables
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/gaia-adm/pumba/teardown"
+)
+
+// rule comment, helps to find rules left by Pumba
+const ruleComment = "pumba"
+
+// Port is a container port published on Docker host: traffic to HostIP:HostPort is DNAT-ed
+// (by Docker iptables rules) to ContainerIP:ContainerPort
+type Port struct {
+	// Proto protocol: tcp or udp
+	Proto string
+	// HostIP host address port is bound to; nil - all host addresses
+	HostIP net.IP
+	// HostPort published port on host
+	HostPort int
+	// ContainerIP container address
+	ContainerIP net.IP
+	// ContainerPort exposed container port
+	ContainerPort int
+}
+
+// DropCommands returns host iptables commands, that drop loss percent (1-100) of packets sent to published ports
+// through host DNAT path; traffic to container from other containers and container own traffic is not affected
+func DropCommands(ports []Port, loss int) [][]string {
+	return Pipeline(ports, loss).ApplyCommands()
+}
+
+// RestoreCommands returns host iptables commands, that delete rules added by DropCommands, in reverse order
+func RestoreCommands(ports []Port, loss int) [][]string {
+	return Pipeline(ports, loss).TeardownCommands()
+}
+
+// Pipeline returns named setup steps of DropCommands: one rule per published port, removed with its
+// RestoreCommands counterpart
+func Pipeline(ports []Port, loss int) teardown.Pipeline {
+	p := teardown.Pipeline{Name: "published ports drop rules"}
+	for _, port := range ports {
+		p.Steps = append(p.Steps, teardown.Step{
+			Name:  fmt.Sprintf("drop rule of port %d/%s", port.HostPort, port.Proto),
+			Apply: rule("-I", port, loss),
+			Undo:  rule("-D", port, loss),
+		})
+	}
+	return p
+}
+
+// 'iptables -I FORWARD -p tcp -d 172.17.0.2 --dport 80 -m conntrack --ctstate DNAT --ctorigdstport 8080 -m comment --comment pumba -j DROP'
+func rule(op string, p Port, loss int) []string {
+	cmd := []string{"iptables"}
+	if p.ContainerIP.To4() == nil {
+		cmd = []string{"ip6tables"}
+	}
+	cmd = append(cmd, op, "FORWARD", "-p", p.Proto, "-d", p.ContainerIP.String(), "--dport", strconv.Itoa(p.ContainerPort),
+		"-m", "conntrack", "--ctstate", "DNAT", "--ctorigdstport", strconv.Itoa(p.HostPort))
+	if p.HostIP != nil && !p.HostIP.IsUnspecified() {
+		cmd = append(cmd, "--ctorigdst", p.HostIP.String())
+	}
+	if loss < 100 {
+		// drop random share of packets
+		probability := strconv.FormatFloat(float64(loss)/100, 'f', 2, 64)
+		cmd = append(cmd, "-m", "statistic", "--mode", "random", "--probability", probability)
+	}
+	return append(cmd, "-m", "comment", "--comment", ruleComment, "-j", "DROP")
+}
@@ -0,0 +1,75 @@
+package iptables
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func join(cmds [][]string) []string {
+	lines := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		lines[i] = strings.Join(cmd, " ")
+	}
+	return lines
+}
+
+func TestDropCommands(t *testing.T) {
+	ports := []Port{
+		{Proto: "tcp", HostIP: net.ParseIP("0.0.0.0"), HostPort: 8080, ContainerIP: net.ParseIP("172.17.0.2"), ContainerPort: 80},
+		{Proto: "udp", HostIP: net.ParseIP("10.0.0.1"), HostPort: 5353, ContainerIP: net.ParseIP("172.17.0.2"), ContainerPort: 53},
+	}
+	assert.Equal(t, []string{
+		"iptables -I FORWARD -p tcp -d 172.17.0.2 --dport 80 -m conntrack --ctstate DNAT --ctorigdstport 8080 -m comment --comment pumba -j DROP",
+		"iptables -I FORWARD -p udp -d 172.17.0.2 --dport 53 -m conntrack --ctstate DNAT --ctorigdstport 5353 --ctorigdst 10.0.0.1 -m comment --comment pumba -j DROP",
+	}, join(DropCommands(ports, 100)))
+}
+
+func TestDropCommands_Loss(t *testing.T) {
+	ports := []Port{
+		{Proto: "tcp", HostPort: 8080, ContainerIP: net.ParseIP("172.17.0.2"), ContainerPort: 80},
+	}
+	assert.Equal(t, []string{
+		"iptables -I FORWARD -p tcp -d 172.17.0.2 --dport 80 -m conntrack --ctstate DNAT --ctorigdstport 8080 -m statistic --mode random --probability 0.25 -m comment --comment pumba -j DROP",
+	}, join(DropCommands(ports, 25)))
+}
+
+func TestDropCommands_IPv6(t *testing.T) {
+	ports := []Port{
+		{Proto: "tcp", HostPort: 8080, ContainerIP: net.ParseIP("fd00::2"), ContainerPort: 80},
+	}
+	assert.Equal(t, []string{
+		"ip6tables -I FORWARD -p tcp -d fd00::2 --dport 80 -m conntrack --ctstate DNAT --ctorigdstport 8080 -m comment --comment pumba -j DROP",
+	}, join(DropCommands(ports, 100)))
+}
+
+func TestRestoreCommands(t *testing.T) {
+	ports := []Port{
+		{Proto: "tcp", HostPort: 8080, ContainerIP: net.ParseIP("172.17.0.2"), ContainerPort: 80},
+	}
+	assert.Equal(t, []string{
+		"iptables -D FORWARD -p tcp -d 172.17.0.2 --dport 80 -m conntrack --ctstate DNAT --ctorigdstport 8080 -m statistic --mode random --probability 0.50 -m comment --comment pumba -j DROP",
+	}, join(RestoreCommands(ports, 50)))
+}
+
+func TestPipeline_Rollback(t *testing.T) {
+	ports := []Port{
+		{Proto: "tcp", HostPort: 8080, ContainerIP: net.ParseIP("172.17.0.2"), ContainerPort: 80},
+		{Proto: "tcp", HostPort: 8443, ContainerIP: net.ParseIP("172.17.0.2"), ContainerPort: 443},
+		{Proto: "udp", HostPort: 5353, ContainerIP: net.ParseIP("172.17.0.2"), ContainerPort: 53},
+	}
+	var ops []string
+	_, err := Pipeline(ports, 100).Apply(func(cmd []string) error {
+		ops = append(ops, cmd[1]+" "+cmd[8])
+		if cmd[1] == "-I" && cmd[8] == "53" {
+			return errors.New("iptables failed")
+		}
+		return nil
+	})
+	assert.EqualError(t, err, "iptables failed")
+	// rules of applied steps are removed in reverse order; failed rule was not added
+	assert.Equal(t, []string{"-I 80", "-I 443", "-I 53", "-D 443", "-D 80"}, ops)
+}
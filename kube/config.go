@@ -0,0 +1,131 @@
+// Package kube configures access to Kubernetes API server: in-cluster service account of Pumba pod or
+// kubeconfig file, when Pumba runs out of cluster
+package kube
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// serviceAccountDir directory with in-cluster service account files (token, ca.crt and namespace)
+var serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// requestTimeout timeout of Kubernetes API requests
+const requestTimeout = 10 * time.Second
+
+// Config Kubernetes API server connection: server URL, HTTP client, that authenticates requests, and
+// default namespace (of Pumba pod or kubeconfig context)
+type Config struct {
+	Server    string
+	Namespace string
+	Client    *http.Client
+}
+
+// Load returns Kubernetes API configuration: from kubeconfig file, if set; in-cluster service account, when
+// running in Kubernetes pod; otherwise kubeconfig from $KUBECONFIG or ~/.kube/config
+func Load(kubeconfig string) (*Config, error) {
+	if kubeconfig != "" {
+		return LoadKubeconfig(kubeconfig)
+	}
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return InCluster()
+	}
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		// like kubectl: list of files, first file with current context wins; only first file is used
+		return LoadKubeconfig(filepath.SplitList(env)[0])
+	}
+	path := filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	if _, err := os.Stat(path); err != nil {
+		return nil, errors.New("Kubernetes API is not configured: not running in Kubernetes pod (KUBERNETES_SERVICE_HOST is not set) and no kubeconfig found; use --kubeconfig")
+	}
+	return LoadKubeconfig(path)
+}
+
+// InCluster returns Kubernetes API configuration with service account of Pumba pod; service account token
+// is re-read for each request, since projected tokens are rotated by kubelet
+func InCluster() (*Config, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("Kubernetes in-cluster configuration requires running in Kubernetes pod: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+	}
+	tokenFile := filepath.Join(serviceAccountDir, "token")
+	if _, err := readToken(tokenFile); err != nil {
+		return nil, fmt.Errorf("Failed to read service account token: %s", err)
+	}
+	ca, err := ioutil.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read service account CA: %s", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("Invalid service account CA certificate")
+	}
+	namespace := "default"
+	if data, err := ioutil.ReadFile(filepath.Join(serviceAccountDir, "namespace")); err == nil {
+		if ns := strings.TrimSpace(string(data)); ns != "" {
+			namespace = ns
+		}
+	}
+	return &Config{
+		Server:    "https://" + net.JoinHostPort(host, port),
+		Namespace: namespace,
+		Client:    newClient(&tls.Config{RootCAs: pool}, "", tokenFile),
+	}, nil
+}
+
+// newClient creates HTTP client, that adds bearer token (static or read from token file) to requests
+func newClient(tlsConfig *tls.Config, token string, tokenFile string) *http.Client {
+	var transport http.RoundTripper = &http.Transport{Proxy: http.ProxyFromEnvironment, TLSClientConfig: tlsConfig}
+	if token != "" || tokenFile != "" {
+		transport = &bearerTransport{token: token, tokenFile: tokenFile, base: transport}
+	}
+	return &http.Client{Transport: transport, Timeout: requestTimeout}
+}
+
+// bearerTransport authenticates requests with bearer token; token file (if set) is read for each request
+type bearerTransport struct {
+	token     string
+	tokenFile string
+	base      http.RoundTripper
+}
+
+// RoundTrip adds Authorization header to copy of request
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token := t.token
+	if t.tokenFile != "" {
+		var err error
+		if token, err = readToken(t.tokenFile); err != nil {
+			return nil, fmt.Errorf("Failed to read service account token: %s", err)
+		}
+	}
+	r := *req
+	r.Header = make(http.Header, len(req.Header)+1)
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(&r)
+}
+
+// readToken reads bearer token from file
+func readToken(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	token := string(bytes.TrimSpace(data))
+	if token == "" {
+		return "", fmt.Errorf("empty token in %s", path)
+	}
+	return token, nil
+}
@@ -0,0 +1,119 @@
+package kube
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tokenServer TLS API server, that records Authorization headers
+type tokenServer struct {
+	sync.Mutex
+	*httptest.Server
+	tokens []string
+}
+
+func newTokenServer() *tokenServer {
+	s := &tokenServer{}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		defer s.Unlock()
+		s.tokens = append(s.tokens, r.Header.Get("Authorization"))
+	}))
+	return s
+}
+
+// caPEM returns PEM encoded certificate of test server
+func (s *tokenServer) caPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.TLS.Certificates[0].Certificate[0]})
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "pumba-kube")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// setenv sets environment variables and returns function, that restores them
+func setenv(vars map[string]string) func() {
+	old := map[string]string{}
+	for k, v := range vars {
+		old[k] = os.Getenv(k)
+		os.Setenv(k, v)
+	}
+	return func() {
+		for k, v := range old {
+			os.Setenv(k, v)
+		}
+	}
+}
+
+func TestInCluster(t *testing.T) {
+	server := newTokenServer()
+	defer server.Close()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	serviceAccountDir = dir
+	defer func() { serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount" }()
+	writeFile(t, filepath.Join(dir, "token"), []byte("token-1\n"))
+	writeFile(t, filepath.Join(dir, "ca.crt"), server.caPEM())
+	writeFile(t, filepath.Join(dir, "namespace"), []byte("chaos"))
+	host, port, _ := net.SplitHostPort(server.Listener.Addr().String())
+	defer setenv(map[string]string{"KUBERNETES_SERVICE_HOST": host, "KUBERNETES_SERVICE_PORT": port})()
+
+	cfg, err := Load("")
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL, cfg.Server)
+	assert.Equal(t, "chaos", cfg.Namespace)
+	_, err = cfg.Client.Get(cfg.Server)
+	assert.NoError(t, err)
+	// rotated token is used for next request
+	writeFile(t, filepath.Join(dir, "token"), []byte("token-2"))
+	_, err = cfg.Client.Get(cfg.Server)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, server.tokens)
+}
+
+func TestInCluster_Errors(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	serviceAccountDir = dir
+	defer func() { serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount" }()
+	defer setenv(map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1", "KUBERNETES_SERVICE_PORT": ""})()
+
+	_, err := InCluster()
+	assert.EqualError(t, err, "Kubernetes in-cluster configuration requires running in Kubernetes pod: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT are not set")
+	os.Setenv("KUBERNETES_SERVICE_PORT", "443")
+	writeFile(t, filepath.Join(dir, "token"), []byte(" "))
+	_, err = InCluster()
+	assert.EqualError(t, err, "Failed to read service account token: empty token in "+filepath.Join(dir, "token"))
+	writeFile(t, filepath.Join(dir, "token"), []byte("token"))
+	writeFile(t, filepath.Join(dir, "ca.crt"), []byte("not a certificate"))
+	_, err = InCluster()
+	assert.EqualError(t, err, "Invalid service account CA certificate")
+}
+
+func TestLoad_NotConfigured(t *testing.T) {
+	home := tempDir(t)
+	defer os.RemoveAll(home)
+	defer setenv(map[string]string{"HOME": home, "KUBECONFIG": "", "KUBERNETES_SERVICE_HOST": ""})()
+
+	_, err := Load("")
+	assert.EqualError(t, err, "Kubernetes API is not configured: not running in Kubernetes pod (KUBERNETES_SERVICE_HOST is not set) and no kubeconfig found; use --kubeconfig")
+}
@@ -0,0 +1,149 @@
+package kube
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"gopkg.in/yaml.v2"
+)
+
+// kubeconfig kubectl configuration file (used fields only)
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Contexts       []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Clusters []struct {
+		Name    string  `yaml:"name"`
+		Cluster cluster `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User user   `yaml:"user"`
+	} `yaml:"users"`
+}
+
+type cluster struct {
+	Server                   string `yaml:"server"`
+	CertificateAuthority     string `yaml:"certificate-authority"`
+	CertificateAuthorityData string `yaml:"certificate-authority-data"`
+	InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+}
+
+type user struct {
+	Token                 string      `yaml:"token"`
+	TokenFile             string      `yaml:"tokenFile"`
+	ClientCertificate     string      `yaml:"client-certificate"`
+	ClientCertificateData string      `yaml:"client-certificate-data"`
+	ClientKey             string      `yaml:"client-key"`
+	ClientKeyData         string      `yaml:"client-key-data"`
+	Exec                  interface{} `yaml:"exec"`
+	AuthProvider          interface{} `yaml:"auth-provider"`
+}
+
+// LoadKubeconfig returns Kubernetes API configuration of current context of kubeconfig file; supported
+// credentials: bearer token (or token file) and client certificate
+func LoadKubeconfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read kubeconfig: %s", err)
+	}
+	var kc kubeconfig
+	if err = yaml.Unmarshal(data, &kc); err != nil {
+		return nil, fmt.Errorf("Failed to parse kubeconfig %s: %s", path, err)
+	}
+	if kc.CurrentContext == "" {
+		return nil, fmt.Errorf("Kubeconfig %s has no current context", path)
+	}
+	var clusterName, userName, namespace string
+	found := false
+	for _, c := range kc.Contexts {
+		if c.Name == kc.CurrentContext {
+			clusterName, userName, namespace, found = c.Context.Cluster, c.Context.User, c.Context.Namespace, true
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("Kubeconfig context '%s' not found", kc.CurrentContext)
+	}
+	var cl *cluster
+	for i := range kc.Clusters {
+		if kc.Clusters[i].Name == clusterName {
+			cl = &kc.Clusters[i].Cluster
+		}
+	}
+	if cl == nil || cl.Server == "" {
+		return nil, fmt.Errorf("Kubeconfig cluster '%s' not found or has no server", clusterName)
+	}
+	var u user
+	for _, item := range kc.Users {
+		if item.Name == userName {
+			u = item.User
+		}
+	}
+	if u.Exec != nil || u.AuthProvider != nil {
+		return nil, fmt.Errorf("Kubeconfig user '%s' uses exec or auth-provider credentials, not supported; use token or client certificate", userName)
+	}
+	// relative file paths are relative to kubeconfig file, like kubectl
+	dir := filepath.Dir(path)
+	tlsConfig := &tls.Config{InsecureSkipVerify: cl.InsecureSkipTLSVerify}
+	ca, err := readData(dir, cl.CertificateAuthority, cl.CertificateAuthorityData)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid certificate authority of kubeconfig cluster '%s': %s", clusterName, err)
+	}
+	if ca != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("Invalid certificate authority of kubeconfig cluster '%s'", clusterName)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	cert, err := readData(dir, u.ClientCertificate, u.ClientCertificateData)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid client certificate of kubeconfig user '%s': %s", userName, err)
+	}
+	key, err := readData(dir, u.ClientKey, u.ClientKeyData)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid client key of kubeconfig user '%s': %s", userName, err)
+	}
+	if cert != nil || key != nil {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid client certificate of kubeconfig user '%s': %s", userName, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+	tokenFile := u.TokenFile
+	if tokenFile != "" && !filepath.IsAbs(tokenFile) {
+		tokenFile = filepath.Join(dir, tokenFile)
+	}
+	if u.Token != "" {
+		tokenFile = ""
+	}
+	if namespace == "" {
+		namespace = "default"
+	}
+	return &Config{Server: cl.Server, Namespace: namespace, Client: newClient(tlsConfig, u.Token, tokenFile)}, nil
+}
+
+// readData returns content of kubeconfig '-data' field (base64) or file; nil, if both are empty
+func readData(dir string, file string, data string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file == "" {
+		return nil, nil
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	return ioutil.ReadFile(file)
+}
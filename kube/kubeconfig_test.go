@@ -0,0 +1,102 @@
+package kube
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testKubeconfig = `
+apiVersion: v1
+kind: Config
+current-context: chaos
+contexts:
+- name: other
+  context: {cluster: other, user: other}
+- name: chaos
+  context: {cluster: lab, user: pumba, namespace: chaos-testing}
+clusters:
+- name: lab
+  cluster:
+    server: %s
+    certificate-authority: ca.crt
+users:
+- name: pumba
+  user:
+    token: secret
+`
+
+func TestLoadKubeconfig(t *testing.T) {
+	server := newTokenServer()
+	defer server.Close()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	// certificate authority file path is relative to kubeconfig
+	writeFile(t, filepath.Join(dir, "ca.crt"), server.caPEM())
+	path := filepath.Join(dir, "config")
+	writeFile(t, path, []byte(fmt.Sprintf(testKubeconfig, server.URL)))
+
+	cfg, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL, cfg.Server)
+	assert.Equal(t, "chaos-testing", cfg.Namespace)
+	_, err = cfg.Client.Get(cfg.Server)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bearer secret"}, server.tokens)
+}
+
+func TestLoadKubeconfig_EnvAndTokenFile(t *testing.T) {
+	server := newTokenServer()
+	defer server.Close()
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	writeFile(t, filepath.Join(dir, "token"), []byte("from-file\n"))
+	path := filepath.Join(dir, "config")
+	writeFile(t, path, []byte(`
+current-context: lab
+contexts:
+- name: lab
+  context: {cluster: lab, user: pumba}
+clusters:
+- name: lab
+  cluster:
+    server: `+server.URL+`
+    certificate-authority-data: `+base64.StdEncoding.EncodeToString(server.caPEM())+`
+users:
+- name: pumba
+  user:
+    tokenFile: token
+`))
+	defer setenv(map[string]string{"KUBECONFIG": path + string(os.PathListSeparator) + "/nonexistent", "KUBERNETES_SERVICE_HOST": ""})()
+
+	cfg, err := Load("")
+	assert.NoError(t, err)
+	assert.Equal(t, "default", cfg.Namespace)
+	_, err = cfg.Client.Get(cfg.Server)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bearer from-file"}, server.tokens)
+}
+
+func TestLoadKubeconfig_Errors(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config")
+	for config, msg := range map[string]string{
+		`clusters: []`:             "Kubeconfig " + path + " has no current context",
+		`current-context: missing`: "Kubeconfig context 'missing' not found",
+		`current-context: [`:       "Failed to parse kubeconfig " + path + ": yaml: line 1: did not find expected node content",
+		"current-context: a\ncontexts: [{name: a, context: {cluster: b}}]": "Kubeconfig cluster 'b' not found or has no server",
+		"current-context: a\ncontexts: [{name: a, context: {cluster: b, user: u}}]\nclusters: [{name: b, cluster: {server: 'https://k8s'}}]\nusers: [{name: u, user: {exec: {command: aws}}}]": "Kubeconfig user 'u' uses exec or auth-provider credentials, not supported; use token or client certificate",
+		"current-context: a\ncontexts: [{name: a, context: {cluster: b, user: u}}]\nclusters: [{name: b, cluster: {server: 'https://k8s', certificate-authority-data: 'bm90IGEgY2VydA=='}}]":   "Invalid certificate authority of kubeconfig cluster 'b'",
+	} {
+		writeFile(t, path, []byte(config))
+		_, err := LoadKubeconfig(path)
+		assert.EqualError(t, err, msg, config)
+	}
+	_, err := LoadKubeconfig(filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+}
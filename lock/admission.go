@@ -0,0 +1,179 @@
+package lock
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gaia-adm/pumba/action"
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/netem"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Admission limits number of simultaneously active disruptions of all Pumba instances, sharing lock backend and
+// namespace: each active disruption holds one of max slot locks, and disruption without free slot is refused
+type Admission struct {
+	locker    Locker
+	namespace string
+	owner     string
+	max       int
+	// slot lock ttl margin, added to disruption duration
+	ttl    time.Duration
+	mu     sync.Mutex
+	seq    int
+	active int
+}
+
+// NewAdmission creates admission control of max active disruptions in lock namespace
+func NewAdmission(locker Locker, namespace string, owner string, max int, ttl time.Duration) *Admission {
+	return &Admission{locker: locker, namespace: namespace, owner: owner, max: max, ttl: ttl}
+}
+
+// slotKey returns lock key of active disruption slot
+func (a *Admission) slotKey(slot int) string {
+	return Key(a.namespace, "active-"+strconv.Itoa(slot))
+}
+
+// Admit acquires free slot for disruption of specified duration; returns false, if all slots are held; release
+// function frees acquired slot
+func (a *Admission) Admit(duration time.Duration) (func(), bool, error) {
+	a.mu.Lock()
+	a.seq++
+	// disruptions of the same Pumba instance hold different slots
+	owner := a.owner + "/" + strconv.Itoa(a.seq)
+	a.mu.Unlock()
+	for slot := 0; slot < a.max; slot++ {
+		key := a.slotKey(slot)
+		ok, err := a.locker.Acquire(key, owner, duration+a.ttl)
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			continue
+		}
+		log.Debugf("Acquired active disruption slot %s", key)
+		a.add(1)
+		release := func() {
+			a.add(-1)
+			if err := a.locker.Release(key, owner); err != nil {
+				log.Warnf("Failed to release active disruption slot %s: %s", key, err)
+			}
+		}
+		return release, true, nil
+	}
+	return nil, false, nil
+}
+
+func (a *Admission) add(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.active += n
+}
+
+// Active returns number of active disruptions, admitted for current Pumba instance
+func (a *Admission) Active() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.active
+}
+
+// Max returns maximal number of active disruptions
+func (a *Admission) Max() int {
+	return a.max
+}
+
+// admissionClient refuses disruptions (chaos actions with duration) beyond max active disruptions; refused
+// disruption is skipped; dry runs are not limited
+type admissionClient struct {
+	container.Client
+	admission *Admission
+}
+
+// NewAdmissionClient wraps container client with admission control of active disruptions
+func NewAdmissionClient(client container.Client, admission *Admission) container.Client {
+	return admissionClient{Client: client, admission: admission}
+}
+
+// admitted runs disruption, holding active disruption slot for its duration
+func (client admissionClient) admitted(c container.Container, name string, duration time.Duration, fn func() error) error {
+	if client.DryRun() {
+		return fn()
+	}
+	release, ok, err := client.admission.Admit(duration)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		reason := fmt.Sprintf("max active disruptions (%d) reached", client.admission.Max())
+		log.WithField("action", name).Warnf("Refusing %s of container %s: %s", name, c.Name(), reason)
+		action.Skip(c, name, reason)
+		return nil
+	}
+	defer release()
+	return fn()
+}
+
+func (client admissionClient) NetemContainer(c container.Container, netInterface string, netemCmd string, filter netem.Filter, duration time.Duration, reapply bool, probe string) error {
+	return client.admitted(c, "netem", duration, func() error {
+		return client.Client.NetemContainer(c, netInterface, netemCmd, filter, duration, reapply, probe)
+	})
+}
+
+func (client admissionClient) PauseContainer(c container.Container, duration time.Duration) error {
+	return client.admitted(c, "pause", duration, func() error { return client.Client.PauseContainer(c, duration) })
+}
+
+func (client admissionClient) SidecarContainer(c container.Container, image string, cmd []string, duration time.Duration) error {
+	return client.admitted(c, "sidecar", duration, func() error { return client.Client.SidecarContainer(c, image, cmd, duration) })
+}
+
+func (client admissionClient) DropPortsContainer(c container.Container, loss int, duration time.Duration) error {
+	return client.admitted(c, "ports", duration, func() error { return client.Client.DropPortsContainer(c, loss, duration) })
+}
+
+func (client admissionClient) BlackoutContainer(c container.Container, timeout int, duration time.Duration) error {
+	ttl := time.Duration(timeout)*time.Second + duration
+	return client.admitted(c, "stop", ttl, func() error { return client.Client.BlackoutContainer(c, timeout, duration) })
+}
+
+func (client admissionClient) BurnCPUContainer(c container.Container, workers int, duration time.Duration) error {
+	return client.admitted(c, "cpu", duration, func() error { return client.Client.BurnCPUContainer(c, workers, duration) })
+}
+
+func (client admissionClient) ExhaustFDsContainer(c container.Container, free int, duration time.Duration) error {
+	return client.admitted(c, "fd", duration, func() error { return client.Client.ExhaustFDsContainer(c, free, duration) })
+}
+
+func (client admissionClient) HealthcheckContainer(c container.Container, probe health.Probe, duration time.Duration) error {
+	return client.admitted(c, "health", duration, func() error {
+		return client.Client.HealthcheckContainer(c, probe, duration)
+	})
+}
+
+func (client admissionClient) MemoryPressureContainer(c container.Container, percent int, duration time.Duration) error {
+	return client.admitted(c, "memory", duration, func() error {
+		return client.Client.MemoryPressureContainer(c, percent, duration)
+	})
+}
+
+func (client admissionClient) CorruptEnvContainer(c container.Container, set []string, unset []string, timeout int, duration time.Duration) error {
+	return client.admitted(c, "env", duration, func() error {
+		return client.Client.CorruptEnvContainer(c, set, unset, timeout, duration)
+	})
+}
+
+func (client admissionClient) RemoveSecretsContainer(c container.Container, secrets []string, configs []string, duration time.Duration) error {
+	return client.admitted(c, "secrets", duration, func() error {
+		return client.Client.RemoveSecretsContainer(c, secrets, configs, duration)
+	})
+}
+
+func (client admissionClient) PoisonImageContainer(c container.Container, image string, duration time.Duration) error {
+	return client.admitted(c, "poison-image", duration, func() error {
+		return client.Client.PoisonImageContainer(c, image, duration)
+	})
+}
@@ -0,0 +1,71 @@
+package lock
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/action"
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmission_Slots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pumba-admission")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	locker, err := NewFileLocker(dir)
+	assert.NoError(t, err)
+	// two Pumba instances share 2 slots
+	a := NewAdmission(locker, "team-a", "host-1", 2, time.Minute)
+	b := NewAdmission(locker, "team-a", "host-2", 2, time.Minute)
+
+	release1, ok, err := a.Admit(time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	release2, ok, err := b.Admit(time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	_, ok, err = a.Admit(time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, 1, a.Active())
+	// other namespace has own slots
+	_, ok, err = NewAdmission(locker, "team-b", "host-1", 2, time.Minute).Admit(time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	release1()
+	assert.Equal(t, 0, a.Active())
+	release3, ok, err := b.Admit(time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, 2, b.Active())
+	release2()
+	release3()
+}
+
+func TestAdmissionClient(t *testing.T) {
+	c := container.NewMockContainer("abc", "/c1", nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("PauseContainer", c, time.Minute).Return(nil)
+	locker := &lockerMock{}
+	locker.On("Acquire", "team-a/active-0", "me/1", 2*time.Minute).Return(true, nil)
+	locker.On("Release", "team-a/active-0", "me/1").Return(nil)
+	locker.On("Acquire", "team-a/active-0", "me/2", 2*time.Minute).Return(false, nil)
+	locker.On("Acquire", "team-a/active-0", "me/3", 2*time.Minute).Return(false, errors.New("redis is down"))
+	var skipped []string
+	action.SkipHandler = func(c container.Container, name string, reason string) { skipped = append(skipped, name+": "+reason) }
+	defer func() { action.SkipHandler = nil }()
+	client := NewAdmissionClient(inner, NewAdmission(locker, "team-a", "me", 1, time.Minute))
+
+	assert.NoError(t, client.PauseContainer(c, time.Minute))
+	// all slots are held: disruption is refused
+	assert.NoError(t, client.PauseContainer(c, time.Minute))
+	assert.Equal(t, []string{"pause: max active disruptions (1) reached"}, skipped)
+	assert.EqualError(t, client.PauseContainer(c, time.Minute), "redis is down")
+	inner.AssertNumberOfCalls(t, "PauseContainer", 1)
+	locker.AssertExpectations(t)
+}
@@ -0,0 +1,151 @@
+package lock
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gaia-adm/pumba/action"
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/netem"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// lockingClient takes experiment lock of container before chaos action and releases it afterwards;
+// container locked by another Pumba instance is skipped; dry runs do not take locks
+type lockingClient struct {
+	container.Client
+	locker    Locker
+	namespace string
+	owner     string
+	// lock ttl margin, added to chaos action duration
+	ttl  time.Duration
+	held *Held
+}
+
+// Held counts container locks, held by chaos actions of current Pumba instance
+type Held struct {
+	mu sync.Mutex
+	n  int
+}
+
+func (h *Held) add(n int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.n += n
+}
+
+// Count returns number of held container locks
+func (h *Held) Count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.n
+}
+
+// NewClient wraps container client with experiment locks in namespace; held locks are counted in held
+func NewClient(client container.Client, locker Locker, namespace string, owner string, ttl time.Duration, held *Held) container.Client {
+	return lockingClient{Client: client, locker: locker, namespace: namespace, owner: owner, ttl: ttl, held: held}
+}
+
+// locked runs action holding container lock for action duration plus ttl margin
+func (client lockingClient) locked(c container.Container, name string, duration time.Duration, fn func() error) error {
+	if client.DryRun() {
+		return fn()
+	}
+	key := Key(client.namespace, c.ID())
+	ok, err := client.locker.Acquire(key, client.owner, duration+client.ttl)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		log.WithFields(log.Fields{"action": name, "lock": key}).Infof("Container %s is locked by another Pumba instance; skipping %s", c.Name(), name)
+		action.Skip(c, name, "locked by another Pumba instance")
+		return nil
+	}
+	log.Debugf("Acquired lock %s for %s", key, name)
+	client.held.add(1)
+	err = fn()
+	client.held.add(-1)
+	if rerr := client.locker.Release(key, client.owner); rerr != nil {
+		log.Warnf("Failed to release lock %s: %s", key, rerr)
+	}
+	return err
+}
+
+func (client lockingClient) StopContainer(c container.Container, timeout int) error {
+	return client.locked(c, "stop", time.Duration(timeout)*time.Second, func() error { return client.Client.StopContainer(c, timeout) })
+}
+
+func (client lockingClient) KillContainer(c container.Container, signal string) error {
+	return client.locked(c, "kill", 0, func() error { return client.Client.KillContainer(c, signal) })
+}
+
+func (client lockingClient) RemoveContainer(c container.Container, force bool, links bool, volumes bool) error {
+	return client.locked(c, "rm", 0, func() error { return client.Client.RemoveContainer(c, force, links, volumes) })
+}
+
+func (client lockingClient) NetemContainer(c container.Container, netInterface string, netemCmd string, filter netem.Filter, duration time.Duration, reapply bool, probe string) error {
+	return client.locked(c, "netem", duration, func() error {
+		return client.Client.NetemContainer(c, netInterface, netemCmd, filter, duration, reapply, probe)
+	})
+}
+
+func (client lockingClient) PauseContainer(c container.Container, duration time.Duration) error {
+	return client.locked(c, "pause", duration, func() error { return client.Client.PauseContainer(c, duration) })
+}
+
+func (client lockingClient) SidecarContainer(c container.Container, image string, cmd []string, duration time.Duration) error {
+	return client.locked(c, "sidecar", duration, func() error { return client.Client.SidecarContainer(c, image, cmd, duration) })
+}
+
+func (client lockingClient) DropPortsContainer(c container.Container, loss int, duration time.Duration) error {
+	return client.locked(c, "ports", duration, func() error { return client.Client.DropPortsContainer(c, loss, duration) })
+}
+
+func (client lockingClient) BlackoutContainer(c container.Container, timeout int, duration time.Duration) error {
+	ttl := time.Duration(timeout)*time.Second + duration
+	return client.locked(c, "stop", ttl, func() error { return client.Client.BlackoutContainer(c, timeout, duration) })
+}
+
+func (client lockingClient) BurnCPUContainer(c container.Container, workers int, duration time.Duration) error {
+	return client.locked(c, "cpu", duration, func() error { return client.Client.BurnCPUContainer(c, workers, duration) })
+}
+
+func (client lockingClient) OOMContainer(c container.Container, timeout time.Duration) error {
+	return client.locked(c, "oom", timeout, func() error { return client.Client.OOMContainer(c, timeout) })
+}
+
+func (client lockingClient) ExhaustFDsContainer(c container.Container, free int, duration time.Duration) error {
+	return client.locked(c, "fd", duration, func() error { return client.Client.ExhaustFDsContainer(c, free, duration) })
+}
+
+func (client lockingClient) HealthcheckContainer(c container.Container, probe health.Probe, duration time.Duration) error {
+	return client.locked(c, "health", duration, func() error {
+		return client.Client.HealthcheckContainer(c, probe, duration)
+	})
+}
+
+func (client lockingClient) MemoryPressureContainer(c container.Container, percent int, duration time.Duration) error {
+	return client.locked(c, "memory", duration, func() error {
+		return client.Client.MemoryPressureContainer(c, percent, duration)
+	})
+}
+
+func (client lockingClient) CorruptEnvContainer(c container.Container, set []string, unset []string, timeout int, duration time.Duration) error {
+	return client.locked(c, "env", duration, func() error {
+		return client.Client.CorruptEnvContainer(c, set, unset, timeout, duration)
+	})
+}
+
+func (client lockingClient) RemoveSecretsContainer(c container.Container, secrets []string, configs []string, duration time.Duration) error {
+	return client.locked(c, "secrets", duration, func() error {
+		return client.Client.RemoveSecretsContainer(c, secrets, configs, duration)
+	})
+}
+
+func (client lockingClient) PoisonImageContainer(c container.Container, image string, duration time.Duration) error {
+	return client.locked(c, "poison-image", duration, func() error {
+		return client.Client.PoisonImageContainer(c, image, duration)
+	})
+}
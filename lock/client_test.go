@@ -0,0 +1,79 @@
+package lock
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/action"
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type lockerMock struct {
+	mock.Mock
+}
+
+func (m *lockerMock) Acquire(key string, owner string, ttl time.Duration) (bool, error) {
+	args := m.Called(key, owner, ttl)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *lockerMock) Release(key string, owner string) error {
+	args := m.Called(key, owner)
+	return args.Error(0)
+}
+
+func TestClient_Locked(t *testing.T) {
+	c := container.NewMockContainer("abc", "/c1", nil)
+	inner := container.NewMockSamalbaClient()
+	held := &Held{}
+	var during int
+	inner.On("PauseContainer", c, time.Minute).Run(func(mock.Arguments) { during = held.Count() }).Return(nil)
+	inner.On("KillContainer", c, "SIGKILL").Return(errors.New("kill failed"))
+	locker := &lockerMock{}
+	locker.On("Acquire", "team-a/abc", "me", 2*time.Minute).Return(true, nil)
+	locker.On("Acquire", "team-a/abc", "me", time.Minute).Return(true, nil)
+	locker.On("Release", "team-a/abc", "me").Return(errors.New("release failed"))
+	client := NewClient(inner, locker, "team-a", "me", time.Minute, held)
+	// release failure does not fail chaos action
+	assert.NoError(t, client.PauseContainer(c, time.Minute))
+	assert.Equal(t, 1, during)
+	assert.Equal(t, 0, held.Count())
+	// lock is released after failed action
+	assert.EqualError(t, client.KillContainer(c, "SIGKILL"), "kill failed")
+	inner.AssertExpectations(t)
+	locker.AssertExpectations(t)
+	locker.AssertNumberOfCalls(t, "Release", 2)
+}
+
+func TestClient_LockedByOther(t *testing.T) {
+	c := container.NewMockContainer("abc", "/c1", nil)
+	inner := container.NewMockSamalbaClient()
+	locker := &lockerMock{}
+	locker.On("Acquire", "pumba/abc", "me", time.Minute).Return(false, nil)
+	var skipped []string
+	action.SkipHandler = func(c container.Container, name string, reason string) { skipped = append(skipped, name, reason) }
+	defer func() { action.SkipHandler = nil }()
+	client := NewClient(inner, locker, "pumba", "me", time.Minute, &Held{})
+	assert.NoError(t, client.KillContainer(c, "SIGKILL"))
+	assert.Equal(t, []string{"kill", "locked by another Pumba instance"}, skipped)
+	inner.AssertNotCalled(t, "KillContainer", c, "SIGKILL")
+	locker.AssertNotCalled(t, "Release", "pumba/abc", "me")
+}
+
+func TestClient_LockErrorAndDryRun(t *testing.T) {
+	c := container.NewMockContainer("abc", "/c1", nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("StopContainer", c, 10).Return(nil)
+	locker := &lockerMock{}
+	locker.On("Acquire", "pumba/abc", "me", 70*time.Second).Return(false, errors.New("connection refused"))
+	client := NewClient(inner, locker, "pumba", "me", time.Minute, &Held{})
+	assert.EqualError(t, client.StopContainer(c, 10), "connection refused")
+	// dry run does not take lock
+	inner.Dry = true
+	assert.NoError(t, client.StopContainer(c, 10))
+	inner.AssertNumberOfCalls(t, "StopContainer", 1)
+	locker.AssertNumberOfCalls(t, "Acquire", 1)
+}
@@ -0,0 +1,131 @@
+package lock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// fileLock content of lock file
+type fileLock struct {
+	Owner   string    `json:"owner"`
+	Expires time.Time `json:"expires"`
+}
+
+// FileLocker keeps locks as files in directory; directory should be shared by Pumba instances
+// (same host or shared file system with file locks)
+type FileLocker struct {
+	dir string
+	now func() time.Time
+}
+
+// NewFileLocker creates file lock backend in directory; directory is created, if missing
+func NewFileLocker(dir string) (*FileLocker, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("Failed to create lock directory: %s", err)
+	}
+	return &FileLocker{dir: dir, now: time.Now}, nil
+}
+
+func (l *FileLocker) path(key string) string {
+	return filepath.Join(l.dir, safeName(key)+".lock")
+}
+
+// guardName name of guard file in lock directory
+const guardName = ".pumba-lock.guard"
+
+// guarded runs fn holding exclusive OS lock of guard file in lock directory, so reading, replacing and removing
+// lock files is atomic for all Pumba instances, sharing the directory; OS lock is released, if instance dies
+func (l *FileLocker) guarded(fn func() error) error {
+	guard, err := os.OpenFile(filepath.Join(l.dir, guardName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer guard.Close()
+	if err = lockFile(guard); err != nil {
+		return fmt.Errorf("Failed to lock %s: %s", guard.Name(), err)
+	}
+	defer unlockFile(guard)
+	return fn()
+}
+
+// Acquire creates lock file; expired lock file (or lock file of the same owner) is replaced
+func (l *FileLocker) Acquire(key string, owner string, ttl time.Duration) (bool, error) {
+	path := l.path(key)
+	ok := false
+	err := l.guarded(func() error {
+		held, err := l.read(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if err == nil && held.Owner != owner && l.now().Before(held.Expires) {
+			return nil
+		}
+		data, err := json.Marshal(fileLock{Owner: owner, Expires: l.now().Add(ttl)})
+		if err != nil {
+			return err
+		}
+		if err = writeFile(path, data); err != nil {
+			return err
+		}
+		ok = true
+		return nil
+	})
+	return ok, err
+}
+
+// Release removes lock file, if held by owner
+func (l *FileLocker) Release(key string, owner string) error {
+	path := l.path(key)
+	return l.guarded(func() error {
+		held, err := l.read(path)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if held.Owner != owner {
+			return nil
+		}
+		if err = os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	})
+}
+
+// writeFile writes lock to temporary file and renames it to lock path, so lock file is never partially written
+// (e.g. when instance dies)
+func writeFile(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".pumba-lock")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+func (l *FileLocker) read(path string) (fileLock, error) {
+	var held fileLock
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return held, err
+	}
+	if err = json.Unmarshal(data, &held); err != nil {
+		return held, fmt.Errorf("Invalid lock file %s: %s", path, err)
+	}
+	return held, nil
+}
@@ -0,0 +1,116 @@
+package lock
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "pumba-lock")
+	assert.NoError(t, err)
+	return dir
+}
+
+func removeAll(dir string) {
+	os.RemoveAll(dir)
+}
+
+func TestFileLocker(t *testing.T) {
+	dir := tempDir(t)
+	defer removeAll(dir)
+	l, err := NewFileLocker(dir)
+	assert.NoError(t, err)
+
+	ok, err := l.Acquire("pumba/abc", "a", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	// held by another owner
+	ok, err = l.Acquire("pumba/abc", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	// renewed by the same owner
+	ok, err = l.Acquire("pumba/abc", "a", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	// release by another owner is ignored
+	assert.NoError(t, l.Release("pumba/abc", "b"))
+	ok, _ = l.Acquire("pumba/abc", "b", time.Minute)
+	assert.False(t, ok)
+	// released
+	assert.NoError(t, l.Release("pumba/abc", "a"))
+	ok, err = l.Acquire("pumba/abc", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	// only guard and lock files are left
+	files, _ := ioutil.ReadDir(dir)
+	if assert.Equal(t, 2, len(files)) {
+		assert.Equal(t, guardName, files[0].Name())
+		assert.Equal(t, "pumba-abc.lock", files[1].Name())
+	}
+}
+
+func TestFileLocker_Expired(t *testing.T) {
+	dir := tempDir(t)
+	defer removeAll(dir)
+	l, _ := NewFileLocker(dir)
+	now := time.Now()
+	l.now = func() time.Time { return now }
+
+	ok, _ := l.Acquire("pumba/abc", "a", time.Minute)
+	assert.True(t, ok)
+	now = now.Add(2 * time.Minute)
+	ok, err := l.Acquire("pumba/abc", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	// release of expired lock does not remove lock of new owner
+	assert.NoError(t, l.Release("pumba/abc", "a"))
+	ok, _ = l.Acquire("pumba/abc", "a", time.Minute)
+	assert.False(t, ok)
+}
+
+func TestFileLocker_ConcurrentTakeover(t *testing.T) {
+	dir := tempDir(t)
+	defer removeAll(dir)
+	l, _ := NewFileLocker(dir)
+	ok, _ := l.Acquire("pumba/abc", "old", -time.Minute)
+	assert.True(t, ok)
+
+	// instances, that see the same expired lock, do not take it over together
+	var wg sync.WaitGroup
+	acquired := make(chan string, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(owner string) {
+			defer wg.Done()
+			locker, _ := NewFileLocker(dir)
+			// widen window between reading expired lock and replacing it
+			locker.now = func() time.Time {
+				time.Sleep(time.Millisecond)
+				return time.Now()
+			}
+			ok, err := locker.Acquire("pumba/abc", owner, time.Minute)
+			assert.NoError(t, err)
+			if ok {
+				acquired <- owner
+			}
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+	close(acquired)
+
+	var owners []string
+	for owner := range acquired {
+		owners = append(owners, owner)
+	}
+	if assert.Len(t, owners, 1) {
+		held, err := l.read(l.path("pumba/abc"))
+		assert.NoError(t, err)
+		assert.Equal(t, owners[0], held.Owner)
+	}
+}
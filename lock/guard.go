@@ -0,0 +1,17 @@
+// +build !windows
+
+package lock
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes exclusive flock of file; blocks until lock is available
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX)
+}
+
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
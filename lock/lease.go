@@ -0,0 +1,185 @@
+package lock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Kubernetes MicroTime format
+const microTime = "2006-01-02T15:04:05.000000Z07:00"
+
+// lease Kubernetes coordination.k8s.io/v1 Lease object (used fields only)
+type lease struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   leaseMetadata `json:"metadata"`
+	Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace,omitempty"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+}
+
+// expired checks if lease is free or expired
+func (l lease) expired(now time.Time) bool {
+	if l.Spec.HolderIdentity == "" {
+		return true
+	}
+	renewed, err := time.Parse(microTime, l.Spec.RenewTime)
+	if err != nil {
+		return true
+	}
+	return now.After(renewed.Add(time.Duration(l.Spec.LeaseDurationSeconds) * time.Second))
+}
+
+// LeaseLocker keeps locks as Kubernetes Lease objects in namespace; updates use optimistic concurrency
+// (resourceVersion), so only one Pumba instance acquires a lease
+type LeaseLocker struct {
+	server    string
+	token     string
+	namespace string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewLeaseLocker creates Kubernetes Lease lock backend for API server URL and bearer token (empty, if client
+// authenticates requests itself)
+func NewLeaseLocker(server string, token string, namespace string, client *http.Client) *LeaseLocker {
+	return &LeaseLocker{server: server, token: token, namespace: namespace, client: client, now: time.Now}
+}
+
+func (l *LeaseLocker) url(name string) string {
+	u := fmt.Sprintf("%s/apis/coordination.k8s.io/v1/namespaces/%s/leases", l.server, l.namespace)
+	if name != "" {
+		u += "/" + name
+	}
+	return u
+}
+
+// Acquire creates lease or takes over expired (or own) lease; returns false, if lease is held by another owner
+// or was updated concurrently
+func (l *LeaseLocker) Acquire(key string, owner string, ttl time.Duration) (bool, error) {
+	name := safeName(key)
+	seconds := int((ttl + time.Second - 1) / time.Second)
+	now := l.now().UTC().Format(microTime)
+	var current lease
+	code, err := l.request("GET", l.url(name), nil, &current)
+	if err != nil {
+		return false, err
+	}
+	switch code {
+	case http.StatusNotFound:
+		created := lease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   leaseMetadata{Name: name, Namespace: l.namespace},
+			Spec:       leaseSpec{HolderIdentity: owner, LeaseDurationSeconds: seconds, AcquireTime: now, RenewTime: now},
+		}
+		if code, err = l.request("POST", l.url(""), created, nil); err != nil {
+			return false, err
+		}
+		return l.acquired(code)
+	case http.StatusOK:
+		if current.Spec.HolderIdentity != owner && !current.expired(l.now()) {
+			return false, nil
+		}
+		if current.Spec.HolderIdentity != owner {
+			current.Spec.AcquireTime = now
+		}
+		current.Spec.HolderIdentity = owner
+		current.Spec.LeaseDurationSeconds = seconds
+		current.Spec.RenewTime = now
+		if code, err = l.request("PUT", l.url(name), current, nil); err != nil {
+			return false, err
+		}
+		return l.acquired(code)
+	}
+	return false, fmt.Errorf("Failed to get Lease %s/%s: HTTP %d", l.namespace, name, code)
+}
+
+// acquired interprets create/update response code; conflict means lease is taken by another instance
+func (l *LeaseLocker) acquired(code int) (bool, error) {
+	switch {
+	case code == http.StatusConflict:
+		return false, nil
+	case code >= 200 && code < 300:
+		return true, nil
+	}
+	return false, fmt.Errorf("Failed to acquire Lease in namespace %s: HTTP %d", l.namespace, code)
+}
+
+// Release clears holder of lease, if held by owner
+func (l *LeaseLocker) Release(key string, owner string) error {
+	name := safeName(key)
+	var current lease
+	code, err := l.request("GET", l.url(name), nil, &current)
+	if err != nil {
+		return err
+	}
+	if code == http.StatusNotFound {
+		return nil
+	}
+	if code != http.StatusOK {
+		return fmt.Errorf("Failed to get Lease %s/%s: HTTP %d", l.namespace, name, code)
+	}
+	if current.Spec.HolderIdentity != owner {
+		return nil
+	}
+	current.Spec.HolderIdentity = ""
+	if code, err = l.request("PUT", l.url(name), current, nil); err != nil {
+		return err
+	}
+	// conflict: lease is already taken over by another instance
+	if code != http.StatusOK && code != http.StatusConflict {
+		return fmt.Errorf("Failed to release Lease %s/%s: HTTP %d", l.namespace, name, code)
+	}
+	return nil
+}
+
+// request sends JSON request to API server and decodes successful response into out (if not nil)
+func (l *LeaseLocker) request(method string, url string, in interface{}, out interface{}) (int, error) {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return 0, err
+		}
+	}
+	req, err := http.NewRequest(method, url, &body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if l.token != "" {
+		req.Header.Set("Authorization", "Bearer "+l.token)
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("Kubernetes API request failed: %s", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return 0, errors.New("Kubernetes API request unauthorized: invalid or expired credentials")
+	case http.StatusForbidden:
+		return 0, fmt.Errorf("Kubernetes API request forbidden: %s %s; Pumba service account needs 'get', 'create' and 'update' permissions on 'leases' in namespace %s", method, req.URL.Path, l.namespace)
+	}
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err = json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return 0, err
+		}
+	}
+	return resp.StatusCode, nil
+}
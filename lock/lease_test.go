@@ -0,0 +1,129 @@
+package lock
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeLeaseServer serves Lease objects of single namespace with resourceVersion conflicts
+type fakeLeaseServer struct {
+	sync.Mutex
+	leases  map[string]lease
+	version int
+	tokens  []string
+}
+
+func (s *fakeLeaseServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+	s.tokens = append(s.tokens, r.Header.Get("Authorization"))
+	const prefix = "/apis/coordination.k8s.io/v1/namespaces/chaos/leases"
+	name := ""
+	if len(r.URL.Path) > len(prefix)+1 {
+		name = r.URL.Path[len(prefix)+1:]
+	}
+	var in lease
+	if r.Method == "POST" || r.Method == "PUT" {
+		json.NewDecoder(r.Body).Decode(&in)
+	}
+	current, exists := s.leases[name]
+	switch r.Method {
+	case "GET":
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(current)
+	case "POST":
+		if _, ok := s.leases[in.Metadata.Name]; ok {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		s.store(in)
+		w.WriteHeader(http.StatusCreated)
+	case "PUT":
+		if !exists || current.Metadata.ResourceVersion != in.Metadata.ResourceVersion {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		s.store(in)
+	}
+}
+
+func (s *fakeLeaseServer) store(l lease) {
+	s.version++
+	l.Metadata.ResourceVersion = string(rune('0' + s.version))
+	s.leases[l.Metadata.Name] = l
+}
+
+func TestLeaseLocker(t *testing.T) {
+	fake := &fakeLeaseServer{leases: map[string]lease{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	now := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	l := NewLeaseLocker(server.URL, "token", "chaos", http.DefaultClient)
+	l.now = func() time.Time { return now }
+
+	ok, err := l.Acquire("pumba/abc", "a", 90*time.Second)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	created := fake.leases["pumba-abc"]
+	assert.Equal(t, leaseSpec{HolderIdentity: "a", LeaseDurationSeconds: 90, AcquireTime: "2016-08-01T10:00:00.000000Z", RenewTime: "2016-08-01T10:00:00.000000Z"}, created.Spec)
+	assert.Equal(t, "Bearer token", fake.tokens[0])
+
+	// held by another owner
+	ok, err = l.Acquire("pumba/abc", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// renewed by the same owner
+	now = now.Add(time.Minute)
+	ok, err = l.Acquire("pumba/abc", "a", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "2016-08-01T10:00:00.000000Z", fake.leases["pumba-abc"].Spec.AcquireTime)
+	assert.Equal(t, "2016-08-01T10:01:00.000000Z", fake.leases["pumba-abc"].Spec.RenewTime)
+
+	// expired lease is taken over
+	now = now.Add(2 * time.Minute)
+	ok, err = l.Acquire("pumba/abc", "b", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "b", fake.leases["pumba-abc"].Spec.HolderIdentity)
+
+	// release by previous owner is ignored; release by holder clears holder
+	assert.NoError(t, l.Release("pumba/abc", "a"))
+	assert.Equal(t, "b", fake.leases["pumba-abc"].Spec.HolderIdentity)
+	assert.NoError(t, l.Release("pumba/abc", "b"))
+	assert.Equal(t, "", fake.leases["pumba-abc"].Spec.HolderIdentity)
+	ok, err = l.Acquire("pumba/abc", "a", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestLeaseLocker_Error(t *testing.T) {
+	code := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	}))
+	defer server.Close()
+	l := NewLeaseLocker(server.URL, "", "chaos", http.DefaultClient)
+
+	_, err := l.Acquire("pumba/abc", "a", time.Minute)
+	assert.EqualError(t, err, "Failed to get Lease chaos/pumba-abc: HTTP 500")
+	assert.EqualError(t, l.Release("pumba/abc", "a"), "Failed to get Lease chaos/pumba-abc: HTTP 500")
+
+	// RBAC: service account without permissions on leases
+	code = http.StatusForbidden
+	_, err = l.Acquire("pumba/abc", "a", time.Minute)
+	assert.EqualError(t, err, "Kubernetes API request forbidden: GET /apis/coordination.k8s.io/v1/namespaces/chaos/leases/pumba-abc; Pumba service account needs 'get', 'create' and 'update' permissions on 'leases' in namespace chaos")
+	code = http.StatusUnauthorized
+	_, err = l.Acquire("pumba/abc", "a", time.Minute)
+	assert.EqualError(t, err, "Kubernetes API request unauthorized: invalid or expired credentials")
+}
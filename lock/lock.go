@@ -0,0 +1,87 @@
+package lock
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gaia-adm/pumba/kube"
+)
+
+// Locker is a lock (lease) backend, shared by Pumba instances; lock expires after ttl, unless released
+type Locker interface {
+	// Acquire acquires (or renews, if already held by owner) lock with key; returns false, if lock is held
+	// by another owner
+	Acquire(key string, owner string, ttl time.Duration) (bool, error)
+	// Release releases lock with key, if held by owner
+	Release(key string, owner string) error
+}
+
+// Parse creates lock backend from URL: 'file:///path/to/dir', 'redis://[:password@]host:port[/db]'
+// or 'k8s://namespace' (Kubernetes Lease objects; in-cluster service account or kubeconfig is used, see kube.Load)
+func Parse(rawurl string, kubeconfig string) (Locker, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid lock URL '%s': %s", rawurl, err)
+	}
+	switch u.Scheme {
+	case "file":
+		if u.Path == "" {
+			return nil, fmt.Errorf("Invalid lock URL '%s': expected file:///path/to/dir", rawurl)
+		}
+		return NewFileLocker(u.Path)
+	case "redis":
+		return newRedisLocker(u)
+	case "k8s":
+		if u.Host == "" {
+			return nil, fmt.Errorf("Invalid lock URL '%s': expected k8s://namespace", rawurl)
+		}
+		cfg, err := kube.Load(kubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		return NewLeaseLocker(cfg.Server, "", u.Host, cfg.Client), nil
+	}
+	return nil, fmt.Errorf("Unsupported lock URL '%s': must be 'file://', 'redis://' or 'k8s://'", rawurl)
+}
+
+// Password returns password of lock backend URL (e.g. 'redis://:password@host'), or empty string
+func Password(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil || u.User == nil {
+		return ""
+	}
+	p, _ := u.User.Password()
+	return p
+}
+
+// Owner returns lock owner identity of current Pumba instance: host name and PID
+func Owner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Key returns lock key of container in lock namespace
+func Key(namespace string, containerID string) string {
+	return namespace + "/" + containerID
+}
+
+// safeName converts lock key to name, valid for files and Kubernetes objects (DNS-1123 subdomain)
+func safeName(key string) string {
+	name := []byte(strings.ToLower(key))
+	for i, b := range name {
+		if !(b >= 'a' && b <= 'z' || b >= '0' && b <= '9' || b == '-' || b == '.') {
+			name[i] = '-'
+		}
+	}
+	s := strings.Trim(string(name), "-.")
+	if len(s) > 253 {
+		s = s[:253]
+	}
+	return s
+}
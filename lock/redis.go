@@ -0,0 +1,158 @@
+package lock
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redis connection and I/O timeout
+const redisTimeout = 5 * time.Second
+
+// acquire lock, if it's free or held by the same owner; KEYS[1] - key, ARGV[1] - owner, ARGV[2] - ttl in ms
+const redisAcquireScript = `local v = redis.call('GET', KEYS[1])
+if v == false or v == ARGV[1] then
+  redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+  return 1
+end
+return 0`
+
+// release lock, if it's held by owner; KEYS[1] - key, ARGV[1] - owner
+const redisReleaseScript = `if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0`
+
+// RedisLocker keeps locks as Redis keys with expiration; minimal RESP client, connection per operation
+type RedisLocker struct {
+	addr     string
+	password string
+	db       int
+}
+
+func newRedisLocker(u *url.URL) (*RedisLocker, error) {
+	l := &RedisLocker{addr: u.Host}
+	if !strings.Contains(l.addr, ":") {
+		l.addr += ":6379"
+	}
+	if u.User != nil {
+		if p, ok := u.User.Password(); ok {
+			l.password = p
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		n, err := strconv.Atoi(db)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("Invalid Redis database '%s' in lock URL", db)
+		}
+		l.db = n
+	}
+	return l, nil
+}
+
+// Acquire sets Redis key to owner with ttl, if key is missing or already held by owner
+func (l *RedisLocker) Acquire(key string, owner string, ttl time.Duration) (bool, error) {
+	ms := int64(ttl / time.Millisecond)
+	if ms <= 0 {
+		ms = 1
+	}
+	reply, err := l.do("EVAL", redisAcquireScript, "1", key, owner, strconv.FormatInt(ms, 10))
+	if err != nil {
+		return false, err
+	}
+	return reply == int64(1), nil
+}
+
+// Release deletes Redis key, if held by owner
+func (l *RedisLocker) Release(key string, owner string) error {
+	_, err := l.do("EVAL", redisReleaseScript, "1", key, owner)
+	return err
+}
+
+// do runs command on new connection, after optional AUTH and SELECT
+func (l *RedisLocker) do(args ...string) (interface{}, error) {
+	conn, err := net.DialTimeout("tcp", l.addr, redisTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to Redis lock server: %s", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(redisTimeout))
+	r := bufio.NewReader(conn)
+	var cmds [][]string
+	if l.password != "" {
+		cmds = append(cmds, []string{"AUTH", l.password})
+	}
+	if l.db != 0 {
+		cmds = append(cmds, []string{"SELECT", strconv.Itoa(l.db)})
+	}
+	cmds = append(cmds, args)
+	var reply interface{}
+	for _, cmd := range cmds {
+		if _, err = conn.Write(encodeRESP(cmd)); err != nil {
+			return nil, err
+		}
+		if reply, err = readRESP(r); err != nil {
+			return nil, fmt.Errorf("Redis %s failed: %s", cmd[0], err)
+		}
+	}
+	return reply, nil
+}
+
+// encodeRESP encodes command as RESP array of bulk strings
+func encodeRESP(args []string) []byte {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, a := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(a))+"\r\n"+a+"\r\n"...)
+	}
+	return buf
+}
+
+// readRESP reads RESP reply: simple string, error, integer (int64), bulk string (nil if missing)
+// or array ([]interface{})
+func readRESP(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err = io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readRESP(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unexpected reply '%s'", line)
+}
@@ -0,0 +1,96 @@
+package lock
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedis accepts single connection, records commands and answers with replies in order
+func fakeRedis(t *testing.T, replies ...string) (string, <-chan []interface{}) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	cmds := make(chan []interface{}, len(replies))
+	go func() {
+		defer ln.Close()
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for _, reply := range replies {
+			cmd, err := readRESP(r)
+			if err != nil {
+				return
+			}
+			cmds <- cmd.([]interface{})
+			conn.Write([]byte(reply))
+		}
+		close(cmds)
+	}()
+	return ln.Addr().String(), cmds
+}
+
+func TestRESP(t *testing.T) {
+	assert.Equal(t, "*2\r\n$3\r\nGET\r\n$1\r\nk\r\n", string(encodeRESP([]string{"GET", "k"})))
+	for reply, expected := range map[string]interface{}{
+		"+OK\r\n":                  "OK",
+		":1\r\n":                   int64(1),
+		"$5\r\nhello\r\n":          "hello",
+		"$2\r\n\r\n\r\n":           "\r\n",
+		"$-1\r\n":                  nil,
+		"*2\r\n:1\r\n$2\r\nab\r\n": []interface{}{int64(1), "ab"},
+	} {
+		v, err := readRESP(bufio.NewReader(strings.NewReader(reply)))
+		assert.NoError(t, err, reply)
+		assert.Equal(t, expected, v, reply)
+	}
+	_, err := readRESP(bufio.NewReader(strings.NewReader("-ERR wrong password\r\n")))
+	assert.EqualError(t, err, "ERR wrong password")
+}
+
+func TestRedisLocker(t *testing.T) {
+	addr, cmds := fakeRedis(t, "+OK\r\n", "+OK\r\n", ":1\r\n")
+	l := &RedisLocker{addr: addr, password: "secret", db: 2}
+
+	ok, err := l.Acquire("pumba/abc", "a", 90*time.Second)
+
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{"AUTH", "secret"}, <-cmds)
+	assert.Equal(t, []interface{}{"SELECT", "2"}, <-cmds)
+	assert.Equal(t, []interface{}{"EVAL", redisAcquireScript, "1", "pumba/abc", "a", "90000"}, <-cmds)
+}
+
+func TestRedisLocker_Held(t *testing.T) {
+	addr, cmds := fakeRedis(t, ":0\r\n")
+	l := &RedisLocker{addr: addr}
+
+	ok, err := l.Acquire("pumba/abc", "b", time.Minute)
+
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, "EVAL", (<-cmds)[0])
+}
+
+func TestRedisLocker_Release(t *testing.T) {
+	addr, cmds := fakeRedis(t, ":1\r\n")
+	l := &RedisLocker{addr: addr}
+
+	assert.NoError(t, l.Release("pumba/abc", "a"))
+	assert.Equal(t, []interface{}{"EVAL", redisReleaseScript, "1", "pumba/abc", "a"}, <-cmds)
+}
+
+func TestRedisLocker_AuthError(t *testing.T) {
+	addr, _ := fakeRedis(t, "-ERR invalid password\r\n")
+	l := &RedisLocker{addr: addr, password: "bad"}
+
+	_, err := l.Acquire("pumba/abc", "a", time.Minute)
+
+	assert.EqualError(t, err, "Redis AUTH failed: ERR invalid password")
+}
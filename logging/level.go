@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// LevelPath Pumba API endpoint of runtime log level
+const LevelPath = "/loglevel"
+
+// override runtime log level, that replaces configured levels of standard logger and all modules until reset
+var override struct {
+	sync.RWMutex
+	set   bool
+	level log.Level
+	// saved configured level of standard logger
+	saved log.Level
+}
+
+// SetLevel changes log level of all modules at runtime, without restart; configured levels are restored by ResetLevel
+func SetLevel(level log.Level) {
+	override.Lock()
+	defer override.Unlock()
+	if !override.set {
+		override.saved = log.GetLevel()
+	}
+	override.set = true
+	override.level = level
+	log.SetLevel(level)
+}
+
+// ResetLevel restores configured log levels
+func ResetLevel() {
+	override.Lock()
+	defer override.Unlock()
+	if override.set {
+		override.set = false
+		log.SetLevel(override.saved)
+	}
+}
+
+// LevelOverride returns runtime log level and reports whether it is set
+func LevelOverride() (log.Level, bool) {
+	override.RLock()
+	defer override.RUnlock()
+	return override.level, override.set
+}
+
+// ToggleDebug turns debug logging on and, when it is on, restores configured log levels; reports whether debug
+// logging is on
+func ToggleDebug() bool {
+	if level, ok := LevelOverride(); ok && level == log.DebugLevel {
+		ResetLevel()
+		log.Info("Debug logging is off: configured log levels are restored")
+		return false
+	}
+	SetLevel(log.DebugLevel)
+	log.Info("Debug logging is on")
+	return true
+}
+
+// LevelHandler serves runtime log level: GET returns current level, PUT sets level (body like 'debug'),
+// DELETE restores configured levels
+type LevelHandler struct{}
+
+func (LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+	case "PUT":
+		body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, 64))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		level, err := log.ParseLevel(strings.TrimSpace(string(body)))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid log level '%s'", strings.TrimSpace(string(body))), http.StatusBadRequest)
+			return
+		}
+		SetLevel(level)
+		log.Infof("Log level is set to %s", level)
+	case "DELETE":
+		ResetLevel()
+		log.Info("Configured log levels are restored")
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintln(w, log.GetLevel())
+}
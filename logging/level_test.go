@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToggleDebug(t *testing.T) {
+	defer log.SetLevel(log.GetLevel())
+	log.SetLevel(log.WarnLevel)
+	assert.True(t, ToggleDebug())
+	assert.Equal(t, log.DebugLevel, log.GetLevel())
+	assert.False(t, ToggleDebug())
+	assert.Equal(t, log.WarnLevel, log.GetLevel())
+	_, ok := LevelOverride()
+	assert.False(t, ok)
+}
+
+func TestFormatter_LevelOverride(t *testing.T) {
+	defer ResetLevel()
+	f := &Formatter{Formatter: &log.TextFormatter{DisableColors: true}, Level: log.InfoLevel, Levels: map[string]log.Level{"logging": log.WarnLevel}}
+	logger, out := testLogger(f)
+	SetLevel(log.DebugLevel)
+	logger.Debug("debug message")
+	assert.Contains(t, out.String(), "debug message")
+	ResetLevel()
+	logger.Info("info message")
+	assert.NotContains(t, out.String(), "info message")
+}
+
+func TestLevelHandler(t *testing.T) {
+	defer log.SetLevel(log.GetLevel())
+	log.SetLevel(log.InfoLevel)
+	serve := func(method, body string) *httptest.ResponseRecorder {
+		r, _ := http.NewRequest(method, LevelPath, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		LevelHandler{}.ServeHTTP(w, r)
+		return w
+	}
+	w := serve("GET", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "info\n", w.Body.String())
+
+	w = serve("PUT", "debug\n")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "debug\n", w.Body.String())
+	assert.Equal(t, log.DebugLevel, log.GetLevel())
+
+	w = serve("PUT", "loud")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Invalid log level 'loud'\n", w.Body.String())
+
+	w = serve("DELETE", "")
+	assert.Equal(t, "info\n", w.Body.String())
+	assert.Equal(t, log.InfoLevel, log.GetLevel())
+
+	w = serve("POST", "")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
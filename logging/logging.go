@@ -0,0 +1,203 @@
+package logging
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaia-adm/pumba/validate"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// DefaultSamplingTick default sampling period
+const DefaultSamplingTick = 1 * time.Second
+
+// Config structured logging configuration
+type Config struct {
+	// Caller add caller (file:line) field to all log events
+	Caller bool `yaml:"caller"`
+	// Levels log levels of Pumba modules (Go packages), like 'container: debug' or 'scheduler: warning';
+	// other modules use global log level
+	Levels map[string]string `yaml:"levels"`
+	// Sampling sampling of repetitive log messages
+	Sampling *Sampling `yaml:"sampling"`
+}
+
+// Sampling logs First events with the same level and message during each Tick and then every Thereafter-th event;
+// Thereafter 0 drops all the rest
+type Sampling struct {
+	Tick       string `yaml:"tick"`
+	First      int    `yaml:"first"`
+	Thereafter int    `yaml:"thereafter"`
+}
+
+// Validate checks logging configuration
+func (cfg Config) Validate() error {
+	for module, level := range cfg.Levels {
+		if _, err := log.ParseLevel(level); err != nil {
+			return fmt.Errorf("Invalid log level '%s' of module '%s'", level, module)
+		}
+	}
+	if s := cfg.Sampling; s != nil {
+		if s.Tick != "" {
+			if d, err := validate.Duration(s.Tick); err != nil || d == 0 {
+				return fmt.Errorf("Invalid log sampling tick '%s'", s.Tick)
+			}
+		}
+		if s.First < 1 || s.Thereafter < 0 {
+			return fmt.Errorf("Invalid log sampling: 'first' must be 1 or more and 'thereafter' must be 0 or more")
+		}
+	}
+	return nil
+}
+
+// Setup applies logging configuration to standard logger; should be called after log level and formatter are set
+func Setup(cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	logger := log.StandardLogger()
+	if cfg.Caller {
+		logger.Hooks.Add(callerHook{})
+	}
+	if len(cfg.Levels) == 0 && cfg.Sampling == nil {
+		return nil
+	}
+	f := &Formatter{Formatter: logger.Formatter, Level: logger.Level, Levels: map[string]log.Level{}}
+	for module, level := range cfg.Levels {
+		l, _ := log.ParseLevel(level)
+		f.Levels[module] = l
+		// logger should pass events of the most verbose module
+		if l > logger.Level {
+			logger.Level = l
+		}
+	}
+	if s := cfg.Sampling; s != nil {
+		tick := DefaultSamplingTick
+		if s.Tick != "" {
+			tick, _ = validate.Duration(s.Tick)
+		}
+		f.Sampler = NewSampler(tick, s.First, s.Thereafter)
+	}
+	logger.Formatter = f
+	return nil
+}
+
+// Formatter drops log events below level of module, that logged the event (or below runtime log level, if set), and
+// events rejected by sampler; passes other events to wrapped formatter
+type Formatter struct {
+	log.Formatter
+	// Level default level of modules
+	Level log.Level
+	// Levels level per module
+	Levels map[string]log.Level
+	// Sampler optional sampler of repetitive events
+	Sampler *Sampler
+}
+
+// Format formats log event; dropped event is formatted as empty output
+func (f *Formatter) Format(entry *log.Entry) ([]byte, error) {
+	level := f.Level
+	if l, ok := LevelOverride(); ok {
+		// runtime log level replaces module levels
+		level = l
+	} else if len(f.Levels) > 0 {
+		if _, module := caller(); module != "" {
+			if l, ok := f.Levels[module]; ok {
+				level = l
+			}
+		}
+	}
+	if entry.Level > level {
+		return nil, nil
+	}
+	if f.Sampler != nil && !f.Sampler.Sample(entry) {
+		return nil, nil
+	}
+	return f.Formatter.Format(entry)
+}
+
+// Sampler samples repetitive log events
+type Sampler struct {
+	tick       time.Duration
+	first      int
+	thereafter int
+	now        func() time.Time
+	mu         sync.Mutex
+	start      time.Time
+	counts     map[string]int
+}
+
+// NewSampler creates new sampler
+func NewSampler(tick time.Duration, first, thereafter int) *Sampler {
+	return &Sampler{tick: tick, first: first, thereafter: thereafter, now: time.Now, counts: map[string]int{}}
+}
+
+// Sample reports whether log event should be logged
+func (s *Sampler) Sample(entry *log.Entry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if now.Sub(s.start) >= s.tick {
+		s.start = now
+		s.counts = map[string]int{}
+	}
+	key := entry.Level.String() + ":" + entry.Message
+	s.counts[key]++
+	n := s.counts[key]
+	if n <= s.first {
+		return true
+	}
+	return s.thereafter > 0 && (n-s.first)%s.thereafter == 0
+}
+
+// callerHook adds caller (file:line) field to log events
+type callerHook struct{}
+
+func (callerHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (callerHook) Fire(entry *log.Entry) error {
+	if location, _ := caller(); location != "" {
+		entry.Data["caller"] = location
+	}
+	return nil
+}
+
+// caller returns location ('container/client.go:123') and module (Go package name) of code, that logged an event:
+// the first stack frame outside of logrus and this package
+func caller() (string, string) {
+	pc := make([]uintptr, 32)
+	n := runtime.Callers(2, pc)
+	for _, p := range pc[:n] {
+		fn := runtime.FuncForPC(p - 1)
+		if fn == nil {
+			continue
+		}
+		name := fn.Name()
+		file, line := fn.FileLine(p - 1)
+		if strings.Contains(name, "github.com/Sirupsen/logrus") ||
+			(strings.Contains(name, "github.com/gaia-adm/pumba/logging.") && !strings.HasSuffix(file, "_test.go")) {
+			continue
+		}
+		location := fmt.Sprintf("%s/%s:%d", filepath.Base(filepath.Dir(file)), filepath.Base(file), line)
+		return location, module(name)
+	}
+	return "", ""
+}
+
+// module returns package name of function: 'github.com/gaia-adm/pumba/container.dockerClient.StopContainer' -> 'container'
+func module(function string) string {
+	if i := strings.LastIndex(function, "/"); i >= 0 {
+		function = function[i+1:]
+	}
+	if i := strings.Index(function, "."); i >= 0 {
+		return function[:i]
+	}
+	return function
+}
@@ -0,0 +1,79 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func testLogger(f log.Formatter) (*log.Logger, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	logger := log.New()
+	logger.Out = out
+	logger.Formatter = f
+	logger.Level = log.DebugLevel
+	return logger, out
+}
+
+func TestConfig_Validate(t *testing.T) {
+	assert.NoError(t, Config{Levels: map[string]string{"container": "debug"}, Sampling: &Sampling{Tick: "5s", First: 10}}.Validate())
+	assert.EqualError(t, Config{Levels: map[string]string{"container": "loud"}}.Validate(), "Invalid log level 'loud' of module 'container'")
+	assert.EqualError(t, Config{Sampling: &Sampling{Tick: "soon", First: 1}}.Validate(), "Invalid log sampling tick 'soon'")
+	assert.EqualError(t, Config{Sampling: &Sampling{Tick: "-5s", First: 1}}.Validate(), "Invalid log sampling tick '-5s'")
+	assert.EqualError(t, Config{Sampling: &Sampling{First: 0}}.Validate(), "Invalid log sampling: 'first' must be 1 or more and 'thereafter' must be 0 or more")
+}
+
+func TestModule(t *testing.T) {
+	assert.Equal(t, "container", module("github.com/gaia-adm/pumba/container.dockerClient.StopContainer"))
+	assert.Equal(t, "action", module("github.com/gaia-adm/pumba/action.(*Pumba).KillContainers"))
+	assert.Equal(t, "main", module("main.before"))
+}
+
+func TestCallerHook(t *testing.T) {
+	logger, out := testLogger(&log.TextFormatter{DisableColors: true})
+	logger.Hooks.Add(callerHook{})
+	logger.Info("hello")
+	assert.Contains(t, out.String(), "caller=\"logging/logging_test.go:")
+}
+
+func TestFormatter_ModuleLevels(t *testing.T) {
+	f := &Formatter{Formatter: &log.TextFormatter{DisableColors: true}, Level: log.DebugLevel, Levels: map[string]log.Level{"logging": log.WarnLevel}}
+	logger, out := testLogger(f)
+	logger.Info("info message")
+	logger.Warn("warning message")
+	assert.NotContains(t, out.String(), "info message")
+	assert.Contains(t, out.String(), "warning message")
+	// other modules use default level
+	f.Levels = map[string]log.Level{"container": log.WarnLevel}
+	logger.Debug("debug message")
+	assert.Contains(t, out.String(), "debug message")
+}
+
+func TestSampler(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := NewSampler(time.Second, 2, 3)
+	s.now = func() time.Time { return now }
+	entry := &log.Entry{Level: log.InfoLevel, Message: "Killing container"}
+	var sampled []bool
+	for i := 0; i < 8; i++ {
+		sampled = append(sampled, s.Sample(entry))
+	}
+	assert.Equal(t, []bool{true, true, false, false, true, false, false, true}, sampled)
+	// other message is counted separately
+	assert.True(t, s.Sample(&log.Entry{Level: log.InfoLevel, Message: "Stopping container"}))
+	// new tick resets counters
+	now = now.Add(time.Second)
+	assert.True(t, s.Sample(entry))
+}
+
+func TestFormatter_Sampling(t *testing.T) {
+	logger, out := testLogger(&Formatter{Formatter: &log.TextFormatter{DisableColors: true}, Level: log.DebugLevel, Sampler: NewSampler(time.Minute, 1, 0)})
+	for i := 0; i < 5; i++ {
+		logger.Info("repeated")
+	}
+	assert.Equal(t, 1, strings.Count(out.String(), "repeated"))
+}
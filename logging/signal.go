@@ -0,0 +1,11 @@
+// +build !windows
+
+package logging
+
+import (
+	"os"
+	"syscall"
+)
+
+// DebugSignal signal, that toggles debug logging of running Pumba instance
+var DebugSignal os.Signal = syscall.SIGUSR2
@@ -0,0 +1,6 @@
+package logging
+
+import "os"
+
+// DebugSignal is not supported on Windows
+var DebugSignal os.Signal
@@ -1,22 +1,54 @@
 package main
 
 import (
+	"bufio"
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
+	"net/http"
+	"net/http/pprof"
 	"os"
 	"os/signal"
-	"regexp"
+	"path/filepath"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gaia-adm/pumba/action"
+	"github.com/gaia-adm/pumba/backoff"
+	"github.com/gaia-adm/pumba/beacon"
+	"github.com/gaia-adm/pumba/config"
 	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/events"
+	"github.com/gaia-adm/pumba/fdlimit"
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/history"
+	"github.com/gaia-adm/pumba/hook"
+	"github.com/gaia-adm/pumba/lock"
+	"github.com/gaia-adm/pumba/logging"
+	"github.com/gaia-adm/pumba/marker"
+	"github.com/gaia-adm/pumba/metrics"
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/gaia-adm/pumba/output"
+	"github.com/gaia-adm/pumba/proxy"
+	"github.com/gaia-adm/pumba/redact"
+	"github.com/gaia-adm/pumba/report"
+	"github.com/gaia-adm/pumba/scenario"
+	"github.com/gaia-adm/pumba/scheduler"
+	"github.com/gaia-adm/pumba/simulate"
+	"github.com/gaia-adm/pumba/snapshot"
+	"github.com/gaia-adm/pumba/state"
+	"github.com/gaia-adm/pumba/status"
+	"github.com/gaia-adm/pumba/summary"
+	"github.com/gaia-adm/pumba/targets"
+	"github.com/gaia-adm/pumba/ui"
+	"github.com/gaia-adm/pumba/validate"
 
 	"github.com/urfave/cli"
 
@@ -29,42 +61,106 @@ var (
 	client    container.Client
 	chaos     action.Chaos
 	gInterval time.Duration
-	gTestRun  bool
+	// stop chaos command after number of runs; 0 - no limit
+	gMaxRuns int
+	gMarker  marker.Marker
+	// run chaos command once and exit
+	gOnce bool
+	// run chaos command specified number of times back-to-back and exit; 0 - recurrent runs
+	gCount int
+	// delay between back-to-back runs (--count)
+	gDelayBetween time.Duration
+	// delay after startup before the first chaos run
+	gWarmup time.Duration
+	// number of failed chaos commands to tolerate; negative: no limit
+	gTolerateFailures = -1
+	// masks sensitive values in logs and audit trail
+	gRedactor = redact.New()
+	// key=value pairs (--context) attached to all events, metrics and reports
+	gContext map[string]string
+	// state file with active disruptions (--state-file)
+	gState *state.File
+	// container locks, held by chaos actions (--lock)
+	gLocks *lock.Held
+	// admission control of active disruptions (--max-active)
+	gAdmission *lock.Admission
+	// chaos event bus; notifiers, metrics, Pumba API and summary subscribe to chaos events
+	gBus = events.NewBus()
+	// Pumba API status server (--api-addr)
+	gStatus *status.Server
+	// running chaos command schedulers; stopped on termination
+	gSchedulers scheduler.Group
+	// gGates manual gates of scenario steps, confirmed with Pumba API (if served); closed on termination
+	gGates = scenario.NewGates()
+	// output of scenario matrix and assertions reports
+	gMatrixOut io.Writer = os.Stdout
+	// summary of chaos actions, logged periodically
+	gSummary *summary.Summary
+	// simulation of chaos command schedule (--simulate) and its report output
+	gSimulation       *simulate.Simulation
+	gSimulationOut    io.Writer = os.Stdout
+	gSimulationFormat           = output.Table
+	// chaos command line, like 'kill --signal SIGTERM re2:^api'
+	gCommandLine string
+	// experiment history (--history-db)
+	gHistory *history.Store
+	// target ALL containers, when chaos command has no targets (--all)
+	gAll bool
+	// gNamePrefix name prefix of target containers
+	gNamePrefix string
+	// gTargets target container names or IDs, read from --targets-file; nil - targets are command arguments
+	gTargets targets.Source
+	// confirm targets of destructive chaos commands (--interactive)
+	gInteractive bool
+	// confirmation prompt input and output
+	gPromptIn  io.Reader = os.Stdin
+	gPromptOut io.Writer = os.Stderr
+	// isTerminal reports whether Pumba runs from TTY
+	isTerminal = func() bool {
+		info, err := os.Stdin.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+)
+
+// chaosFunc is a chaos action, like Chaos.KillContainers
+type chaosFunc func(container.Client, []string, string, interface{}) error
+
+// chaos commands, that can be combined by 'multi' command
+var multiCommands = map[string]bool{
+	"kill":  true,
+	"netem": true,
+	"http":  true,
+	"ports": true,
+	"pause": true,
+	"cpu":   true,
+	"oom":   true,
+	"stop":  true,
+	"rm":    true,
+}
+
+// in-container exec hooks flags of disruption commands (netem, pause, ports)
+var (
+	execBeforeFlag = cli.StringFlag{
+		Name:  "exec-before",
+		Usage: "shell command to exec inside target container before disruption starts",
+	}
+	execAfterFlag = cli.StringFlag{
+		Name:  "exec-after",
+		Usage: "shell command to exec inside target container after disruption ends, e.g. 'nginx -s reload'",
+	}
 )
 
-// LinuxSignals valid Linux signal table
-// http://www.comptechdoc.org/os/linux/programming/linux_pgsignals.html
-var LinuxSignals = map[string]int{
-	"SIGHUP":    1,
-	"SIGINT":    2,
-	"SIGQUIT":   3,
-	"SIGILL":    4,
-	"SIGTRAP":   5,
-	"SIGIOT":    6,
-	"SIGBUS":    7,
-	"SIGFPE":    8,
-	"SIGKILL":   9,
-	"SIGUSR1":   10,
-	"SIGSEGV":   11,
-	"SIGUSR2":   12,
-	"SIGPIPE":   13,
-	"SIGALRM":   14,
-	"SIGTERM":   15,
-	"SIGSTKFLT": 16,
-	"SIGCHLD":   17,
-	"SIGCONT":   18,
-	"SIGSTOP":   19,
-	"SIGTSTP":   20,
-	"SIGTTIN":   21,
-	"SIGTTOU":   22,
-	"SIGURG":    23,
-	"SIGXCPU":   24,
-	"SIGXFSZ":   25,
-	"SIGVTALRM": 26,
-	"SIGPROF":   27,
-	"SIGWINCH":  28,
-	"SIGIO":     29,
-	"SIGPWR":    30,
+// output format flag of list, status and report commands
+var formatFlag = cli.StringFlag{
+	Name:  "format",
+	Usage: "output format: 'table', 'json' or 'yaml'",
+	Value: output.Table,
+}
+
+// host directory with static helper binaries, injected into target containers (netem, cpu, oom)
+var injectToolsFlag = cli.StringFlag{
+	Name:  "inject-tools",
+	Usage: "host directory with static helper binaries (e.g. tc, iptables, busybox), copied into target container before chaos action and removed afterwards",
 }
 
 const (
@@ -74,20 +170,31 @@ const (
 	DefaultSignal = "SIGKILL"
 	// Re2Prefix re2 regexp string prefix
 	Re2Prefix = "re2:"
+	// DefaultProxyImage default Docker image for HTTP proxy sidecar
+	DefaultProxyImage = "gaiaadm/pumba"
+	// DefaultProxyPort default HTTP proxy sidecar port
+	DefaultProxyPort = 15001
+	// DefaultHTTPErrorCode default HTTP status code for failed requests
+	DefaultHTTPErrorCode = 503
+	// DefaultMarkFile default audit file for 'audit' mark
+	DefaultMarkFile = "pumba-audit.log"
+	// DefaultConfigFile default configuration file with chaos profiles
+	DefaultConfigFile = "pumba.yml"
+	// snapshotPruneInterval maximal interval between removals of expired container snapshots
+	snapshotPruneInterval = 10 * time.Minute
 )
 
 func init() {
 	log.SetLevel(log.InfoLevel)
 	log.SetFormatter(&log.TextFormatter{})
-	// set chaos to Pumba{}
-	chaos = action.Pumba{}
+	// set chaos to Pumba with group rotation state
+	chaos = action.NewPumba()
 }
 
 func main() {
-	rootCertPath := "/etc/ssl/docker"
-
-	if os.Getenv("DOCKER_CERT_PATH") != "" {
-		rootCertPath = os.Getenv("DOCKER_CERT_PATH")
+	rootCertPath := os.Getenv("DOCKER_CERT_PATH")
+	if rootCertPath == "" {
+		rootCertPath = defaultCertPath()
 	}
 
 	app := cli.NewApp()
@@ -96,6 +203,8 @@ func main() {
 	app.Usage = "Pumba is a resilience testing tool, that helps applications tolerate random Docker container failures: process, network and performance."
 	app.ArgsUsage = "containers (name, list of names, RE2 regex)"
 	app.Before = before
+	app.After = after
+	app.Action = runProfile
 	app.Commands = []cli.Command{
 		{
 			Name: "kill",
@@ -105,6 +214,10 @@ func main() {
 					Usage: "termination signal, that will be sent by Pumba to the main process inside target container(s)",
 					Value: DefaultSignal,
 				},
+				cli.BoolFlag{
+					Name:  "cascade",
+					Usage: "also kill containers of docker-compose services depending on target container(s) (depends_on or links), following dependency chain",
+				},
 			},
 			Usage:       "kill specified containers",
 			ArgsUsage:   "containers (name, list of names, RE2 regex)",
@@ -121,13 +234,60 @@ func main() {
 				},
 				cli.StringFlag{
 					Name:  "interface, i",
-					Usage: "network interface to apply delay on",
+					Usage: "network interface to apply delay on (e.g. 'eth0', 'ens3', 'enp0s8'); 'all' - all container interfaces, that are up, except loopback",
 					Value: "eth0",
 				},
+				cli.StringFlag{
+					Name:  "network, n",
+					Usage: "Docker network name; apply delay on container interface connected to this network (macvlan, overlay and other drivers); overrides --interface",
+				},
 				cli.StringFlag{
 					Name:  "target, t",
-					Usage: "target IP filter; netem will impact only on traffic to target IP",
+					Usage: "target IP filter: comma-separated IP addresses, CIDR ranges and host names, e.g. '10.0.0.1,10.1.0.0/16,db.service.local'; netem will impact only on traffic to target IPs; host names are resolved by Pumba and re-resolved every 30s during netem",
+				},
+				cli.StringFlag{
+					Name:  "exclude",
+					Usage: "excluded IP filter: comma-separated IP addresses and CIDR ranges, e.g. '127.0.0.11,10.96.0.0/12'; traffic to excluded IPs bypasses netem, even if selected by other filters",
+				},
+				cli.StringFlag{
+					Name:  "target-alias",
+					Usage: "target host name filter (e.g. Docker network alias); resolved with container DNS on each run, netem will impact only on traffic to resolved IPs",
+				},
+				cli.BoolFlag{
+					Name:  "target-peer",
+					Usage: "target peer filter: netem will impact only on traffic to other matching containers (on --network, if set); with --random, a random pair of target container and peer is selected on each run",
+				},
+				cli.StringFlag{
+					Name:  "protocol",
+					Usage: "IP protocol filter: 'tcp', 'udp' or 'icmp'; netem will impact only on traffic of this protocol",
+				},
+				cli.IntFlag{
+					Name:  "port",
+					Usage: "destination port filter; netem will impact only on traffic to this TCP/UDP port",
+				},
+				cli.BoolFlag{
+					Name:  "fwmark",
+					Usage: "per-connection mode: mark selected packets with iptables (MARK) and apply netem only to marked traffic with tc fw filter; requires iptables in container",
+				},
+				cli.StringFlag{
+					Name:  "uid",
+					Usage: "socket owner filter (user ID or name) for --fwmark mode; netem will impact only on traffic of this user processes",
+				},
+				cli.IntFlag{
+					Name:  "dst-percent",
+					Usage: "partial upstream degradation: netem will impact only on traffic to this percent of destinations, selected deterministically by destination IP hash (16 buckets); not supported with --target, --target-alias, --target-peer and --fwmark",
+				},
+				cli.BoolFlag{
+					Name:  "reapply-on-restart",
+					Usage: "watch Docker events and re-apply netem for the remaining duration, if target container is restarted",
+				},
+				cli.StringFlag{
+					Name:  "probe",
+					Usage: "verify impairment: measure latency to this host (ping) or HTTP(S) URL (curl) from container before netem and under netem, and report baseline and impaired latency",
 				},
+				execBeforeFlag,
+				execAfterFlag,
+				injectToolsFlag,
 			},
 			Usage:       "emulate the properties of wide area networks",
 			ArgsUsage:   "containers (name, list of names, RE2 regex)",
@@ -151,6 +311,22 @@ func main() {
 							Usage: "delay correlation; in percents",
 							Value: 20,
 						},
+						cli.BoolFlag{
+							Name:  "jitter-only",
+							Usage: "jitter-only delay: zero base delay with random --variation (jitter); --amount is ignored",
+						},
+						cli.StringFlag{
+							Name:  "distribution",
+							Usage: "delay jitter distribution: 'uniform', 'normal', 'pareto' or 'paretonormal' (default: uniform)",
+						},
+						cli.IntFlag{
+							Name:  "reorder",
+							Usage: "percent of packets to send immediately (reordered); by default, delay jitter is applied in order (no reordering)",
+						},
+						cli.IntFlag{
+							Name:  "limit",
+							Usage: "netem queue limit, in packets (default: netem 1000 packets, or 10 packets per millisecond of maximum delay of 1s or more)",
+						},
 					},
 					Usage:       "dealy egress traffic",
 					ArgsUsage:   "containers (name, list of names, RE2 regex)",
@@ -160,14 +336,241 @@ func main() {
 				},
 				{
 					Name: "loss",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "model, m",
+							Usage: "packet loss model: 'random' (independent loss), 'state' (4-state Markov model) or 'gemodel' (Gilbert-Elliott model) for bursty loss",
+							Value: netem.LossModelRandom,
+						},
+						cli.IntFlag{
+							Name:  "percent, p",
+							Usage: "percent of packets to drop; 'random' model",
+							Value: 1,
+						},
+						cli.IntFlag{
+							Name:  "correlation, c",
+							Usage: "loss correlation; in percents; 'random' model",
+						},
+						cli.IntFlag{
+							Name:  "p13",
+							Usage: "probability to go from good reception state to burst loss state; in percents; 'state' model",
+						},
+						cli.IntFlag{
+							Name:  "p31",
+							Usage: "probability to go from burst loss state back to good reception state; in percents; 'state' model (default: 100 - p13)",
+						},
+						cli.IntFlag{
+							Name:  "p32",
+							Usage: "probability to go from burst loss state to good reception within burst state; in percents; 'state' model",
+						},
+						cli.IntFlag{
+							Name:  "p23",
+							Usage: "probability to go from good reception within burst state back to burst loss state; in percents; 'state' model (default: 100)",
+						},
+						cli.IntFlag{
+							Name:  "p14",
+							Usage: "probability to go from good reception state to isolated loss state; in percents; 'state' model",
+						},
+						cli.IntFlag{
+							Name:  "pg",
+							Usage: "transition probability to bad state; in percents; 'gemodel' model",
+						},
+						cli.IntFlag{
+							Name:  "pb",
+							Usage: "transition probability back to good state; in percents; 'gemodel' model (default: 100 - pg)",
+						},
+						cli.IntFlag{
+							Name:  "one-h",
+							Usage: "loss probability in bad state; in percents; 'gemodel' model (default: 100)",
+						},
+						cli.IntFlag{
+							Name:  "one-k",
+							Usage: "loss probability in good state; in percents; 'gemodel' model",
+						},
+					},
+					Usage:       "drop egress packets",
+					ArgsUsage:   "containers (name, list of names, RE2 regex)",
+					Description: "drop egress packets of specified containers: independent random loss or bursty loss of 4-state Markov ('state') or Gilbert-Elliott ('gemodel') model",
+					Action:      netemLoss,
+					Before:      beforeCommand,
 				},
 				{
 					Name: "duplicate",
 				},
+				{
+					Name: "combine",
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "delay",
+							Usage: "delay time; in milliseconds; 0 - no delay",
+						},
+						cli.IntFlag{
+							Name:  "variation",
+							Usage: "random delay variation (jitter); in milliseconds; example: --delay 100 --variation 10 results in 90ms - 110ms delay",
+						},
+						cli.IntFlag{
+							Name:  "delay-correlation",
+							Usage: "delay correlation; in percents",
+						},
+						cli.StringFlag{
+							Name:  "distribution",
+							Usage: "delay jitter distribution: 'uniform', 'normal', 'pareto' or 'paretonormal' (default: uniform); requires --variation",
+						},
+						cli.IntFlag{
+							Name:  "loss",
+							Usage: "percent of packets to drop; 0 - no loss",
+						},
+						cli.IntFlag{
+							Name:  "loss-correlation",
+							Usage: "loss correlation; in percents",
+						},
+						cli.IntFlag{
+							Name:  "corrupt",
+							Usage: "percent of packets to corrupt (random single-bit error); 0 - no corruption",
+						},
+						cli.IntFlag{
+							Name:  "corrupt-correlation",
+							Usage: "corruption correlation; in percents",
+						},
+					},
+					Usage:       "combine delay, loss and corrupt impairments",
+					ArgsUsage:   "containers (name, list of names, RE2 regex)",
+					Description: "apply several impairments (delay, loss and corrupt) to egress traffic of specified containers in single netem qdisc; impairments, that are not set, are not applied",
+					Action:      netemCombine,
+					Before:      beforeCommand,
+				},
 				{
 					Name: "corrupt",
+					Flags: []cli.Flag{
+						cli.IntFlag{
+							Name:  "percent, p",
+							Usage: "percent of packets to corrupt (random single-bit error)",
+							Value: 1,
+						},
+						cli.IntFlag{
+							Name:  "correlation, c",
+							Usage: "corruption correlation; in percents",
+						},
+					},
+					Usage:       "corrupt egress traffic",
+					ArgsUsage:   "containers (name, list of names, RE2 regex)",
+					Description: "corrupt egress traffic of specified containers: introduce random single-bit error at random offset in specified percent of packets",
+					Action:      netemCorrupt,
+					Before:      beforeCommand,
+				},
+				{
+					Name: "throttle",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "rate, r",
+							Usage: "bandwidth limit with tc units, e.g. '1mbit', '500kbit' or '10mbps'",
+						},
+						cli.StringFlag{
+							Name:  "burst, b",
+							Usage: "token bucket size: traffic burst sent at full speed, with tc units, e.g. '32kbit' or '10kb'",
+							Value: "32kbit",
+						},
+						cli.IntFlag{
+							Name:  "latency, l",
+							Usage: "maximal time packet waits for bandwidth, longer waiting packets are dropped; in milliseconds",
+							Value: 400,
+						},
+					},
+					Usage:       "throttle egress bandwidth",
+					ArgsUsage:   "containers (name, list of names, RE2 regex)",
+					Description: "throttle bandwidth of egress traffic of specified containers with token bucket filter (tbf): hard and predictable rate limit for bandwidth-starvation experiments",
+					Action:      netemThrottle,
+					Before:      beforeCommand,
+				},
+				{
+					Name:        "stop",
+					Usage:       "remove netem now",
+					ArgsUsage:   "containers (name, list of names, RE2 regex)",
+					Description: "remove netem, added by Pumba, from --interface (or --network interface) of specified containers, cancelling network emulation in progress (e.g. started from another shell) without waiting for its duration; root qdisc, not added by Pumba, is left as is",
+					Action:      netemStop,
+					Before:      beforeCommand,
+				},
+			},
+		},
+		{
+			Name: "http",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "duration, d",
+					Usage: "HTTP chaos duration; should be smaller than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
+				},
+				cli.IntFlag{
+					Name:  "port, p",
+					Usage: "container port, serving HTTP traffic; only incoming traffic to this port is affected",
+				},
+				cli.IntFlag{
+					Name:  "delay",
+					Usage: "delay added to each HTTP request; in milliseconds",
+				},
+				cli.IntFlag{
+					Name:  "error-rate",
+					Usage: "percent of HTTP requests to fail with error code",
+				},
+				cli.IntFlag{
+					Name:  "error-code",
+					Usage: "HTTP status code for failed requests",
+					Value: DefaultHTTPErrorCode,
+				},
+				cli.IntFlag{
+					Name:  "truncate-rate",
+					Usage: "percent of HTTP responses to truncate (only half of response body is sent)",
 				},
+				cli.IntFlag{
+					Name:  "proxy-port",
+					Usage: "port for HTTP proxy sidecar; should not be used inside target container(s)",
+					Value: DefaultProxyPort,
+				},
+				cli.StringFlag{
+					Name:  "image",
+					Usage: "Docker image for HTTP proxy sidecar; should contain Pumba binary (/usr/bin/pumba) and iptables; pulled, if missing",
+					Value: DefaultProxyImage,
+				},
+			},
+			Usage:       "inject HTTP faults",
+			ArgsUsage:   "containers (name, list of names, RE2 regex)",
+			Description: "delay, fail (5xx) and truncate HTTP requests to target container(s), using proxy sidecar container and iptables REDIRECT",
+			Action:      httpChaos,
+			Before:      beforeCommand,
+		},
+		{
+			Name: "ports",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "duration, d",
+					Usage: "duration; should be smaller than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
+				},
+				cli.IntFlag{
+					Name:  "loss, l",
+					Usage: "percent of packets to drop",
+					Value: 100,
+				},
+				execBeforeFlag,
+				execAfterFlag,
+			},
+			Usage:       "drop packets to published ports",
+			ArgsUsage:   "containers (name, list of names, RE2 regex)",
+			Description: "drop packets sent to published ports of target container(s) through Docker host (DNAT path), using host iptables; container traffic on Docker networks is not affected",
+			Action:      ports,
+			Before:      beforeCommand,
+		},
+		{
+			Name: "proxy",
+			Flags: []cli.Flag{
+				cli.IntFlag{Name: "port"},
+				cli.IntFlag{Name: "proxy-port", Value: DefaultProxyPort},
+				cli.IntFlag{Name: "delay"},
+				cli.IntFlag{Name: "error-rate"},
+				cli.IntFlag{Name: "error-code", Value: DefaultHTTPErrorCode},
+				cli.IntFlag{Name: "truncate-rate"},
 			},
+			Usage:  "run HTTP fault injection proxy (used by 'http' command sidecar)",
+			Hidden: true,
+			Action: runProxy,
 		},
 		{
 			Name: "pause",
@@ -176,6 +579,8 @@ func main() {
 					Name:  "duration, d",
 					Usage: "pause duration: should be smaller than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
 				},
+				execBeforeFlag,
+				execAfterFlag,
 			},
 			Usage:       "pause all processes",
 			ArgsUsage:   "containers (name, list of names, RE2 regex)",
@@ -183,6 +588,183 @@ func main() {
 			Action:      pause,
 			Before:      beforeCommand,
 		},
+		{
+			Name: "cpu",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "duration, d",
+					Usage: "CPU burn duration: should be smaller than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
+				},
+				cli.IntFlag{
+					Name:  "workers, w",
+					Usage: "number of CPU busy-loop processes; 0 - one per CPU available to container (CPU quota of container cgroup or CPUs visible in container)",
+				},
+				injectToolsFlag,
+			},
+			Usage:       "burn CPU",
+			ArgsUsage:   "containers (name, list of names, RE2 regex)",
+			Description: "burn CPU of target containers with shell busy-loop processes, exec-ed inside container; no stress tools are needed in container image, only POSIX shell",
+			Action:      cpu,
+			Before:      beforeCommand,
+		},
+		{
+			Name: "oom",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "timeout, t",
+					Usage: "stop memory balloon, if OOM killer is not triggered within timeout; use with optional unit suffix: 'ms/s/m/h'",
+					Value: "1m",
+				},
+				injectToolsFlag,
+			},
+			Usage:       "trigger OOM killer",
+			ArgsUsage:   "containers (name, list of names, RE2 regex)",
+			Description: "trigger kernel OOM killer inside target containers: main process is made preferred OOM victim and memory balloon is exec-ed in container memory cgroup; containers must have memory limit",
+			Action:      oom,
+			Before:      beforeCommand,
+		},
+		{
+			Name: "fd",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "duration, d",
+					Usage: "file descriptor exhaustion duration: should be smaller than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
+				},
+				cli.IntFlag{
+					Name:  "free",
+					Usage: "number of file descriptors, that each container process can still open",
+				},
+			},
+			Usage:       "exhaust file descriptors",
+			ArgsUsage:   "containers (name, list of names, RE2 regex)",
+			Description: "exhaust file descriptors of target container processes ('too many open files'), using Pumba binary injected into container as helper; Pumba must be static Linux binary",
+			Action:      fd,
+			Before:      beforeCommand,
+		},
+		{
+			Name: "fd-helper",
+			Flags: []cli.Flag{
+				cli.StringFlag{Name: "duration"},
+				cli.IntFlag{Name: "free"},
+			},
+			Usage:  "exhaust file descriptors of container processes (exec-ed by 'fd' command inside target container)",
+			Hidden: true,
+			Action: runFDHelper,
+		},
+		{
+			Name: "poison-image",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "duration, d",
+					Usage: "image poisoning duration: should be smaller than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
+				},
+				cli.StringFlag{
+					Name:  "image",
+					Usage: "bogus image, tagged over image of target containers; pulled, if not available locally",
+					Value: "busybox:latest",
+				},
+			},
+			Usage:       "poison local image cache",
+			ArgsUsage:   "containers (name, list of names, RE2 regex)",
+			Description: "tag bogus image over image tag of target containers in local image cache of Docker host, and restore the tag after duration; tests orchestrator behavior, when a node has corrupted/poisoned image cache",
+			Action:      poisonImage,
+			Before:      beforeCommand,
+		},
+		{
+			Name: "health",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "duration, d",
+					Usage: "healthcheck disruption duration: should be smaller than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
+				},
+				cli.StringFlag{
+					Name:  "probe-binary",
+					Usage: "absolute path of healthcheck probe binary in container (e.g. /usr/bin/curl); replaced with stub, that passes (false-healthy container), or fails with --fail",
+				},
+				cli.BoolFlag{
+					Name:  "fail",
+					Usage: "stub of --probe-binary fails: container looks unhealthy, while serving traffic",
+				},
+				cli.IntFlag{
+					Name:  "probe-port",
+					Usage: "TCP port of healthcheck probe; probe traffic to loopback interface is rejected, traffic of other hosts is not affected; requires iptables in container",
+				},
+				injectToolsFlag,
+			},
+			Usage:       "disrupt only healthcheck",
+			ArgsUsage:   "containers (name, list of names, RE2 regex)",
+			Description: "disrupt only healthcheck probe of target containers for duration: replace probe binary with stub or reject probe traffic to loopback port; tests alerting, based on real traffic rather than healthchecks",
+			Action:      healthcheck,
+			Before:      beforeCommand,
+		},
+		{
+			Name: "memory",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "duration, d",
+					Usage: "memory pressure duration: should be smaller than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
+				},
+				cli.IntFlag{
+					Name:  "percent, p",
+					Usage: "memory limit of container (memory.high) in percents of its current memory usage; 1 to 99",
+					Value: 80,
+				},
+			},
+			Usage:       "put containers under memory pressure",
+			ArgsUsage:   "containers (name, list of names, RE2 regex)",
+			Description: "lower memory limit (cgroup v2 memory.high) of target containers below their memory usage for duration: containers are throttled and their memory is reclaimed without OOM kill; requires Pumba running on Docker host",
+			Action:      memory,
+			Before:      beforeCommand,
+		},
+		{
+			Name: "env",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "set, e",
+					Usage: "override environment variable of container, 'NAME=value'; repeatable",
+				},
+				cli.StringSliceFlag{
+					Name:  "unset, u",
+					Usage: "remove environment variable of container (set empty, if it is defined in image); repeatable",
+				},
+				cli.IntFlag{
+					Name:  "time, t",
+					Usage: "seconds to wait for stop of original container before killing it (default 10)",
+					Value: 10,
+				},
+				cli.StringFlag{
+					Name:  "duration, d",
+					Usage: "corrupted environment duration: should be smaller than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
+				},
+			},
+			Usage:       "re-create containers with corrupted environment",
+			ArgsUsage:   "containers (name, list of names, RE2 regex)",
+			Description: "stop target containers and run their copies with environment variables overridden or removed for duration, to test resilience to misconfiguration; copies are removed and original containers are started again afterwards",
+			Action:      env,
+			Before:      beforeCommand,
+		},
+		{
+			Name: "secrets",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "secret, s",
+					Usage: "name of Docker secret to remove from Swarm service; repeatable",
+				},
+				cli.StringSliceFlag{
+					Name:  "config, c",
+					Usage: "name of Docker config to remove from Swarm service; repeatable",
+				},
+				cli.StringFlag{
+					Name:  "duration, d",
+					Usage: "unavailability duration: should be smaller than recurrent interval; use with optional unit suffix: 'ms/s/m/h'",
+				},
+			},
+			Usage:       "remove secrets and configs from Swarm services",
+			ArgsUsage:   "containers (name, list of names, RE2 regex)",
+			Description: "update Swarm services of target containers (service tasks) without specified secrets and configs for duration, to test application behavior, when they are unavailable; services re-deploy tasks on update; secrets and configs are restored afterwards",
+			Action:      secrets,
+			Before:      beforeCommand,
+		},
 		{
 			Name: "stop",
 			Flags: []cli.Flag{
@@ -191,6 +773,14 @@ func main() {
 					Usage: "seconds to wait for stop before killing container (default 10)",
 					Value: 10,
 				},
+				cli.StringFlag{
+					Name:  "duration, d",
+					Usage: "blackout window: keep containers stopped for specified interval, before starting them again (with --restart); use with optional unit suffix: 'ms/s/m/h'",
+				},
+				cli.BoolFlag{
+					Name:  "restart",
+					Usage: "start stopped containers again after --duration interval",
+				},
 			},
 			Usage:       "stop containers",
 			ArgsUsage:   "containers (name, list of names, RE2 regex)",
@@ -220,42 +810,195 @@ func main() {
 			Action:      remove,
 			Before:      beforeCommand,
 		},
-	}
-	app.Flags = []cli.Flag{
-		cli.StringFlag{
-			Name:   "host, H",
-			Usage:  "daemon socket to connect to",
-			Value:  "unix:///var/run/docker.sock",
-			EnvVar: "DOCKER_HOST",
-		},
-		cli.BoolFlag{
-			Name:  "tls",
-			Usage: "use TLS; implied by --tlsverify",
+		{
+			Name: "multi",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "spec, s",
+					Usage: "chaos commands separated by ';', e.g. 'kill re2:^api; netem --duration 1m delay re2:^db'",
+				},
+			},
+			Usage:       "run multiple chaos commands",
+			Description: "run several chaos commands (kill, netem, http, ports, pause, cpu, oom, stop, rm) concurrently, sharing Docker client and schedule",
+			Action:      multi,
+			Before:      beforeCommand,
+		},
+		{
+			Name:  "recipe",
+			Usage: "run built-in chaos recipes",
+			Subcommands: []cli.Command{
+				{
+					Name:   "list",
+					Flags:  []cli.Flag{formatFlag},
+					Usage:  "list built-in recipes and their parameters",
+					Action: recipeList,
+				},
+				{
+					Name:            "run",
+					Usage:           "run built-in recipe",
+					ArgsUsage:       "recipe [--param value ...]",
+					Description:     "run built-in recipe (scenario template) once, with specified parameters",
+					SkipFlagParsing: true,
+					Action:          recipeRun,
+				},
+			},
+		},
+		{
+			Name: "status",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "addr",
+					Usage: "API address of running Pumba daemon (see --api-addr)",
+					Value: status.DefaultAddr,
+				},
+				formatFlag,
+			},
+			Usage:       "show status of running Pumba daemon",
+			Description: "connect to API of running Pumba daemon and print active experiments, next scheduled ticks and currently disrupted containers",
+			Action:      statusCommand,
+		},
+		{
+			Name: "history",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "db",
+					Usage: "experiment history database file (see --history-db)",
+					Value: history.DefaultFile,
+				},
+				cli.StringFlag{
+					Name:  "since",
+					Usage: "show chaos actions since specified time: RFC3339 time or duration ago, e.g. '24h'",
+				},
+				cli.StringFlag{
+					Name:  "until",
+					Usage: "show chaos actions before specified time: RFC3339 time or duration ago, e.g. '1h'",
+				},
+				cli.StringFlag{
+					Name:  "container",
+					Usage: "show chaos actions on container with specified name or ID prefix",
+				},
+				cli.StringFlag{
+					Name:  "action",
+					Usage: "show specified chaos actions: kill, stop, rm, pause, netem, ports, cpu, oom, fd or sidecar",
+				},
+				formatFlag,
+			},
+			Usage:       "query experiment history",
+			Description: "print chaos actions and their outcomes, recorded by Pumba daemon in experiment history database",
+			Action:      historyCommand,
+		},
+		{
+			Name:  "report",
+			Usage: "export experiment reports",
+			Subcommands: []cli.Command{
+				{
+					Name: "export",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "db",
+							Usage: "experiment history database file (see --history-db)",
+							Value: history.DefaultFile,
+						},
+						cli.StringFlag{
+							Name:  "since",
+							Usage: "report experiment since specified time: RFC3339 time or duration ago, e.g. '24h'; default - the first recorded event",
+						},
+						cli.StringFlag{
+							Name:  "until",
+							Usage: "report experiment before specified time: RFC3339 time or duration ago, e.g. '1h'; default - the last recorded event",
+						},
+						cli.StringFlag{
+							Name:  "container",
+							Usage: "report only chaos actions on container with specified name or ID prefix",
+						},
+						cli.StringFlag{
+							Name:  "action",
+							Usage: "report only specified chaos actions: kill, stop, rm, pause, netem, ports, cpu, oom, fd or sidecar",
+						},
+						cli.StringFlag{
+							Name:  "title",
+							Usage: "report title",
+							Value: "Pumba chaos experiment",
+						},
+						cli.StringFlag{
+							Name:  "format",
+							Usage: "report format: 'html' (standalone HTML page) or 'json'",
+							Value: "html",
+						},
+						cli.StringFlag{
+							Name:  "output, o",
+							Usage: "report file; default - standard output",
+						},
+					},
+					Usage:       "export experiment report",
+					Description: "export read-only report of chaos experiment from experiment history database: timeline of disruptions, targets, chaos action outcomes, probe results and runs",
+					Action:      reportExport,
+				},
+			},
+		},
+		{
+			Name:  "scenario",
+			Usage: "run chaos scenarios",
+			Subcommands: []cli.Command{
+				{
+					Name:            "run",
+					Usage:           "run scenario from YAML file",
+					ArgsUsage:       "file [--param value ...]",
+					Description:     "run scenario (sequence of chaos steps) from YAML template file once, with specified parameters",
+					SkipFlagParsing: true,
+					Action:          scenarioRun,
+				},
+			},
+		},
+	}
+	app.Flags = []cli.Flag{
+		cli.StringFlag{
+			Name:  "host, H",
+			Usage: "daemon socket to connect to: 'unix:///path' or 'tcp://host[:port]' (default port 2375, 2376 with TLS); DOCKER_HOST or 'unix:///var/run/docker.sock', if not set",
+		},
+		cli.StringFlag{
+			Name:  "docker-context",
+			Usage: "Docker CLI context to connect to (see 'docker context ls'); DOCKER_CONTEXT or current context, if not set; cannot be used with --host",
+		},
+		cli.BoolFlag{
+			Name:  "tls",
+			Usage: "use TLS; implied by --tlsverify",
 		},
 		cli.BoolFlag{
-			Name:   "tlsverify",
-			Usage:  "use TLS and verify the remote",
-			EnvVar: "DOCKER_TLS_VERIFY",
+			Name:  "tlsverify",
+			Usage: "use TLS and verify the remote; implied by non-empty DOCKER_TLS_VERIFY",
 		},
 		cli.StringFlag{
 			Name:  "tlscacert",
-			Usage: "trust certs signed only by this CA",
+			Usage: "trust certs signed only by this CA; PEM file path or PEM content",
 			Value: fmt.Sprintf("%s/ca.pem", rootCertPath),
 		},
 		cli.StringFlag{
 			Name:  "tlscert",
-			Usage: "client certificate for TLS authentication",
+			Usage: "client certificate for TLS authentication; PEM file path or PEM content",
 			Value: fmt.Sprintf("%s/cert.pem", rootCertPath),
 		},
 		cli.StringFlag{
 			Name:  "tlskey",
-			Usage: "client key for TLS authentication",
+			Usage: "client key for TLS authentication; PEM file path or PEM content",
 			Value: fmt.Sprintf("%s/key.pem", rootCertPath),
 		},
+		cli.StringFlag{
+			Name:  "tlscertdir",
+			Usage: "directory with TLS certificates in standard files: ca.pem, cert.pem and key.pem; --tlscacert, --tlscert and --tlskey override them",
+		},
 		cli.BoolFlag{
 			Name:  "debug",
 			Usage: "enable debug mode with verbose logging",
 		},
+		cli.BoolFlag{
+			Name:  "quiet, q",
+			Usage: "quiet mode for long soak tests: log warnings, errors and summary of chaos actions (see --summary-interval) only",
+		},
+		cli.StringFlag{
+			Name:  "summary-interval",
+			Usage: "log summary of chaos actions (actions run, failures, affected containers) every interval; 10m by default with --quiet; use with optional unit suffix: 'ms/s/m/h'",
+		},
 		cli.BoolFlag{
 			Name:  "json",
 			Usage: "produce log in JSON format: Logstash and Splunk friendly"},
@@ -268,15 +1011,246 @@ func main() {
 			Usage: "Slack channel (default #pumba)",
 			Value: "#pumba",
 		},
+		cli.StringFlag{
+			Name:  "slacklevel",
+			Usage: "lowest log level reported to Slack: debug, info, warning, error, fatal or panic (default: current log level)",
+		},
+		cli.StringFlag{
+			Name:  "slackemoji",
+			Usage: "Slack bot icon emoji",
+			Value: ":boar:",
+		},
+		cli.StringFlag{
+			Name:  "slackuser",
+			Usage: "Slack bot user name",
+			Value: "pumba_bot",
+		},
+		cli.StringSliceFlag{
+			Name:  "slack",
+			Usage: "additional Slack hook 'url[,channel=#name][,level=error][,emoji=:icon:][,user=name]'; can be repeated to send different log levels to different channels",
+		},
+		cli.StringFlag{
+			Name:  "state-file",
+			Usage: "state file with active disruptions (chaos actions with duration) of running Pumba instance; required by --takeover",
+		},
+		cli.BoolFlag{
+			Name:  "takeover",
+			Usage: "take over running Pumba instance with the same --state-file: adopt its active disruptions (restore containers when disruption ends) and make the instance exit",
+		},
+		cli.StringFlag{
+			Name:  "takeover-timeout",
+			Usage: "how long to wait for running Pumba instance exit on takeover; use with optional unit suffix: 'ms/s/m/h'",
+			Value: "30s",
+		},
+		cli.BoolFlag{
+			Name:  "log-caller",
+			Usage: "add caller (file:line) to log events; see 'logging' block of configuration file for module log levels and log sampling",
+		},
+		cli.StringFlag{
+			Name:  "proxy",
+			Usage: "HTTP proxy URL for Slack web hooks, HTTP probes and TCP Docker host; overrides HTTP_PROXY and HTTPS_PROXY, hosts listed in NO_PROXY are reached directly",
+		},
 		cli.StringFlag{
 			Name:  "interval, i",
 			Usage: "recurrent interval for chaos command; use with optional unit suffix: 'ms/s/m/h'",
 		},
+		cli.BoolFlag{
+			Name:        "all",
+			Usage:       "target ALL containers, when chaos command has no container names or pattern; without it, chaos command with no targets fails",
+			Destination: &gAll,
+		},
+		cli.StringFlag{
+			Name:        "name-prefix",
+			Usage:       "target only containers with name prefix (e.g. 'svc_'), filtered by Docker daemon; chaos command with no container names or pattern targets all of them",
+			Destination: &gNamePrefix,
+		},
+		cli.StringFlag{
+			Name:  "targets-file",
+			Usage: "read target container names or IDs from file ('-' for stdin), one per line, instead of command arguments; file is read again on each run, when changed",
+		},
+		cli.BoolFlag{
+			Name:        "interactive",
+			Usage:       "list target containers and ask for confirmation before first run of rm, kill, stop, oom and env commands; skipped when not running from TTY",
+			Destination: &gInteractive,
+		},
 		cli.BoolFlag{
 			Name:        "random, r",
 			Usage:       "randomly select single matching container from list of target containers",
 			Destination: &action.RandomMode,
 		},
+		cli.StringFlag{
+			Name:        "group-by",
+			Usage:       "rotate chaos across groups of target containers sharing the same label value (e.g. availability zone); one group per interval",
+			Destination: &action.GroupByLabel,
+		},
+		cli.IntFlag{
+			Name:        "max-blast",
+			Usage:       "maximum number of containers affected by single chaos action; chaos action is skipped, when blast radius is bigger (default: no limit)",
+			Destination: &action.MaxBlast,
+		},
+		cli.StringFlag{
+			Name:  "min-memory",
+			Usage: "target only containers with memory limit of at least specified size, e.g. '512m' or '1g'; containers without memory limit are skipped",
+		},
+		cli.StringFlag{
+			Name:  "max-memory",
+			Usage: "target only containers with memory limit of at most specified size, e.g. '128m'; containers without memory limit are skipped",
+		},
+		cli.StringFlag{
+			Name:  "min-cpus",
+			Usage: "target only containers with CPU limit (--cpu-quota or --cpuset-cpus) of at least specified number of CPUs, e.g. '1.0'; containers without CPU limit are skipped",
+		},
+		cli.StringFlag{
+			Name:  "max-cpus",
+			Usage: "target only containers with CPU limit (--cpu-quota or --cpuset-cpus) of at most specified number of CPUs, e.g. '0.5'; containers without CPU limit are skipped",
+		},
+		cli.StringFlag{
+			Name:  "pprof-addr",
+			Usage: "serve Go runtime profiling data (pprof) on specified address, e.g. 'localhost:6060'; address without host (':6060') binds to localhost",
+		},
+		cli.StringFlag{
+			Name:  "pre-hook",
+			Usage: "shell command to run on Pumba host before each chaos action; PUMBA_ACTION, PUMBA_CONTAINER_ID, PUMBA_CONTAINER_NAME and other PUMBA_* variables describe action target",
+		},
+		cli.StringFlag{
+			Name:  "post-hook",
+			Usage: "shell command to run on Pumba host after each chaos action; PUMBA_RESULT (success/failure) and PUMBA_ERROR describe action result",
+		},
+		cli.StringFlag{
+			Name:  "metrics-addr",
+			Usage: "serve Prometheus metrics (container shutdown and Docker API latency) on specified address, e.g. ':9100'",
+		},
+		cli.StringFlag{
+			Name:  "api-addr",
+			Usage: "serve Pumba API (status of chaos commands and disrupted containers, see 'pumba status') on specified address, e.g. 'localhost:8585'",
+		},
+		cli.BoolFlag{
+			Name:  "ui",
+			Usage: "serve web UI on Pumba API address (see --api-addr) at '/ui/': experiments, active disruptions, saved scenarios to run and recent history",
+		},
+		cli.StringFlag{
+			Name:  "scenario-dir",
+			Usage: "directory of saved scenario files (*.yml), that can be run from web UI, in addition to built-in recipes",
+		},
+		cli.StringFlag{
+			Name:  "history-db",
+			Usage: "record experiment runs and chaos action outcomes in SQLite database file, e.g. 'pumba-history.db'; see 'pumba history'",
+		},
+		cli.StringFlag{
+			Name:  "lock",
+			Usage: "experiment lock backend, shared by Pumba instances, so only one instance disrupts a container at a time: 'file:///path/to/dir', 'redis://[:password@]host:6379[/db]' or 'k8s://namespace' (Kubernetes Lease)",
+		},
+		cli.StringFlag{
+			Name:  "kubeconfig",
+			Usage: "kubeconfig file for Kubernetes API access, when Pumba runs out of cluster; by default, in-cluster service account of Pumba pod is used, then $KUBECONFIG or ~/.kube/config",
+		},
+		cli.StringFlag{
+			Name:  "lock-namespace",
+			Usage: "experiment lock namespace; Pumba instances lock containers only against instances with the same namespace",
+			Value: "pumba",
+		},
+		cli.StringFlag{
+			Name:  "lock-ttl",
+			Usage: "experiment lock expiration margin, added to chaos action duration; lock of crashed Pumba instance expires after it; use with optional unit suffix: 'ms/s/m/h'",
+			Value: "1m",
+		},
+		cli.IntFlag{
+			Name:  "max-active",
+			Usage: "maximal number of simultaneously active disruptions (chaos actions with duration) of all Pumba instances, sharing --lock backend and --lock-namespace (local lock directory, if --lock is not set); new disruptions are refused beyond it; 0 - no limit",
+		},
+		cli.BoolFlag{
+			Name:  "once",
+			Usage: "run chaos command once and exit; exit with non-zero code on failure (CI mode)",
+		},
+		cli.IntFlag{
+			Name:  "count",
+			Usage: "run chaos command specified number of times back-to-back, without --interval, and exit; exit with non-zero code on failure, like --once",
+		},
+		cli.StringFlag{
+			Name:  "delay-between",
+			Usage: "delay between runs of --count; use with optional unit suffix: 'ms/s/m/h'",
+		},
+		cli.StringFlag{
+			Name:  "warmup",
+			Usage: "wait after startup before the first chaos run, giving freshly deployed containers time to become healthy; use with optional unit suffix: 'ms/s/m/h'",
+		},
+		cli.IntFlag{
+			Name:  "tolerate-failures",
+			Usage: "number of failed chaos commands to tolerate before exit with non-zero code; no limit by default, unless --once or --count is used",
+		},
+		cli.BoolFlag{
+			Name:  "beacons",
+			Usage: "create and remove labeled no-op container at start and end of each chaos action, so tools watching Docker events can observe chaos",
+		},
+		cli.IntFlag{
+			Name:  "denylist-after",
+			Usage: "skip target container after specified number of consecutive failures of chaos action (e.g. no tc in container), with exponential back-off; 0 - disabled",
+		},
+		cli.StringFlag{
+			Name:  "denylist-backoff",
+			Usage: "initial back-off of denylisted target; doubled on each failure after back-off expires; use with optional unit suffix: 'ms/s/m/h'",
+			Value: "1m",
+		},
+		cli.StringFlag{
+			Name:  "denylist-max-backoff",
+			Usage: "maximal back-off of denylisted target; use with optional unit suffix: 'ms/s/m/h'",
+			Value: "1h",
+		},
+		cli.StringFlag{
+			Name:  "exec-user",
+			Usage: "user of commands, executed in target containers by chaos actions (tc, iptables, stress, probes and exec hooks): 'user[:group]' (name or ID); container default user if not set",
+		},
+		cli.StringFlag{
+			Name:  "exec-workdir",
+			Usage: "working directory (absolute path) of commands, executed in target containers by chaos actions; requires 'sh' in container",
+		},
+		cli.BoolFlag{
+			Name:  "snapshot-before",
+			Usage: "commit container to 'pumba-snapshot/<name>:<time>' image before destructive chaos action (rm), so container state can be inspected or restored",
+		},
+		cli.StringFlag{
+			Name:  "snapshot-ttl",
+			Usage: "remove container snapshots after TTL; use with optional unit suffix: 'ms/s/m/h'",
+			Value: "24h",
+		},
+		cli.StringFlag{
+			Name:  "mark",
+			Usage: "mark containers affected by chaos: 'state' (add mark entry to --state-file) or 'audit' (write JSON event to --mark-file)",
+		},
+		cli.StringFlag{
+			Name:  "mark-cooldown",
+			Usage: "remove container mark after cooldown period; use with optional unit suffix: 'ms/s/m/h'",
+			Value: "10m",
+		},
+		cli.StringFlag{
+			Name:  "mark-file",
+			Usage: "audit file for 'audit' mark",
+			Value: DefaultMarkFile,
+		},
+		cli.StringFlag{
+			Name:   "config",
+			Usage:  "configuration file with named chaos profiles",
+			Value:  DefaultConfigFile,
+			EnvVar: "PUMBA_CONFIG",
+		},
+		cli.StringFlag{
+			Name:   "profile",
+			Usage:  "run named chaos profile (schedule and chaos commands) from configuration file",
+			EnvVar: "PUMBA_PROFILE",
+		},
+		cli.StringSliceFlag{
+			Name:  "context",
+			Usage: "key=value pair attached to all events, metrics and reports, e.g. build or commit of tested system; can be repeated",
+		},
+		cli.StringFlag{
+			Name:  "simulate",
+			Usage: "simulate chaos command schedule for specified virtual time, e.g. '1h', in dry mode and print timeline of chaos actions, that would be run; containers are listed once; use with optional unit suffix: 'ms/s/m/h'",
+		},
+		cli.StringFlag{
+			Name:  "simulate-format",
+			Usage: "output format of simulation report: 'table', 'json' or 'yaml'",
+			Value: output.Table,
+		},
 		cli.BoolFlag{
 			Name:        "dry",
 			Usage:       "dry runl does not create chaos, only logs planned chaos commands",
@@ -284,211 +1258,1993 @@ func main() {
 		},
 	}
 
-	if err := app.Run(os.Args); err != nil {
+	err := app.Run(os.Args)
+	if gSummary != nil {
+		gSummary.Stop()
+	}
+	if err != nil {
 		log.Fatal(err)
 	}
+	// wait for disruptions adopted on takeover
+	gWG.Wait()
+	// stopped by termination signal: signal handler exits, when cleanup is complete
+	if gSchedulers.Stopped() {
+		select {}
+	}
 }
 
 func before(c *cli.Context) error {
-	// set debug log level
+	// set debug log level; quiet mode hides info log events, except chaos summary
+	if c.GlobalBool("debug") && c.GlobalBool("quiet") {
+		return errors.New("Options --debug and --quiet are mutually exclusive")
+	}
 	if c.GlobalBool("debug") {
 		log.SetLevel(log.DebugLevel)
 	}
+	if c.GlobalBool("quiet") {
+		log.SetLevel(log.WarnLevel)
+	}
 	// set log formatter to JSON
 	if c.GlobalBool("json") {
 		log.SetFormatter(&log.JSONFormatter{})
 	}
-	// set Slack log channel
-	if c.GlobalString("slackhook") != "" {
-		log.AddHook(&slackrus.SlackrusHook{
-			HookURL:        c.GlobalString("slackhook"),
-			AcceptedLevels: slackrus.LevelThreshold(log.GetLevel()),
-			Channel:        c.GlobalString("slackchannel"),
-			IconEmoji:      ":boar:",
-			Username:       "pumba_bot",
-		})
-	}
-	// Set-up container client
-	tls, err := tlsConfig(c)
-	if err != nil {
+	// attach context pairs to all log events
+	var err error
+	if gContext, err = parseContext(c.GlobalStringSlice("context")); err != nil {
 		return err
 	}
-	// create new Docker client
-	client = container.NewClient(c.GlobalString("host"), tls)
-	// habdle termination signal
-	handleSignals()
-	return nil
-}
-
-// beforeCommand run before each chaos command
-func beforeCommand(c *cli.Context) error {
-	// get recurrent time interval
-	if intervalString := c.GlobalString("interval"); intervalString == "" {
-		return errors.New("Undefined interval value.")
-	} else if interval, err := time.ParseDuration(intervalString); err != nil {
-		return err
-	} else {
-		gInterval = interval
+	if len(gContext) > 0 {
+		log.AddHook(contextHook(gContext))
 	}
-	return nil
-}
-
-func getNamesOrPattern(c *cli.Context) ([]string, string) {
-	names := []string{}
-	pattern := ""
-	// get container names or pattern: no Args means ALL containers
-	if c.Args().Present() {
-		// more than one argument, assume that this a list of names
-		if len(c.Args()) > 1 {
-			names = c.Args()
-			log.Debugf("Names: '%s'", names)
-		} else {
-			first := c.Args().First()
-			if strings.HasPrefix(first, Re2Prefix) {
-				pattern = strings.Trim(first, Re2Prefix)
-				log.Debugf("Pattern: '%s'", pattern)
-			}
-		}
+	// Slack hooks: --slackhook and all --slack hooks
+	slackHookList, err := slackHooks(c)
+	if err != nil {
+		return err
 	}
-	return names, pattern
-}
-
-func runChaosCommand(cmd interface{}, names []string, pattern string, chaosFn func(container.Client, []string, string, interface{}) error) {
-	// channel for 'chaos' command
-	dc := make(chan interface{})
-	// create Time channel for specified intterval: for TestRun use Timer (one time call)
-	var cmdTimeChan <-chan time.Time
-	if gTestRun {
-		cmdTimeChan = time.NewTimer(gInterval).C
-	} else {
-		cmdTimeChan = time.NewTicker(gInterval).C
-	}
-	// handle interval timer event
-	go func(cmd interface{}) {
-		for range cmdTimeChan {
-			dc <- cmd
-			if gTestRun {
-				close(dc)
-			}
-		}
-	}(cmd)
-	// handle 'chaos' command
-	for cmd := range dc {
-		gWG.Add(1)
-		go func(cmd interface{}) {
-			defer gWG.Done()
-			if err := chaosFn(client, names, pattern, cmd); err != nil {
-				log.Error(err)
-			}
-		}(cmd)
+	// redact sensitive values from logs, Slack notifications and audit trail;
+	// redaction hook should be added before Slack hooks
+	gRedactor.Add(c.GlobalString("tlskey"), lock.Password(c.GlobalString("lock")))
+	for _, h := range slackHookList {
+		gRedactor.Add(h.HookURL)
 	}
-}
-
-// KILL Command
-func kill(c *cli.Context) error {
-	// get names or pattern
-	names, pattern := getNamesOrPattern(c)
-	// get signal
-	signal := c.String("signal")
-	if _, ok := LinuxSignals[signal]; !ok {
-		err := errors.New("Unexpected signal: " + signal)
-		log.Error(err)
+	log.AddHook(redact.Hook{Redactor: gRedactor})
+	// use proxy for Slack web hooks and HTTP probes (default HTTP transport) and TCP Docker host
+	proxy, err := container.ProxyFunc(c.GlobalString("proxy"))
+	if err != nil {
 		return err
 	}
-	runChaosCommand(action.CommandKill{Signal: signal}, names, pattern, chaos.KillContainers)
-	return nil
-}
-
-// NETEM DELAY command
-func netemDelay(c *cli.Context) error {
-	// get names or pattern
-	names, pattern := getNamesOrPattern(c)
-	// get duration
-	var durationString string
-	if c.Parent() != nil {
-		durationString = c.Parent().String("duration")
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		t.Proxy = proxy
 	}
-	if durationString == "" {
-		err := errors.New("Undefined duration interval")
-		log.Error(err)
+	// caller, module log levels and log sampling
+	logCfg, err := loggingConfig(c)
+	if err != nil {
 		return err
 	}
-	duration, err := time.ParseDuration(durationString)
-	if err != nil {
-		log.Error(err)
+	if err = logging.Setup(logCfg); err != nil {
 		return err
 	}
-	// get network interface and target ip
-	netInterface := "eth0"
-	var ip net.IP
-	if c.Parent() != nil {
-		netInterface = c.Parent().String("interface")
-		// protect from Command Injection, using Regexp
-		reInterface := regexp.MustCompile("[a-zA-Z]+[0-9]{0,2}")
-		validInterface := reInterface.FindString(netInterface)
-		if netInterface != validInterface {
-			err := fmt.Errorf("Bad network interface name. Must match '%s'", reInterface.String())
-			log.Error(err)
+	for _, h := range slackHookList {
+		log.AddHook(h)
+	}
+	// serve runtime profiling data
+	if addr := c.GlobalString("pprof-addr"); addr != "" {
+		if err := servePprof(addr); err != nil {
 			return err
 		}
-		// get target IP Filter
-		ip = net.ParseIP(c.Parent().String("target"))
 	}
-	// get delay amount
-	amount := c.Int("amount")
-	if amount <= 0 {
-		err = errors.New("Invalid delay amount")
-		log.Error(err)
+	// Set-up container client: Docker host and TLS, like Docker CLI
+	host, tls, err := dockerEndpoint(c)
+	if err != nil {
 		return err
 	}
-	// get delay variation
+	// create new Docker client
+	client = container.NewClient(host, tls, proxy)
+	// user and working directory of commands, executed in containers
+	if err = setupExec(c); err != nil {
+		return err
+	}
+	// containers with name prefix
+	if err = setupNamePrefix(); err != nil {
+		return err
+	}
+	// targets read from file or stdin
+	if err = setupTargets(c); err != nil {
+		return err
+	}
+	// select targets by resource footprint
+	if err = setupFootprint(c); err != nil {
+		return err
+	}
+	// dry run: log chaos actions instead of running them
+	setupDryRun()
+	// simulate chaos command schedule: record chaos actions on virtual clock
+	if err = setupSimulation(c); err != nil {
+		return err
+	}
+	// measure container shutdown and Docker API latency and serve metrics
+	var registry *metrics.Registry
+	if addr := c.GlobalString("metrics-addr"); addr != "" {
+		registry = metrics.NewRegistry(gContext)
+		if err := serveMetrics(addr, registry); err != nil {
+			return err
+		}
+		client = container.ObserveAPI(client, func(action, call string, d time.Duration, err error) {
+			registry.ObserveAPI(action, call, d)
+		})
+		client = metrics.NewClient(client, registry)
+		gBus.Subscribe(registry.Handle)
+	}
+	// snapshot containers before destructive chaos actions and prune expired snapshots
+	if err = setupSnapshot(c); err != nil {
+		return err
+	}
+	// track active disruptions in state file; take over running Pumba instance
+	if err = setupState(c); err != nil {
+		return err
+	}
+	if registry != nil && gState != nil {
+		registry.DisruptionsGauge(gState.Disruptions)
+	}
+	// record experiment history
+	if path := c.GlobalString("history-db"); path != "" {
+		if gHistory, err = history.Open(path); err != nil {
+			return err
+		}
+		client = history.NewClient(client, gHistory)
+	}
+	// count chaos actions and log their summary periodically
+	if err = setupSummary(c); err != nil {
+		return err
+	}
+	// serve Pumba API and web UI (server mode)
+	webUI, err := createUI(c)
+	if err != nil {
+		return err
+	}
+	if addr := c.GlobalString("api-addr"); addr != "" {
+		gStatus = status.NewServer(Release, gState)
+		if err = serveAPI(addr, gStatus, gGates, webUI); err != nil {
+			return err
+		}
+		gBus.Subscribe(gStatus.Handle)
+	}
+	gCommandLine = strings.Join(c.Args(), " ")
+	// run once (CI) mode, fixed number of runs and failures threshold
+	if err = setupRuns(c); err != nil {
+		return err
+	}
+	// create beacon containers, observed in Docker events, at start and end of chaos actions
+	if c.GlobalBool("beacons") {
+		gBus.Subscribe(beacon.NewEmitter(client, gContext).Handle)
+	}
+	// run hooks before and after chaos actions
+	if pre, post := c.GlobalString("pre-hook"), c.GlobalString("post-hook"); pre != "" || post != "" {
+		gBus.Subscribe(hook.NewExecutor(pre, post, gContext).Handle)
+	}
+	// publish chaos action events to subscribers: metrics, Pumba API, summary, beacons and hooks
+	client = events.NewClient(client, gBus)
+	action.SkipHandler = publishSkipped
+	// mark containers affected by chaos
+	if gMarker, err = createMarker(c); err != nil {
+		return err
+	}
+	if gMarker != nil {
+		client = marker.NewClient(client, gMarker)
+	}
+	// lock containers against other Pumba instances; locked containers skip hooks and marks too
+	if err = setupLock(c); err != nil {
+		return err
+	}
+	// refuse disruptions beyond max active disruptions of all Pumba instances
+	if err = setupAdmission(c, registry); err != nil {
+		return err
+	}
+	// skip targets, that repeatedly fail chaos actions, with exponential back-off
+	if err = setupBackoff(c, registry); err != nil {
+		return err
+	}
+	// handle termination signal
+	handleSignals()
+	return nil
+}
+
+// setupExec sets user and working directory of commands, executed in containers, e.g. 'root' for images, whose
+// default user can not run tc or iptables
+func setupExec(c *cli.Context) error {
+	opts := container.ExecOptions{User: c.GlobalString("exec-user"), WorkingDir: c.GlobalString("exec-workdir")}
+	if opts.User != "" {
+		if err := validate.ExecUser(opts.User); err != nil {
+			return err
+		}
+	}
+	if opts.WorkingDir != "" {
+		if err := validate.WorkingDir(opts.WorkingDir); err != nil {
+			return err
+		}
+	}
+	client = container.WithExecOptions(client, opts)
+	return nil
+}
+
+// setupNamePrefix limits target containers to containers with --name-prefix; Docker daemon filters containers by
+// name, so Pumba does not inspect and match other containers on each run
+func setupNamePrefix() error {
+	if gNamePrefix == "" {
+		return nil
+	}
+	if err := validate.NamePrefix(gNamePrefix); err != nil {
+		return err
+	}
+	client = container.WithNamePrefix(client, gNamePrefix)
+	return nil
+}
+
+// setupTargets opens --targets-file (or stdin): external systems select targets of chaos command
+func setupTargets(c *cli.Context) error {
+	path := c.GlobalString("targets-file")
+	if path == "" {
+		return nil
+	}
+	if gInteractive {
+		return errors.New("Options --targets-file and --interactive are mutually exclusive")
+	}
+	var err error
+	gTargets, err = targets.Open(path)
+	return err
+}
+
+// setupFootprint parses footprint filters of target containers: memory and CPU limits
+func setupFootprint(c *cli.Context) error {
+	var err error
+	action.MinMemory, action.MaxMemory, action.MinCPUs, action.MaxCPUs = 0, 0, 0, 0
+	if value := c.GlobalString("min-memory"); value != "" {
+		if action.MinMemory, err = validate.MemorySize(value); err != nil {
+			return err
+		}
+	}
+	if value := c.GlobalString("max-memory"); value != "" {
+		if action.MaxMemory, err = validate.MemorySize(value); err != nil {
+			return err
+		}
+	}
+	if value := c.GlobalString("min-cpus"); value != "" {
+		if action.MinCPUs, err = validate.CPUs(value); err != nil {
+			return err
+		}
+	}
+	if value := c.GlobalString("max-cpus"); value != "" {
+		if action.MaxCPUs, err = validate.CPUs(value); err != nil {
+			return err
+		}
+	}
+	if action.MaxMemory > 0 && action.MinMemory > action.MaxMemory {
+		return errors.New("Option --min-memory must not be greater than --max-memory")
+	}
+	if action.MaxCPUs > 0 && action.MinCPUs > action.MaxCPUs {
+		return errors.New("Option --min-cpus must not be greater than --max-cpus")
+	}
+	return nil
+}
+
+// setupSnapshot wraps container client with snapshots before destructive chaos actions (--snapshot-before);
+// expired snapshots are pruned at start and periodically
+func setupSnapshot(c *cli.Context) error {
+	if !c.GlobalBool("snapshot-before") {
+		if c.GlobalIsSet("snapshot-ttl") {
+			return errors.New("Option --snapshot-ttl requires --snapshot-before")
+		}
+		return nil
+	}
+	ttl, err := validate.Duration(c.GlobalString("snapshot-ttl"))
+	if err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		return errors.New("Snapshot TTL must be positive")
+	}
+	snapshotter := snapshot.New(client, ttl)
+	if _, err := snapshotter.Prune(); err != nil {
+		log.Warn(err)
+	}
+	interval := snapshotPruneInterval
+	if ttl < interval {
+		interval = ttl
+	}
+	snapshotter.PruneEvery(interval, nil)
+	client = snapshot.NewClient(client, snapshotter)
+	return nil
+}
+
+// setupState creates state file of current Pumba instance and wraps container client with disruption tracking;
+// on takeover, makes running Pumba instance exit and adopts its active disruptions
+func setupState(c *cli.Context) error {
+	path := c.GlobalString("state-file")
+	if path == "" {
+		if c.GlobalBool("takeover") {
+			return errors.New("Takeover requires --state-file of running Pumba instance")
+		}
+		// Pumba API and metrics report disrupted containers: keep state in memory
+		if c.GlobalString("api-addr") == "" && c.GlobalString("metrics-addr") == "" {
+			return nil
+		}
+	}
+	var adopted []state.Disruption
+	if c.GlobalBool("takeover") {
+		timeout, err := validate.Duration(c.GlobalString("takeover-timeout"))
+		if err != nil {
+			return err
+		}
+		if adopted, err = takeover(path, timeout); err != nil {
+			return err
+		}
+	}
+	var err error
+	if gState, err = state.NewFile(path); err != nil {
+		return err
+	}
+	client = state.NewClient(client, gState)
+	// restore adopted disruptions in background; exit waits for them
+	for _, d := range adopted {
+		gWG.Add(1)
+		go func(d state.Disruption) {
+			defer gWG.Done()
+			if err := state.Adopt(client, gState, d, time.Sleep); err != nil {
+				log.Error(err)
+			}
+		}(d)
+	}
+	return nil
+}
+
+// publishSkipped publishes event of container skipped by chaos action
+func publishSkipped(c container.Container, name string, reason string) {
+	gBus.Publish(events.Event{Type: events.Skipped, Action: name, Container: c, Reason: reason})
+}
+
+// setupRuns sets run mode: once (CI) mode, fixed number of back-to-back runs (--count) or recurrent runs
+// every interval, after optional warm-up; single failure fails once and count modes by default
+func setupRuns(c *cli.Context) error {
+	gOnce = c.GlobalBool("once")
+	gCount = c.GlobalInt("count")
+	switch {
+	case gCount < 0:
+		return fmt.Errorf("Invalid count %d: should be positive", gCount)
+	case gCount > 0 && gOnce:
+		return errors.New("Options --count and --once are mutually exclusive")
+	}
+	if value := c.GlobalString("delay-between"); value != "" {
+		if gCount == 0 {
+			return errors.New("Option --delay-between requires --count")
+		}
+		d, err := validate.Duration(value)
+		if err != nil {
+			return fmt.Errorf("Invalid delay between runs '%s'", value)
+		}
+		gDelayBetween = d
+	}
+	if value := c.GlobalString("warmup"); value != "" {
+		d, err := validate.Duration(value)
+		if err != nil {
+			return fmt.Errorf("Invalid warm-up delay '%s'", value)
+		}
+		gWarmup = d
+	}
+	if gOnce || gCount > 0 || c.GlobalIsSet("tolerate-failures") {
+		gTolerateFailures = c.GlobalInt("tolerate-failures")
+	}
+	return nil
+}
+
+// setupDryRun wraps container client with dry run client, which logs chaos actions instead of running them (--dry)
+func setupDryRun() {
+	if action.DryMode {
+		client = container.NewDryRunClient(client)
+	}
+}
+
+// setupSimulation enables dry mode and wraps container client with chaos action recording on virtual clock (--simulate)
+func setupSimulation(c *cli.Context) error {
+	value := c.GlobalString("simulate")
+	if value == "" {
+		return nil
+	}
+	d, err := validate.Duration(value)
+	if err != nil || d == 0 {
+		return fmt.Errorf("Invalid simulation time '%s'", value)
+	}
+	if c.GlobalBool("once") {
+		return errors.New("Options --simulate and --once are mutually exclusive")
+	}
+	if c.GlobalInt("count") > 0 {
+		return errors.New("Options --simulate and --count are mutually exclusive")
+	}
+	gSimulationFormat = c.GlobalString("simulate-format")
+	if err = output.Validate(gSimulationFormat); err != nil {
+		return err
+	}
+	action.DryMode = true
+	gSimulation = simulate.New(d)
+	// freeze windows of targets are evaluated on virtual clock
+	action.Now = gSimulation.Now
+	client = simulate.NewClient(container.NewDryRunClient(client), gSimulation)
+	gBus.Subscribe(gSimulation.Handle)
+	return nil
+}
+
+// setupSummary subscribes chaos action counting to event bus and starts periodic summary logging,
+// when --summary-interval or --quiet is set
+func setupSummary(c *cli.Context) error {
+	var interval time.Duration
+	if c.GlobalBool("quiet") {
+		interval = summary.DefaultInterval
+	}
+	if value := c.GlobalString("summary-interval"); value != "" {
+		d, err := validate.Duration(value)
+		if err != nil || d == 0 {
+			return fmt.Errorf("Invalid summary interval '%s'", value)
+		}
+		interval = d
+	}
+	if interval == 0 {
+		return nil
+	}
+	gSummary = summary.New(interval)
+	gBus.Subscribe(gSummary.Handle)
+	gSummary.Start()
+	return nil
+}
+
+// setupLock wraps container client with experiment locks, shared by Pumba instances
+func setupLock(c *cli.Context) error {
+	backend := c.GlobalString("lock")
+	if backend == "" {
+		if c.GlobalString("kubeconfig") != "" {
+			return errors.New("Option --kubeconfig requires --lock k8s://namespace")
+		}
+		return nil
+	}
+	ttl, err := validate.Duration(c.GlobalString("lock-ttl"))
+	if err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		return errors.New("Lock TTL must be positive")
+	}
+	namespace := c.GlobalString("lock-namespace")
+	if namespace == "" {
+		return errors.New("Undefined lock namespace")
+	}
+	locker, err := lock.Parse(backend, c.GlobalString("kubeconfig"))
+	if err != nil {
+		return err
+	}
+	owner := lock.Owner()
+	log.Infof("Locking containers in namespace '%s' as '%s'", namespace, owner)
+	gLocks = &lock.Held{}
+	client = lock.NewClient(client, locker, namespace, owner, ttl, gLocks)
+	return nil
+}
+
+// setupAdmission wraps container client with admission control of active disruptions (--max-active), shared by
+// Pumba instances through lock backend; local lock directory is used, if lock backend is not set
+func setupAdmission(c *cli.Context, registry *metrics.Registry) error {
+	max := c.GlobalInt("max-active")
+	if max < 0 {
+		return errors.New("Invalid max active disruptions: must be 0 (no limit) or more")
+	}
+	if max == 0 {
+		return nil
+	}
+	ttl, err := validate.Duration(c.GlobalString("lock-ttl"))
+	if err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		return errors.New("Lock TTL must be positive")
+	}
+	namespace := c.GlobalString("lock-namespace")
+	if namespace == "" {
+		return errors.New("Undefined lock namespace")
+	}
+	var locker lock.Locker
+	if backend := c.GlobalString("lock"); backend != "" {
+		locker, err = lock.Parse(backend, c.GlobalString("kubeconfig"))
+	} else {
+		locker, err = lock.NewFileLocker(filepath.Join(os.TempDir(), "pumba-locks"))
+	}
+	if err != nil {
+		return err
+	}
+	log.Infof("Limiting active disruptions in namespace '%s' to %d", namespace, max)
+	gAdmission = lock.NewAdmission(locker, namespace, lock.Owner(), max, ttl)
+	if registry != nil {
+		registry.AdmissionGauge(max, gAdmission.Active)
+	}
+	client = lock.NewAdmissionClient(client, gAdmission)
+	return nil
+}
+
+// setupBackoff wraps container client with denylist of targets, that repeatedly fail chaos actions
+// (--denylist-after); denylisted targets are exposed in metrics, if enabled
+func setupBackoff(c *cli.Context, registry *metrics.Registry) error {
+	after := c.GlobalInt("denylist-after")
+	if after < 0 {
+		return errors.New("Invalid number of failures before denylisting: must be 0 (disabled) or more")
+	}
+	if after == 0 {
+		return nil
+	}
+	base, err := validate.Duration(c.GlobalString("denylist-backoff"))
+	if err != nil {
+		return err
+	}
+	max, err := validate.Duration(c.GlobalString("denylist-max-backoff"))
+	if err != nil {
+		return err
+	}
+	if base <= 0 || max < base {
+		return errors.New("Denylist back-off must be positive and not greater than --denylist-max-backoff")
+	}
+	denylist := backoff.New(after, base, max)
+	if registry != nil {
+		denylist.Observe(func(action string, id string, d time.Duration) { registry.ObserveDenylisted(action) })
+		registry.DenylistGauge(denylist.Len)
+	}
+	client = backoff.NewClient(client, denylist)
+	return nil
+}
+
+// takeover makes Pumba instance, that owns state file, exit and returns its active disruptions
+func takeover(path string, timeout time.Duration) ([]state.Disruption, error) {
+	old, err := state.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("Taking over Pumba instance (PID %d) started at %s", old.PID, old.Started.Format(time.RFC3339))
+	if ds := old.Unadoptable(); len(ds) > 0 {
+		return nil, fmt.Errorf("Pumba instance (PID %d) can not hand over active disruptions: %s", old.PID, disruptionActions(ds))
+	}
+	if err = state.Handover(old.PID, timeout); err != nil {
+		return nil, err
+	}
+	// read state again: disruptions could end before the instance exit
+	if old, err = state.Load(path); err != nil {
+		return nil, err
+	}
+	return old.Disruptions, nil
+}
+
+// disruptionActions lists actions of disruptions
+func disruptionActions(ds []state.Disruption) string {
+	actions := make([]string, 0, len(ds))
+	for _, d := range ds {
+		actions = append(actions, fmt.Sprintf("%s of %s", d.Action, d.ContainerName))
+	}
+	return strings.Join(actions, ", ")
+}
+
+// handoverRefusal returns reason to refuse handover to new Pumba instance, or empty string; new instance restores
+// only adoptable disruptions, container locks and active disruption slots are not handed over
+func handoverRefusal() string {
+	if ds := gState.Unadoptable(); len(ds) > 0 {
+		return fmt.Sprintf("active disruptions can not be restored by new instance: %s", disruptionActions(ds))
+	}
+	if gLocks != nil && gLocks.Count() > 0 {
+		return fmt.Sprintf("%d container locks are held", gLocks.Count())
+	}
+	if gAdmission != nil && gAdmission.Active() > 0 {
+		return fmt.Sprintf("%d active disruption slots are held", gAdmission.Active())
+	}
+	return ""
+}
+
+// parseContext parses key=value pairs
+func parseContext(pairs []string) (map[string]string, error) {
+	ctx := map[string]string{}
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("Invalid context '%s': expected key=value", pair)
+		}
+		ctx[strings.TrimSpace(kv[0])] = kv[1]
+	}
+	return ctx, nil
+}
+
+// loggingConfig returns 'logging' block of configuration file (if the file exists or --config is set) and --log-caller
+func loggingConfig(c *cli.Context) (logging.Config, error) {
+	var cfg logging.Config
+	filename := c.GlobalString("config")
+	if _, err := os.Stat(filename); err == nil || c.GlobalIsSet("config") {
+		file, err := config.Load(filename)
+		if err != nil {
+			return cfg, err
+		}
+		cfg = file.Logging
+	}
+	if c.GlobalBool("log-caller") {
+		cfg.Caller = true
+	}
+	// quiet mode: log chaos summary, unless summary module level is configured
+	if c.GlobalBool("quiet") {
+		if cfg.Levels == nil {
+			cfg.Levels = map[string]string{}
+		}
+		if _, ok := cfg.Levels["summary"]; !ok {
+			cfg.Levels["summary"] = "info"
+		}
+	}
+	return cfg, nil
+}
+
+// slackHooks returns Slack log hooks configured by --slackhook (with --slackchannel, --slacklevel, --slackemoji
+// and --slackuser) and --slack flags
+func slackHooks(c *cli.Context) ([]*slackrus.SlackrusHook, error) {
+	var hooks []*slackrus.SlackrusHook
+	if url := c.GlobalString("slackhook"); url != "" {
+		hook := &slackrus.SlackrusHook{
+			HookURL:   url,
+			Channel:   c.GlobalString("slackchannel"),
+			IconEmoji: c.GlobalString("slackemoji"),
+			Username:  c.GlobalString("slackuser"),
+		}
+		if err := setSlackLevel(hook, c.GlobalString("slacklevel")); err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	for _, spec := range c.GlobalStringSlice("slack") {
+		hook, err := parseSlackHook(spec, c.GlobalString("slackchannel"), c.GlobalString("slackemoji"), c.GlobalString("slackuser"))
+		if err != nil {
+			return nil, err
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, nil
+}
+
+// parseSlackHook parses Slack hook 'url[,channel=#name][,level=error][,emoji=:icon:][,user=name]';
+// channel, emoji and user default to specified values, level - to current log level
+func parseSlackHook(spec, channel, emoji, user string) (*slackrus.SlackrusHook, error) {
+	parts := strings.Split(spec, ",")
+	url := strings.TrimSpace(parts[0])
+	if url == "" || strings.Contains(url, "=") {
+		return nil, fmt.Errorf("Invalid Slack hook '%s': expected url[,channel=#name][,level=error][,emoji=:icon:][,user=name]", spec)
+	}
+	hook := &slackrus.SlackrusHook{HookURL: url, Channel: channel, IconEmoji: emoji, Username: user}
+	level := ""
+	for _, part := range parts[1:] {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("Invalid Slack hook option '%s': expected key=value", part)
+		}
+		value := strings.TrimSpace(kv[1])
+		switch strings.TrimSpace(kv[0]) {
+		case "channel":
+			hook.Channel = value
+		case "level":
+			level = value
+		case "emoji":
+			hook.IconEmoji = value
+		case "user":
+			hook.Username = value
+		default:
+			return nil, fmt.Errorf("Unknown Slack hook option '%s': must be 'channel', 'level', 'emoji' or 'user'", kv[0])
+		}
+	}
+	if err := setSlackLevel(hook, level); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+// setSlackLevel sets lowest log level accepted by Slack hook; empty - current log level
+func setSlackLevel(hook *slackrus.SlackrusHook, level string) error {
+	threshold := log.GetLevel()
+	if level != "" {
+		var err error
+		if threshold, err = log.ParseLevel(level); err != nil {
+			return fmt.Errorf("Invalid Slack log level '%s'", level)
+		}
+	}
+	hook.AcceptedLevels = slackrus.LevelThreshold(threshold)
+	return nil
+}
+
+// contextHook adds context pairs to all log entries
+type contextHook map[string]string
+
+func (h contextHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h contextHook) Fire(entry *log.Entry) error {
+	for k, v := range h {
+		if _, ok := entry.Data[k]; !ok {
+			entry.Data[k] = v
+		}
+	}
+	return nil
+}
+
+// createUI creates web UI server (--ui), that runs saved scenarios with the container client; nil, if disabled
+func createUI(c *cli.Context) (*ui.Server, error) {
+	dir := c.GlobalString("scenario-dir")
+	if !c.GlobalBool("ui") {
+		if dir != "" {
+			return nil, errors.New("Option --scenario-dir requires --ui")
+		}
+		return nil, nil
+	}
+	if c.GlobalString("api-addr") == "" {
+		return nil, errors.New("Option --ui requires --api-addr")
+	}
+	if dir != "" {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("Invalid scenario directory '%s'", dir)
+		}
+	}
+	return ui.NewServer(dir, gHistory, runScenario), nil
+}
+
+// serveAPI serves Pumba API (/status, /loglevel, /gates) and web UI (/ui/, if not nil) in background
+func serveAPI(addr string, srv *status.Server, gates *scenario.Gates, webUI *ui.Server) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/status", srv)
+	mux.Handle(logging.LevelPath, logging.LevelHandler{})
+	mux.Handle(scenario.GatesPath, gates)
+	mux.Handle(scenario.GatesPath+"/", gates)
+	log.Infof("Serving Pumba API on http://%s/status", listener.Addr())
+	if webUI != nil {
+		mux.Handle(ui.Prefix, webUI)
+		log.Infof("Serving Pumba web UI on http://%s%s", listener.Addr(), ui.Prefix)
+	}
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			log.Error(err)
+		}
+	}()
+	return nil
+}
+
+// pprofAddr binds pprof address without host (e.g. ':6060') to localhost; profiling data should not be exposed
+// on all interfaces, unless host is set explicitly
+func pprofAddr(addr string) string {
+	if host, port, err := net.SplitHostPort(addr); err == nil && host == "" {
+		return net.JoinHostPort("localhost", port)
+	}
+	return addr
+}
+
+// servePprof serves pprof endpoints (/debug/pprof/) in background, on localhost by default; command line endpoint
+// is not served: Pumba command line may carry credentials (e.g. lock URL, Slack hook)
+func servePprof(addr string) error {
+	listener, err := net.Listen("tcp", pprofAddr(addr))
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	log.Infof("Serving pprof on http://%s/debug/pprof/", listener.Addr())
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			log.Error(err)
+		}
+	}()
+	return nil
+}
+
+func serveMetrics(addr string, registry *metrics.Registry) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", registry)
+	log.Infof("Serving metrics on http://%s/metrics", listener.Addr())
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			log.Error(err)
+		}
+	}()
+	return nil
+}
+
+func createMarker(c *cli.Context) (marker.Marker, error) {
+	mode := c.GlobalString("mark")
+	if mode == "" {
+		return nil, nil
+	}
+	cooldown, err := validate.Duration(c.GlobalString("mark-cooldown"))
+	if err != nil {
+		return nil, err
+	}
+	switch mode {
+	case "state":
+		if gState == nil {
+			return nil, errors.New("State mark requires --state-file")
+		}
+		return marker.NewStateMarker(gState, cooldown), nil
+	case "audit":
+		f, err := os.OpenFile(c.GlobalString("mark-file"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, err
+		}
+		m := marker.NewAuditMarker(redact.NewWriter(f, gRedactor), cooldown)
+		m.Context = gContext
+		return m, nil
+	}
+	return nil, fmt.Errorf("Unexpected mark mode: '%s'", mode)
+}
+
+// after run after command completes
+func after(c *cli.Context) error {
+	if gMarker != nil {
+		return gMarker.Close()
+	}
+	return nil
+}
+
+// beforeCommand run before each chaos command
+func beforeCommand(c *cli.Context) error {
+	// in once and count modes interval is not used; interval of collected chaos commands is resolved by runMulti caller
+	if gOnce || gCount > 0 || collector(c) != nil {
+		return nil
+	}
+	// get recurrent time interval
+	if intervalString := c.GlobalString("interval"); intervalString == "" {
+		return errors.New("Undefined interval value.")
+	} else if interval, err := validate.Duration(intervalString); err != nil {
+		return err
+	} else {
+		gInterval = interval
+	}
+	return nil
+}
+
+func getNamesOrPattern(c *cli.Context) ([]string, string) {
+	names := []string{}
+	pattern := ""
+	// get container names or pattern: no Args means ALL containers (requires --all)
+	if c.Args().Present() {
+		// more than one argument, assume that this a list of names
+		if len(c.Args()) > 1 {
+			names = c.Args()
+			log.Debugf("Names: '%s'", names)
+		} else {
+			first := c.Args().First()
+			if strings.HasPrefix(first, Re2Prefix) {
+				pattern = strings.Trim(first, Re2Prefix)
+				log.Debugf("Pattern: '%s'", pattern)
+			} else {
+				names = append(names, first)
+				log.Debugf("Names: '%s'", names)
+			}
+		}
+	}
+	return names, pattern
+}
+
+// errNoTargets chaos command without targets and without --all (safe mode)
+var errNoTargets = errors.New("No target containers: specify container names or 're2:' pattern, or use --all option to target ALL containers")
+
+func runChaosCommand(c *cli.Context, cmd interface{}, names []string, pattern string, chaosFn chaosFunc) error {
+	if gTargets != nil {
+		if len(names) > 0 || pattern != "" {
+			err := errors.New("Container names or 're2:' pattern can not be used with --targets-file")
+			log.Error(err)
+			return err
+		}
+		chaosFn = withTargets(gTargets, chaosFn)
+	} else if len(names) == 0 && pattern == "" && !gAll && gNamePrefix == "" {
+		log.Error(errNoTargets)
+		return errNoTargets
+	}
+	if pattern != "" {
+		if _, err := validate.Pattern(pattern); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+	if err := confirmTargets(cmd, names, pattern); err != nil {
+		log.Error(err)
+		return err
+	}
+	if collect := collector(c); collect != nil {
+		collect(cmd, names, pattern, chaosFn)
+		return nil
+	}
+	return runScheduler(gCommandLine, gInterval, func() error { return chaosFn(client, names, pattern, cmd) })
+}
+
+// withTargets runs chaos command on current targets of --targets-file; run is skipped, when there are no targets
+func withTargets(source targets.Source, chaosFn chaosFunc) chaosFunc {
+	return func(client container.Client, _ []string, _ string, cmd interface{}) error {
+		names, err := source.Targets()
+		if err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			log.Info("No targets in --targets-file: skipping chaos command run")
+			return nil
+		}
+		return chaosFn(client, names, "", cmd)
+	}
+}
+
+// destructiveCommand returns name of destructive chaos command (rm, kill, stop, oom or env), or empty string
+func destructiveCommand(cmd interface{}) string {
+	switch cmd.(type) {
+	case action.CommandRemove:
+		return "rm"
+	case action.CommandKill:
+		return "kill"
+	case action.CommandStop:
+		return "stop"
+	case action.CommandOOM:
+		return "oom"
+	case action.CommandEnv:
+		return "env"
+	}
+	return ""
+}
+
+// confirmTargets lists containers, matching destructive chaos command, and asks user to confirm it (--interactive);
+// no confirmation in dry run or when not running from TTY
+func confirmTargets(cmd interface{}, names []string, pattern string) error {
+	name := destructiveCommand(cmd)
+	if !gInteractive || name == "" || action.DryMode {
+		return nil
+	}
+	if !isTerminal() {
+		log.Debugf("Not running from TTY: skip confirmation of '%s' command", name)
+		return nil
+	}
+	containers, err := action.FindContainers(client, names, pattern)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(gPromptOut, "'%s' command matches %d containers:\n", name, len(containers))
+	for _, c := range containers {
+		id := c.ID()
+		if len(id) > 12 {
+			id = id[:12]
+		}
+		fmt.Fprintf(gPromptOut, "  %s (%s) %s\n", strings.TrimPrefix(c.Name(), "/"), id, c.ImageName())
+	}
+	fmt.Fprint(gPromptOut, "Continue? [y/N]: ")
+	answer, _ := bufio.NewReader(gPromptIn).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return fmt.Errorf("'%s' command is not confirmed", name)
+}
+
+// confirmStep waits for operator confirmation of manual scenario step: with Pumba API (/gates), if it is served,
+// or TTY prompt; manual step is aborted on termination
+func confirmStep(scenarioName string, step scenario.Step) error {
+	if gStatus != nil {
+		return gGates.Wait(scenarioName, step)
+	}
+	fmt.Fprintf(gPromptOut, "Scenario '%s' manual step '%s' (%s). Continue? [y/N]: ", scenarioName, step.Name, step.Action)
+	answers := make(chan string, 1)
+	go func() {
+		answer, _ := bufio.NewReader(gPromptIn).ReadString('\n')
+		answers <- answer
+	}()
+	select {
+	case answer := <-answers:
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return nil
+		}
+		return fmt.Errorf("Manual step '%s' is not confirmed", step.Name)
+	case <-gGates.Closed():
+		return scenario.ErrGatesClosed
+	}
+}
+
+// runScheduler runs chaos task with new scheduler; chaos command is reported by Pumba API
+func runScheduler(command string, interval time.Duration, task scheduler.Task) error {
+	if gSimulation != nil {
+		return runSimulation(command, interval, task)
+	}
+	s := newScheduler(interval)
+	defer gSchedulers.Add(s)()
+	if gStatus != nil {
+		defer gStatus.Register(command, s)()
+	}
+	if gHistory != nil {
+		task = history.RecordRun(gHistory, command, gContext, task)
+	}
+	beforeRun := s.BeforeRun
+	s.BeforeRun = func() {
+		beforeRun()
+		gBus.Publish(events.Event{Type: events.Scheduled, Command: command})
+	}
+	if s.Warmup > 0 {
+		log.Infof("Warming up for %s before chaos command '%s'", s.Warmup, command)
+		s.AfterWarmup = func() {
+			log.Infof("Warm-up complete, chaos starting: '%s'", command)
+			gBus.Publish(events.Event{Type: events.WarmupComplete, Command: command})
+		}
+	}
+	return s.Run(task)
+}
+
+// runSimulation runs chaos task on virtual clock and prints timeline of simulated chaos actions
+func runSimulation(command string, interval time.Duration, task scheduler.Task) error {
+	log.Infof("Simulating %s of chaos command '%s'", gSimulation.Duration, command)
+	if err := gSimulation.Run(interval, gMaxRuns, task); err != nil {
+		return err
+	}
+	report := gSimulation.Report(command)
+	return output.Write(gSimulationOut, gSimulationFormat, report, func(out io.Writer) error { return simulate.WriteTable(out, report) })
+}
+
+func newScheduler(interval time.Duration) *scheduler.Scheduler {
+	s := scheduler.New(interval)
+	s.Once = gOnce
+	s.MaxRuns = gMaxRuns
+	s.Count = gCount
+	s.Delay = gDelayBetween
+	s.TolerateFailures = gTolerateFailures
+	s.Warmup = gWarmup
+	// signal handler waits for running chaos command
+	s.BeforeRun = func() { gWG.Add(1) }
+	s.AfterRun = func(err error) {
+		if err != nil {
+			logRunError(err)
+		}
+		gWG.Done()
+	}
+	return s
+}
+
+// logRunError logs error of chaos command run; errors of chaos action on container are logged with action,
+// container ID and error kind
+func logRunError(err error) {
+	e, ok := err.(*container.Error)
+	if !ok {
+		log.Error(err)
+		return
+	}
+	fields := log.Fields{"action": e.Action, "container": e.ContainerID}
+	switch kind := e.Kind.(type) {
+	case *container.ErrExecFailed:
+		fields["exit_code"] = kind.ExitCode
+	case error:
+		fields["kind"] = kind.Error()
+	}
+	log.WithFields(fields).Error(err)
+}
+
+// MULTI command
+func multi(c *cli.Context) error {
+	specs := parseMultiSpec(c.String("spec"))
+	if len(specs) == 0 {
+		err := errors.New("Undefined chaos commands spec")
+		log.Error(err)
+		return err
+	}
+	return runMulti(c, specs, chaos, gInterval)
+}
+
+// collectFunc collects chaos command, instead of running it
+type collectFunc func(cmd interface{}, names []string, pattern string, chaosFn chaosFunc)
+
+// collectorKey key of chaos command collector in metadata of application, that runs chaos commands of 'multi' spec
+const collectorKey = "collector"
+
+// chaosKey key of chaos actions in metadata of application, that runs chaos commands of profile
+const chaosKey = "chaos"
+
+// chaosOf returns chaos actions of command context: chaos actions with profile settings or default chaos actions
+func chaosOf(c *cli.Context) action.Chaos {
+	if c != nil && c.App != nil {
+		if ch, ok := c.App.Metadata[chaosKey].(action.Chaos); ok {
+			return ch
+		}
+	}
+	return chaos
+}
+
+// collector returns chaos command collector of command context, if any
+func collector(c *cli.Context) collectFunc {
+	if c == nil || c.App == nil {
+		return nil
+	}
+	collect, _ := c.App.Metadata[collectorKey].(collectFunc)
+	return collect
+}
+
+// runMulti runs chaos commands concurrently, with one scheduler; chaos commands run with specified chaos actions
+// on specified interval
+func runMulti(c *cli.Context, specs [][]string, ch action.Chaos, interval time.Duration) error {
+	// collect chaos commands, instead of running them: commands of spec run in copy of application with collector
+	var tasks []func() error
+	var taskSpecs []string
+	var spec string
+	app := *c.App
+	app.Metadata = map[string]interface{}{
+		chaosKey: ch,
+		collectorKey: collectFunc(func(cmd interface{}, names []string, pattern string, chaosFn chaosFunc) {
+			tasks = append(tasks, func() error { return chaosFn(client, names, pattern, cmd) })
+			taskSpecs = append(taskSpecs, spec)
+		}),
+	}
+	for _, args := range specs {
+		spec = strings.Join(args, " ")
+		// every command rotates groups (--group-by) on its own, so concurrent commands select the same group
+		if pumba, ok := ch.(action.Pumba); ok {
+			app.Metadata[chaosKey] = pumba.WithNewRotation()
+		}
+		command := app.Command(args[0])
+		if command == nil || !multiCommands[args[0]] {
+			err := fmt.Errorf("Unsupported chaos command in spec: '%s'", args[0])
+			log.Error(err)
+			return err
+		}
+		set := flag.NewFlagSet(args[0], flag.ContinueOnError)
+		set.Parse(args)
+		if err := command.Run(cli.NewContext(&app, set, c)); err != nil {
+			return err
+		}
+	}
+	log.Infof("Running %d chaos commands", len(tasks))
+	// run all chaos commands concurrently on every scheduler tick
+	return runScheduler(strings.Join(taskSpecs, "; "), interval, func() error {
+		errs := make([]error, len(tasks))
+		var wg sync.WaitGroup
+		for i, task := range tasks {
+			wg.Add(1)
+			go func(i int, task func() error) {
+				defer wg.Done()
+				errs[i] = task()
+			}(i, task)
+		}
+		wg.Wait()
+		var msgs []string
+		for i, err := range errs {
+			if err != nil {
+				msgs = append(msgs, fmt.Sprintf("'%s': %s", taskSpecs[i], err))
+			}
+		}
+		if len(msgs) > 0 {
+			return fmt.Errorf("Chaos commands failed: %s", strings.Join(msgs, "; "))
+		}
+		return nil
+	})
+}
+
+// PROFILE: run named profile from configuration file, when no command is specified
+func runProfile(c *cli.Context) error {
+	name := c.GlobalString("profile")
+	if name == "" {
+		cli.ShowAppHelp(c)
+		return nil
+	}
+	cfg, err := config.Load(c.GlobalString("config"))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	profile, err := cfg.Profile(name)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	log.Infof("Running profile '%s' %s", name, profile.Description)
+	// command line options override profile settings
+	settings, err := profile.Resolve(c.GlobalString("interval"), c.GlobalBool("random"), c.GlobalString("group-by"))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if settings.Interval == 0 && !gOnce && gCount == 0 {
+		err = errors.New("Undefined interval value.")
+		log.Error(err)
+		return err
+	}
+	// profile chaos commands run with chaos actions of profile settings
+	profileChaos := chaos
+	if pumba, ok := chaos.(action.Pumba); ok {
+		pumba.Random, pumba.GroupBy = settings.Random, settings.GroupBy
+		profileChaos = pumba
+	}
+	return runMulti(c, parseMultiSpec(strings.Join(settings.Commands, ";")), profileChaos, settings.Interval)
+}
+
+// parseMultiSpec splits spec into chaos commands (separated by ';') and command arguments
+func parseMultiSpec(spec string) [][]string {
+	var specs [][]string
+	for _, part := range strings.Split(spec, ";") {
+		if args := strings.Fields(part); len(args) > 0 {
+			specs = append(specs, args)
+		}
+	}
+	return specs
+}
+
+// KILL Command
+func kill(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get signal
+	signal := c.String("signal")
+	if err := validate.Signal(signal); err != nil {
+		log.Error(err)
+		return err
+	}
+	return runChaosCommand(c, action.CommandKill{Signal: signal, Cascade: c.Bool("cascade")}, names, pattern, chaosOf(c).KillContainers)
+}
+
+// netemCommand returns arguments of 'netem' command (parent of netem sub-command context), common to netem
+// sub-commands
+func netemCommand(c *cli.Context) (action.CommandNetem, error) {
+	cmd := action.CommandNetem{NetInterface: "eth0"}
+	// get duration
+	var durationString string
+	if c.Parent() != nil {
+		durationString = c.Parent().String("duration")
+	}
+	var err error
+	if cmd.Duration, err = validate.Duration(durationString); err != nil {
+		return cmd, err
+	}
+	if c.Parent() == nil {
+		return cmd, nil
+	}
+	// protect from command injection: interface name is passed to tc
+	cmd.NetInterface = c.Parent().String("interface")
+	if err = validate.Interface(cmd.NetInterface); err != nil {
+		return cmd, err
+	}
+	// get Docker network; interface is resolved per container
+	cmd.Network = c.Parent().String("network")
+	// get target IPs, CIDR ranges and host names filter
+	if target := c.Parent().String("target"); target != "" {
+		if cmd.Targets, cmd.TargetHosts, err = validate.Targets(target); err != nil {
+			return cmd, err
+		}
+	}
+	// get excluded IPs and CIDR ranges
+	if exclude := c.Parent().String("exclude"); exclude != "" {
+		if cmd.Exclude, err = validate.Excludes(exclude); err != nil {
+			return cmd, err
+		}
+	}
+	// get target alias filter
+	cmd.TargetAlias = c.Parent().String("target-alias")
+	if cmd.TargetAlias != "" {
+		if err = validate.Hostname(cmd.TargetAlias); err != nil {
+			return cmd, err
+		}
+	}
+	// get target peer filter: IPs of other matching containers
+	cmd.TargetPeer = c.Parent().Bool("target-peer")
+	// get protocol filter
+	cmd.Protocol = c.Parent().String("protocol")
+	if cmd.Protocol != "" && !netem.ValidProtocol(cmd.Protocol) {
+		return cmd, fmt.Errorf("Unsupported protocol '%s'. Must be 'tcp', 'udp' or 'icmp'", cmd.Protocol)
+	}
+	// get port and per-connection (fwmark) filters
+	cmd.Port = c.Parent().Int("port")
+	if err = validate.Range("port", cmd.Port, 0, 65535); err != nil {
+		return cmd, err
+	}
+	cmd.FwMark = c.Parent().Bool("fwmark")
+	cmd.UID = c.Parent().String("uid")
+	if cmd.UID != "" && !cmd.FwMark {
+		return cmd, errors.New("UID filter requires --fwmark mode")
+	}
+	if cmd.UID != "" {
+		if err = validate.User(cmd.UID); err != nil {
+			return cmd, err
+		}
+	}
+	// get destination hash percent filter
+	cmd.DstPercent = c.Parent().Int("dst-percent")
+	if err = validate.Percent("destination percent", cmd.DstPercent); err != nil {
+		return cmd, err
+	}
+	if cmd.DstPercent > 0 && (len(cmd.Targets) > 0 || len(cmd.TargetHosts) > 0 || cmd.TargetAlias != "" || cmd.TargetPeer || cmd.FwMark) {
+		return cmd, errors.New("Destination percent filter is not supported with --target, --target-alias, --target-peer and --fwmark")
+	}
+	// re-apply netem on container restart
+	cmd.ReapplyOnRestart = c.Parent().Bool("reapply-on-restart")
+	// get latency probe target
+	cmd.Probe = c.Parent().String("probe")
+	if cmd.Probe != "" {
+		if err = validate.Probe(cmd.Probe); err != nil {
+			return cmd, err
+		}
+	}
+	// commands to exec in container before and after netem
+	cmd.ExecHooks = execHooks(c.Parent())
+	// helper tools to inject into container
+	cmd.InjectTools, err = injectTools(c.Parent())
+	return cmd, err
+}
+
+// NETEM DELAY command
+func netemDelay(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get duration, network interface and filters
+	netemCmd, err := netemCommand(c)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	// get delay amount; jitter-only delay has zero base delay
+	jitterOnly := c.Bool("jitter-only")
+	amount := c.Int("amount")
+	if jitterOnly {
+		amount = 0
+	} else if amount <= 0 {
+		err = errors.New("Invalid delay amount")
+		log.Error(err)
+		return err
+	}
+	// get delay variation
 	variation := c.Int("variation")
-	if variation < 0 || variation > amount {
+	if variation < 0 || (!jitterOnly && variation > amount) {
+		err = errors.New("Invalid delay variation")
+		log.Error(err)
+		return err
+	}
+	if jitterOnly && variation == 0 {
+		err = errors.New("Jitter-only delay requires --variation")
+		log.Error(err)
+		return err
+	}
+	// get delay variation
+	correlation := c.Int("correlation")
+	if err = validate.Percent("delay correlation", correlation); err != nil {
+		log.Error(err)
+		return err
+	}
+	// get delay distribution
+	distribution := c.String("distribution")
+	if distribution != "" && !netem.ValidDistribution(distribution) {
+		err = fmt.Errorf("Unsupported delay distribution '%s'. Must be 'uniform', 'normal', 'pareto' or 'paretonormal'", distribution)
+		log.Error(err)
+		return err
+	}
+	if distribution != "" && variation == 0 {
+		err = errors.New("Delay distribution requires --variation")
+		log.Error(err)
+		return err
+	}
+	// get reorder percent and queue limit
+	reorder := c.Int("reorder")
+	if err = validate.Percent("reorder percent", reorder); err != nil {
+		log.Error(err)
+		return err
+	}
+	if reorder > 0 && amount == 0 {
+		err = errors.New("Packet reorder requires base delay (--amount)")
+		log.Error(err)
+		return err
+	}
+	if reorder > 0 {
+		log.Warn("Packets will be reordered (--reorder): TCP-based applications may see retransmissions and reduced throughput")
+	}
+	limit := c.Int("limit")
+	if limit < 0 {
+		err = errors.New("Invalid netem queue limit: must be 0 (auto) or more")
+		log.Error(err)
+		return err
+	}
+	// pepare netem delay command
+	delayCmd := action.CommandNetemDelay{
+		CommandNetem: netemCmd,
+		Amount:       amount,
+		Variation:    variation,
+		Correlation:  correlation,
+		Distribution: distribution,
+		Reorder:      reorder,
+		Limit:        limit,
+	}
+	return runChaosCommand(c, delayCmd, names, pattern, chaosOf(c).NetemDelayContainers)
+}
+
+// NETEM CORRUPT command
+func netemCorrupt(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get duration, network interface and filters
+	netemCmd, err := netemCommand(c)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	// get corrupt percent and correlation
+	percent := c.Int("percent")
+	if err = validate.Range("corrupt percent", percent, 1, 100); err != nil {
+		log.Error(err)
+		return err
+	}
+	correlation := c.Int("correlation")
+	if err = validate.Percent("corrupt correlation", correlation); err != nil {
+		log.Error(err)
+		return err
+	}
+	cmd := action.CommandNetemCorrupt{CommandNetem: netemCmd, Percent: percent, Correlation: correlation}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).NetemCorruptContainers)
+}
+
+// NETEM THROTTLE command
+func netemThrottle(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get duration, network interface and filters
+	netemCmd, err := netemCommand(c)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	cmd := action.CommandNetemThrottle{CommandNetem: netemCmd, Rate: c.String("rate"), Burst: c.String("burst"), Latency: c.Int("latency")}
+	switch {
+	case cmd.Rate == "":
+		err = errors.New("Undefined throttle rate: specify --rate, e.g. '1mbit'")
+	case !netem.ValidRate(cmd.Rate):
+		err = fmt.Errorf("Invalid throttle rate '%s': must be number with unit, e.g. '1mbit', '500kbit' or '10mbps'", cmd.Rate)
+	case !netem.ValidSize(cmd.Burst):
+		err = fmt.Errorf("Invalid throttle burst '%s': must be size with unit, e.g. '32kbit' or '10kb'", cmd.Burst)
+	default:
+		err = validate.Range("throttle latency", cmd.Latency, 1, 60000)
+	}
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).NetemThrottleContainers)
+}
+
+// NETEM STOP command
+func netemStop(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get network interface or Docker network; netem duration and filters are ignored
+	cmd := action.CommandNetemStop{NetInterface: "eth0"}
+	if c.Parent() != nil {
+		cmd.NetInterface = c.Parent().String("interface")
+		cmd.Network = c.Parent().String("network")
+	}
+	if err := validate.Interface(cmd.NetInterface); err != nil {
+		log.Error(err)
+		return err
+	}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).NetemStopContainers)
+}
+
+// NETEM COMBINE command
+func netemCombine(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get duration, network interface and filters
+	netemCmd, err := netemCommand(c)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	cmd := action.CommandNetemCombine{
+		CommandNetem:       netemCmd,
+		Delay:              c.Int("delay"),
+		Variation:          c.Int("variation"),
+		DelayCorrelation:   c.Int("delay-correlation"),
+		Distribution:       c.String("distribution"),
+		Loss:               c.Int("loss"),
+		LossCorrelation:    c.Int("loss-correlation"),
+		Corrupt:            c.Int("corrupt"),
+		CorruptCorrelation: c.Int("corrupt-correlation"),
+	}
+	switch {
+	case cmd.Delay <= 0 && cmd.Loss <= 0 && cmd.Corrupt <= 0:
+		err = errors.New("Specify at least one impairment: --delay, --loss or --corrupt")
+	case cmd.Delay < 0:
+		err = errors.New("Invalid delay amount")
+	case cmd.Variation < 0 || cmd.Variation > cmd.Delay:
 		err = errors.New("Invalid delay variation")
+	case cmd.Distribution != "" && !netem.ValidDistribution(cmd.Distribution):
+		err = fmt.Errorf("Unsupported delay distribution '%s'. Must be 'uniform', 'normal', 'pareto' or 'paretonormal'", cmd.Distribution)
+	case cmd.Distribution != "" && cmd.Variation == 0:
+		err = errors.New("Delay distribution requires --variation")
+	default:
+		for _, p := range []struct {
+			name  string
+			value int
+		}{
+			{"delay correlation", cmd.DelayCorrelation},
+			{"loss percent", cmd.Loss},
+			{"loss correlation", cmd.LossCorrelation},
+			{"corrupt percent", cmd.Corrupt},
+			{"corrupt correlation", cmd.CorruptCorrelation},
+		} {
+			if err = validate.Percent(p.name, p.value); err != nil {
+				break
+			}
+		}
+	}
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).NetemCombineContainers)
+}
+
+// lossOption integer option of netem loss model
+type lossOption struct {
+	name  string
+	value *int
+}
+
+// lossOptions returns options of netem loss model, bound to command fields; the first option is required
+func lossOptions(model string, cmd *action.CommandNetemLoss) []lossOption {
+	switch model {
+	case netem.LossModelState:
+		return []lossOption{{"p13", &cmd.P13}, {"p31", &cmd.P31}, {"p32", &cmd.P32}, {"p23", &cmd.P23}, {"p14", &cmd.P14}}
+	case netem.LossModelGE:
+		return []lossOption{{"pg", &cmd.PG}, {"pb", &cmd.PB}, {"one-h", &cmd.OneH}, {"one-k", &cmd.OneK}}
+	}
+	return []lossOption{{"percent", &cmd.Percent}, {"correlation", &cmd.Correlation}}
+}
+
+// NETEM LOSS command
+func netemLoss(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get duration, network interface and filters
+	netemCmd, err := netemCommand(c)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	cmd := action.CommandNetemLoss{CommandNetem: netemCmd, Model: c.String("model")}
+	if !netem.ValidLossModel(cmd.Model) {
+		err = fmt.Errorf("Unsupported loss model '%s'. Must be 'random', 'state' or 'gemodel'", cmd.Model)
+		log.Error(err)
+		return err
+	}
+	// options of other models are rejected: they would be silently ignored
+	for _, model := range []string{netem.LossModelRandom, netem.LossModelState, netem.LossModelGE} {
+		if model == cmd.Model {
+			continue
+		}
+		for _, option := range lossOptions(model, &cmd) {
+			if c.IsSet(option.name) {
+				err = fmt.Errorf("Option --%s requires --model %s", option.name, model)
+				log.Error(err)
+				return err
+			}
+		}
+	}
+	// get loss model parameters in percents
+	for i, option := range lossOptions(cmd.Model, &cmd) {
+		*option.value = c.Int(option.name)
+		min := 0
+		if i == 0 {
+			min = 1
+		}
+		if err = validate.Range("loss "+option.name, *option.value, min, 100); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).NetemLossContainers)
+}
+
+// PORTS command
+func ports(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get duration
+	duration, err := validate.Duration(c.String("duration"))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	// get loss percent
+	loss := c.Int("loss")
+	if err = validate.Range("packet loss", loss, 1, 100); err != nil {
+		log.Error(err)
+		return err
+	}
+	cmd := action.CommandPorts{Duration: duration, Loss: loss, ExecHooks: execHooks(c)}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).PortsContainers)
+}
+
+// HTTP command
+func httpChaos(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get duration
+	duration, err := validate.Duration(c.String("duration"))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	// get HTTP port
+	port := c.Int("port")
+	if err = validate.Range("HTTP port", port, 1, 65535); err != nil {
+		log.Error(err)
+		return err
+	}
+	proxyPort := c.Int("proxy-port")
+	if err = validate.Range("proxy port", proxyPort, 1, 65535); err != nil {
+		log.Error(err)
+		return err
+	}
+	if proxyPort == port {
+		err = errors.New("Invalid proxy port: must differ from HTTP port")
+		log.Error(err)
+		return err
+	}
+	// get delay and rates
+	delay := c.Int("delay")
+	if delay < 0 {
+		err = errors.New("Invalid HTTP delay")
+		log.Error(err)
+		return err
+	}
+	errorRate := c.Int("error-rate")
+	if err = validate.Percent("HTTP error rate", errorRate); err != nil {
+		log.Error(err)
+		return err
+	}
+	truncateRate := c.Int("truncate-rate")
+	if err = validate.Percent("HTTP truncate rate", truncateRate); err != nil {
+		log.Error(err)
+		return err
+	}
+	errorCode := c.Int("error-code")
+	if err = validate.Range("HTTP error code", errorCode, 100, 599); err != nil {
+		log.Error(err)
+		return err
+	}
+	image := c.String("image")
+	if image == "" {
+		image = DefaultProxyImage
+	}
+	cmd := action.CommandHTTP{
+		Port:         port,
+		ProxyPort:    proxyPort,
+		Image:        image,
+		Duration:     duration,
+		Delay:        delay,
+		ErrorRate:    errorRate,
+		ErrorCode:    errorCode,
+		TruncateRate: truncateRate,
+	}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).HTTPContainers)
+}
+
+// PROXY command: HTTP fault injection proxy, running inside sidecar container
+func runProxy(c *cli.Context) error {
+	port := c.Int("port")
+	proxyPort := c.Int("proxy-port")
+	handler := proxy.NewHandler(proxy.Config{
+		Upstream:     fmt.Sprintf("127.0.0.1:%d", port),
+		Delay:        time.Duration(c.Int("delay")) * time.Millisecond,
+		ErrorRate:    c.Int("error-rate"),
+		ErrorCode:    c.Int("error-code"),
+		TruncateRate: c.Int("truncate-rate"),
+	})
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", proxyPort))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if err = proxy.Redirect(port, proxyPort); err != nil {
+		log.Error(err)
+		return err
+	}
+	// remove redirect rule on termination or proxy failure (once); signal handler waits for WaitGroup
+	gWG.Add(1)
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			if err := proxy.Unredirect(port, proxyPort); err != nil {
+				log.Error(err)
+			}
+			gWG.Done()
+		})
+	}
+	stop := make(chan os.Signal, 1)
+	stopped := make(chan struct{})
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-stop
+		close(stopped)
+		listener.Close()
+		release()
+	}()
+	log.Infof("HTTP proxy listening on %d, forwarding port %d traffic", proxyPort, port)
+	err = http.Serve(listener, handler)
+	select {
+	case <-stopped:
+		// listener is closed on termination
+		gWG.Wait()
+		return nil
+	default:
+	}
+	// proxy failed: remove redirect rule, so traffic is not redirected to closed port
+	signal.Stop(stop)
+	release()
+	err = fmt.Errorf("HTTP proxy failed: %s", err)
+	log.Error(err)
+	return err
+}
+
+// PAUSE command
+func pause(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get duration
+	duration, err := validate.Duration(c.String("duration"))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	cmd := action.CommandPause{Duration: duration, ExecHooks: execHooks(c)}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).PauseContainers)
+}
+
+// CPU command
+func cpu(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get duration
+	duration, err := validate.Duration(c.String("duration"))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	// get number of workers
+	workers := c.Int("workers")
+	if workers < 0 {
+		err = errors.New("Invalid number of CPU workers: must be 0 (one per CPU) or more")
+		log.Error(err)
+		return err
+	}
+	// get helper tools to inject into container
+	tools, err := injectTools(c)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	cmd := action.CommandCPU{Duration: duration, Workers: workers, InjectTools: tools}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).CPUContainers)
+}
+
+// OOM command
+func oom(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get balloon timeout
+	timeout, err := validate.Duration(c.String("timeout"))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if timeout <= 0 {
+		err = errors.New("OOM timeout must be positive")
+		log.Error(err)
+		return err
+	}
+	// get helper tools to inject into container
+	tools, err := injectTools(c)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	cmd := action.CommandOOM{Timeout: timeout, InjectTools: tools}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).OOMContainers)
+}
+
+// FD command
+func fd(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get duration
+	duration, err := validate.Duration(c.String("duration"))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	// get number of free descriptors
+	free := c.Int("free")
+	if free < 0 {
+		err = errors.New("Invalid number of free file descriptors: must be 0 or more")
+		log.Error(err)
+		return err
+	}
+	cmd := action.CommandFD{Duration: duration, Free: free}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).FDContainers)
+}
+
+// POISON-IMAGE command
+func poisonImage(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get duration
+	duration, err := validate.Duration(c.String("duration"))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	// get bogus image
+	image := c.String("image")
+	if image == "" {
+		err = errors.New("Undefined bogus image")
+		log.Error(err)
+		return err
+	}
+	cmd := action.CommandPoisonImage{Duration: duration, Image: image}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).PoisonImageContainers)
+}
+
+// HEALTH command
+func healthcheck(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get duration
+	duration, err := validate.Duration(c.String("duration"))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	// get probe: binary or port
+	probe := health.Probe{Binary: c.String("probe-binary"), Fail: c.Bool("fail"), Port: c.Int("probe-port")}
+	switch {
+	case (probe.Binary == "") == (probe.Port == 0):
+		err = errors.New("Specify healthcheck probe: either --probe-binary or --probe-port")
+	case probe.Binary != "":
+		err = validate.BinaryPath(probe.Binary)
+	case probe.Fail:
+		err = errors.New("Option --fail requires --probe-binary: rejected probe port always fails")
+	default:
+		err = validate.Range("probe port", probe.Port, 1, 65535)
+	}
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	cmd := action.CommandHealth{Duration: duration, Probe: probe}
+	if cmd.InjectTools, err = injectTools(c); err != nil {
+		log.Error(err)
+		return err
+	}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).HealthContainers)
+}
+
+// memory command
+func memory(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get duration
+	duration, err := validate.Duration(c.String("duration"))
+	if err != nil {
 		log.Error(err)
 		return err
 	}
-	// get delay variation
-	correlation := c.Int("correlation")
-	if correlation < 0 || correlation > 100 {
-		err = errors.New("Invalid delay correlation: must be between 0 and 100")
+	// get percent of memory usage
+	percent := c.Int("percent")
+	if err = validate.Range("memory percent", percent, 1, 99); err != nil {
 		log.Error(err)
 		return err
 	}
-	// pepare netem delay command
-	delayCmd := action.CommandNetemDelay{
-		NetInterface: netInterface,
-		IP:           ip,
-		Duration:     duration,
-		Amount:       amount,
-		Variation:    variation,
-		Correlation:  correlation,
+	cmd := action.CommandMemory{Duration: duration, Percent: percent}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).MemoryContainers)
+}
+
+// env re-creates containers with corrupted environment
+func env(c *cli.Context) error {
+	// get names or pattern
+	names, pattern := getNamesOrPattern(c)
+	// get duration
+	duration, err := validate.Duration(c.String("duration"))
+	if err != nil {
+		log.Error(err)
+		return err
 	}
-	runChaosCommand(delayCmd, names, pattern, chaos.NetemDelayContainers)
-	return nil
+	set, unset := c.StringSlice("set"), c.StringSlice("unset")
+	if len(set) == 0 && len(unset) == 0 {
+		err = errors.New("Undefined environment corruption: specify --set NAME=value or --unset NAME")
+		log.Error(err)
+		return err
+	}
+	for _, v := range set {
+		if !strings.Contains(v, "=") {
+			err = fmt.Errorf("Invalid environment variable '%s': must be 'NAME=value'", v)
+		} else {
+			err = validate.EnvName(v[:strings.Index(v, "=")])
+		}
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+	for _, name := range unset {
+		if err = validate.EnvName(name); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+	cmd := action.CommandEnv{Set: set, Unset: unset, WaitTime: c.Int("time"), Duration: duration}
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).EnvContainers)
 }
 
-// PAUSE command
-func pause(c *cli.Context) error {
+// secrets removes secrets and configs from Swarm services of containers
+func secrets(c *cli.Context) error {
 	// get names or pattern
 	names, pattern := getNamesOrPattern(c)
 	// get duration
-	durationString := c.String("duration")
-	if durationString == "" {
-		err := errors.New("Undefined duration interval")
+	duration, err := validate.Duration(c.String("duration"))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	cmd := action.CommandSecrets{Secrets: c.StringSlice("secret"), Configs: c.StringSlice("config"), Duration: duration}
+	if len(cmd.Secrets) == 0 && len(cmd.Configs) == 0 {
+		err = errors.New("Undefined secrets and configs: specify --secret or --config")
 		log.Error(err)
 		return err
 	}
-	duration, err := time.ParseDuration(durationString)
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).SecretsContainers)
+}
+
+// runFDHelper exhausts file descriptors of container processes for duration; run inside target container
+func runFDHelper(c *cli.Context) error {
+	duration, err := validate.Duration(c.String("duration"))
 	if err != nil {
 		log.Error(err)
 		return err
 	}
-	cmd := action.CommandPause{Duration: duration}
-	runChaosCommand(cmd, names, pattern, chaos.PauseContainers)
+	// restore limits on termination; signal handler waits for WaitGroup
+	gWG.Add(1)
+	defer gWG.Done()
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	if err = fdlimit.Run(duration, c.Int("free"), stop); err != nil {
+		log.Error(err)
+		return err
+	}
 	return nil
 }
 
+// execHooks returns in-container exec hooks of command
+func execHooks(c *cli.Context) action.ExecHooks {
+	return action.ExecHooks{Before: c.String("exec-before"), After: c.String("exec-after")}
+}
+
+// injectTools returns host directory with helper tools to inject into target containers; empty - no tools
+func injectTools(c *cli.Context) (string, error) {
+	dir := c.String("inject-tools")
+	if dir == "" {
+		return "", nil
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("Invalid tools directory '%s': %s", dir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("Invalid tools directory '%s': not a directory", dir)
+	}
+	return dir, nil
+}
+
 // REMOVE Command
 func remove(c *cli.Context) error {
 	// get names or pattern
@@ -501,78 +3257,491 @@ func remove(c *cli.Context) error {
 	volumes := c.BoolT("volumes")
 	// run chaos command
 	cmd := action.CommandRemove{Force: force, Links: links, Volumes: volumes}
-	runChaosCommand(cmd, names, pattern, chaos.RemoveContainers)
-	return nil
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).RemoveContainers)
 }
 
 // STOP Command
 func stop(c *cli.Context) error {
 	// get names or pattern
 	names, pattern := getNamesOrPattern(c)
+	cmd := action.CommandStop{WaitTime: c.Int("time"), Restart: c.Bool("restart")}
+	// get blackout window duration
+	durationString := c.String("duration")
+	if cmd.Restart && durationString == "" {
+		err := errors.New("Undefined duration interval: --restart requires --duration")
+		log.Error(err)
+		return err
+	}
+	if durationString != "" {
+		if !cmd.Restart {
+			err := errors.New("Stop --duration requires --restart option")
+			log.Error(err)
+			return err
+		}
+		duration, err := validate.Duration(durationString)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		cmd.Duration = duration
+	}
 	// run chaos command
-	cmd := action.CommandStop{WaitTime: c.Int("time")}
-	runChaosCommand(cmd, names, pattern, chaos.StopContainers)
+	return runChaosCommand(c, cmd, names, pattern, chaosOf(c).StopContainers)
+}
+
+// RECIPE LIST command
+func recipeList(c *cli.Context) error {
+	format := c.String("format")
+	if err := output.Validate(format); err != nil {
+		log.Error(err)
+		return err
+	}
+	var recipes []scenario.Scenario
+	for _, name := range scenario.Recipes() {
+		info, err := scenario.RecipeInfo(name)
+		if err != nil {
+			log.Error(err)
+			return err
+		}
+		// recipes are listed without steps
+		info.Steps = nil
+		recipes = append(recipes, *info)
+	}
+	return output.Write(c.App.Writer, format, recipes, func(out io.Writer) error {
+		for _, info := range recipes {
+			fmt.Fprintf(out, "%s - %s\n", info.Name, info.Description)
+			for _, p := range info.Params {
+				if p.Required {
+					fmt.Fprintf(out, "   --%s\t%s (required)\n", p.Name, p.Description)
+				} else {
+					fmt.Fprintf(out, "   --%s\t%s (default: %s)\n", p.Name, p.Description, p.Default)
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// STATUS command
+func statusCommand(c *cli.Context) error {
+	format := c.String("format")
+	if err := output.Validate(format); err != nil {
+		log.Error(err)
+		return err
+	}
+	st, err := status.Get(c.String("addr"), 10*time.Second)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	return output.Write(c.App.Writer, format, st, func(out io.Writer) error { return status.WriteTable(out, st) })
+}
+
+// HISTORY command
+func historyCommand(c *cli.Context) error {
+	format := c.String("format")
+	if err := output.Validate(format); err != nil {
+		log.Error(err)
+		return err
+	}
+	now := time.Now()
+	filter := history.Filter{Container: c.String("container"), Action: c.String("action")}
+	var err error
+	if filter.Since, err = parseHistoryTime(c.String("since"), now); err != nil {
+		log.Error(err)
+		return err
+	}
+	if filter.Until, err = parseHistoryTime(c.String("until"), now); err != nil {
+		log.Error(err)
+		return err
+	}
+	store, err := history.Open(c.String("db"))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer store.Close()
+	actions, err := store.Actions(filter)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	return output.Write(c.App.Writer, format, actions, func(out io.Writer) error { return history.WriteTable(out, actions) })
+}
+
+// REPORT EXPORT command
+func reportExport(c *cli.Context) error {
+	format := c.String("format")
+	if format != "html" && format != output.JSON {
+		err := fmt.Errorf("Unsupported report format '%s': must be 'html' or 'json'", format)
+		log.Error(err)
+		return err
+	}
+	now := time.Now()
+	filter := history.Filter{Container: c.String("container"), Action: c.String("action")}
+	var err error
+	if filter.Since, err = parseHistoryTime(c.String("since"), now); err != nil {
+		log.Error(err)
+		return err
+	}
+	if filter.Until, err = parseHistoryTime(c.String("until"), now); err != nil {
+		log.Error(err)
+		return err
+	}
+	store, err := history.Open(c.String("db"))
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	defer store.Close()
+	runs, err := store.Runs(filter)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	actions, err := store.Actions(filter)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	probes, err := store.Probes(filter)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	r := report.New(c.String("title"), filter.Since, filter.Until, now, runs, actions, probes)
+	if err = writeReport(c, format, r); err != nil {
+		log.Error(err)
+		return err
+	}
+	return nil
+}
+
+// writeReport writes experiment report to --output file or standard output
+func writeReport(c *cli.Context, format string, r report.Report) error {
+	out := c.App.Writer
+	if path := c.String("output"); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	if format == output.JSON {
+		return output.Write(out, format, r, nil)
+	}
+	return report.WriteHTML(out, r)
+}
+
+// parseHistoryTime parses RFC3339 time or duration ago; empty value - zero time
+func parseHistoryTime(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, fmt.Errorf("Invalid time '%s': expected RFC3339 time or duration, e.g. '24h'", value)
+	}
+	return t, nil
+}
+
+// RECIPE RUN command
+func recipeRun(c *cli.Context) error {
+	if !c.Args().Present() {
+		err := errors.New("Undefined recipe name")
+		log.Error(err)
+		return err
+	}
+	params, err := scenario.ParseArgs(c.Args().Tail())
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	s, err := scenario.Recipe(c.Args().First(), params)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	return runScenario(s)
+}
+
+// SCENARIO RUN command
+func scenarioRun(c *cli.Context) error {
+	if !c.Args().Present() {
+		err := errors.New("Undefined scenario file")
+		log.Error(err)
+		return err
+	}
+	params, err := scenario.ParseArgs(c.Args().Tail())
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	s, err := scenario.Load(c.Args().First(), params)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	return runScenario(s)
+}
+
+func runScenario(s *scenario.Scenario) error {
+	// scenario is running once, protect it from termination in the middle of step
+	gWG.Add(1)
+	defer gWG.Done()
+	runner := scenario.NewRunner(client, chaos, gAll || gNamePrefix != "")
+	if gStatus != nil || isTerminal() {
+		runner.Confirm = confirmStep
+	}
+	runner.ReportMatrix = reportMatrix
+	runner.ReportAssertions = reportAssertions
+	if gHistory != nil {
+		runner.RecordProbe = func(p history.Probe) {
+			if err := gHistory.AddProbe(p); err != nil {
+				log.Warnf("Failed to record probe '%s' in history: %s", p.Name, err)
+			}
+		}
+	}
+	if err := runner.Run(s); err != nil {
+		log.Error(err)
+		return err
+	}
 	return nil
 }
 
+// reportMatrix writes outcomes of scenario matrix cells as text table
+func reportMatrix(scenarioName string, results []scenario.CellResult) {
+	fmt.Fprintf(gMatrixOut, "Scenario '%s' matrix:\n", scenarioName)
+	if err := scenario.WriteMatrixTable(gMatrixOut, results); err != nil {
+		log.Warnf("Failed to write scenario matrix report: %s", err)
+	}
+}
+
+// reportAssertions writes outcomes of scenario assertion steps as text table
+func reportAssertions(scenarioName string, results []scenario.AssertionResult) {
+	fmt.Fprintf(gMatrixOut, "Scenario '%s' assertions:\n", scenarioName)
+	if err := scenario.WriteAssertionsTable(gMatrixOut, results); err != nil {
+		log.Warnf("Failed to write scenario assertions report: %s", err)
+	}
+}
+
 func handleSignals() {
 	// Graceful shut-down on SIGINT/SIGTERM
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
 	signal.Notify(c, syscall.SIGTERM)
 
+	// exit on handover to new Pumba instance, that restores active disruptions
+	if gState != nil && state.HandoverSignal != nil {
+		handover := make(chan os.Signal, 1)
+		signal.Notify(handover, state.HandoverSignal)
+		go func() {
+			for range handover {
+				reason := handoverRefusal()
+				if reason == "" {
+					break
+				}
+				log.Warnf("Refusing handover to new Pumba instance: %s", reason)
+			}
+			log.Infof("Handing over %d active disruptions to new Pumba instance", len(gState.Disruptions()))
+			if gMarker != nil {
+				gMarker.Close()
+			}
+			os.Exit(0)
+		}()
+	}
+
+	// toggle debug logging without restart, keeping scheduler state
+	if logging.DebugSignal != nil {
+		debug := make(chan os.Signal, 1)
+		signal.Notify(debug, logging.DebugSignal)
+		go func() {
+			for range debug {
+				logging.ToggleDebug()
+			}
+		}()
+	}
+
 	go func() {
 		<-c
+		// stop scheduling chaos commands and abort scenarios, waiting for confirmation of manual steps
+		gSchedulers.Stop()
+		gGates.Close()
 		gWG.Wait()
+		if gSummary != nil {
+			gSummary.Stop()
+		}
+		// remove container marks before exit
+		if gMarker != nil {
+			gMarker.Close()
+		}
 		os.Exit(1)
 	}()
 }
 
-// tlsConfig translates the command-line options into a tls.Config struct
+// standard file names of TLS certificates directory (--tlscertdir)
+const (
+	tlsCAFile   = "ca.pem"
+	tlsCertFile = "cert.pem"
+	tlsKeyFile  = "key.pem"
+)
+
+// legacyCertPath default TLS certificates directory of Pumba, used when Docker CLI config directory has no certificates
+const legacyCertPath = "/etc/ssl/docker"
+
+// defaultCertPath returns default TLS certificates directory (without DOCKER_CERT_PATH): Docker CLI config directory,
+// like Docker CLI, if it contains certificates, or legacy Pumba directory
+func defaultCertPath() string {
+	dir := container.ConfigDir()
+	for _, file := range []string{tlsCAFile, tlsCertFile} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err == nil {
+			return dir
+		}
+	}
+	return legacyCertPath
+}
+
+// tlsVerify returns true, if remote should be verified: --tlsverify or any non-empty DOCKER_TLS_VERIFY, like Docker CLI
+func tlsVerify(c *cli.Context) bool {
+	return c.GlobalBool("tlsverify") || os.Getenv("DOCKER_TLS_VERIFY") != ""
+}
+
+// dockerContextName resolves Docker CLI context, like Docker CLI: --docker-context, default context if Docker host is
+// set (--host or DOCKER_HOST), DOCKER_CONTEXT or current context of Docker CLI config
+func dockerContextName(c *cli.Context) (string, error) {
+	name := c.GlobalString("docker-context")
+	host := c.GlobalString("host")
+	switch {
+	case name != "" && host != "":
+		return "", errors.New("Options --host and --docker-context are mutually exclusive")
+	case name != "":
+		return name, nil
+	case host != "" || os.Getenv("DOCKER_HOST") != "":
+		return container.DefaultContext, nil
+	case os.Getenv("DOCKER_CONTEXT") != "":
+		return os.Getenv("DOCKER_CONTEXT"), nil
+	}
+	return container.CurrentContext(container.ConfigDir())
+}
+
+// dockerEndpoint returns Docker host and TLS configuration: from command-line options and DOCKER_* variables
+// for default context or from Docker CLI context
+func dockerEndpoint(c *cli.Context) (string, *tls.Config, error) {
+	name, err := dockerContextName(c)
+	if err != nil {
+		return "", nil, err
+	}
+	if name == container.DefaultContext {
+		tls, err := tlsConfig(c)
+		if err != nil {
+			return "", nil, err
+		}
+		host := c.GlobalString("host")
+		if host == "" {
+			host = os.Getenv("DOCKER_HOST")
+		}
+		host, err = container.ParseHost(host, tls != nil)
+		return host, tls, err
+	}
+	ctx, err := container.LoadContext(container.ConfigDir(), name)
+	if err != nil {
+		return "", nil, err
+	}
+	tls, err := ctx.TLSConfig()
+	if err != nil {
+		return "", nil, err
+	}
+	host, err := container.ParseHost(ctx.Host, tls != nil)
+	if err != nil {
+		return "", nil, err
+	}
+	log.WithFields(log.Fields{"context": name, "host": host}).Debug("using Docker context")
+	return host, tls, nil
+}
+
+// tlsConfig translates the command-line options into a tls.Config struct; TLS options are either PEM content
+// or paths of PEM files; default files (and files in --tlscertdir) are skipped, if missing
 func tlsConfig(c *cli.Context) (*tls.Config, error) {
-	var tlsConfig *tls.Config
-	var err error
-	caCertFlag := c.GlobalString("tlscacert")
-	certFlag := c.GlobalString("tlscert")
-	keyFlag := c.GlobalString("tlskey")
-
-	if c.GlobalBool("tls") || c.GlobalBool("tlsverify") {
-		tlsConfig = &tls.Config{
-			InsecureSkipVerify: !c.GlobalBool("tlsverify"),
-		}
-
-		// Load CA cert
-		if caCertFlag != "" {
-			var caCert []byte
-			if strings.HasPrefix(caCertFlag, "/") {
-				caCert, err = ioutil.ReadFile(caCertFlag)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				caCert = []byte(caCertFlag)
-			}
-			caCertPool := x509.NewCertPool()
-			caCertPool.AppendCertsFromPEM(caCert)
-			tlsConfig.RootCAs = caCertPool
-		}
-
-		// Load client certificate
-		if certFlag != "" && keyFlag != "" {
-			var cert tls.Certificate
-			if strings.HasPrefix(certFlag, "/") && strings.HasPrefix(keyFlag, "/") {
-				cert, err = tls.LoadX509KeyPair(certFlag, keyFlag)
-				if err != nil {
-					return nil, err
-				}
-			} else {
-				cert, err = tls.X509KeyPair([]byte(certFlag), []byte(keyFlag))
-				if err != nil {
-					return nil, err
-				}
-			}
-			tlsConfig.Certificates = []tls.Certificate{cert}
+	certDir := c.GlobalString("tlscertdir")
+	verify := tlsVerify(c)
+	if !c.GlobalBool("tls") && !verify {
+		if certDir != "" {
+			return nil, errors.New("TLS certificates directory (--tlscertdir) requires --tls or --tlsverify option")
+		}
+		return nil, nil
+	}
+	if certDir != "" {
+		if info, err := os.Stat(certDir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("Invalid TLS certificates directory '%s'", certDir)
+		}
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: !verify,
+	}
+
+	// Load CA cert
+	caCert, err := tlsOption(c, "tlscacert", certDir, tlsCAFile, "CA certificate")
+	if err != nil {
+		return nil, err
+	}
+	if caCert != nil {
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("Invalid TLS CA certificate (--tlscacert): no PEM certificates found")
+		}
+		tlsConfig.RootCAs = caCertPool
+	}
+
+	// Load client certificate
+	cert, err := tlsOption(c, "tlscert", certDir, tlsCertFile, "client certificate")
+	if err != nil {
+		return nil, err
+	}
+	key, err := tlsOption(c, "tlskey", certDir, tlsKeyFile, "client key")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case cert != nil && key != nil:
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid TLS client certificate (--tlscert) or key (--tlskey): %s", err)
 		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	case cert != nil:
+		return nil, errors.New("TLS client certificate (--tlscert) requires client key (--tlskey)")
+	case key != nil:
+		return nil, errors.New("TLS client key (--tlskey) requires client certificate (--tlscert)")
 	}
 	return tlsConfig, nil
 }
+
+// tlsOption returns PEM content of TLS option: option value with PEM content or content of PEM file;
+// nil, if option is empty or file, not set explicitly (default value or file in TLS certificates directory),
+// does not exist
+func tlsOption(c *cli.Context, name, certDir, file, description string) ([]byte, error) {
+	value := c.GlobalString(name)
+	explicit := c.GlobalIsSet(name)
+	if certDir != "" && !explicit {
+		value = filepath.Join(certDir, file)
+	}
+	if value == "" {
+		return nil, nil
+	}
+	if strings.Contains(value, "-----BEGIN ") {
+		return []byte(value), nil
+	}
+	data, err := ioutil.ReadFile(value)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			log.Debugf("TLS %s file '%s' does not exist", description, value)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Failed to read TLS %s (--%s) file '%s': %s", description, name, value, err)
+	}
+	return data, nil
+}
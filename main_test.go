@@ -1,18 +1,49 @@
 package main
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
 	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gaia-adm/pumba/action"
 	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/events"
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/lock"
+	"github.com/gaia-adm/pumba/logging"
+	"github.com/gaia-adm/pumba/marker"
+	"github.com/gaia-adm/pumba/metrics"
+	"github.com/gaia-adm/pumba/scenario"
+	"github.com/gaia-adm/pumba/simulate"
+	"github.com/gaia-adm/pumba/state"
+	"github.com/gaia-adm/pumba/status"
+	"github.com/johntdyer/slackrus"
+	"github.com/samalba/dockerclient"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"github.com/urfave/cli"
+
+	log "github.com/Sirupsen/logrus"
 )
 
 //---- MOCK: Chaos Iterface
@@ -46,6 +77,81 @@ func (m *ChaosMock) NetemDelayContainers(c container.Client, n []string, p strin
 	return args.Error(0)
 }
 
+func (m *ChaosMock) NetemCorruptContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+func (m *ChaosMock) HealthContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+func (m *ChaosMock) MemoryContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+func (m *ChaosMock) EnvContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+func (m *ChaosMock) SecretsContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+func (m *ChaosMock) NetemThrottleContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+func (m *ChaosMock) NetemStopContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+func (m *ChaosMock) NetemCombineContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+func (m *ChaosMock) NetemLossContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+func (m *ChaosMock) HTTPContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+func (m *ChaosMock) PortsContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+func (m *ChaosMock) CPUContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+func (m *ChaosMock) OOMContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+func (m *ChaosMock) FDContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
+func (m *ChaosMock) PoisonImageContainers(c container.Client, n []string, p string, cmd interface{}) error {
+	args := m.Called(c, n, p, cmd)
+	return args.Error(0)
+}
+
 //---- TESTS
 
 type mainTestSuite struct {
@@ -53,13 +159,15 @@ type mainTestSuite struct {
 }
 
 func (s *mainTestSuite) SetupSuite() {
-	gTestRun = true
+	gMaxRuns = 1
 }
 
 func (s *mainTestSuite) TearDownSuite() {
 }
 
 func (s *mainTestSuite) SetupTest() {
+	// chaos command tests run without targets: target ALL containers
+	gAll = true
 }
 
 func (s *mainTestSuite) TearDownTest() {
@@ -88,6 +196,200 @@ func (s *mainTestSuite) Test_getPattern() {
 	assert.True(s.T(), pattern == "^test")
 }
 
+func (s *mainTestSuite) Test_getSingleName() {
+	globalSet := flag.NewFlagSet("test", 0)
+	globalSet.Parse([]string{"c1"})
+	c := cli.NewContext(nil, globalSet, nil)
+	names, pattern := getNamesOrPattern(c)
+	assert.Equal(s.T(), []string{"c1"}, names)
+	assert.Equal(s.T(), "", pattern)
+}
+
+func (s *mainTestSuite) Test_safeModeNoTargets() {
+	gAll = false
+	set := flag.NewFlagSet("kill", 0)
+	set.String("signal", "SIGKILL", "doc")
+	c := cli.NewContext(nil, set, nil)
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	err := kill(c)
+	assert.EqualError(s.T(), err, "No target containers: specify container names or 're2:' pattern, or use --all option to target ALL containers")
+	chaosMock.AssertNotCalled(s.T(), "KillContainers", nil, []string{}, "", action.CommandKill{Signal: "SIGKILL"})
+}
+
+func (s *mainTestSuite) Test_safeModeWithTargets() {
+	gAll = false
+	gInterval = 1 * time.Millisecond
+	set := flag.NewFlagSet("kill", 0)
+	set.String("signal", "SIGKILL", "doc")
+	set.Parse([]string{"re2:^api"})
+	c := cli.NewContext(nil, set, nil)
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("KillContainers", nil, []string{}, "^api", action.CommandKill{Signal: "SIGKILL"}).Return(nil)
+	err := kill(c)
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_safeModeNamePrefix() {
+	gAll = false
+	gNamePrefix = "svc_"
+	defer func() { gNamePrefix = "" }()
+	gInterval = 1 * time.Millisecond
+	set := flag.NewFlagSet("kill", 0)
+	set.String("signal", "SIGKILL", "doc")
+	c := cli.NewContext(nil, set, nil)
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("KillContainers", nil, []string{}, "", action.CommandKill{Signal: "SIGKILL"}).Return(nil)
+	err := kill(c)
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+type staticTargets []string
+
+func (t staticTargets) Targets() ([]string, error) { return t, nil }
+
+func (s *mainTestSuite) Test_targetsFile() {
+	gTargets = staticTargets{"api_1", "3f2a9c1b7e4d"}
+	defer func() { gTargets = nil }()
+	gInterval = 1 * time.Millisecond
+	set := flag.NewFlagSet("kill", 0)
+	set.String("signal", "SIGKILL", "doc")
+	c := cli.NewContext(nil, set, nil)
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("KillContainers", nil, []string{"api_1", "3f2a9c1b7e4d"}, "", action.CommandKill{Signal: "SIGKILL"}).Return(nil)
+	err := kill(c)
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_targetsFileEmpty() {
+	gTargets = staticTargets{}
+	defer func() { gTargets = nil }()
+	gInterval = 1 * time.Millisecond
+	set := flag.NewFlagSet("kill", 0)
+	set.String("signal", "SIGKILL", "doc")
+	c := cli.NewContext(nil, set, nil)
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	err := kill(c)
+	assert.NoError(s.T(), err)
+	// no targets: chaos command is not run on ALL containers
+	chaosMock.AssertNotCalled(s.T(), "KillContainers", nil, []string{}, "", action.CommandKill{Signal: "SIGKILL"})
+}
+
+func (s *mainTestSuite) Test_targetsFileWithNames() {
+	gTargets = staticTargets{"api_1"}
+	defer func() { gTargets = nil }()
+	set := flag.NewFlagSet("kill", 0)
+	set.String("signal", "SIGKILL", "doc")
+	set.Parse([]string{"re2:^api"})
+	c := cli.NewContext(nil, set, nil)
+	chaos = &ChaosMock{}
+	err := kill(c)
+	assert.EqualError(s.T(), err, "Container names or 're2:' pattern can not be used with --targets-file")
+}
+
+var terminalCheck = isTerminal
+
+func interactiveTest(tty bool, answer string) *bytes.Buffer {
+	gInteractive = true
+	isTerminal = func() bool { return tty }
+	gPromptIn = strings.NewReader(answer)
+	out := &bytes.Buffer{}
+	gPromptOut = out
+	return out
+}
+
+func (s *mainTestSuite) resetInteractive() {
+	gInteractive = false
+	isTerminal = terminalCheck
+	gPromptIn, gPromptOut = os.Stdin, os.Stderr
+}
+
+func (s *mainTestSuite) Test_interactiveConfirmed() {
+	defer s.resetInteractive()
+	out := interactiveTest(true, "y\n")
+	gInterval = 1 * time.Millisecond
+	clientMock := &container.MockClient{}
+	client = clientMock
+	defer func() { client = nil }()
+	api := *container.NewContainer(&dockerclient.ContainerInfo{Id: "0123456789abcdef", Name: "/api_1"}, nil)
+	clientMock.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{api}, nil)
+	set := flag.NewFlagSet("stop", 0)
+	set.Int("time", 5, "doc")
+	set.Parse([]string{"api_1"})
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("StopContainers", clientMock, []string{"api_1"}, "", action.CommandStop{WaitTime: 5}).Return(nil)
+	err := stop(cli.NewContext(nil, set, nil))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "'stop' command matches 1 containers:\n  api_1 (0123456789ab) \nContinue? [y/N]: ", out.String())
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_interactiveRejected() {
+	defer s.resetInteractive()
+	interactiveTest(true, "\n")
+	clientMock := &container.MockClient{}
+	client = clientMock
+	defer func() { client = nil }()
+	clientMock.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{}, nil)
+	set := flag.NewFlagSet("kill", 0)
+	set.String("signal", "SIGKILL", "doc")
+	set.Parse([]string{"re2:^api"})
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	err := kill(cli.NewContext(nil, set, nil))
+	assert.EqualError(s.T(), err, "'kill' command is not confirmed")
+	chaosMock.AssertNotCalled(s.T(), "KillContainers", clientMock, []string{}, "^api", action.CommandKill{Signal: "SIGKILL"})
+}
+
+func (s *mainTestSuite) Test_interactiveNoTTY() {
+	defer s.resetInteractive()
+	out := interactiveTest(false, "")
+	gInterval = 1 * time.Millisecond
+	set := flag.NewFlagSet("kill", 0)
+	set.String("signal", "SIGKILL", "doc")
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("KillContainers", nil, []string{}, "", action.CommandKill{Signal: "SIGKILL"}).Return(nil)
+	err := kill(cli.NewContext(nil, set, nil))
+	assert.NoError(s.T(), err)
+	assert.Empty(s.T(), out.String())
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_reportMatrix() {
+	var out bytes.Buffer
+	gMatrixOut = &out
+	defer func() { gMatrixOut = os.Stdout }()
+	reportMatrix("explore", []scenario.CellResult{{Cell: 1, Label: "target=api", Duration: time.Minute, Error: "ERROR"}})
+	assert.Equal(s.T(), "Scenario 'explore' matrix:\nCELL  VALUES      DURATION  RESULT\n1     target=api  1m0s      failure: ERROR\n", out.String())
+}
+
+func (s *mainTestSuite) Test_reportAssertions() {
+	var out bytes.Buffer
+	gMatrixOut = &out
+	defer func() { gMatrixOut = os.Stdout }()
+	reportAssertions("restart", []scenario.AssertionResult{{Step: 2, Assertion: "container api running within 1m", Duration: time.Second}})
+	assert.Equal(s.T(), "Scenario 'restart' assertions:\nSTEP  ASSERTION                        DURATION  RESULT\n2     container api running within 1m  1s        PASS\n", out.String())
+}
+
+func (s *mainTestSuite) Test_confirmStep() {
+	defer s.resetInteractive()
+	step := scenario.Step{Name: "kill primary", Action: "kill"}
+	out := interactiveTest(true, "yes\n")
+	assert.NoError(s.T(), confirmStep("game-day", step))
+	assert.Equal(s.T(), "Scenario 'game-day' manual step 'kill primary' (kill). Continue? [y/N]: ", out.String())
+	interactiveTest(true, "n\n")
+	assert.EqualError(s.T(), confirmStep("game-day", step), "Manual step 'kill primary' is not confirmed")
+}
+
 func (s *mainTestSuite) Test_beforeCommand_NoInterval() {
 	// prepare
 	set := flag.NewFlagSet("test", 0)
@@ -237,153 +539,1488 @@ func (s *mainTestSuite) Test_killError() {
 	chaosMock.AssertExpectations(s.T())
 }
 
-func (s *mainTestSuite) Test_pauseSucess() {
+func multiTestApp() *cli.App {
+	app := cli.NewApp()
+	app.Commands = []cli.Command{
+		{
+			Name:   "kill",
+			Flags:  []cli.Flag{cli.StringFlag{Name: "signal, s", Value: DefaultSignal}},
+			Action: kill,
+		},
+		{
+			Name:   "pause",
+			Flags:  []cli.Flag{cli.StringFlag{Name: "duration, d"}},
+			Action: pause,
+		},
+		{
+			Name:   "scenario",
+			Action: scenarioRun,
+		},
+	}
+	return app
+}
+
+func (s *mainTestSuite) Test_multiSuccess() {
 	// prepare
-	set := flag.NewFlagSet("pause", 0)
-	set.String("duration", "10s", "doc")
-	c := cli.NewContext(nil, set, nil)
-	// set interval to 1ms
-	gInterval = 1 * time.Millisecond
+	set := flag.NewFlagSet("multi", 0)
+	set.String("spec", "kill --signal SIGTERM re2:^api; pause --duration 10s re2:^db;", "doc")
+	app := multiTestApp()
+	c := cli.NewContext(app, set, nil)
 	// setup mock
 	chaosMock := &ChaosMock{}
 	chaos = chaosMock
-	cmd := action.CommandPause{Duration: time.Duration(10 * time.Second)}
-	chaosMock.On("PauseContainers", nil, []string{}, "", cmd).Return(nil)
+	chaosMock.On("KillContainers", nil, []string{}, "^api", action.CommandKill{Signal: "SIGTERM"}).Return(nil)
+	chaosMock.On("PauseContainers", nil, []string{}, "^db", action.CommandPause{Duration: 10 * time.Second}).Return(nil)
 	// invoke command
-	err := pause(c)
+	err := multi(c)
 	// asserts
-	// (!)WAIT till called action is completed (Sleep > Timer), it's executed in separate go routine
-	time.Sleep(2 * time.Millisecond)
 	assert.NoError(s.T(), err)
+	assert.Nil(s.T(), collector(c))
 	chaosMock.AssertExpectations(s.T())
 }
 
-func (s *mainTestSuite) Test_pauseMissingDuraation() {
+func (s *mainTestSuite) Test_multiUnsupportedCommand() {
 	// prepare
-	set := flag.NewFlagSet("pause", 0)
-	c := cli.NewContext(nil, set, nil)
-	// set interval to 1ms
-	gInterval = 1 * time.Millisecond
+	set := flag.NewFlagSet("multi", 0)
+	set.String("spec", "kill re2:^api; scenario run test.yml", "doc")
+	c := cli.NewContext(multiTestApp(), set, nil)
 	// invoke command
-	err := pause(c)
+	err := multi(c)
 	// asserts
-	assert.EqualError(s.T(), err, "Undefined duration interval")
+	assert.EqualError(s.T(), err, "Unsupported chaos command in spec: 'scenario'")
+	assert.Nil(s.T(), collector(c))
 }
 
-func (s *mainTestSuite) Test_pauseBadDuraation() {
+func (s *mainTestSuite) Test_multiNoSpec() {
 	// prepare
-	set := flag.NewFlagSet("pause", 0)
-	set.String("duration", "BAD", "doc")
-	c := cli.NewContext(nil, set, nil)
-	// set interval to 1ms
-	gInterval = 1 * time.Millisecond
+	set := flag.NewFlagSet("multi", 0)
+	set.String("spec", " ; ", "doc")
+	c := cli.NewContext(multiTestApp(), set, nil)
 	// invoke command
-	err := pause(c)
+	err := multi(c)
 	// asserts
-	assert.EqualError(s.T(), err, "time: invalid duration BAD")
+	assert.EqualError(s.T(), err, "Undefined chaos commands spec")
 }
 
-func (s *mainTestSuite) Test_stopSucess() {
+func (s *mainTestSuite) Test_runProfile() {
 	// prepare
-	set := flag.NewFlagSet("stop", 0)
-	set.Int("time", 10, "doc")
-	c := cli.NewContext(nil, set, nil)
-	// set interval to 1ms
-	gInterval = 1 * time.Millisecond
+	file, err := ioutil.TempFile("", "pumba")
+	assert.NoError(s.T(), err)
+	defer os.Remove(file.Name())
+	file.WriteString(`
+profiles:
+  nightly-soak:
+    interval: 1ms
+    commands:
+      - kill --signal SIGTERM re2:^api
+      - pause --duration 10s re2:^db
+`)
+	file.Close()
+	set := flag.NewFlagSet("pumba", 0)
+	set.String("config", file.Name(), "doc")
+	set.String("profile", "nightly-soak", "doc")
+	set.String("interval", "", "doc")
+	c := cli.NewContext(multiTestApp(), set, nil)
 	// setup mock
-	cmd := action.CommandStop{WaitTime: 10}
 	chaosMock := &ChaosMock{}
 	chaos = chaosMock
-	chaosMock.On("StopContainers", nil, []string{}, "", cmd).Return(nil)
+	chaosMock.On("KillContainers", nil, []string{}, "^api", action.CommandKill{Signal: "SIGTERM"}).Return(nil)
+	chaosMock.On("PauseContainers", nil, []string{}, "^db", action.CommandPause{Duration: 10 * time.Second}).Return(nil)
 	// invoke command
-	err := stop(c)
+	err = runProfile(c)
 	// asserts
-	// (!)WAIT till called action is completed (Sleep > Timer), it's executed in separate go routine
-	time.Sleep(2 * time.Millisecond)
 	assert.NoError(s.T(), err)
+	// profile settings are not written into command line options
+	assert.Equal(s.T(), "", c.GlobalString("interval"))
 	chaosMock.AssertExpectations(s.T())
 }
 
-func (s *mainTestSuite) Test_stopError() {
-	// prepare
-	set := flag.NewFlagSet("stop", 0)
-	set.Int("time", 10, "doc")
+func (s *mainTestSuite) Test_loggingConfig() {
+	file, err := ioutil.TempFile("", "pumba")
+	assert.NoError(s.T(), err)
+	defer os.Remove(file.Name())
+	file.WriteString("logging:\n  levels:\n    container: debug\n")
+	file.Close()
+	set := flag.NewFlagSet("pumba", 0)
+	set.String("config", file.Name(), "doc")
+	set.Bool("log-caller", true, "doc")
 	c := cli.NewContext(nil, set, nil)
-	// set interval to 1ms
-	gInterval = 1 * time.Millisecond
-	// setup mock
-	cmd := action.CommandStop{WaitTime: 10}
-	chaosMock := &ChaosMock{}
-	chaos = chaosMock
-	chaosMock.On("StopContainers", nil, []string{}, "", cmd).Return(errors.New("ERROR"))
-	// invoke command
-	err := stop(c)
-	// asserts
-	// (!)WAIT till called action is completed (Sleep > Timer), it's executed in separate go routine
-	time.Sleep(2 * time.Millisecond)
+	cfg, err := loggingConfig(c)
 	assert.NoError(s.T(), err)
-	chaosMock.AssertExpectations(s.T())
+	assert.True(s.T(), cfg.Caller)
+	assert.Equal(s.T(), map[string]string{"container": "debug"}, cfg.Levels)
+	// missing default configuration file
+	set = flag.NewFlagSet("pumba", 0)
+	set.String("config", file.Name()+".missing", "doc")
+	set.Bool("log-caller", false, "doc")
+	cfg, err = loggingConfig(cli.NewContext(nil, set, nil))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), logging.Config{}, cfg)
 }
 
-func (s *mainTestSuite) Test_removeSucess() {
-	// prepare
-	set := flag.NewFlagSet("stop", 0)
-	set.Bool("force", true, "doc")
-	set.Bool("links", true, "doc")
-	set.Bool("volumes", true, "doc")
-	c := cli.NewContext(nil, set, nil)
-	// set interval to 1ms
-	gInterval = 1 * time.Millisecond
-	// setup mock
-	cmd := action.CommandRemove{Force: true, Links: true, Volumes: true}
-	chaosMock := &ChaosMock{}
-	chaos = chaosMock
-	chaosMock.On("RemoveContainers", nil, []string{}, "", cmd).Return(nil)
-	// invoke command
-	err := remove(c)
-	// asserts
-	// (!)WAIT till called action is completed (Sleep > Timer), it's executed in separate go routine
-	time.Sleep(2 * time.Millisecond)
+func (s *mainTestSuite) Test_setupState_TakeoverWithoutStateFile() {
+	set := flag.NewFlagSet("pumba", 0)
+	set.String("state-file", "", "doc")
+	set.Bool("takeover", true, "doc")
+	err := setupState(cli.NewContext(nil, set, nil))
+	assert.EqualError(s.T(), err, "Takeover requires --state-file of running Pumba instance")
+}
+
+func (s *mainTestSuite) Test_takeover_Unadoptable() {
+	file, _ := ioutil.TempFile("", "pumba.state")
+	defer os.Remove(file.Name())
+	file.WriteString(`{"pid": 999999, "disruptions": [{"id": 1, "action": "pause", "container_name": "/c1"}, {"id": 2, "action": "cpu", "container_name": "/c2"}]}`)
+	file.Close()
+	_, err := takeover(file.Name(), time.Second)
+	assert.EqualError(s.T(), err, "Pumba instance (PID 999999) can not hand over active disruptions: cpu of /c2")
+}
+
+func (s *mainTestSuite) Test_handoverRefusal() {
+	var err error
+	gState, err = state.NewFile("")
 	assert.NoError(s.T(), err)
-	chaosMock.AssertExpectations(s.T())
+	defer func() { gState = nil }()
+	assert.Equal(s.T(), "", handoverRefusal())
+	id := gState.Add(state.Disruption{Action: "sidecar", ContainerName: "/c1"})
+	assert.Equal(s.T(), "active disruptions can not be restored by new instance: sidecar of /c1", handoverRefusal())
+	gState.Remove(id)
+	gState.Add(state.Disruption{Action: "netem", ContainerName: "/c1"})
+	assert.Equal(s.T(), "", handoverRefusal())
 }
 
-func (s *mainTestSuite) Test_netemDelaySucess() {
-	// prepare test data
-	// netem flags
-	netemSet := flag.NewFlagSet("netem", 0)
-	netemSet.String("duration", "10ms", "doc")
-	netemSet.String("interface", "test0", "doc")
-	netemCtx := cli.NewContext(nil, netemSet, nil)
-	// delay flags
-	delaySet := flag.NewFlagSet("delay", 0)
-	delaySet.Int("amount", 200, "doc")
-	delaySet.Int("variation", 20, "doc")
-	delaySet.Int("correlation", 10, "doc")
-	delaySet.Parse([]string{"c1", "c2", "c3"})
-	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
-	// set interval to 1ms
-	gInterval = 1 * time.Millisecond
-	// setup mock
-	cmd := action.CommandNetemDelay{
-		NetInterface: "test0",
-		Duration:     10 * time.Millisecond,
-		Amount:       200,
-		Variation:    20,
-		Correlation:  10,
-	}
-	chaosMock := &ChaosMock{}
+func (s *mainTestSuite) Test_setupLock() {
+	dir, _ := ioutil.TempDir("", "pumba-lock")
+	defer os.RemoveAll(dir)
+	set := flag.NewFlagSet("pumba", 0)
+	set.String("lock", "file://"+dir, "doc")
+	set.String("lock-namespace", "team-a", "doc")
+	set.String("lock-ttl", "30s", "doc")
+	client = container.NewMockSamalbaClient()
+	defer func() { client = nil }()
+	err := setupLock(cli.NewContext(nil, set, nil))
+	assert.NoError(s.T(), err)
+	assert.IsType(s.T(), lock.NewClient(nil, nil, "", "", 0, nil), client)
+}
+
+func (s *mainTestSuite) Test_setupLockErrors() {
+	for _, args := range [][]string{
+		{"redis://host", "pumba", "0s", "Lock TTL must be positive"},
+		{"redis://host", "", "1m", "Undefined lock namespace"},
+		{"zk://host", "pumba", "1m", "Unsupported lock URL 'zk://host': must be 'file://', 'redis://' or 'k8s://'"},
+	} {
+		set := flag.NewFlagSet("pumba", 0)
+		set.String("lock", args[0], "doc")
+		set.String("lock-namespace", args[1], "doc")
+		set.String("lock-ttl", args[2], "doc")
+		err := setupLock(cli.NewContext(nil, set, nil))
+		assert.EqualError(s.T(), err, args[3])
+	}
+}
+
+func (s *mainTestSuite) Test_setupAdmission() {
+	dir, _ := ioutil.TempDir("", "pumba-lock")
+	defer os.RemoveAll(dir)
+	set := flag.NewFlagSet("pumba", 0)
+	set.Int("max-active", 3, "doc")
+	set.String("lock", "file://"+dir, "doc")
+	set.String("lock-namespace", "team-a", "doc")
+	set.String("lock-ttl", "30s", "doc")
+	client = container.NewMockSamalbaClient()
+	defer func() { client = nil }()
+	err := setupAdmission(cli.NewContext(nil, set, nil), nil)
+	assert.NoError(s.T(), err)
+	assert.IsType(s.T(), lock.NewAdmissionClient(nil, nil), client)
+}
+
+func (s *mainTestSuite) Test_setupAdmissionErrors() {
+	for _, args := range [][]string{
+		{"-1", "pumba", "1m", "Invalid max active disruptions: must be 0 (no limit) or more"},
+		{"2", "pumba", "0s", "Lock TTL must be positive"},
+		{"2", "", "1m", "Undefined lock namespace"},
+	} {
+		set := flag.NewFlagSet("pumba", 0)
+		set.String("max-active", args[0], "doc")
+		set.String("lock-namespace", args[1], "doc")
+		set.String("lock-ttl", args[2], "doc")
+		err := setupAdmission(cli.NewContext(nil, set, nil), nil)
+		assert.EqualError(s.T(), err, args[3])
+	}
+}
+
+func (s *mainTestSuite) Test_setupFootprint() {
+	set := flag.NewFlagSet("pumba", 0)
+	set.String("min-memory", "512m", "doc")
+	set.String("max-memory", "", "doc")
+	set.String("min-cpus", "", "doc")
+	set.String("max-cpus", "1.5", "doc")
+	defer func() { action.MinMemory, action.MaxCPUs = 0, 0 }()
+	err := setupFootprint(cli.NewContext(nil, set, nil))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(512<<20), action.MinMemory)
+	assert.Equal(s.T(), int64(0), action.MaxMemory)
+	assert.Equal(s.T(), 0.0, action.MinCPUs)
+	assert.Equal(s.T(), 1.5, action.MaxCPUs)
+}
+
+func (s *mainTestSuite) Test_setupFootprintErrors() {
+	defer func() { action.MinMemory, action.MaxMemory, action.MinCPUs, action.MaxCPUs = 0, 0, 0, 0 }()
+	for _, args := range [][]string{
+		{"512x", "", "", "", "Invalid memory size '512x': must be number with optional unit suffix, e.g. '512m' or '1g'"},
+		{"", "", "one", "", "Invalid number of CPUs 'one': must be positive number, e.g. '0.5' or '2'"},
+		{"1g", "512m", "", "", "Option --min-memory must not be greater than --max-memory"},
+		{"", "", "2", "1", "Option --min-cpus must not be greater than --max-cpus"},
+	} {
+		set := flag.NewFlagSet("pumba", 0)
+		set.String("min-memory", args[0], "doc")
+		set.String("max-memory", args[1], "doc")
+		set.String("min-cpus", args[2], "doc")
+		set.String("max-cpus", args[3], "doc")
+		err := setupFootprint(cli.NewContext(nil, set, nil))
+		assert.EqualError(s.T(), err, args[4])
+	}
+}
+
+func (s *mainTestSuite) Test_createUI() {
+	dir, _ := ioutil.TempDir("", "pumba-scenarios")
+	defer os.RemoveAll(dir)
+	for _, args := range [][]string{
+		{"false", "", "", ""},
+		{"false", "", dir, "Option --scenario-dir requires --ui"},
+		{"true", "", "", "Option --ui requires --api-addr"},
+		{"true", "localhost:8585", dir + "/missing", "Invalid scenario directory '" + dir + "/missing'"},
+		{"true", "localhost:8585", dir, ""},
+	} {
+		set := flag.NewFlagSet("pumba", 0)
+		set.Bool("ui", args[0] == "true", "doc")
+		set.String("api-addr", args[1], "doc")
+		set.String("scenario-dir", args[2], "doc")
+		webUI, err := createUI(cli.NewContext(nil, set, nil))
+		if args[3] != "" {
+			assert.EqualError(s.T(), err, args[3])
+			continue
+		}
+		assert.NoError(s.T(), err)
+		assert.Equal(s.T(), args[0] == "true", webUI != nil)
+	}
+}
+
+func (s *mainTestSuite) Test_setupLockKubeconfigWithoutLock() {
+	set := flag.NewFlagSet("pumba", 0)
+	set.String("lock", "", "doc")
+	set.String("kubeconfig", "/root/.kube/config", "doc")
+	err := setupLock(cli.NewContext(nil, set, nil))
+	assert.EqualError(s.T(), err, "Option --kubeconfig requires --lock k8s://namespace")
+}
+
+func (s *mainTestSuite) Test_setupSnapshotTTLWithoutSnapshot() {
+	set := flag.NewFlagSet("pumba", 0)
+	set.Bool("snapshot-before", false, "doc")
+	set.String("snapshot-ttl", "24h", "doc")
+	set.Parse([]string{"--snapshot-ttl", "1h"})
+	err := setupSnapshot(cli.NewContext(nil, set, nil))
+	assert.EqualError(s.T(), err, "Option --snapshot-ttl requires --snapshot-before")
+}
+
+func (s *mainTestSuite) Test_setupSnapshotBadTTL() {
+	set := flag.NewFlagSet("pumba", 0)
+	set.Bool("snapshot-before", true, "doc")
+	set.String("snapshot-ttl", "0s", "doc")
+	err := setupSnapshot(cli.NewContext(nil, set, nil))
+	assert.EqualError(s.T(), err, "Snapshot TTL must be positive")
+}
+
+func (s *mainTestSuite) Test_setupBackoffBadBackoff() {
+	set := flag.NewFlagSet("pumba", 0)
+	set.Int("denylist-after", 3, "doc")
+	set.String("denylist-backoff", "2h", "doc")
+	set.String("denylist-max-backoff", "1h", "doc")
+	err := setupBackoff(cli.NewContext(nil, set, nil), nil)
+	assert.EqualError(s.T(), err, "Denylist back-off must be positive and not greater than --denylist-max-backoff")
+}
+
+func (s *mainTestSuite) Test_setupBackoffNegative() {
+	set := flag.NewFlagSet("pumba", 0)
+	set.Int("denylist-after", -1, "doc")
+	err := setupBackoff(cli.NewContext(nil, set, nil), nil)
+	assert.EqualError(s.T(), err, "Invalid number of failures before denylisting: must be 0 (disabled) or more")
+}
+
+func (s *mainTestSuite) Test_setupExecBadUser() {
+	set := flag.NewFlagSet("pumba", 0)
+	set.String("exec-user", "root;id", "doc")
+	set.String("exec-workdir", "", "doc")
+	err := setupExec(cli.NewContext(nil, set, nil))
+	assert.EqualError(s.T(), err, "Invalid exec user 'root;id': must be 'user[:group]' (name or ID)")
+}
+
+func (s *mainTestSuite) Test_setupExecBadWorkdir() {
+	set := flag.NewFlagSet("pumba", 0)
+	set.String("exec-user", "root", "doc")
+	set.String("exec-workdir", "tmp", "doc")
+	err := setupExec(cli.NewContext(nil, set, nil))
+	assert.EqualError(s.T(), err, "Invalid working directory 'tmp': must be an absolute path")
+}
+
+func (s *mainTestSuite) Test_setupNamePrefixBad() {
+	gNamePrefix = "^svc"
+	defer func() { gNamePrefix = "" }()
+	err := setupNamePrefix()
+	assert.EqualError(s.T(), err, "Invalid container name prefix '^svc': must start with a letter or digit, followed by letters, digits, '_', '.' or '-'")
+}
+
+func (s *mainTestSuite) Test_statusCommand() {
+	srv := status.NewServer(Release, nil)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	app := cli.NewApp()
+	out := &bytes.Buffer{}
+	app.Writer = out
+	set := flag.NewFlagSet("status", 0)
+	set.String("addr", strings.TrimPrefix(ts.URL, "http://"), "doc")
+	set.String("format", "json", "doc")
+	err := statusCommand(cli.NewContext(app, set, nil))
+	assert.NoError(s.T(), err)
+	assert.Contains(s.T(), out.String(), `"version": "`+Release+`"`)
+	assert.Contains(s.T(), out.String(), `"experiments": []`)
+}
+
+func (s *mainTestSuite) Test_statusCommandYAML() {
+	srv := status.NewServer(Release, nil)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	app := cli.NewApp()
+	out := &bytes.Buffer{}
+	app.Writer = out
+	set := flag.NewFlagSet("status", 0)
+	set.String("addr", strings.TrimPrefix(ts.URL, "http://"), "doc")
+	set.String("format", "yaml", "doc")
+	err := statusCommand(cli.NewContext(app, set, nil))
+	assert.NoError(s.T(), err)
+	assert.Contains(s.T(), out.String(), "version: "+Release+"\n")
+	assert.Contains(s.T(), out.String(), "experiments: []\n")
+}
+
+func (s *mainTestSuite) Test_recipeListJSON() {
+	app := cli.NewApp()
+	out := &bytes.Buffer{}
+	app.Writer = out
+	set := flag.NewFlagSet("list", 0)
+	set.String("format", "json", "doc")
+	err := recipeList(cli.NewContext(app, set, nil))
+	assert.NoError(s.T(), err)
+	var recipes []scenario.Scenario
+	assert.NoError(s.T(), json.Unmarshal(out.Bytes(), &recipes))
+	assert.Equal(s.T(), len(scenario.Recipes()), len(recipes))
+	assert.Empty(s.T(), recipes[0].Steps)
+	assert.NotEmpty(s.T(), recipes[0].Params)
+}
+
+func (s *mainTestSuite) Test_statusCommandBadFormat() {
+	set := flag.NewFlagSet("status", 0)
+	set.String("addr", status.DefaultAddr, "doc")
+	set.String("format", "xml", "doc")
+	err := statusCommand(cli.NewContext(nil, set, nil))
+	assert.EqualError(s.T(), err, "Unsupported output format 'xml': must be 'table', 'json' or 'yaml'")
+}
+
+func (s *mainTestSuite) Test_parseHistoryTime() {
+	now := time.Date(2016, 8, 2, 10, 0, 0, 0, time.UTC)
+	t, err := parseHistoryTime("24h", now)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC), t)
+	t, err = parseHistoryTime("2016-08-01T12:00:00Z", now)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), time.Date(2016, 8, 1, 12, 0, 0, 0, time.UTC), t)
+	t, err = parseHistoryTime("", now)
+	assert.NoError(s.T(), err)
+	assert.True(s.T(), t.IsZero())
+	_, err = parseHistoryTime("yesterday", now)
+	assert.EqualError(s.T(), err, "Invalid time 'yesterday': expected RFC3339 time or duration, e.g. '24h'")
+}
+
+func (s *mainTestSuite) Test_historyCommandBadFormat() {
+	set := flag.NewFlagSet("history", 0)
+	set.String("format", "csv", "doc")
+	err := historyCommand(cli.NewContext(nil, set, nil))
+	assert.EqualError(s.T(), err, "Unsupported output format 'csv': must be 'table', 'json' or 'yaml'")
+}
+
+func (s *mainTestSuite) Test_reportExportBadOptions() {
+	set := flag.NewFlagSet("export", 0)
+	set.String("format", "pdf", "doc")
+	set.String("since", "", "doc")
+	err := reportExport(cli.NewContext(nil, set, nil))
+	assert.EqualError(s.T(), err, "Unsupported report format 'pdf': must be 'html' or 'json'")
+	set.Set("format", "html")
+	set.Set("since", "last week")
+	err = reportExport(cli.NewContext(nil, set, nil))
+	assert.EqualError(s.T(), err, "Invalid time 'last week': expected RFC3339 time or duration, e.g. '24h'")
+}
+
+func (s *mainTestSuite) Test_runProfileUnknown() {
+	// prepare
+	file, err := ioutil.TempFile("", "pumba")
+	assert.NoError(s.T(), err)
+	defer os.Remove(file.Name())
+	file.WriteString("profiles:\n  smoke:\n    commands: [\"kill re2:^api\"]\n")
+	file.Close()
+	set := flag.NewFlagSet("pumba", 0)
+	set.String("config", file.Name(), "doc")
+	set.String("profile", "nightly-soak", "doc")
+	c := cli.NewContext(multiTestApp(), set, nil)
+	// invoke command
+	err = runProfile(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Unknown profile: 'nightly-soak'; available profiles: smoke")
+}
+
+func (s *mainTestSuite) Test_runProfileNoInterval() {
+	// prepare
+	file, err := ioutil.TempFile("", "pumba")
+	assert.NoError(s.T(), err)
+	defer os.Remove(file.Name())
+	file.WriteString("profiles:\n  smoke:\n    commands: [\"kill re2:^api\"]\n")
+	file.Close()
+	set := flag.NewFlagSet("pumba", 0)
+	set.String("config", file.Name(), "doc")
+	set.String("profile", "smoke", "doc")
+	set.String("interval", "", "doc")
+	c := cli.NewContext(multiTestApp(), set, nil)
+	// invoke command
+	err = runProfile(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Undefined interval value.")
+}
+
+func (s *mainTestSuite) Test_parseContext() {
+	ctx, err := parseContext([]string{"build=1.2.3", " commit =abc=123", "env="})
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), map[string]string{"build": "1.2.3", "commit": "abc=123", "env": ""}, ctx)
+	_, err = parseContext([]string{"build"})
+	assert.EqualError(s.T(), err, "Invalid context 'build': expected key=value")
+	_, err = parseContext([]string{"=1.2.3"})
+	assert.EqualError(s.T(), err, "Invalid context '=1.2.3': expected key=value")
+}
+
+func (s *mainTestSuite) Test_contextHook() {
+	entry := log.WithField("build", "explicit")
+	err := contextHook{"build": "1.2.3", "commit": "abc123"}.Fire(entry)
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "explicit", entry.Data["build"])
+	assert.Equal(s.T(), "abc123", entry.Data["commit"])
+}
+
+func (s *mainTestSuite) Test_parseSlackHook() {
+	hook, err := parseSlackHook("https://hooks.slack.com/1, channel=#oncall,level=error,emoji=:fire:,user=chaos", "#pumba", ":boar:", "pumba_bot")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "https://hooks.slack.com/1", hook.HookURL)
+	assert.Equal(s.T(), "#oncall", hook.Channel)
+	assert.Equal(s.T(), ":fire:", hook.IconEmoji)
+	assert.Equal(s.T(), "chaos", hook.Username)
+	assert.Equal(s.T(), slackrus.LevelThreshold(log.ErrorLevel), hook.AcceptedLevels)
+	// defaults
+	hook, err = parseSlackHook("https://hooks.slack.com/2", "#pumba", ":boar:", "pumba_bot")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "#pumba", hook.Channel)
+	assert.Equal(s.T(), ":boar:", hook.IconEmoji)
+	assert.Equal(s.T(), "pumba_bot", hook.Username)
+	assert.Contains(s.T(), hook.AcceptedLevels, log.GetLevel())
+	// errors
+	_, err = parseSlackHook("channel=#oncall", "#pumba", ":boar:", "pumba_bot")
+	assert.EqualError(s.T(), err, "Invalid Slack hook 'channel=#oncall': expected url[,channel=#name][,level=error][,emoji=:icon:][,user=name]")
+	_, err = parseSlackHook("https://hooks.slack.com/1,channel", "#pumba", ":boar:", "pumba_bot")
+	assert.EqualError(s.T(), err, "Invalid Slack hook option 'channel': expected key=value")
+	_, err = parseSlackHook("https://hooks.slack.com/1,color=red", "#pumba", ":boar:", "pumba_bot")
+	assert.EqualError(s.T(), err, "Unknown Slack hook option 'color': must be 'channel', 'level', 'emoji' or 'user'")
+	_, err = parseSlackHook("https://hooks.slack.com/1,level=loud", "#pumba", ":boar:", "pumba_bot")
+	assert.EqualError(s.T(), err, "Invalid Slack log level 'loud'")
+}
+
+func (s *mainTestSuite) Test_slackHooks() {
+	globalSet := flag.NewFlagSet("test", 0)
+	globalSet.String("slackhook", "", "doc")
+	globalSet.String("slackchannel", "#pumba", "doc")
+	globalSet.String("slacklevel", "", "doc")
+	globalSet.String("slackemoji", ":boar:", "doc")
+	globalSet.String("slackuser", "pumba_bot", "doc")
+	slack := cli.StringSlice{}
+	globalSet.Var(&slack, "slack", "doc")
+	globalSet.Parse([]string{"--slackhook", "https://hooks.slack.com/1", "--slacklevel", "warning",
+		"--slack", "https://hooks.slack.com/2,channel=#oncall,level=error", "--slack", "https://hooks.slack.com/3,level=info"})
+	c := cli.NewContext(nil, flag.NewFlagSet("cmd", 0), cli.NewContext(nil, globalSet, nil))
+	hooks, err := slackHooks(c)
+	assert.NoError(s.T(), err)
+	if assert.Len(s.T(), hooks, 3) {
+		assert.Equal(s.T(), "#pumba", hooks[0].Channel)
+		assert.Equal(s.T(), slackrus.LevelThreshold(log.WarnLevel), hooks[0].AcceptedLevels)
+		assert.Equal(s.T(), "#oncall", hooks[1].Channel)
+		assert.Equal(s.T(), slackrus.LevelThreshold(log.ErrorLevel), hooks[1].AcceptedLevels)
+		assert.Equal(s.T(), "#pumba", hooks[2].Channel)
+		assert.Equal(s.T(), slackrus.LevelThreshold(log.InfoLevel), hooks[2].AcceptedLevels)
+	}
+}
+
+func (s *mainTestSuite) Test_parseMultiSpec() {
+	specs := parseMultiSpec("kill -s SIGTERM re2:^api ;netem --duration 1m delay c1 c2")
+	assert.Equal(s.T(), [][]string{
+		{"kill", "-s", "SIGTERM", "re2:^api"},
+		{"netem", "--duration", "1m", "delay", "c1", "c2"},
+	}, specs)
+}
+
+func (s *mainTestSuite) Test_pauseSucess() {
+	// prepare
+	set := flag.NewFlagSet("pause", 0)
+	set.String("duration", "10s", "doc")
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandPause{Duration: time.Duration(10 * time.Second)}
+	chaosMock.On("PauseContainers", nil, []string{}, "", cmd).Return(nil)
+	// invoke command
+	err := pause(c)
+	// asserts
+	// (!)WAIT till called action is completed (Sleep > Timer), it's executed in separate go routine
+	time.Sleep(2 * time.Millisecond)
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_pauseExecHooks() {
+	// prepare
+	set := flag.NewFlagSet("pause", 0)
+	set.String("duration", "10s", "doc")
+	set.String("exec-before", "sync", "doc")
+	set.String("exec-after", "nginx -s reload", "doc")
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandPause{Duration: 10 * time.Second, ExecHooks: action.ExecHooks{Before: "sync", After: "nginx -s reload"}}
+	chaosMock.On("PauseContainers", nil, []string{}, "", cmd).Return(nil)
+	// invoke command
+	err := pause(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_portsSucess() {
+	// prepare
+	set := flag.NewFlagSet("ports", 0)
+	set.String("duration", "10s", "doc")
+	set.Int("loss", 30, "doc")
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandPorts{Duration: time.Duration(10 * time.Second), Loss: 30}
+	chaosMock.On("PortsContainers", nil, []string{}, "", cmd).Return(nil)
+	// invoke command
+	err := ports(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_portsBadLoss() {
+	// prepare
+	set := flag.NewFlagSet("ports", 0)
+	set.String("duration", "10s", "doc")
+	set.Int("loss", 101, "doc")
+	c := cli.NewContext(nil, set, nil)
+	// invoke command
+	err := ports(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Invalid packet loss 101: must be between 1 and 100")
+}
+
+func (s *mainTestSuite) Test_cpuSuccess() {
+	// prepare
+	set := flag.NewFlagSet("cpu", 0)
+	set.String("duration", "10s", "doc")
+	set.Int("workers", 2, "doc")
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandCPU{Duration: 10 * time.Second, Workers: 2}
+	chaosMock.On("CPUContainers", nil, []string{}, "", cmd).Return(nil)
+	// invoke command
+	err := cpu(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_cpuBadWorkers() {
+	// prepare
+	set := flag.NewFlagSet("cpu", 0)
+	set.String("duration", "10s", "doc")
+	set.Int("workers", -1, "doc")
+	c := cli.NewContext(nil, set, nil)
+	// invoke command
+	err := cpu(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Invalid number of CPU workers: must be 0 (one per CPU) or more")
+}
+
+func (s *mainTestSuite) Test_cpuMissingDuration() {
+	// prepare
+	set := flag.NewFlagSet("cpu", 0)
+	c := cli.NewContext(nil, set, nil)
+	// invoke command
+	err := cpu(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Undefined duration interval")
+}
+
+func (s *mainTestSuite) Test_cpuInjectTools() {
+	// prepare
+	dir, _ := ioutil.TempDir("", "pumba-tools")
+	defer os.RemoveAll(dir)
+	set := flag.NewFlagSet("cpu", 0)
+	set.String("duration", "10s", "doc")
+	set.String("inject-tools", dir, "doc")
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandCPU{Duration: 10 * time.Second, InjectTools: dir}
+	chaosMock.On("CPUContainers", nil, []string{}, "", cmd).Return(nil)
+	// invoke command
+	err := cpu(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_cpuBadInjectTools() {
+	// prepare
+	set := flag.NewFlagSet("cpu", 0)
+	set.String("duration", "10s", "doc")
+	set.String("inject-tools", "main.go", "doc")
+	c := cli.NewContext(nil, set, nil)
+	// invoke command
+	err := cpu(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Invalid tools directory 'main.go': not a directory")
+}
+
+func (s *mainTestSuite) Test_oomSuccess() {
+	// prepare
+	set := flag.NewFlagSet("oom", 0)
+	set.String("timeout", "30s", "doc")
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandOOM{Timeout: 30 * time.Second}
+	chaosMock.On("OOMContainers", nil, []string{}, "", cmd).Return(nil)
+	// invoke command
+	err := oom(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_oomBadTimeout() {
+	// prepare
+	set := flag.NewFlagSet("oom", 0)
+	set.String("timeout", "0s", "doc")
+	c := cli.NewContext(nil, set, nil)
+	// invoke command
+	err := oom(c)
+	// asserts
+	assert.EqualError(s.T(), err, "OOM timeout must be positive")
+}
+
+func (s *mainTestSuite) Test_fdSuccess() {
+	// prepare
+	set := flag.NewFlagSet("fd", 0)
+	set.String("duration", "10s", "doc")
+	set.Int("free", 2, "doc")
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandFD{Duration: 10 * time.Second, Free: 2}
+	chaosMock.On("FDContainers", nil, []string{}, "", cmd).Return(nil)
+	// invoke command
+	err := fd(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_fdBadFree() {
+	// prepare
+	set := flag.NewFlagSet("fd", 0)
+	set.String("duration", "10s", "doc")
+	set.Int("free", -1, "doc")
+	c := cli.NewContext(nil, set, nil)
+	// invoke command
+	err := fd(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Invalid number of free file descriptors: must be 0 or more")
+}
+
+func (s *mainTestSuite) Test_poisonImageSuccess() {
+	// prepare
+	set := flag.NewFlagSet("poison-image", 0)
+	set.String("duration", "5m", "doc")
+	set.String("image", "busybox:latest", "doc")
+	set.Parse([]string{"re2:^api"})
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandPoisonImage{Duration: 5 * time.Minute, Image: "busybox:latest"}
+	chaosMock.On("PoisonImageContainers", nil, []string{}, "^api", cmd).Return(nil)
+	// invoke command
+	err := poisonImage(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_poisonImageNoImage() {
+	// prepare
+	set := flag.NewFlagSet("poison-image", 0)
+	set.String("duration", "5m", "doc")
+	set.String("image", "", "doc")
+	c := cli.NewContext(nil, set, nil)
+	// invoke command
+	err := poisonImage(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Undefined bogus image")
+}
+
+func (s *mainTestSuite) Test_healthcheckSuccess() {
+	// prepare
+	set := flag.NewFlagSet("health", 0)
+	set.String("duration", "5m", "doc")
+	set.String("probe-binary", "/usr/bin/curl", "doc")
+	set.Bool("fail", true, "doc")
+	set.Int("probe-port", 0, "doc")
+	set.String("inject-tools", "", "doc")
+	set.Parse([]string{"re2:^api"})
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandHealth{Duration: 5 * time.Minute, Probe: health.Probe{Binary: "/usr/bin/curl", Fail: true}}
+	chaosMock.On("HealthContainers", nil, []string{}, "^api", cmd).Return(nil)
+	// invoke command
+	err := healthcheck(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_healthcheckBadProbe() {
+	for _, test := range []struct {
+		binary string
+		fail   bool
+		port   int
+		err    string
+	}{
+		{"", false, 0, "Specify healthcheck probe: either --probe-binary or --probe-port"},
+		{"/usr/bin/curl", false, 8080, "Specify healthcheck probe: either --probe-binary or --probe-port"},
+		{"curl", false, 0, "Invalid binary path 'curl': must be an absolute path of letters, digits, '_', '.', '+' or '-'"},
+		{"", true, 8080, "Option --fail requires --probe-binary: rejected probe port always fails"},
+		{"", false, 70000, "Invalid probe port 70000: must be between 1 and 65535"},
+	} {
+		// prepare
+		set := flag.NewFlagSet("health", 0)
+		set.String("duration", "5m", "doc")
+		set.String("probe-binary", test.binary, "doc")
+		set.Bool("fail", test.fail, "doc")
+		set.Int("probe-port", test.port, "doc")
+		c := cli.NewContext(nil, set, nil)
+		// invoke command
+		err := healthcheck(c)
+		// asserts
+		assert.EqualError(s.T(), err, test.err)
+	}
+}
+
+func (s *mainTestSuite) Test_memorySuccess() {
+	// prepare
+	set := flag.NewFlagSet("memory", 0)
+	set.String("duration", "2m", "doc")
+	set.Int("percent", 60, "doc")
+	set.Parse([]string{"c1", "c2"})
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandMemory{Duration: 2 * time.Minute, Percent: 60}
+	chaosMock.On("MemoryContainers", nil, []string{"c1", "c2"}, "", cmd).Return(nil)
+	// invoke command
+	err := memory(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_memoryBadPercent() {
+	// prepare
+	set := flag.NewFlagSet("memory", 0)
+	set.String("duration", "2m", "doc")
+	set.Int("percent", 100, "doc")
+	c := cli.NewContext(nil, set, nil)
+	// invoke command
+	err := memory(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Invalid memory percent 100: must be between 1 and 99")
+}
+
+// envFlags returns flags of env command
+func envFlags(args ...string) *flag.FlagSet {
+	set := flag.NewFlagSet("env", 0)
+	set.String("duration", "1m", "doc")
+	set.Int("time", 10, "doc")
+	setVars, unsetVars := cli.StringSlice{}, cli.StringSlice{}
+	set.Var(&setVars, "set", "doc")
+	set.Var(&unsetVars, "unset", "doc")
+	set.Parse(args)
+	return set
+}
+
+func (s *mainTestSuite) Test_envSuccess() {
+	// prepare
+	c := cli.NewContext(nil, envFlags("--set", "DB_HOST=nowhere", "--unset", "DB_PASSWORD", "c1"), nil)
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandEnv{Set: []string{"DB_HOST=nowhere"}, Unset: []string{"DB_PASSWORD"}, WaitTime: 10, Duration: time.Minute}
+	chaosMock.On("EnvContainers", nil, []string{"c1"}, "", cmd).Return(nil)
+	// invoke command
+	err := env(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_envBadOptions() {
+	for args, msg := range map[string]string{
+		"c1":                 "Undefined environment corruption: specify --set NAME=value or --unset NAME",
+		"--set DB_HOST c1":   "Invalid environment variable 'DB_HOST': must be 'NAME=value'",
+		"--set DB-HOST=x c1": "Invalid environment variable name 'DB-HOST': must be letters, digits or '_', not starting with digit",
+		"--unset 2FA_KEY c1": "Invalid environment variable name '2FA_KEY': must be letters, digits or '_', not starting with digit",
+	} {
+		err := env(cli.NewContext(nil, envFlags(strings.Fields(args)...), nil))
+		assert.EqualError(s.T(), err, msg, args)
+	}
+}
+
+// secretsFlags returns flags of secrets command
+func secretsFlags(args ...string) *flag.FlagSet {
+	set := flag.NewFlagSet("secrets", 0)
+	set.String("duration", "1m", "doc")
+	set.Var(&cli.StringSlice{}, "secret", "doc")
+	set.Var(&cli.StringSlice{}, "config", "doc")
+	set.Parse(args)
+	return set
+}
+
+func (s *mainTestSuite) Test_secretsSuccess() {
+	// prepare
+	c := cli.NewContext(nil, secretsFlags("--secret", "db-password", "--config", "nginx-conf", "web"), nil)
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandSecrets{Secrets: []string{"db-password"}, Configs: []string{"nginx-conf"}, Duration: time.Minute}
+	chaosMock.On("SecretsContainers", nil, []string{"web"}, "", cmd).Return(nil)
+	// invoke command
+	err := secrets(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_secretsUndefined() {
+	// invoke command
+	err := secrets(cli.NewContext(nil, secretsFlags("web"), nil))
+	// asserts
+	assert.EqualError(s.T(), err, "Undefined secrets and configs: specify --secret or --config")
+}
+
+func (s *mainTestSuite) Test_pauseMissingDuraation() {
+	// prepare
+	set := flag.NewFlagSet("pause", 0)
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// invoke command
+	err := pause(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Undefined duration interval")
+}
+
+func (s *mainTestSuite) Test_pauseBadDuraation() {
+	// prepare
+	set := flag.NewFlagSet("pause", 0)
+	set.String("duration", "BAD", "doc")
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// invoke command
+	err := pause(c)
+	// asserts
+	assert.EqualError(s.T(), err, "time: invalid duration BAD")
+}
+
+func (s *mainTestSuite) Test_stopSucess() {
+	// prepare
+	set := flag.NewFlagSet("stop", 0)
+	set.Int("time", 10, "doc")
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	cmd := action.CommandStop{WaitTime: 10}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("StopContainers", nil, []string{}, "", cmd).Return(nil)
+	// invoke command
+	err := stop(c)
+	// asserts
+	// (!)WAIT till called action is completed (Sleep > Timer), it's executed in separate go routine
+	time.Sleep(2 * time.Millisecond)
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_stopError() {
+	// prepare
+	set := flag.NewFlagSet("stop", 0)
+	set.Int("time", 10, "doc")
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	cmd := action.CommandStop{WaitTime: 10}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("StopContainers", nil, []string{}, "", cmd).Return(errors.New("ERROR"))
+	// invoke command
+	err := stop(c)
+	// asserts
+	// (!)WAIT till called action is completed (Sleep > Timer), it's executed in separate go routine
+	time.Sleep(2 * time.Millisecond)
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_stopRestart() {
+	set := flag.NewFlagSet("stop", 0)
+	set.Int("time", 10, "doc")
+	set.String("duration", "5m", "doc")
+	set.Bool("restart", true, "doc")
+	c := cli.NewContext(nil, set, nil)
+	gInterval = 1 * time.Millisecond
+	cmd := action.CommandStop{WaitTime: 10, Restart: true, Duration: 5 * time.Minute}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("StopContainers", nil, []string{}, "", cmd).Return(nil)
+	err := stop(c)
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_stopRestartBadArgs() {
+	tests := []struct {
+		duration string
+		restart  bool
+		err      string
+	}{
+		{"", true, "Undefined duration interval: --restart requires --duration"},
+		{"5m", false, "Stop --duration requires --restart option"},
+		{"BAD", true, "time: invalid duration BAD"},
+	}
+	for _, tt := range tests {
+		set := flag.NewFlagSet("stop", 0)
+		set.Int("time", 10, "doc")
+		set.String("duration", tt.duration, "doc")
+		set.Bool("restart", tt.restart, "doc")
+		err := stop(cli.NewContext(nil, set, nil))
+		assert.Error(s.T(), err)
+		assert.Contains(s.T(), err.Error(), strings.Split(tt.err, " BAD")[0])
+	}
+}
+
+func (s *mainTestSuite) Test_removeSucess() {
+	// prepare
+	set := flag.NewFlagSet("stop", 0)
+	set.Bool("force", true, "doc")
+	set.Bool("links", true, "doc")
+	set.Bool("volumes", true, "doc")
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	cmd := action.CommandRemove{Force: true, Links: true, Volumes: true}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("RemoveContainers", nil, []string{}, "", cmd).Return(nil)
+	// invoke command
+	err := remove(c)
+	// asserts
+	// (!)WAIT till called action is completed (Sleep > Timer), it's executed in separate go routine
+	time.Sleep(2 * time.Millisecond)
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_netemDelaySucess() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "test0", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// delay flags
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 200, "doc")
+	delaySet.Int("variation", 20, "doc")
+	delaySet.Int("correlation", 10, "doc")
+	delaySet.Parse([]string{"c1", "c2", "c3"})
+	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	cmd := action.CommandNetemDelay{
+		CommandNetem: action.CommandNetem{
+			NetInterface: "test0",
+			Duration:     10 * time.Millisecond,
+		},
+		Amount:      200,
+		Variation:   20,
+		Correlation: 10,
+	}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("NetemDelayContainers", nil, []string{"c1", "c2", "c3"}, "", cmd).Return(nil)
+	// invoke command
+	err := netemDelay(delayCtx)
+	// asserts
+	// (!)WAIT till called action is completed (Sleep > Timer), it's executed in separate go routine
+	time.Sleep(2 * time.Millisecond)
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_netemDelayNetwork() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
+	netemSet.String("network", "macvlan0", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// delay flags
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 200, "doc")
+	delaySet.Parse([]string{"c1", "c2"})
+	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	cmd := action.CommandNetemDelay{
+		CommandNetem: action.CommandNetem{
+			NetInterface: "eth0",
+			Network:      "macvlan0",
+			Duration:     10 * time.Millisecond,
+		},
+		Amount: 200,
+	}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("NetemDelayContainers", nil, []string{"c1", "c2"}, "", cmd).Return(nil)
+	// invoke command
+	err := netemDelay(delayCtx)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_netemDelayTargetAlias() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
+	netemSet.String("target-alias", "db", "doc")
+	netemSet.String("protocol", "udp", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// delay flags
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 200, "doc")
+	delaySet.Parse([]string{"c1", "c2"})
+	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	cmd := action.CommandNetemDelay{
+		CommandNetem: action.CommandNetem{
+			NetInterface: "eth0",
+			TargetAlias:  "db",
+			Protocol:     "udp",
+			Duration:     10 * time.Millisecond,
+		},
+		Amount: 200,
+	}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("NetemDelayContainers", nil, []string{"c1", "c2"}, "", cmd).Return(nil)
+	// invoke command
+	err := netemDelay(delayCtx)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_netemDelayFwMark() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
+	netemSet.Int("port", 5432, "doc")
+	netemSet.Bool("fwmark", true, "doc")
+	netemSet.String("uid", "1000", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// delay flags
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 200, "doc")
+	delaySet.Parse([]string{"c1", "c2"})
+	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	cmd := action.CommandNetemDelay{
+		CommandNetem: action.CommandNetem{
+			NetInterface: "eth0",
+			Port:         5432,
+			FwMark:       true,
+			UID:          "1000",
+			Duration:     10 * time.Millisecond,
+		},
+		Amount: 200,
+	}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("NetemDelayContainers", nil, []string{"c1", "c2"}, "", cmd).Return(nil)
+	// invoke command
+	err := netemDelay(delayCtx)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_netemDelayUIDWithoutFwMark() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
+	netemSet.String("uid", "1000", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// delay flags
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 200, "doc")
+	delaySet.Parse([]string{"c1", "c2"})
+	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	// invoke command
+	err := netemDelay(delayCtx)
+	// asserts
+	assert.EqualError(s.T(), err, "UID filter requires --fwmark mode")
+}
+
+func (s *mainTestSuite) Test_netemDelayDstPercent() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
+	netemSet.Int("dst-percent", 50, "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// delay flags
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 200, "doc")
+	delaySet.Parse([]string{"c1"})
+	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	cmd := action.CommandNetemDelay{
+		CommandNetem: action.CommandNetem{
+			NetInterface: "eth0",
+			DstPercent:   50,
+			Duration:     10 * time.Millisecond,
+		},
+		Amount: 200,
+	}
+	chaosMock := &ChaosMock{}
 	chaos = chaosMock
-	chaosMock.On("NetemDelayContainers", nil, []string{"c1", "c2", "c3"}, "", cmd).Return(nil)
+	chaosMock.On("NetemDelayContainers", nil, []string{"c1"}, "", cmd).Return(nil)
 	// invoke command
 	err := netemDelay(delayCtx)
 	// asserts
-	// (!)WAIT till called action is completed (Sleep > Timer), it's executed in separate go routine
-	time.Sleep(2 * time.Millisecond)
 	assert.NoError(s.T(), err)
 	chaosMock.AssertExpectations(s.T())
 }
 
+func (s *mainTestSuite) Test_netemDelayBadDstPercent() {
+	tests := []struct {
+		percent int
+		target  string
+		err     string
+	}{
+		{101, "", "Invalid destination percent 101: must be between 0 and 100"},
+		{50, "10.0.0.1", "Destination percent filter is not supported with --target, --target-alias, --target-peer and --fwmark"},
+		{50, "db.service.local", "Destination percent filter is not supported with --target, --target-alias, --target-peer and --fwmark"},
+	}
+	for _, tt := range tests {
+		netemSet := flag.NewFlagSet("netem", 0)
+		netemSet.String("duration", "10ms", "doc")
+		netemSet.String("interface", "eth0", "doc")
+		netemSet.String("target", tt.target, "doc")
+		netemSet.Int("dst-percent", tt.percent, "doc")
+		netemCtx := cli.NewContext(nil, netemSet, nil)
+		delaySet := flag.NewFlagSet("delay", 0)
+		delaySet.Int("amount", 200, "doc")
+		delaySet.Parse([]string{"c1"})
+		err := netemDelay(cli.NewContext(nil, delaySet, netemCtx))
+		assert.EqualError(s.T(), err, tt.err)
+	}
+}
+
+func (s *mainTestSuite) Test_netemDelayJitterOnly() {
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 100, "doc")
+	delaySet.Int("variation", 200, "doc")
+	delaySet.Bool("jitter-only", true, "doc")
+	delaySet.String("distribution", "normal", "doc")
+	delaySet.Parse([]string{"c1"})
+	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	gInterval = 1 * time.Millisecond
+	cmd := action.CommandNetemDelay{
+		CommandNetem: action.CommandNetem{
+			NetInterface: "eth0",
+			Duration:     10 * time.Millisecond,
+		},
+		Variation:    200,
+		Distribution: "normal",
+	}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("NetemDelayContainers", nil, []string{"c1"}, "", cmd).Return(nil)
+	err := netemDelay(delayCtx)
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_netemDelayBadJitterOptions() {
+	tests := []struct {
+		jitterOnly   bool
+		variation    int
+		distribution string
+		reorder      int
+		err          string
+	}{
+		{true, 0, "", 0, "Jitter-only delay requires --variation"},
+		{false, 10, "gauss", 0, "Unsupported delay distribution 'gauss'. Must be 'uniform', 'normal', 'pareto' or 'paretonormal'"},
+		{false, 0, "normal", 0, "Delay distribution requires --variation"},
+		{false, 10, "", 101, "Invalid reorder percent 101: must be between 0 and 100"},
+		{true, 10, "", 25, "Packet reorder requires base delay (--amount)"},
+	}
+	for _, tt := range tests {
+		netemSet := flag.NewFlagSet("netem", 0)
+		netemSet.String("duration", "10ms", "doc")
+		netemSet.String("interface", "eth0", "doc")
+		netemCtx := cli.NewContext(nil, netemSet, nil)
+		delaySet := flag.NewFlagSet("delay", 0)
+		delaySet.Int("amount", 100, "doc")
+		delaySet.Int("variation", tt.variation, "doc")
+		delaySet.Bool("jitter-only", tt.jitterOnly, "doc")
+		delaySet.String("distribution", tt.distribution, "doc")
+		delaySet.Int("reorder", tt.reorder, "doc")
+		delaySet.Parse([]string{"c1"})
+		err := netemDelay(cli.NewContext(nil, delaySet, netemCtx))
+		assert.EqualError(s.T(), err, tt.err)
+	}
+}
+
+func (s *mainTestSuite) Test_netemDelayBadProtocol() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
+	netemSet.String("protocol", "sctp", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// delay flags
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 200, "doc")
+	delaySet.Parse([]string{"c1", "c2"})
+	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	// invoke command
+	err := netemDelay(delayCtx)
+	// asserts
+	assert.EqualError(s.T(), err, "Unsupported protocol 'sctp'. Must be 'tcp', 'udp' or 'icmp'")
+}
+
+func (s *mainTestSuite) Test_netemDelayTargetHosts() {
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
+	netemSet.String("target", "10.0.0.1,db.service.local", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 200, "doc")
+	delaySet.Parse([]string{"c1"})
+	cmd := action.CommandNetemDelay{
+		CommandNetem: action.CommandNetem{
+			NetInterface: "eth0",
+			Targets:      []*net.IPNet{{IP: net.ParseIP("10.0.0.1").To4(), Mask: net.CIDRMask(32, 32)}},
+			TargetHosts:  []string{"db.service.local"},
+			Duration:     10 * time.Millisecond,
+		},
+		Amount: 200,
+	}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("NetemDelayContainers", nil, []string{"c1"}, "", cmd).Return(nil)
+	// invoke command
+	err := netemDelay(cli.NewContext(nil, delaySet, netemCtx))
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_netemDelayExclude() {
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
+	netemSet.String("exclude", "127.0.0.11,10.96.0.0/12", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 200, "doc")
+	delaySet.Parse([]string{"c1"})
+	_, services, _ := net.ParseCIDR("10.96.0.0/12")
+	cmd := action.CommandNetemDelay{
+		CommandNetem: action.CommandNetem{
+			NetInterface: "eth0",
+			Exclude:      []*net.IPNet{{IP: net.ParseIP("127.0.0.11").To4(), Mask: net.CIDRMask(32, 32)}, services},
+			Duration:     10 * time.Millisecond,
+		},
+		Amount: 200,
+	}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("NetemDelayContainers", nil, []string{"c1"}, "", cmd).Return(nil)
+	// invoke command
+	err := netemDelay(cli.NewContext(nil, delaySet, netemCtx))
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+	// host names are not supported
+	netemSet.Set("exclude", "kubernetes.default")
+	err = netemDelay(cli.NewContext(nil, delaySet, netemCtx))
+	assert.EqualError(s.T(), err, "Invalid exclude list 'kubernetes.default': host name 'kubernetes.default' is not supported, use IP address or CIDR range")
+}
+
+func (s *mainTestSuite) Test_netemDelayBadTarget() {
+	for target, expected := range map[string]string{
+		"10.0.0":               "Invalid IP address '10.0.0'",
+		"10.0.0.1,10.0.0.0/33": "Invalid CIDR range '10.0.0.0/33'",
+		"db;reboot":            "Invalid host name 'db;reboot'",
+	} {
+		netemSet := flag.NewFlagSet("netem", 0)
+		netemSet.String("duration", "10ms", "doc")
+		netemSet.String("interface", "eth0", "doc")
+		netemSet.String("target", target, "doc")
+		netemCtx := cli.NewContext(nil, netemSet, nil)
+		delaySet := flag.NewFlagSet("delay", 0)
+		delaySet.Int("amount", 200, "doc")
+		delaySet.Parse([]string{"c1", "c2"})
+		err := netemDelay(cli.NewContext(nil, delaySet, netemCtx))
+		assert.EqualError(s.T(), err, expected)
+	}
+}
+
+func (s *mainTestSuite) Test_runChaosCommandBadPattern() {
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	err := runChaosCommand(nil, action.CommandStop{WaitTime: 10}, []string{}, "(api", chaos.StopContainers)
+	assert.EqualError(s.T(), err, "Invalid RE2 pattern '(api': error parsing regexp: missing closing ): `(api`")
+	chaosMock.AssertNotCalled(s.T(), "StopContainers", nil, []string{}, "(api", action.CommandStop{WaitTime: 10})
+}
+
+func (s *mainTestSuite) Test_netemDelayBadTargetAlias() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
+	netemSet.String("target-alias", "db; rm -rf /", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// delay flags
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 200, "doc")
+	delaySet.Parse([]string{"c1", "c2"})
+	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	// invoke command
+	err := netemDelay(delayCtx)
+	// asserts
+	assert.EqualError(s.T(), err, "Invalid host name 'db; rm -rf /'")
+}
+
+func (s *mainTestSuite) Test_netemDelayBadProbe() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
+	netemSet.String("probe", "-c1000 db", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// delay flags
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 200, "doc")
+	delaySet.Parse([]string{"c1", "c2"})
+	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	// invoke command
+	err := netemDelay(delayCtx)
+	// asserts
+	assert.EqualError(s.T(), err, "Invalid probe target '-c1000 db': must be a host name, IP address or HTTP(S) URL")
+}
+
 func (s *mainTestSuite) Test_netemDelayNoDuration() {
 	// prepare test data
 	// netem flags
@@ -418,91 +2055,903 @@ func (s *mainTestSuite) Test_netemDelayBadDuration() {
 	delaySet.Parse([]string{"c1", "c2", "c3"})
 	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
 	// invoke command
-	err := netemDelay(delayCtx)
+	err := netemDelay(delayCtx)
+	// asserts
+	assert.EqualError(s.T(), err, "time: invalid duration BAD")
+}
+
+func (s *mainTestSuite) Test_netemDelayBadNetInterface() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("interface", "hello test", "doc")
+	netemSet.String("duration", "10ms", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// delay flags
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 200, "doc")
+	delaySet.Int("variation", 20, "doc")
+	delaySet.Int("correlation", 10, "doc")
+	delaySet.Parse([]string{"c1", "c2", "c3"})
+	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	// invoke command
+	err := netemDelay(delayCtx)
+	// asserts
+	assert.EqualError(s.T(), err, "Invalid network interface name 'hello test': must be up to 15 letters, digits, '_', '.' or '-'")
+}
+
+func (s *mainTestSuite) Test_netemDelayInvalidVariation() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("interface", "test0", "doc")
+	netemSet.String("duration", "10ms", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// delay flags
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 200, "doc")
+	delaySet.Int("variation", -10, "doc")
+	delaySet.Int("correlation", 10, "doc")
+	delaySet.Parse([]string{"c1", "c2", "c3"})
+	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	// invoke command
+	err := netemDelay(delayCtx)
+	// asserts
+	assert.EqualError(s.T(), err, "Invalid delay variation")
+}
+
+func (s *mainTestSuite) Test_netemDelayInvalidAmount() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("interface", "test0", "doc")
+	netemSet.String("duration", "10ms", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// delay flags
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", -20, "doc")
+	delaySet.Int("variation", 20, "doc")
+	delaySet.Int("correlation", 101, "doc")
+	delaySet.Parse([]string{"c1", "c2", "c3"})
+	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	// invoke command
+	err := netemDelay(delayCtx)
+	// asserts
+	assert.EqualError(s.T(), err, "Invalid delay amount")
+}
+
+func (s *mainTestSuite) Test_netemDelayInvalidCorrelation() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("interface", "test0", "doc")
+	netemSet.String("duration", "10ms", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// delay flags
+	delaySet := flag.NewFlagSet("delay", 0)
+	delaySet.Int("amount", 200, "doc")
+	delaySet.Int("variation", 20, "doc")
+	delaySet.Int("correlation", 101, "doc")
+	delaySet.Parse([]string{"c1", "c2", "c3"})
+	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	// invoke command
+	err := netemDelay(delayCtx)
+	// asserts
+	assert.EqualError(s.T(), err, "Invalid delay correlation 101: must be between 0 and 100")
+}
+
+func (s *mainTestSuite) Test_netemCorruptSuccess() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
+	netemSet.String("protocol", "udp", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// corrupt flags
+	corruptSet := flag.NewFlagSet("corrupt", 0)
+	corruptSet.Int("percent", 5, "doc")
+	corruptSet.Int("correlation", 25, "doc")
+	corruptSet.Parse([]string{"c1", "c2"})
+	corruptCtx := cli.NewContext(nil, corruptSet, netemCtx)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	cmd := action.CommandNetemCorrupt{
+		CommandNetem: action.CommandNetem{
+			NetInterface: "eth0",
+			Protocol:     "udp",
+			Duration:     10 * time.Millisecond,
+		},
+		Percent:     5,
+		Correlation: 25,
+	}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("NetemCorruptContainers", nil, []string{"c1", "c2"}, "", cmd).Return(nil)
+	// invoke command
+	err := netemCorrupt(corruptCtx)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_netemCorruptInvalidPercent() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("interface", "eth0", "doc")
+	netemSet.String("duration", "10ms", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	// corrupt flags
+	corruptSet := flag.NewFlagSet("corrupt", 0)
+	corruptSet.Int("percent", 0, "doc")
+	corruptSet.Int("correlation", 0, "doc")
+	corruptSet.Parse([]string{"c1"})
+	corruptCtx := cli.NewContext(nil, corruptSet, netemCtx)
+	// invoke command
+	err := netemCorrupt(corruptCtx)
+	// asserts
+	assert.EqualError(s.T(), err, "Invalid corrupt percent 0: must be between 1 and 100")
+}
+
+// combineFlags returns flags of 'netem combine' sub-command, parsed from args
+func combineFlags(args ...string) *flag.FlagSet {
+	set := flag.NewFlagSet("combine", 0)
+	for _, name := range []string{"delay", "variation", "delay-correlation", "loss", "loss-correlation", "corrupt", "corrupt-correlation"} {
+		set.Int(name, 0, "doc")
+	}
+	set.String("distribution", "", "doc")
+	set.Parse(args)
+	return set
+}
+
+func (s *mainTestSuite) Test_netemCombineSuccess() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	combineCtx := cli.NewContext(nil, combineFlags("--delay", "100", "--variation", "20", "--distribution", "pareto", "--loss", "5", "--corrupt", "1", "c1"), netemCtx)
+	// setup mock
+	cmd := action.CommandNetemCombine{
+		CommandNetem: action.CommandNetem{
+			NetInterface: "eth0",
+			Duration:     10 * time.Millisecond,
+		},
+		Delay:        100,
+		Variation:    20,
+		Distribution: "pareto",
+		Loss:         5,
+		Corrupt:      1,
+	}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("NetemCombineContainers", nil, []string{"c1"}, "", cmd).Return(nil)
+	// invoke command
+	err := netemCombine(combineCtx)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_netemCombineInvalidOptions() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("interface", "eth0", "doc")
+	netemSet.String("duration", "10ms", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"c1"}, "Specify at least one impairment: --delay, --loss or --corrupt"},
+		{[]string{"--loss", "5", "--variation", "10", "c1"}, "Invalid delay variation"},
+		{[]string{"--delay", "100", "--corrupt", "101", "c1"}, "Invalid corrupt percent 101: must be between 0 and 100"},
+		{[]string{"--delay", "100", "--variation", "10", "--distribution", "gauss", "c1"}, "Unsupported delay distribution 'gauss'. Must be 'uniform', 'normal', 'pareto' or 'paretonormal'"},
+		{[]string{"--delay", "100", "--distribution", "normal", "c1"}, "Delay distribution requires --variation"},
+	} {
+		// invoke command
+		err := netemCombine(cli.NewContext(nil, combineFlags(test.args...), netemCtx))
+		// asserts
+		assert.EqualError(s.T(), err, test.err, strings.Join(test.args, " "))
+	}
+}
+
+func throttleFlags(args ...string) *flag.FlagSet {
+	set := flag.NewFlagSet("throttle", 0)
+	set.String("rate", "", "doc")
+	set.String("burst", "32kbit", "doc")
+	set.Int("latency", 400, "doc")
+	set.Parse(args)
+	return set
+}
+
+func (s *mainTestSuite) Test_netemThrottleSuccess() {
+	// prepare test data
+	// netem flags
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	throttleCtx := cli.NewContext(nil, throttleFlags("--rate", "1mbit", "--latency", "100", "re2:^api"), netemCtx)
+	// setup mock
+	cmd := action.CommandNetemThrottle{
+		CommandNetem: action.CommandNetem{
+			NetInterface: "eth0",
+			Duration:     10 * time.Millisecond,
+		},
+		Rate:    "1mbit",
+		Burst:   "32kbit",
+		Latency: 100,
+	}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("NetemThrottleContainers", nil, []string{}, "^api", cmd).Return(nil)
+	// invoke command
+	err := netemThrottle(throttleCtx)
 	// asserts
-	assert.EqualError(s.T(), err, "time: invalid duration BAD")
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
 }
 
-func (s *mainTestSuite) Test_netemDelayBadNetInterface() {
+func (s *mainTestSuite) Test_netemThrottleInvalidOptions() {
 	// prepare test data
 	// netem flags
 	netemSet := flag.NewFlagSet("netem", 0)
-	netemSet.String("interface", "hello test", "doc")
+	netemSet.String("interface", "eth0", "doc")
 	netemSet.String("duration", "10ms", "doc")
 	netemCtx := cli.NewContext(nil, netemSet, nil)
-	// delay flags
-	delaySet := flag.NewFlagSet("delay", 0)
-	delaySet.Int("amount", 200, "doc")
-	delaySet.Int("variation", 20, "doc")
-	delaySet.Int("correlation", 10, "doc")
-	delaySet.Parse([]string{"c1", "c2", "c3"})
-	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"c1"}, "Undefined throttle rate: specify --rate, e.g. '1mbit'"},
+		{[]string{"--rate", "fast", "c1"}, "Invalid throttle rate 'fast': must be number with unit, e.g. '1mbit', '500kbit' or '10mbps'"},
+		{[]string{"--rate", "1mbit", "--burst", "big", "c1"}, "Invalid throttle burst 'big': must be size with unit, e.g. '32kbit' or '10kb'"},
+		{[]string{"--rate", "1mbit", "--latency", "0", "c1"}, "Invalid throttle latency 0: must be between 1 and 60000"},
+	} {
+		// invoke command
+		err := netemThrottle(cli.NewContext(nil, throttleFlags(test.args...), netemCtx))
+		// asserts
+		assert.EqualError(s.T(), err, test.err, strings.Join(test.args, " "))
+	}
+}
+
+func (s *mainTestSuite) Test_netemStop() {
+	// prepare test data
+	// netem flags: duration is not required
+	netemSet := flag.NewFlagSet("netem", 0)
+	netemSet.String("interface", "eth1", "doc")
+	netemSet.String("network", "", "doc")
+	netemCtx := cli.NewContext(nil, netemSet, nil)
+	stopSet := flag.NewFlagSet("stop", 0)
+	stopSet.Parse([]string{"re2:^api"})
+	// setup mock
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("NetemStopContainers", nil, []string{}, "^api", action.CommandNetemStop{NetInterface: "eth1"}).Return(nil)
 	// invoke command
-	err := netemDelay(delayCtx)
+	err := netemStop(cli.NewContext(nil, stopSet, netemCtx))
 	// asserts
-	assert.EqualError(s.T(), err, "Bad network interface name. Must match '[a-zA-Z]+[0-9]{0,2}'")
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
 }
 
-func (s *mainTestSuite) Test_netemDelayInvalidVariation() {
+func (s *mainTestSuite) Test_netemStopInvalidInterface() {
 	// prepare test data
-	// netem flags
 	netemSet := flag.NewFlagSet("netem", 0)
-	netemSet.String("interface", "test0", "doc")
-	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0; reboot", "doc")
 	netemCtx := cli.NewContext(nil, netemSet, nil)
-	// delay flags
-	delaySet := flag.NewFlagSet("delay", 0)
-	delaySet.Int("amount", 200, "doc")
-	delaySet.Int("variation", -10, "doc")
-	delaySet.Int("correlation", 10, "doc")
-	delaySet.Parse([]string{"c1", "c2", "c3"})
-	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	stopSet := flag.NewFlagSet("stop", 0)
+	stopSet.Parse([]string{"c1"})
 	// invoke command
-	err := netemDelay(delayCtx)
+	err := netemStop(cli.NewContext(nil, stopSet, netemCtx))
 	// asserts
-	assert.EqualError(s.T(), err, "Invalid delay variation")
+	assert.EqualError(s.T(), err, "Invalid network interface name 'eth0; reboot': must be up to 15 letters, digits, '_', '.' or '-'")
 }
 
-func (s *mainTestSuite) Test_netemDelayInvalidAmount() {
+func (s *mainTestSuite) Test_netemLossStateSuccess() {
 	// prepare test data
 	// netem flags
 	netemSet := flag.NewFlagSet("netem", 0)
-	netemSet.String("interface", "test0", "doc")
 	netemSet.String("duration", "10ms", "doc")
+	netemSet.String("interface", "eth0", "doc")
 	netemCtx := cli.NewContext(nil, netemSet, nil)
-	// delay flags
-	delaySet := flag.NewFlagSet("delay", 0)
-	delaySet.Int("amount", -20, "doc")
-	delaySet.Int("variation", 20, "doc")
-	delaySet.Int("correlation", 101, "doc")
-	delaySet.Parse([]string{"c1", "c2", "c3"})
-	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	// loss flags
+	lossSet := flag.NewFlagSet("loss", 0)
+	lossSet.String("model", "random", "doc")
+	lossSet.Int("percent", 1, "doc")
+	lossSet.Int("correlation", 0, "doc")
+	for _, name := range []string{"p13", "p31", "p32", "p23", "p14", "pg", "pb", "one-h", "one-k"} {
+		lossSet.Int(name, 0, "doc")
+	}
+	lossSet.Parse([]string{"--model", "state", "--p13", "5", "--p31", "60", "c1"})
+	lossCtx := cli.NewContext(nil, lossSet, netemCtx)
+	// setup mock
+	cmd := action.CommandNetemLoss{
+		CommandNetem: action.CommandNetem{
+			NetInterface: "eth0",
+			Duration:     10 * time.Millisecond,
+		},
+		Model: "state",
+		P13:   5,
+		P31:   60,
+	}
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	chaosMock.On("NetemLossContainers", nil, []string{"c1"}, "", cmd).Return(nil)
 	// invoke command
-	err := netemDelay(delayCtx)
+	err := netemLoss(lossCtx)
 	// asserts
-	assert.EqualError(s.T(), err, "Invalid delay amount")
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
 }
 
-func (s *mainTestSuite) Test_netemDelayInvalidCorrelation() {
+func (s *mainTestSuite) Test_netemLossInvalidOptions() {
 	// prepare test data
 	// netem flags
 	netemSet := flag.NewFlagSet("netem", 0)
-	netemSet.String("interface", "test0", "doc")
+	netemSet.String("interface", "eth0", "doc")
 	netemSet.String("duration", "10ms", "doc")
 	netemCtx := cli.NewContext(nil, netemSet, nil)
-	// delay flags
-	delaySet := flag.NewFlagSet("delay", 0)
-	delaySet.Int("amount", 200, "doc")
-	delaySet.Int("variation", 20, "doc")
-	delaySet.Int("correlation", 101, "doc")
-	delaySet.Parse([]string{"c1", "c2", "c3"})
-	delayCtx := cli.NewContext(nil, delaySet, netemCtx)
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--model", "bernoulli", "c1"}, "Unsupported loss model 'bernoulli'. Must be 'random', 'state' or 'gemodel'"},
+		{[]string{"--pg", "5", "c1"}, "Option --pg requires --model gemodel"},
+		{[]string{"--model", "gemodel", "--percent", "5", "c1"}, "Option --percent requires --model random"},
+		{[]string{"--model", "gemodel", "c1"}, "Invalid loss pg 0: must be between 1 and 100"},
+		{[]string{"--model", "state", "--p13", "5", "--p14", "101", "c1"}, "Invalid loss p14 101: must be between 0 and 100"},
+	} {
+		// loss flags
+		lossSet := flag.NewFlagSet("loss", 0)
+		lossSet.String("model", "random", "doc")
+		lossSet.Int("percent", 1, "doc")
+		lossSet.Int("correlation", 0, "doc")
+		for _, name := range []string{"p13", "p31", "p32", "p23", "p14", "pg", "pb", "one-h", "one-k"} {
+			lossSet.Int(name, 0, "doc")
+		}
+		lossSet.Parse(test.args)
+		// invoke command
+		err := netemLoss(cli.NewContext(nil, lossSet, netemCtx))
+		// asserts
+		assert.EqualError(s.T(), err, test.err, strings.Join(test.args, " "))
+	}
+}
+
+func (s *mainTestSuite) Test_httpSucess() {
+	// prepare
+	set := flag.NewFlagSet("http", 0)
+	set.String("duration", "10s", "doc")
+	set.Int("port", 80, "doc")
+	set.Int("proxy-port", 15001, "doc")
+	set.Int("delay", 100, "doc")
+	set.Int("error-rate", 10, "doc")
+	set.Int("error-code", 503, "doc")
+	set.Int("truncate-rate", 0, "doc")
+	set.String("image", "gaiaadm/pumba", "doc")
+	c := cli.NewContext(nil, set, nil)
+	// set interval to 1ms
+	gInterval = 1 * time.Millisecond
+	// setup mock
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandHTTP{Port: 80, ProxyPort: 15001, Image: "gaiaadm/pumba", Duration: 10 * time.Second,
+		Delay: 100, ErrorRate: 10, ErrorCode: 503}
+	chaosMock.On("HTTPContainers", nil, []string{}, "", cmd).Return(nil)
 	// invoke command
-	err := netemDelay(delayCtx)
+	err := httpChaos(c)
+	// asserts
+	// (!)WAIT till called action is completed (Sleep > Timer), it's executed in separate go routine
+	time.Sleep(2 * time.Millisecond)
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_httpBadPort() {
+	// prepare
+	set := flag.NewFlagSet("http", 0)
+	set.String("duration", "10s", "doc")
+	set.Int("port", 0, "doc")
+	c := cli.NewContext(nil, set, nil)
+	// invoke command
+	err := httpChaos(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Invalid HTTP port 0: must be between 1 and 65535")
+}
+
+func (s *mainTestSuite) Test_httpBadRate() {
+	// prepare
+	set := flag.NewFlagSet("http", 0)
+	set.String("duration", "10s", "doc")
+	set.Int("port", 80, "doc")
+	set.Int("proxy-port", 15001, "doc")
+	set.Int("error-rate", 120, "doc")
+	c := cli.NewContext(nil, set, nil)
+	// invoke command
+	err := httpChaos(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Invalid HTTP error rate 120: must be between 0 and 100")
+}
+
+func (s *mainTestSuite) Test_recipeRun() {
+	// prepare
+	set := flag.NewFlagSet("run", 0)
+	set.Parse([]string{"rabbit-partition", "--nodes", "re2:^rabbit", "--duration=30s"})
+	c := cli.NewContext(nil, set, nil)
+	// setup mock
+	clientMock := &container.MockClient{}
+	client = clientMock
+	defer func() { client = nil }()
+	rabbit := *container.NewContainer(&dockerclient.ContainerInfo{Id: "0123456789abcdef", Name: "/rabbit1"}, nil)
+	clientMock.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{rabbit}, nil)
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandNetemLoss{CommandNetem: action.CommandNetem{NetInterface: "eth0", Duration: 30 * time.Second}, Model: "random", Percent: 100}
+	chaosMock.On("NetemLossContainers", clientMock, []string{"rabbit1"}, "", cmd).Return(nil)
+	// invoke command
+	err := recipeRun(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_recipeRunMissingParam() {
+	// prepare
+	set := flag.NewFlagSet("run", 0)
+	set.Parse([]string{"kafka-leader-kill"})
+	c := cli.NewContext(nil, set, nil)
+	// invoke command
+	err := recipeRun(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Missing required scenario parameter: 'brokers'")
+}
+
+func (s *mainTestSuite) Test_scenarioRunNoFile() {
+	// prepare
+	set := flag.NewFlagSet("run", 0)
+	c := cli.NewContext(nil, set, nil)
+	// invoke command
+	err := scenarioRun(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Undefined scenario file")
+}
+
+func (s *mainTestSuite) Test_createMarkerNone() {
+	// prepare
+	globalSet := flag.NewFlagSet("test", 0)
+	globalSet.String("mark", "", "doc")
+	c := cli.NewContext(nil, flag.NewFlagSet("test", 0), cli.NewContext(nil, globalSet, nil))
+	// invoke
+	m, err := createMarker(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	assert.Nil(s.T(), m)
+}
+
+func (s *mainTestSuite) Test_createMarkerBadMode() {
+	// prepare
+	globalSet := flag.NewFlagSet("test", 0)
+	globalSet.String("mark", "label", "doc")
+	globalSet.String("mark-cooldown", "10m", "doc")
+	c := cli.NewContext(nil, flag.NewFlagSet("test", 0), cli.NewContext(nil, globalSet, nil))
+	// invoke
+	_, err := createMarker(c)
+	// asserts
+	assert.EqualError(s.T(), err, "Unexpected mark mode: 'label'")
+}
+
+func (s *mainTestSuite) Test_createMarkerState() {
+	// prepare
+	globalSet := flag.NewFlagSet("test", 0)
+	globalSet.String("mark", "state", "doc")
+	globalSet.String("mark-cooldown", "1m", "doc")
+	c := cli.NewContext(nil, flag.NewFlagSet("test", 0), cli.NewContext(nil, globalSet, nil))
+	// invoke without state file
+	_, err := createMarker(c)
+	assert.EqualError(s.T(), err, "State mark requires --state-file")
+	gState, err = state.NewFile("")
+	assert.NoError(s.T(), err)
+	defer func() { gState = nil }()
+	m, err := createMarker(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	assert.IsType(s.T(), &marker.StateMarker{}, m)
+}
+
+func (s *mainTestSuite) Test_runChaosCommandOnce() {
+	// prepare
+	gOnce, gTolerateFailures = true, 0
+	defer func() { gOnce, gTolerateFailures = false, -1 }()
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandStop{WaitTime: 10}
+	chaosMock.On("StopContainers", nil, []string{"c1"}, "", cmd).Return(nil)
+	// invoke command
+	err := runChaosCommand(nil, cmd, []string{"c1"}, "", chaos.StopContainers)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_runChaosCommandCount() {
+	// prepare
+	gCount, gTolerateFailures = 3, 0
+	defer func() { gCount, gTolerateFailures = 0, -1 }()
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandStop{WaitTime: 10}
+	chaosMock.On("StopContainers", nil, []string{"c1"}, "", cmd).Return(nil).Times(3)
+	// invoke command
+	err := runChaosCommand(nil, cmd, []string{"c1"}, "", chaos.StopContainers)
+	// asserts
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_setupRuns() {
+	defer func() { gOnce, gCount, gDelayBetween, gWarmup, gTolerateFailures = false, 0, 0, 0, -1 }()
+	for _, args := range [][]string{
+		{"false", "-1", "", "", "Invalid count -1: should be positive"},
+		{"true", "2", "", "", "Options --count and --once are mutually exclusive"},
+		{"false", "0", "1s", "", "Option --delay-between requires --count"},
+		{"false", "2", "1x", "", "Invalid delay between runs '1x'"},
+		{"false", "2", "-1s", "", "Invalid delay between runs '-1s'"},
+		{"false", "2", "", "-2m", "Invalid warm-up delay '-2m'"},
+		{"false", "2", "", "soon", "Invalid warm-up delay 'soon'"},
+		{"false", "2", "500ms", "2m", ""},
+	} {
+		set := flag.NewFlagSet("pumba", 0)
+		set.Bool("once", args[0] == "true", "doc")
+		set.String("count", args[1], "doc")
+		set.String("delay-between", args[2], "doc")
+		set.String("warmup", args[3], "doc")
+		set.Int("tolerate-failures", 0, "doc")
+		err := setupRuns(cli.NewContext(nil, set, nil))
+		if args[4] == "" {
+			assert.NoError(s.T(), err)
+		} else {
+			assert.EqualError(s.T(), err, args[4])
+		}
+	}
+	assert.Equal(s.T(), 2, gCount)
+	assert.Equal(s.T(), 500*time.Millisecond, gDelayBetween)
+	assert.Equal(s.T(), 2*time.Minute, gWarmup)
+	// single failure fails count mode by default
+	assert.Equal(s.T(), 0, gTolerateFailures)
+}
+
+func (s *mainTestSuite) Test_runChaosCommandOnceFailure() {
+	// prepare
+	gOnce, gTolerateFailures = true, 0
+	defer func() { gOnce, gTolerateFailures = false, -1 }()
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandStop{WaitTime: 10}
+	chaosMock.On("StopContainers", nil, []string{"c1"}, "", cmd).Return(errors.New("ERROR"))
+	// invoke command
+	err := runChaosCommand(nil, cmd, []string{"c1"}, "", chaos.StopContainers)
+	// asserts
+	assert.EqualError(s.T(), err, "ERROR")
+	// tolerate single failure
+	gTolerateFailures = 1
+	err = runChaosCommand(nil, cmd, []string{"c1"}, "", chaos.StopContainers)
+	assert.NoError(s.T(), err)
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_runChaosCommandTooManyFailures() {
+	// prepare: recurrent mode, abort after 2 failures
+	gMaxRuns, gTolerateFailures = 0, 1
+	defer func() { gMaxRuns, gTolerateFailures = 1, -1 }()
+	gInterval = 1 * time.Millisecond
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	cmd := action.CommandStop{WaitTime: 10}
+	chaosMock.On("StopContainers", nil, []string{"c1"}, "", cmd).Return(errors.New("ERROR"))
+	// invoke command
+	err := runChaosCommand(nil, cmd, []string{"c1"}, "", chaos.StopContainers)
 	// asserts
-	assert.EqualError(s.T(), err, "Invalid delay correlation: must be between 0 and 100")
+	assert.EqualError(s.T(), err, "Too many failed runs: 2; last error: ERROR")
+}
+
+func (s *mainTestSuite) Test_servePprof() {
+	// start pprof server on random port
+	err := servePprof("127.0.0.1:0")
+	assert.NoError(s.T(), err)
+	// bad address
+	err = servePprof("bad address")
+	assert.Error(s.T(), err)
+}
+
+func (s *mainTestSuite) Test_pprofAddr() {
+	assert.Equal(s.T(), "localhost:6060", pprofAddr(":6060"))
+	assert.Equal(s.T(), "0.0.0.0:6060", pprofAddr("0.0.0.0:6060"))
+	assert.Equal(s.T(), "bad address", pprofAddr("bad address"))
+}
+
+func (s *mainTestSuite) Test_servePprofNoCmdline() {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(s.T(), err) {
+		return
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+	if !assert.NoError(s.T(), servePprof(addr)) {
+		return
+	}
+	resp, err := http.Get("http://" + addr + "/debug/pprof/cmdline")
+	if assert.NoError(s.T(), err) {
+		resp.Body.Close()
+		assert.Equal(s.T(), http.StatusNotFound, resp.StatusCode)
+	}
+}
+
+func (s *mainTestSuite) Test_serveMetrics() {
+	// start metrics server on random port
+	err := serveMetrics("127.0.0.1:0", metrics.NewRegistry(nil))
+	assert.NoError(s.T(), err)
+	// bad address
+	err = serveMetrics("bad address", metrics.NewRegistry(nil))
+	assert.Error(s.T(), err)
 }
 
 func TestMainTestSuite(t *testing.T) {
 	suite.Run(t, new(mainTestSuite))
 }
+
+func (s *mainTestSuite) Test_setupSummary() {
+	set := flag.NewFlagSet("pumba", 0)
+	set.Bool("quiet", true, "doc")
+	set.String("summary-interval", "", "doc")
+	bus := gBus
+	gBus = events.NewBus()
+	defer func() { gSummary, gBus = nil, bus }()
+	err := setupSummary(cli.NewContext(nil, set, nil))
+	assert.NoError(s.T(), err)
+	if assert.NotNil(s.T(), gSummary) {
+		// summary counts chaos events
+		c := *container.NewContainer(&dockerclient.ContainerInfo{Id: "abc", Name: "/api_1"}, nil)
+		gBus.Publish(events.Event{Type: events.Succeeded, Action: "kill", Container: c})
+		assert.Equal(s.T(), 1, gSummary.Flush().Total())
+		gSummary.Stop()
+	}
+	// quiet mode logs chaos summary
+	cfg, err := loggingConfig(cli.NewContext(nil, set, nil))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), map[string]string{"summary": "info"}, cfg.Levels)
+}
+
+func (s *mainTestSuite) Test_publishSkipped() {
+	bus := gBus
+	gBus = events.NewBus()
+	defer func() { gBus = bus }()
+	var published []events.Event
+	gBus.Subscribe(func(e events.Event) { published = append(published, e) })
+	c := *container.NewContainer(&dockerclient.ContainerInfo{Id: "abc", Name: "/api_1"}, nil)
+	publishSkipped(c, "kill", "locked by another Pumba instance")
+	if assert.Len(s.T(), published, 1) {
+		assert.Equal(s.T(), events.Skipped, published[0].Type)
+		assert.Equal(s.T(), "kill", published[0].Action)
+		assert.Equal(s.T(), "/api_1", published[0].ContainerName)
+		assert.Equal(s.T(), "locked by another Pumba instance", published[0].Reason)
+	}
+}
+
+func (s *mainTestSuite) Test_setupSummaryErrors() {
+	for _, interval := range []string{"0s", "-1m", "bad"} {
+		set := flag.NewFlagSet("pumba", 0)
+		set.String("summary-interval", interval, "doc")
+		err := setupSummary(cli.NewContext(nil, set, nil))
+		assert.EqualError(s.T(), err, "Invalid summary interval '"+interval+"'")
+	}
+	// no summary by default
+	set := flag.NewFlagSet("pumba", 0)
+	assert.NoError(s.T(), setupSummary(cli.NewContext(nil, set, nil)))
+	assert.Nil(s.T(), gSummary)
+}
+
+func (s *mainTestSuite) Test_killSimulation() {
+	// prepare
+	set := flag.NewFlagSet("kill", 0)
+	set.String("signal", "SIGTERM", "doc")
+	c := cli.NewContext(nil, set, nil)
+	gInterval = 20 * time.Second
+	gMaxRuns = 0
+	gSimulation = simulate.New(time.Minute)
+	var out bytes.Buffer
+	gSimulationOut = &out
+	defer func() { gSimulation, gSimulationOut, gMaxRuns = nil, os.Stdout, 1 }()
+	chaosMock := &ChaosMock{}
+	chaos = chaosMock
+	command := action.CommandKill{Signal: "SIGTERM"}
+	chaosMock.On("KillContainers", nil, []string{}, "", command).Return(nil).Times(3)
+	// invoke command
+	err := kill(c)
+	// asserts
+	assert.NoError(s.T(), err)
+	assert.Contains(s.T(), out.String(), "Simulated 1m0s of '': 3 runs every 20s, 0 failed, 0 chaos actions")
+	chaosMock.AssertExpectations(s.T())
+}
+
+func (s *mainTestSuite) Test_setupSimulationErrors() {
+	for _, args := range [][]string{
+		{"0s", "false", "Invalid simulation time '0s'"},
+		{"1x", "false", "Invalid simulation time '1x'"},
+		{"-1h", "false", "Invalid simulation time '-1h'"},
+		{"1h", "true", "Options --simulate and --once are mutually exclusive"},
+	} {
+		set := flag.NewFlagSet("pumba", 0)
+		set.String("simulate", args[0], "doc")
+		set.Bool("once", args[1] == "true", "doc")
+		err := setupSimulation(cli.NewContext(nil, set, nil))
+		assert.EqualError(s.T(), err, args[2])
+	}
+	assert.Nil(s.T(), gSimulation)
+}
+
+// writeTLSFiles writes self-signed certificate (ca.pem and cert.pem) and its key (key.pem) to directory
+func writeTLSFiles(t *testing.T, dir string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "pumba"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	ioutil.WriteFile(filepath.Join(dir, "ca.pem"), cert, 0600)
+	ioutil.WriteFile(filepath.Join(dir, "cert.pem"), cert, 0600)
+	ioutil.WriteFile(filepath.Join(dir, "key.pem"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+}
+
+// tlsFlags creates global context with TLS flags; default values point to missing files, set values are explicit
+func tlsFlags(verify bool, certDir string, set map[string]string) *cli.Context {
+	flags := flag.NewFlagSet("pumba", 0)
+	flags.Bool("tls", false, "doc")
+	flags.Bool("tlsverify", verify, "doc")
+	flags.String("tlscertdir", certDir, "doc")
+	flags.String("tlscacert", "/missing/ca.pem", "doc")
+	flags.String("tlscert", "/missing/cert.pem", "doc")
+	flags.String("tlskey", "/missing/key.pem", "doc")
+	flags.String("host", "", "doc")
+	flags.String("docker-context", "", "doc")
+	for name, value := range set {
+		flags.Set(name, value)
+	}
+	return cli.NewContext(nil, flags, nil)
+}
+
+func (s *mainTestSuite) Test_tlsConfigCertDir() {
+	dir, _ := ioutil.TempDir("", "pumba-tls")
+	defer os.RemoveAll(dir)
+	writeTLSFiles(s.T(), dir)
+	cfg, err := tlsConfig(tlsFlags(true, dir, nil))
+	assert.NoError(s.T(), err)
+	if assert.NotNil(s.T(), cfg) {
+		assert.False(s.T(), cfg.InsecureSkipVerify)
+		assert.NotNil(s.T(), cfg.RootCAs)
+		assert.Len(s.T(), cfg.Certificates, 1)
+	}
+	// missing default files are skipped
+	cfg, err = tlsConfig(tlsFlags(true, "", nil))
+	assert.NoError(s.T(), err)
+	if assert.NotNil(s.T(), cfg) {
+		assert.Nil(s.T(), cfg.RootCAs)
+		assert.Empty(s.T(), cfg.Certificates)
+	}
+	// no TLS
+	cfg, err = tlsConfig(tlsFlags(false, "", nil))
+	assert.NoError(s.T(), err)
+	assert.Nil(s.T(), cfg)
+}
+
+func (s *mainTestSuite) Test_tlsConfigVerifyEnv() {
+	defer os.Unsetenv("DOCKER_TLS_VERIFY")
+	// any non-empty value enables TLS verification, like Docker CLI
+	for _, value := range []string{"1", "0", "yes"} {
+		os.Setenv("DOCKER_TLS_VERIFY", value)
+		cfg, err := tlsConfig(tlsFlags(false, "", nil))
+		assert.NoError(s.T(), err)
+		if assert.NotNil(s.T(), cfg) {
+			assert.False(s.T(), cfg.InsecureSkipVerify)
+		}
+	}
+	os.Setenv("DOCKER_TLS_VERIFY", "")
+	cfg, err := tlsConfig(tlsFlags(false, "", nil))
+	assert.NoError(s.T(), err)
+	assert.Nil(s.T(), cfg)
+}
+
+func (s *mainTestSuite) Test_dockerEndpoint() {
+	dir, _ := ioutil.TempDir("", "pumba-docker")
+	defer os.RemoveAll(dir)
+	meta := filepath.Join(dir, "contexts", "meta", fmt.Sprintf("%x", sha256.Sum256([]byte("remote"))))
+	os.MkdirAll(meta, 0700)
+	ioutil.WriteFile(filepath.Join(meta, "meta.json"), []byte(`{"Name":"remote","Endpoints":{"docker":{"Host":"tcp://remote","SkipTLSVerify":false}}}`), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"currentContext":"remote"}`), 0600)
+	os.Setenv("DOCKER_CONFIG", dir)
+	defer os.Unsetenv("DOCKER_CONFIG")
+	// current context of Docker CLI config
+	host, tls, err := dockerEndpoint(tlsFlags(false, "", nil))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "tcp://remote:2375", host)
+	assert.Nil(s.T(), tls)
+	// DOCKER_HOST selects default context
+	os.Setenv("DOCKER_HOST", "tcp://127.0.0.1")
+	host, _, err = dockerEndpoint(tlsFlags(true, "", nil))
+	os.Unsetenv("DOCKER_HOST")
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "tcp://127.0.0.1:2376", host)
+	// --host selects default context
+	host, _, err = dockerEndpoint(tlsFlags(false, "", map[string]string{"host": "unix://"}))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), container.DefaultHost, host)
+	// --docker-context
+	host, _, err = dockerEndpoint(tlsFlags(false, "", map[string]string{"docker-context": "remote"}))
+	assert.NoError(s.T(), err)
+	assert.Equal(s.T(), "tcp://remote:2375", host)
+	_, _, err = dockerEndpoint(tlsFlags(false, "", map[string]string{"docker-context": "missing"}))
+	assert.EqualError(s.T(), err, "Docker context 'missing' not found")
+	_, _, err = dockerEndpoint(tlsFlags(false, "", map[string]string{"docker-context": "remote", "host": "tcp://host"}))
+	assert.EqualError(s.T(), err, "Options --host and --docker-context are mutually exclusive")
+}
+
+func (s *mainTestSuite) Test_tlsConfigPathOrContent() {
+	dir, _ := ioutil.TempDir("", "pumba-tls")
+	defer os.RemoveAll(dir)
+	writeTLSFiles(s.T(), dir)
+	wd, _ := os.Getwd()
+	relative, err := filepath.Rel(wd, filepath.Join(dir, "ca.pem"))
+	assert.NoError(s.T(), err)
+	key, _ := ioutil.ReadFile(filepath.Join(dir, "key.pem"))
+	cfg, err := tlsConfig(tlsFlags(true, "", map[string]string{
+		"tlscacert": relative,
+		"tlscert":   filepath.Join(dir, "cert.pem"),
+		"tlskey":    string(key),
+	}))
+	assert.NoError(s.T(), err)
+	if assert.NotNil(s.T(), cfg) {
+		assert.NotNil(s.T(), cfg.RootCAs)
+		assert.Len(s.T(), cfg.Certificates, 1)
+	}
+}
+
+func (s *mainTestSuite) Test_tlsConfigErrors() {
+	dir, _ := ioutil.TempDir("", "pumba-tls")
+	defer os.RemoveAll(dir)
+	writeTLSFiles(s.T(), dir)
+	ioutil.WriteFile(filepath.Join(dir, "bad.pem"), []byte("not a certificate"), 0600)
+	_, err := tlsConfig(tlsFlags(false, dir, nil))
+	assert.EqualError(s.T(), err, "TLS certificates directory (--tlscertdir) requires --tls or --tlsverify option")
+	_, err = tlsConfig(tlsFlags(true, filepath.Join(dir, "missing"), nil))
+	assert.EqualError(s.T(), err, "Invalid TLS certificates directory '"+filepath.Join(dir, "missing")+"'")
+	_, err = tlsConfig(tlsFlags(true, "", map[string]string{"tlscacert": "ca.pem"}))
+	assert.EqualError(s.T(), err, "Failed to read TLS CA certificate (--tlscacert) file 'ca.pem': open ca.pem: no such file or directory")
+	_, err = tlsConfig(tlsFlags(true, "", map[string]string{"tlscacert": filepath.Join(dir, "bad.pem")}))
+	assert.EqualError(s.T(), err, "Invalid TLS CA certificate (--tlscacert): no PEM certificates found")
+	_, err = tlsConfig(tlsFlags(true, "", map[string]string{"tlscert": filepath.Join(dir, "cert.pem")}))
+	assert.EqualError(s.T(), err, "TLS client certificate (--tlscert) requires client key (--tlskey)")
+	_, err = tlsConfig(tlsFlags(true, "", map[string]string{"tlscert": filepath.Join(dir, "cert.pem"), "tlskey": filepath.Join(dir, "bad.pem")}))
+	if assert.Error(s.T(), err) {
+		assert.Contains(s.T(), err.Error(), "Invalid TLS client certificate (--tlscert) or key (--tlskey): ")
+	}
+}
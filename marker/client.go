@@ -0,0 +1,96 @@
+package marker
+
+import (
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/netem"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// markingClient marks containers after successful chaos action; dry runs are not marked
+type markingClient struct {
+	container.Client
+	marker Marker
+}
+
+// NewClient wraps container client with marker
+func NewClient(client container.Client, marker Marker) container.Client {
+	return markingClient{Client: client, marker: marker}
+}
+
+func (client markingClient) mark(c container.Container, action string, err error) error {
+	if err != nil || client.DryRun() {
+		return err
+	}
+	if merr := client.marker.Mark(c, action); merr != nil {
+		log.Warnf("Failed to mark container %s: %s", c.Name(), merr)
+	}
+	return nil
+}
+
+func (client markingClient) StopContainer(c container.Container, timeout int) error {
+	return client.mark(c, "stop", client.Client.StopContainer(c, timeout))
+}
+
+func (client markingClient) KillContainer(c container.Container, signal string) error {
+	return client.mark(c, "kill", client.Client.KillContainer(c, signal))
+}
+
+func (client markingClient) RemoveContainer(c container.Container, force bool, links bool, volumes bool) error {
+	return client.mark(c, "rm", client.Client.RemoveContainer(c, force, links, volumes))
+}
+
+func (client markingClient) NetemContainer(c container.Container, netInterface string, netemCmd string, filter netem.Filter, duration time.Duration, reapply bool, probe string) error {
+	return client.mark(c, "netem", client.Client.NetemContainer(c, netInterface, netemCmd, filter, duration, reapply, probe))
+}
+
+func (client markingClient) PauseContainer(c container.Container, duration time.Duration) error {
+	return client.mark(c, "pause", client.Client.PauseContainer(c, duration))
+}
+
+func (client markingClient) SidecarContainer(c container.Container, image string, cmd []string, duration time.Duration) error {
+	return client.mark(c, "sidecar", client.Client.SidecarContainer(c, image, cmd, duration))
+}
+
+func (client markingClient) DropPortsContainer(c container.Container, loss int, duration time.Duration) error {
+	return client.mark(c, "ports", client.Client.DropPortsContainer(c, loss, duration))
+}
+
+func (client markingClient) BlackoutContainer(c container.Container, timeout int, duration time.Duration) error {
+	return client.mark(c, "stop", client.Client.BlackoutContainer(c, timeout, duration))
+}
+
+func (client markingClient) BurnCPUContainer(c container.Container, workers int, duration time.Duration) error {
+	return client.mark(c, "cpu", client.Client.BurnCPUContainer(c, workers, duration))
+}
+
+func (client markingClient) OOMContainer(c container.Container, timeout time.Duration) error {
+	return client.mark(c, "oom", client.Client.OOMContainer(c, timeout))
+}
+
+func (client markingClient) ExhaustFDsContainer(c container.Container, free int, duration time.Duration) error {
+	return client.mark(c, "fd", client.Client.ExhaustFDsContainer(c, free, duration))
+}
+
+func (client markingClient) HealthcheckContainer(c container.Container, probe health.Probe, duration time.Duration) error {
+	return client.mark(c, "health", client.Client.HealthcheckContainer(c, probe, duration))
+}
+
+func (client markingClient) MemoryPressureContainer(c container.Container, percent int, duration time.Duration) error {
+	return client.mark(c, "memory", client.Client.MemoryPressureContainer(c, percent, duration))
+}
+
+func (client markingClient) CorruptEnvContainer(c container.Container, set []string, unset []string, timeout int, duration time.Duration) error {
+	return client.mark(c, "env", client.Client.CorruptEnvContainer(c, set, unset, timeout, duration))
+}
+
+func (client markingClient) RemoveSecretsContainer(c container.Container, secrets []string, configs []string, duration time.Duration) error {
+	return client.mark(c, "secrets", client.Client.RemoveSecretsContainer(c, secrets, configs, duration))
+}
+
+func (client markingClient) PoisonImageContainer(c container.Container, image string, duration time.Duration) error {
+	return client.mark(c, "poison-image", client.Client.PoisonImageContainer(c, image, duration))
+}
@@ -0,0 +1,55 @@
+package marker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type markerMock struct {
+	mock.Mock
+}
+
+func (m *markerMock) Mark(c container.Container, action string) error {
+	args := m.Called(c, action)
+	return args.Error(0)
+}
+
+func (m *markerMock) Close() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func TestClient_MarkOnSuccess(t *testing.T) {
+	c := container.NewMockContainer("abc", "/c1", nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("KillContainer", c, "SIGKILL").Return(nil)
+	inner.On("PauseContainer", c, time.Second).Return(nil)
+	m := &markerMock{}
+	m.On("Mark", c, "kill").Return(nil)
+	m.On("Mark", c, "pause").Return(errors.New("mark failed"))
+	client := NewClient(inner, m)
+	assert.NoError(t, client.KillContainer(c, "SIGKILL"))
+	// marker errors do not fail chaos action
+	assert.NoError(t, client.PauseContainer(c, time.Second))
+	inner.AssertExpectations(t)
+	m.AssertExpectations(t)
+}
+
+func TestClient_NoMarkOnErrorOrDryRun(t *testing.T) {
+	c := container.NewMockContainer("abc", "/c1", nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("StopContainer", c, 10).Return(errors.New("stop failed"))
+	inner.On("KillContainer", c, "SIGKILL").Return(nil)
+	m := &markerMock{}
+	client := NewClient(inner, m)
+	assert.EqualError(t, client.StopContainer(c, 10), "stop failed")
+	inner.Dry = true
+	assert.NoError(t, client.KillContainer(c, "SIGKILL"))
+	inner.AssertExpectations(t)
+	m.AssertNotCalled(t, "Mark", mock.Anything, mock.Anything)
+}
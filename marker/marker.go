@@ -0,0 +1,143 @@
+package marker
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/state"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// A Marker marks containers, recently affected by chaos actions, so other tools can detect them;
+// marks are removed after cooldown period
+type Marker interface {
+	Mark(c container.Container, action string) error
+	// Close removes pending marks and releases marker resources
+	Close() error
+}
+
+// StateMarker marks container with entry in Pumba state file; mark is removed after cooldown
+type StateMarker struct {
+	file     *state.File
+	cooldown time.Duration
+	mu       sync.Mutex
+	pending  map[string]*time.Timer
+	now      func() time.Time
+}
+
+// NewStateMarker creates new state marker
+func NewStateMarker(file *state.File, cooldown time.Duration) *StateMarker {
+	return &StateMarker{file: file, cooldown: cooldown, pending: map[string]*time.Timer{}, now: time.Now}
+}
+
+// Mark adds container mark to state file; repeated mark extends cooldown period; removed containers are not marked
+func (m *StateMarker) Mark(c container.Container, action string) error {
+	if action == "rm" {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	name := strings.TrimPrefix(c.Name(), "/")
+	m.file.SetMark(state.Mark{ContainerID: c.ID(), ContainerName: name, Action: action, Until: m.now().Add(m.cooldown)})
+	log.Debugf("Marked container %s after %s", name, action)
+	id := c.ID()
+	if timer, ok := m.pending[id]; ok {
+		timer.Reset(m.cooldown)
+		return nil
+	}
+	m.pending[id] = time.AfterFunc(m.cooldown, func() { m.unmark(id) })
+	return nil
+}
+
+func (m *StateMarker) unmark(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remove(id)
+}
+
+// remove container mark; should be called with lock held
+func (m *StateMarker) remove(id string) {
+	timer, ok := m.pending[id]
+	if !ok {
+		return
+	}
+	delete(m.pending, id)
+	timer.Stop()
+	m.file.RemoveMark(id)
+}
+
+// Close removes marks of all marked containers
+func (m *StateMarker) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id := range m.pending {
+		m.remove(id)
+	}
+	return nil
+}
+
+// AuditMarker marks container by writing audit event (JSON line) with container ID, action and cooldown expiration time
+type AuditMarker struct {
+	// Context key=value pairs attached to all audit events (e.g. build and deployment of tested system)
+	Context  map[string]string
+	w        io.Writer
+	cooldown time.Duration
+	mu       sync.Mutex
+	now      func() time.Time
+}
+
+// AuditEvent audit log record
+type AuditEvent struct {
+	Time      time.Time         `json:"time"`
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Action    string            `json:"action"`
+	ExpiresAt time.Time         `json:"expires_at"`
+	Context   map[string]string `json:"context,omitempty"`
+	// RestartPolicy and Recovery (expected outcome: 'auto' recovery or 'none') of kill, rm and oom actions
+	RestartPolicy string `json:"restart_policy,omitempty"`
+	Recovery      string `json:"recovery,omitempty"`
+}
+
+// NewAuditMarker creates new audit marker, writing events into w
+func NewAuditMarker(w io.Writer, cooldown time.Duration) *AuditMarker {
+	return &AuditMarker{w: w, cooldown: cooldown, now: time.Now}
+}
+
+// Mark writes audit event for container
+func (m *AuditMarker) Mark(c container.Container, action string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.now()
+	event := AuditEvent{
+		Time:      now,
+		ID:        c.ID(),
+		Name:      strings.TrimPrefix(c.Name(), "/"),
+		Action:    action,
+		ExpiresAt: now.Add(m.cooldown),
+		Context:   m.Context,
+	}
+	if action == "kill" || action == "rm" || action == "oom" {
+		event.RestartPolicy = c.RestartPolicy()
+		event.Recovery = c.Recovery(action)
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = m.w.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes audit writer, if it's closable
+func (m *AuditMarker) Close() error {
+	if closer, ok := m.w.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
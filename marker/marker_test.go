@@ -0,0 +1,93 @@
+package marker
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/state"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateMarker_Cooldown(t *testing.T) {
+	f, err := state.NewFile("")
+	assert.NoError(t, err)
+	now := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	m := NewStateMarker(f, 5*time.Millisecond)
+	m.now = func() time.Time { return now }
+	err = m.Mark(container.NewMockContainer("abc", "/c1", nil), "kill")
+	assert.NoError(t, err)
+	// repeated mark replaces the mark
+	err = m.Mark(container.NewMockContainer("abc", "/c1", nil), "pause")
+	assert.NoError(t, err)
+	assert.Equal(t, []state.Mark{{ContainerID: "abc", ContainerName: "c1", Action: "pause", Until: now.Add(5 * time.Millisecond)}}, f.Marks())
+	time.Sleep(20 * time.Millisecond)
+	assert.Empty(t, f.Marks())
+}
+
+func TestStateMarker_Close(t *testing.T) {
+	f, err := state.NewFile("")
+	assert.NoError(t, err)
+	m := NewStateMarker(f, time.Hour)
+	assert.NoError(t, m.Mark(container.NewMockContainer("abc", "/c1", nil), "pause"))
+	assert.NoError(t, m.Mark(container.NewMockContainer("def", "/c2", nil), "netem"))
+	assert.Len(t, f.Marks(), 2)
+	assert.NoError(t, m.Close())
+	assert.Empty(t, f.Marks())
+}
+
+func TestStateMarker_Removed(t *testing.T) {
+	f, err := state.NewFile("")
+	assert.NoError(t, err)
+	m := NewStateMarker(f, time.Hour)
+	assert.NoError(t, m.Mark(container.NewMockContainer("abc", "/c1", nil), "rm"))
+	assert.Empty(t, f.Marks())
+	assert.Empty(t, m.pending)
+}
+
+func TestAuditMarker(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	m := NewAuditMarker(&buf, 10*time.Minute)
+	m.now = func() time.Time { return now }
+	err := m.Mark(container.NewMockContainer("abc", "/c1", nil), "kill")
+	assert.NoError(t, err)
+	var event AuditEvent
+	err = json.Unmarshal(buf.Bytes(), &event)
+	assert.NoError(t, err)
+	assert.Equal(t, AuditEvent{Time: now, ID: "abc", Name: "c1", Action: "kill", ExpiresAt: now.Add(10 * time.Minute), RestartPolicy: "no", Recovery: "none"}, event)
+	assert.NoError(t, m.Close())
+}
+
+func TestAuditMarker_RestartPolicy(t *testing.T) {
+	var buf bytes.Buffer
+	m := NewAuditMarker(&buf, 10*time.Minute)
+	c := *container.NewContainer(&dockerclient.ContainerInfo{
+		Id:         "abc",
+		Name:       "/c1",
+		HostConfig: &dockerclient.HostConfig{RestartPolicy: dockerclient.RestartPolicy{Name: "always"}},
+	}, nil)
+	assert.NoError(t, m.Mark(c, "kill"))
+	assert.NoError(t, m.Mark(c, "rm"))
+	assert.NoError(t, m.Mark(c, "pause"))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Contains(t, lines[0], `"restart_policy":"always","recovery":"auto"`)
+	// removed container is not restarted by Docker
+	assert.Contains(t, lines[1], `"restart_policy":"always","recovery":"none"`)
+	assert.NotContains(t, lines[2], "recovery")
+}
+
+func TestAuditMarker_Context(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	m := NewAuditMarker(&buf, 10*time.Minute)
+	m.now = func() time.Time { return now }
+	m.Context = map[string]string{"build": "1.2.3", "commit": "abc123"}
+	err := m.Mark(container.NewMockContainer("abc", "/c1", nil), "kill")
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `"context":{"build":"1.2.3","commit":"abc123"}`)
+}
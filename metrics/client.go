@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// ExitTimeout how long to wait for container exit after kill/stop signal
+const ExitTimeout = 5 * time.Minute
+
+// measuringClient measures container shutdown latency of kill and stop actions; dry runs are not measured
+type measuringClient struct {
+	container.Client
+	registry *Registry
+	now      func() time.Time
+	// observed is called, when background shutdown latency measurement is done; nil - not called
+	observed func()
+}
+
+// NewClient wraps container client with shutdown latency measurement
+func NewClient(client container.Client, registry *Registry) container.Client {
+	return measuringClient{Client: client, registry: registry, now: time.Now}
+}
+
+// Service returns service name of container: docker-compose service or container name
+func Service(c container.Container) string {
+	if service := c.ComposeService(); service != "" {
+		return service
+	}
+	return strings.TrimPrefix(c.Name(), "/")
+}
+
+func (client measuringClient) KillContainer(c container.Container, signal string) error {
+	if client.DryRun() {
+		return client.Client.KillContainer(c, signal)
+	}
+	exited := client.watch(c)
+	start := client.now()
+	err := client.Client.KillContainer(c, signal)
+	client.observe("kill", c, start, exited, err)
+	return err
+}
+
+func (client measuringClient) StopContainer(c container.Container, timeout int) error {
+	if client.DryRun() {
+		return client.Client.StopContainer(c, timeout)
+	}
+	exited := client.watch(c)
+	start := client.now()
+	err := client.Client.StopContainer(c, timeout)
+	client.observe("stop", c, start, exited, err)
+	return err
+}
+
+// watch starts watching container exit; should be called before signal is sent
+func (client measuringClient) watch(c container.Container) <-chan time.Time {
+	exited, err := client.ExitWatch(c, ExitTimeout)
+	if err != nil {
+		log.Warnf("Failed to watch exit of container %s: %s", c.ID(), err)
+		return nil
+	}
+	return exited
+}
+
+// observe records shutdown latency in background, when container exits
+func (client measuringClient) observe(action string, c container.Container, start time.Time, exited <-chan time.Time, err error) {
+	if exited == nil || err != nil {
+		return
+	}
+	go func() {
+		if client.observed != nil {
+			defer client.observed()
+		}
+		if t, ok := <-exited; ok {
+			latency := t.Sub(start)
+			log.Debugf("Container %s exited %s after %s signal", c.ID(), latency, action)
+			client.registry.ObserveShutdown(action, Service(c), latency)
+		}
+	}()
+}
@@ -0,0 +1,60 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService(t *testing.T) {
+	assert.Equal(t, "api", Service(container.NewMockContainer("abc", "/proj_api_1", map[string]string{"com.docker.compose.service": "api"})))
+	assert.Equal(t, "c1", Service(container.NewMockContainer("abc", "/c1", nil)))
+}
+
+func TestClient_StopLatency(t *testing.T) {
+	c := container.NewMockContainer("abc", "/c1", nil)
+	start := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	exited := make(chan time.Time, 1)
+	exited <- start.Add(3 * time.Second)
+	inner := container.NewMockSamalbaClient()
+	inner.On("ExitWatch", c, ExitTimeout).Return((<-chan time.Time)(exited), nil)
+	inner.On("StopContainer", c, 10).Return(nil)
+	r := NewRegistry(nil)
+	observed := make(chan struct{})
+	client := measuringClient{Client: inner, registry: r, now: func() time.Time { return start }, observed: func() { close(observed) }}
+	assert.NoError(t, client.StopContainer(c, 10))
+	<-observed
+	h := r.Shutdown("stop", "c1")
+	if assert.NotNil(t, h) {
+		assert.Equal(t, uint64(1), h.Count())
+		assert.Equal(t, 3.0, h.Sum())
+	}
+	inner.AssertExpectations(t)
+}
+
+func TestClient_KillError(t *testing.T) {
+	c := container.NewMockContainer("abc", "/c1", nil)
+	exited := make(chan time.Time, 1)
+	inner := container.NewMockSamalbaClient()
+	inner.On("ExitWatch", c, ExitTimeout).Return((<-chan time.Time)(exited), nil)
+	inner.On("KillContainer", c, "SIGKILL").Return(errors.New("kill failed"))
+	r := NewRegistry(nil)
+	client := measuringClient{Client: inner, registry: r, now: time.Now, observed: func() { t.Error("latency of failed kill is measured") }}
+	assert.EqualError(t, client.KillContainer(c, "SIGKILL"), "kill failed")
+	assert.Nil(t, r.Shutdown("kill", "c1"))
+	inner.AssertExpectations(t)
+}
+
+func TestClient_DryRun(t *testing.T) {
+	c := container.NewMockContainer("abc", "/c1", nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("KillContainer", c, "SIGKILL").Return(nil)
+	client := NewClient(inner, NewRegistry(nil))
+	inner.Dry = true
+	assert.NoError(t, client.KillContainer(c, "SIGKILL"))
+	inner.AssertNotCalled(t, "ExitWatch", c, ExitTimeout)
+	inner.AssertExpectations(t)
+}
@@ -0,0 +1,391 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaia-adm/pumba/events"
+	"github.com/gaia-adm/pumba/state"
+)
+
+// ShutdownBuckets histogram buckets for container shutdown latency; in seconds
+var ShutdownBuckets = []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60}
+
+// APIBuckets histogram buckets for Docker API call latency; in seconds
+var APIBuckets = []float64{0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10}
+
+// Histogram cumulative histogram of observed values
+type Histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// NewHistogram creates histogram with upper bounds of buckets (sorted); +Inf bucket is implicit
+func NewHistogram(buckets []float64) *Histogram {
+	return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// Observe adds value to histogram
+func (h *Histogram) Observe(v float64) {
+	for i, le := range h.buckets {
+		if v <= le {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// Count returns number of observed values
+func (h *Histogram) Count() uint64 {
+	return h.count
+}
+
+// Sum returns sum of observed values
+func (h *Histogram) Sum() float64 {
+	return h.sum
+}
+
+// shutdown latency series key
+type shutdownKey struct {
+	action  string
+	service string
+}
+
+// Docker API call latency series key
+type apiKey struct {
+	action string
+	call   string
+}
+
+// chaos action counter key
+type actionKey struct {
+	action string
+	result string
+}
+
+// Registry collects Pumba metrics and exposes them in Prometheus text format
+type Registry struct {
+	mu       sync.Mutex
+	context  map[string]string
+	shutdown map[shutdownKey]*Histogram
+	api      map[apiKey]*Histogram
+	actions  map[actionKey]uint64
+	// denylistings number of targets denylisted after repeated failures, per action
+	denylistings map[string]uint64
+	// denylisted returns number of currently denylisted targets; nil - not exposed
+	denylisted func() int
+	// disruptions returns active disruptions; nil - not exposed
+	disruptions func() []state.Disruption
+	// admitted returns number of disruptions, admitted by admission control (--max-active); nil - not exposed
+	admitted    func() int
+	maxAdmitted int
+	now         func() time.Time
+}
+
+// NewRegistry creates metrics registry; context pairs are added as labels to all metrics
+func NewRegistry(context map[string]string) *Registry {
+	return &Registry{context: context, shutdown: map[shutdownKey]*Histogram{}, api: map[apiKey]*Histogram{}, actions: map[actionKey]uint64{}, denylistings: map[string]uint64{}, now: time.Now}
+}
+
+// ObserveShutdown records time between signal sent by Pumba (kill/stop action) and container exit
+func (r *Registry) ObserveShutdown(action, service string, latency time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := shutdownKey{action: action, service: service}
+	h, ok := r.shutdown[key]
+	if !ok {
+		h = NewHistogram(ShutdownBuckets)
+		r.shutdown[key] = h
+	}
+	h.Observe(latency.Seconds())
+}
+
+// Shutdown returns shutdown latency histogram for action and service; nil if nothing was observed
+func (r *Registry) Shutdown(action, service string) *Histogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.shutdown[shutdownKey{action: action, service: service}]
+}
+
+// ObserveAPI records duration of Docker API call made by Pumba action
+func (r *Registry) ObserveAPI(action, call string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := apiKey{action: action, call: call}
+	h, ok := r.api[key]
+	if !ok {
+		h = NewHistogram(APIBuckets)
+		r.api[key] = h
+	}
+	h.Observe(d.Seconds())
+}
+
+// API returns Docker API call latency histogram for action and call; nil if nothing was observed
+func (r *Registry) API(action, call string) *Histogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.api[apiKey{action: action, call: call}]
+}
+
+// Handle counts results of chaos actions; dry runs are not counted
+func (r *Registry) Handle(e events.Event) {
+	if e.DryRun {
+		return
+	}
+	result := "success"
+	switch e.Type {
+	case events.Succeeded:
+	case events.Failed:
+		result = "failure"
+	default:
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.actions[actionKey{action: e.Action, result: result}]++
+}
+
+// Actions returns number of chaos actions with result ('success' or 'failure')
+func (r *Registry) Actions(action, result string) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.actions[actionKey{action: action, result: result}]
+}
+
+// ObserveDenylisted counts target denylisted after repeated failures of chaos action
+func (r *Registry) ObserveDenylisted(action string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.denylistings[action]++
+}
+
+// DenylistGauge sets source of number of currently denylisted targets
+func (r *Registry) DenylistGauge(fn func() int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.denylisted = fn
+}
+
+// DisruptionsGauge sets source of active disruptions (pause, netem, ports)
+func (r *Registry) DisruptionsGauge(fn func() []state.Disruption) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.disruptions = fn
+}
+
+// AdmissionGauge sets source of number of admitted active disruptions and their limit (--max-active)
+func (r *Registry) AdmissionGauge(max int, fn func() int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.admitted = fn
+	r.maxAdmitted = max
+}
+
+// WriteTo writes metrics in Prometheus text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var buf bytes.Buffer
+	name := "pumba_shutdown_latency_seconds"
+	fmt.Fprintf(&buf, "# HELP %s Time between signal sent by Pumba and container exit.\n", name)
+	fmt.Fprintf(&buf, "# TYPE %s histogram\n", name)
+	keys := make([]shutdownKey, 0, len(r.shutdown))
+	for key := range r.shutdown {
+		keys = append(keys, key)
+	}
+	sort.Sort(byActionService(keys))
+	for _, key := range keys {
+		writeHistogram(&buf, name, r.labels("action", key.action, "service", key.service), r.shutdown[key])
+	}
+	if len(r.actions) > 0 {
+		name = "pumba_chaos_actions_total"
+		fmt.Fprintf(&buf, "# HELP %s Number of chaos actions by result.\n", name)
+		fmt.Fprintf(&buf, "# TYPE %s counter\n", name)
+		actionKeys := make([]actionKey, 0, len(r.actions))
+		for key := range r.actions {
+			actionKeys = append(actionKeys, key)
+		}
+		sort.Sort(byActionResult(actionKeys))
+		for _, key := range actionKeys {
+			fmt.Fprintf(&buf, "%s{%s} %d\n", name, r.labels("action", key.action, "result", key.result), r.actions[key])
+		}
+	}
+	if r.denylisted != nil {
+		name = "pumba_denylisted_targets"
+		fmt.Fprintf(&buf, "# HELP %s Number of targets, skipped after repeated failures of chaos action.\n", name)
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n", name)
+		labels := r.labels()
+		if labels != "" {
+			labels = "{" + labels + "}"
+		}
+		fmt.Fprintf(&buf, "%s%s %d\n", name, labels, r.denylisted())
+	}
+	if len(r.denylistings) > 0 {
+		name = "pumba_denylisted_total"
+		fmt.Fprintf(&buf, "# HELP %s Number of times targets were denylisted after repeated failures of chaos action.\n", name)
+		fmt.Fprintf(&buf, "# TYPE %s counter\n", name)
+		actions := make([]string, 0, len(r.denylistings))
+		for action := range r.denylistings {
+			actions = append(actions, action)
+		}
+		sort.Strings(actions)
+		for _, action := range actions {
+			fmt.Fprintf(&buf, "%s{%s} %d\n", name, r.labels("action", action), r.denylistings[action])
+		}
+	}
+	if r.disruptions != nil {
+		r.writeDisruptions(&buf)
+	}
+	if r.admitted != nil {
+		labels := r.labels()
+		if labels != "" {
+			labels = "{" + labels + "}"
+		}
+		name = "pumba_admitted_disruptions"
+		fmt.Fprintf(&buf, "# HELP %s Number of active disruptions, admitted for this Pumba instance.\n", name)
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n", name)
+		fmt.Fprintf(&buf, "%s%s %d\n", name, labels, r.admitted())
+		name = "pumba_max_active_disruptions"
+		fmt.Fprintf(&buf, "# HELP %s Maximal number of active disruptions of all Pumba instances.\n", name)
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n", name)
+		fmt.Fprintf(&buf, "%s%s %d\n", name, labels, r.maxAdmitted)
+	}
+	if len(r.api) > 0 {
+		name = "pumba_docker_api_latency_seconds"
+		fmt.Fprintf(&buf, "# HELP %s Duration of Docker API calls made by Pumba actions.\n", name)
+		fmt.Fprintf(&buf, "# TYPE %s histogram\n", name)
+		apiKeys := make([]apiKey, 0, len(r.api))
+		for key := range r.api {
+			apiKeys = append(apiKeys, key)
+		}
+		sort.Sort(byActionCall(apiKeys))
+		for _, key := range apiKeys {
+			writeHistogram(&buf, name, r.labels("action", key.action, "call", key.call), r.api[key])
+		}
+	}
+	return buf.WriteTo(w)
+}
+
+// writeDisruptions writes number of active disruptions per action and remaining duration of each disruption
+func (r *Registry) writeDisruptions(buf *bytes.Buffer) {
+	disruptions := r.disruptions()
+	now := r.now()
+	active := map[string]int{}
+	for _, d := range disruptions {
+		active[d.Action]++
+	}
+	name := "pumba_active_disruptions"
+	fmt.Fprintf(buf, "# HELP %s Number of active disruptions by action.\n", name)
+	fmt.Fprintf(buf, "# TYPE %s gauge\n", name)
+	actions := make([]string, 0, len(active))
+	for action := range active {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+	for _, action := range actions {
+		fmt.Fprintf(buf, "%s{%s} %d\n", name, r.labels("action", action), active[action])
+	}
+	if len(disruptions) == 0 {
+		return
+	}
+	name = "pumba_disruption_remaining_seconds"
+	fmt.Fprintf(buf, "# HELP %s Remaining duration of active disruption.\n", name)
+	fmt.Fprintf(buf, "# TYPE %s gauge\n", name)
+	for _, d := range disruptions {
+		left := d.Until.Sub(now).Seconds()
+		if left < 0 {
+			left = 0
+		}
+		container := strings.TrimPrefix(d.ContainerName, "/")
+		fmt.Fprintf(buf, "%s{%s} %s\n", name, r.labels("action", d.Action, "container", container), formatFloat(left))
+	}
+}
+
+func writeHistogram(buf *bytes.Buffer, name, labels string, h *Histogram) {
+	for i, le := range h.buckets {
+		fmt.Fprintf(buf, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, formatFloat(le), h.counts[i])
+	}
+	fmt.Fprintf(buf, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(buf, "%s_sum{%s} %s\n", name, labels, formatFloat(h.sum))
+	fmt.Fprintf(buf, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+// ServeHTTP serves metrics in Prometheus text format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	r.WriteTo(w)
+}
+
+var invalidLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// labels formats metric labels: name/value pairs followed by context pairs (sorted by name)
+func (r *Registry) labels(pairs ...string) string {
+	var labels []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		labels = append(labels, label(pairs[i], pairs[i+1]))
+	}
+	names := make([]string, 0, len(r.context))
+	for k := range r.context {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		labels = append(labels, label(invalidLabelChars.ReplaceAllString(k, "_"), r.context[k]))
+	}
+	return strings.Join(labels, ",")
+}
+
+func label(name, value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	value = strings.Replace(value, "\n", `\n`, -1)
+	return fmt.Sprintf("%s=\"%s\"", name, value)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type byActionService []shutdownKey
+
+func (s byActionService) Len() int      { return len(s) }
+func (s byActionService) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byActionService) Less(i, j int) bool {
+	if s[i].action != s[j].action {
+		return s[i].action < s[j].action
+	}
+	return s[i].service < s[j].service
+}
+
+type byActionCall []apiKey
+
+func (s byActionCall) Len() int      { return len(s) }
+func (s byActionCall) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byActionCall) Less(i, j int) bool {
+	if s[i].action != s[j].action {
+		return s[i].action < s[j].action
+	}
+	return s[i].call < s[j].call
+}
+
+type byActionResult []actionKey
+
+func (s byActionResult) Len() int      { return len(s) }
+func (s byActionResult) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byActionResult) Less(i, j int) bool {
+	if s[i].action != s[j].action {
+		return s[i].action < s[j].action
+	}
+	return s[i].result < s[j].result
+}
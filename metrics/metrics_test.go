@@ -0,0 +1,190 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/events"
+	"github.com/gaia-adm/pumba/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogram_Observe(t *testing.T) {
+	h := NewHistogram([]float64{1, 5})
+	h.Observe(0.5)
+	h.Observe(3)
+	h.Observe(10)
+	assert.Equal(t, []uint64{1, 2}, h.counts)
+	assert.Equal(t, uint64(3), h.Count())
+	assert.Equal(t, 13.5, h.Sum())
+}
+
+func TestRegistry_WriteTo(t *testing.T) {
+	r := NewRegistry(map[string]string{"run-id": "42", "team": `a"b`})
+	r.ObserveShutdown("stop", "web", 1500*time.Millisecond)
+	r.ObserveShutdown("kill", "db", 50*time.Millisecond)
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	assert.NoError(t, err)
+	expected := `# HELP pumba_shutdown_latency_seconds Time between signal sent by Pumba and container exit.
+# TYPE pumba_shutdown_latency_seconds histogram
+pumba_shutdown_latency_seconds_bucket{action="kill",service="db",run_id="42",team="a\"b",le="0.1"} 1
+pumba_shutdown_latency_seconds_bucket{action="kill",service="db",run_id="42",team="a\"b",le="0.5"} 1
+pumba_shutdown_latency_seconds_bucket{action="kill",service="db",run_id="42",team="a\"b",le="1"} 1
+pumba_shutdown_latency_seconds_bucket{action="kill",service="db",run_id="42",team="a\"b",le="2"} 1
+pumba_shutdown_latency_seconds_bucket{action="kill",service="db",run_id="42",team="a\"b",le="5"} 1
+pumba_shutdown_latency_seconds_bucket{action="kill",service="db",run_id="42",team="a\"b",le="10"} 1
+pumba_shutdown_latency_seconds_bucket{action="kill",service="db",run_id="42",team="a\"b",le="30"} 1
+pumba_shutdown_latency_seconds_bucket{action="kill",service="db",run_id="42",team="a\"b",le="60"} 1
+pumba_shutdown_latency_seconds_bucket{action="kill",service="db",run_id="42",team="a\"b",le="+Inf"} 1
+pumba_shutdown_latency_seconds_sum{action="kill",service="db",run_id="42",team="a\"b"} 0.05
+pumba_shutdown_latency_seconds_count{action="kill",service="db",run_id="42",team="a\"b"} 1
+pumba_shutdown_latency_seconds_bucket{action="stop",service="web",run_id="42",team="a\"b",le="0.1"} 0
+pumba_shutdown_latency_seconds_bucket{action="stop",service="web",run_id="42",team="a\"b",le="0.5"} 0
+pumba_shutdown_latency_seconds_bucket{action="stop",service="web",run_id="42",team="a\"b",le="1"} 0
+pumba_shutdown_latency_seconds_bucket{action="stop",service="web",run_id="42",team="a\"b",le="2"} 1
+pumba_shutdown_latency_seconds_bucket{action="stop",service="web",run_id="42",team="a\"b",le="5"} 1
+pumba_shutdown_latency_seconds_bucket{action="stop",service="web",run_id="42",team="a\"b",le="10"} 1
+pumba_shutdown_latency_seconds_bucket{action="stop",service="web",run_id="42",team="a\"b",le="30"} 1
+pumba_shutdown_latency_seconds_bucket{action="stop",service="web",run_id="42",team="a\"b",le="60"} 1
+pumba_shutdown_latency_seconds_bucket{action="stop",service="web",run_id="42",team="a\"b",le="+Inf"} 1
+pumba_shutdown_latency_seconds_sum{action="stop",service="web",run_id="42",team="a\"b"} 1.5
+pumba_shutdown_latency_seconds_count{action="stop",service="web",run_id="42",team="a\"b"} 1
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	r := NewRegistry(nil)
+	r.ObserveShutdown("kill", "web", time.Second)
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, w.Body.String(), `pumba_shutdown_latency_seconds_count{action="kill",service="web"} 1`)
+}
+
+func TestRegistry_WriteAPI(t *testing.T) {
+	r := NewRegistry(map[string]string{"run-id": "42"})
+	r.ObserveAPI("netem", "exec_start", 20*time.Millisecond)
+	r.ObserveAPI("list", "inspect", 3*time.Millisecond)
+	r.ObserveAPI("list", "inspect", 2*time.Second)
+	assert.Equal(t, uint64(2), r.API("list", "inspect").Count())
+	assert.Nil(t, r.API("kill", "kill"))
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	assert.NoError(t, err)
+	expected := `# HELP pumba_shutdown_latency_seconds Time between signal sent by Pumba and container exit.
+# TYPE pumba_shutdown_latency_seconds histogram
+# HELP pumba_docker_api_latency_seconds Duration of Docker API calls made by Pumba actions.
+# TYPE pumba_docker_api_latency_seconds histogram
+pumba_docker_api_latency_seconds_bucket{action="list",call="inspect",run_id="42",le="0.005"} 1
+pumba_docker_api_latency_seconds_bucket{action="list",call="inspect",run_id="42",le="0.01"} 1
+pumba_docker_api_latency_seconds_bucket{action="list",call="inspect",run_id="42",le="0.05"} 1
+pumba_docker_api_latency_seconds_bucket{action="list",call="inspect",run_id="42",le="0.1"} 1
+pumba_docker_api_latency_seconds_bucket{action="list",call="inspect",run_id="42",le="0.5"} 1
+pumba_docker_api_latency_seconds_bucket{action="list",call="inspect",run_id="42",le="1"} 1
+pumba_docker_api_latency_seconds_bucket{action="list",call="inspect",run_id="42",le="5"} 2
+pumba_docker_api_latency_seconds_bucket{action="list",call="inspect",run_id="42",le="10"} 2
+pumba_docker_api_latency_seconds_bucket{action="list",call="inspect",run_id="42",le="+Inf"} 2
+pumba_docker_api_latency_seconds_sum{action="list",call="inspect",run_id="42"} 2.003
+pumba_docker_api_latency_seconds_count{action="list",call="inspect",run_id="42"} 2
+pumba_docker_api_latency_seconds_bucket{action="netem",call="exec_start",run_id="42",le="0.005"} 0
+pumba_docker_api_latency_seconds_bucket{action="netem",call="exec_start",run_id="42",le="0.01"} 0
+pumba_docker_api_latency_seconds_bucket{action="netem",call="exec_start",run_id="42",le="0.05"} 1
+pumba_docker_api_latency_seconds_bucket{action="netem",call="exec_start",run_id="42",le="0.1"} 1
+pumba_docker_api_latency_seconds_bucket{action="netem",call="exec_start",run_id="42",le="0.5"} 1
+pumba_docker_api_latency_seconds_bucket{action="netem",call="exec_start",run_id="42",le="1"} 1
+pumba_docker_api_latency_seconds_bucket{action="netem",call="exec_start",run_id="42",le="5"} 1
+pumba_docker_api_latency_seconds_bucket{action="netem",call="exec_start",run_id="42",le="10"} 1
+pumba_docker_api_latency_seconds_bucket{action="netem",call="exec_start",run_id="42",le="+Inf"} 1
+pumba_docker_api_latency_seconds_sum{action="netem",call="exec_start",run_id="42"} 0.02
+pumba_docker_api_latency_seconds_count{action="netem",call="exec_start",run_id="42"} 1
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestRegistry_Handle(t *testing.T) {
+	c := container.NewMockContainer("abc", "/c1", nil)
+	r := NewRegistry(nil)
+	r.Handle(events.Event{Type: events.Started, Action: "kill", Container: c})
+	r.Handle(events.Event{Type: events.Succeeded, Action: "kill", Container: c})
+	r.Handle(events.Event{Type: events.Succeeded, Action: "kill", Container: c, DryRun: true})
+	r.Handle(events.Event{Type: events.Failed, Action: "kill", Container: c, Err: errors.New("oops")})
+	r.Handle(events.Event{Type: events.Succeeded, Action: "kill", Container: c})
+	assert.Equal(t, uint64(2), r.Actions("kill", "success"))
+	assert.Equal(t, uint64(1), r.Actions("kill", "failure"))
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `# TYPE pumba_chaos_actions_total counter
+pumba_chaos_actions_total{action="kill",result="failure"} 1
+pumba_chaos_actions_total{action="kill",result="success"} 2
+`)
+}
+
+func TestRegistry_Denylist(t *testing.T) {
+	r := NewRegistry(map[string]string{"env": "ci"})
+	r.ObserveDenylisted("netem")
+	r.ObserveDenylisted("netem")
+	r.ObserveDenylisted("cpu")
+	r.DenylistGauge(func() int { return 2 })
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `# TYPE pumba_denylisted_targets gauge
+pumba_denylisted_targets{env="ci"} 2
+`)
+	assert.Contains(t, buf.String(), `pumba_denylisted_total{action="cpu",env="ci"} 1
+pumba_denylisted_total{action="netem",env="ci"} 2
+`)
+}
+
+func TestRegistry_Admission(t *testing.T) {
+	r := NewRegistry(map[string]string{"env": "ci"})
+	r.AdmissionGauge(5, func() int { return 2 })
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `# TYPE pumba_admitted_disruptions gauge
+pumba_admitted_disruptions{env="ci"} 2
+`)
+	assert.Contains(t, buf.String(), `# TYPE pumba_max_active_disruptions gauge
+pumba_max_active_disruptions{env="ci"} 5
+`)
+}
+
+func TestRegistry_Disruptions(t *testing.T) {
+	now := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	r := NewRegistry(nil)
+	r.now = func() time.Time { return now }
+	var disruptions []state.Disruption
+	r.DisruptionsGauge(func() []state.Disruption { return disruptions })
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "# TYPE pumba_active_disruptions gauge\n")
+	assert.NotContains(t, buf.String(), "pumba_disruption_remaining_seconds")
+
+	disruptions = []state.Disruption{
+		{Action: "netem", ContainerName: "/db_1", Until: now.Add(90 * time.Second)},
+		{Action: "netem", ContainerName: "/db_2", Until: now.Add(-time.Second)},
+		{Action: "pause", ContainerName: "/api_1", Until: now.Add(500 * time.Millisecond)},
+	}
+	buf.Reset()
+	_, err = r.WriteTo(&buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `pumba_active_disruptions{action="netem"} 2
+pumba_active_disruptions{action="pause"} 1
+# HELP pumba_disruption_remaining_seconds Remaining duration of active disruption.
+# TYPE pumba_disruption_remaining_seconds gauge
+pumba_disruption_remaining_seconds{action="netem",container="db_1"} 90
+pumba_disruption_remaining_seconds{action="netem",container="db_2"} 0
+pumba_disruption_remaining_seconds{action="pause",container="api_1"} 0.5
+`)
+}
@@ -0,0 +1,94 @@
+package netem
+
+import (
+	"strconv"
+	"strings"
+)
+
+// netem delay safety settings
+const (
+	// netem handle, used to attach child qdisc
+	netemHandle = "10:"
+	// netem rate, that keeps delay jitter in order: adds ~1.2us per 1500-byte packet; fits 32-bit netem rate
+	// (bytes per second) of old iproute2
+	inOrderRate = "10gbit"
+	// auto queue limit: packets per millisecond of maximum delay, set for delays of 1s or more
+	limitPacketsPerMs = 10
+	limitMinDelayMs   = 1000
+)
+
+// delay distributions, supported by netem
+var distributions = map[string]bool{
+	"uniform":      true,
+	"normal":       true,
+	"pareto":       true,
+	"paretonormal": true,
+}
+
+// ValidDistribution returns true if delay distribution is supported by netem
+func ValidDistribution(distribution string) bool {
+	return distributions[distribution]
+}
+
+// Distribution returns netem delay distribution option; empty or 'uniform' - netem default
+func Distribution(distribution string) []string {
+	if distribution == "" || distribution == "uniform" {
+		return nil
+	}
+	return []string{"distribution", distribution}
+}
+
+// Reorder returns netem reorder option: percent of packets sent immediately, others are delayed; 0 - not set
+func Reorder(percent int) []string {
+	if percent <= 0 {
+		return nil
+	}
+	return []string{"reorder", strconv.Itoa(percent) + "%"}
+}
+
+// Limit returns netem queue limit option, in packets; 0 - auto limit for maximum delay (amount + variation)
+// of 1s or more, 10 packets per millisecond of delay (netem default of 1000 packets drops packets at 1000 pps)
+func Limit(packets, amount, variation int) []string {
+	if packets <= 0 {
+		if amount+variation < limitMinDelayMs {
+			return nil
+		}
+		packets = (amount + variation) * limitPacketsPerMs
+	}
+	return []string{"limit", strconv.Itoa(packets)}
+}
+
+// inOrder returns true if netem impairment has delay jitter and does not ask to reorder packets explicitly;
+// netem queue (tfifo) is sorted by send time, so packets with jitter are reordered
+func inOrder(impairment []string) bool {
+	jitter, reorder := false, false
+	for i, arg := range impairment {
+		switch arg {
+		case "delay":
+			// 'delay 100ms 10ms': time after delay amount is jitter
+			jitter = i+2 < len(impairment) && isTime(impairment[i+2])
+		case "reorder":
+			reorder = true
+		}
+	}
+	return jitter && !reorder
+}
+
+// isTime returns true for tc time value, like '10ms', '500us' or '1s'
+func isTime(value string) bool {
+	for _, unit := range []string{"us", "ms", "s"} {
+		if n := strings.TrimSuffix(value, unit); n != value {
+			_, err := strconv.ParseFloat(n, 64)
+			return err == nil
+		}
+	}
+	return false
+}
+
+// inOrderOptions returns netem rate option, that keeps packets with delay jitter in order: with rate set, netem
+// schedules packet not before last packet in its queue. Child qdisc (e.g. pfifo) does not help: it gets packets
+// from netem queue, already reordered. Packets wait for delayed packets sent before them, so delay of later
+// packets grows
+func inOrderOptions() []string {
+	return []string{"rate", inOrderRate}
+}
@@ -0,0 +1,87 @@
+package netem
+
+import (
+	"strconv"
+
+	"github.com/gaia-adm/pumba/teardown"
+)
+
+// packet mark, set by Pumba iptables rules and matched by tc fw filter
+const fwMark = "0x50"
+
+// fwMarkSteps returns tc fw filters, that route marked packets to netem band (removed with root qdisc), and
+// iptables rules, that mark egress packets selected by filter
+func fwMarkSteps(netInterface string, f Filter) []teardown.Step {
+	var steps []teardown.Step
+	for _, protocol := range Families(f) {
+		// 'tc filter add dev eth0 protocol ip parent 1:0 prio 3 handle 0x50 fw flowid 1:3'
+		steps = append(steps, teardown.Step{
+			Name: protocol + " fw filter",
+			Apply: tc("filter", "add", "dev", netInterface, "protocol", protocol, "parent", parentClass, "prio", filterPrios[protocol],
+				"handle", fwMark, "fw", "flowid", filterBand),
+		})
+	}
+	undo := markRules("-D", netInterface, f)
+	for i, rule := range markRules("-A", netInterface, f) {
+		steps = append(steps, teardown.Step{Name: rule[0] + " mark rule", Apply: rule, Undo: undo[i]})
+	}
+	return steps
+}
+
+// markRules returns iptables (ip6tables) rules in mangle OUTPUT chain, that mark packets selected by filter:
+// one rule per destination IP (or family) and protocol;
+// 'iptables -t mangle -A OUTPUT -o eth0 -d 10.0.0.1 -p tcp --dport 5432 -m owner --uid-owner 1000 -m comment --comment pumba -j MARK --set-mark 0x50'
+func markRules(op string, netInterface string, f Filter) [][]string {
+	type dst struct {
+		iptables string
+		ip       string
+		family   int
+	}
+	var dsts []dst
+	if len(f.IPs) == 0 && len(f.Nets) == 0 {
+		dsts = []dst{{"iptables", "", 0}, {"ip6tables", "", 1}}
+	}
+	for _, ip := range f.IPs {
+		if ip.To4() != nil {
+			dsts = append(dsts, dst{"iptables", ip.String(), 0})
+		} else {
+			dsts = append(dsts, dst{"ip6tables", ip.String(), 1})
+		}
+	}
+	for _, n := range f.Nets {
+		if n.IP.To4() != nil {
+			dsts = append(dsts, dst{"iptables", n.String(), 0})
+		} else {
+			dsts = append(dsts, dst{"ip6tables", n.String(), 1})
+		}
+	}
+	// port match requires protocol: mark both TCP and UDP, if protocol is not set
+	protocols := []string{f.Protocol}
+	if f.Protocol == "" && f.Port != 0 {
+		protocols = []string{"tcp", "udp"}
+	}
+	var cmds [][]string
+	for _, d := range dsts {
+		for _, protocol := range protocols {
+			cmd := []string{d.iptables, "-t", "mangle", op, "OUTPUT", "-o", netInterface}
+			if d.ip != "" {
+				cmd = append(cmd, "-d", d.ip)
+			}
+			if protocol != "" {
+				// ip6tables uses 'icmpv6' protocol name
+				if protocol == "icmp" && d.family == 1 {
+					protocol = "icmpv6"
+				}
+				cmd = append(cmd, "-p", protocol)
+			}
+			if f.Port != 0 {
+				cmd = append(cmd, "--dport", strconv.Itoa(f.Port))
+			}
+			if f.UID != "" {
+				cmd = append(cmd, "-m", "owner", "--uid-owner", f.UID)
+			}
+			cmds = append(cmds, append(cmd, "-m", "comment", "--comment", "pumba", "-j", "MARK", "--set-mark", fwMark))
+		}
+	}
+	return cmds
+}
@@ -0,0 +1,58 @@
+package netem
+
+import (
+	"strconv"
+
+	"github.com/gaia-adm/pumba/teardown"
+)
+
+// destination hash buckets: traffic is spread by the last byte of destination IP address
+const hashBuckets = 16
+
+// hash table handles and destination address offsets (of the last 4 bytes) in IPv4 and IPv6 headers
+var hashTables = map[string]struct {
+	handle string
+	offset string
+}{
+	"ip":   {"2:", "16"},
+	"ipv6": {"3:", "36"},
+}
+
+// HashBuckets returns number of destination hash buckets, impaired for percent of destinations
+func HashBuckets(percent int) int {
+	n := (percent*hashBuckets + 50) / 100
+	if n < 1 {
+		n = 1
+	}
+	if n > hashBuckets {
+		n = hashBuckets
+	}
+	return n
+}
+
+// hashSteps returns tc u32 hashing filters, that route traffic to first HashBuckets(f.DstPercent)
+// of destination hash buckets to netem band; other filter matches (protocol, port) select hashed traffic;
+// filters are removed with root qdisc
+func hashSteps(netInterface string, f Filter) []teardown.Step {
+	var steps []teardown.Step
+	for _, m := range matches(f) {
+		ht := hashTables[m.protocol]
+		// hash table is linked by filter of family priority: tables of both families can not share it
+		filter := []string{"filter", "add", "dev", netInterface, "protocol", m.protocol, "parent", parentClass, "prio", filterPrios[m.protocol]}
+		// 'tc filter add dev eth0 protocol ip parent 1:0 prio 3 handle 2: u32 divisor 16'
+		steps = append(steps, teardown.Step{Name: m.protocol + " hash table", Apply: tc(append(filter, "handle", ht.handle, "u32", "divisor", strconv.Itoa(hashBuckets))...)})
+		// 'tc filter add dev eth0 protocol ip parent 1:0 prio 3 u32 match u32 0 0 hashkey mask 0x000000ff at 16 link 2:'
+		args := m.args
+		if len(args) == 0 {
+			args = []string{"match", "u32", "0", "0"}
+		}
+		link := append(append(filter, "u32"), args...)
+		steps = append(steps, teardown.Step{Name: m.protocol + " hash link filter", Apply: tc(append(link, "hashkey", "mask", "0x000000ff", "at", ht.offset, "link", ht.handle)...)})
+		// 'tc filter add dev eth0 protocol ip parent 1:0 prio 3 u32 ht 2:0: match u32 0 0 flowid 1:3'
+		for b := 0; b < HashBuckets(f.DstPercent); b++ {
+			bucket := ht.handle + strconv.FormatInt(int64(b), 16) + ":"
+			steps = append(steps, teardown.Step{Name: m.protocol + " hash bucket filter " + bucket, Apply: tc(append(filter, "u32", "ht", bucket, "match", "u32", "0", "0", "flowid", filterBand)...)})
+		}
+	}
+	return steps
+}
@@ -0,0 +1,54 @@
+package netem
+
+import "strconv"
+
+// netem packet loss models
+const (
+	// LossModelRandom independent random loss with optional correlation
+	LossModelRandom = "random"
+	// LossModelState 4-state Markov loss model with burst and isolated loss states
+	LossModelState = "state"
+	// LossModelGE Gilbert-Elliott loss model: good and bad state with loss probability in each state
+	LossModelGE = "gemodel"
+)
+
+// ValidLossModel returns true if packet loss model is supported by netem
+func ValidLossModel(model string) bool {
+	return model == LossModelRandom || model == LossModelState || model == LossModelGE
+}
+
+// Loss returns netem random loss impairment: percent of lost packets; correlation is optional (0 - not set)
+func Loss(percent, correlation int) []string {
+	args := []string{"loss", strconv.Itoa(percent) + "%"}
+	if correlation > 0 {
+		args = append(args, strconv.Itoa(correlation)+"%")
+	}
+	return args
+}
+
+// LossState returns netem 4-state Markov loss impairment with transition probabilities in percents; p13 is
+// required, trailing zero probabilities are not set (netem defaults: p31 = 100 - p13, p32 = 0, p23 = 100, p14 = 0)
+func LossState(p13, p31, p32, p23, p14 int) []string {
+	return append([]string{"loss", LossModelState}, percents(p13, p31, p32, p23, p14)...)
+}
+
+// LossGEModel returns netem Gilbert-Elliott loss impairment: transition probability p to bad state, transition
+// probability r back to good state, loss probability 1-h in bad state and 1-k in good state, in percents; p is
+// required, trailing zero values are not set (netem defaults: r = 100 - p, 1-h = 100, 1-k = 0)
+func LossGEModel(p, r, oneH, oneK int) []string {
+	return append([]string{"loss", LossModelGE}, percents(p, r, oneH, oneK)...)
+}
+
+// percents formats positional percent values of netem option; trailing zero values are omitted, the first value
+// is always set
+func percents(values ...int) []string {
+	n := len(values)
+	for n > 1 && values[n-1] == 0 {
+		n--
+	}
+	args := make([]string, n)
+	for i, v := range values[:n] {
+		args[i] = strconv.Itoa(v) + "%"
+	}
+	return args
+}
@@ -0,0 +1,463 @@
+package netem
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/gaia-adm/pumba/teardown"
+)
+
+// tc settings for filtered traffic: root prio qdisc with handle 1: and netem on band 3; excluded traffic is routed
+// to band 1 by filters with higher priority
+const (
+	rootHandle  = "1:"
+	filterBand  = "1:3"
+	parentClass = "1:0"
+	excludeBand = "1:1"
+)
+
+// filterPrios priorities of netem band filters by protocol family: kernel rejects filter with priority of filter of
+// other protocol ('Protocol mismatch for filter with specified priority'), so IPv4 and IPv6 filters can not share it
+var filterPrios = map[string]string{"ip": "3", "ipv6": "4"}
+
+// excludePrios priorities of exclude filters by protocol family, higher than priorities of netem band filters
+var excludePrios = map[string]string{"ip": "1", "ipv6": "2"}
+
+// Filter selects network traffic for netem impairment; empty Filter selects all traffic
+type Filter struct {
+	// IPs destination IP addresses, IPv4 or IPv6; traffic to any of them is selected
+	IPs []net.IP
+	// Nets destination CIDR ranges, IPv4 or IPv6; traffic to any of them (or to IPs) is selected
+	Nets []*net.IPNet
+	// Hosts destination host names, resolved by Pumba (and re-resolved during netem) into IPs
+	Hosts []string
+	// Port destination TCP/UDP port; 0 - any port
+	Port int
+	// Protocol IP protocol: tcp, udp or icmp; empty - any protocol
+	Protocol string
+	// FwMark select traffic with iptables packet mark (see fwmark.go) instead of u32 matches;
+	// allows to select connections by socket owner (UID)
+	FwMark bool
+	// UID socket owner user (ID or name); FwMark mode only
+	UID string
+	// DstPercent percent of destinations (by destination IP hash bucket) to select; 0 - all destinations;
+	// not used with IPs and FwMark
+	DstPercent int
+	// Exclude destination IPs (host ranges) and CIDR ranges, IPv4 or IPv6; traffic to them bypasses netem,
+	// even if selected by other filter fields
+	Exclude []*net.IPNet
+}
+
+// String returns filter description, e.g. 'dst 10.0.0.1,fd00::1,10.1.0.0/16 host db.local protocol udp dport 53
+// exclude 127.0.0.11/32'
+func (f Filter) String() string {
+	var parts []string
+	if len(f.IPs) > 0 || len(f.Nets) > 0 {
+		var dsts []string
+		for _, ip := range f.IPs {
+			dsts = append(dsts, ip.String())
+		}
+		for _, n := range f.Nets {
+			dsts = append(dsts, n.String())
+		}
+		parts = append(parts, "dst "+strings.Join(dsts, ","))
+	}
+	if len(f.Hosts) > 0 {
+		parts = append(parts, "host "+strings.Join(f.Hosts, ","))
+	}
+	if f.Protocol != "" {
+		parts = append(parts, "protocol "+f.Protocol)
+	}
+	if f.Port != 0 {
+		parts = append(parts, "dport "+strconv.Itoa(f.Port))
+	}
+	if f.UID != "" {
+		parts = append(parts, "uid "+f.UID)
+	}
+	if f.FwMark {
+		parts = append(parts, "(fwmark)")
+	}
+	if f.DstPercent > 0 {
+		parts = append(parts, "dst-percent "+strconv.Itoa(f.DstPercent))
+	}
+	if len(f.Exclude) > 0 {
+		var excludes []string
+		for _, n := range f.Exclude {
+			excludes = append(excludes, n.String())
+		}
+		parts = append(parts, "exclude "+strings.Join(excludes, ","))
+	}
+	return strings.Join(parts, " ")
+}
+
+// IP protocol numbers for u32 'protocol' match: IPv4 and IPv6 (ICMPv6 for icmp)
+var protocols = map[string][2]int{
+	"tcp":  {6, 6},
+	"udp":  {17, 17},
+	"icmp": {1, 58},
+}
+
+// ValidProtocol returns true if protocol is supported by Filter
+func ValidProtocol(protocol string) bool {
+	_, ok := protocols[protocol]
+	return ok
+}
+
+// IsEmpty returns true if filter selects all traffic
+func (f Filter) IsEmpty() bool {
+	return len(f.IPs) == 0 && len(f.Nets) == 0 && len(f.Hosts) == 0 && f.Port == 0 && f.Protocol == "" && !f.FwMark && f.DstPercent == 0 && len(f.Exclude) == 0
+}
+
+// Delay returns netem delay impairment; variation and correlation are optional (0 - not set)
+func Delay(amount, variation, correlation int) []string {
+	args := []string{"delay", strconv.Itoa(amount) + "ms"}
+	if variation > 0 {
+		args = append(args, strconv.Itoa(variation)+"ms")
+		if correlation > 0 {
+			args = append(args, strconv.Itoa(correlation)+"%")
+		}
+	}
+	return args
+}
+
+// Corrupt returns netem corrupt impairment: random single-bit error in percent of packets; correlation is optional
+// (0 - not set)
+func Corrupt(percent, correlation int) []string {
+	args := []string{"corrupt", strconv.Itoa(percent) + "%"}
+	if correlation > 0 {
+		args = append(args, strconv.Itoa(correlation)+"%")
+	}
+	return args
+}
+
+// Impairment returns tc arguments of netem command (e.g. 'delay 100ms 10ms')
+func Impairment(netemCmd string) []string {
+	return strings.Fields(strings.ToLower(netemCmd))
+}
+
+// StartCommands returns tc commands, that apply netem impairment (e.g. 'delay 100ms') to egress traffic
+// of network interface, selected by filter; delay jitter is applied in order, unless impairment sets 'reorder'
+func StartCommands(netInterface string, impairment []string, f Filter) [][]string {
+	return Pipeline(netInterface, impairment, f).ApplyCommands()
+}
+
+// StopCommands returns commands, that remove netem impairment, applied with StartCommands, in reverse order
+func StopCommands(netInterface string, f Filter) [][]string {
+	return Pipeline(netInterface, nil, f).TeardownCommands()
+}
+
+// Pipeline returns named setup steps of netem impairment of network interface, selected by filter: qdiscs,
+// tc filters and iptables mark rules; steps without undo command are removed with root qdisc
+func Pipeline(netInterface string, impairment []string, f Filter) teardown.Pipeline {
+	p := teardown.Pipeline{Name: "netem on " + netInterface}
+	// bandwidth throttle is tbf child qdisc, attached to netem handle
+	impairment, throttle := splitThrottle(impairment)
+	var child *teardown.Step
+	netemArgs := []string{"netem"}
+	if throttle != nil {
+		child = &teardown.Step{Name: "tbf throttle qdisc", Apply: throttleCommand(netInterface, throttle)}
+		netemArgs = []string{"handle", netemHandle, "netem"}
+	}
+	netemArgs = append(netemArgs, impairment...)
+	// delay jitter reorders packets: keep order with netem rate
+	if inOrder(impairment) {
+		netemArgs = append(netemArgs, inOrderOptions()...)
+	}
+	// root qdisc is replaced, not added: 'add' fails, when netem of previous run is left on interface
+	if f.IsEmpty() {
+		// 'tc qdisc replace dev eth0 root netem delay 100ms'
+		// http://www.linuxfoundation.org/collaborate/workgroups/networking/netem
+		p.Steps = append(p.Steps, teardown.Step{
+			Name:  "root netem qdisc",
+			Apply: tc(append([]string{"qdisc", "replace", "dev", netInterface, "root"}, netemArgs...)...),
+			Undo:  tc("qdisc", "del", "dev", netInterface, "root", "netem"),
+		})
+		if child != nil {
+			p.Steps = append(p.Steps, *child)
+		}
+		return p
+	}
+	// to filter traffic, create a priority scheduling, apply netem on low priority band only,
+	// and route selected traffic to this band; removing root prio qdisc removes netem and filters too
+	// See more: http://stuff.onse.fi/man?program=tc, http://stuff.onse.fi/man?program=tc-u32
+	p.Steps = append(p.Steps,
+		teardown.Step{
+			Name:  "root prio qdisc",
+			Apply: tc("qdisc", "replace", "dev", netInterface, "root", "handle", rootHandle, "prio"),
+			Undo:  tc("qdisc", "del", "dev", netInterface, "root", "handle", rootHandle, "prio"),
+		},
+		teardown.Step{Name: "netem qdisc", Apply: tc(append([]string{"qdisc", "add", "dev", netInterface, "parent", filterBand}, netemArgs...)...)},
+	)
+	if child != nil {
+		p.Steps = append(p.Steps, *child)
+	}
+	p.Steps = append(p.Steps, excludeSteps(netInterface, f)...)
+	if f.FwMark {
+		p.Steps = append(p.Steps, fwMarkSteps(netInterface, f)...)
+		return p
+	}
+	if f.DstPercent > 0 {
+		p.Steps = append(p.Steps, hashSteps(netInterface, f)...)
+		return p
+	}
+	// 'tc filter add dev <netInterface> protocol ip parent 1:0 prio 3 u32 match ip dst <targetIP>/32 flowid 1:3'
+	for _, match := range matches(f) {
+		cmd := []string{"filter", "add", "dev", netInterface, "protocol", match.protocol, "parent", parentClass, "prio", filterPrios[match.protocol], "u32"}
+		// exclude only filter selects all other traffic
+		if len(match.args) == 0 {
+			cmd = append(cmd, "match", "u32", "0", "0")
+		}
+		cmd = append(cmd, match.args...)
+		p.Steps = append(p.Steps, teardown.Step{Name: match.protocol + " u32 filter", Apply: tc(append(cmd, "flowid", filterBand)...)})
+	}
+	return p
+}
+
+// excludeSteps returns tc u32 filters, that route traffic to excluded destinations to band 1 (without netem) before
+// filters of netem band are matched; filters are removed with root qdisc
+func excludeSteps(netInterface string, f Filter) []teardown.Step {
+	var steps []teardown.Step
+	for _, n := range f.Exclude {
+		protocol, sel := "ip", "ip"
+		if n.IP.To4() == nil {
+			protocol, sel = "ipv6", "ip6"
+		}
+		// 'tc filter add dev eth0 protocol ip parent 1:0 prio 1 u32 match ip dst 127.0.0.11/32 flowid 1:1'
+		steps = append(steps, teardown.Step{
+			Name: protocol + " exclude filter " + n.String(),
+			Apply: tc("filter", "add", "dev", netInterface, "protocol", protocol, "parent", parentClass, "prio", excludePrios[protocol],
+				"u32", "match", sel, "dst", n.String(), "flowid", excludeBand),
+		})
+	}
+	return steps
+}
+
+type u32Match struct {
+	protocol string
+	args     []string
+}
+
+// matches returns u32 matches for filter: single match per IP address and CIDR range, IPv4 and IPv6 matches for
+// port/protocol only filter
+func matches(f Filter) []u32Match {
+	if len(f.IPs) == 0 && len(f.Nets) == 0 {
+		return []u32Match{match("", f, false), match("", f, true)}
+	}
+	ms := make([]u32Match, 0, len(f.IPs)+len(f.Nets))
+	for _, ip := range f.IPs {
+		ipv6 := ip.To4() == nil
+		mask := "/32"
+		if ipv6 {
+			mask = "/128"
+		}
+		ms = append(ms, match(ip.String()+mask, f, ipv6))
+	}
+	for _, n := range f.Nets {
+		ms = append(ms, match(n.String(), f, n.IP.To4() == nil))
+	}
+	return ms
+}
+
+// match returns u32 match for destination CIDR (empty - any), protocol and port of filter
+func match(dst string, f Filter, ipv6 bool) u32Match {
+	m := u32Match{protocol: "ip"}
+	sel, family := "ip", 0
+	if ipv6 {
+		m.protocol = "ipv6"
+		sel, family = "ip6", 1
+	}
+	if dst != "" {
+		m.args = append(m.args, "match", sel, "dst", dst)
+	}
+	if f.Protocol != "" {
+		m.args = append(m.args, "match", sel, "protocol", strconv.Itoa(protocols[f.Protocol][family]), "0xff")
+	}
+	if f.Port != 0 {
+		m.args = append(m.args, "match", sel, "dport", strconv.Itoa(f.Port), "0xffff")
+	}
+	return m
+}
+
+func tc(args ...string) []string {
+	return append([]string{"tc"}, args...)
+}
+
+// ShowCommand returns tc command, that lists qdiscs of network interface
+func ShowCommand(netInterface string) []string {
+	return tc("qdisc", "show", "dev", netInterface)
+}
+
+// ClearCommand returns tc command, that removes root qdisc of network interface with its child qdiscs and filters
+func ClearCommand(netInterface string) []string {
+	return tc("qdisc", "del", "dev", netInterface, "root")
+}
+
+// Lingering checks 'tc qdisc show' output for qdisc left by StartCommands after StopCommands
+func Lingering(output string, f Filter) bool {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "qdisc" {
+			continue
+		}
+		// 'qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms' or 'qdisc prio 1: root refcnt 2 bands 3 ...'
+		if f.IsEmpty() && fields[1] == "netem" && strings.Contains(line, " root ") {
+			return true
+		}
+		if !f.IsEmpty() && fields[1] == "prio" && fields[2] == rootHandle {
+			return true
+		}
+	}
+	return false
+}
+
+// Applied checks 'tc qdisc show' output for qdiscs added by StartCommands: root netem qdisc or, with filter, root prio
+// qdisc and netem qdisc on its filtered band
+func Applied(output string, f Filter) bool {
+	prio, band := false, false
+	for _, line := range strings.Split(output, "\n") {
+		// 'qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms' or 'qdisc netem 10: parent 1:3 limit 1000 ...'
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "qdisc" {
+			continue
+		}
+		switch {
+		case f.IsEmpty() && fields[1] == "netem" && fields[3] == "root":
+			return true
+		case fields[1] == "prio" && fields[2] == rootHandle && fields[3] == "root":
+			prio = true
+		case fields[1] == "netem" && fields[3] == "parent" && len(fields) > 4 && fields[4] == filterBand:
+			band = true
+		}
+	}
+	return !f.IsEmpty() && prio && band
+}
+
+// Leftovers returns qdiscs of 'tc qdisc show' output, that are added by StartCommands: netem qdiscs (root or child)
+// and root prio qdisc of filters; empty - network interface is clean
+func Leftovers(output string) []string {
+	var qdiscs []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[0] != "qdisc" {
+			continue
+		}
+		if fields[1] == "netem" || (fields[1] == "prio" && fields[2] == rootHandle) {
+			qdiscs = append(qdiscs, strings.Join(fields, " "))
+		}
+	}
+	return qdiscs
+}
+
+// defaultQdiscs root qdiscs, set up by kernel for network interface; netem replaces them, and they are restored, when
+// netem is removed
+var defaultQdiscs = map[string]bool{"noqueue": true, "pfifo_fast": true, "mq": true, "fq_codel": true, "fq": true}
+
+// ForeignRoot returns root qdisc of 'tc qdisc show' output, that is neither default qdisc nor added by StartCommands
+// (e.g. traffic shaping of container), so netem can not replace it safely; empty - netem can be applied
+func ForeignRoot(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || fields[0] != "qdisc" || fields[3] != "root" {
+			continue
+		}
+		if defaultQdiscs[fields[1]] || fields[1] == "netem" || (fields[1] == "prio" && fields[2] == rootHandle) {
+			return ""
+		}
+		return strings.Join(fields, " ")
+	}
+	return ""
+}
+
+// FilterShowCommand returns tc command, that lists filters of network interface
+func FilterShowCommand(netInterface string) []string {
+	return tc("filter", "show", "dev", netInterface)
+}
+
+// Families returns protocol families ('ip' for IPv4, 'ipv6' for IPv6), that filter selects traffic for
+func Families(f Filter) []string {
+	var families []string
+	seen := map[string]bool{}
+	for _, m := range matches(f) {
+		if !seen[m.protocol] {
+			seen[m.protocol] = true
+			families = append(families, m.protocol)
+		}
+	}
+	return families
+}
+
+// FilterFamilies returns protocol families of filters, directing traffic to netem band, in 'tc filter show' output
+func FilterFamilies(output string) []string {
+	var families []string
+	seen := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		// 'filter parent 1: protocol ip pref 3 u32 chain 0 fh 800::800 order 2048 key ht 800 bkt 0 *flowid 1:3 not_in_hw'
+		// or 'filter parent 1: protocol ipv6 pref 4 fw chain 0 handle 0x50 classid 1:3'
+		fields := strings.Fields(line)
+		if len(fields) < 5 || fields[0] != "filter" || fields[3] != "protocol" ||
+			!(strings.Contains(line, "flowid "+filterBand) || strings.Contains(line, "classid "+filterBand)) {
+			continue
+		}
+		if family := fields[4]; !seen[family] {
+			seen[family] = true
+			families = append(families, family)
+		}
+	}
+	return families
+}
+
+// AddrCommand returns command, that lists network interface addresses, one line per address
+func AddrCommand() []string {
+	return []string{"ip", "-o", "addr", "show"}
+}
+
+// LinkCommand returns command, that lists network interfaces, one line per interface
+func LinkCommand() []string {
+	return []string{"ip", "-o", "link", "show"}
+}
+
+// Interfaces returns names of network interfaces, that are up, except loopback, in 'ip -o link show' output
+func Interfaces(output string) []string {
+	var names []string
+	for _, line := range strings.Split(output, "\n") {
+		// '12: eth0@if13: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc noqueue state UP mode DEFAULT ...'
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !strings.HasSuffix(fields[0], ":") || !strings.HasPrefix(fields[2], "<") {
+			continue
+		}
+		up, loopback := false, false
+		for _, flag := range strings.Split(strings.Trim(fields[2], "<>"), ",") {
+			switch flag {
+			case "UP":
+				up = true
+			case "LOOPBACK":
+				loopback = true
+			}
+		}
+		if !up || loopback {
+			continue
+		}
+		// veth devices are shown with peer link index: 'eth0@if13:'
+		names = append(names, strings.SplitN(strings.TrimSuffix(fields[1], ":"), "@", 2)[0])
+	}
+	return names
+}
+
+// InterfaceByIP finds network interface with specified address in 'ip -o addr show' output;
+// returns empty string if not found
+func InterfaceByIP(output string, ip net.IP) string {
+	for _, line := range strings.Split(output, "\n") {
+		// '12: eth1@if13    inet 10.0.9.3/24 scope global eth1\       valid_lft forever preferred_lft forever'
+		fields := strings.Fields(line)
+		if len(fields) < 4 || (fields[2] != "inet" && fields[2] != "inet6") {
+			continue
+		}
+		addr := net.ParseIP(strings.SplitN(fields[3], "/", 2)[0])
+		if addr != nil && addr.Equal(ip) {
+			// veth/macvlan devices are shown with peer link index: 'eth1@if13'
+			return strings.SplitN(fields[1], "@", 2)[0]
+		}
+	}
+	return ""
+}
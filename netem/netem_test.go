@@ -0,0 +1,382 @@
+package netem
+
+import (
+	"flag"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// golden compares commands (one command per line) with testdata/<name>.golden file
+func golden(t *testing.T, name string, cmds [][]string) {
+	lines := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		lines[i] = strings.Join(cmd, " ")
+	}
+	actual := strings.Join(lines, "\n") + "\n"
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := ioutil.WriteFile(path, []byte(actual), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(expected), actual, name)
+}
+
+func TestDelay(t *testing.T) {
+	assert.Equal(t, []string{"delay", "100ms"}, Delay(100, 0, 0))
+	assert.Equal(t, []string{"delay", "100ms", "10ms"}, Delay(100, 10, 0))
+	assert.Equal(t, []string{"delay", "100ms", "10ms", "20%"}, Delay(100, 10, 20))
+	// correlation requires variation
+	assert.Equal(t, []string{"delay", "100ms"}, Delay(100, 0, 20))
+}
+
+func TestCorrupt(t *testing.T) {
+	assert.Equal(t, []string{"corrupt", "5%"}, Corrupt(5, 0))
+	assert.Equal(t, []string{"corrupt", "5%", "25%"}, Corrupt(5, 25))
+	// corrupt impairment has no jitter: netem queue keeps packet order
+	assert.False(t, inOrder(Corrupt(5, 25)))
+}
+
+func TestLoss(t *testing.T) {
+	assert.Equal(t, []string{"loss", "5%"}, Loss(5, 0))
+	assert.Equal(t, []string{"loss", "5%", "25%"}, Loss(5, 25))
+	assert.Equal(t, []string{"loss", "state", "2%"}, LossState(2, 0, 0, 0, 0))
+	assert.Equal(t, []string{"loss", "state", "2%", "0%", "0%", "0%", "1%"}, LossState(2, 0, 0, 0, 1))
+	assert.Equal(t, []string{"loss", "gemodel", "1%", "25%"}, LossGEModel(1, 25, 0, 0))
+	assert.Equal(t, []string{"loss", "gemodel", "1%", "25%", "80%", "2%"}, LossGEModel(1, 25, 80, 2))
+	assert.True(t, ValidLossModel("gemodel"))
+	assert.False(t, ValidLossModel("bernoulli"))
+}
+
+func TestImpairment(t *testing.T) {
+	assert.Equal(t, []string{"delay", "100ms", "10ms"}, Impairment("delay 100ms 10ms"))
+	assert.Equal(t, []string{"loss", "10%"}, Impairment("LOSS 10%"))
+}
+
+func TestDelayOptions(t *testing.T) {
+	assert.Nil(t, Distribution(""))
+	assert.Nil(t, Distribution("uniform"))
+	assert.Equal(t, []string{"distribution", "pareto"}, Distribution("pareto"))
+	assert.Nil(t, Reorder(0))
+	assert.Equal(t, []string{"reorder", "25%"}, Reorder(25))
+	assert.Equal(t, []string{"limit", "500"}, Limit(500, 2000, 0))
+	// auto limit: 10 packets per millisecond of maximum delay of 1s or more
+	assert.Nil(t, Limit(0, 900, 99))
+	assert.Equal(t, []string{"limit", "10000"}, Limit(0, 900, 100))
+	assert.Equal(t, []string{"limit", "25000"}, Limit(0, 2000, 500))
+	assert.True(t, ValidDistribution("paretonormal"))
+	assert.False(t, ValidDistribution("gauss"))
+}
+
+func TestInOrder(t *testing.T) {
+	assert.False(t, inOrder(Delay(100, 0, 0)))
+	assert.True(t, inOrder(Delay(100, 10, 0)))
+	assert.True(t, inOrder(Delay(0, 50, 20)))
+	assert.False(t, inOrder(append(Delay(100, 10, 0), Reorder(25)...)))
+	assert.False(t, inOrder([]string{"loss", "10%"}))
+	// jitter is kept in order by netem rate, not by child qdisc: child gets packets, already reordered by netem
+	assert.Equal(t, [][]string{{"tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "100ms", "10ms",
+		"rate", "10gbit"}}, StartCommands("eth0", Delay(100, 10, 0), Filter{}))
+	// explicit reorder: no rate
+	assert.Equal(t, [][]string{{"tc", "qdisc", "replace", "dev", "eth0", "root", "netem", "delay", "100ms", "10ms",
+		"reorder", "25%"}}, StartCommands("eth0", append(Delay(100, 10, 0), Reorder(25)...), Filter{}))
+	// throttle child qdisc does not keep order either
+	assert.Equal(t, [][]string{
+		{"tc", "qdisc", "replace", "dev", "eth0", "root", "handle", "10:", "netem", "delay", "100ms", "10ms", "rate", "10gbit"},
+		{"tc", "qdisc", "add", "dev", "eth0", "parent", "10:1", "tbf", "rate", "1mbit", "burst", "32kbit", "latency", "400ms"},
+	}, StartCommands("eth0", append(Delay(100, 10, 0), Throttle("1mbit", "32kbit", 400)...), Filter{}))
+}
+
+func TestThrottle(t *testing.T) {
+	assert.Equal(t, []string{"tbf", "rate", "1mbit", "burst", "32kbit", "latency", "400ms"}, Throttle("1mbit", "32kbit", 400))
+	for _, rate := range []string{"1mbit", "500kbit", "1.5mbit", "10mbps", "100bit"} {
+		assert.True(t, ValidRate(rate), rate)
+	}
+	for _, rate := range []string{"", "1", "1 mbit", "fast", "-1mbit"} {
+		assert.False(t, ValidRate(rate), rate)
+	}
+	for _, size := range []string{"1540", "32kbit", "10kb", "1mb", "2k"} {
+		assert.True(t, ValidSize(size), size)
+	}
+	for _, size := range []string{"", "32kbps", "big"} {
+		assert.False(t, ValidSize(size), size)
+	}
+	netemArgs, throttle := splitThrottle(append(Delay(100, 0, 0), Throttle("1mbit", "32kbit", 400)...))
+	assert.Equal(t, Delay(100, 0, 0), netemArgs)
+	assert.Equal(t, Throttle("1mbit", "32kbit", 400), throttle)
+}
+
+func TestCommands_Golden(t *testing.T) {
+	impairments := map[string][]string{
+		"delay":             Delay(100, 0, 0),
+		"delay-variation":   Delay(100, 10, 0),
+		"delay-correlation": Delay(100, 10, 20),
+		"jitter-normal":     append(Delay(0, 50, 0), Distribution("normal")...),
+		"delay-reorder":     append(Delay(100, 10, 0), Reorder(25)...),
+		"throttle":          Throttle("1mbit", "32kbit", 400),
+		"delay-throttle":    append(Delay(100, 10, 0), Throttle("512kbit", "16kb", 100)...),
+	}
+	filters := map[string]Filter{
+		"all":              {},
+		"ipv4":             {IPs: []net.IP{net.ParseIP("10.10.0.1")}},
+		"ipv6":             {IPs: []net.IP{net.ParseIP("fd00::1")}},
+		"port":             {Port: 8080},
+		"ipv4-port":        {IPs: []net.IP{net.ParseIP("10.10.0.1")}, Port: 8080},
+		"ipv6-port":        {IPs: []net.IP{net.ParseIP("fd00::1")}, Port: 8080},
+		"udp":              {Protocol: "udp"},
+		"ipv4-icmp":        {IPs: []net.IP{net.ParseIP("10.10.0.1")}, Protocol: "icmp"},
+		"ipv6-icmp":        {IPs: []net.IP{net.ParseIP("fd00::1")}, Protocol: "icmp"},
+		"tcp-port":         {Protocol: "tcp", Port: 8080},
+		"fwmark-uid":       {FwMark: true, UID: "1000"},
+		"fwmark-ipv4-port": {FwMark: true, IPs: []net.IP{net.ParseIP("10.10.0.1")}, Port: 5432},
+		"fwmark-ipv6-icmp": {FwMark: true, IPs: []net.IP{net.ParseIP("fd00::1")}, Protocol: "icmp"},
+		"multi":            {IPs: []net.IP{net.ParseIP("10.10.0.1"), net.ParseIP("fd00::1"), net.ParseIP("10.10.0.2")}},
+		"dst-percent":      {DstPercent: 50},
+		"tcp-port-percent": {Protocol: "tcp", Port: 8080, DstPercent: 25},
+		"cidr":             {IPs: []net.IP{net.ParseIP("10.10.0.1")}, Nets: []*net.IPNet{testNet("10.20.0.0/16"), testNet("fd00:1::/64")}},
+		"fwmark-cidr-port": {FwMark: true, Nets: []*net.IPNet{testNet("10.20.0.0/16"), testNet("fd00:1::/64")}, Port: 5432},
+		"exclude":          {Exclude: []*net.IPNet{testNet("127.0.0.11/32"), testNet("fd00:2::/64")}},
+		"cidr-exclude":     {Nets: []*net.IPNet{testNet("10.20.0.0/16")}, Exclude: []*net.IPNet{testNet("10.20.0.10/32")}},
+	}
+	for iname, impairment := range impairments {
+		for fname, filter := range filters {
+			golden(t, iname+"-"+fname+".start", StartCommands("eth0", impairment, filter))
+		}
+	}
+	for fname, filter := range filters {
+		golden(t, fname+".stop", StopCommands("eth0", filter))
+	}
+}
+
+// testNet parses CIDR range of test filter
+func testNet(cidr string) *net.IPNet {
+	_, n, _ := net.ParseCIDR(cidr)
+	return n
+}
+
+func TestFilter_IsEmpty(t *testing.T) {
+	assert.True(t, Filter{}.IsEmpty())
+	assert.False(t, Filter{Nets: []*net.IPNet{testNet("10.0.0.0/8")}}.IsEmpty())
+	assert.False(t, Filter{Port: 80}.IsEmpty())
+	assert.False(t, Filter{Protocol: "udp"}.IsEmpty())
+	assert.False(t, Filter{FwMark: true}.IsEmpty())
+	assert.False(t, Filter{DstPercent: 50}.IsEmpty())
+	assert.False(t, Filter{IPs: []net.IP{net.ParseIP("10.0.0.1")}}.IsEmpty())
+	assert.False(t, Filter{Exclude: []*net.IPNet{testNet("127.0.0.11/32")}}.IsEmpty())
+}
+
+func TestLingering(t *testing.T) {
+	clean := "qdisc noqueue 0: root refcnt 2 \r\n"
+	rootNetem := "qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms\r\n"
+	prio := "qdisc prio 1: root refcnt 2 bands 3 priomap  1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1\n" +
+		"qdisc netem 8002: parent 1:3 limit 1000 delay 100.0ms\n"
+	ipFilter := Filter{IPs: []net.IP{net.ParseIP("10.0.0.1")}}
+	assert.False(t, Lingering(clean, Filter{}))
+	assert.False(t, Lingering(clean, ipFilter))
+	assert.True(t, Lingering(rootNetem, Filter{}))
+	assert.True(t, Lingering(prio, ipFilter))
+	// netem under prio band is not a root netem
+	assert.False(t, Lingering(prio, Filter{}))
+	assert.False(t, Lingering("", Filter{}))
+}
+
+func TestLeftovers(t *testing.T) {
+	assert.Empty(t, Leftovers("qdisc noqueue 0: root refcnt 2 \r\n"))
+	assert.Empty(t, Leftovers(""))
+	assert.Equal(t, []string{"qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms"}, Leftovers("qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms\r\n"))
+	prio := "qdisc prio 1: root refcnt 2 bands 3 priomap  1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1\n" +
+		"qdisc netem 8002: parent 1:3 limit 1000 delay 100.0ms\n" +
+		"qdisc pfifo_fast 0: parent 1:1 bands 3\n"
+	assert.Equal(t, []string{
+		"qdisc prio 1: root refcnt 2 bands 3 priomap 1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1",
+		"qdisc netem 8002: parent 1:3 limit 1000 delay 100.0ms",
+	}, Leftovers(prio))
+}
+
+func TestApplied(t *testing.T) {
+	filter := Filter{Protocol: "tcp"}
+	root := "qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms\r\n"
+	prio := "qdisc prio 1: root refcnt 2 bands 3 priomap  1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1\n" +
+		"qdisc netem 10: parent 1:3 limit 1000 delay 100.0ms\n" +
+		"qdisc pfifo 8003: parent 10:1 limit 1000p\n"
+	assert.True(t, Applied(root, Filter{}))
+	assert.True(t, Applied(prio, filter))
+	assert.False(t, Applied(root, filter))
+	assert.False(t, Applied(prio, Filter{}))
+	// failed 'tc' command leaves interface as is
+	assert.False(t, Applied("qdisc noqueue 0: root refcnt 2 \r\n", Filter{}))
+	assert.False(t, Applied("OCI runtime exec failed: exec: \"tc\": executable file not found in $PATH\r\n", Filter{}))
+	// prio qdisc without netem on filtered band
+	assert.False(t, Applied("qdisc prio 1: root refcnt 2 bands 3\nqdisc pfifo_fast 0: parent 1:3 bands 3\n", filter))
+}
+
+func TestForeignRoot(t *testing.T) {
+	for _, output := range []string{
+		"",
+		"qdisc noqueue 0: root refcnt 2 \r\n",
+		"qdisc mq 0: root\nqdisc fq_codel 0: parent :1 limit 10240p\n",
+		"qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms\r\n",
+		"qdisc prio 1: root refcnt 2 bands 3 priomap  1 2 2 2 1 2 0 0 1 1 1 1 1 1 1 1\nqdisc netem 8002: parent 1:3 limit 1000\n",
+		"qdisc ingress ffff: parent ffff:fff1 ----------------\nqdisc noqueue 0: root refcnt 2\n",
+	} {
+		assert.Equal(t, "", ForeignRoot(output), output)
+	}
+	assert.Equal(t, "qdisc htb 1: root refcnt 2 r2q 10 default 0x10", ForeignRoot("qdisc htb 1: root refcnt 2 r2q 10 default 0x10 \r\n"))
+	assert.Equal(t, "qdisc prio 5: root refcnt 2 bands 3", ForeignRoot("qdisc prio 5: root refcnt 2 bands 3\n"))
+}
+
+func TestShowCommand(t *testing.T) {
+	assert.Equal(t, []string{"tc", "qdisc", "show", "dev", "eth0"}, ShowCommand("eth0"))
+	assert.Equal(t, []string{"tc", "qdisc", "del", "dev", "eth0", "root"}, ClearCommand("eth0"))
+}
+
+func TestFilter_String(t *testing.T) {
+	assert.Equal(t, "", Filter{}.String())
+	assert.Equal(t, "dst 10.0.0.1,fd00::1 protocol udp dport 53", Filter{IPs: []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("fd00::1")}, Protocol: "udp", Port: 53}.String())
+	assert.Equal(t, "dst 10.0.0.1,10.1.0.0/16", Filter{IPs: []net.IP{net.ParseIP("10.0.0.1")}, Nets: []*net.IPNet{testNet("10.1.0.0/16")}}.String())
+	assert.Equal(t, "protocol tcp dst-percent 50", Filter{Protocol: "tcp", DstPercent: 50}.String())
+	assert.Equal(t, "dport 80 exclude 127.0.0.11/32,10.96.0.0/12", Filter{Port: 80, Exclude: []*net.IPNet{testNet("127.0.0.11/32"), testNet("10.96.0.0/12")}}.String())
+}
+
+func TestHashBuckets(t *testing.T) {
+	assert.Equal(t, 8, HashBuckets(50))
+	assert.Equal(t, 4, HashBuckets(25))
+	assert.Equal(t, 16, HashBuckets(100))
+	// at least one bucket
+	assert.Equal(t, 1, HashBuckets(1))
+	assert.Equal(t, 2, HashBuckets(10))
+}
+
+func TestValidProtocol(t *testing.T) {
+	assert.True(t, ValidProtocol("tcp"))
+	assert.True(t, ValidProtocol("udp"))
+	assert.True(t, ValidProtocol("icmp"))
+	assert.False(t, ValidProtocol("sctp"))
+	assert.False(t, ValidProtocol(""))
+}
+
+func TestFamilies(t *testing.T) {
+	assert.Equal(t, []string{"ip"}, Families(Filter{IPs: []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2")}}))
+	assert.Equal(t, []string{"ip", "ipv6"}, Families(Filter{IPs: []net.IP{net.ParseIP("10.0.0.1"), net.ParseIP("fd00::1")}}))
+	assert.Equal(t, []string{"ipv6"}, Families(Filter{Nets: []*net.IPNet{testNet("fd00::/64")}}))
+	assert.Equal(t, []string{"ip", "ipv6"}, Families(Filter{Port: 53}))
+}
+
+func TestFilterFamilies(t *testing.T) {
+	// 'tc filter show' output of IPv4 and IPv6 target filters
+	output := "filter parent 1: protocol ip pref 3 u32 chain 0 \n" +
+		"filter parent 1: protocol ip pref 3 u32 chain 0 fh 800: ht divisor 1 \n" +
+		"filter parent 1: protocol ip pref 3 u32 chain 0 fh 800::800 order 2048 key ht 800 bkt 0 *flowid 1:3 not_in_hw \n" +
+		"  match 0a000001/ffffffff at 16\n" +
+		"filter parent 1: protocol ipv6 pref 4 u32 chain 0 \n" +
+		"filter parent 1: protocol ipv6 pref 4 u32 chain 0 fh 801: ht divisor 1 \n" +
+		"filter parent 1: protocol ipv6 pref 4 u32 chain 0 fh 801::800 order 2048 key ht 801 bkt 0 *flowid 1:3 not_in_hw \n" +
+		"  match fd000000/ffffffff at 24\n" +
+		"  match 00000000/ffffffff at 28\n" +
+		"  match 00000000/ffffffff at 32\n" +
+		"  match 00000001/ffffffff at 36\n"
+	assert.Equal(t, []string{"ip", "ipv6"}, FilterFamilies(output))
+	fw := "filter parent 1: protocol ipv6 pref 4 fw chain 0 \n" +
+		"filter parent 1: protocol ipv6 pref 4 fw chain 0 handle 0x50 classid 1:3 \n"
+	assert.Equal(t, []string{"ipv6"}, FilterFamilies(fw))
+	assert.Empty(t, FilterFamilies(""))
+}
+
+func TestHashFilters(t *testing.T) {
+	// 'tc filter show' output of filters of delay-dst-percent.start.golden, applied by kernel
+	output, err := ioutil.ReadFile(filepath.Join("testdata", "delay-dst-percent.filters.golden"))
+	assert.NoError(t, err)
+	f := Filter{DstPercent: 50}
+	assert.Equal(t, Families(f), FilterFamilies(string(output)))
+	// hash bucket filters of both families are installed
+	buckets := map[string]int{}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 4 && strings.Contains(line, " bkt ") && strings.Contains(line, "flowid "+filterBand) {
+			buckets[fields[4]]++
+		}
+	}
+	assert.Equal(t, map[string]int{"ip": HashBuckets(50), "ipv6": HashBuckets(50)}, buckets)
+}
+
+// bandFamilies returns protocol families, that 'tc filter show' output routes to band
+func bandFamilies(output string, band string) map[string]bool {
+	families := map[string]bool{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 4 && fields[0] == "filter" && strings.Contains(line, "flowid "+band) {
+			families[fields[4]] = true
+		}
+	}
+	return families
+}
+
+func TestExcludeFilters(t *testing.T) {
+	// 'tc filter show' output of filters of delay-exclude.start.golden, applied by kernel
+	output, err := ioutil.ReadFile(filepath.Join("testdata", "delay-exclude.filters.golden"))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"ip": true, "ipv6": true}, bandFamilies(string(output), excludeBand))
+	assert.Equal(t, []string{"ip", "ipv6"}, FilterFamilies(string(output)))
+}
+
+func TestInterfaces(t *testing.T) {
+	output := "1: lo: <LOOPBACK,UP,LOWER_UP> mtu 65536 qdisc noqueue state UNKNOWN mode DEFAULT group default qlen 1000\\    link/loopback 00:00:00:00:00:00 brd 00:00:00:00:00:00\r\n" +
+		"2: tunl0@NONE: <NOARP> mtu 1480 qdisc noop state DOWN mode DEFAULT group default qlen 1000\\    link/ipip 0.0.0.0 brd 0.0.0.0\r\n" +
+		"12: eth0@if13: <BROADCAST,MULTICAST,UP,LOWER_UP> mtu 1500 qdisc noqueue state UP mode DEFAULT group default \\    link/ether 02:42:ac:11:00:02 brd ff:ff:ff:ff:ff:ff link-netnsid 0\r\n" +
+		"14: enp0s8: <BROADCAST,MULTICAST,UP,LOWER_UP,M-DOWN> mtu 1500 qdisc noqueue \\    link/ether 02:42:0a:00:09:03 brd ff:ff:ff:ff:ff:ff\r\n"
+	assert.Equal(t, []string{"eth0", "enp0s8"}, Interfaces(output))
+	assert.Empty(t, Interfaces(""))
+	assert.Equal(t, []string{"ip", "-o", "link", "show"}, LinkCommand())
+}
+
+func TestInterfaceByIP(t *testing.T) {
+	output := "1: lo    inet 127.0.0.1/8 scope host lo\\       valid_lft forever preferred_lft forever\r\n" +
+		"10: eth0@if11    inet 172.17.0.2/16 scope global eth0\\       valid_lft forever preferred_lft forever\r\n" +
+		"12: eth1@if13    inet 10.0.9.3/24 scope global eth1\\       valid_lft forever preferred_lft forever\r\n" +
+		"12: eth1@if13    inet6 fd00::3/64 scope global \\       valid_lft forever preferred_lft forever\r\n"
+	assert.Equal(t, "eth0", InterfaceByIP(output, net.ParseIP("172.17.0.2")))
+	assert.Equal(t, "eth1", InterfaceByIP(output, net.ParseIP("10.0.9.3")))
+	assert.Equal(t, "eth1", InterfaceByIP(output, net.ParseIP("fd00::3")))
+	assert.Equal(t, "", InterfaceByIP(output, net.ParseIP("10.0.9.4")))
+	assert.Equal(t, "", InterfaceByIP("", net.ParseIP("10.0.9.3")))
+}
+
+func TestProbeCommand(t *testing.T) {
+	assert.Equal(t, []string{"ping", "-q", "-c", "3", "-w", "10", "10.0.0.1"}, ProbeCommand("10.0.0.1"))
+	assert.Equal(t, []string{"curl", "-s", "-o", "/dev/null", "--max-time", "10", "-w", "%{time_total}\n", "http://api:8080/health"}, ProbeCommand("http://api:8080/health"))
+}
+
+func TestParseProbe(t *testing.T) {
+	iputils := "PING db (10.0.0.1) 56(84) bytes of data.\r\n\r\n--- db ping statistics ---\r\n" +
+		"3 packets transmitted, 3 received, 0% packet loss, time 2003ms\r\nrtt min/avg/max/mdev = 0.051/0.062/0.071/0.008 ms\r\n"
+	d, err := ParseProbe("db", iputils)
+	assert.NoError(t, err)
+	assert.Equal(t, 62*time.Microsecond, d)
+	busybox := "--- db ping statistics ---\n3 packets transmitted, 3 packets received, 0% packet loss\n" +
+		"round-trip min/avg/max = 100.321/120.500/140.004 ms\n"
+	d, err = ParseProbe("db", busybox)
+	assert.NoError(t, err)
+	assert.Equal(t, 120500*time.Microsecond, d)
+	_, err = ParseProbe("db", "--- db ping statistics ---\n3 packets transmitted, 0 received, 100% packet loss, time 2015ms\n")
+	assert.EqualError(t, err, "No response from db")
+
+	d, err = ParseProbe("http://api/health", "0.250000\r\n")
+	assert.NoError(t, err)
+	assert.Equal(t, 250*time.Millisecond, d)
+	_, err = ParseProbe("http://api/health", "0.000000")
+	assert.EqualError(t, err, "No response from http://api/health")
+	_, err = ParseProbe("http://api/health", "sh: curl: not found")
+	assert.EqualError(t, err, "Unexpected probe output: sh: curl: not found")
+}
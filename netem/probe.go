@@ -0,0 +1,68 @@
+package netem
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	probePings = "3"
+	// probeTimeout probe deadline, seconds; fully dropped traffic does not hang probe
+	probeTimeout = "10"
+)
+
+// IsURLProbe checks if probe target is HTTP(S) URL (probed with curl) and not a host (probed with ping)
+func IsURLProbe(target string) bool {
+	return strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")
+}
+
+// ProbeCommand returns command, that measures latency to probe target from container: ping for host or IP,
+// curl request time for HTTP(S) URL
+func ProbeCommand(target string) []string {
+	if IsURLProbe(target) {
+		return []string{"curl", "-s", "-o", "/dev/null", "--max-time", probeTimeout, "-w", "%{time_total}\n", target}
+	}
+	return []string{"ping", "-q", "-c", probePings, "-w", probeTimeout, target}
+}
+
+// ParseProbe returns latency, measured by ProbeCommand: average round-trip time of ping or total time of curl request
+func ParseProbe(target string, output string) (time.Duration, error) {
+	if IsURLProbe(target) {
+		// '0.104213'; curl prints '0.000000' when request fails
+		fields := strings.Fields(output)
+		if len(fields) == 0 {
+			return 0, fmt.Errorf("No response from %s", target)
+		}
+		seconds, err := strconv.ParseFloat(fields[len(fields)-1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("Unexpected probe output: %s", strings.TrimSpace(output))
+		}
+		if seconds <= 0 {
+			return 0, fmt.Errorf("No response from %s", target)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	for _, line := range strings.Split(output, "\n") {
+		// 'rtt min/avg/max/mdev = 0.051/0.062/0.071/0.008 ms' (iputils) or
+		// 'round-trip min/avg/max = 0.051/0.062/0.071 ms' (busybox)
+		if !strings.Contains(line, "min/avg/max") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		values := strings.Split(strings.Fields(parts[1])[0], "/")
+		if len(values) < 2 {
+			continue
+		}
+		ms, err := strconv.ParseFloat(values[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("Unexpected probe output: %s", strings.TrimSpace(line))
+		}
+		return time.Duration(ms * float64(time.Millisecond)), nil
+	}
+	return 0, fmt.Errorf("No response from %s", target)
+}
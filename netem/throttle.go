@@ -0,0 +1,49 @@
+package netem
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// throttleQdisc tbf (token bucket filter) qdisc, that throttles bandwidth; attached as child qdisc of netem
+const throttleQdisc = "tbf"
+
+var (
+	// tc rate: bits or bytes per second, e.g. '1mbit', '500kbit' or '10mbps'
+	rateRE = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?(bit|kbit|mbit|gbit|bps|kbps|mbps|gbps)$`)
+	// tc size: bytes or bits, e.g. '1540', '32kbit', '10kb' or '1mb'
+	sizeRE = regexp.MustCompile(`^[0-9]+(b|k|kb|kbit|m|mb|mbit|g|gb|gbit)?$`)
+)
+
+// ValidRate returns true if rate is valid tc rate, e.g. '1mbit'
+func ValidRate(rate string) bool {
+	return rateRE.MatchString(rate)
+}
+
+// ValidSize returns true if size is valid tc size, e.g. '32kbit'
+func ValidSize(size string) bool {
+	return sizeRE.MatchString(size)
+}
+
+// Throttle returns tbf bandwidth throttle: rate, burst size (bucket size) and latency in milliseconds - maximal time
+// packet waits for tokens, packets waiting longer are dropped; unlike netem rate, tbf shapes traffic predictably
+// (bursts are limited by bucket size)
+func Throttle(rate, burst string, latency int) []string {
+	return []string{throttleQdisc, "rate", rate, "burst", burst, "latency", strconv.Itoa(latency) + "ms"}
+}
+
+// splitThrottle splits impairment into netem options and tbf throttle options; nil throttle - no throttle
+func splitThrottle(impairment []string) ([]string, []string) {
+	for i, arg := range impairment {
+		if arg == throttleQdisc {
+			return impairment[:i], impairment[i:]
+		}
+	}
+	return impairment, nil
+}
+
+// throttleCommand returns tc command, that attaches tbf child qdisc to netem (with netemHandle)
+func throttleCommand(netInterface string, throttle []string) []string {
+	// 'tc qdisc add dev eth0 parent 10:1 tbf rate 1mbit burst 32kbit latency 400ms'
+	return tc(append([]string{"qdisc", "add", "dev", netInterface, "parent", netemHandle + "1"}, throttle...)...)
+}
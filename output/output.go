@@ -0,0 +1,90 @@
+// Package output writes results of list, status and report commands as text table, JSON or YAML, so automation
+// can parse them without scraping logs
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// Table human readable text table (default)
+	Table = "table"
+	// JSON indented JSON document
+	JSON = "json"
+	// YAML YAML document with the same keys as JSON
+	YAML = "yaml"
+)
+
+// Validate checks output format
+func Validate(format string) error {
+	if format != Table && format != JSON && format != YAML {
+		return fmt.Errorf("Unsupported output format '%s': must be 'table', 'json' or 'yaml'", format)
+	}
+	return nil
+}
+
+// Write writes value in output format; table writes text table of value
+func Write(out io.Writer, format string, v interface{}, table func(io.Writer) error) error {
+	switch format {
+	case Table:
+		return table(out)
+	case JSON:
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	case YAML:
+		data, err := toYAML(v)
+		if err != nil {
+			return err
+		}
+		_, err = out.Write(data)
+		return err
+	}
+	return Validate(format)
+}
+
+// toYAML converts value to YAML through JSON, so YAML keys and values match JSON output (json struct tags,
+// RFC 3339 times)
+func toYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc interface{}
+	if err = dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(numbers(doc))
+}
+
+// numbers converts JSON numbers of decoded document to integers (or floats), so large integers, like durations
+// in nanoseconds, are not written in exponent form
+func numbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = numbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = numbers(item)
+		}
+	}
+	return v
+}
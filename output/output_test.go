@@ -0,0 +1,73 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+type item struct {
+	Name     string        `json:"name"`
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration"`
+	Ratio    float64       `json:"ratio"`
+	Error    string        `json:"error,omitempty"`
+}
+
+var items = []item{{Name: "api", Time: time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC), Duration: 90 * time.Second, Ratio: 0.5}}
+
+func table(out io.Writer) error {
+	_, err := fmt.Fprintln(out, "NAME\napi")
+	return err
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("table"))
+	assert.NoError(t, Validate("json"))
+	assert.NoError(t, Validate("yaml"))
+	assert.EqualError(t, Validate("csv"), "Unsupported output format 'csv': must be 'table', 'json' or 'yaml'")
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, Table, items, table))
+	assert.Equal(t, "NAME\napi\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, Write(&buf, JSON, items, table))
+	assert.Equal(t, `[
+  {
+    "name": "api",
+    "time": "2016-08-01T10:00:00Z",
+    "duration": 90000000000,
+    "ratio": 0.5
+  }
+]
+`, buf.String())
+
+	// YAML keys and values match JSON; timestamp quoting depends on yaml version, so document is compared decoded
+	buf.Reset()
+	assert.NoError(t, Write(&buf, YAML, items, table))
+	assert.Contains(t, buf.String(), "duration: 90000000000\n")
+	var doc []struct {
+		Name     string  `yaml:"name"`
+		Time     string  `yaml:"time"`
+		Duration int64   `yaml:"duration"`
+		Ratio    float64 `yaml:"ratio"`
+		Error    string  `yaml:"error"`
+	}
+	if assert.NoError(t, yaml.Unmarshal(buf.Bytes(), &doc)) && assert.Len(t, doc, 1) {
+		assert.Equal(t, "api", doc[0].Name)
+		assert.Equal(t, "2016-08-01T10:00:00Z", doc[0].Time)
+		assert.Equal(t, int64(90*time.Second), doc[0].Duration)
+		assert.Equal(t, 0.5, doc[0].Ratio)
+		assert.Empty(t, doc[0].Error)
+	}
+
+	assert.Error(t, Write(&buf, "xml", items, table))
+}
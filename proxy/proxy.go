@@ -0,0 +1,156 @@
+package proxy
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// maxTruncateBody maximal size of response body of unknown length (chunked response), buffered to truncate it
+const maxTruncateBody = 10 << 20
+
+// Config HTTP fault injection configuration
+type Config struct {
+	// Upstream address (host:port) to forward requests to
+	Upstream string
+	// Delay added to each request
+	Delay time.Duration
+	// ErrorRate percent of requests answered with ErrorCode, without calling upstream
+	ErrorRate int
+	// ErrorCode HTTP status code returned for failed requests
+	ErrorCode int
+	// TruncateRate percent of responses, where only half of the body is sent
+	TruncateRate int
+}
+
+// Handler HTTP handler, that forwards requests to upstream and injects faults
+type Handler struct {
+	config    Config
+	transport http.RoundTripper
+	mu        sync.Mutex
+	rnd       *rand.Rand
+}
+
+// NewHandler creates new fault injecting HTTP handler
+func NewHandler(config Config) *Handler {
+	if config.ErrorCode == 0 {
+		config.ErrorCode = http.StatusServiceUnavailable
+	}
+	return &Handler{
+		config:    config,
+		transport: http.DefaultTransport,
+		rnd:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// hit returns true with specified probability (in percents)
+func (h *Handler) hit(percent int) bool {
+	if percent <= 0 {
+		return false
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.rnd.Intn(100) < percent
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.config.Delay > 0 {
+		time.Sleep(h.config.Delay)
+	}
+	if h.hit(h.config.ErrorRate) {
+		log.Debugf("Failing request %s %s with %d", r.Method, r.URL, h.config.ErrorCode)
+		http.Error(w, http.StatusText(h.config.ErrorCode), h.config.ErrorCode)
+		return
+	}
+	// forward request to upstream
+	out := new(http.Request)
+	*out = *r
+	u := *r.URL
+	u.Scheme = "http"
+	u.Host = h.config.Upstream
+	out.URL = &u
+	out.RequestURI = ""
+	out.Close = false
+	resp, err := h.transport.RoundTrip(out)
+	if err != nil {
+		log.Debugf("Upstream error: %s", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	for k, vv := range resp.Header {
+		for _, v := range vv {
+			w.Header().Add(k, v)
+		}
+	}
+	if h.hit(h.config.TruncateRate) {
+		h.truncate(w, r, resp)
+		return
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body)
+}
+
+// truncate sends only half of the response body, keeping original Content-Length; body of unknown length
+// (chunked response) is buffered to get its length, and sent with Content-Length instead of chunked encoding
+func (h *Handler) truncate(w http.ResponseWriter, r *http.Request, resp *http.Response) {
+	var body io.Reader = resp.Body
+	length := resp.ContentLength
+	if length < 0 {
+		buf, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxTruncateBody+1))
+		if err != nil {
+			log.Debugf("Upstream error: %s", err)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		if len(buf) > maxTruncateBody {
+			log.Warningf("Not truncating response for %s %s: chunked body is larger than %d bytes", r.Method, r.URL, maxTruncateBody)
+			w.WriteHeader(resp.StatusCode)
+			w.Write(buf)
+			io.Copy(w, resp.Body)
+			return
+		}
+		body = bytes.NewReader(buf)
+		length = int64(len(buf))
+	}
+	if length == 0 {
+		// nothing to truncate
+		w.WriteHeader(resp.StatusCode)
+		return
+	}
+	log.Debugf("Truncating response for %s %s", r.Method, r.URL)
+	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+	w.WriteHeader(resp.StatusCode)
+	io.CopyN(w, body, length/2)
+}
+
+// redirectArgs returns iptables arguments, that redirect incoming TCP traffic from port to toPort
+func redirectArgs(op string, port int, toPort int) []string {
+	return []string{"-t", "nat", op, "PREROUTING", "-p", "tcp", "--dport", strconv.Itoa(port),
+		"-j", "REDIRECT", "--to-ports", strconv.Itoa(toPort)}
+}
+
+// Redirect redirects incoming TCP traffic from port to proxy port, using iptables
+func Redirect(port int, toPort int) error {
+	if out, err := exec.Command("iptables", redirectArgs("-I", port, toPort)...).CombinedOutput(); err != nil {
+		return fmt.Errorf("Failed to redirect port %d to %d: %s (%s)", port, toPort, err, out)
+	}
+	return nil
+}
+
+// Unredirect removes redirect rule, created with Redirect
+func Unredirect(port int, toPort int) error {
+	if out, err := exec.Command("iptables", redirectArgs("-D", port, toPort)...).CombinedOutput(); err != nil {
+		return fmt.Errorf("Failed to remove redirect from port %d to %d: %s (%s)", port, toPort, err, out)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func upstream(t *testing.T) (*httptest.Server, string) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Upstream", "yes")
+		w.Write([]byte("0123456789"))
+	}))
+	u, err := url.Parse(s.URL)
+	assert.NoError(t, err)
+	return s, u.Host
+}
+
+func TestHandler_Forward(t *testing.T) {
+	up, host := upstream(t)
+	defer up.Close()
+	p := httptest.NewServer(NewHandler(Config{Upstream: host, Delay: 5 * time.Millisecond}))
+	defer p.Close()
+
+	start := time.Now()
+	resp, err := http.Get(p.URL + "/test")
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 5*time.Millisecond)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "yes", resp.Header.Get("X-Upstream"))
+	assert.Equal(t, "0123456789", string(body))
+}
+
+func TestHandler_Error(t *testing.T) {
+	up, host := upstream(t)
+	defer up.Close()
+	p := httptest.NewServer(NewHandler(Config{Upstream: host, ErrorRate: 100, ErrorCode: 502}))
+	defer p.Close()
+
+	resp, err := http.Get(p.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 502, resp.StatusCode)
+}
+
+func TestHandler_Truncate(t *testing.T) {
+	up, host := upstream(t)
+	defer up.Close()
+	p := httptest.NewServer(NewHandler(Config{Upstream: host, TruncateRate: 100}))
+	defer p.Close()
+
+	resp, err := http.Get(p.URL)
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Error(t, err)
+	assert.Equal(t, "01234", string(body))
+}
+
+func TestHandler_TruncateChunked(t *testing.T) {
+	// flushed response has no Content-Length: upstream sends it chunked
+	up := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("01234"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("56789"))
+	}))
+	defer up.Close()
+	u, err := url.Parse(up.URL)
+	assert.NoError(t, err)
+	p := httptest.NewServer(NewHandler(Config{Upstream: u.Host, TruncateRate: 100}))
+	defer p.Close()
+
+	resp, err := http.Get(p.URL)
+	assert.NoError(t, err)
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Error(t, err)
+	assert.Equal(t, int64(10), resp.ContentLength)
+	assert.Equal(t, "01234", string(body))
+}
+
+func TestRedirectArgs(t *testing.T) {
+	assert.Equal(t, []string{"-t", "nat", "-I", "PREROUTING", "-p", "tcp", "--dport", "80",
+		"-j", "REDIRECT", "--to-ports", "15001"}, redirectArgs("-I", 80, 15001))
+}
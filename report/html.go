@@ -0,0 +1,161 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+)
+
+// minBarWidth minimal width of timeline bar, in percents of timeline, so short actions (e.g. kill) are visible
+const minBarWidth = 0.5
+
+// timelineBar chaos action or probe on timeline; position and width in percents of report time window
+type timelineBar struct {
+	Left, Width float64
+	Title       string
+	Failed      bool
+}
+
+// timelineRow timeline of target container or probes
+type timelineRow struct {
+	Label string
+	Bars  []timelineBar
+}
+
+// bar places event on timeline of report time window
+func (r Report) bar(start time.Time, d time.Duration, title string, failed bool) timelineBar {
+	window := r.Until.Sub(r.Since)
+	if window <= 0 {
+		return timelineBar{Left: 0, Width: 100, Title: title, Failed: failed}
+	}
+	left := 100 * float64(start.Sub(r.Since)) / float64(window)
+	width := 100 * float64(d) / float64(window)
+	if left < 0 {
+		width += left
+		left = 0
+	}
+	if left > 100-minBarWidth {
+		left = 100 - minBarWidth
+	}
+	if width < minBarWidth {
+		width = minBarWidth
+	}
+	if left+width > 100 {
+		width = 100 - left
+	}
+	return timelineBar{Left: left, Width: width, Title: title, Failed: failed}
+}
+
+// timeline returns timeline rows: one per target container and probes row, if any
+func (r Report) timeline() []timelineRow {
+	var rows []timelineRow
+	for _, t := range r.Targets {
+		row := timelineRow{Label: t.Name}
+		for _, a := range r.Actions {
+			if a.ContainerID == t.ID {
+				title := fmt.Sprintf("%s %s at %s for %s", a.Action, a.ContainerName, formatTime(a.Time), a.Duration)
+				if a.Error != "" {
+					title += ": " + a.Error
+				}
+				row.Bars = append(row.Bars, r.bar(a.Time, a.Duration, title, a.Error != ""))
+			}
+		}
+		rows = append(rows, row)
+	}
+	if len(r.Probes) > 0 {
+		row := timelineRow{Label: "probes"}
+		for _, p := range r.Probes {
+			title := fmt.Sprintf("probe '%s' at %s for %s", p.Name, formatTime(p.Time), p.Duration)
+			if p.Error != "" {
+				title += ": " + p.Error
+			}
+			row.Bars = append(row.Bars, r.bar(p.Time, p.Duration, title, p.Error != ""))
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"time":    formatTime,
+	"percent": func(v float64) string { return fmt.Sprintf("%.3f", v) },
+	"result": func(err string) string {
+		if err == "" {
+			return "success"
+		}
+		return "failure: " + err
+	},
+}).Parse(reportHTML))
+
+// WriteHTML writes report as standalone HTML page: styles are inlined and no external resources or scripts are used,
+// so the page can be attached to documents and opened offline
+func WriteHTML(out io.Writer, r Report) error {
+	return htmlTemplate.Execute(out, struct {
+		Report
+		Timeline []timelineRow
+	}{r, r.timeline()})
+}
+
+const reportHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; font-size: 0.9em; }
+th { background: #f0f0f0; }
+.failure { color: #b00; }
+.timeline { width: 100%; margin-bottom: 1.5em; }
+.row { display: flex; align-items: center; margin: 0.2em 0; }
+.label { width: 15em; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; font-size: 0.9em; }
+.track { position: relative; flex: 1; height: 1.2em; background: #f5f5f5; }
+.bar { position: absolute; top: 0; height: 100%; background: #3b7dd8; }
+.bar.failed { background: #d83b3b; }
+.axis { display: flex; justify-content: space-between; margin-left: 15em; font-size: 0.8em; color: #666; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+<tr><th>Time window</th><td>{{time .Since}} - {{time .Until}}</td></tr>
+{{range $key, $value := .Context}}<tr><th>{{$key}}</th><td>{{$value}}</td></tr>
+{{end}}<tr><th>Runs</th><td>{{len .Runs}}</td></tr>
+<tr><th>Chaos actions</th><td>{{len .Actions}} ({{.Failures}} failed)</td></tr>
+<tr><th>Targets</th><td>{{len .Targets}}</td></tr>
+<tr><th>Probes</th><td>{{len .Probes}} ({{.FailedProbes}} failed)</td></tr>
+<tr><th>Generated</th><td>{{time .Generated}}</td></tr>
+</table>
+{{if .Timeline}}<h2>Timeline</h2>
+<div class="timeline">
+{{range .Timeline}}<div class="row"><div class="label" title="{{.Label}}">{{.Label}}</div><div class="track">{{range .Bars}}<div class="bar{{if .Failed}} failed{{end}}" style="left: {{percent .Left}}%; width: {{percent .Width}}%" title="{{.Title}}"></div>{{end}}</div></div>
+{{end}}<div class="axis"><span>{{time .Since}}</span><span>{{time .Until}}</span></div>
+</div>
+{{end}}<h2>Targets</h2>
+<table><thead><tr><th>Container</th><th>ID</th><th>Actions</th><th>Failures</th></tr></thead><tbody>
+{{range .Targets}}<tr><td>{{.Name}}</td><td>{{.ID}}</td><td>{{.Actions}}</td><td{{if .Failures}} class="failure"{{end}}>{{.Failures}}</td></tr>
+{{end}}</tbody></table>
+<h2>Chaos actions</h2>
+<table><thead><tr><th>Time</th><th>Action</th><th>Container</th><th>Duration</th><th>Result</th></tr></thead><tbody>
+{{range .Actions}}<tr><td>{{time .Time}}</td><td>{{.Action}}</td><td>{{.ContainerName}}</td><td>{{.Duration}}</td><td{{if .Error}} class="failure"{{end}}>{{result .Error}}</td></tr>
+{{end}}</tbody></table>
+<h2>Probes</h2>
+<table><thead><tr><th>Time</th><th>Probe</th><th>Duration</th><th>Result</th></tr></thead><tbody>
+{{range .Probes}}<tr><td>{{time .Time}}</td><td>{{.Name}}</td><td>{{.Duration}}</td><td{{if .Error}} class="failure"{{end}}>{{result .Error}}</td></tr>
+{{end}}</tbody></table>
+<h2>Runs</h2>
+<table><thead><tr><th>Started</th><th>Finished</th><th>Command</th><th>Result</th></tr></thead><tbody>
+{{range .Runs}}<tr><td>{{time .Started}}</td><td>{{time .Finished}}</td><td>{{.Command}}</td><td{{if .Error}} class="failure"{{end}}>{{result .Error}}</td></tr>
+{{end}}</tbody></table>
+</body>
+</html>
+`
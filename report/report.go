@@ -0,0 +1,143 @@
+// Package report builds read-only report of chaos experiment from experiment history (runs, chaos actions and probe
+// outcomes), that can be shared, e.g. attached to game-day retrospective
+package report
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gaia-adm/pumba/history"
+)
+
+// Report chaos experiment report: experiment runs, chaos actions and probe outcomes in time window
+type Report struct {
+	Title     string    `json:"title"`
+	Generated time.Time `json:"generated"`
+	Since     time.Time `json:"since"`
+	Until     time.Time `json:"until"`
+	// Context metadata of system under test (--context) of experiment runs; distinct values are comma separated
+	Context map[string]string `json:"context,omitempty"`
+	Runs    []history.Run     `json:"runs"`
+	Actions []history.Action  `json:"actions"`
+	Probes  []history.Probe   `json:"probes"`
+	Targets []Target          `json:"targets"`
+}
+
+// Target chaos actions on target container and their outcomes
+type Target struct {
+	Name     string `json:"name"`
+	ID       string `json:"id"`
+	Actions  int    `json:"actions"`
+	Failures int    `json:"failures"`
+}
+
+// New creates experiment report; zero since or until is set to time of the first or the last recorded event
+func New(title string, since, until, generated time.Time, runs []history.Run, actions []history.Action, probes []history.Probe) Report {
+	r := Report{Title: title, Generated: generated, Since: since, Until: until, Runs: runs, Actions: actions, Probes: probes}
+	var first, last time.Time
+	span := func(start time.Time, d time.Duration) {
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if end := start.Add(d); end.After(last) {
+			last = end
+		}
+	}
+	for _, run := range runs {
+		span(run.Started, run.Finished.Sub(run.Started))
+	}
+	for _, a := range actions {
+		span(a.Time, a.Duration)
+	}
+	for _, p := range probes {
+		span(p.Time, p.Duration)
+	}
+	if r.Since.IsZero() {
+		r.Since = first
+	}
+	if r.Until.IsZero() {
+		r.Until = last
+	}
+	r.Targets = targets(actions)
+	r.Context = runsContext(runs)
+	return r
+}
+
+// runsContext merges context of experiment runs; nil - runs have no context
+func runsContext(runs []history.Run) map[string]string {
+	values := map[string][]string{}
+	for _, run := range runs {
+		for k, v := range run.Context {
+			found := false
+			for _, value := range values[k] {
+				found = found || value == v
+			}
+			if !found {
+				values[k] = append(values[k], v)
+			}
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	context := make(map[string]string, len(values))
+	for k, v := range values {
+		context[k] = strings.Join(v, ", ")
+	}
+	return context
+}
+
+// Failures returns number of failed chaos actions
+func (r Report) Failures() int {
+	failures := 0
+	for _, t := range r.Targets {
+		failures += t.Failures
+	}
+	return failures
+}
+
+// FailedProbes returns number of failed probes
+func (r Report) FailedProbes() int {
+	failed := 0
+	for _, p := range r.Probes {
+		if p.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// targets returns target containers of chaos actions, ordered by name
+func targets(actions []history.Action) []Target {
+	byID := map[string]*Target{}
+	for _, a := range actions {
+		t, ok := byID[a.ContainerID]
+		if !ok {
+			t = &Target{Name: a.ContainerName, ID: a.ContainerID}
+			byID[a.ContainerID] = t
+		}
+		t.Actions++
+		if a.Error != "" {
+			t.Failures++
+		}
+	}
+	list := make([]Target, 0, len(byID))
+	for _, t := range byID {
+		list = append(list, *t)
+	}
+	sort.Sort(byName(list))
+	return list
+}
+
+// byName sorts targets by container name and ID
+type byName []Target
+
+func (t byName) Len() int      { return len(t) }
+func (t byName) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
+func (t byName) Less(i, j int) bool {
+	if t[i].Name != t[j].Name {
+		return t[i].Name < t[j].Name
+	}
+	return t[i].ID < t[j].ID
+}
@@ -0,0 +1,73 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/history"
+	"github.com/stretchr/testify/assert"
+)
+
+var t0 = time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+
+func testReport() Report {
+	runs := []history.Run{
+		{Command: "netem delay re2:^api", Started: t0, Finished: t0.Add(10 * time.Minute), Context: map[string]string{"build": "1.2.3", "env": "staging"}},
+		{Command: "netem delay re2:^api", Started: t0.Add(5 * time.Minute), Finished: t0.Add(10 * time.Minute), Context: map[string]string{"build": "1.2.4", "env": "staging"}},
+	}
+	actions := []history.Action{
+		{Time: t0, Duration: 5 * time.Minute, Action: "netem", ContainerID: "def456", ContainerName: "/db_1"},
+		{Time: t0.Add(time.Minute), Duration: time.Second, Action: "kill", ContainerID: "abc123", ContainerName: "/api_1", Error: "<no such container>"},
+		{Time: t0.Add(5 * time.Minute), Duration: 5 * time.Minute, Action: "netem", ContainerID: "abc123", ContainerName: "/api_1"},
+	}
+	probes := []history.Probe{
+		{Time: t0.Add(9 * time.Minute), Duration: 30 * time.Second, Name: "api healthy"},
+	}
+	return New("Game day", time.Time{}, time.Time{}, t0.Add(time.Hour), runs, actions, probes)
+}
+
+func TestNew(t *testing.T) {
+	r := testReport()
+	assert.True(t, t0.Equal(r.Since))
+	assert.True(t, t0.Add(10*time.Minute).Equal(r.Until))
+	assert.Equal(t, []Target{
+		{Name: "/api_1", ID: "abc123", Actions: 2, Failures: 1},
+		{Name: "/db_1", ID: "def456", Actions: 1},
+	}, r.Targets)
+	assert.Equal(t, 1, r.Failures())
+	assert.Equal(t, 0, r.FailedProbes())
+	assert.Equal(t, map[string]string{"build": "1.2.3, 1.2.4", "env": "staging"}, r.Context)
+}
+
+func TestTimeline(t *testing.T) {
+	rows := testReport().timeline()
+	if assert.Len(t, rows, 3) {
+		assert.Equal(t, "/api_1", rows[0].Label)
+		if assert.Len(t, rows[0].Bars, 2) {
+			// short kill is shown with minimal width
+			assert.InDelta(t, 10, rows[0].Bars[0].Left, 0.001)
+			assert.InDelta(t, minBarWidth, rows[0].Bars[0].Width, 0.001)
+			assert.True(t, rows[0].Bars[0].Failed)
+			assert.InDelta(t, 50, rows[0].Bars[1].Width, 0.001)
+		}
+		assert.Equal(t, "probes", rows[2].Label)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var out bytes.Buffer
+	err := WriteHTML(&out, testReport())
+	assert.NoError(t, err)
+	html := out.String()
+	assert.True(t, strings.HasPrefix(html, "<!DOCTYPE html>"))
+	assert.Contains(t, html, "<title>Game day</title>")
+	assert.Contains(t, html, `style="left: 10.000%; width: 0.500%"`)
+	assert.Contains(t, html, "<td>api healthy</td>")
+	assert.Contains(t, html, "<tr><th>build</th><td>1.2.3, 1.2.4</td></tr>")
+	// values are escaped, no external resources are used
+	assert.Contains(t, html, "failure: &lt;no such container&gt;")
+	assert.NotContains(t, html, "<script")
+	assert.NotContains(t, html, "http")
+}
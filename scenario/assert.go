@@ -0,0 +1,210 @@
+package scenario
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gaia-adm/pumba/action"
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/validate"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// assertion subjects
+const (
+	assertContainer = "container"
+	assertQdisc     = "qdisc"
+)
+
+// container states of 'container' assertion; stopped - no running (or paused) container matches targets
+var containerStates = map[string]bool{"running": true, "paused": true, "stopped": true}
+
+// assertion of scenario 'assert' step, evaluated against Docker state:
+// 'container <targets> running|paused|stopped [within <duration>]' or
+// 'qdisc clean on <targets> [interface <name>] [within <duration>]'; targets are comma-separated container names
+// or 're2:' pattern
+type assertion struct {
+	subject      string
+	names        []string
+	pattern      string
+	state        string
+	netInterface string
+	// within deadline of assertion, repeated till it passes; 0 - evaluated once
+	within time.Duration
+}
+
+// AssertionResult outcome of scenario assertion step
+type AssertionResult struct {
+	Step      int           `json:"step"`
+	Assertion string        `json:"assertion"`
+	Start     time.Time     `json:"start"`
+	Duration  time.Duration `json:"duration"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// parseAssertion parses assertion expression of 'assert' step
+func parseAssertion(expr string) (*assertion, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("Invalid assertion '%s': %s", expr, reason)
+	}
+	fields := strings.Fields(expr)
+	a := &assertion{}
+	if n := len(fields); n >= 2 && fields[n-2] == "within" {
+		d, err := validate.Duration(fields[n-1])
+		if err != nil {
+			return nil, invalid(err.Error())
+		}
+		a.within = d
+		fields = fields[:n-2]
+	}
+	var targets string
+	switch {
+	case len(fields) == 3 && fields[0] == assertContainer && containerStates[fields[2]]:
+		a.subject, targets, a.state = assertContainer, fields[1], fields[2]
+	case (len(fields) == 4 || len(fields) == 6 && fields[4] == "interface") && fields[0] == assertQdisc && fields[1] == "clean" && fields[2] == "on":
+		a.subject, targets, a.netInterface = assertQdisc, fields[3], container.AllInterfaces
+		if len(fields) == 6 {
+			a.netInterface = fields[5]
+			if err := validate.Interface(a.netInterface); err != nil {
+				return nil, invalid(err.Error())
+			}
+		}
+	default:
+		return nil, invalid("must be 'container <targets> running|paused|stopped [within <duration>]' or 'qdisc clean on <targets> [interface <name>] [within <duration>]'")
+	}
+	list := []string{targets}
+	if !strings.HasPrefix(targets, re2Prefix) {
+		list = strings.Split(targets, ",")
+	}
+	var err error
+	if a.names, a.pattern, err = targetsNamesOrPattern(list); err != nil {
+		return nil, invalid(err.Error())
+	}
+	if len(a.names) == 0 && a.pattern == "" {
+		return nil, invalid("no targets")
+	}
+	return a, nil
+}
+
+// runAssert evaluates assertion of step every 'interval' (default 1s) till it passes or its deadline is exceeded
+func (r *Runner) runAssert(index int, step Step) (err error) {
+	a, err := parseAssertion(step.Assert)
+	if err != nil {
+		return err
+	}
+	interval, err := optDurationParam(step.Params, "interval", defaultProbeInterval)
+	if err != nil {
+		return err
+	}
+	check := r.containerCheck(a)
+	if a.subject == assertQdisc {
+		check = r.qdiscCheck(a)
+	}
+	name := step.Name
+	if name == "" {
+		name = step.Assert
+	}
+	start := time.Now()
+	defer r.recordProbe(name, start, &err)
+	defer func() {
+		result := AssertionResult{Step: index, Assertion: step.Assert, Start: start, Duration: time.Since(start)}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		r.assertions = append(r.assertions, result)
+	}()
+	if err = r.poll(step.Assert, a.within, interval, check); err != nil {
+		log.Errorf("FAIL: assert %s: %s", step.Assert, err)
+		return fmt.Errorf("Assertion '%s' failed: %s", step.Assert, err)
+	}
+	log.Infof("PASS: assert %s (after %s)", step.Assert, time.Since(start))
+	return nil
+}
+
+// containerCheck checks state of target containers: every target name (or pattern) matches running containers in
+// expected state, or does not match any running container (stopped)
+func (r *Runner) containerCheck(a *assertion) probeFn {
+	return func(time.Duration) error {
+		if a.pattern != "" {
+			return r.checkContainers(a.state, nil, a.pattern, re2Prefix+a.pattern)
+		}
+		for _, name := range a.names {
+			if err := r.checkContainers(a.state, []string{name}, "", name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// checkContainers checks state of running containers, matching names or RE2 pattern (target label)
+func (r *Runner) checkContainers(state string, names []string, pattern string, label string) error {
+	containers, err := action.FindContainers(r.Client, names, pattern)
+	if err != nil {
+		return err
+	}
+	if state == "stopped" {
+		if len(containers) > 0 {
+			return fmt.Errorf("Container %s is running", containers[0].Name())
+		}
+		return nil
+	}
+	if len(containers) == 0 {
+		return fmt.Errorf("No running containers match '%s'", label)
+	}
+	for _, c := range containers {
+		if c.IsPaused() != (state == "paused") {
+			return fmt.Errorf("Container %s is not %s", c.Name(), state)
+		}
+	}
+	return nil
+}
+
+// qdiscCheck checks, that network interfaces of target containers have no qdiscs, added by netem
+func (r *Runner) qdiscCheck(a *assertion) probeFn {
+	return func(time.Duration) error {
+		containers, err := action.FindContainers(r.Client, a.names, a.pattern)
+		if err != nil {
+			return err
+		}
+		if len(containers) == 0 {
+			return fmt.Errorf("No target containers found")
+		}
+		for _, c := range containers {
+			ifaces := []string{a.netInterface}
+			if a.netInterface == container.AllInterfaces {
+				if ifaces, err = r.Client.NetworkInterfaces(c); err != nil {
+					return err
+				}
+			}
+			for _, iface := range ifaces {
+				qdiscs, err := r.Client.NetemLeftovers(c, iface)
+				if err != nil {
+					return err
+				}
+				if len(qdiscs) > 0 {
+					return fmt.Errorf("Netem qdisc is left on container %s on '%s': %s", c.Name(), iface, strings.Join(qdiscs, "; "))
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// WriteAssertionsTable writes outcomes of scenario assertion steps as text table
+func WriteAssertionsTable(out io.Writer, results []AssertionResult) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "STEP\tASSERTION\tDURATION\tRESULT")
+	for _, r := range results {
+		result := "PASS"
+		if r.Error != "" {
+			result = "FAIL: " + r.Error
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", r.Step, r.Assertion, r.Duration, result)
+	}
+	return w.Flush()
+}
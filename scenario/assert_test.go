@@ -0,0 +1,158 @@
+package scenario
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/action"
+	"github.com/gaia-adm/pumba/container"
+	"github.com/samalba/dockerclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func makePausedContainer(name string) container.Container {
+	return *container.NewContainer(&dockerclient.ContainerInfo{
+		Name:   name,
+		Config: &dockerclient.ContainerConfig{},
+		State:  &dockerclient.State{Running: true, Paused: true},
+	}, nil)
+}
+
+func TestParseAssertion(t *testing.T) {
+	a, err := parseAssertion("container re2:^api running within 60s")
+	assert.NoError(t, err)
+	assert.Equal(t, &assertion{subject: assertContainer, names: []string{}, pattern: "^api", state: "running", within: time.Minute}, a)
+	a, err = parseAssertion("container api_1,api_2 stopped")
+	assert.NoError(t, err)
+	assert.Equal(t, &assertion{subject: assertContainer, names: []string{"api_1", "api_2"}, state: "stopped"}, a)
+	a, err = parseAssertion("qdisc clean on re2:^db")
+	assert.NoError(t, err)
+	assert.Equal(t, &assertion{subject: assertQdisc, names: []string{}, pattern: "^db", netInterface: "all"}, a)
+	a, err = parseAssertion("qdisc clean on db interface eth1 within 10s")
+	assert.NoError(t, err)
+	assert.Equal(t, &assertion{subject: assertQdisc, names: []string{"db"}, netInterface: "eth1", within: 10 * time.Second}, a)
+
+	usage := "must be 'container <targets> running|paused|stopped [within <duration>]' or 'qdisc clean on <targets> [interface <name>] [within <duration>]'"
+	for expr, expected := range map[string]string{
+		"":                                    "Invalid assertion '': " + usage,
+		"container api healthy":               "Invalid assertion 'container api healthy': " + usage,
+		"container api running within -1s":    "Invalid assertion 'container api running within -1s': Invalid duration '-1s': must not be negative",
+		"qdisc clean api":                     "Invalid assertion 'qdisc clean api': " + usage,
+		"qdisc clean on db interface e;th0":   "Invalid assertion 'qdisc clean on db interface e;th0': Invalid network interface name 'e;th0': must be up to 15 letters, digits, '_', '.' or '-'",
+		"container re2:(api running":          "Invalid assertion 'container re2:(api running': Invalid RE2 pattern '(api': error parsing regexp: missing closing ): `(api`",
+		"container , running":                 "Invalid assertion 'container , running': no targets",
+		"qdisc clean on db eth0":              "Invalid assertion 'qdisc clean on db eth0': " + usage,
+		"container api running within 1m 30s": "Invalid assertion 'container api running within 1m 30s': " + usage,
+	} {
+		_, err = parseAssertion(expr)
+		assert.EqualError(t, err, expected, expr)
+	}
+}
+
+func TestRun_AssertContainer(t *testing.T) {
+	s, err := Parse([]byte(`
+name: restart
+steps:
+  - name: kill api
+    action: kill
+    targets: [api]
+  - assert: container api running within 5s
+  - name: db gone
+    assert: container re2:^db stopped
+`), nil)
+	assert.NoError(t, err)
+	client := container.NewMockSamalbaClient()
+	chaos := action.NewMockChaos()
+	chaos.On("KillContainers", client, []string{"api"}, "", action.CommandKill{Signal: "SIGKILL"}).Return(nil)
+	// api is restarted (paused first) on the third check; db is not running
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{}, nil).Once()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{makePausedContainer("/api")}, nil).Once()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{container.NewMockContainer("", "/api", nil)}, nil).Once()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{}, nil).Once()
+	var slept time.Duration
+	var report []AssertionResult
+	r := &Runner{Client: client, Chaos: chaos, Sleep: func(d time.Duration) { slept += d }}
+	r.ReportAssertions = func(scenario string, results []AssertionResult) { report = results }
+
+	err = r.Run(s)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Second, slept)
+	if assert.Len(t, report, 2) {
+		assert.Equal(t, 2, report[0].Step)
+		assert.Equal(t, "container api running within 5s", report[0].Assertion)
+		assert.Equal(t, "", report[0].Error)
+		assert.Equal(t, 3, report[1].Step)
+	}
+	chaos.AssertExpectations(t)
+	client.AssertExpectations(t)
+}
+
+func TestRun_AssertFailed(t *testing.T) {
+	s := &Scenario{Name: "pause", Steps: []Step{
+		{Assert: "container api,db running"},
+		{Assert: "container api paused within 3ms", Params: map[string]string{"interval": "1ms"}},
+		{Name: "never", Assert: "container api stopped"},
+	}}
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{container.NewMockContainer("", "/api", nil)}, nil)
+	var report []AssertionResult
+	r := &Runner{Client: client, Sleep: func(time.Duration) {}, ReportAssertions: func(scenario string, results []AssertionResult) { report = results }}
+
+	err := r.Run(s)
+
+	assert.EqualError(t, err, "Scenario 'pause' step 2 '' failed: Assertion 'container api paused within 3ms' failed: Container /api is not paused")
+	if assert.Len(t, report, 2) {
+		assert.Equal(t, "", report[0].Error)
+		assert.Equal(t, "Assertion 'container api paused within 3ms' failed: Container /api is not paused", report[1].Error)
+	}
+}
+
+func TestRun_AssertQdisc(t *testing.T) {
+	db1, db2 := container.NewMockContainer("", "/db1", nil), container.NewMockContainer("", "/db2", nil)
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{db1, db2}, nil)
+	client.On("NetworkInterfaces", db1).Return([]string{"eth0", "eth1"}, nil)
+	client.On("NetworkInterfaces", db2).Return([]string{"eth0"}, nil)
+	client.On("NetemLeftovers", db1, "eth0").Return([]string{}, nil)
+	client.On("NetemLeftovers", db1, "eth1").Return([]string{}, nil)
+	client.On("NetemLeftovers", db2, "eth0").Return([]string{"qdisc netem 8001: root refcnt 2 limit 1000 delay 100.0ms"}, nil).Once()
+	client.On("NetemLeftovers", db2, "eth0").Return([]string{}, nil).Once()
+	s := &Scenario{Name: "netem", Steps: []Step{{Assert: "qdisc clean on re2:^db within 1m"}}}
+
+	err := (&Runner{Client: client, Sleep: func(time.Duration) {}}).Run(s)
+
+	assert.NoError(t, err)
+	client.AssertExpectations(t)
+
+	client = container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{db1}, nil)
+	client.On("NetemLeftovers", db1, "eth1").Return([]string(nil), errors.New("exec failed"))
+	s = &Scenario{Name: "netem", Steps: []Step{{Name: "clean", Assert: "qdisc clean on db1 interface eth1"}}}
+
+	err = (&Runner{Client: client, Sleep: func(time.Duration) {}}).Run(s)
+
+	assert.EqualError(t, err, "Scenario 'netem' step 1 'clean' failed: Assertion 'qdisc clean on db1 interface eth1' failed: exec failed")
+}
+
+func TestRun_AssertWithAction(t *testing.T) {
+	s := &Scenario{Name: "bad", Steps: []Step{{Action: "kill", Assert: "container api running"}}}
+	err := NewRunner(nil, action.NewMockChaos(), false).Run(s)
+	assert.EqualError(t, err, "Scenario 'bad' step 1 '' failed: Step can not have both 'action' and 'assert'")
+}
+
+func TestWriteAssertionsTable(t *testing.T) {
+	results := []AssertionResult{
+		{Step: 2, Assertion: "container api running within 1m", Duration: 3 * time.Second},
+		{Step: 4, Assertion: "qdisc clean on re2:^db", Duration: time.Second, Error: "ERROR"},
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, WriteAssertionsTable(&buf, results))
+	assert.Equal(t, `STEP  ASSERTION                        DURATION  RESULT
+2     container api running within 1m  3s        PASS
+4     qdisc clean on re2:^db           1s        FAIL: ERROR
+`, buf.String())
+}
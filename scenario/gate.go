@@ -0,0 +1,175 @@
+package scenario
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// GatesPath Pumba API endpoint of manual gates
+const GatesPath = "/gates"
+
+// GateHeader request header, required to resolve gate; browsers do not send custom headers cross-origin without
+// CORS preflight, so other sites can not continue chaos through operator's browser
+const GateHeader = "X-Pumba-UI"
+
+// ErrGatesClosed error of manual step, aborted on Pumba termination
+var ErrGatesClosed = errors.New("Manual step is aborted: Pumba is terminating")
+
+// Gate manual step of running scenario, waiting for operator confirmation
+type Gate struct {
+	ID       int       `json:"id"`
+	Scenario string    `json:"scenario"`
+	Step     string    `json:"step"`
+	Action   string    `json:"action"`
+	Since    time.Time `json:"since"`
+}
+
+// pendingGate gate and channel of operator decision: true - continue, false - abort
+type pendingGate struct {
+	Gate
+	decision chan bool
+}
+
+// Gates manual gates of running scenarios, confirmed or aborted with Pumba API: GET /gates lists pending gates,
+// POST /gates/<id> continues scenario, DELETE /gates/<id> aborts it
+type Gates struct {
+	mu      sync.Mutex
+	pending map[int]*pendingGate
+	nextID  int
+	closed  chan struct{}
+	now     func() time.Time
+}
+
+// NewGates creates registry of manual gates
+func NewGates() *Gates {
+	return &Gates{pending: map[int]*pendingGate{}, closed: make(chan struct{}), now: time.Now}
+}
+
+// Wait registers manual step as pending gate and blocks until operator continues (nil) or aborts (error) scenario
+func (g *Gates) Wait(scenario string, step Step) error {
+	g.mu.Lock()
+	if g.isClosed() {
+		g.mu.Unlock()
+		return ErrGatesClosed
+	}
+	g.nextID++
+	p := &pendingGate{
+		Gate:     Gate{ID: g.nextID, Scenario: scenario, Step: step.Name, Action: step.Action, Since: g.now()},
+		decision: make(chan bool, 1),
+	}
+	g.pending[p.ID] = p
+	g.mu.Unlock()
+	log.Infof("Manual step '%s' of scenario '%s' waits at gate %d: POST %s/%d (with %s header) to continue, DELETE to abort", step.Name, scenario, p.ID, GatesPath, p.ID, GateHeader)
+	if <-p.decision {
+		return nil
+	}
+	if g.isClosed() {
+		return ErrGatesClosed
+	}
+	return fmt.Errorf("Manual step '%s' is aborted by operator", step.Name)
+}
+
+// Close aborts pending and future gates, e.g. on Pumba termination
+func (g *Gates) Close() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.isClosed() {
+		return
+	}
+	close(g.closed)
+	for id, p := range g.pending {
+		delete(g.pending, id)
+		p.decision <- false
+	}
+}
+
+// Closed returns channel, that is closed when gates are closed
+func (g *Gates) Closed() <-chan struct{} {
+	return g.closed
+}
+
+func (g *Gates) isClosed() bool {
+	select {
+	case <-g.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Pending returns pending gates, ordered by ID
+func (g *Gates) Pending() []Gate {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	gates := make([]Gate, 0, len(g.pending))
+	for _, p := range g.pending {
+		gates = append(gates, p.Gate)
+	}
+	sort.Sort(byID(gates))
+	return gates
+}
+
+// Resolve continues (proceed - true) or aborts scenario, waiting on gate
+func (g *Gates) Resolve(id int, proceed bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, ok := g.pending[id]
+	if !ok {
+		return fmt.Errorf("No pending gate %d", id)
+	}
+	delete(g.pending, id)
+	p.decision <- proceed
+	return nil
+}
+
+func (g *Gates) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(strings.TrimPrefix(r.URL.Path, GatesPath), "/")
+	if path == "" {
+		if r.Method != "GET" {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(g.Pending())
+		return
+	}
+	id, err := strconv.Atoi(path)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid gate ID '%s'", path), http.StatusBadRequest)
+		return
+	}
+	var proceed bool
+	switch r.Method {
+	case "POST":
+		proceed = true
+	case "DELETE":
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get(GateHeader) == "" {
+		http.Error(w, "Missing "+GateHeader+" header", http.StatusForbidden)
+		return
+	}
+	if err = g.Resolve(id, proceed); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// byID sorts gates by ID
+type byID []Gate
+
+func (s byID) Len() int           { return len(s) }
+func (s byID) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byID) Less(i, j int) bool { return s[i].ID < s[j].ID }
@@ -0,0 +1,111 @@
+package scenario
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// waitPending waits until n gates are pending
+func waitPending(g *Gates, n int) []Gate {
+	for i := 0; i < 1000; i++ {
+		if pending := g.Pending(); len(pending) == n {
+			return pending
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return g.Pending()
+}
+
+func TestGates_Resolve(t *testing.T) {
+	g := NewGates()
+	done := make(chan error, 2)
+	go func() { done <- g.Wait("failover", Step{Name: "kill primary", Action: "kill"}) }()
+	pending := waitPending(g, 1)
+	go func() { done <- g.Wait("failover", Step{Name: "kill replica", Action: "kill"}) }()
+	pending = waitPending(g, 2)
+	if assert.Len(t, pending, 2) {
+		assert.Equal(t, 1, pending[0].ID)
+		assert.Equal(t, "kill primary", pending[0].Step)
+		assert.Equal(t, "failover", pending[0].Scenario)
+		assert.Equal(t, 2, pending[1].ID)
+	}
+
+	assert.NoError(t, g.Resolve(1, true))
+	assert.NoError(t, <-done)
+	assert.NoError(t, g.Resolve(2, false))
+	assert.EqualError(t, <-done, "Manual step 'kill replica' is aborted by operator")
+	assert.EqualError(t, g.Resolve(2, true), "No pending gate 2")
+	assert.Empty(t, g.Pending())
+}
+
+func TestGates_Close(t *testing.T) {
+	g := NewGates()
+	done := make(chan error, 1)
+	go func() { done <- g.Wait("failover", Step{Name: "kill primary"}) }()
+	waitPending(g, 1)
+	g.Close()
+	assert.Equal(t, ErrGatesClosed, <-done)
+	assert.Equal(t, ErrGatesClosed, g.Wait("failover", Step{Name: "kill replica"}))
+	// closed twice
+	g.Close()
+}
+
+func TestGates_ServeHTTP(t *testing.T) {
+	g := NewGates()
+	mux := http.NewServeMux()
+	mux.Handle(GatesPath, g)
+	mux.Handle(GatesPath+"/", g)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	done := make(chan error, 1)
+	go func() { done <- g.Wait("failover", Step{Name: "kill primary", Action: "kill"}) }()
+	waitPending(g, 1)
+
+	resp, err := http.Get(ts.URL + "/gates")
+	if assert.NoError(t, err) {
+		var gates []Gate
+		assert.NoError(t, json.NewDecoder(resp.Body).Decode(&gates))
+		resp.Body.Close()
+		if assert.Len(t, gates, 1) {
+			assert.Equal(t, "kill primary", gates[0].Step)
+			assert.Equal(t, "kill", gates[0].Action)
+		}
+	}
+	post := func(path string, header bool) (*http.Response, error) {
+		req, _ := http.NewRequest("POST", ts.URL+path, nil)
+		if header {
+			req.Header.Set(GateHeader, "1")
+		}
+		return http.DefaultClient.Do(req)
+	}
+	for path, code := range map[string]int{"/gates/abc": http.StatusBadRequest, "/gates/2": http.StatusNotFound} {
+		resp, err = post(path, true)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+			assert.Equal(t, code, resp.StatusCode, path)
+		}
+	}
+	resp, err = post("/gates", true)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+	}
+	// cross-site form POST can not set custom header
+	resp, err = http.PostForm(ts.URL+"/gates/1", nil)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	}
+	assert.Len(t, g.Pending(), 1)
+	resp, err = post("/gates/1", true)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	}
+	assert.NoError(t, <-done)
+}
@@ -0,0 +1,130 @@
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// maxMatrixCells limits number of scenario runs, expanded from matrix
+const maxMatrixCells = 256
+
+// Dimension scenario matrix dimension: template variable and its values, e.g. targets, impairments or intensities
+type Dimension struct {
+	Name   string   `yaml:"name" json:"name"`
+	Values []string `yaml:"values" json:"values"`
+}
+
+// Cell single combination of matrix dimension values and scenario steps, rendered with them; label lists values
+// in order of matrix dimensions, e.g. 'target=api impairment=pause'
+type Cell struct {
+	Label  string            `json:"label"`
+	Values map[string]string `json:"values"`
+	Steps  []Step            `json:"steps"`
+}
+
+// CellResult outcome of scenario run for matrix cell
+type CellResult struct {
+	Cell     int               `json:"cell"`
+	Values   map[string]string `json:"values"`
+	Label    string            `json:"label"`
+	Start    time.Time         `json:"start"`
+	Duration time.Duration     `json:"duration"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// validateMatrix checks matrix dimensions: names must not conflict with scenario parameters, values must be set
+func validateMatrix(matrix []Dimension, params []Param) error {
+	declared := map[string]bool{}
+	for _, p := range params {
+		declared[p.Name] = true
+	}
+	cells := 1
+	for _, d := range matrix {
+		if d.Name == "" {
+			return errors.New("Scenario matrix dimension has no name")
+		}
+		if declared[d.Name] {
+			return fmt.Errorf("Scenario matrix dimension '%s' conflicts with scenario parameter or other dimension", d.Name)
+		}
+		declared[d.Name] = true
+		if len(d.Values) == 0 {
+			return fmt.Errorf("Scenario matrix dimension '%s' has no values", d.Name)
+		}
+		if cells *= len(d.Values); cells > maxMatrixCells {
+			return fmt.Errorf("Scenario matrix has more than %d cells", maxMatrixCells)
+		}
+	}
+	return nil
+}
+
+// expandMatrix returns all combinations of matrix dimension values; the last dimension changes first
+func expandMatrix(matrix []Dimension) []Cell {
+	cells := []Cell{{Values: map[string]string{}}}
+	for _, d := range matrix {
+		var expanded []Cell
+		for _, c := range cells {
+			for _, v := range d.Values {
+				values := map[string]string{d.Name: v}
+				for k, x := range c.Values {
+					values[k] = x
+				}
+				label := d.Name + "=" + v
+				if c.Label != "" {
+					label = c.Label + " " + label
+				}
+				expanded = append(expanded, Cell{Values: values, Label: label})
+			}
+		}
+		cells = expanded
+	}
+	return cells
+}
+
+// runMatrix runs scenario steps for every matrix cell; failed cell does not stop the matrix, so all combinations are
+// explored; returns error, if some cells failed
+func (r *Runner) runMatrix(s *Scenario) error {
+	log.Infof("Running scenario '%s' matrix of %d cells: %s", s.Name, len(s.Cells), s.Description)
+	results := make([]CellResult, 0, len(s.Cells))
+	failed := 0
+	for i, cell := range s.Cells {
+		log.Infof("Matrix cell %d/%d: %s", i+1, len(s.Cells), cell.Label)
+		result := CellResult{Cell: i + 1, Values: cell.Values, Label: cell.Label, Start: time.Now()}
+		err := r.runSteps(s.Name, cell.Steps)
+		result.Duration = time.Since(result.Start)
+		if err != nil {
+			failed++
+			result.Error = err.Error()
+			log.Errorf("Matrix cell %d/%d (%s) failed: %s", i+1, len(s.Cells), cell.Label, err)
+		} else {
+			log.Infof("Matrix cell %d/%d (%s) passed", i+1, len(s.Cells), cell.Label)
+		}
+		results = append(results, result)
+	}
+	if r.ReportMatrix != nil {
+		r.ReportMatrix(s.Name, results)
+	}
+	if failed > 0 {
+		return fmt.Errorf("Scenario '%s' failed in %d of %d matrix cells", s.Name, failed, len(s.Cells))
+	}
+	log.Infof("Scenario '%s' matrix completed", s.Name)
+	return nil
+}
+
+// WriteMatrixTable writes outcomes of scenario matrix cells as text table
+func WriteMatrixTable(out io.Writer, results []CellResult) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CELL\tVALUES\tDURATION\tRESULT")
+	for _, r := range results {
+		result := "success"
+		if r.Error != "" {
+			result = "failure: " + r.Error
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", r.Cell, r.Label, r.Duration, result)
+	}
+	return w.Flush()
+}
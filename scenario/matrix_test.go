@@ -0,0 +1,22 @@
+package scenario
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteMatrixTable(t *testing.T) {
+	results := []CellResult{
+		{Cell: 1, Label: "target=api impairment=pause", Duration: time.Minute},
+		{Cell: 2, Label: "target=api impairment=stop", Duration: time.Second, Error: "ERROR"},
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, WriteMatrixTable(&buf, results))
+	assert.Equal(t, `CELL  VALUES                       DURATION  RESULT
+1     target=api impairment=pause  1m0s      success
+2     target=api impairment=stop   1s        failure: ERROR
+`, buf.String())
+}
@@ -0,0 +1,126 @@
+package scenario
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gaia-adm/pumba/action"
+	"github.com/gaia-adm/pumba/history"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	defaultProbeTimeout  = 30 * time.Second
+	defaultProbeInterval = 1 * time.Second
+	defaultProbeStatus   = http.StatusOK
+)
+
+// probe check function: returns nil when check passed
+type probeFn func(timeout time.Duration) error
+
+// runProbe repeats check till it passes or deadline ('timeout' parameter) is exceeded
+func (r *Runner) runProbe(step Step, check probeFn) (err error) {
+	timeout, err := optDurationParam(step.Params, "timeout", defaultProbeTimeout)
+	if err != nil {
+		return err
+	}
+	interval, err := optDurationParam(step.Params, "interval", defaultProbeInterval)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	defer r.recordProbe(step.Name, start, &err)
+	if err = r.poll(step.Name, timeout, interval, check); err != nil {
+		log.Errorf("FAIL: probe '%s' did not succeed within %s", step.Name, timeout)
+		return fmt.Errorf("Probe did not succeed within %s: %s", timeout, err)
+	}
+	log.Infof("PASS: probe '%s' succeeded after %s", step.Name, time.Since(start))
+	return nil
+}
+
+// poll repeats check every interval till it passes or timeout is exceeded; returns error of the last check
+func (r *Runner) poll(name string, timeout, interval time.Duration, check probeFn) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := check(interval)
+		if err == nil {
+			return nil
+		}
+		log.Debugf("Check '%s' failed: %s", name, err)
+		if time.Now().Add(interval).After(deadline) {
+			return err
+		}
+		r.sleep(interval)
+	}
+}
+
+// recordProbe records probe outcome in experiment history, if recording is set
+func (r *Runner) recordProbe(name string, start time.Time, err *error) {
+	if r.RecordProbe == nil {
+		return
+	}
+	p := history.Probe{Time: start, Duration: time.Since(start), Name: name}
+	if *err != nil {
+		p.Error = (*err).Error()
+	}
+	r.RecordProbe(p)
+}
+
+// execProbe runs shell command in target containers: passes when command exits with 0 in any of them
+func (r *Runner) execProbe(step Step) (probeFn, error) {
+	command := step.Params["command"]
+	if command == "" {
+		return nil, fmt.Errorf("Undefined step parameter 'command'")
+	}
+	names, pattern, err := targetsNamesOrPattern(step.Targets)
+	if err != nil {
+		return nil, err
+	}
+	return func(timeout time.Duration) error {
+		containers, err := action.FindContainers(r.Client, names, pattern)
+		if err != nil {
+			return err
+		}
+		if len(containers) == 0 {
+			return fmt.Errorf("No target containers found")
+		}
+		for _, c := range containers {
+			code, err := r.Client.ExecContainer(c, []string{"sh", "-c", command}, timeout)
+			if err != nil {
+				log.Debugf("Probe command failed in container %s: %s", c.Name(), err)
+				continue
+			}
+			if code == 0 {
+				log.Infof("Probe command succeeded in container %s", c.Name())
+				return nil
+			}
+		}
+		return fmt.Errorf("Command '%s' did not succeed in any target container", command)
+	}, nil
+}
+
+// httpProbe sends GET request to URL: passes when response has expected status code
+func (r *Runner) httpProbe(step Step) (probeFn, error) {
+	url := step.Params["url"]
+	if url == "" {
+		return nil, fmt.Errorf("Undefined step parameter 'url'")
+	}
+	status, err := intParam(step.Params, "status", defaultProbeStatus)
+	if err != nil {
+		return nil, err
+	}
+	return func(timeout time.Duration) error {
+		client := http.Client{Timeout: timeout}
+		resp, err := client.Get(url)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != status {
+			return fmt.Errorf("Unexpected HTTP status %d from %s, expected %d", resp.StatusCode, url, status)
+		}
+		return nil
+	}, nil
+}
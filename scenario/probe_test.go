@@ -0,0 +1,94 @@
+package scenario
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/action"
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/history"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRun_DBFailover(t *testing.T) {
+	s, err := Recipe("db-failover", map[string]string{"primary": "db1", "replicas": "re2:^db[23]", "check": "test -f '/tmp/promoted'"})
+	assert.NoError(t, err)
+	client := container.NewMockSamalbaClient()
+	chaos := action.NewMockChaos()
+	chaos.On("KillContainers", client, []string{"db1"}, "", action.CommandKill{Signal: "SIGKILL"}).Return(nil)
+	db2, db3 := container.NewMockContainer("", "db2", nil), container.NewMockContainer("", "db3", nil)
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{db2, db3}, nil)
+	cmd := []string{"sh", "-c", "test -f '/tmp/promoted'"}
+	// first attempt: no replica promoted yet; second attempt: db3 promoted
+	client.On("ExecContainer", db2, cmd, time.Second).Return(1, nil)
+	client.On("ExecContainer", db3, cmd, time.Second).Return(1, nil).Once()
+	client.On("ExecContainer", db3, cmd, time.Second).Return(0, nil).Once()
+	var slept time.Duration
+	r := &Runner{Client: client, Chaos: chaos, Sleep: func(d time.Duration) { slept += d }}
+	err = r.Run(s)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, slept)
+	chaos.AssertExpectations(t)
+	client.AssertExpectations(t)
+}
+
+func TestRun_ProbeExecDeadline(t *testing.T) {
+	s := &Scenario{Name: "probe", Steps: []Step{
+		{Name: "promoted", Action: "probe-exec", Targets: []string{"db2"}, Params: map[string]string{"command": "false", "timeout": "5ms", "interval": "1ms"}},
+	}}
+	client := container.NewMockSamalbaClient()
+	db2 := container.NewMockContainer("", "db2", nil)
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{db2}, nil)
+	client.On("ExecContainer", db2, []string{"sh", "-c", "false"}, time.Millisecond).Return(-1, errors.New("exec failed"))
+	err := NewRunner(client, action.NewMockChaos(), false).Run(s)
+	assert.EqualError(t, err, "Scenario 'probe' step 1 'promoted' failed: Probe did not succeed within 5ms: Command 'false' did not succeed in any target container")
+}
+
+func TestRun_ProbeExecNoCommand(t *testing.T) {
+	s := &Scenario{Name: "probe", Steps: []Step{{Name: "promoted", Action: "probe-exec"}}}
+	err := NewRunner(nil, action.NewMockChaos(), false).Run(s)
+	assert.EqualError(t, err, "Scenario 'probe' step 1 'promoted' failed: Undefined step parameter 'command'")
+}
+
+func TestRun_ProbeHTTP(t *testing.T) {
+	calls := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+	s, err := Recipe("db-failover-http", map[string]string{"primary": "re2:^db1", "url": ts.URL})
+	assert.NoError(t, err)
+	chaos := action.NewMockChaos()
+	chaos.On("KillContainers", nil, []string{}, "^db1", action.CommandKill{Signal: "SIGKILL"}).Return(nil)
+	var probes []history.Probe
+	r := &Runner{Chaos: chaos, Sleep: func(time.Duration) {}, RecordProbe: func(p history.Probe) { probes = append(probes, p) }}
+	err = r.Run(s)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	chaos.AssertExpectations(t)
+	// probe outcome is recorded once, after it passes
+	if assert.Len(t, probes, 1) {
+		assert.Equal(t, "replica promoted", probes[0].Name)
+		assert.Empty(t, probes[0].Error)
+	}
+}
+
+func TestRun_ProbeHTTPDeadline(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	s := &Scenario{Name: "probe", Steps: []Step{
+		{Name: "healthy", Action: "probe-http", Params: map[string]string{"url": ts.URL, "timeout": "20ms", "interval": "5ms"}},
+	}}
+	err := NewRunner(nil, action.NewMockChaos(), false).Run(s)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Unexpected HTTP status 503")
+}
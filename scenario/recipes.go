@@ -0,0 +1,197 @@
+package scenario
+
+import (
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// built-in recipes: scenario templates for common infrastructures
+var recipes = map[string]string{
+	"kafka-leader-kill": `
+name: kafka-leader-kill
+description: kill Kafka broker, that leads topic partition, wait for leader election and kill the new leader
+params:
+  - name: brokers
+    description: Kafka broker containers (name or RE2 regex with 're2:' prefix)
+    required: true
+  - name: topic
+    description: Kafka topic
+    required: true
+  - name: partition
+    description: topic partition
+    default: "0"
+  - name: describe
+    description: shell command, run inside brokers, that describes topic (with --topic option appended)
+    default: kafka-topics.sh --zookeeper "$KAFKA_ZOOKEEPER_CONNECT" --describe
+  - name: id
+    description: shell expression of broker ID, evaluated inside brokers
+    default: $KAFKA_BROKER_ID
+  - name: signal
+    description: termination signal
+    default: SIGKILL
+  - name: wait
+    description: time to wait for leader election between kills
+    default: 1m
+steps:
+  - name: kill partition leader
+    action: kill
+    targets: ["{{.brokers}}"]
+    select: '{{escape .describe}} --topic {{escape .topic}} | grep -qE "Partition: {{escape .partition}}[[:space:]]+Leader: {{escape .id}}[[:space:]]"'
+    params:
+      signal: "{{.signal}}"
+  - name: wait for leader election
+    action: wait
+    params:
+      duration: "{{.wait}}"
+  - name: kill new partition leader
+    action: kill
+    targets: ["{{.brokers}}"]
+    select: '{{escape .describe}} --topic {{escape .topic}} | grep -qE "Partition: {{escape .partition}}[[:space:]]+Leader: {{escape .id}}[[:space:]]"'
+    params:
+      signal: "{{.signal}}"
+`,
+	"kafka-broker-slow": `
+name: kafka-broker-slow
+description: delay network traffic of a random Kafka broker, emulating a slow replica
+params:
+  - name: brokers
+    description: Kafka broker containers (name or RE2 regex with 're2:' prefix)
+    required: true
+  - name: duration
+    description: network delay duration
+    default: 2m
+  - name: amount
+    description: delay amount; in milliseconds
+    default: "500"
+steps:
+  - name: delay broker traffic
+    action: netem-delay
+    targets: ["{{.brokers}}"]
+    random: true
+    params:
+      duration: "{{.duration}}"
+      amount: "{{.amount}}"
+      variation: "0"
+`,
+	"db-failover": `
+name: db-failover
+description: kill DB cluster primary and check (with SQL command) that a replica is promoted within a deadline
+params:
+  - name: primary
+    description: primary DB container (name or RE2 regex with 're2:' prefix)
+    required: true
+  - name: replicas
+    description: replica DB containers (name or RE2 regex with 're2:' prefix)
+    required: true
+  - name: check
+    description: shell command, run inside replicas, that succeeds on promoted replica
+    default: "psql -U postgres -tAc 'select pg_is_in_recovery()' | grep -q f"
+  - name: deadline
+    description: time for replica promotion
+    default: 30s
+  - name: signal
+    description: termination signal
+    default: SIGKILL
+steps:
+  - name: kill primary
+    action: kill
+    targets: ["{{.primary}}"]
+    params:
+      signal: "{{.signal}}"
+  - name: replica promoted
+    action: probe-exec
+    targets: ["{{.replicas}}"]
+    params:
+      command: '{{escape .check}}'
+      timeout: "{{.deadline}}"
+`,
+	"db-failover-http": `
+name: db-failover-http
+description: kill DB cluster primary and check (with HTTP health endpoint) that a replica is promoted within a deadline
+params:
+  - name: primary
+    description: primary DB container (name or RE2 regex with 're2:' prefix)
+    required: true
+  - name: url
+    description: HTTP URL, that returns expected status once replica is promoted
+    required: true
+  - name: status
+    description: expected HTTP status code
+    default: "200"
+  - name: deadline
+    description: time for replica promotion
+    default: 30s
+  - name: signal
+    description: termination signal
+    default: SIGKILL
+steps:
+  - name: kill primary
+    action: kill
+    targets: ["{{.primary}}"]
+    params:
+      signal: "{{.signal}}"
+  - name: replica promoted
+    action: probe-http
+    params:
+      url: '{{escape .url}}'
+      status: "{{.status}}"
+      timeout: "{{.deadline}}"
+`,
+	"rabbit-partition": `
+name: rabbit-partition
+description: cut a random RabbitMQ node off its cluster with 100% packet loss, so node and peers detect a partition
+params:
+  - name: nodes
+    description: RabbitMQ node containers (name or RE2 regex with 're2:' prefix)
+    required: true
+  - name: peers
+    description: comma separated IPs or CIDRs of cluster peers to cut off; all traffic of the node is dropped if empty
+    default: ""
+  - name: duration
+    description: partition duration; should exceed net_ticktime
+    default: 2m
+steps:
+  - name: partition node
+    action: netem-loss
+    targets: ["{{.nodes}}"]
+    random: true
+    params:
+      duration: "{{.duration}}"
+      percent: "100"
+      target: "{{.peers}}"
+`,
+}
+
+// Recipes returns sorted names of built-in recipes
+func Recipes() []string {
+	names := make([]string, 0, len(recipes))
+	for name := range recipes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Recipe returns built-in recipe rendered with specified parameters
+func Recipe(name string, params map[string]string) (*Scenario, error) {
+	data, ok := recipes[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown recipe: '%s'", name)
+	}
+	return Parse([]byte(data), params)
+}
+
+// RecipeInfo returns built-in recipe description and parameters, without rendering it
+func RecipeInfo(name string) (*Scenario, error) {
+	data, ok := recipes[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown recipe: '%s'", name)
+	}
+	var s Scenario
+	if err := yaml.Unmarshal([]byte(data), &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,449 @@
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gaia-adm/pumba/action"
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/history"
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/gaia-adm/pumba/validate"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	re2Prefix = "re2:"
+	// default values for step parameters
+	defaultStopTime     = 10
+	defaultNetInterface = "eth0"
+	// timeout of step 'select' command in target container
+	defaultSelectTimeout = 10 * time.Second
+)
+
+// Runner executes scenario steps, one after another, with Chaos actions
+type Runner struct {
+	Client container.Client
+	Chaos  action.Chaos
+	// sleep function, used by 'wait' and probe steps; time.Sleep if not set
+	Sleep func(time.Duration)
+	// AllowAll allows chaos steps without targets to affect ALL containers; otherwise such steps fail
+	AllowAll bool
+	// RecordProbe records outcomes of probe steps in experiment history; not recorded if not set
+	RecordProbe func(history.Probe)
+	// Confirm waits for operator confirmation of manual step; error aborts scenario; manual steps fail if not set
+	Confirm func(scenario string, step Step) error
+	// ReportMatrix reports outcomes of matrix cells, after all cells are run; only logged if not set
+	ReportMatrix func(scenario string, results []CellResult)
+	// ReportAssertions reports outcomes of assertion steps of scenario (without matrix), after it is run, even if it
+	// failed; assertions are only logged if not set
+	ReportAssertions func(scenario string, results []AssertionResult)
+	// outcomes of assertion steps of running scenario
+	assertions []AssertionResult
+}
+
+// NewRunner creates new scenario runner
+func NewRunner(client container.Client, chaos action.Chaos, allowAll bool) *Runner {
+	return &Runner{Client: client, Chaos: chaos, Sleep: time.Sleep, AllowAll: allowAll}
+}
+
+// Run executes all scenario steps; stops on first failed step; scenario matrix steps are run for every cell
+func (r *Runner) Run(s *Scenario) error {
+	if len(s.Cells) > 0 {
+		return r.runMatrix(s)
+	}
+	log.Infof("Running scenario '%s': %s", s.Name, s.Description)
+	r.assertions = nil
+	err := r.runSteps(s.Name, s.Steps)
+	if len(r.assertions) > 0 && r.ReportAssertions != nil {
+		r.ReportAssertions(s.Name, r.assertions)
+	}
+	if err != nil {
+		return err
+	}
+	log.Infof("Scenario '%s' completed", s.Name)
+	return nil
+}
+
+// runSteps executes steps one after another; stops on first failed step
+func (r *Runner) runSteps(scenario string, steps []Step) error {
+	for i, step := range steps {
+		what := step.Action
+		if step.Assert != "" {
+			what = "assert " + step.Assert
+		}
+		log.Infof("Step %d/%d '%s': %s", i+1, len(steps), step.Name, what)
+		if step.Manual {
+			if err := r.confirm(scenario, step); err != nil {
+				return fmt.Errorf("Scenario '%s' step %d '%s' failed: %s", scenario, i+1, step.Name, err)
+			}
+		}
+		if err := r.runStep(i+1, step); err != nil {
+			return fmt.Errorf("Scenario '%s' step %d '%s' failed: %s", scenario, i+1, step.Name, err)
+		}
+	}
+	return nil
+}
+
+// confirm waits for operator confirmation of manual step
+func (r *Runner) confirm(scenario string, step Step) error {
+	if r.Confirm == nil {
+		return errors.New("Manual step requires operator confirmation: run Pumba from TTY or serve Pumba API (--api-addr)")
+	}
+	return r.Confirm(scenario, step)
+}
+
+func (r *Runner) runStep(index int, step Step) error {
+	if step.Assert != "" {
+		if step.Action != "" {
+			return errors.New("Step can not have both 'action' and 'assert'")
+		}
+		return r.runAssert(index, step)
+	}
+	switch step.Action {
+	case "wait":
+		d, err := durationParam(step.Params, "duration")
+		if err != nil {
+			return err
+		}
+		r.sleep(d)
+		return nil
+	case "probe-exec":
+		check, err := r.execProbe(step)
+		if err != nil {
+			return err
+		}
+		return r.runProbe(step, check)
+	case "probe-http":
+		check, err := r.httpProbe(step)
+		if err != nil {
+			return err
+		}
+		return r.runProbe(step, check)
+	}
+	cmd, chaosFn, err := r.buildCommand(step)
+	if err != nil {
+		return err
+	}
+	names, pattern, err := targetsNamesOrPattern(step.Targets)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 && pattern == "" && !r.AllowAll {
+		return errors.New("No step targets: set step targets or use --all option to target ALL containers")
+	}
+	if names, pattern, err = r.selectTargets(step, names, pattern); err != nil {
+		return err
+	}
+	return chaosFn(r.Client, names, pattern, cmd)
+}
+
+// selectTargets narrows step targets down to containers, where 'select' command succeeds, and then to one random
+// container for random step; selected containers are passed to chaos action by name
+func (r *Runner) selectTargets(step Step, names []string, pattern string) ([]string, string, error) {
+	if step.Select == "" && !step.Random {
+		return names, pattern, nil
+	}
+	containers, err := action.FindContainers(r.Client, names, pattern)
+	if err != nil {
+		return nil, "", err
+	}
+	if step.Select != "" {
+		var selected []container.Container
+		for _, c := range containers {
+			code, err := r.Client.ExecContainer(c, []string{"sh", "-c", step.Select}, defaultSelectTimeout)
+			if err != nil {
+				log.Debugf("Select command failed in container %s: %s", c.Name(), err)
+				continue
+			}
+			if code == 0 {
+				selected = append(selected, c)
+			}
+		}
+		containers = selected
+	}
+	if len(containers) == 0 {
+		return nil, "", errors.New("No step target containers selected")
+	}
+	if step.Random {
+		rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+		containers = []container.Container{containers[rnd.Intn(len(containers))]}
+	}
+	selected := make([]string, len(containers))
+	for i, c := range containers {
+		selected[i] = strings.TrimPrefix(c.Name(), "/")
+	}
+	log.Infof("Step '%s' targets selected: %s", step.Name, strings.Join(selected, ", "))
+	return selected, "", nil
+}
+
+func (r *Runner) buildCommand(step Step) (interface{}, func(container.Client, []string, string, interface{}) error, error) {
+	p := step.Params
+	switch step.Action {
+	case "kill":
+		signal := p["signal"]
+		if signal == "" {
+			signal = action.DefaultKillSignal
+		}
+		if err := validate.Signal(signal); err != nil {
+			return nil, nil, paramError("signal", err)
+		}
+		cascade, err := boolParam(p, "cascade")
+		if err != nil {
+			return nil, nil, err
+		}
+		return action.CommandKill{Signal: signal, Cascade: cascade}, r.Chaos.KillContainers, nil
+	case "stop":
+		waitTime, err := intParam(p, "time", defaultStopTime)
+		if err != nil {
+			return nil, nil, err
+		}
+		cmd := action.CommandStop{WaitTime: waitTime}
+		if cmd.Restart, err = boolParam(p, "restart"); err != nil {
+			return nil, nil, err
+		}
+		if cmd.Restart {
+			if cmd.Duration, err = durationParam(p, "duration"); err != nil {
+				return nil, nil, err
+			}
+		}
+		return cmd, r.Chaos.StopContainers, nil
+	case "rm":
+		cmd := action.CommandRemove{}
+		var err error
+		if cmd.Force, err = boolParam(p, "force"); err != nil {
+			return nil, nil, err
+		}
+		if cmd.Links, err = boolParam(p, "links"); err != nil {
+			return nil, nil, err
+		}
+		if cmd.Volumes, err = boolParam(p, "volumes"); err != nil {
+			return nil, nil, err
+		}
+		return cmd, r.Chaos.RemoveContainers, nil
+	case "pause":
+		d, err := durationParam(p, "duration")
+		if err != nil {
+			return nil, nil, err
+		}
+		return action.CommandPause{Duration: d, ExecHooks: execHooks(p)}, r.Chaos.PauseContainers, nil
+	case "netem-delay":
+		base, err := netemParams(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		cmd := action.CommandNetemDelay{CommandNetem: base}
+		if cmd.Amount, err = intParam(p, "amount", 100); err != nil {
+			return nil, nil, err
+		}
+		if cmd.Variation, err = intParam(p, "variation", 10); err != nil {
+			return nil, nil, err
+		}
+		if cmd.Correlation, err = intParam(p, "correlation", 20); err != nil {
+			return nil, nil, err
+		}
+		if err = validate.Percent("delay correlation", cmd.Correlation); err != nil {
+			return nil, nil, paramError("correlation", err)
+		}
+		jitterOnly, err := boolParam(p, "jitter-only")
+		if err != nil {
+			return nil, nil, err
+		}
+		if jitterOnly {
+			cmd.Amount = 0
+		}
+		if cmd.Distribution = p["distribution"]; cmd.Distribution != "" && !netem.ValidDistribution(cmd.Distribution) {
+			return nil, nil, fmt.Errorf("Unsupported delay distribution: '%s'", cmd.Distribution)
+		}
+		if cmd.Reorder, err = intParam(p, "reorder", 0); err != nil {
+			return nil, nil, err
+		}
+		if err = validate.Percent("reorder percent", cmd.Reorder); err != nil {
+			return nil, nil, paramError("reorder", err)
+		}
+		if cmd.Limit, err = intParam(p, "limit", 0); err != nil {
+			return nil, nil, err
+		}
+		return cmd, r.Chaos.NetemDelayContainers, nil
+	case "netem-loss":
+		base, err := netemParams(p)
+		if err != nil {
+			return nil, nil, err
+		}
+		cmd := action.CommandNetemLoss{CommandNetem: base, Model: netem.LossModelRandom}
+		if cmd.Percent, err = intParam(p, "percent", 1); err != nil {
+			return nil, nil, err
+		}
+		if err = validate.Range("loss percent", cmd.Percent, 1, 100); err != nil {
+			return nil, nil, paramError("percent", err)
+		}
+		if cmd.Correlation, err = intParam(p, "correlation", 0); err != nil {
+			return nil, nil, err
+		}
+		if err = validate.Percent("loss correlation", cmd.Correlation); err != nil {
+			return nil, nil, paramError("correlation", err)
+		}
+		return cmd, r.Chaos.NetemLossContainers, nil
+	}
+	return nil, nil, fmt.Errorf("Unsupported step action: '%s'", step.Action)
+}
+
+// netemParams returns parameters of netem steps, common to netem actions: network interface, traffic filters,
+// duration, hooks and verification probe
+func netemParams(p map[string]string) (action.CommandNetem, error) {
+	cmd := action.CommandNetem{NetInterface: p["interface"], Network: p["network"], TargetAlias: p["target-alias"], ExecHooks: execHooks(p)}
+	if cmd.NetInterface == "" {
+		cmd.NetInterface = defaultNetInterface
+	}
+	if err := validate.Interface(cmd.NetInterface); err != nil {
+		return cmd, paramError("interface", err)
+	}
+	if target := p["target"]; target != "" {
+		targets, hosts, err := validate.Targets(target)
+		if err != nil {
+			return cmd, paramError("target", err)
+		}
+		cmd.Targets, cmd.TargetHosts = targets, hosts
+	}
+	if exclude := p["exclude"]; exclude != "" {
+		excludes, err := validate.Excludes(exclude)
+		if err != nil {
+			return cmd, paramError("exclude", err)
+		}
+		cmd.Exclude = excludes
+	}
+	if cmd.TargetAlias != "" {
+		if err := validate.Hostname(cmd.TargetAlias); err != nil {
+			return cmd, paramError("target-alias", err)
+		}
+	}
+	var err error
+	if cmd.TargetPeer, err = boolParam(p, "target-peer"); err != nil {
+		return cmd, err
+	}
+	if cmd.Protocol = p["protocol"]; cmd.Protocol != "" && !netem.ValidProtocol(cmd.Protocol) {
+		return cmd, fmt.Errorf("Unsupported protocol: '%s'", cmd.Protocol)
+	}
+	if cmd.Duration, err = durationParam(p, "duration"); err != nil {
+		return cmd, err
+	}
+	if cmd.ReapplyOnRestart, err = boolParam(p, "reapply-on-restart"); err != nil {
+		return cmd, err
+	}
+	if cmd.Probe = p["probe"]; cmd.Probe != "" {
+		if err = validate.Probe(cmd.Probe); err != nil {
+			return cmd, paramError("probe", err)
+		}
+	}
+	if cmd.Port, err = intParam(p, "port", 0); err != nil {
+		return cmd, err
+	}
+	if err = validate.Range("port", cmd.Port, 0, 65535); err != nil {
+		return cmd, paramError("port", err)
+	}
+	if cmd.FwMark, err = boolParam(p, "fwmark"); err != nil {
+		return cmd, err
+	}
+	if cmd.UID = p["uid"]; cmd.UID != "" && !cmd.FwMark {
+		return cmd, fmt.Errorf("Step parameter 'uid' requires 'fwmark'")
+	}
+	if cmd.UID != "" {
+		if err = validate.User(cmd.UID); err != nil {
+			return cmd, paramError("uid", err)
+		}
+	}
+	if cmd.DstPercent, err = intParam(p, "dst-percent", 0); err != nil {
+		return cmd, err
+	}
+	if err = validate.Percent("destination percent", cmd.DstPercent); err != nil {
+		return cmd, paramError("dst-percent", err)
+	}
+	if cmd.DstPercent > 0 && (len(cmd.Targets) > 0 || len(cmd.TargetHosts) > 0 || cmd.TargetAlias != "" || cmd.TargetPeer || cmd.FwMark) {
+		return cmd, fmt.Errorf("Step parameter 'dst-percent' is not supported with 'target', 'target-alias', 'target-peer' and 'fwmark'")
+	}
+	return cmd, nil
+}
+
+func (r *Runner) sleep(d time.Duration) {
+	if r.Sleep == nil {
+		time.Sleep(d)
+		return
+	}
+	r.Sleep(d)
+}
+
+// targetsNamesOrPattern converts step targets into container names or RE2 pattern
+func targetsNamesOrPattern(targets []string) ([]string, string, error) {
+	if len(targets) == 1 && strings.HasPrefix(targets[0], re2Prefix) {
+		pattern := strings.TrimPrefix(targets[0], re2Prefix)
+		if _, err := validate.Pattern(pattern); err != nil {
+			return nil, "", err
+		}
+		return []string{}, pattern, nil
+	}
+	names := []string{}
+	for _, t := range targets {
+		if t != "" {
+			names = append(names, t)
+		}
+	}
+	return names, "", nil
+}
+
+// paramError returns error of invalid step parameter
+func paramError(name string, err error) error {
+	return fmt.Errorf("Invalid step parameter '%s': %s", name, err)
+}
+
+func durationParam(p map[string]string, name string) (time.Duration, error) {
+	v, ok := p[name]
+	if !ok || v == "" {
+		return 0, fmt.Errorf("Undefined step parameter '%s'", name)
+	}
+	d, err := validate.Duration(v)
+	if err != nil {
+		return 0, paramError(name, err)
+	}
+	return d, nil
+}
+
+func optDurationParam(p map[string]string, name string, def time.Duration) (time.Duration, error) {
+	if v, ok := p[name]; !ok || v == "" {
+		return def, nil
+	}
+	return durationParam(p, name)
+}
+
+// execHooks returns in-container exec hooks of step: 'exec-before' and 'exec-after' parameters
+func execHooks(p map[string]string) action.ExecHooks {
+	return action.ExecHooks{Before: p["exec-before"], After: p["exec-after"]}
+}
+
+func intParam(p map[string]string, name string, def int) (int, error) {
+	v, ok := p[name]
+	if !ok || v == "" {
+		return def, nil
+	}
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, paramError(name, err)
+	}
+	return i, nil
+}
+
+func boolParam(p map[string]string, name string) (bool, error) {
+	v, ok := p[name]
+	if !ok || v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("Invalid step parameter '%s': %s", name, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,256 @@
+package scenario
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/action"
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRun_KafkaLeaderKill(t *testing.T) {
+	s, err := Recipe("kafka-leader-kill", map[string]string{"brokers": "re2:kafka-", "topic": "orders", "wait": "10s"})
+	assert.NoError(t, err)
+	client := container.NewMockSamalbaClient()
+	k1, k2, k3 := container.NewMockContainer("", "/kafka-1", nil), container.NewMockContainer("", "/kafka-2", nil), container.NewMockContainer("", "/kafka-3", nil)
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{k1, k2, k3}, nil).Once()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{k1, k3}, nil).Once()
+	cmd := []string{"sh", "-c", `kafka-topics.sh --zookeeper "$KAFKA_ZOOKEEPER_CONNECT" --describe --topic orders | grep -qE "Partition: 0[[:space:]]+Leader: $KAFKA_BROKER_ID[[:space:]]"`}
+	// kafka-2 leads partition, then kafka-3 is elected
+	client.On("ExecContainer", k1, cmd, defaultSelectTimeout).Return(1, nil)
+	client.On("ExecContainer", k2, cmd, defaultSelectTimeout).Return(0, nil)
+	client.On("ExecContainer", k3, cmd, defaultSelectTimeout).Return(1, nil).Once()
+	client.On("ExecContainer", k3, cmd, defaultSelectTimeout).Return(0, nil).Once()
+	chaos := action.NewMockChaos()
+	chaos.On("KillContainers", client, []string{"kafka-2"}, "", action.CommandKill{Signal: "SIGKILL"}).Return(nil).Once()
+	chaos.On("KillContainers", client, []string{"kafka-3"}, "", action.CommandKill{Signal: "SIGKILL"}).Return(nil).Once()
+	var slept time.Duration
+	r := &Runner{Client: client, Chaos: chaos, Sleep: func(d time.Duration) { slept += d }}
+	err = r.Run(s)
+	assert.NoError(t, err)
+	assert.Equal(t, 10*time.Second, slept)
+	client.AssertExpectations(t)
+	chaos.AssertExpectations(t)
+}
+
+func TestRun_KafkaLeaderKillNoLeader(t *testing.T) {
+	s, err := Recipe("kafka-leader-kill", map[string]string{"brokers": "re2:kafka-", "topic": "orders"})
+	assert.NoError(t, err)
+	client := container.NewMockSamalbaClient()
+	k1 := container.NewMockContainer("", "/kafka-1", nil)
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{k1}, nil)
+	client.On("ExecContainer", k1, mock.Anything, defaultSelectTimeout).Return(1, nil)
+	chaos := action.NewMockChaos()
+	err = NewRunner(client, chaos, false).Run(s)
+	assert.EqualError(t, err, "Scenario 'kafka-leader-kill' step 1 'kill partition leader' failed: No step target containers selected")
+	chaos.AssertExpectations(t)
+}
+
+func TestRun_RabbitPartition(t *testing.T) {
+	s, err := Recipe("rabbit-partition", map[string]string{"nodes": "re2:^rabbit", "peers": "10.0.1.0/24"})
+	assert.NoError(t, err)
+	client := container.NewMockSamalbaClient()
+	client.On("ListContainers", mock.AnythingOfType("container.Filter")).Return([]container.Container{container.NewMockContainer("", "/rabbit1", nil)}, nil)
+	chaos := action.NewMockChaos()
+	_, peers, _ := net.ParseCIDR("10.0.1.0/24")
+	cmd := action.CommandNetemLoss{CommandNetem: action.CommandNetem{NetInterface: "eth0", Targets: []*net.IPNet{peers}, Duration: 2 * time.Minute}, Model: "random", Percent: 100}
+	chaos.On("NetemLossContainers", client, []string{"rabbit1"}, "", cmd).Return(nil)
+	err = NewRunner(client, chaos, false).Run(s)
+	assert.NoError(t, err)
+	chaos.AssertExpectations(t)
+}
+
+func TestRun_NetemDelay(t *testing.T) {
+	s := &Scenario{Name: "delay", Steps: []Step{
+		{Name: "delay", Action: "netem-delay", Targets: []string{"c1", "c2"}, Params: map[string]string{"duration": "1m", "amount": "300"}},
+	}}
+	client := container.NewMockSamalbaClient()
+	chaos := action.NewMockChaos()
+	cmd := action.CommandNetemDelay{CommandNetem: action.CommandNetem{NetInterface: "eth0", Duration: time.Minute}, Amount: 300, Variation: 10, Correlation: 20}
+	chaos.On("NetemDelayContainers", client, []string{"c1", "c2"}, "", cmd).Return(nil)
+	err := NewRunner(client, chaos, false).Run(s)
+	assert.NoError(t, err)
+	chaos.AssertExpectations(t)
+}
+
+func TestRun_NetemDelayFwMark(t *testing.T) {
+	s := &Scenario{Name: "delay", Steps: []Step{
+		{Name: "delay", Action: "netem-delay", Targets: []string{"c1", "c2"}, Params: map[string]string{"duration": "1m", "fwmark": "true", "port": "5432", "uid": "1000"}},
+	}}
+	client := container.NewMockSamalbaClient()
+	chaos := action.NewMockChaos()
+	cmd := action.CommandNetemDelay{CommandNetem: action.CommandNetem{NetInterface: "eth0", Duration: time.Minute, Port: 5432, FwMark: true, UID: "1000"}, Amount: 100, Variation: 10, Correlation: 20}
+	chaos.On("NetemDelayContainers", client, []string{"c1", "c2"}, "", cmd).Return(nil)
+	err := NewRunner(client, chaos, false).Run(s)
+	assert.NoError(t, err)
+	chaos.AssertExpectations(t)
+}
+
+func TestRun_NetemDelayJitterOnly(t *testing.T) {
+	s := &Scenario{Name: "jitter", Steps: []Step{
+		{Name: "jitter", Action: "netem-delay", Targets: []string{"c1"}, Params: map[string]string{"duration": "1m", "jitter-only": "true", "variation": "50", "distribution": "normal", "limit": "5000"}},
+	}}
+	client := container.NewMockSamalbaClient()
+	chaos := action.NewMockChaos()
+	cmd := action.CommandNetemDelay{CommandNetem: action.CommandNetem{NetInterface: "eth0", Duration: time.Minute}, Variation: 50, Correlation: 20, Distribution: "normal", Limit: 5000}
+	chaos.On("NetemDelayContainers", client, []string{"c1"}, "", cmd).Return(nil)
+	err := NewRunner(client, chaos, false).Run(s)
+	assert.NoError(t, err)
+	chaos.AssertExpectations(t)
+}
+
+func TestRun_PauseExecHooks(t *testing.T) {
+	s := &Scenario{Name: "pause", Steps: []Step{
+		{Name: "pause", Action: "pause", Targets: []string{"c1", "c2"}, Params: map[string]string{"duration": "1m", "exec-after": "nginx -s reload"}},
+	}}
+	client := container.NewMockSamalbaClient()
+	chaos := action.NewMockChaos()
+	cmd := action.CommandPause{Duration: time.Minute, ExecHooks: action.ExecHooks{After: "nginx -s reload"}}
+	chaos.On("PauseContainers", client, []string{"c1", "c2"}, "", cmd).Return(nil)
+	err := NewRunner(client, chaos, false).Run(s)
+	assert.NoError(t, err)
+	chaos.AssertExpectations(t)
+}
+
+func TestRun_StopRestart(t *testing.T) {
+	s := &Scenario{Name: "blackout", Steps: []Step{
+		{Name: "blackout", Action: "stop", Targets: []string{"c1"}, Params: map[string]string{"duration": "5m", "restart": "true"}},
+	}}
+	client := container.NewMockSamalbaClient()
+	chaos := action.NewMockChaos()
+	cmd := action.CommandStop{WaitTime: 10, Restart: true, Duration: 5 * time.Minute}
+	chaos.On("StopContainers", client, []string{"c1"}, "", cmd).Return(nil)
+	err := NewRunner(client, chaos, false).Run(s)
+	assert.NoError(t, err)
+	chaos.AssertExpectations(t)
+}
+
+func TestRun_StopOnError(t *testing.T) {
+	s := &Scenario{Name: "fail", Steps: []Step{
+		{Name: "stop", Action: "stop", Targets: []string{"c1"}},
+		{Name: "rm", Action: "rm", Targets: []string{"c1"}},
+	}}
+	client := container.NewMockSamalbaClient()
+	chaos := action.NewMockChaos()
+	chaos.On("StopContainers", client, []string{"c1"}, "", action.CommandStop{WaitTime: 10}).Return(errors.New("ERROR"))
+	err := NewRunner(client, chaos, false).Run(s)
+	assert.EqualError(t, err, "Scenario 'fail' step 1 'stop' failed: ERROR")
+	chaos.AssertExpectations(t)
+}
+
+func TestRun_BadStep(t *testing.T) {
+	tests := []struct {
+		step Step
+		err  string
+	}{
+		{Step{Name: "s", Action: "bad"}, "Unsupported step action: 'bad'"},
+		{Step{Name: "s", Action: "pause"}, "Undefined step parameter 'duration'"},
+		{Step{Name: "s", Action: "wait", Params: map[string]string{"duration": "BAD"}}, "Invalid step parameter 'duration'"},
+		{Step{Name: "s", Action: "stop", Params: map[string]string{"time": "BAD"}}, "Invalid step parameter 'time'"},
+		{Step{Name: "s", Action: "stop", Params: map[string]string{"restart": "true"}}, "Undefined step parameter 'duration'"},
+		{Step{Name: "s", Action: "rm", Params: map[string]string{"force": "BAD"}}, "Invalid step parameter 'force'"},
+		{Step{Name: "s", Action: "netem-delay", Params: map[string]string{"duration": "1m", "protocol": "sctp"}}, "Unsupported protocol: 'sctp'"},
+		{Step{Name: "s", Action: "netem-delay", Params: map[string]string{"duration": "1m", "uid": "1000"}}, "Step parameter 'uid' requires 'fwmark'"},
+		{Step{Name: "s", Action: "netem-delay", Params: map[string]string{"duration": "1m", "distribution": "gauss"}}, "Unsupported delay distribution: 'gauss'"},
+		{Step{Name: "s", Action: "netem-delay", Params: map[string]string{"duration": "1m", "dst-percent": "150"}}, "Invalid step parameter 'dst-percent'"},
+		{Step{Name: "s", Action: "netem-delay", Params: map[string]string{"duration": "1m", "dst-percent": "50", "fwmark": "true"}}, "Step parameter 'dst-percent' is not supported"},
+		{Step{Name: "s", Action: "kill", Params: map[string]string{"signal": "SIGFOO"}}, "Invalid step parameter 'signal': Unexpected signal: SIGFOO"},
+		{Step{Name: "s", Action: "kill", Targets: []string{"re2:(api"}}, "Invalid RE2 pattern '(api'"},
+		{Step{Name: "s", Action: "netem-delay", Params: map[string]string{"duration": "1m", "interface": "eth0;reboot"}}, "Invalid step parameter 'interface': Invalid network interface name 'eth0;reboot'"},
+		{Step{Name: "s", Action: "netem-delay", Params: map[string]string{"duration": "1m", "target": "10.0.0"}}, "Invalid step parameter 'target': Invalid IP address '10.0.0'"},
+		{Step{Name: "s", Action: "netem-delay", Params: map[string]string{"duration": "1m", "exclude": "kubernetes.default"}}, "Invalid step parameter 'exclude': Invalid exclude list 'kubernetes.default'"},
+		{Step{Name: "s", Action: "netem-delay", Params: map[string]string{"duration": "1m", "target-alias": "db;reboot"}}, "Invalid step parameter 'target-alias': Invalid host name 'db;reboot'"},
+		{Step{Name: "s", Action: "netem-delay", Params: map[string]string{"duration": "1m", "probe": "ftp://db/"}}, "Invalid step parameter 'probe'"},
+		{Step{Name: "s", Action: "netem-delay", Params: map[string]string{"duration": "1m", "correlation": "101"}}, "Invalid step parameter 'correlation'"},
+		{Step{Name: "s", Action: "netem-delay", Params: map[string]string{"duration": "1m", "port": "70000"}}, "Invalid step parameter 'port'"},
+		{Step{Name: "s", Action: "netem-delay", Params: map[string]string{"duration": "1m", "fwmark": "true", "uid": "root;id"}}, "Invalid step parameter 'uid'"},
+		{Step{Name: "s", Action: "wait", Params: map[string]string{"duration": "-1m"}}, "Invalid step parameter 'duration': Invalid duration '-1m': must not be negative"},
+	}
+	for _, tt := range tests {
+		err := NewRunner(nil, action.NewMockChaos(), false).Run(&Scenario{Name: "bad", Steps: []Step{tt.step}})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), tt.err)
+	}
+}
+
+func TestRunner_NoTargets(t *testing.T) {
+	chaos := action.NewMockChaos()
+	s := &Scenario{Name: "all", Steps: []Step{
+		{Name: "kill-all", Action: "kill", Targets: []string{""}},
+	}}
+	err := NewRunner(nil, chaos, false).Run(s)
+	assert.EqualError(t, err, "Scenario 'all' step 1 'kill-all' failed: No step targets: set step targets or use --all option to target ALL containers")
+	chaos.AssertNotCalled(t, "KillContainers", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	chaos.On("KillContainers", nil, []string{}, "", action.CommandKill{Signal: "SIGKILL"}).Return(nil)
+	err = NewRunner(nil, chaos, true).Run(s)
+	assert.NoError(t, err)
+	chaos.AssertExpectations(t)
+}
+
+func TestRun_ManualStep(t *testing.T) {
+	s := &Scenario{Name: "game-day", Steps: []Step{
+		{Name: "kill primary", Action: "kill", Targets: []string{"db1"}, Manual: true},
+		{Name: "kill replica", Action: "kill", Targets: []string{"db2"}, Manual: true},
+	}}
+	client := container.NewMockSamalbaClient()
+	chaos := action.NewMockChaos()
+	chaos.On("KillContainers", client, []string{"db1"}, "", action.CommandKill{Signal: "SIGKILL"}).Return(nil)
+	var confirmed []string
+	r := NewRunner(client, chaos, false)
+	r.Confirm = func(scenario string, step Step) error {
+		confirmed = append(confirmed, scenario+"/"+step.Name)
+		if step.Name == "kill replica" {
+			return errors.New("Manual step 'kill replica' is not confirmed")
+		}
+		return nil
+	}
+	err := r.Run(s)
+	assert.EqualError(t, err, "Scenario 'game-day' step 2 'kill replica' failed: Manual step 'kill replica' is not confirmed")
+	assert.Equal(t, []string{"game-day/kill primary", "game-day/kill replica"}, confirmed)
+	chaos.AssertExpectations(t)
+}
+
+func TestRun_ManualStepNoConfirm(t *testing.T) {
+	s := &Scenario{Name: "game-day", Steps: []Step{
+		{Name: "kill primary", Action: "kill", Targets: []string{"db1"}, Manual: true},
+	}}
+	chaos := action.NewMockChaos()
+	err := NewRunner(container.NewMockSamalbaClient(), chaos, false).Run(s)
+	assert.EqualError(t, err, "Scenario 'game-day' step 1 'kill primary' failed: Manual step requires operator confirmation: run Pumba from TTY or serve Pumba API (--api-addr)")
+	chaos.AssertExpectations(t)
+}
+
+func TestRun_Matrix(t *testing.T) {
+	s, err := Parse([]byte(testMatrixScenario), nil)
+	assert.NoError(t, err)
+	client := container.NewMockSamalbaClient()
+	chaos := action.NewMockChaos()
+	pause := action.CommandPause{Duration: time.Minute}
+	stop := action.CommandStop{WaitTime: 10}
+	chaos.On("PauseContainers", client, []string{"api"}, "", pause).Return(nil)
+	chaos.On("StopContainers", client, []string{"api"}, "", stop).Return(errors.New("ERROR"))
+	chaos.On("PauseContainers", client, []string{"db"}, "", pause).Return(nil)
+	chaos.On("StopContainers", client, []string{"db"}, "", stop).Return(nil)
+	var results []CellResult
+	r := NewRunner(client, chaos, false)
+	r.ReportMatrix = func(scenario string, cells []CellResult) {
+		assert.Equal(t, "explore", scenario)
+		results = cells
+	}
+	err = r.Run(s)
+	// failed cell does not stop the matrix
+	assert.EqualError(t, err, "Scenario 'explore' failed in 1 of 4 matrix cells")
+	chaos.AssertExpectations(t)
+	assert.Len(t, results, 4)
+	for i, c := range results {
+		assert.Equal(t, i+1, c.Cell)
+		assert.Equal(t, s.Cells[i].Label, c.Label)
+	}
+	assert.Equal(t, "Scenario 'explore' step 1 'stop api' failed: ERROR", results[1].Error)
+	assert.Empty(t, results[0].Error)
+}
@@ -0,0 +1,175 @@
+package scenario
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Param scenario parameter declaration
+type Param struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+	Default     string `yaml:"default" json:"default,omitempty"`
+	Required    bool   `yaml:"required" json:"required"`
+}
+
+// Step single scenario step: chaos action, wait, probe or assertion on Docker state ('assert' instead of 'action');
+// manual step waits for operator confirmation before it runs; chaos step with 'select' shell command affects only
+// target containers, where the command succeeds
+type Step struct {
+	Name    string            `yaml:"name" json:"name,omitempty"`
+	Action  string            `yaml:"action" json:"action,omitempty"`
+	Assert  string            `yaml:"assert" json:"assert,omitempty"`
+	Targets []string          `yaml:"targets" json:"targets,omitempty"`
+	Select  string            `yaml:"select" json:"select,omitempty"`
+	Random  bool              `yaml:"random" json:"random,omitempty"`
+	Manual  bool              `yaml:"manual" json:"manual,omitempty"`
+	Params  map[string]string `yaml:"params" json:"params,omitempty"`
+}
+
+// Scenario is a sequence of chaos steps, executed one after another; scenario with matrix is expanded into cells
+// (all combinations of matrix dimension values), and its steps are run for every cell
+type Scenario struct {
+	Name        string      `yaml:"name" json:"name"`
+	Description string      `yaml:"description" json:"description"`
+	Params      []Param     `yaml:"params" json:"params"`
+	Matrix      []Dimension `yaml:"matrix" json:"matrix,omitempty"`
+	Steps       []Step      `yaml:"steps" json:"steps,omitempty"`
+	Cells       []Cell      `yaml:"-" json:"cells,omitempty"`
+}
+
+// functions available in scenario templates
+var templateFuncs = template.FuncMap{
+	// escape value for single-quoted YAML string
+	"escape": func(s string) string { return strings.Replace(s, "'", "''", -1) },
+}
+
+// Load reads scenario template from file and renders it with specified parameters
+func Load(filename string, params map[string]string) (*Scenario, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data, params)
+}
+
+// Parse renders scenario template with parameters and parses the result; template may
+// refer parameters as {{.name}} (or '{{escape .name}}' for values with quotes);
+// parameter defaults are taken from 'params' section
+func Parse(data []byte, params map[string]string) (*Scenario, error) {
+	// first pass: read parameter declarations
+	var decl Scenario
+	if err := yaml.Unmarshal(data, &decl); err != nil {
+		return nil, fmt.Errorf("Failed to parse scenario: %s", err)
+	}
+	values := map[string]string{}
+	for _, p := range decl.Params {
+		if v, ok := params[p.Name]; ok {
+			values[p.Name] = v
+		} else if p.Required {
+			return nil, fmt.Errorf("Missing required scenario parameter: '%s'", p.Name)
+		} else {
+			values[p.Name] = p.Default
+		}
+	}
+	for k, v := range params {
+		if _, ok := values[k]; !ok {
+			return nil, fmt.Errorf("Unknown scenario parameter: '%s'", k)
+		}
+		values[k] = v
+	}
+	// second pass: render template and parse steps
+	tmpl, err := template.New(decl.Name).Option("missingkey=error").Funcs(templateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse scenario template: %s", err)
+	}
+	if len(decl.Matrix) > 0 {
+		return parseMatrix(tmpl, &decl, values)
+	}
+	return render(tmpl, values)
+}
+
+// parseMatrix renders scenario template for every matrix cell: matrix dimensions are template variables, as
+// parameters are
+func parseMatrix(tmpl *template.Template, decl *Scenario, values map[string]string) (*Scenario, error) {
+	if err := validateMatrix(decl.Matrix, decl.Params); err != nil {
+		return nil, err
+	}
+	var s *Scenario
+	var cells []Cell
+	for _, cell := range expandMatrix(decl.Matrix) {
+		cellValues := map[string]string{}
+		for k, v := range values {
+			cellValues[k] = v
+		}
+		for k, v := range cell.Values {
+			cellValues[k] = v
+		}
+		rendered, err := render(tmpl, cellValues)
+		if err != nil {
+			return nil, fmt.Errorf("Matrix cell (%s): %s", cell.Label, err)
+		}
+		cell.Steps = rendered.Steps
+		cells = append(cells, cell)
+		s = rendered
+	}
+	s.Steps = nil
+	s.Cells = cells
+	return s, nil
+}
+
+// render renders scenario template with values and parses the result
+func render(tmpl *template.Template, values map[string]string) (*Scenario, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, values); err != nil {
+		return nil, fmt.Errorf("Failed to render scenario template: %s", err)
+	}
+	var s Scenario
+	if err := yaml.Unmarshal(buf.Bytes(), &s); err != nil {
+		return nil, fmt.Errorf("Failed to parse scenario: %s", err)
+	}
+	if len(s.Steps) == 0 {
+		return nil, fmt.Errorf("Scenario '%s' has no steps", s.Name)
+	}
+	return &s, nil
+}
+
+// Info reads scenario file description and parameters, without rendering it
+func Info(filename string) (*Scenario, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var s Scenario
+	if err = yaml.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("Failed to parse scenario: %s", err)
+	}
+	return &s, nil
+}
+
+// ParseArgs converts command line arguments '--name value' or '--name=value' into parameters map
+func ParseArgs(args []string) (map[string]string, error) {
+	params := map[string]string{}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "--") || len(arg) < 3 {
+			return nil, fmt.Errorf("Unexpected argument '%s': expected '--name value'", arg)
+		}
+		name := strings.TrimPrefix(arg, "--")
+		if idx := strings.Index(name, "="); idx >= 0 {
+			params[name[:idx]] = name[idx+1:]
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, fmt.Errorf("Missing value for parameter '%s'", name)
+		}
+		params[name] = args[i+1]
+		i++
+	}
+	return params, nil
+}
@@ -0,0 +1,151 @@
+package scenario
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testScenario = `
+name: test
+description: test scenario
+params:
+  - name: target
+    required: true
+  - name: signal
+    default: SIGTERM
+steps:
+  - name: kill
+    action: kill
+    targets: ["{{.target}}"]
+    params:
+      signal: "{{.signal}}"
+`
+
+func TestParse_Defaults(t *testing.T) {
+	s, err := Parse([]byte(testScenario), map[string]string{"target": "c1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "test", s.Name)
+	assert.Len(t, s.Steps, 1)
+	assert.Equal(t, []string{"c1"}, s.Steps[0].Targets)
+	assert.Equal(t, "SIGTERM", s.Steps[0].Params["signal"])
+}
+
+func TestParse_Override(t *testing.T) {
+	s, err := Parse([]byte(testScenario), map[string]string{"target": "re2:^c", "signal": "SIGKILL"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"re2:^c"}, s.Steps[0].Targets)
+	assert.Equal(t, "SIGKILL", s.Steps[0].Params["signal"])
+}
+
+func TestParse_MissingRequired(t *testing.T) {
+	_, err := Parse([]byte(testScenario), map[string]string{})
+	assert.EqualError(t, err, "Missing required scenario parameter: 'target'")
+}
+
+func TestParse_UnknownParam(t *testing.T) {
+	_, err := Parse([]byte(testScenario), map[string]string{"target": "c1", "bad": "x"})
+	assert.EqualError(t, err, "Unknown scenario parameter: 'bad'")
+}
+
+func TestParse_NoSteps(t *testing.T) {
+	_, err := Parse([]byte("name: empty"), nil)
+	assert.EqualError(t, err, "Scenario 'empty' has no steps")
+}
+
+func TestParseArgs(t *testing.T) {
+	params, err := ParseArgs([]string{"--brokers", "re2:kafka", "--wait=30s"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"brokers": "re2:kafka", "wait": "30s"}, params)
+}
+
+func TestParseArgs_Errors(t *testing.T) {
+	_, err := ParseArgs([]string{"brokers"})
+	assert.Error(t, err)
+	_, err = ParseArgs([]string{"--brokers"})
+	assert.EqualError(t, err, "Missing value for parameter 'brokers'")
+}
+
+func TestRecipes(t *testing.T) {
+	names := Recipes()
+	assert.Equal(t, []string{"db-failover", "db-failover-http", "kafka-broker-slow", "kafka-leader-kill", "rabbit-partition"}, names)
+	// all built-in recipes should render with required parameters
+	for _, name := range names {
+		info, err := RecipeInfo(name)
+		assert.NoError(t, err)
+		params := map[string]string{}
+		for _, p := range info.Params {
+			if p.Required {
+				params[p.Name] = "re2:test"
+			}
+		}
+		s, err := Recipe(name, params)
+		assert.NoError(t, err, name)
+		assert.NotEmpty(t, s.Steps, name)
+	}
+}
+
+func TestRecipe_Unknown(t *testing.T) {
+	_, err := Recipe("bad", nil)
+	assert.EqualError(t, err, "Unknown recipe: 'bad'")
+}
+
+const testMatrixScenario = `
+name: explore
+params:
+  - name: duration
+    default: 1m
+matrix:
+  - name: target
+    values: [api, db]
+  - name: impairment
+    values: [pause, stop]
+steps:
+  - name: "{{.impairment}} {{.target}}"
+    action: "{{.impairment}}"
+    targets: ["{{.target}}"]
+    params:
+      duration: "{{.duration}}"
+`
+
+func TestParse_Matrix(t *testing.T) {
+	s, err := Parse([]byte(testMatrixScenario), map[string]string{"duration": "30s"})
+	assert.NoError(t, err)
+	assert.Empty(t, s.Steps)
+	assert.Len(t, s.Cells, 4)
+	var labels, steps []string
+	for _, c := range s.Cells {
+		labels = append(labels, c.Label)
+		steps = append(steps, c.Steps[0].Name)
+		assert.Equal(t, "30s", c.Steps[0].Params["duration"])
+	}
+	assert.Equal(t, []string{"target=api impairment=pause", "target=api impairment=stop", "target=db impairment=pause", "target=db impairment=stop"}, labels)
+	assert.Equal(t, []string{"pause api", "stop api", "pause db", "stop db"}, steps)
+	assert.Equal(t, map[string]string{"target": "db", "impairment": "stop"}, s.Cells[3].Values)
+}
+
+func TestParse_MatrixErrors(t *testing.T) {
+	for matrix, msg := range map[string]string{
+		"[{values: [a]}]":                  "Scenario matrix dimension has no name",
+		"[{name: target}]":                 "Scenario matrix dimension 'target' has no values",
+		"[{name: duration, values: [1m]}]": "Scenario matrix dimension 'duration' conflicts with scenario parameter or other dimension",
+		"[{name: target, values: [a]}, {name: target, values: [b]}]": "Scenario matrix dimension 'target' conflicts with scenario parameter or other dimension",
+		"[{name: target, values: [a]}]":                              "Matrix cell (target=a): Failed to render scenario template",
+	} {
+		data := "name: bad\nparams: [{name: duration}]\nmatrix: " + matrix + "\nsteps: [{action: \"{{.impairment}}\"}]\n"
+		_, err := Parse([]byte(data), nil)
+		assert.Error(t, err, matrix)
+		if err != nil {
+			assert.Contains(t, err.Error(), msg, matrix)
+		}
+	}
+	var values []string
+	for i := 0; i < 20; i++ {
+		values = append(values, strconv.Itoa(i))
+	}
+	data := "name: big\nmatrix: [{name: a, values: [" + strings.Join(values, ",") + "]}, {name: b, values: [" + strings.Join(values, ",") + "]}]\nsteps: [{action: wait}]\n"
+	_, err := Parse([]byte(data), nil)
+	assert.EqualError(t, err, "Scenario matrix has more than 256 cells")
+}
@@ -0,0 +1,272 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gaia-adm/pumba/clock"
+)
+
+// Task is a function, executed by Scheduler on each run
+type Task func() error
+
+// Scheduler runs task recurrently, every interval, or once; zero value uses real clock and tolerates no failures
+type Scheduler struct {
+	// Interval between task runs
+	Interval time.Duration
+	// Clock used for ticker; real clock by default
+	Clock clock.Clock
+	// Once runs task only once, immediately and synchronously
+	Once bool
+	// MaxRuns stops scheduler after specified number of runs; 0 - no limit
+	MaxRuns int
+	// Count runs task specified number of times back-to-back, synchronously, and stops; 0 - recurrent runs
+	Count int
+	// Delay between back-to-back runs of Count mode
+	Delay time.Duration
+	// TolerateFailures number of failed runs to tolerate; negative - no limit
+	TolerateFailures int
+	// Warmup delay before the first run; recurrent runs start right after warm-up
+	Warmup time.Duration
+	// AfterWarmup called, when warm-up is complete, before the first run
+	AfterWarmup func()
+	// BeforeRun called before each task run
+	BeforeRun func()
+	// AfterRun called after each task run with its result
+	AfterRun func(error)
+
+	stop     chan struct{}
+	initOnce sync.Once
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+	failures int32
+	runs     int32
+	mu       sync.Mutex
+	next     time.Time
+}
+
+// New creates new scheduler with real clock and no failures limit
+func New(interval time.Duration) *Scheduler {
+	return &Scheduler{
+		Interval:         interval,
+		Clock:            clock.New(),
+		TolerateFailures: -1,
+	}
+}
+
+// stopped returns channel, closed by Stop; created on first use, so zero value scheduler can be stopped
+func (s *Scheduler) stopped() chan struct{} {
+	s.initOnce.Do(func() { s.stop = make(chan struct{}) })
+	return s.stop
+}
+
+// getClock returns scheduler clock; real clock, if not set
+func (s *Scheduler) getClock() clock.Clock {
+	if s.Clock == nil {
+		return clock.New()
+	}
+	return s.Clock
+}
+
+// Run executes task according to schedule; blocks till scheduler is stopped, runs MaxRuns times
+// or number of failed runs exceeds TolerateFailures (error carries the last task error); waits for running tasks
+// to complete
+func (s *Scheduler) Run(task Task) error {
+	if s.Interval <= 0 && !s.Once && s.Count == 0 {
+		return errors.New("Scheduler interval should be positive")
+	}
+	warmedUp := s.Warmup > 0
+	if warmedUp && !s.warmup() {
+		return nil
+	}
+	if s.Once {
+		_, err := s.run(task)
+		return err
+	}
+	if s.Count > 0 {
+		return s.runCount(task)
+	}
+	ticker := s.getClock().NewTicker(s.Interval)
+	defer ticker.Stop()
+	s.setNext(s.getClock().Now().Add(s.Interval))
+	defer s.setNext(time.Time{})
+	// first run right after warm-up
+	var first <-chan time.Time
+	if warmedUp {
+		now := make(chan time.Time, 1)
+		now <- s.getClock().Now()
+		first = now
+	}
+	abort := make(chan error, 1)
+	runs := 0
+	for {
+		var tick time.Time
+		select {
+		case <-s.stopped():
+			s.wg.Wait()
+			return nil
+		case err := <-abort:
+			s.wg.Wait()
+			return err
+		case tick = <-first:
+			first = nil
+		case tick = <-ticker.C():
+		}
+		s.setNext(tick.Add(s.Interval))
+		runs++
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if n, err := s.run(task); err != nil {
+				select {
+				case abort <- fmt.Errorf("Too many failed runs: %d; last error: %s", n, err):
+				default:
+				}
+			}
+		}()
+		if s.MaxRuns > 0 && runs >= s.MaxRuns {
+			s.wg.Wait()
+			select {
+			case err := <-abort:
+				return err
+			default:
+				return nil
+			}
+		}
+	}
+}
+
+// warmup waits for warm-up delay; returns false, if scheduler is stopped during warm-up
+func (s *Scheduler) warmup() bool {
+	s.setNext(s.getClock().Now().Add(s.Warmup))
+	select {
+	case <-s.stopped():
+		s.setNext(time.Time{})
+		return false
+	case <-s.getClock().After(s.Warmup):
+	}
+	s.setNext(time.Time{})
+	if s.AfterWarmup != nil {
+		s.AfterWarmup()
+	}
+	return true
+}
+
+// runCount runs task Count times, one run after another, waiting Delay between runs; stops on Stop
+// or when number of failed runs exceeds TolerateFailures, returning error of the last run
+func (s *Scheduler) runCount(task Task) error {
+	for i := 0; i < s.Count; i++ {
+		if i > 0 && s.Delay > 0 {
+			select {
+			case <-s.stopped():
+				return nil
+			case <-s.getClock().After(s.Delay):
+			}
+		}
+		select {
+		case <-s.stopped():
+			return nil
+		default:
+		}
+		if _, err := s.run(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop stops scheduler; running tasks are completed
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() { close(s.stopped()) })
+}
+
+// Next returns time of the next scheduled run (end of warm-up, during warm-up); zero time, if scheduler is not
+// running recurrently
+func (s *Scheduler) Next() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.next
+}
+
+func (s *Scheduler) setNext(next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next = next
+}
+
+// Runs returns number of started runs
+func (s *Scheduler) Runs() int {
+	return int(atomic.LoadInt32(&s.runs))
+}
+
+// Failures returns number of failed runs
+func (s *Scheduler) Failures() int {
+	return int(atomic.LoadInt32(&s.failures))
+}
+
+// run executes task with callbacks; returns number of failed runs and task error,
+// only when number of failures exceeds tolerated one
+func (s *Scheduler) run(task Task) (int, error) {
+	atomic.AddInt32(&s.runs, 1)
+	if s.BeforeRun != nil {
+		s.BeforeRun()
+	}
+	err := task()
+	if s.AfterRun != nil {
+		s.AfterRun(err)
+	}
+	if err == nil {
+		return s.Failures(), nil
+	}
+	n := int(atomic.AddInt32(&s.failures, 1))
+	if s.TolerateFailures >= 0 && n > s.TolerateFailures {
+		return n, err
+	}
+	return n, nil
+}
+
+// Group running schedulers, stopped together (e.g. on termination signal)
+type Group struct {
+	mu         sync.Mutex
+	schedulers map[*Scheduler]bool
+	stopped    bool
+}
+
+// Add adds scheduler to group and returns function, that removes it; scheduler, added to stopped group, is
+// stopped immediately
+func (g *Group) Add(s *Scheduler) func() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopped {
+		s.Stop()
+	}
+	if g.schedulers == nil {
+		g.schedulers = map[*Scheduler]bool{}
+	}
+	g.schedulers[s] = true
+	return func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		delete(g.schedulers, s)
+	}
+}
+
+// Stop stops all schedulers of group, and schedulers added later
+func (g *Group) Stop() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.stopped = true
+	for s := range g.schedulers {
+		s.Stop()
+	}
+}
+
+// Stopped returns true, if group is stopped
+func (g *Group) Stopped() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.stopped
+}
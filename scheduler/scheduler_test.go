@@ -0,0 +1,253 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func newFakeScheduler(interval time.Duration) (*Scheduler, *clock.Fake) {
+	fake := clock.NewFake(time.Date(2016, 8, 1, 0, 0, 0, 0, time.UTC))
+	s := New(interval)
+	s.Clock = fake
+	return s, fake
+}
+
+func TestRun_Once(t *testing.T) {
+	s, _ := newFakeScheduler(time.Minute)
+	s.Once = true
+	runs := 0
+	err := s.Run(func() error { runs++; return nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 1, runs)
+}
+
+func TestRun_OnceFailure(t *testing.T) {
+	s, _ := newFakeScheduler(time.Minute)
+	s.Once = true
+	s.TolerateFailures = 0
+	var result error
+	s.AfterRun = func(err error) { result = err }
+	err := s.Run(func() error { return errors.New("ERROR") })
+	assert.EqualError(t, err, "ERROR")
+	assert.EqualError(t, result, "ERROR")
+	assert.Equal(t, 1, s.Failures())
+}
+
+func TestRun_OnceToleratedFailure(t *testing.T) {
+	s, _ := newFakeScheduler(time.Minute)
+	s.Once = true
+	s.TolerateFailures = 1
+	err := s.Run(func() error { return errors.New("ERROR") })
+	assert.NoError(t, err)
+}
+
+func TestRun_MaxRuns(t *testing.T) {
+	s, fake := newFakeScheduler(time.Minute)
+	s.MaxRuns = 3
+	var runs, before int32
+	s.BeforeRun = func() { atomic.AddInt32(&before, 1) }
+	done := make(chan error)
+	go func() { done <- s.Run(func() error { atomic.AddInt32(&runs, 1); return nil }) }()
+	for i := 0; i < 3; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(time.Minute)
+		// wait for tick to be consumed
+		for atomic.LoadInt32(&before) < int32(i+1) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	assert.NoError(t, <-done)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&runs))
+}
+
+func TestRun_Count(t *testing.T) {
+	// interval is not used in count mode
+	s, _ := newFakeScheduler(0)
+	s.Count = 3
+	runs := 0
+	err := s.Run(func() error { runs++; return nil })
+	assert.NoError(t, err)
+	assert.Equal(t, 3, runs)
+	assert.Equal(t, 3, s.Runs())
+}
+
+func TestRun_CountDelay(t *testing.T) {
+	s, fake := newFakeScheduler(0)
+	s.Count = 2
+	s.Delay = time.Second
+	var runs int32
+	done := make(chan error)
+	go func() { done <- s.Run(func() error { atomic.AddInt32(&runs, 1); return nil }) }()
+	fake.BlockUntil(1)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&runs))
+	fake.Advance(time.Second)
+	assert.NoError(t, <-done)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&runs))
+}
+
+func TestRun_CountFailure(t *testing.T) {
+	s, _ := newFakeScheduler(0)
+	s.Count = 5
+	s.TolerateFailures = 1
+	runs := 0
+	err := s.Run(func() error { runs++; return fmt.Errorf("ERROR %d", runs) })
+	assert.EqualError(t, err, "ERROR 2")
+	assert.Equal(t, 2, runs)
+}
+
+func TestRun_CountStop(t *testing.T) {
+	s, fake := newFakeScheduler(0)
+	s.Count = 3
+	s.Delay = time.Minute
+	done := make(chan error)
+	go func() { done <- s.Run(func() error { return nil }) }()
+	fake.BlockUntil(1)
+	s.Stop()
+	assert.NoError(t, <-done)
+	assert.Equal(t, 1, s.Runs())
+}
+
+func TestRun_TooManyFailures(t *testing.T) {
+	s, fake := newFakeScheduler(time.Second)
+	s.TolerateFailures = 1
+	var runs int32
+	done := make(chan error)
+	go func() {
+		done <- s.Run(func() error { atomic.AddInt32(&runs, 1); return errors.New("ERROR") })
+	}()
+	for i := int32(0); i < 2; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(time.Second)
+		for atomic.LoadInt32(&runs) < i+1 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	assert.EqualError(t, <-done, "Too many failed runs: 2; last error: ERROR")
+}
+
+func TestRun_Stop(t *testing.T) {
+	s, fake := newFakeScheduler(time.Second)
+	done := make(chan error)
+	go func() { done <- s.Run(func() error { return nil }) }()
+	fake.BlockUntil(1)
+	s.Stop()
+	// repeated stop is safe
+	s.Stop()
+	assert.NoError(t, <-done)
+}
+
+func TestRun_ZeroValueStop(t *testing.T) {
+	s := &Scheduler{Interval: time.Hour}
+	done := make(chan error)
+	go func() { done <- s.Run(func() error { return nil }) }()
+	s.Stop()
+	assert.NoError(t, <-done)
+	// stop of scheduler, that never ran, does not panic
+	(&Scheduler{}).Stop()
+}
+
+func TestRun_BadInterval(t *testing.T) {
+	s := New(0)
+	err := s.Run(func() error { return nil })
+	assert.EqualError(t, err, "Scheduler interval should be positive")
+}
+
+func TestRun_Next(t *testing.T) {
+	s, fake := newFakeScheduler(time.Minute)
+	start := fake.Now()
+	done := make(chan error)
+	go func() { done <- s.Run(func() error { return nil }) }()
+	fake.BlockUntil(1)
+	for s.Next().IsZero() {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, start.Add(time.Minute), s.Next())
+	fake.Advance(time.Minute)
+	for s.Runs() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, start.Add(2*time.Minute), s.Next())
+	s.Stop()
+	assert.NoError(t, <-done)
+	assert.True(t, s.Next().IsZero())
+	assert.Equal(t, 1, s.Runs())
+}
+
+func TestRun_Warmup(t *testing.T) {
+	s, fake := newFakeScheduler(time.Minute)
+	s.Warmup = 2 * time.Minute
+	s.MaxRuns = 2
+	start := fake.Now()
+	var runs, warmedUp int32
+	s.AfterWarmup = func() { atomic.AddInt32(&warmedUp, 1) }
+	done := make(chan error)
+	go func() { done <- s.Run(func() error { atomic.AddInt32(&runs, 1); return nil }) }()
+	fake.BlockUntil(1)
+	assert.Equal(t, start.Add(2*time.Minute), s.Next())
+	fake.Advance(time.Minute)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&warmedUp))
+	fake.Advance(time.Minute)
+	// first run right after warm-up, then every interval
+	for s.Runs() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&warmedUp))
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	assert.NoError(t, <-done)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&runs))
+}
+
+func TestRun_WarmupOnce(t *testing.T) {
+	s, fake := newFakeScheduler(time.Minute)
+	s.Once = true
+	s.Warmup = time.Minute
+	runs := 0
+	done := make(chan error)
+	go func() { done <- s.Run(func() error { runs++; return nil }) }()
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	assert.NoError(t, <-done)
+	assert.Equal(t, 1, runs)
+	assert.True(t, s.Next().IsZero())
+}
+
+func TestRun_WarmupStop(t *testing.T) {
+	s, fake := newFakeScheduler(time.Minute)
+	s.Warmup = time.Minute
+	warmedUp := false
+	s.AfterWarmup = func() { warmedUp = true }
+	done := make(chan error)
+	go func() { done <- s.Run(func() error { return nil }) }()
+	fake.BlockUntil(1)
+	s.Stop()
+	assert.NoError(t, <-done)
+	assert.False(t, warmedUp)
+	assert.Equal(t, 0, s.Runs())
+}
+
+func TestGroup_Stop(t *testing.T) {
+	var g Group
+	s1, _ := newFakeScheduler(time.Second)
+	s2, _ := newFakeScheduler(time.Second)
+	done := make(chan error)
+	g.Add(s1)
+	go func() { done <- s1.Run(func() error { return nil }) }()
+	remove := g.Add(s2)
+	remove()
+	assert.False(t, g.Stopped())
+	g.Stop()
+	assert.True(t, g.Stopped())
+	assert.NoError(t, <-done)
+	// scheduler, added after stop, does not run
+	s3, _ := newFakeScheduler(time.Second)
+	g.Add(s3)
+	assert.NoError(t, s3.Run(func() error { t.Error("stopped scheduler run"); return nil }))
+	assert.Len(t, g.schedulers, 2)
+}
@@ -1,18 +1,19 @@
 #!/bin/bash
 
 distdir=.dist
-exarch="amd64 386"
-oslist="linux windows darwin"
+# linux/arm is built for ARMv7 (Raspberry Pi 2/3 and similar edge devices)
+osarch="linux/amd64 linux/386 linux/arm64 linux/arm windows/amd64 windows/386 darwin/amd64 darwin/386"
 BUILD_DATE=$(date -u '+%Y/%m/%d')
 BUILD_VERSION=$(git describe --tags)
-CGO_ENABLE=0
+export CGO_ENABLED=0
+export GOARM=7
 
 gox_build() {
   rm -rf "${distdir}"
   mkdir "${distdir}"
   echo "Building" ${BUILD_VERSION} "on" ${BUILD_DATE}
   glide install
-  gox -os="${oslist}" -arch="${exarch}"  -ldflags "-X main.Version=${BUILD_VERSION} -X main.BuildDate='${BUILD_DATE}'" -verbose -output=.dist/pumba_{{.OS}}_{{.Arch}}
+  gox -osarch="${osarch}" -ldflags "-X main.Version=${BUILD_VERSION} -X main.BuildDate='${BUILD_DATE}'" -verbose -output=.dist/pumba_{{.OS}}_{{.Arch}}
 }
 
 gox_build
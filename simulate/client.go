@@ -0,0 +1,152 @@
+package simulate
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/netem"
+)
+
+// simulationClient records chaos actions in simulation, instead of running them; running containers are
+// listed once and the same list is used for the whole simulation
+type simulationClient struct {
+	container.Client
+	simulation *Simulation
+	listing    *listing
+}
+
+// listing containers, listed once
+type listing struct {
+	sync.Mutex
+	containers []container.Container
+	listed     bool
+}
+
+// NewClient wraps container client with chaos action recording
+func NewClient(client container.Client, simulation *Simulation) container.Client {
+	return simulationClient{Client: client, simulation: simulation, listing: &listing{}}
+}
+
+func allContainers(container.Container) bool { return true }
+
+func (client simulationClient) ListContainers(fn container.Filter) ([]container.Container, error) {
+	client.listing.Lock()
+	defer client.listing.Unlock()
+	if !client.listing.listed {
+		all, err := client.Client.ListContainers(allContainers)
+		if err != nil {
+			return nil, err
+		}
+		client.listing.containers = all
+		client.listing.listed = true
+	}
+	var cs []container.Container
+	for _, c := range client.listing.containers {
+		if fn(c) {
+			cs = append(cs, c)
+		}
+	}
+	return cs, nil
+}
+
+func (client simulationClient) StopContainer(c container.Container, timeout int) error {
+	client.simulation.record("stop", c, fmt.Sprintf("timeout %ds", timeout))
+	return nil
+}
+
+func (client simulationClient) BlackoutContainer(c container.Container, timeout int, duration time.Duration) error {
+	client.simulation.record("stop", c, fmt.Sprintf("timeout %ds, restart after %s", timeout, duration))
+	return nil
+}
+
+func (client simulationClient) KillContainer(c container.Container, signal string) error {
+	client.simulation.record("kill", c, "signal "+signal)
+	return nil
+}
+
+func (client simulationClient) RemoveContainer(c container.Container, force bool, links bool, volumes bool) error {
+	client.simulation.record("rm", c, fmt.Sprintf("force %t, links %t, volumes %t", force, links, volumes))
+	return nil
+}
+
+func (client simulationClient) NetemContainer(c container.Container, netInterface string, netemCmd string, filter netem.Filter, duration time.Duration, reapply bool, probe string) error {
+	details := fmt.Sprintf("%s %s for %s", netInterface, netemCmd, duration)
+	if !filter.IsEmpty() {
+		details += ", " + filter.String()
+	}
+	if probe != "" {
+		details += ", latency probe " + probe
+	}
+	client.simulation.record("netem", c, details)
+	return nil
+}
+
+func (client simulationClient) PauseContainer(c container.Container, duration time.Duration) error {
+	client.simulation.record("pause", c, "for "+duration.String())
+	return nil
+}
+
+func (client simulationClient) SidecarContainer(c container.Container, image string, cmd []string, duration time.Duration) error {
+	client.simulation.record("sidecar", c, fmt.Sprintf("%s for %s", image, duration))
+	return nil
+}
+
+func (client simulationClient) DropPortsContainer(c container.Container, loss int, duration time.Duration) error {
+	client.simulation.record("ports", c, fmt.Sprintf("loss %d%% for %s", loss, duration))
+	return nil
+}
+
+func (client simulationClient) BurnCPUContainer(c container.Container, workers int, duration time.Duration) error {
+	client.simulation.record("cpu", c, fmt.Sprintf("%d workers for %s", workers, duration))
+	return nil
+}
+
+func (client simulationClient) OOMContainer(c container.Container, timeout time.Duration) error {
+	client.simulation.record("oom", c, fmt.Sprintf("timeout %s", timeout))
+	return nil
+}
+
+func (client simulationClient) ExhaustFDsContainer(c container.Container, free int, duration time.Duration) error {
+	client.simulation.record("fd", c, fmt.Sprintf("%d free for %s", free, duration))
+	return nil
+}
+
+func (client simulationClient) HealthcheckContainer(c container.Container, probe health.Probe, duration time.Duration) error {
+	client.simulation.record("health", c, fmt.Sprintf("with %s for %s", probe, duration))
+	return nil
+}
+
+func (client simulationClient) MemoryPressureContainer(c container.Container, percent int, duration time.Duration) error {
+	client.simulation.record("memory", c, fmt.Sprintf("to %d%% of memory usage for %s", percent, duration))
+	return nil
+}
+
+func (client simulationClient) CorruptEnvContainer(c container.Container, set []string, unset []string, timeout int, duration time.Duration) error {
+	client.simulation.record("env", c, fmt.Sprintf("set %d and unset %d variables for %s", len(set), len(unset), duration))
+	return nil
+}
+
+func (client simulationClient) RemoveSecretsContainer(c container.Container, secrets []string, configs []string, duration time.Duration) error {
+	client.simulation.record("secrets", c, fmt.Sprintf("remove secrets %v and configs %v for %s", secrets, configs, duration))
+	return nil
+}
+
+func (client simulationClient) PoisonImageContainer(c container.Container, image string, duration time.Duration) error {
+	client.simulation.record("poison-image", c, fmt.Sprintf("with %s for %s", image, duration))
+	return nil
+}
+
+func (client simulationClient) InjectTools(c container.Container, dir string) error {
+	return nil
+}
+
+func (client simulationClient) RemoveTools(c container.Container) error {
+	return nil
+}
+
+func (client simulationClient) ExecContainer(c container.Container, cmd []string, timeout time.Duration) (int, error) {
+	return 0, nil
+}
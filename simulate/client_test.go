@@ -0,0 +1,45 @@
+package simulate
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestClient_ListOnce(t *testing.T) {
+	c1 := container.NewMockContainer("abc", "/api_1", nil)
+	c2 := container.NewMockContainer("def", "/db_1", nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("ListContainers", mock.Anything).Return([]container.Container{c1, c2}, nil).Once()
+	client := NewClient(inner, New(time.Hour))
+	api := func(c container.Container) bool { return strings.HasPrefix(c.Name(), "/api") }
+	for i := 0; i < 3; i++ {
+		cs, err := client.ListContainers(api)
+		assert.NoError(t, err)
+		assert.Equal(t, []container.Container{c1}, cs)
+	}
+	inner.AssertExpectations(t)
+}
+
+func TestClient_Record(t *testing.T) {
+	c := container.NewMockContainer("abc", "/api_1", nil)
+	inner := container.NewMockSamalbaClient()
+	s := New(time.Hour)
+	client := NewClient(inner, s)
+	assert.NoError(t, client.PauseContainer(c, 10*time.Second))
+	assert.NoError(t, client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{Port: 80}, time.Minute, false, ""))
+	events := s.Events()
+	if assert.Len(t, events, 2) {
+		assert.Equal(t, "pause", events[0].Action)
+		assert.Equal(t, "for 10s", events[0].Details)
+		assert.Equal(t, "netem", events[1].Action)
+		assert.Equal(t, "eth0 delay 100ms for 1m0s, "+netem.Filter{Port: 80}.String(), events[1].Details)
+	}
+	// chaos is not created
+	inner.AssertNotCalled(t, "PauseContainer", c, 10*time.Second)
+}
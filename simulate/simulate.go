@@ -0,0 +1,230 @@
+package simulate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/events"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Event simulated chaos action
+type Event struct {
+	// Offset virtual time since simulation start
+	Offset        time.Duration
+	Action        string
+	ContainerID   string
+	ContainerName string
+	Details       string
+}
+
+// Simulation runs chaos task on virtual clock and records chaos actions, instead of running them
+type Simulation struct {
+	// Duration of simulated (virtual) time
+	Duration time.Duration
+	// Start virtual time of simulation start
+	Start    time.Time
+	mu       sync.Mutex
+	offset   time.Duration
+	events   []Event
+	skips    map[skip]int
+	runs     int
+	failures int
+	interval time.Duration
+}
+
+// skip container, skipped by chaos actions, and skip reason
+type skip struct {
+	container string
+	reason    string
+}
+
+// New creates simulation of specified virtual time
+func New(duration time.Duration) *Simulation {
+	return &Simulation{Duration: duration, Start: time.Now(), skips: map[skip]int{}}
+}
+
+// Now returns current virtual time
+func (s *Simulation) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Start.Add(s.offset)
+}
+
+// Handle counts containers skipped by simulated chaos actions, per skip reason
+func (s *Simulation) Handle(e events.Event) {
+	if e.Type != events.Skipped {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skips[skip{container: strings.TrimPrefix(e.ContainerName, "/"), reason: e.Reason}]++
+}
+
+// record records chaos action at current virtual time
+func (s *Simulation) record(action string, c container.Container, details string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, Event{
+		Offset:        s.offset,
+		Action:        action,
+		ContainerID:   c.ID(),
+		ContainerName: strings.TrimPrefix(c.Name(), "/"),
+		Details:       details,
+	})
+}
+
+// Run runs task every interval of virtual time, like scheduler does, till the end of simulation
+// or maxRuns runs (0 - no limit); failed runs are logged and counted
+func (s *Simulation) Run(interval time.Duration, maxRuns int, task func() error) error {
+	if interval <= 0 {
+		return errors.New("Simulation interval should be positive")
+	}
+	s.interval = interval
+	for offset := interval; offset <= s.Duration; offset += interval {
+		if maxRuns > 0 && s.runs >= maxRuns {
+			break
+		}
+		s.mu.Lock()
+		s.offset = offset
+		s.mu.Unlock()
+		s.runs++
+		if err := task(); err != nil {
+			log.Errorf("Simulated run at %s failed: %s", formatOffset(offset), err)
+			s.failures++
+		}
+	}
+	return nil
+}
+
+// Events returns recorded chaos actions
+func (s *Simulation) Events() []Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Event{}, s.events...)
+}
+
+// Report simulation report: timeline of simulated chaos actions, number of actions per container and skipped containers
+type Report struct {
+	Command  string `json:"command"`
+	Duration string `json:"duration"`
+	Interval string `json:"interval"`
+	Runs     int    `json:"runs"`
+	Failures int    `json:"failures"`
+	// Actions timeline of simulated chaos actions
+	Actions []ReportAction `json:"actions"`
+	// PerContainer number of chaos actions per container name
+	PerContainer map[string]int `json:"per_container"`
+	Skipped      []ReportSkip   `json:"skipped,omitempty"`
+}
+
+// ReportAction simulated chaos action; time is virtual time since simulation start (hh:mm:ss)
+type ReportAction struct {
+	Time          string `json:"time"`
+	Action        string `json:"action"`
+	ContainerName string `json:"container_name"`
+	ContainerID   string `json:"container_id"`
+	Details       string `json:"details"`
+}
+
+// ReportSkip container, skipped by simulated chaos actions, with number of runs and skip reason
+type ReportSkip struct {
+	ContainerName string `json:"container_name"`
+	Runs          int    `json:"runs"`
+	Reason        string `json:"reason"`
+}
+
+// Report returns simulation report of chaos command
+func (s *Simulation) Report(command string) Report {
+	events := s.Events()
+	r := Report{
+		Command:      command,
+		Duration:     s.Duration.String(),
+		Interval:     s.interval.String(),
+		Runs:         s.runs,
+		Failures:     s.failures,
+		Actions:      []ReportAction{},
+		PerContainer: map[string]int{},
+	}
+	for _, e := range events {
+		r.Actions = append(r.Actions, ReportAction{Time: formatOffset(e.Offset), Action: e.Action, ContainerName: e.ContainerName, ContainerID: e.ContainerID, Details: e.Details})
+		r.PerContainer[e.ContainerName]++
+	}
+	s.mu.Lock()
+	skips := make([]skip, 0, len(s.skips))
+	for k := range s.skips {
+		skips = append(skips, k)
+	}
+	sort.Sort(byContainerReason(skips))
+	for _, k := range skips {
+		r.Skipped = append(r.Skipped, ReportSkip{ContainerName: k.container, Runs: s.skips[k], Reason: k.reason})
+	}
+	s.mu.Unlock()
+	return r
+}
+
+// WriteReport writes simulation report as text tables
+func (s *Simulation) WriteReport(out io.Writer, command string) error {
+	return WriteTable(out, s.Report(command))
+}
+
+// WriteTable writes simulation report as text tables
+func WriteTable(out io.Writer, r Report) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Simulated %s of '%s': %d runs every %s, %d failed, %d chaos actions\n\n", r.Duration, r.Command, r.Runs, r.Interval, r.Failures, len(r.Actions))
+	fmt.Fprintln(w, "TIME\tACTION\tCONTAINER\tID\tDETAILS")
+	for _, a := range r.Actions {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", a.Time, a.Action, a.ContainerName, shortID(a.ContainerID), a.Details)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "CONTAINER\tACTIONS")
+	names := make([]string, 0, len(r.PerContainer))
+	for name := range r.PerContainer {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%d\n", name, r.PerContainer[name])
+	}
+	if len(r.Skipped) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "SKIPPED CONTAINER\tRUNS\tREASON")
+		for _, k := range r.Skipped {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", k.ContainerName, k.Runs, k.Reason)
+		}
+	}
+	return w.Flush()
+}
+
+// byContainerReason sorts skips by container name and reason
+type byContainerReason []skip
+
+func (s byContainerReason) Len() int      { return len(s) }
+func (s byContainerReason) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byContainerReason) Less(i, j int) bool {
+	if s[i].container != s[j].container {
+		return s[i].container < s[j].container
+	}
+	return s[i].reason < s[j].reason
+}
+
+// formatOffset formats virtual time offset as hh:mm:ss
+func formatOffset(d time.Duration) string {
+	d = d / time.Second
+	return fmt.Sprintf("%02d:%02d:%02d", d/3600, d/60%60, d%60)
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
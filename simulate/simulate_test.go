@@ -0,0 +1,112 @@
+package simulate
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulation_Run(t *testing.T) {
+	s := New(time.Minute)
+	c1 := container.NewMockContainer("0123456789abcdef", "/api_1", nil)
+	c2 := container.NewMockContainer("fedcba9876543210", "/api_2", nil)
+	runs := 0
+	err := s.Run(20*time.Second, 0, func() error {
+		runs++
+		if runs == 2 {
+			return errors.New("oops")
+		}
+		s.record("kill", c1, "signal SIGKILL")
+		s.record("kill", c2, "signal SIGKILL")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, runs)
+	events := s.Events()
+	if assert.Len(t, events, 4) {
+		assert.Equal(t, Event{Offset: 20 * time.Second, Action: "kill", ContainerID: "0123456789abcdef", ContainerName: "api_1", Details: "signal SIGKILL"}, events[0])
+		assert.Equal(t, time.Minute, events[3].Offset)
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, s.WriteReport(&buf, "kill re2:^api"))
+	expected := `Simulated 1m0s of 'kill re2:^api': 3 runs every 20s, 1 failed, 4 chaos actions
+
+TIME      ACTION  CONTAINER  ID            DETAILS
+00:00:20  kill    api_1      0123456789ab  signal SIGKILL
+00:00:20  kill    api_2      fedcba987654  signal SIGKILL
+00:01:00  kill    api_1      0123456789ab  signal SIGKILL
+00:01:00  kill    api_2      fedcba987654  signal SIGKILL
+
+CONTAINER  ACTIONS
+api_1      2
+api_2      2
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestSimulation_MaxRuns(t *testing.T) {
+	s := New(time.Hour)
+	runs := 0
+	assert.NoError(t, s.Run(time.Minute, 5, func() error { runs++; return nil }))
+	assert.Equal(t, 5, runs)
+	assert.EqualError(t, s.Run(0, 0, nil), "Simulation interval should be positive")
+}
+
+func TestSimulation_Now(t *testing.T) {
+	s := New(time.Hour)
+	s.Start = time.Date(2017, 1, 6, 23, 0, 0, 0, time.UTC)
+	var times []time.Time
+	s.Run(30*time.Minute, 0, func() error {
+		times = append(times, s.Now())
+		return nil
+	})
+	assert.Equal(t, []time.Time{time.Date(2017, 1, 6, 23, 30, 0, 0, time.UTC), time.Date(2017, 1, 7, 0, 0, 0, 0, time.UTC)}, times)
+}
+
+func TestFormatOffset(t *testing.T) {
+	assert.Equal(t, "00:00:00", formatOffset(500*time.Millisecond))
+	assert.Equal(t, "01:02:03", formatOffset(time.Hour+2*time.Minute+3*time.Second))
+	assert.Equal(t, "25:00:00", formatOffset(25*time.Hour))
+}
+
+func TestSimulation_Skipped(t *testing.T) {
+	s := New(time.Minute)
+	assert.NoError(t, s.Run(30*time.Second, 0, func() error {
+		s.Handle(events.Event{Type: events.Skipped, ContainerName: "/pumba", Reason: "Pumba container"})
+		s.Handle(events.Event{Type: events.Skipped, ContainerName: "/api_2", Reason: "not selected in random mode"})
+		s.Handle(events.Event{Type: events.Succeeded, ContainerName: "/api_1", Action: "kill"})
+		return nil
+	}))
+	var buf bytes.Buffer
+	assert.NoError(t, s.WriteReport(&buf, "--random kill re2:."))
+	assert.Contains(t, buf.String(), `
+SKIPPED CONTAINER  RUNS  REASON
+api_2              2     not selected in random mode
+pumba              2     Pumba container
+`)
+}
+
+func TestSimulation_Report(t *testing.T) {
+	s := New(time.Minute)
+	c := container.NewMockContainer("0123456789abcdef", "/api_1", nil)
+	assert.NoError(t, s.Run(30*time.Second, 0, func() error {
+		s.record("pause", c, "for 10s")
+		s.Handle(events.Event{Type: events.Skipped, ContainerName: "/pumba", Reason: "Pumba container"})
+		return nil
+	}))
+	r := s.Report("pause --duration 10s re2:^api")
+	assert.Equal(t, "1m0s", r.Duration)
+	assert.Equal(t, "30s", r.Interval)
+	assert.Equal(t, 2, r.Runs)
+	assert.Equal(t, []ReportAction{
+		{Time: "00:00:30", Action: "pause", ContainerName: "api_1", ContainerID: "0123456789abcdef", Details: "for 10s"},
+		{Time: "00:01:00", Action: "pause", ContainerName: "api_1", ContainerID: "0123456789abcdef", Details: "for 10s"},
+	}, r.Actions)
+	assert.Equal(t, map[string]int{"api_1": 2}, r.PerContainer)
+	assert.Equal(t, []ReportSkip{{ContainerName: "pumba", Runs: 2, Reason: "Pumba container"}}, r.Skipped)
+}
@@ -0,0 +1,28 @@
+package snapshot
+
+import (
+	"fmt"
+
+	"github.com/gaia-adm/pumba/container"
+)
+
+// snapshotClient snapshots containers before destructive chaos actions; dry runs are not snapshotted
+type snapshotClient struct {
+	container.Client
+	snapshotter *Snapshotter
+}
+
+// NewClient wraps container client with snapshotter
+func NewClient(client container.Client, snapshotter *Snapshotter) container.Client {
+	return snapshotClient{Client: client, snapshotter: snapshotter}
+}
+
+// RemoveContainer snapshots container before removal; container is not removed, if snapshot fails
+func (client snapshotClient) RemoveContainer(c container.Container, force bool, links bool, volumes bool) error {
+	if !client.DryRun() {
+		if _, err := client.snapshotter.Take(c); err != nil {
+			return fmt.Errorf("Failed to snapshot container %s before removal: %s", c.Name(), err)
+		}
+	}
+	return client.Client.RemoveContainer(c, force, links, volumes)
+}
@@ -0,0 +1,40 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestClient_SnapshotBeforeRemove(t *testing.T) {
+	c := container.NewMockContainer("abc", "/web", nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("CommitContainer", c, "pumba-snapshot/web:20160801-100000", mock.Anything).Return("sha256:123", nil)
+	inner.On("RemoveContainer", c, true, false, false).Return(nil)
+	client := NewClient(inner, newTestSnapshotter(inner))
+	assert.NoError(t, client.RemoveContainer(c, true, false, false))
+	inner.AssertExpectations(t)
+}
+
+func TestClient_NoRemoveOnSnapshotError(t *testing.T) {
+	c := container.NewMockContainer("abc", "/web", nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("CommitContainer", c, mock.Anything, mock.Anything).Return("", errors.New("no space left on device"))
+	client := NewClient(inner, newTestSnapshotter(inner))
+	err := client.RemoveContainer(c, true, false, false)
+	assert.EqualError(t, err, "Failed to snapshot container /web before removal: no space left on device")
+	inner.AssertNotCalled(t, "RemoveContainer", c, true, false, false)
+}
+
+func TestClient_NoSnapshotOnDryRun(t *testing.T) {
+	c := container.NewMockContainer("abc", "/web", nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("RemoveContainer", c, true, false, false).Return(nil)
+	client := NewClient(inner, newTestSnapshotter(inner))
+	inner.Dry = true
+	assert.NoError(t, client.RemoveContainer(c, true, false, false))
+	inner.AssertNotCalled(t, "CommitContainer", c, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,124 @@
+// Package snapshot commits containers to images before destructive chaos actions, so container state can be
+// inspected or restored afterwards; expired snapshot images are pruned
+package snapshot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+const (
+	// Label marks snapshot images
+	Label = "com.gaiaadm.pumba.snapshot"
+	// ContainerLabel name of snapshotted container
+	ContainerLabel = "com.gaiaadm.pumba.snapshot.container"
+	// ContainerIDLabel ID of snapshotted container
+	ContainerIDLabel = "com.gaiaadm.pumba.snapshot.container-id"
+	// ExpiresLabel snapshot expiration time (RFC 3339); expired snapshots are pruned
+	ExpiresLabel = "com.gaiaadm.pumba.snapshot.expires"
+	// Repository image repository prefix of snapshots: 'pumba-snapshot/<container name>:<time>'
+	Repository = "pumba-snapshot"
+	tagFormat  = "20060102-150405"
+)
+
+// Snapshotter commits containers to snapshot images and removes snapshots after TTL
+type Snapshotter struct {
+	client container.Client
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// New creates snapshotter; snapshots expire after ttl
+func New(client container.Client, ttl time.Duration) *Snapshotter {
+	return &Snapshotter{client: client, ttl: ttl, now: time.Now}
+}
+
+// Take commits container to snapshot image and returns image reference
+func (s *Snapshotter) Take(c container.Container) (string, error) {
+	now := s.now().UTC()
+	reference := Reference(c, now)
+	labels := map[string]string{
+		Label:            "true",
+		ContainerLabel:   strings.TrimPrefix(c.Name(), "/"),
+		ContainerIDLabel: c.ID(),
+		ExpiresLabel:     now.Add(s.ttl).Format(time.RFC3339),
+	}
+	if _, err := s.client.CommitContainer(c, reference, labels); err != nil {
+		return "", err
+	}
+	log.Infof("Saved snapshot of container %s as image %s (expires in %s)", c.Name(), reference, s.ttl)
+	return reference, nil
+}
+
+// Reference returns snapshot image reference of container: container name, converted to valid repository
+// name, and snapshot time as tag
+func Reference(c container.Container, t time.Time) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '_' || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(strings.TrimPrefix(c.Name(), "/")))
+	// repository path component starts and ends with alphanumeric character
+	if name = strings.Trim(name, "._-"); name == "" {
+		name = c.ID()
+		if len(name) > 12 {
+			name = name[:12]
+		}
+	}
+	return fmt.Sprintf("%s/%s:%s", Repository, name, t.UTC().Format(tagFormat))
+}
+
+// Prune removes expired snapshot images and returns number of removed images; snapshots without valid
+// expiration label expire TTL after creation; removal failures (e.g. snapshot used by restored container) are
+// logged and do not stop pruning
+func (s *Snapshotter) Prune() (int, error) {
+	images, err := s.client.ListImages(Label)
+	if err != nil {
+		return 0, fmt.Errorf("Failed to list snapshot images: %s", err)
+	}
+	now := s.now()
+	removed := 0
+	for _, img := range images {
+		expires, err := time.Parse(time.RFC3339, img.Labels[ExpiresLabel])
+		if err != nil {
+			expires = img.Created.Add(s.ttl)
+		}
+		if now.Before(expires) {
+			continue
+		}
+		if err := s.client.DeleteImage(img.ID, false); err != nil {
+			log.Warnf("Failed to remove expired snapshot %s %v: %s", img.ID, img.Tags, err)
+			continue
+		}
+		log.Debugf("Removed expired snapshot %s %v", img.ID, img.Tags)
+		removed++
+	}
+	if removed > 0 {
+		log.Infof("Removed %d expired container snapshots", removed)
+	}
+	return removed, nil
+}
+
+// PruneEvery prunes expired snapshots periodically in background, until stop is closed
+func (s *Snapshotter) PruneEvery(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := s.Prune(); err != nil {
+					log.Warn(err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
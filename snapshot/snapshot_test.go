@@ -0,0 +1,68 @@
+package snapshot
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/assert"
+)
+
+var testTime = time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+
+func newTestSnapshotter(client container.Client) *Snapshotter {
+	s := New(client, time.Hour)
+	s.now = func() time.Time { return testTime }
+	return s
+}
+
+func TestReference(t *testing.T) {
+	assert.Equal(t, "pumba-snapshot/web_1:20160801-100000", Reference(container.NewMockContainer("abc", "/web_1", nil), testTime))
+	assert.Equal(t, "pumba-snapshot/my-app.db:20160801-100000", Reference(container.NewMockContainer("abc", "/My+App.db_", nil), testTime))
+	assert.Equal(t, "pumba-snapshot/0123456789ab:20160801-100000", Reference(container.NewMockContainer("0123456789abcdef", "/__", nil), testTime))
+}
+
+func TestSnapshotter_Take(t *testing.T) {
+	c := container.NewMockContainer("abc", "/web", nil)
+	client := container.NewMockSamalbaClient()
+	labels := map[string]string{
+		Label:            "true",
+		ContainerLabel:   "web",
+		ContainerIDLabel: "abc",
+		ExpiresLabel:     "2016-08-01T11:00:00Z",
+	}
+	client.On("CommitContainer", c, "pumba-snapshot/web:20160801-100000", labels).Return("sha256:123", nil)
+	ref, err := newTestSnapshotter(client).Take(c)
+	assert.NoError(t, err)
+	assert.Equal(t, "pumba-snapshot/web:20160801-100000", ref)
+	client.AssertExpectations(t)
+}
+
+func TestSnapshotter_Prune(t *testing.T) {
+	client := container.NewMockSamalbaClient()
+	client.On("ListImages", Label).Return([]container.Image{
+		{ID: "expired", Labels: map[string]string{ExpiresLabel: "2016-08-01T09:59:59Z"}},
+		{ID: "active", Labels: map[string]string{ExpiresLabel: "2016-08-01T10:00:01Z"}},
+		// without expiration label: TTL after creation
+		{ID: "old", Created: testTime.Add(-2 * time.Hour), Labels: map[string]string{Label: "true"}},
+		{ID: "new", Created: testTime.Add(-time.Minute), Labels: map[string]string{Label: "true"}},
+		{ID: "in-use", Labels: map[string]string{ExpiresLabel: "2016-08-01T09:00:00Z"}},
+	}, nil)
+	client.On("DeleteImage", "expired", false).Return(nil)
+	client.On("DeleteImage", "old", false).Return(nil)
+	client.On("DeleteImage", "in-use", false).Return(errors.New("image is being used by running container"))
+	removed, err := newTestSnapshotter(client).Prune()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	client.AssertExpectations(t)
+	client.AssertNotCalled(t, "DeleteImage", "active", false)
+	client.AssertNotCalled(t, "DeleteImage", "new", false)
+}
+
+func TestSnapshotter_PruneListError(t *testing.T) {
+	client := container.NewMockSamalbaClient()
+	client.On("ListImages", Label).Return([]container.Image{}, errors.New("connection refused"))
+	_, err := newTestSnapshotter(client).Prune()
+	assert.EqualError(t, err, "Failed to list snapshot images: connection refused")
+}
@@ -0,0 +1,150 @@
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/health"
+	"github.com/gaia-adm/pumba/netem"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// trackingClient records active disruptions (chaos actions with duration) in state file; dry runs are not recorded
+type trackingClient struct {
+	container.Client
+	file *File
+	now  func() time.Time
+}
+
+// NewClient wraps container client with disruption tracking
+func NewClient(client container.Client, file *File) container.Client {
+	return trackingClient{Client: client, file: file, now: time.Now}
+}
+
+// tracked runs chaos action, recording disruption in state file for action duration
+func (client trackingClient) tracked(d Disruption, fn func() error) error {
+	if client.DryRun() {
+		return fn()
+	}
+	id := client.file.Add(d)
+	defer client.file.Remove(id)
+	return fn()
+}
+
+func (client trackingClient) PauseContainer(c container.Container, duration time.Duration) error {
+	return client.tracked(client.disruption("pause", c, duration), func() error {
+		return client.Client.PauseContainer(c, duration)
+	})
+}
+
+func (client trackingClient) NetemContainer(c container.Container, netInterface string, netemCmd string, filter netem.Filter, duration time.Duration, reapply bool, probe string) error {
+	d := client.disruption("netem", c, duration)
+	d.Interface = netInterface
+	d.Filter = &filter
+	return client.tracked(d, func() error {
+		return client.Client.NetemContainer(c, netInterface, netemCmd, filter, duration, reapply, probe)
+	})
+}
+
+func (client trackingClient) DropPortsContainer(c container.Container, loss int, duration time.Duration) error {
+	d := client.disruption("ports", c, duration)
+	d.Loss = loss
+	return client.tracked(d, func() error {
+		return client.Client.DropPortsContainer(c, loss, duration)
+	})
+}
+
+func (client trackingClient) SidecarContainer(c container.Container, image string, cmd []string, duration time.Duration) error {
+	return client.tracked(client.disruption("sidecar", c, duration), func() error {
+		return client.Client.SidecarContainer(c, image, cmd, duration)
+	})
+}
+
+func (client trackingClient) BlackoutContainer(c container.Container, timeout int, duration time.Duration) error {
+	return client.tracked(client.disruption("stop", c, time.Duration(timeout)*time.Second+duration), func() error {
+		return client.Client.BlackoutContainer(c, timeout, duration)
+	})
+}
+
+func (client trackingClient) BurnCPUContainer(c container.Container, workers int, duration time.Duration) error {
+	return client.tracked(client.disruption("cpu", c, duration), func() error {
+		return client.Client.BurnCPUContainer(c, workers, duration)
+	})
+}
+
+func (client trackingClient) ExhaustFDsContainer(c container.Container, free int, duration time.Duration) error {
+	return client.tracked(client.disruption("fd", c, duration), func() error {
+		return client.Client.ExhaustFDsContainer(c, free, duration)
+	})
+}
+
+func (client trackingClient) HealthcheckContainer(c container.Container, probe health.Probe, duration time.Duration) error {
+	return client.tracked(client.disruption("health", c, duration), func() error {
+		return client.Client.HealthcheckContainer(c, probe, duration)
+	})
+}
+
+func (client trackingClient) MemoryPressureContainer(c container.Container, percent int, duration time.Duration) error {
+	return client.tracked(client.disruption("memory", c, duration), func() error {
+		return client.Client.MemoryPressureContainer(c, percent, duration)
+	})
+}
+
+func (client trackingClient) CorruptEnvContainer(c container.Container, set []string, unset []string, timeout int, duration time.Duration) error {
+	return client.tracked(client.disruption("env", c, duration), func() error {
+		return client.Client.CorruptEnvContainer(c, set, unset, timeout, duration)
+	})
+}
+
+func (client trackingClient) RemoveSecretsContainer(c container.Container, secrets []string, configs []string, duration time.Duration) error {
+	return client.tracked(client.disruption("secrets", c, duration), func() error {
+		return client.Client.RemoveSecretsContainer(c, secrets, configs, duration)
+	})
+}
+
+func (client trackingClient) PoisonImageContainer(c container.Container, image string, duration time.Duration) error {
+	return client.tracked(client.disruption("poison-image", c, duration), func() error {
+		return client.Client.PoisonImageContainer(c, image, duration)
+	})
+}
+
+func (client trackingClient) disruption(action string, c container.Container, duration time.Duration) Disruption {
+	return Disruption{Action: action, ContainerID: c.ID(), ContainerName: c.Name(), Until: client.now().Add(duration)}
+}
+
+// Adopt tracks disruption, started by another Pumba instance, waits for its end time and restores container;
+// blocks until container is restored; only Adoptable disruptions can be restored
+func Adopt(client container.Client, file *File, d Disruption, sleep func(time.Duration)) error {
+	if !d.Adoptable() {
+		return fmt.Errorf("Can not adopt %s disruption of container %s", d.Action, d.ContainerID)
+	}
+	id := file.Add(d)
+	defer file.Remove(id)
+	log.Infof("Adopted %s disruption of container %s until %s", d.Action, d.ContainerID, d.Until.Format(time.RFC3339))
+	sleep(d.Until.Sub(time.Now()))
+	containers, err := client.ListContainers(func(c container.Container) bool {
+		return c.ID() == d.ContainerID
+	})
+	if err != nil {
+		return err
+	}
+	if len(containers) == 0 {
+		return fmt.Errorf("Container %s of adopted %s disruption is not running", d.ContainerID, d.Action)
+	}
+	c := containers[0]
+	switch d.Action {
+	case "pause":
+		return client.UnpauseContainer(c)
+	case "netem":
+		var filter netem.Filter
+		if d.Filter != nil {
+			filter = *d.Filter
+		}
+		return client.StopNetemContainer(c, d.Interface, filter)
+	case "ports":
+		return client.RestorePortsContainer(c, d.Loss)
+	}
+	return fmt.Errorf("Unknown disruption action '%s'", d.Action)
+}
@@ -0,0 +1,140 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestClient_TracksDisruption(t *testing.T) {
+	path, cleanup := tempPath(t)
+	defer cleanup()
+	f, err := NewFile(path)
+	assert.NoError(t, err)
+	c := container.NewMockContainer("abc", "/c1", nil)
+	start := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	inner := container.NewMockSamalbaClient()
+	var during []Disruption
+	inner.On("NetemContainer", c, "eth0", "delay 100ms", netem.Filter{Protocol: "udp"}, time.Minute).Run(func(mock.Arguments) {
+		during = f.Disruptions()
+	}).Return(nil)
+	client := trackingClient{Client: inner, file: f, now: func() time.Time { return start }}
+
+	err = client.NetemContainer(c, "eth0", "delay 100ms", netem.Filter{Protocol: "udp"}, time.Minute, false, "")
+
+	assert.NoError(t, err)
+	if assert.Len(t, during, 1) {
+		assert.Equal(t, "netem", during[0].Action)
+		assert.Equal(t, "abc", during[0].ContainerID)
+		assert.Equal(t, "eth0", during[0].Interface)
+		assert.Equal(t, "udp", during[0].Filter.Protocol)
+		assert.Equal(t, start.Add(time.Minute), during[0].Until)
+	}
+	assert.Empty(t, f.Disruptions())
+	inner.AssertExpectations(t)
+}
+
+func TestClient_TracksBlackout(t *testing.T) {
+	path, cleanup := tempPath(t)
+	defer cleanup()
+	f, err := NewFile(path)
+	assert.NoError(t, err)
+	c := container.NewMockContainer("abc", "/c1", nil)
+	start := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	inner := container.NewMockSamalbaClient()
+	var during []Disruption
+	inner.On("BlackoutContainer", c, 10, time.Minute).Run(func(mock.Arguments) {
+		during = f.Unadoptable()
+	}).Return(nil)
+	client := trackingClient{Client: inner, file: f, now: func() time.Time { return start }}
+
+	assert.NoError(t, client.BlackoutContainer(c, 10, time.Minute))
+
+	// container is stopped until stop timeout and blackout duration pass
+	if assert.Len(t, during, 1) {
+		assert.Equal(t, "stop", during[0].Action)
+		assert.Equal(t, start.Add(70*time.Second), during[0].Until)
+	}
+	assert.Empty(t, f.Disruptions())
+	inner.AssertExpectations(t)
+}
+
+func TestClient_DryRunNotTracked(t *testing.T) {
+	path, cleanup := tempPath(t)
+	defer cleanup()
+	f, err := NewFile(path)
+	assert.NoError(t, err)
+	c := container.NewMockContainer("abc", "/c1", nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("PauseContainer", c, time.Minute).Run(func(mock.Arguments) {
+		assert.Empty(t, f.Disruptions())
+	}).Return(nil)
+	client := NewClient(inner, f)
+
+	inner.Dry = true
+	assert.NoError(t, client.PauseContainer(c, time.Minute))
+	inner.AssertExpectations(t)
+}
+
+func TestAdopt(t *testing.T) {
+	path, cleanup := tempPath(t)
+	defer cleanup()
+	f, err := NewFile(path)
+	assert.NoError(t, err)
+	c := container.NewMockContainer("abc", "/c1", nil)
+	inner := container.NewMockSamalbaClient()
+	inner.On("ListContainers", mock.Anything).Return([]container.Container{c}, nil)
+	inner.On("StopNetemContainer", c, "eth0", netem.Filter{Port: 53}).Return(nil)
+	inner.On("UnpauseContainer", c).Return(nil)
+	inner.On("RestorePortsContainer", c, 25).Return(nil)
+	var slept []time.Duration
+	sleep := func(d time.Duration) {
+		slept = append(slept, d)
+		// disruption is tracked by current instance until restored
+		assert.Len(t, f.Disruptions(), 1)
+	}
+	until := time.Now().Add(time.Hour)
+
+	assert.NoError(t, Adopt(inner, f, Disruption{Action: "netem", ContainerID: "abc", Interface: "eth0", Filter: &netem.Filter{Port: 53}, Until: until}, sleep))
+	assert.NoError(t, Adopt(inner, f, Disruption{Action: "pause", ContainerID: "abc", Until: until}, sleep))
+	assert.NoError(t, Adopt(inner, f, Disruption{Action: "ports", ContainerID: "abc", Loss: 25, Until: until}, sleep))
+
+	assert.Len(t, slept, 3)
+	assert.True(t, slept[0] > 59*time.Minute)
+	assert.Empty(t, f.Disruptions())
+	inner.AssertExpectations(t)
+}
+
+func TestAdopt_ContainerGone(t *testing.T) {
+	path, cleanup := tempPath(t)
+	defer cleanup()
+	f, err := NewFile(path)
+	assert.NoError(t, err)
+	inner := container.NewMockSamalbaClient()
+	inner.On("ListContainers", mock.Anything).Return([]container.Container{}, nil)
+
+	err = Adopt(inner, f, Disruption{Action: "pause", ContainerID: "abc"}, func(time.Duration) {})
+
+	assert.EqualError(t, err, "Container abc of adopted pause disruption is not running")
+	assert.Empty(t, f.Disruptions())
+}
+
+func TestAdopt_Unadoptable(t *testing.T) {
+	path, cleanup := tempPath(t)
+	defer cleanup()
+	f, err := NewFile(path)
+	assert.NoError(t, err)
+	inner := container.NewMockSamalbaClient()
+
+	err = Adopt(inner, f, Disruption{Action: "env", ContainerID: "abc"}, func(time.Duration) {
+		t.Error("unadoptable disruption is waited for")
+	})
+
+	assert.EqualError(t, err, "Can not adopt env disruption of container abc")
+	assert.Empty(t, f.Disruptions())
+	inner.AssertNotCalled(t, "ListContainers", mock.Anything)
+}
@@ -0,0 +1,35 @@
+// +build !windows
+
+package state
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"time"
+)
+
+// HandoverSignal signal, that makes running Pumba instance exit without restoring its active disruptions
+var HandoverSignal os.Signal = syscall.SIGUSR1
+
+// handoverPollInterval how often to check if Pumba instance has exited
+const handoverPollInterval = 100 * time.Millisecond
+
+// Handover sends handover signal to Pumba instance and waits for its exit
+func Handover(pid int, timeout time.Duration) error {
+	p, err := os.FindProcess(pid)
+	if err == nil {
+		err = p.Signal(HandoverSignal)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to signal Pumba instance (PID %d): %s", pid, err)
+	}
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); time.Sleep(handoverPollInterval) {
+		// signal 0 checks that process exists
+		if p.Signal(syscall.Signal(0)) != nil {
+			return nil
+		}
+	}
+	// instance refuses handover, while its disruptions can not be restored by new instance
+	return fmt.Errorf("Pumba instance (PID %d) did not exit in %s; see its log for refused handover", pid, timeout)
+}
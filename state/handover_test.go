@@ -0,0 +1,22 @@
+// +build !windows
+
+package state
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandover(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	assert.NoError(t, cmd.Start())
+	// reap process: zombie process still exists
+	go cmd.Wait()
+
+	err := Handover(cmd.Process.Pid, 5*time.Second)
+
+	assert.NoError(t, err)
+}
@@ -0,0 +1,16 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+)
+
+// HandoverSignal is not supported on Windows
+var HandoverSignal os.Signal
+
+// Handover is not supported on Windows: Pumba instance cannot be signaled
+func Handover(pid int, timeout time.Duration) error {
+	return fmt.Errorf("Takeover is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,207 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gaia-adm/pumba/netem"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Disruption active (not yet restored) disruption of container
+type Disruption struct {
+	// ID disruption ID, unique in state file
+	ID int `json:"id"`
+	// Action disruption action, e.g. pause, netem or ports
+	Action        string `json:"action"`
+	ContainerID   string `json:"container_id"`
+	ContainerName string `json:"container_name"`
+	// Until disruption end time, when container should be restored
+	Until time.Time `json:"until"`
+	// Interface network interface (netem)
+	Interface string `json:"interface,omitempty"`
+	// Filter netem filter (netem)
+	Filter *netem.Filter `json:"filter,omitempty"`
+	// Loss dropped packets percent (ports)
+	Loss int `json:"loss,omitempty"`
+}
+
+// adoptable disruption actions, that can be restored by another Pumba instance (see Adopt)
+var adoptable = map[string]bool{"pause": true, "netem": true, "ports": true}
+
+// Adoptable reports, whether disruption can be restored by another Pumba instance
+func (d Disruption) Adoptable() bool {
+	return adoptable[d.Action]
+}
+
+// Mark container, recently affected by chaos action (--mark state); mark expires after cooldown
+type Mark struct {
+	ContainerID   string `json:"container_id"`
+	ContainerName string `json:"container_name"`
+	// Action last chaos action on container
+	Action string    `json:"action"`
+	Until  time.Time `json:"until"`
+}
+
+// State state of running Pumba instance
+type State struct {
+	// PID process ID of Pumba instance, that owns the state
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+	// Disruptions active disruptions, that should be restored by Pumba instance
+	Disruptions []Disruption `json:"disruptions"`
+	// Marks containers, recently affected by chaos actions
+	Marks []Mark `json:"marks,omitempty"`
+}
+
+// Unadoptable returns active disruptions, that can not be restored by another Pumba instance
+func (st *State) Unadoptable() []Disruption {
+	var ds []Disruption
+	for _, d := range st.Disruptions {
+		if !d.Adoptable() {
+			ds = append(ds, d)
+		}
+	}
+	return ds
+}
+
+// Load reads state file
+func Load(path string) (*State, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var st State
+	if err = json.Unmarshal(data, &st); err != nil {
+		return nil, fmt.Errorf("Failed to parse state file '%s': %s", path, err)
+	}
+	return &st, nil
+}
+
+// File state of current Pumba instance, saved to file on every change
+type File struct {
+	path   string
+	mu     sync.Mutex
+	state  State
+	lastID int
+}
+
+// NewFile creates state file of current Pumba instance; empty path keeps state in memory only
+func NewFile(path string) (*File, error) {
+	f := &File{path: path, state: State{PID: os.Getpid(), Started: time.Now(), Disruptions: []Disruption{}}}
+	if err := f.save(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Add adds active disruption and returns its ID
+func (f *File) Add(d Disruption) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastID++
+	d.ID = f.lastID
+	f.state.Disruptions = append(f.state.Disruptions, d)
+	f.saveOrWarn()
+	return d.ID
+}
+
+// Remove removes restored disruption
+func (f *File) Remove(id int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, d := range f.state.Disruptions {
+		if d.ID == id {
+			f.state.Disruptions = append(f.state.Disruptions[:i], f.state.Disruptions[i+1:]...)
+			break
+		}
+	}
+	f.saveOrWarn()
+}
+
+// Disruptions returns active disruptions
+func (f *File) Disruptions() []Disruption {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Disruption{}, f.state.Disruptions...)
+}
+
+// Unadoptable returns active disruptions, that can not be restored by another Pumba instance
+func (f *File) Unadoptable() []Disruption {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.state.Unadoptable()
+}
+
+// SetMark marks container; replaces previous mark of the same container
+func (f *File) SetMark(m Mark) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.removeMark(m.ContainerID)
+	f.state.Marks = append(f.state.Marks, m)
+	f.saveOrWarn()
+}
+
+// RemoveMark removes expired container mark
+func (f *File) RemoveMark(containerID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.removeMark(containerID) {
+		f.saveOrWarn()
+	}
+}
+
+// Marks returns container marks
+func (f *File) Marks() []Mark {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Mark{}, f.state.Marks...)
+}
+
+// removeMark removes container mark, if any; should be called with lock held
+func (f *File) removeMark(containerID string) bool {
+	for i, m := range f.state.Marks {
+		if m.ContainerID == containerID {
+			f.state.Marks = append(f.state.Marks[:i], f.state.Marks[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (f *File) saveOrWarn() {
+	if err := f.save(); err != nil {
+		log.Warnf("Failed to save state file '%s': %s", f.path, err)
+	}
+}
+
+// save writes state to temporary file and renames it, so readers never see partially written state
+func (f *File) save() error {
+	if f.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(f.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path)+".")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), f.path)
+}
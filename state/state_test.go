@@ -0,0 +1,76 @@
+package state
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/netem"
+	"github.com/stretchr/testify/assert"
+)
+
+func tempPath(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "pumba")
+	assert.NoError(t, err)
+	return filepath.Join(dir, "pumba.state"), func() { os.RemoveAll(dir) }
+}
+
+func TestFile_AddRemove(t *testing.T) {
+	path, cleanup := tempPath(t)
+	defer cleanup()
+	f, err := NewFile(path)
+	assert.NoError(t, err)
+	until := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	id1 := f.Add(Disruption{Action: "pause", ContainerID: "abc", ContainerName: "/c1", Until: until})
+	id2 := f.Add(Disruption{Action: "netem", ContainerID: "def", Interface: "eth0", Filter: &netem.Filter{IPs: []net.IP{net.ParseIP("10.0.0.1")}, Protocol: "tcp"}, Until: until})
+	assert.NotEqual(t, id1, id2)
+
+	st, err := Load(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.Getpid(), st.PID)
+	if assert.Len(t, st.Disruptions, 2) {
+		assert.Equal(t, "pause", st.Disruptions[0].Action)
+		assert.True(t, until.Equal(st.Disruptions[0].Until))
+		assert.Equal(t, "eth0", st.Disruptions[1].Interface)
+		assert.Equal(t, "10.0.0.1", st.Disruptions[1].Filter.IPs[0].String())
+		assert.Equal(t, "tcp", st.Disruptions[1].Filter.Protocol)
+	}
+
+	f.Remove(id1)
+	st, err = Load(path)
+	assert.NoError(t, err)
+	if assert.Len(t, st.Disruptions, 1) {
+		assert.Equal(t, id2, st.Disruptions[0].ID)
+	}
+	assert.Len(t, f.Disruptions(), 1)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	path, cleanup := tempPath(t)
+	defer cleanup()
+	_, err := Load(path)
+	assert.Error(t, err)
+	ioutil.WriteFile(path, []byte("{pid"), 0644)
+	_, err = Load(path)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "Failed to parse state file")
+}
+
+func TestState_Unadoptable(t *testing.T) {
+	st := State{Disruptions: []Disruption{
+		{Action: "pause", ContainerID: "abc"},
+		{Action: "cpu", ContainerID: "def"},
+		{Action: "netem", ContainerID: "ghi"},
+		{Action: "sidecar", ContainerID: "jkl"},
+	}}
+
+	ds := st.Unadoptable()
+
+	if assert.Len(t, ds, 2) {
+		assert.Equal(t, "cpu", ds[0].Action)
+		assert.Equal(t, "sidecar", ds[1].Action)
+	}
+}
@@ -0,0 +1,206 @@
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gaia-adm/pumba/events"
+	"github.com/gaia-adm/pumba/scheduler"
+	"github.com/gaia-adm/pumba/state"
+)
+
+// DefaultAddr default address of Pumba API
+const DefaultAddr = "localhost:8585"
+
+// RecentEvents number of recent chaos events reported in status
+const RecentEvents = 20
+
+// Experiment running chaos command
+type Experiment struct {
+	// Command chaos command, like 'kill --signal SIGTERM re2:^api'
+	Command  string `json:"command"`
+	Interval string `json:"interval,omitempty"`
+	// Next time of the next scheduled run
+	Next     *time.Time `json:"next,omitempty"`
+	Runs     int        `json:"runs"`
+	Failures int        `json:"failures"`
+}
+
+// Status status of running Pumba daemon
+type Status struct {
+	PID         int          `json:"pid"`
+	Version     string       `json:"version"`
+	Started     time.Time    `json:"started"`
+	Experiments []Experiment `json:"experiments"`
+	// Disrupted active disruptions of containers
+	Disrupted []Disruption `json:"disrupted"`
+	// Marked containers, recently affected by chaos actions (--mark state)
+	Marked []state.Mark `json:"marked,omitempty"`
+	// Events recent chaos events, oldest first
+	Events []events.Event `json:"events,omitempty"`
+}
+
+// Disruption active disruption of container (pause, netem or ports) with remaining duration
+type Disruption struct {
+	state.Disruption
+	// Remaining remaining duration at status time, in seconds
+	Remaining int64 `json:"remaining_seconds"`
+}
+
+type experiment struct {
+	command   string
+	scheduler *scheduler.Scheduler
+}
+
+// Server serves status of Pumba daemon on '/status' endpoint
+type Server struct {
+	version     string
+	started     time.Time
+	state       *state.File
+	mu          sync.Mutex
+	experiments []*experiment
+	events      []events.Event
+	now         func() time.Time
+}
+
+// NewServer creates status server; active disruptions are read from state
+func NewServer(version string, st *state.File) *Server {
+	return &Server{version: version, started: time.Now(), state: st, now: time.Now}
+}
+
+// Register adds running chaos command with its scheduler; returns function, that removes the command
+func (srv *Server) Register(command string, s *scheduler.Scheduler) func() {
+	e := &experiment{command: command, scheduler: s}
+	srv.mu.Lock()
+	srv.experiments = append(srv.experiments, e)
+	srv.mu.Unlock()
+	return func() {
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		for i, x := range srv.experiments {
+			if x == e {
+				srv.experiments = append(srv.experiments[:i], srv.experiments[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// Handle keeps recent chaos events
+func (srv *Server) Handle(e events.Event) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.events = append(srv.events, e)
+	if len(srv.events) > RecentEvents {
+		srv.events = srv.events[len(srv.events)-RecentEvents:]
+	}
+}
+
+// Status returns current status
+func (srv *Server) Status() Status {
+	st := Status{PID: os.Getpid(), Version: srv.version, Started: srv.started, Experiments: []Experiment{}, Disrupted: []Disruption{}}
+	srv.mu.Lock()
+	for _, e := range srv.experiments {
+		x := Experiment{Command: e.command, Runs: e.scheduler.Runs(), Failures: e.scheduler.Failures()}
+		if !e.scheduler.Once {
+			x.Interval = e.scheduler.Interval.String()
+		}
+		if next := e.scheduler.Next(); !next.IsZero() {
+			x.Next = &next
+		}
+		st.Experiments = append(st.Experiments, x)
+	}
+	st.Events = append(st.Events, srv.events...)
+	srv.mu.Unlock()
+	if srv.state != nil {
+		now := srv.now()
+		for _, d := range srv.state.Disruptions() {
+			st.Disrupted = append(st.Disrupted, Disruption{Disruption: d, Remaining: Remaining(d, now)})
+		}
+		st.Marked = srv.state.Marks()
+	}
+	return st
+}
+
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(srv.Status())
+}
+
+// Remaining returns remaining duration of disruption in seconds, rounded to the nearest second; 0 if disruption
+// is over
+func Remaining(d state.Disruption, now time.Time) int64 {
+	left := d.Until.Sub(now)
+	if left <= 0 {
+		return 0
+	}
+	return int64((left + time.Second/2) / time.Second)
+}
+
+// Get reads status of Pumba daemon, serving API on specified address
+func Get(addr string, timeout time.Duration) (*Status, error) {
+	client := http.Client{Timeout: timeout}
+	resp, err := client.Get(fmt.Sprintf("http://%s/status", addr))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Unexpected status of Pumba API %s: %s", addr, resp.Status)
+	}
+	var st Status
+	if err = json.NewDecoder(resp.Body).Decode(&st); err != nil {
+		return nil, fmt.Errorf("Failed to parse Pumba status: %s", err)
+	}
+	return &st, nil
+}
+
+// WriteTable writes status as text tables: experiments and disrupted containers
+func WriteTable(out io.Writer, st *Status) error {
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Pumba %s (PID %d), started %s\n\n", st.Version, st.PID, st.Started.Format(time.RFC3339))
+	fmt.Fprintln(w, "EXPERIMENT\tINTERVAL\tNEXT TICK\tRUNS\tFAILURES")
+	for _, e := range st.Experiments {
+		next := "-"
+		if e.Next != nil {
+			next = e.Next.Format(time.RFC3339)
+		}
+		interval := e.Interval
+		if interval == "" {
+			interval = "once"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\n", e.Command, interval, next, e.Runs, e.Failures)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "CONTAINER\tID\tACTION\tUNTIL\tREMAINING")
+	for _, d := range st.Disrupted {
+		remaining := time.Duration(d.Remaining) * time.Second
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.ContainerName, d.ContainerID, d.Action, d.Until.Format(time.RFC3339), remaining)
+	}
+	if len(st.Events) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "TIME\tEVENT\tACTION\tCONTAINER\tERROR/REASON")
+		for _, e := range st.Events {
+			target := e.Command
+			if e.Type != events.Scheduled {
+				target = e.ContainerName
+			}
+			details := e.Error
+			if e.Type == events.Skipped {
+				details = e.Reason
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Time.Format(time.RFC3339), e.Type, e.Action, target, details)
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,126 @@
+package status
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/events"
+	"github.com/gaia-adm/pumba/scheduler"
+	"github.com/gaia-adm/pumba/state"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_Status(t *testing.T) {
+	st, err := state.NewFile("")
+	assert.NoError(t, err)
+	now := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	st.Add(state.Disruption{Action: "pause", ContainerID: "abc", ContainerName: "/api_1", Until: now.Add(time.Minute)})
+	st.Add(state.Disruption{Action: "netem", ContainerID: "def", ContainerName: "/db_1", Until: now.Add(-time.Second)})
+	srv := NewServer("v0.2.0", st)
+	srv.now = func() time.Time { return now }
+	unregister := srv.Register("kill re2:^api", scheduler.New(10*time.Minute))
+
+	status := srv.Status()
+	assert.Equal(t, "v0.2.0", status.Version)
+	if assert.Len(t, status.Experiments, 1) {
+		assert.Equal(t, "kill re2:^api", status.Experiments[0].Command)
+		assert.Equal(t, "10m0s", status.Experiments[0].Interval)
+		// scheduler is not running
+		assert.Nil(t, status.Experiments[0].Next)
+	}
+	if assert.Len(t, status.Disrupted, 2) {
+		assert.Equal(t, "/api_1", status.Disrupted[0].ContainerName)
+		assert.Equal(t, int64(60), status.Disrupted[0].Remaining)
+		// disruption is being removed
+		assert.Equal(t, int64(0), status.Disrupted[1].Remaining)
+	}
+
+	unregister()
+	assert.Empty(t, srv.Status().Experiments)
+}
+
+func TestGet(t *testing.T) {
+	srv := NewServer("v0.2.0", nil)
+	srv.Register("netem --duration 1m delay re2:^db", scheduler.New(time.Hour))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+
+	status, err := Get(u.Host, time.Second)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "v0.2.0", status.Version)
+	if assert.Len(t, status.Experiments, 1) {
+		assert.Equal(t, "1h0m0s", status.Experiments[0].Interval)
+	}
+	assert.Empty(t, status.Disrupted)
+}
+
+func TestGet_Error(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL)
+
+	_, err := Get(u.Host, time.Second)
+
+	assert.EqualError(t, err, "Unexpected status of Pumba API "+u.Host+": 404 Not Found")
+}
+
+func TestWriteTable(t *testing.T) {
+	next := time.Date(2016, 8, 1, 10, 10, 0, 0, time.UTC)
+	status := &Status{
+		PID:         42,
+		Version:     "v0.2.0",
+		Started:     time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC),
+		Experiments: []Experiment{{Command: "kill re2:^api", Interval: "10m0s", Next: &next, Runs: 3, Failures: 1}},
+		Disrupted:   []Disruption{{Disruption: state.Disruption{Action: "netem", ContainerID: "abc", ContainerName: "/db_1", Until: time.Date(2016, 8, 1, 10, 5, 0, 0, time.UTC)}, Remaining: 270}},
+	}
+	var out bytes.Buffer
+
+	assert.NoError(t, WriteTable(&out, status))
+
+	assert.Equal(t, `Pumba v0.2.0 (PID 42), started 2016-08-01T10:00:00Z
+
+EXPERIMENT     INTERVAL  NEXT TICK             RUNS  FAILURES
+kill re2:^api  10m0s     2016-08-01T10:10:00Z  3     1
+
+CONTAINER  ID   ACTION  UNTIL                 REMAINING
+/db_1      abc  netem   2016-08-01T10:05:00Z  4m30s
+`, out.String())
+}
+
+func TestRemaining(t *testing.T) {
+	now := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	d := state.Disruption{Until: now.Add(90 * time.Second)}
+	assert.Equal(t, int64(90), Remaining(d, now))
+	assert.Equal(t, int64(1), Remaining(d, now.Add(89*time.Second+100*time.Millisecond)))
+	assert.Equal(t, int64(0), Remaining(d, now.Add(2*time.Minute)))
+}
+
+func TestServer_Events(t *testing.T) {
+	srv := NewServer("v0.2.0", nil)
+	for i := 0; i < RecentEvents+5; i++ {
+		srv.Handle(events.Event{Type: events.Scheduled, Command: fmt.Sprintf("kill %d", i)})
+	}
+	st := srv.Status()
+	if assert.Len(t, st.Events, RecentEvents) {
+		assert.Equal(t, "kill 5", st.Events[0].Command)
+	}
+	var out bytes.Buffer
+	at := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	assert.NoError(t, WriteTable(&out, &Status{Started: at, Events: []events.Event{
+		{Type: events.Scheduled, Time: at, Command: "kill re2:^api"},
+		{Type: events.Failed, Time: at, Action: "kill", ContainerName: "/api_1", Error: "oops"},
+		{Type: events.Skipped, Time: at, ContainerName: "/api_2", Reason: "not selected in random mode"},
+	}}))
+	assert.Contains(t, out.String(), `TIME                  EVENT      ACTION  CONTAINER      ERROR/REASON
+2016-08-01T10:00:00Z  scheduled          kill re2:^api  
+2016-08-01T10:00:00Z  failed     kill    /api_1         oops
+2016-08-01T10:00:00Z  skipped            /api_2         not selected in random mode
+`)
+}
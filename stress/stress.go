@@ -0,0 +1,47 @@
+package stress
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// cpusScript shell script, that sets number of CPUs available to container, if not set: CPU quota of container
+// cgroup (cpu.max of cgroup v2 or cpu.cfs_quota_us of cgroup v1), rounded up, or number of CPUs visible in container,
+// if quota is not set
+const cpusScript = `[ "$n" -gt 0 ] || { q=; p=; { read q p < /sys/fs/cgroup/cpu.max || ` +
+	`{ read q < /sys/fs/cgroup/cpu/cpu.cfs_quota_us && read p < /sys/fs/cgroup/cpu/cpu.cfs_period_us; }; } 2>/dev/null; ` +
+	`case "$q" in ""|max|-*) n=$(grep -c ^processor /proc/cpuinfo) ;; *) p=${p:-100000}; n=$(( (q + p - 1) / p )) ;; esac; }; `
+
+// cpuScript shell script, that burns CPU with busy-loop workers (one per CPU available to container, if workers
+// number is 0) and kills them after duration; needs only POSIX shell, no stress tools in container image
+const cpuScript = `n=%d; ` + cpusScript +
+	`pids=""; i=0; while [ "$i" -lt "$n" ]; do (while :; do :; done) & pids="$pids $!"; i=$((i+1)); done; ` +
+	`trap 'kill $pids 2>/dev/null; exit 0' INT TERM; sleep %d; kill $pids 2>/dev/null; exit 0`
+
+// CPUCommand returns command, that runs CPU busy-loop workers inside container for duration (rounded up to seconds);
+// workers: number of busy-loop processes, 0 - one per CPU available to container (CPU quota of cgroup v1 or v2)
+func CPUCommand(workers int, duration time.Duration) []string {
+	seconds := int(math.Ceil(duration.Seconds()))
+	return []string{"sh", "-c", fmt.Sprintf(cpuScript, workers, seconds)}
+}
+
+// Exit codes of OOM command: memory balloon is killed by OOM killer (SIGKILL) or stopped after timeout (SIGTERM)
+const (
+	OOMKilledCode  = 137
+	OOMTimeoutCode = 143
+)
+
+// oomScript shell script, that makes container main process (PID 1) preferred OOM killer victim and runs memory
+// balloon ('tail' buffers endless line of /dev/zero) till kernel OOM killer fires in container memory cgroup; if
+// OOM killer does not fire, balloon is stopped after timeout and OOM score of main process is restored
+const oomScript = `adj=$(cat /proc/1/oom_score_adj 2>/dev/null); { echo 1000 > /proc/1/oom_score_adj; } 2>/dev/null; ` +
+	`tail /dev/zero & b=$!; (sleep %d; kill $b 2>/dev/null) & t=$!; ` +
+	`wait $b; rc=$?; kill $t 2>/dev/null; [ -z "$adj" ] || { echo "$adj" > /proc/1/oom_score_adj; } 2>/dev/null; exit $rc`
+
+// OOMCommand returns command, that runs memory balloon inside container till OOM killer fires or timeout (rounded
+// up to seconds); exits with OOMKilledCode or OOMTimeoutCode
+func OOMCommand(timeout time.Duration) []string {
+	seconds := int(math.Ceil(timeout.Seconds()))
+	return []string{"sh", "-c", fmt.Sprintf(oomScript, seconds)}
+}
@@ -0,0 +1,50 @@
+package stress
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCPUCommand(t *testing.T) {
+	cmd := CPUCommand(2, 1500*time.Millisecond)
+	assert.Equal(t, []string{"sh", "-c"}, cmd[:2])
+	assert.True(t, strings.HasPrefix(cmd[2], "n=2; "))
+	assert.True(t, strings.Contains(cmd[2], "sleep 2; "))
+}
+
+func TestCPUCommand_Run(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	cmd := CPUCommand(1, time.Second)
+	start := time.Now()
+	out, err := exec.Command(cmd[0], cmd[1:]...).CombinedOutput()
+	assert.NoError(t, err, string(out))
+	assert.True(t, time.Since(start) >= time.Second)
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestCPUsScript(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+	// number of workers is set
+	out, err := exec.Command("sh", "-c", "n=3; "+cpusScript+"echo $n").CombinedOutput()
+	assert.NoError(t, err, string(out))
+	assert.Equal(t, "3", strings.TrimSpace(string(out)))
+	// number of CPUs available to process: CPU quota or number of CPUs
+	out, err = exec.Command("sh", "-c", "n=0; "+cpusScript+"echo $n").CombinedOutput()
+	assert.NoError(t, err, string(out))
+	assert.Regexp(t, "^[1-9][0-9]*$", strings.TrimSpace(string(out)))
+}
+
+func TestOOMCommand(t *testing.T) {
+	cmd := OOMCommand(500 * time.Millisecond)
+	assert.Equal(t, []string{"sh", "-c"}, cmd[:2])
+	assert.True(t, strings.Contains(cmd[2], "tail /dev/zero & "))
+	assert.True(t, strings.Contains(cmd[2], "(sleep 1; kill $b"))
+}
@@ -0,0 +1,27 @@
+package summary
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary_Handle(t *testing.T) {
+	c := container.NewMockContainer("abc", "/api_1", nil)
+	s := New(time.Minute)
+	s.Handle(events.Event{Type: events.Started, Action: "pause", Container: c})
+	s.Handle(events.Event{Type: events.Failed, Action: "pause", Container: c, Err: errors.New("pause failed")})
+	s.Handle(events.Event{Type: events.Succeeded, Action: "kill", Container: c, DryRun: true})
+	s.Handle(events.Event{Type: events.CleanedUp, Action: "pause", Container: c})
+	s.Handle(events.Event{Type: events.Skipped, Container: container.NewMockContainer("def", "/api_2", nil), Reason: "Pumba container"})
+	r := s.Flush()
+	assert.Equal(t, map[string]int{"pause": 1, "kill": 1}, r.Actions)
+	assert.Equal(t, 1, r.Failures)
+	assert.Equal(t, 1, r.Containers)
+	assert.Equal(t, 1, r.Skipped)
+	assert.Equal(t, "2 chaos actions (kill: 1, pause: 1), 1 failed, 1 containers affected, 1 skipped in last "+r.Period.String(), r.String())
+}
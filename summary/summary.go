@@ -0,0 +1,162 @@
+package summary
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/gaia-adm/pumba/events"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// DefaultInterval summary interval in quiet mode
+const DefaultInterval = 10 * time.Minute
+
+// Report summary of chaos actions run during period
+type Report struct {
+	Period time.Duration
+	// Actions number of actions per action name (kill, netem, ...)
+	Actions    map[string]int
+	Failures   int
+	Containers int
+	// Skipped number of containers, matching chaos command targets, that were skipped
+	Skipped int
+}
+
+// Total returns number of actions run
+func (r Report) Total() int {
+	total := 0
+	for _, n := range r.Actions {
+		total += n
+	}
+	return total
+}
+
+// String formats report as single line: '5 chaos actions (kill: 3, netem: 2), 1 failed, 4 containers affected in last 10m0s';
+// skipped containers are reported, if any: '..., 4 containers affected, 2 skipped in last 10m0s'
+func (r Report) String() string {
+	names := make([]string, 0, len(r.Actions))
+	for name := range r.Actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	counts := make([]string, 0, len(names))
+	for _, name := range names {
+		counts = append(counts, fmt.Sprintf("%s: %d", name, r.Actions[name]))
+	}
+	actions := fmt.Sprintf("%d chaos actions", r.Total())
+	if len(counts) > 0 {
+		actions += " (" + strings.Join(counts, ", ") + ")"
+	}
+	skipped := ""
+	if r.Skipped > 0 {
+		skipped = fmt.Sprintf(", %d skipped", r.Skipped)
+	}
+	return fmt.Sprintf("%s, %d failed, %d containers affected%s in last %s", actions, r.Failures, r.Containers, skipped, r.Period)
+}
+
+// Summary counts chaos actions and logs their summary every interval
+type Summary struct {
+	interval   time.Duration
+	now        func() time.Time
+	mu         sync.Mutex
+	start      time.Time
+	actions    map[string]int
+	failures   int
+	containers map[string]bool
+	skipped    map[string]bool
+	done       chan struct{}
+	stopOnce   sync.Once
+}
+
+// New creates summary of chaos actions; call Start to log summary every interval
+func New(interval time.Duration) *Summary {
+	s := &Summary{interval: interval, now: time.Now, done: make(chan struct{})}
+	s.reset()
+	return s
+}
+
+func (s *Summary) reset() {
+	s.start = s.now()
+	s.actions = map[string]int{}
+	s.failures = 0
+	s.containers = map[string]bool{}
+	s.skipped = map[string]bool{}
+}
+
+// Record counts chaos action on container and its failure
+func (s *Summary) Record(action string, c container.Container, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actions[action]++
+	if err != nil {
+		s.failures++
+	}
+	s.containers[c.ID()] = true
+}
+
+// Skip counts container skipped by chaos action
+func (s *Summary) Skip(c container.Container) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.skipped[c.ID()] = true
+}
+
+// Handle counts result of chaos action, including dry runs, and skipped containers
+func (s *Summary) Handle(e events.Event) {
+	switch e.Type {
+	case events.Succeeded, events.Failed:
+		s.Record(e.Action, e.Container, e.Err)
+	case events.Skipped:
+		s.Skip(e.Container)
+	}
+}
+
+// Flush returns report of actions recorded since last flush and starts new period
+func (s *Summary) Flush() Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := Report{Period: s.now().Sub(s.start), Actions: s.actions, Failures: s.failures, Containers: len(s.containers), Skipped: len(s.skipped)}
+	s.reset()
+	return r
+}
+
+// Log logs report of actions recorded since last flush
+func (s *Summary) Log() {
+	r := s.Flush()
+	log.WithFields(log.Fields{
+		"actions":    r.Total(),
+		"failures":   r.Failures,
+		"containers": r.Containers,
+		"skipped":    r.Skipped,
+		"period":     r.Period.String(),
+	}).Info(r.String())
+}
+
+// Start logs summary every interval in background, till Stop
+func (s *Summary) Start() {
+	ticker := time.NewTicker(s.interval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.Log()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops periodic summary and logs summary of the last (partial) period
+func (s *Summary) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.done)
+		s.Log()
+	})
+}
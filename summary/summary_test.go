@@ -0,0 +1,41 @@
+package summary
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/container"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary_Flush(t *testing.T) {
+	now := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	s := New(time.Minute)
+	s.now = func() time.Time { return now }
+	s.reset()
+	s.Record("kill", container.NewMockContainer("abc", "/c1", nil), nil)
+	s.Record("kill", container.NewMockContainer("def", "/c2", nil), errors.New("oops"))
+	s.Record("netem", container.NewMockContainer("abc", "/c1", nil), nil)
+	now = now.Add(10 * time.Minute)
+	r := s.Flush()
+	assert.Equal(t, Report{Period: 10 * time.Minute, Actions: map[string]int{"kill": 2, "netem": 1}, Failures: 1, Containers: 2}, r)
+	assert.Equal(t, 3, r.Total())
+	assert.Equal(t, "3 chaos actions (kill: 2, netem: 1), 1 failed, 2 containers affected in last 10m0s", r.String())
+	// new period
+	now = now.Add(time.Minute)
+	r = s.Flush()
+	assert.Equal(t, 0, r.Total())
+	assert.Equal(t, "0 chaos actions, 0 failed, 0 containers affected in last 1m0s", r.String())
+}
+
+func TestSummary_StartStop(t *testing.T) {
+	s := New(time.Millisecond)
+	s.Start()
+	s.Record("kill", container.NewMockContainer("abc", "/c1", nil), nil)
+	time.Sleep(10 * time.Millisecond)
+	s.Stop()
+	// stop is idempotent
+	s.Stop()
+	assert.Equal(t, 0, s.Flush().Total())
+}
@@ -0,0 +1,149 @@
+// Package targets reads target container names or IDs from file or stdin (--targets-file), so external systems
+// can drive target selection of running chaos command
+package targets
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// Stdin targets file name, that reads targets from standard input
+const Stdin = "-"
+
+// Source provides current target container names or IDs
+type Source interface {
+	Targets() ([]string, error)
+}
+
+// Open opens targets source: file, re-read when changed, or standard input stream
+func Open(path string) (Source, error) {
+	if path == Stdin {
+		return NewStream(os.Stdin), nil
+	}
+	f := NewFile(path)
+	if _, err := f.Targets(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Parse reads targets, one container name or ID per line; empty lines and '#' comments are skipped
+func Parse(r io.Reader) ([]string, error) {
+	targets := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if target := parseLine(scanner.Text()); target != "" {
+			targets = append(targets, target)
+		}
+	}
+	return targets, scanner.Err()
+}
+
+// parseLine returns target of line: container name (without leading slash) or ID; empty for blank and comment lines
+func parseLine(line string) string {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "#") {
+		return ""
+	}
+	return strings.TrimPrefix(line, "/")
+}
+
+// File targets file, re-read when its modification time or size changes
+type File struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+	targets []string
+}
+
+// NewFile creates targets file source
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Targets returns targets of file; file is read again, only if it was changed since last read
+func (f *File) Targets() ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read targets file: %s", err)
+	}
+	if f.targets != nil && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.targets, nil
+	}
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read targets file: %s", err)
+	}
+	defer file.Close()
+	targets, err := Parse(file)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read targets file %s: %s", f.path, err)
+	}
+	log.WithField("targets", targets).Infof("Read %d targets from %s", len(targets), f.path)
+	f.targets, f.modTime, f.size = targets, info.ModTime(), info.Size()
+	return targets, nil
+}
+
+// Stream targets read from stream (stdin) in background: each batch of lines, terminated by empty line or end of
+// stream, replaces current targets
+type Stream struct {
+	mu      sync.Mutex
+	targets []string
+	err     error
+}
+
+// NewStream creates targets source and starts reading stream; no targets till the first batch is read
+func NewStream(r io.Reader) *Stream {
+	s := &Stream{targets: []string{}}
+	go s.read(r)
+	return s
+}
+
+// Targets returns targets of the last complete batch
+func (s *Stream) Targets() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.targets, s.err
+}
+
+func (s *Stream) read(r io.Reader) {
+	var batch []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			s.replace(batch)
+			batch = nil
+			continue
+		}
+		if target := parseLine(scanner.Text()); target != "" {
+			batch = append(batch, target)
+		}
+	}
+	if batch != nil {
+		s.replace(batch)
+	}
+	if err := scanner.Err(); err != nil {
+		s.mu.Lock()
+		s.err = fmt.Errorf("Failed to read targets from stdin: %s", err)
+		s.mu.Unlock()
+	}
+}
+
+// replace replaces current targets with batch; empty batch clears targets
+func (s *Stream) replace(batch []string) {
+	targets := append([]string{}, batch...)
+	log.WithField("targets", targets).Infof("Read %d targets from stdin", len(targets))
+	s.mu.Lock()
+	s.targets = targets
+	s.mu.Unlock()
+}
@@ -0,0 +1,85 @@
+package targets
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	targets, err := Parse(strings.NewReader("api_1\n  /db_1 \n\n# canary\n3f2a9c1b7e4d\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"api_1", "db_1", "3f2a9c1b7e4d"}, targets)
+
+	targets, err = Parse(strings.NewReader(""))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, targets)
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "targets")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "targets.txt")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("api_1\n"), 0644))
+
+	f, err := Open(path)
+	assert.NoError(t, err)
+	targets, err := f.Targets()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"api_1"}, targets)
+
+	// changed file is read again
+	assert.NoError(t, ioutil.WriteFile(path, []byte("api_1\napi_2\n"), 0644))
+	targets, err = f.Targets()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"api_1", "api_2"}, targets)
+
+	assert.NoError(t, os.Remove(path))
+	_, err = f.Targets()
+	assert.Error(t, err)
+}
+
+func TestOpen_Missing(t *testing.T) {
+	_, err := Open("/nonexistent/targets.txt")
+	assert.EqualError(t, err, "Failed to read targets file: stat /nonexistent/targets.txt: no such file or directory")
+}
+
+func waitTargets(t *testing.T, s *Stream, expected []string) {
+	for i := 0; i < 1000; i++ {
+		if targets, _ := s.Targets(); assert.ObjectsAreEqual(expected, targets) {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	targets, _ := s.Targets()
+	assert.Equal(t, expected, targets)
+}
+
+func TestStream(t *testing.T) {
+	r, w := io.Pipe()
+	s := NewStream(r)
+	targets, err := s.Targets()
+	assert.NoError(t, err)
+	assert.Empty(t, targets)
+
+	// incomplete batch is not used
+	io.WriteString(w, "api_1\napi_2\n")
+	time.Sleep(10 * time.Millisecond)
+	targets, _ = s.Targets()
+	assert.Empty(t, targets)
+
+	io.WriteString(w, "\n")
+	waitTargets(t, s, []string{"api_1", "api_2"})
+
+	// new batch replaces targets; end of stream completes batch
+	io.WriteString(w, "db_1\n")
+	w.Close()
+	waitTargets(t, s, []string{"db_1"})
+}
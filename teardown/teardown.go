@@ -0,0 +1,110 @@
+// Package teardown applies composite disruptions (e.g. prio qdisc, netem, tc filters and iptables rules) as named
+// pipelines of setup steps and removes them in reverse order of creation
+package teardown
+
+import (
+	log "github.com/Sirupsen/logrus"
+)
+
+// Step named setup step: command, that creates resource, and command, that removes it
+type Step struct {
+	// Name resource description, e.g. 'root prio qdisc' or 'ip filter dst 10.0.0.1'
+	Name  string
+	Apply []string
+	// Undo command, that removes resource; nil, if resource is removed with an earlier step (e.g. tc filters
+	// with root qdisc)
+	Undo []string
+}
+
+// Pipeline named sequence of setup steps, applied in order and torn down in reverse order
+type Pipeline struct {
+	// Name pipeline description, e.g. 'netem on eth0'
+	Name  string
+	Steps []Step
+}
+
+// Exec runs command of step
+type Exec func(cmd []string) error
+
+// Stack teardown stack of applied steps of pipeline: last applied step is removed first
+type Stack struct {
+	name  string
+	steps []Step
+}
+
+// ApplyCommands returns commands, that apply all pipeline steps
+func (p Pipeline) ApplyCommands() [][]string {
+	cmds := make([][]string, 0, len(p.Steps))
+	for _, s := range p.Steps {
+		cmds = append(cmds, s.Apply)
+	}
+	return cmds
+}
+
+// TeardownCommands returns commands, that remove all pipeline steps, in reverse order
+func (p Pipeline) TeardownCommands() [][]string {
+	return p.Stack().commands()
+}
+
+// Stack returns teardown stack of fully applied pipeline; used to remove disruption, applied earlier (or by
+// another Pumba instance)
+func (p Pipeline) Stack() *Stack {
+	return &Stack{name: p.Name, steps: append([]Step(nil), p.Steps...)}
+}
+
+// Apply runs pipeline steps in order and returns teardown stack of applied steps; when a step fails, steps
+// applied before it are rolled back in reverse order and error of failed step is returned
+func (p Pipeline) Apply(exec Exec) (*Stack, error) {
+	stack := &Stack{name: p.Name}
+	for i, s := range p.Steps {
+		log.Debugf("Applying %s (step %d/%d of %s)", s.Name, i+1, len(p.Steps), p.Name)
+		if err := exec(s.Apply); err != nil {
+			if len(stack.steps) > 0 {
+				log.Warnf("Failed to apply %s of %s; rolling back %d applied steps", s.Name, p.Name, len(stack.steps))
+				if rerr := stack.Unwind(exec); rerr != nil {
+					log.Warnf("Failed to roll back %s: %s", p.Name, rerr)
+				}
+			}
+			return nil, err
+		}
+		stack.steps = append(stack.steps, s)
+	}
+	return stack, nil
+}
+
+// Len returns number of applied steps
+func (s *Stack) Len() int {
+	return len(s.steps)
+}
+
+// Unwind removes applied steps in reverse order; continues after failed step, so other resources are removed,
+// and returns the first error; stack is empty afterwards
+func (s *Stack) Unwind(exec Exec) error {
+	var first error
+	for i := len(s.steps) - 1; i >= 0; i-- {
+		step := s.steps[i]
+		if step.Undo == nil {
+			continue
+		}
+		log.Debugf("Removing %s of %s", step.Name, s.name)
+		if err := exec(step.Undo); err != nil {
+			log.Warnf("Failed to remove %s of %s: %s", step.Name, s.name, err)
+			if first == nil {
+				first = err
+			}
+		}
+	}
+	s.steps = nil
+	return first
+}
+
+// commands returns undo commands of applied steps, in reverse order
+func (s *Stack) commands() [][]string {
+	var cmds [][]string
+	for i := len(s.steps) - 1; i >= 0; i-- {
+		if s.steps[i].Undo != nil {
+			cmds = append(cmds, s.steps[i].Undo)
+		}
+	}
+	return cmds
+}
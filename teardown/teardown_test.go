@@ -0,0 +1,74 @@
+package teardown
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testPipeline() Pipeline {
+	return Pipeline{Name: "netem on eth0", Steps: []Step{
+		{Name: "root qdisc", Apply: []string{"add", "root"}, Undo: []string{"del", "root"}},
+		{Name: "netem qdisc", Apply: []string{"add", "netem"}},
+		{Name: "mark rule 1", Apply: []string{"add", "rule1"}, Undo: []string{"del", "rule1"}},
+		{Name: "mark rule 2", Apply: []string{"add", "rule2"}, Undo: []string{"del", "rule2"}},
+	}}
+}
+
+// recorder records executed commands and fails commands listed in fail
+type recorder struct {
+	cmds []string
+	fail map[string]bool
+}
+
+func (r *recorder) exec(cmd []string) error {
+	c := strings.Join(cmd, " ")
+	r.cmds = append(r.cmds, c)
+	if r.fail[c] {
+		return errors.New(c + " failed")
+	}
+	return nil
+}
+
+func TestPipeline_Commands(t *testing.T) {
+	p := testPipeline()
+	assert.Equal(t, [][]string{{"add", "root"}, {"add", "netem"}, {"add", "rule1"}, {"add", "rule2"}}, p.ApplyCommands())
+	// reverse order; steps without undo are removed with earlier steps
+	assert.Equal(t, [][]string{{"del", "rule2"}, {"del", "rule1"}, {"del", "root"}}, p.TeardownCommands())
+}
+
+func TestPipeline_ApplyAndUnwind(t *testing.T) {
+	r := &recorder{}
+	stack, err := testPipeline().Apply(r.exec)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, stack.Len())
+	assert.NoError(t, stack.Unwind(r.exec))
+	assert.Equal(t, 0, stack.Len())
+	assert.Equal(t, []string{"add root", "add netem", "add rule1", "add rule2", "del rule2", "del rule1", "del root"}, r.cmds)
+}
+
+func TestPipeline_RollbackOnFailure(t *testing.T) {
+	r := &recorder{fail: map[string]bool{"add rule2": true}}
+	stack, err := testPipeline().Apply(r.exec)
+	assert.EqualError(t, err, "add rule2 failed")
+	assert.Nil(t, stack)
+	// failed step is not rolled back, applied steps are removed in reverse order
+	assert.Equal(t, []string{"add root", "add netem", "add rule1", "add rule2", "del rule1", "del root"}, r.cmds)
+}
+
+func TestPipeline_FirstStepFails(t *testing.T) {
+	r := &recorder{fail: map[string]bool{"add root": true}}
+	_, err := testPipeline().Apply(r.exec)
+	assert.EqualError(t, err, "add root failed")
+	assert.Equal(t, []string{"add root"}, r.cmds)
+}
+
+func TestStack_UnwindContinuesAfterFailure(t *testing.T) {
+	r := &recorder{fail: map[string]bool{"del rule2": true, "del rule1": true}}
+	err := testPipeline().Stack().Unwind(r.exec)
+	// first error is returned; other resources are still removed
+	assert.EqualError(t, err, "del rule2 failed")
+	assert.Equal(t, []string{"del rule2", "del rule1", "del root"}, r.cmds)
+}
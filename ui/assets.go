@@ -0,0 +1,202 @@
+package ui
+
+// asset static web UI file, embedded into Pumba binary
+type asset struct {
+	contentType string
+	content     string
+}
+
+// assets web UI files by path under Prefix; plain HTML, CSS and JavaScript without build step or external
+// resources, so web UI works in air-gapped environments
+var assets = map[string]asset{
+	"/index.html": {"text/html; charset=utf-8", indexHTML},
+	"/style.css":  {"text/css; charset=utf-8", styleCSS},
+	"/app.js":     {"application/javascript; charset=utf-8", appJS},
+}
+
+const indexHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Pumba</title>
+<link rel="stylesheet" href="style.css">
+</head>
+<body>
+<header><h1>Pumba</h1><span id="version"></span><span id="error"></span></header>
+<section>
+<h2>Experiments</h2>
+<table><thead><tr><th>Command</th><th>Interval</th><th>Next tick</th><th>Runs</th><th>Failures</th></tr></thead><tbody id="experiments"></tbody></table>
+</section>
+<section>
+<h2>Active disruptions</h2>
+<table><thead><tr><th>Container</th><th>ID</th><th>Action</th><th>Until</th><th>Remaining</th></tr></thead><tbody id="disrupted"></tbody></table>
+</section>
+<section>
+<h2>Scenarios</h2>
+<div id="scenarios"></div>
+<h3>Triggered runs</h3>
+<table><thead><tr><th>#</th><th>Scenario</th><th>Started</th><th>Result</th></tr></thead><tbody id="runs"></tbody></table>
+</section>
+<section>
+<h2>History (last 24 hours)</h2>
+<table><thead><tr><th>Time</th><th>Action</th><th>Container</th><th>Duration</th><th>Result</th></tr></thead><tbody id="history"></tbody></table>
+</section>
+<script src="app.js"></script>
+</body>
+</html>
+`
+
+const styleCSS = `body { font-family: sans-serif; margin: 0 2em 2em; color: #222; }
+header { display: flex; align-items: baseline; gap: 1em; border-bottom: 1px solid #ccc; }
+header h1 { margin: 0.4em 0; }
+#error { color: #b00; }
+h2 { margin-top: 1.5em; font-size: 1.2em; }
+table { border-collapse: collapse; width: 100%; font-size: 0.9em; }
+th, td { text-align: left; padding: 0.3em 0.6em; border-bottom: 1px solid #eee; }
+td.failure { color: #b00; }
+td.success { color: #070; }
+.scenario { border: 1px solid #ddd; border-radius: 4px; padding: 0.6em; margin-bottom: 0.6em; }
+.scenario p { margin: 0.3em 0; color: #555; }
+.scenario label { display: inline-block; margin-right: 1em; font-size: 0.9em; }
+.scenario input { width: 10em; }
+`
+
+const appJS = `(function () {
+  "use strict";
+
+  function text(value) {
+    return document.createTextNode(value === undefined || value === null ? "" : String(value));
+  }
+
+  function row(cells, classes) {
+    var tr = document.createElement("tr");
+    cells.forEach(function (value, i) {
+      var td = document.createElement("td");
+      if (classes && classes[i]) {
+        td.className = classes[i];
+      }
+      td.appendChild(text(value));
+      tr.appendChild(td);
+    });
+    return tr;
+  }
+
+  function fill(id, rows) {
+    var body = document.getElementById(id);
+    while (body.firstChild) {
+      body.removeChild(body.firstChild);
+    }
+    rows.forEach(function (r) { body.appendChild(r); });
+  }
+
+  function request(method, url, body, done) {
+    var xhr = new XMLHttpRequest();
+    xhr.open(method, url);
+    xhr.setRequestHeader("X-Pumba-UI", "1");
+    if (body) {
+      xhr.setRequestHeader("Content-Type", "application/json");
+    }
+    xhr.onload = function () {
+      if (xhr.status >= 200 && xhr.status < 300) {
+        document.getElementById("error").textContent = "";
+        done(JSON.parse(xhr.responseText));
+      } else {
+        document.getElementById("error").textContent = method + " " + url + ": " + xhr.responseText;
+      }
+    };
+    xhr.onerror = function () {
+      document.getElementById("error").textContent = "Pumba API is not available";
+    };
+    xhr.send(body ? JSON.stringify(body) : null);
+  }
+
+  function result(error) {
+    return error ? ["failure: " + error, "failure"] : ["success", "success"];
+  }
+
+  function refreshStatus() {
+    request("GET", "../status", null, function (st) {
+      document.getElementById("version").textContent = "version " + st.version + ", PID " + st.pid + ", started " + st.started;
+      fill("experiments", st.experiments.map(function (e) {
+        return row([e.command, e.interval || "once", e.next || "-", e.runs, e.failures]);
+      }));
+      fill("disrupted", st.disrupted.map(function (d) {
+        return row([d.container_name, d.container_id, d.action, d.until, d.remaining_seconds + "s"]);
+      }));
+    });
+  }
+
+  function refreshRuns() {
+    request("GET", "api/runs", null, function (runs) {
+      fill("runs", runs.map(function (r) {
+        var res = r.finished ? result(r.error) : ["running", ""];
+        return row([r.id, r.scenario, r.started, res[0]], [null, null, null, res[1]]);
+      }));
+    });
+  }
+
+  function refreshHistory() {
+    request("GET", "api/history", null, function (actions) {
+      fill("history", actions.map(function (a) {
+        var res = result(a.error);
+        return row([a.time, a.action, a.container_name, (a.duration / 1e6).toFixed(0) + "ms", res[0]], [null, null, null, null, res[1]]);
+      }));
+    });
+  }
+
+  function scenarioForm(s) {
+    var div = document.createElement("div");
+    div.className = "scenario";
+    var title = document.createElement("strong");
+    title.appendChild(text(s.name + " (" + s.id + ")"));
+    div.appendChild(title);
+    var description = document.createElement("p");
+    description.appendChild(text(s.description));
+    div.appendChild(description);
+    var inputs = {};
+    s.params.forEach(function (p) {
+      var label = document.createElement("label");
+      label.title = p.description || "";
+      label.appendChild(text(p.name + (p.required ? "* " : " ")));
+      var input = document.createElement("input");
+      input.placeholder = p.default || "";
+      label.appendChild(input);
+      div.appendChild(label);
+      inputs[p.name] = input;
+    });
+    var button = document.createElement("button");
+    button.appendChild(text("Run"));
+    button.onclick = function () {
+      var params = {};
+      Object.keys(inputs).forEach(function (name) {
+        if (inputs[name].value !== "") {
+          params[name] = inputs[name].value;
+        }
+      });
+      if (!window.confirm("Run scenario " + s.name + "?")) {
+        return;
+      }
+      request("POST", "api/runs", {scenario: s.id, params: params}, refreshRuns);
+    };
+    div.appendChild(button);
+    return div;
+  }
+
+  function loadScenarios() {
+    request("GET", "api/scenarios", null, function (list) {
+      var container = document.getElementById("scenarios");
+      list.forEach(function (s) { container.appendChild(scenarioForm(s)); });
+    });
+  }
+
+  function refresh() {
+    refreshStatus();
+    refreshRuns();
+    refreshHistory();
+  }
+
+  loadScenarios();
+  refresh();
+  window.setInterval(refresh, 5000);
+}());
+`
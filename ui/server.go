@@ -0,0 +1,269 @@
+// Package ui serves Pumba web UI: experiments and active disruptions (from Pumba API status), saved
+// scenarios, that can be triggered, and recent experiment history
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gaia-adm/pumba/history"
+	"github.com/gaia-adm/pumba/scenario"
+)
+
+// Prefix URL path of web UI
+const Prefix = "/ui/"
+
+// RecentRuns number of recent scenario runs, triggered from web UI, kept in memory
+const RecentRuns = 20
+
+// HistoryPeriod period of experiment history shown in web UI
+const HistoryPeriod = 24 * time.Hour
+
+// triggerHeader request header, required to trigger scenario; browsers do not send custom headers
+// cross-origin without CORS preflight, so other sites can not trigger chaos through user's browser
+const triggerHeader = scenario.GateHeader
+
+// Param scenario parameter
+type Param struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// Scenario saved scenario: built-in recipe ('recipe/name') or scenario file of scenario directory ('file/name.yml')
+type Scenario struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description,omitempty"`
+	Params      []Param `json:"params"`
+}
+
+// Run scenario run, triggered from web UI
+type Run struct {
+	ID       int               `json:"id"`
+	Scenario string            `json:"scenario"`
+	Params   map[string]string `json:"params,omitempty"`
+	Started  time.Time         `json:"started"`
+	Finished *time.Time        `json:"finished,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// Server serves web UI assets and UI API: saved scenarios, scenario runs and history
+type Server struct {
+	dir     string
+	history *history.Store
+	run     func(*scenario.Scenario) error
+	now     func() time.Time
+	mu      sync.Mutex
+	runs    []*Run
+	nextID  int
+	wg      sync.WaitGroup
+}
+
+// NewServer creates web UI server; dir - directory of saved scenario files (optional), hist - experiment
+// history (optional), run - runs triggered scenario
+func NewServer(dir string, hist *history.Store, run func(*scenario.Scenario) error) *Server {
+	return &Server{dir: dir, history: hist, run: run, now: time.Now}
+}
+
+// Scenarios returns saved scenarios: built-in recipes and scenario files (*.yml, *.yaml) of scenario directory
+func (srv *Server) Scenarios() ([]Scenario, error) {
+	list := []Scenario{}
+	for _, name := range scenario.Recipes() {
+		s, err := scenario.RecipeInfo(name)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, info("recipe/"+name, s))
+	}
+	if srv.dir == "" {
+		return list, nil
+	}
+	files, err := ioutil.ReadDir(srv.dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if f.IsDir() || !scenarioFile(f.Name()) {
+			continue
+		}
+		s, err := scenario.Info(filepath.Join(srv.dir, f.Name()))
+		if err != nil {
+			// broken scenario file should not hide others
+			list = append(list, Scenario{ID: "file/" + f.Name(), Name: f.Name(), Description: err.Error(), Params: []Param{}})
+			continue
+		}
+		list = append(list, info("file/"+f.Name(), s))
+	}
+	return list, nil
+}
+
+// info converts scenario declaration to saved scenario
+func info(id string, s *scenario.Scenario) Scenario {
+	x := Scenario{ID: id, Name: s.Name, Description: s.Description, Params: []Param{}}
+	if x.Name == "" {
+		x.Name = id[strings.Index(id, "/")+1:]
+	}
+	for _, p := range s.Params {
+		x.Params = append(x.Params, Param{Name: p.Name, Description: p.Description, Default: p.Default, Required: p.Required})
+	}
+	return x
+}
+
+// scenarioFile checks that name is a plain YAML file name (no path)
+func scenarioFile(name string) bool {
+	ext := filepath.Ext(name)
+	return filepath.Base(name) == name && !strings.HasPrefix(name, ".") && (ext == ".yml" || ext == ".yaml")
+}
+
+// load renders saved scenario with parameters
+func (srv *Server) load(id string, params map[string]string) (*scenario.Scenario, error) {
+	switch {
+	case strings.HasPrefix(id, "recipe/"):
+		return scenario.Recipe(strings.TrimPrefix(id, "recipe/"), params)
+	case strings.HasPrefix(id, "file/") && srv.dir != "" && scenarioFile(strings.TrimPrefix(id, "file/")):
+		return scenario.Load(filepath.Join(srv.dir, strings.TrimPrefix(id, "file/")), params)
+	}
+	return nil, fmt.Errorf("Unknown scenario: '%s'", id)
+}
+
+// Trigger renders saved scenario and runs it in background
+func (srv *Server) Trigger(id string, params map[string]string) (*Run, error) {
+	s, err := srv.load(id, params)
+	if err != nil {
+		return nil, err
+	}
+	srv.mu.Lock()
+	srv.nextID++
+	r := &Run{ID: srv.nextID, Scenario: id, Params: params, Started: srv.now()}
+	srv.runs = append(srv.runs, r)
+	if len(srv.runs) > RecentRuns {
+		srv.runs = srv.runs[len(srv.runs)-RecentRuns:]
+	}
+	result := *r
+	srv.mu.Unlock()
+	srv.wg.Add(1)
+	go func() {
+		defer srv.wg.Done()
+		err := srv.run(s)
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		finished := srv.now()
+		r.Finished = &finished
+		if err != nil {
+			r.Error = err.Error()
+		}
+	}()
+	return &result, nil
+}
+
+// Wait waits for running scenarios
+func (srv *Server) Wait() {
+	srv.wg.Wait()
+}
+
+// Runs returns recent scenario runs, newest first
+func (srv *Server) Runs() []Run {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	runs := make([]Run, 0, len(srv.runs))
+	for i := len(srv.runs) - 1; i >= 0; i-- {
+		runs = append(runs, *srv.runs[i])
+	}
+	return runs
+}
+
+// History returns chaos actions of last HistoryPeriod, newest first; empty without history database
+func (srv *Server) History() ([]history.Action, error) {
+	if srv.history == nil {
+		return []history.Action{}, nil
+	}
+	actions, err := srv.history.Actions(history.Filter{Since: srv.now().Add(-HistoryPeriod)})
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(sort.Reverse(byTime(actions)))
+	return actions, nil
+}
+
+type byTime []history.Action
+
+func (a byTime) Len() int           { return len(a) }
+func (a byTime) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a byTime) Less(i, j int) bool { return a[i].Time.Before(a[j].Time) }
+
+// ServeHTTP serves web UI assets and UI API under Prefix
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, strings.TrimSuffix(Prefix, "/"))
+	if strings.HasPrefix(path, "/api/") {
+		srv.serveAPI(w, r, strings.TrimPrefix(path, "/api/"))
+		return
+	}
+	if r.Method != "GET" {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if path == "" || path == "/" {
+		path = "/index.html"
+	}
+	a, ok := assets[path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", a.contentType)
+	w.Write([]byte(a.content))
+}
+
+// triggerRequest request to trigger scenario
+type triggerRequest struct {
+	Scenario string            `json:"scenario"`
+	Params   map[string]string `json:"params"`
+}
+
+func (srv *Server) serveAPI(w http.ResponseWriter, r *http.Request, endpoint string) {
+	var result interface{}
+	var err error
+	switch {
+	case endpoint == "runs" && r.Method == "POST":
+		if r.Header.Get(triggerHeader) == "" {
+			http.Error(w, "Missing "+triggerHeader+" header", http.StatusForbidden)
+			return
+		}
+		var req triggerRequest
+		if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if result, err = srv.Trigger(req.Scenario, req.Params); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	case r.Method != "GET":
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	case endpoint == "scenarios":
+		result, err = srv.Scenarios()
+	case endpoint == "runs":
+		result = srv.Runs()
+	case endpoint == "history":
+		result, err = srv.History()
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,157 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gaia-adm/pumba/scenario"
+	"github.com/stretchr/testify/assert"
+)
+
+const testScenario = `
+name: api-restart
+description: kill API containers
+params:
+  - name: signal
+    default: SIGTERM
+steps:
+  - action: kill
+    targets: ["re2:^api"]
+    params:
+      signal: "{{.signal}}"
+`
+
+// recorder records scenarios run by web UI
+type recorder struct {
+	sync.Mutex
+	scenarios []*scenario.Scenario
+	err       error
+}
+
+func (r *recorder) run(s *scenario.Scenario) error {
+	r.Lock()
+	defer r.Unlock()
+	r.scenarios = append(r.scenarios, s)
+	return r.err
+}
+
+func newTestServer(t *testing.T) (*Server, *recorder, string) {
+	dir, err := ioutil.TempDir("", "pumba-ui")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.WriteFile(filepath.Join(dir, "api.yml"), []byte(testScenario), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a scenario"), 0600)
+	rec := &recorder{}
+	srv := NewServer(dir, nil, rec.run)
+	now := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	srv.now = func() time.Time { return now }
+	return srv, rec, dir
+}
+
+func TestServer_Scenarios(t *testing.T) {
+	srv, _, dir := newTestServer(t)
+	defer os.RemoveAll(dir)
+
+	list, err := srv.Scenarios()
+	assert.NoError(t, err)
+	assert.Equal(t, len(scenario.Recipes())+1, len(list))
+	assert.Equal(t, "recipe/"+scenario.Recipes()[0], list[0].ID)
+	assert.Equal(t, Scenario{ID: "file/api.yml", Name: "api-restart", Description: "kill API containers", Params: []Param{{Name: "signal", Default: "SIGTERM"}}}, list[len(list)-1])
+}
+
+func TestServer_Trigger(t *testing.T) {
+	srv, rec, dir := newTestServer(t)
+	defer os.RemoveAll(dir)
+
+	r, err := srv.Trigger("file/api.yml", map[string]string{"signal": "SIGKILL"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, r.ID)
+	rec.Lock()
+	rec.err = errors.New("kill failed")
+	rec.Unlock()
+	_, err = srv.Trigger("file/api.yml", nil)
+	assert.NoError(t, err)
+	srv.Wait()
+	assert.Equal(t, 2, len(rec.scenarios))
+	signals := []string{rec.scenarios[0].Steps[0].Params["signal"], rec.scenarios[1].Steps[0].Params["signal"]}
+	assert.Contains(t, signals, "SIGKILL")
+	assert.Contains(t, signals, "SIGTERM")
+
+	runs := srv.Runs()
+	assert.Equal(t, 2, len(runs))
+	assert.Equal(t, 2, runs[0].ID)
+	assert.NotNil(t, runs[0].Finished)
+	assert.Equal(t, "kill failed", runs[0].Error)
+
+	for _, id := range []string{"file/../api.yml", "file/notes.txt", "recipe/unknown", "api.yml"} {
+		_, err = srv.Trigger(id, nil)
+		assert.Error(t, err, id)
+	}
+}
+
+func TestServer_ServeHTTP(t *testing.T) {
+	srv, rec, dir := newTestServer(t)
+	defer os.RemoveAll(dir)
+	mux := http.NewServeMux()
+	mux.Handle(Prefix, srv)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/ui/")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	resp, err = http.Get(ts.URL + "/ui/app.js")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp, err = http.Get(ts.URL + "/ui/missing.js")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	var list []Scenario
+	resp, err = http.Get(ts.URL + "/ui/api/scenarios")
+	assert.NoError(t, err)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&list))
+	assert.Equal(t, "file/api.yml", list[len(list)-1].ID)
+
+	var actions []interface{}
+	resp, err = http.Get(ts.URL + "/ui/api/history")
+	assert.NoError(t, err)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&actions))
+	assert.Equal(t, []interface{}{}, actions)
+
+	// trigger requires UI header (no cross-site triggering)
+	body := `{"scenario":"file/api.yml"}`
+	resp, err = http.Post(ts.URL+"/ui/api/runs", "application/json", strings.NewReader(body))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	req, _ := http.NewRequest("POST", ts.URL+"/ui/api/runs", strings.NewReader(body))
+	req.Header.Set(triggerHeader, "1")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	req, _ = http.NewRequest("POST", ts.URL+"/ui/api/runs", strings.NewReader(`{"scenario":"recipe/unknown"}`))
+	req.Header.Set(triggerHeader, "1")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	srv.Wait()
+	assert.Equal(t, 1, len(rec.scenarios))
+
+	var runs []Run
+	resp, err = http.Get(ts.URL + "/ui/api/runs")
+	assert.NoError(t, err)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&runs))
+	assert.Equal(t, 1, len(runs))
+	assert.Equal(t, "file/api.yml", runs[0].Scenario)
+}
@@ -0,0 +1,148 @@
+package validate
+
+import (
+	"math/rand"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+// fuzzInput random string, biased to characters, that matter for parsers and shell commands
+type fuzzInput string
+
+const fuzzAlphabet = "aZ09_.-/:;|&$`'\"\\ ()[]{}*+?^,%@!#~<>=\n\t\x00é"
+
+// Generate implements quick.Generator
+func (fuzzInput) Generate(r *rand.Rand, size int) reflect.Value {
+	n := r.Intn(size + 1)
+	b := make([]rune, n)
+	alphabet := []rune(fuzzAlphabet)
+	for i := range b {
+		b[i] = alphabet[r.Intn(len(alphabet))]
+	}
+	return reflect.ValueOf(fuzzInput(string(b)))
+}
+
+var fuzzConfig = &quick.Config{MaxCount: 5000}
+
+// shellSafe returns true, if value has no shell metacharacters and whitespace
+func shellSafe(value string) bool {
+	return !strings.ContainsAny(value, ";|&$`'\"\\ ()[]{}*?<>!#~=\n\t\x00/:%@,^+")
+}
+
+func TestFuzz_Interface(t *testing.T) {
+	property := func(in fuzzInput) bool {
+		name := string(in)
+		if Interface(name) != nil {
+			return true
+		}
+		return len(name) > 0 && len(name) <= 15 && shellSafe(name) && !strings.HasPrefix(name, "-")
+	}
+	if err := quick.Check(property, fuzzConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFuzz_HostnameAndUser(t *testing.T) {
+	property := func(in fuzzInput) bool {
+		value := string(in)
+		if Hostname(value) == nil && (!shellSafe(value) || strings.HasPrefix(value, "-")) {
+			return false
+		}
+		if User(value) == nil && (!shellSafe(value) || strings.HasPrefix(value, "-")) {
+			return false
+		}
+		return true
+	}
+	if err := quick.Check(property, fuzzConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFuzz_Pattern(t *testing.T) {
+	// never panics; accepted patterns are compiled and can be matched
+	property := func(in fuzzInput, name string) bool {
+		re, err := Pattern(string(in))
+		if err != nil {
+			return re == nil
+		}
+		re.MatchString(name)
+		return true
+	}
+	if err := quick.Check(property, fuzzConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFuzz_IP(t *testing.T) {
+	// accepted addresses are single IPs, printed back without shell metacharacters
+	property := func(in fuzzInput) bool {
+		ip, err := IP(string(in))
+		if err != nil {
+			return ip == nil
+		}
+		return net.ParseIP(ip.String()) != nil && !strings.ContainsAny(ip.String(), " ;|&$/")
+	}
+	if err := quick.Check(property, fuzzConfig); err != nil {
+		t.Error(err)
+	}
+	// random IPv4 and IPv6 addresses are accepted
+	roundTrip := func(b [16]byte, v4 bool) bool {
+		ip := net.IP(b[:])
+		if v4 {
+			ip = net.IPv4(b[0], b[1], b[2], b[3])
+		}
+		parsed, err := IP(ip.String())
+		return err == nil && parsed.Equal(ip)
+	}
+	if err := quick.Check(roundTrip, fuzzConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFuzz_Duration(t *testing.T) {
+	// accepted durations are not negative; printed durations are parsed back
+	property := func(in fuzzInput) bool {
+		d, err := Duration(string(in))
+		return err != nil || d >= 0
+	}
+	if err := quick.Check(property, fuzzConfig); err != nil {
+		t.Error(err)
+	}
+	roundTrip := func(n int64) bool {
+		d := time.Duration(n)
+		if d < 0 {
+			d = -d
+		}
+		if d < 0 {
+			return true
+		}
+		parsed, err := Duration(d.String())
+		return err == nil && parsed == d
+	}
+	if err := quick.Check(roundTrip, fuzzConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFuzz_Range(t *testing.T) {
+	property := func(value int) bool {
+		return (Percent("percent", value) == nil) == (value >= 0 && value <= 100)
+	}
+	if err := quick.Check(property, fuzzConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFuzz_Signal(t *testing.T) {
+	property := func(in fuzzInput) bool {
+		_, known := Signals[string(in)]
+		return (Signal(string(in)) == nil) == known
+	}
+	if err := quick.Check(property, fuzzConfig); err != nil {
+		t.Error(err)
+	}
+}
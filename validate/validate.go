@@ -0,0 +1,316 @@
+// Package validate checks user-supplied inputs: command-line options, scenario step parameters and recipe
+// arguments. Valid values are safe to pass to Docker API and to commands (tc, iptables), run in target containers.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxPatternLength maximum length of RE2 pattern of container names
+const MaxPatternLength = 1024
+
+// maxCPUs upper bound of number of CPUs
+const maxCPUs = 1 << 20
+
+// maxHostnameLength maximum length of DNS name
+const maxHostnameLength = 253
+
+var (
+	// reInterface Linux network interface name: up to 15 (IFNAMSIZ-1) characters, without shell metacharacters
+	reInterface = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,14}$`)
+	// reHostname host name (DNS name or Docker network alias)
+	reHostname = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9_.-]*[a-zA-Z0-9])?$`)
+	// reNamePrefix prefix of Docker container names
+	reNamePrefix = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+	// reUser user ID or name
+	reUser = regexp.MustCompile(`^([0-9]+|[a-z_][a-z0-9_-]*)$`)
+	// reBinaryPath absolute path of binary in container, without shell metacharacters
+	reBinaryPath = regexp.MustCompile(`^(/[a-zA-Z0-9_.+-]+)+$`)
+	// reEnvName environment variable name
+	reEnvName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	// reMemorySize memory size with optional binary unit suffix, like in 'docker run --memory', e.g. '512m' or '1.5g'
+	reMemorySize = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?)([bkmgt]?)b?$`)
+)
+
+// memoryUnits multipliers of memory size unit suffixes
+var memoryUnits = map[string]float64{"": 1, "b": 1, "k": 1 << 10, "m": 1 << 20, "g": 1 << 30, "t": 1 << 40}
+
+// Signals valid Linux signal table
+// http://www.comptechdoc.org/os/linux/programming/linux_pgsignals.html
+// Signals are sent to Linux containers by Docker daemon, so the table does not depend on Pumba OS/architecture
+// (signal numbers are the same on x86, ARM and ARM64 Linux)
+var Signals = map[string]int{
+	"SIGHUP":    1,
+	"SIGINT":    2,
+	"SIGQUIT":   3,
+	"SIGILL":    4,
+	"SIGTRAP":   5,
+	"SIGIOT":    6,
+	"SIGBUS":    7,
+	"SIGFPE":    8,
+	"SIGKILL":   9,
+	"SIGUSR1":   10,
+	"SIGSEGV":   11,
+	"SIGUSR2":   12,
+	"SIGPIPE":   13,
+	"SIGALRM":   14,
+	"SIGTERM":   15,
+	"SIGSTKFLT": 16,
+	"SIGCHLD":   17,
+	"SIGCONT":   18,
+	"SIGSTOP":   19,
+	"SIGTSTP":   20,
+	"SIGTTIN":   21,
+	"SIGTTOU":   22,
+	"SIGURG":    23,
+	"SIGXCPU":   24,
+	"SIGXFSZ":   25,
+	"SIGVTALRM": 26,
+	"SIGPROF":   27,
+	"SIGWINCH":  28,
+	"SIGIO":     29,
+	"SIGPWR":    30,
+}
+
+// Duration parses duration with unit suffix ('ms/s/m/h'); empty and negative durations are invalid
+func Duration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, errors.New("Undefined duration interval")
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("Invalid duration '%s': must not be negative", value)
+	}
+	return d, nil
+}
+
+// MemorySize parses positive memory size in bytes with optional unit suffix ('b/k/m/g/t', case-insensitive, binary
+// units), e.g. '512m' or '1.5g'
+func MemorySize(value string) (int64, error) {
+	m := reMemorySize.FindStringSubmatch(strings.ToLower(value))
+	if m == nil {
+		return 0, fmt.Errorf("Invalid memory size '%s': must be number with optional unit suffix, e.g. '512m' or '1g'", value)
+	}
+	n, _ := strconv.ParseFloat(m[1], 64)
+	size := n * memoryUnits[m[3]]
+	if size < 1 {
+		return 0, fmt.Errorf("Invalid memory size '%s': must be positive", value)
+	}
+	if size > math.MaxInt64/2 {
+		return 0, fmt.Errorf("Invalid memory size '%s': too big", value)
+	}
+	return int64(size), nil
+}
+
+// CPUs parses positive number of CPUs, e.g. '0.5' or '2'
+func CPUs(value string) (float64, error) {
+	n, err := strconv.ParseFloat(value, 64)
+	// NaN fails both comparisons
+	if err != nil || !(n > 0 && n <= maxCPUs) {
+		return 0, fmt.Errorf("Invalid number of CPUs '%s': must be positive number, e.g. '0.5' or '2'", value)
+	}
+	return n, nil
+}
+
+// Range checks that named integer value is between min and max, inclusive
+func Range(name string, value, min, max int) error {
+	if value < min || value > max {
+		return fmt.Errorf("Invalid %s %d: must be between %d and %d", name, value, min, max)
+	}
+	return nil
+}
+
+// Percent checks that named percent value is between 0 and 100
+func Percent(name string, value int) error {
+	return Range(name, value, 0, 100)
+}
+
+// Pattern compiles RE2 pattern of container names
+func Pattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, errors.New("Empty RE2 pattern")
+	}
+	if len(pattern) > MaxPatternLength {
+		return nil, fmt.Errorf("Invalid RE2 pattern: longer than %d characters", MaxPatternLength)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid RE2 pattern '%s': %s", pattern, err)
+	}
+	return re, nil
+}
+
+// IP parses IPv4 or IPv6 address; single host CIDR notation (/32 or /128) is accepted, CIDR ranges are not
+func IP(value string) (net.IP, error) {
+	if strings.Contains(value, "/") {
+		ip, ipnet, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid IP address '%s'", value)
+		}
+		if ones, bits := ipnet.Mask.Size(); ones != bits {
+			return nil, fmt.Errorf("Invalid IP address '%s': CIDR ranges are not supported, use single IP address", value)
+		}
+		return ip, nil
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return nil, fmt.Errorf("Invalid IP address '%s'", value)
+	}
+	return ip, nil
+}
+
+// Targets parses comma-separated list of target IPv4 or IPv6 addresses, CIDR ranges and host names (e.g.
+// '10.0.0.1,10.1.0.0/16,db.service.local'); single IP address is returned as host range (/32 or /128)
+func Targets(value string) ([]*net.IPNet, []string, error) {
+	var targets []*net.IPNet
+	var hosts []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			return nil, nil, fmt.Errorf("Invalid target list '%s': empty target", value)
+		}
+		if strings.Contains(item, "/") {
+			_, ipnet, err := net.ParseCIDR(item)
+			if err != nil {
+				return nil, nil, fmt.Errorf("Invalid CIDR range '%s'", item)
+			}
+			targets = append(targets, ipnet)
+			continue
+		}
+		// anything, that does not look like IP address, is host name
+		if !strings.Contains(item, ":") && strings.Trim(item, "0123456789.") != "" {
+			if err := Hostname(item); err != nil {
+				return nil, nil, err
+			}
+			hosts = append(hosts, item)
+			continue
+		}
+		ip, err := IP(item)
+		if err != nil {
+			return nil, nil, err
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		targets = append(targets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return targets, hosts, nil
+}
+
+// Excludes parses comma-separated list of excluded IPv4 or IPv6 addresses and CIDR ranges (e.g.
+// '127.0.0.11,10.96.0.0/12'); host names are not supported
+func Excludes(value string) ([]*net.IPNet, error) {
+	excludes, hosts, err := Targets(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(hosts) > 0 {
+		return nil, fmt.Errorf("Invalid exclude list '%s': host name '%s' is not supported, use IP address or CIDR range", value, hosts[0])
+	}
+	return excludes, nil
+}
+
+// NamePrefix checks prefix of container names: Docker container name characters, without leading slash
+func NamePrefix(prefix string) error {
+	if !reNamePrefix.MatchString(prefix) {
+		return fmt.Errorf("Invalid container name prefix '%s': must start with a letter or digit, followed by letters, digits, '_', '.' or '-'", prefix)
+	}
+	return nil
+}
+
+// Interface checks Linux network interface name
+func Interface(name string) error {
+	if !reInterface.MatchString(name) {
+		return fmt.Errorf("Invalid network interface name '%s': must be up to 15 letters, digits, '_', '.' or '-'", name)
+	}
+	return nil
+}
+
+// Hostname checks host name: DNS name or Docker network alias
+func Hostname(name string) error {
+	if len(name) > maxHostnameLength || !reHostname.MatchString(name) {
+		return fmt.Errorf("Invalid host name '%s'", name)
+	}
+	return nil
+}
+
+// Probe checks latency probe target: host name, IP address or HTTP(S) URL
+func Probe(target string) error {
+	host := target
+	if strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://") {
+		u, err := url.Parse(target)
+		if err != nil || u.Host == "" || strings.ContainsAny(target, " \t\n") {
+			return fmt.Errorf("Invalid probe URL '%s'", target)
+		}
+		host = u.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.Trim(host, "[]")
+	}
+	if net.ParseIP(host) != nil || Hostname(host) == nil {
+		return nil
+	}
+	return fmt.Errorf("Invalid probe target '%s': must be a host name, IP address or HTTP(S) URL", target)
+}
+
+// User checks user ID or name
+func User(name string) error {
+	if !reUser.MatchString(name) {
+		return fmt.Errorf("Invalid user '%s': must be a user ID or name", name)
+	}
+	return nil
+}
+
+// ExecUser checks user of commands, executed in containers: user ID or name, optionally with group ('user[:group]')
+func ExecUser(value string) error {
+	parts := strings.Split(value, ":")
+	if len(parts) > 2 || User(parts[0]) != nil || (len(parts) == 2 && User(parts[1]) != nil) {
+		return fmt.Errorf("Invalid exec user '%s': must be 'user[:group]' (name or ID)", value)
+	}
+	return nil
+}
+
+// WorkingDir checks working directory of commands, executed in containers: absolute path
+func WorkingDir(dir string) error {
+	if !strings.HasPrefix(dir, "/") || strings.ContainsAny(dir, "\x00\n") {
+		return fmt.Errorf("Invalid working directory '%s': must be an absolute path", dir)
+	}
+	return nil
+}
+
+// BinaryPath checks path of binary in container: absolute path of letters, digits, '_', '.', '+' or '-'
+func BinaryPath(path string) error {
+	if !reBinaryPath.MatchString(path) || strings.Contains(path+"/", "/./") || strings.Contains(path+"/", "/../") {
+		return fmt.Errorf("Invalid binary path '%s': must be an absolute path of letters, digits, '_', '.', '+' or '-'", path)
+	}
+	return nil
+}
+
+// EnvName checks environment variable name: letters, digits and '_', not starting with digit
+func EnvName(name string) error {
+	if !reEnvName.MatchString(name) {
+		return fmt.Errorf("Invalid environment variable name '%s': must be letters, digits or '_', not starting with digit", name)
+	}
+	return nil
+}
+
+// Signal checks Linux signal name, like SIGTERM
+func Signal(name string) error {
+	if _, ok := Signals[name]; !ok {
+		return errors.New("Unexpected signal: " + name)
+	}
+	return nil
+}
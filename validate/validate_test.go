@@ -0,0 +1,191 @@
+package validate
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuration(t *testing.T) {
+	d, err := Duration("1m30s")
+	assert.NoError(t, err)
+	assert.Equal(t, 90*time.Second, d)
+	_, err = Duration("")
+	assert.EqualError(t, err, "Undefined duration interval")
+	_, err = Duration("-1s")
+	assert.EqualError(t, err, "Invalid duration '-1s': must not be negative")
+	_, err = Duration("10")
+	assert.Error(t, err)
+}
+
+func TestMemorySize(t *testing.T) {
+	for value, expected := range map[string]int64{"1024": 1024, "512m": 512 << 20, "512MB": 512 << 20, "1.5g": 3 << 29, "64k": 64 << 10} {
+		size, err := MemorySize(value)
+		assert.NoError(t, err, value)
+		assert.Equal(t, expected, size, value)
+	}
+	for value, expected := range map[string]string{
+		"":         "Invalid memory size '': must be number with optional unit suffix, e.g. '512m' or '1g'",
+		"-1g":      "Invalid memory size '-1g': must be number with optional unit suffix, e.g. '512m' or '1g'",
+		"512x":     "Invalid memory size '512x': must be number with optional unit suffix, e.g. '512m' or '1g'",
+		"0m":       "Invalid memory size '0m': must be positive",
+		"9e9t":     "Invalid memory size '9e9t': must be number with optional unit suffix, e.g. '512m' or '1g'",
+		"9000000t": "Invalid memory size '9000000t': too big",
+	} {
+		_, err := MemorySize(value)
+		assert.EqualError(t, err, expected, value)
+	}
+}
+
+func TestCPUs(t *testing.T) {
+	n, err := CPUs("0.5")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, n)
+	for _, value := range []string{"", "0", "-1", "two", "NaN", "Inf", "2e9"} {
+		_, err = CPUs(value)
+		assert.EqualError(t, err, "Invalid number of CPUs '"+value+"': must be positive number, e.g. '0.5' or '2'", value)
+	}
+}
+
+func TestRange(t *testing.T) {
+	assert.NoError(t, Range("port", 0, 0, 65535))
+	assert.NoError(t, Percent("reorder percent", 100))
+	assert.EqualError(t, Percent("reorder percent", 101), "Invalid reorder percent 101: must be between 0 and 100")
+	assert.EqualError(t, Range("packet loss", 0, 1, 100), "Invalid packet loss 0: must be between 1 and 100")
+}
+
+func TestPattern(t *testing.T) {
+	re, err := Pattern("^api")
+	assert.NoError(t, err)
+	assert.True(t, re.MatchString("api_1"))
+	_, err = Pattern("")
+	assert.EqualError(t, err, "Empty RE2 pattern")
+	_, err = Pattern("(api")
+	assert.EqualError(t, err, "Invalid RE2 pattern '(api': error parsing regexp: missing closing ): `(api`")
+	_, err = Pattern(strings.Repeat("a", MaxPatternLength+1))
+	assert.EqualError(t, err, "Invalid RE2 pattern: longer than 1024 characters")
+}
+
+func TestIP(t *testing.T) {
+	for _, value := range []string{"10.0.0.1", "10.0.0.1/32", "fd00::1", "fd00::1/128"} {
+		ip, err := IP(value)
+		assert.NoError(t, err, value)
+		assert.NotNil(t, ip, value)
+	}
+	_, err := IP("10.0.0.0/24")
+	assert.EqualError(t, err, "Invalid IP address '10.0.0.0/24': CIDR ranges are not supported, use single IP address")
+	for _, value := range []string{"", "10.0.0", "host", "10.0.0.1/33", "10.0.0.1; reboot"} {
+		_, err = IP(value)
+		assert.EqualError(t, err, "Invalid IP address '"+value+"'")
+	}
+}
+
+func TestTargets(t *testing.T) {
+	targets, hosts, err := Targets("10.0.0.1, 10.1.0.5/16,fd00::1,db.service.local,fd00:1::/64")
+	if assert.NoError(t, err) && assert.Len(t, targets, 4) {
+		assert.Equal(t, "10.0.0.1/32", targets[0].String())
+		assert.Equal(t, "10.1.0.0/16", targets[1].String())
+		assert.Equal(t, "fd00::1/128", targets[2].String())
+		assert.Equal(t, "fd00:1::/64", targets[3].String())
+	}
+	assert.Equal(t, []string{"db.service.local"}, hosts)
+	for value, msg := range map[string]string{
+		"10.0.0":            "Invalid IP address '10.0.0'",
+		"fd00::zz":          "Invalid IP address 'fd00::zz'",
+		"10.0.0.1,":         "Invalid target list '10.0.0.1,': empty target",
+		"10.0.0.0/33":       "Invalid CIDR range '10.0.0.0/33'",
+		"10.0.0.0/8;reboot": "Invalid CIDR range '10.0.0.0/8;reboot'",
+		"db;reboot":         "Invalid host name 'db;reboot'",
+	} {
+		_, _, err = Targets(value)
+		assert.EqualError(t, err, msg, value)
+	}
+}
+
+func TestExcludes(t *testing.T) {
+	excludes, err := Excludes("127.0.0.11,10.96.0.0/12")
+	if assert.NoError(t, err) && assert.Len(t, excludes, 2) {
+		assert.Equal(t, "127.0.0.11/32", excludes[0].String())
+		assert.Equal(t, "10.96.0.0/12", excludes[1].String())
+	}
+	_, err = Excludes("127.0.0.11,kubernetes.default")
+	assert.EqualError(t, err, "Invalid exclude list '127.0.0.11,kubernetes.default': host name 'kubernetes.default' is not supported, use IP address or CIDR range")
+	_, err = Excludes("10.0.0.0/33")
+	assert.EqualError(t, err, "Invalid CIDR range '10.0.0.0/33'")
+}
+
+func TestInterface(t *testing.T) {
+	for _, name := range []string{"eth0", "eth0.100", "br-3f2a9c1b7e4d", "ens192", "ens3", "enp0s8", "veth_1", "all"} {
+		assert.NoError(t, Interface(name), name)
+	}
+	for _, name := range []string{"", "hello test", "eth0;reboot", "$(reboot)", "-eth0", "verylonginterface0", "eth0/1"} {
+		assert.Error(t, Interface(name), name)
+	}
+	assert.EqualError(t, Interface("hello test"), "Invalid network interface name 'hello test': must be up to 15 letters, digits, '_', '.' or '-'")
+}
+
+func TestHostnameAndUser(t *testing.T) {
+	assert.NoError(t, Hostname("db.internal"))
+	assert.EqualError(t, Hostname("db; rm -rf /"), "Invalid host name 'db; rm -rf /'")
+	assert.Error(t, Hostname(strings.Repeat("a", 254)))
+	assert.NoError(t, User("1000"))
+	assert.NoError(t, User("www-data"))
+	assert.EqualError(t, User("root;id"), "Invalid user 'root;id': must be a user ID or name")
+}
+
+func TestProbe(t *testing.T) {
+	assert.NoError(t, Probe("db.internal"))
+	assert.NoError(t, Probe("10.0.0.1"))
+	assert.NoError(t, Probe("fd00::1"))
+	assert.NoError(t, Probe("http://api:8080/health?full=1"))
+	assert.NoError(t, Probe("https://[fd00::1]/"))
+	assert.EqualError(t, Probe("-oProxyCommand=id"), "Invalid probe target '-oProxyCommand=id': must be a host name, IP address or HTTP(S) URL")
+	assert.EqualError(t, Probe("http://"), "Invalid probe URL 'http://'")
+	assert.Error(t, Probe("http://api;id/"))
+	assert.Error(t, Probe("ftp://api/"))
+}
+
+func TestExecUserAndWorkingDir(t *testing.T) {
+	assert.NoError(t, ExecUser("root"))
+	assert.NoError(t, ExecUser("0:0"))
+	assert.NoError(t, ExecUser("www-data:1000"))
+	assert.EqualError(t, ExecUser("root:"), "Invalid exec user 'root:': must be 'user[:group]' (name or ID)")
+	assert.Error(t, ExecUser("a:b:c"))
+	assert.Error(t, ExecUser("root;id"))
+	assert.NoError(t, WorkingDir("/var/lib/app"))
+	assert.EqualError(t, WorkingDir("app"), "Invalid working directory 'app': must be an absolute path")
+	assert.Error(t, WorkingDir(""))
+}
+
+func TestNamePrefix(t *testing.T) {
+	assert.NoError(t, NamePrefix("svc_"))
+	assert.NoError(t, NamePrefix("app.v1-"))
+	assert.EqualError(t, NamePrefix("/svc"), "Invalid container name prefix '/svc': must start with a letter or digit, followed by letters, digits, '_', '.' or '-'")
+	assert.Error(t, NamePrefix("svc*"))
+	assert.Error(t, NamePrefix(""))
+}
+
+func TestBinaryPath(t *testing.T) {
+	assert.NoError(t, BinaryPath("/usr/bin/curl"))
+	assert.NoError(t, BinaryPath("/opt/app/bin/health-check.v2"))
+	assert.EqualError(t, BinaryPath("curl"), "Invalid binary path 'curl': must be an absolute path of letters, digits, '_', '.', '+' or '-'")
+	assert.Error(t, BinaryPath("/usr/bin/"))
+	assert.Error(t, BinaryPath("/usr//bin/curl"))
+	assert.Error(t, BinaryPath("/usr/bin/curl;id"))
+	assert.Error(t, BinaryPath("/usr/bin/.."))
+}
+
+func TestEnvName(t *testing.T) {
+	assert.NoError(t, EnvName("DB_HOST"))
+	assert.NoError(t, EnvName("_debug2"))
+	assert.EqualError(t, EnvName("2FA"), "Invalid environment variable name '2FA': must be letters, digits or '_', not starting with digit")
+	assert.Error(t, EnvName("DB-HOST"))
+	assert.Error(t, EnvName(""))
+}
+
+func TestSignal(t *testing.T) {
+	assert.NoError(t, Signal("SIGTERM"))
+	assert.EqualError(t, Signal("UNKNOWN"), "Unexpected signal: UNKNOWN")
+}